@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yaroslav/nebulagc/sdk"
+	"nebulagc.io/pkg/bundle"
+)
+
+// bundleSizeWarnPercent is the share of bundle.MaxBundleSize above which an
+// upload warns that the bundle is close to the limit.
+const bundleSizeWarnPercent = 80
+
+var (
+	bundleEffectiveAt string
+	bundleSigningKey  string
+	bundleCanaryNodes []string
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Manage config bundles",
+	Long:  `Upload Nebula config bundles. Requires an admin node token in the configuration file.`,
+}
+
+var bundleUploadCmd = &cobra.Command{
+	Use:   "upload <bundle.tar.gz>",
+	Short: "Upload a config bundle",
+	Long: `Upload a tar.gz config bundle and print the version assigned to it,
+with the bundle's file count and compressed and uncompressed sizes.
+
+The bundle must contain config.yml, ca.crt, host.crt and host.key.
+
+With --signing-key the bundle is signed with an Ed25519 private key (base64
+or PEM), so daemons configured with the matching bundle_public_key accept it.
+
+With --canary the bundle is released only to the given nodes until the canary
+is promoted; every other node stays on the previous version.`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"gz", "tgz"}, cobra.ShellCompDirectiveFilterFileExt
+	},
+	RunE: runBundleUpload,
+}
+
+func init() {
+	rootCmd.AddCommand(bundleCmd)
+	bundleCmd.AddCommand(bundleUploadCmd)
+
+	bundleUploadCmd.Flags().StringVar(&bundleEffectiveAt, "effective-at", "",
+		"Activate the bundle at this time (RFC 3339) instead of immediately")
+	bundleUploadCmd.Flags().StringVar(&bundleSigningKey, "signing-key", "",
+		"Sign the bundle with the Ed25519 private key in this file")
+	bundleUploadCmd.Flags().StringSliceVar(&bundleCanaryNodes, "canary", nil,
+		"Release the bundle only to these node IDs as a canary rollout")
+}
+
+func runBundleUpload(cmd *cobra.Command, args []string) error {
+	opts := []sdk.RequestOption{sdk.WithCanaryNodes(bundleCanaryNodes...)}
+	if bundleEffectiveAt != "" {
+		t, err := time.Parse(time.RFC3339, bundleEffectiveAt)
+		if err != nil {
+			return fmt.Errorf("invalid --effective-at: %w", err)
+		}
+		opts = append(opts, sdk.WithEffectiveAt(t))
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	var signature []byte
+	if bundleSigningKey != "" {
+		keyData, err := os.ReadFile(bundleSigningKey)
+		if err != nil {
+			return fmt.Errorf("failed to read signing key: %w", err)
+		}
+		key, err := bundle.ParsePrivateKey(string(keyData))
+		if err != nil {
+			return fmt.Errorf("invalid --signing-key: %w", err)
+		}
+		signature = bundle.Sign(key, data)
+	}
+
+	client, err := newClusterClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), 2*time.Minute)
+	defer cancel()
+
+	result, err := client.UploadSignedBundle(ctx, data, signature, opts...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Uploaded bundle version %d (%d files, %d bytes compressed, %d bytes uncompressed)\n",
+		result.Version, result.FileCount, result.CompressedSize, result.UncompressedSize)
+	if result.Checksum != "" {
+		fmt.Printf("SHA-256: %s\n", result.Checksum)
+	}
+	if len(bundleCanaryNodes) > 0 {
+		fmt.Printf("Released as a canary to %d node(s)\n", len(bundleCanaryNodes))
+	}
+	if percent := result.CompressedSize * 100 / bundle.MaxBundleSize; percent >= bundleSizeWarnPercent {
+		fmt.Fprintf(os.Stderr, "Warning: bundle is at %d%% of the %d byte size limit\n", percent, bundle.MaxBundleSize)
+	}
+	return nil
+}
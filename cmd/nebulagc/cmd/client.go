@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/yaroslav/nebulagc/cmd/nebulagc/daemon"
+	"github.com/yaroslav/nebulagc/sdk"
+)
+
+// loadCluster loads the daemon configuration file and resolves the cluster
+// selected with --cluster. The cluster may be omitted when the file lists
+// exactly one.
+func loadCluster() (*daemon.Daemon, string, error) {
+	d, err := daemon.Initialize(configPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	name := clusterName
+	if name == "" {
+		names := d.ClusterNames()
+		if len(names) != 1 {
+			return nil, "", fmt.Errorf("configuration lists %d clusters, select one with --cluster", len(names))
+		}
+		name = names[0]
+	}
+
+	return d, name, nil
+}
+
+// newClusterClient returns an SDK client for the cluster selected with
+// --cluster, using the credentials from the daemon configuration file.
+func newClusterClient() (*sdk.Client, error) {
+	d, name, err := loadCluster()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.GetClient(name)
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yaroslav/nebulagc/cmd/nebulagc/daemon"
+)
+
+// completionTimeout bounds control plane queries made while completing, so an
+// unreachable control plane delays the shell briefly and yields no suggestions.
+const completionTimeout = 2 * time.Second
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish]",
+	Short: "Generate shell completion scripts",
+	Long: `Generate a completion script for the given shell.
+
+Node IDs are completed from the control plane and cluster names from the
+configuration file. When the control plane is unreachable no node IDs are
+suggested.
+
+  bash:  source <(nebulagc completion bash)
+  zsh:   nebulagc completion zsh > "${fpath[1]}/_nebulagc"
+  fish:  nebulagc completion fish > ~/.config/fish/completions/nebulagc.fish`,
+	ValidArgs:             []string{"bash", "zsh", "fish"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	RunE:                  runCompletion,
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+
+	rootCmd.RegisterFlagCompletionFunc("cluster", completeClusterNames)
+}
+
+func runCompletion(cmd *cobra.Command, args []string) error {
+	switch args[0] {
+	case "bash":
+		return rootCmd.GenBashCompletionV2(os.Stdout, true)
+	case "zsh":
+		return rootCmd.GenZshCompletion(os.Stdout)
+	case "fish":
+		return rootCmd.GenFishCompletion(os.Stdout, true)
+	default:
+		return fmt.Errorf("unsupported shell: %s", args[0])
+	}
+}
+
+// completeClusterNames suggests the cluster names from the configuration file.
+func completeClusterNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	config, err := daemon.LoadConfigFromPath(configPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, cluster := range config.Clusters {
+		if strings.HasPrefix(cluster.Name, toComplete) {
+			names = append(names, cluster.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeNodeIDs suggests the IDs of the cluster's nodes, described by name.
+// Any failure, including an unreachable control plane, yields no suggestions.
+func completeNodeIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	client, err := newClusterClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	nodes, err := client.ListNodes(ctx, 1, 1000)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var ids []string
+	for _, node := range nodes {
+		if strings.HasPrefix(node.ID, toComplete) {
+			ids = append(ids, node.ID+"\t"+node.Name)
+		}
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
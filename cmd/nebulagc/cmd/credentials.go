@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yaroslav/nebulagc/cmd/nebulagc/daemon"
+)
+
+var credentialsCmd = &cobra.Command{
+	Use:   "credentials",
+	Short: "Manage tokens in the credential store",
+	Long: `Manage the tokens that clusters reference with node_token_ref and
+cluster_token_ref, in the credential store selected by credential_store in the
+configuration file.`,
+}
+
+var credentialsSetCmd = &cobra.Command{
+	Use:   "set <key>",
+	Short: "Store a token",
+	Long: `Store a token under key, reading it from standard input so it does not
+end up in the shell history:
+
+  nebulagc credentials set prod/node < new-token.txt
+
+A running daemon picks up a new node token the next time the control plane
+rejects the old one; run nebulagc reload to make that happen now.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCredentialsSet,
+}
+
+func init() {
+	rootCmd.AddCommand(credentialsCmd)
+	credentialsCmd.AddCommand(credentialsSetCmd)
+}
+
+func runCredentialsSet(cmd *cobra.Command, args []string) error {
+	config, _, err := daemon.LoadConfigPartial(configPath)
+	if err != nil {
+		return err
+	}
+
+	token, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && token == "" {
+		return fmt.Errorf("failed to read token from standard input: %w", err)
+	}
+	token = strings.TrimSpace(token)
+	if len(token) < daemon.MinTokenLength {
+		return fmt.Errorf("token is too short (minimum %d characters, got %d)", daemon.MinTokenLength, len(token))
+	}
+
+	store, err := daemon.OpenCredentialStore(config.CredentialStore)
+	if err != nil {
+		return err
+	}
+	if err := store.Set(args[0], token); err != nil {
+		return err
+	}
+
+	fmt.Printf("Stored token under %s\n", args[0])
+	return nil
+}
@@ -6,12 +6,13 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/yaroslav/nebulagc/cmd/nebulagc/daemon"
 	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
 )
 
 var (
-	configPath string
-	devMode    bool
+	devMode      bool
+	dryRun       bool
+	logFormat    string
+	statusSocket string
 )
 
 var daemonCmd = &cobra.Command{
@@ -26,26 +27,38 @@ The daemon will:
   - Manage Nebula processes for each configured cluster
   - Automatically restart processes on crashes
   - Handle graceful shutdown on SIGTERM/SIGINT
+  - Check for config updates immediately on SIGUSR2 (or nebulagc reload)
+
+With --dry-run the daemon instead downloads each cluster's latest bundle,
+verifies its signature against bundle_public_key if set, validates it and
+writes it to a temporary directory, reports what it would install and exits.
+Nebula is not started or restarted and the installed config is left alone.
+The command exits non-zero if any bundle is invalid or badly signed.
 
 Configuration file should be in JSON format and specify:
   - Control plane URLs
   - Cluster credentials (tenant ID, cluster ID, node ID, tokens)
-  - Local config directories`,
+  - Local config directories
+  - Log format, "json" (default) or "console" (optional, log_format)`,
 	RunE: runDaemon,
 }
 
 func init() {
 	rootCmd.AddCommand(daemonCmd)
 
-	daemonCmd.Flags().StringVarP(&configPath, "config", "c", "/etc/nebulagc/config.json",
-		"Path to daemon configuration file")
+	daemonCmd.Flags().StringVar(&statusSocket, "status-socket", daemon.DefaultStatusSocket,
+		"Unix socket to serve status on (empty to disable)")
+	daemonCmd.Flags().StringVar(&logFormat, "log-format", "",
+		"Log format: json or console (overrides log_format in the config file)")
 	daemonCmd.Flags().BoolVar(&devMode, "dev", false,
-		"Enable development mode (console logging instead of JSON)")
+		"Enable development mode (same as --log-format console)")
+	daemonCmd.Flags().BoolVar(&dryRun, "dry-run", false,
+		"Download and validate each cluster's latest bundle without installing it, then exit")
 }
 
 func runDaemon(cmd *cobra.Command, args []string) error {
 	// Initialize logger
-	logger, err := initLogger(devMode)
+	logger, err := daemon.NewLogger(resolveLogFormat())
 	if err != nil {
 		return fmt.Errorf("failed to initialize logger: %w", err)
 	}
@@ -58,8 +71,9 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 
 	// Create daemon manager
 	manager, err := daemon.NewManager(daemon.ManagerConfig{
-		ConfigPath: configPath,
-		Logger:     logger,
+		ConfigPath:   configPath,
+		Logger:       logger,
+		StatusSocket: statusSocket,
 	})
 	if err != nil {
 		logger.Error("Failed to create daemon manager", zap.Error(err))
@@ -68,6 +82,10 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 
 	logger.Info("Daemon manager created successfully")
 
+	if dryRun {
+		return runDryRun(cmd, manager)
+	}
+
 	// Run the manager (blocks until shutdown)
 	if err := manager.Run(); err != nil {
 		logger.Error("Manager error", zap.Error(err))
@@ -78,19 +96,36 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func initLogger(devMode bool) (*zap.Logger, error) {
-	var config zap.Config
+// runDryRun runs a dry run for every cluster and prints what the daemon
+// would install.
+func runDryRun(cmd *cobra.Command, manager *daemon.Manager) error {
+	reports, err := manager.DryRun(cmd.Context())
+	for _, report := range reports {
+		state := fmt.Sprintf("would install (installed: %d)", report.InstalledVersion)
+		if report.UpToDate {
+			state = "already installed"
+		}
+		fmt.Printf("%s: version %d, %d files, %d bytes, %s\n",
+			report.Cluster, report.Version, len(report.Files), report.Size, state)
+		for _, file := range report.Files {
+			fmt.Printf("  %s\n", file)
+		}
+	}
+	return err
+}
 
+// resolveLogFormat picks the log format from --dev, then --log-format, then
+// the config file, defaulting to JSON. A config file that fails to load is
+// ignored here; the manager reports the error once logging is set up.
+func resolveLogFormat() string {
 	if devMode {
-		// Development mode: human-readable console output
-		config = zap.NewDevelopmentConfig()
-		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
-	} else {
-		// Production mode: JSON structured logging
-		config = zap.NewProductionConfig()
-		config.EncoderConfig.TimeKey = "timestamp"
-		config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		return daemon.LogFormatConsole
 	}
-
-	return config.Build()
+	if logFormat != "" {
+		return logFormat
+	}
+	if config, _, err := daemon.LoadConfigPartial(configPath); err == nil && config.LogFormat != "" {
+		return config.LogFormat
+	}
+	return daemon.LogFormatJSON
 }
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	nodeName    string
+	nodeIsAdmin bool
+	nodeMTU     int
+)
+
+var nodeCmd = &cobra.Command{
+	Use:   "node",
+	Short: "Manage cluster nodes",
+	Long:  `Create, list and delete nodes in a cluster. Requires the cluster token in the configuration file.`,
+}
+
+var nodeCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a node",
+	Long: `Create a node in the cluster and print its credentials.
+
+The node token is only shown once; store it in the new node's daemon configuration.`,
+	RunE: runNodeCreate,
+}
+
+var nodeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List nodes",
+	Long:  `List the nodes in the cluster.`,
+	RunE:  runNodeList,
+}
+
+var nodeDeleteCmd = &cobra.Command{
+	Use:               "delete <node-id>",
+	Short:             "Delete a node",
+	Long:              `Delete a node from the cluster, revoking its token.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeNodeIDs,
+	RunE:              runNodeDelete,
+}
+
+var nodeRotateTokenCmd = &cobra.Command{
+	Use:   "rotate-token <node-id>",
+	Short: "Rotate a node's token",
+	Long: `Issue a new token for a node, revoking the old one.
+
+When the node is the one this configuration belongs to and its token is held
+in the credential store (node_token_ref), the new token is written back to
+the store. Otherwise the new token is printed; update the node's daemon
+configuration with it, or the node loses access to the control plane.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeNodeIDs,
+	RunE:              runNodeRotateToken,
+}
+
+func init() {
+	rootCmd.AddCommand(nodeCmd)
+	nodeCmd.AddCommand(nodeCreateCmd)
+	nodeCmd.AddCommand(nodeListCmd)
+	nodeCmd.AddCommand(nodeDeleteCmd)
+	nodeCmd.AddCommand(nodeRotateTokenCmd)
+
+	nodeCreateCmd.Flags().StringVar(&nodeName, "name", "", "Node name (required)")
+	nodeCreateCmd.Flags().BoolVar(&nodeIsAdmin, "admin", false, "Grant the node admin privileges")
+	nodeCreateCmd.Flags().IntVar(&nodeMTU, "mtu", 1300, "Nebula MTU for the node")
+	nodeCreateCmd.MarkFlagRequired("name")
+}
+
+func runNodeCreate(cmd *cobra.Command, args []string) error {
+	client, err := newClusterClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+	defer cancel()
+
+	creds, err := client.CreateNode(ctx, nodeName, nodeIsAdmin, nodeMTU)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Node ID:    %s\n", creds.NodeID)
+	fmt.Printf("Nebula IP:  %s\n", creds.NebulaIP)
+	fmt.Printf("Node token: %s\n", creds.NodeToken)
+	return nil
+}
+
+func runNodeList(cmd *cobra.Command, args []string) error {
+	client, err := newClusterClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+	defer cancel()
+
+	nodes, err := client.ListNodes(ctx, 1, 1000)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tNEBULA IP\tADMIN")
+	for _, node := range nodes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\n", node.ID, node.Name, node.NebulaIP, node.IsAdmin)
+	}
+	return w.Flush()
+}
+
+func runNodeDelete(cmd *cobra.Command, args []string) error {
+	client, err := newClusterClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+	defer cancel()
+
+	if err := client.DeleteNode(ctx, args[0]); err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleted node %s\n", args[0])
+	return nil
+}
+
+func runNodeRotateToken(cmd *cobra.Command, args []string) error {
+	d, name, err := loadCluster()
+	if err != nil {
+		return err
+	}
+	client, err := d.GetClient(name)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+	defer cancel()
+
+	nodeID := args[0]
+	token, err := client.RotateNodeToken(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+
+	if nodeID == client.NodeID {
+		stored, err := d.StoreNodeToken(name, token)
+		if err != nil {
+			// The old token is already revoked, so the new one must not be lost
+			fmt.Printf("Node token: %s\n", token)
+			return err
+		}
+		if stored {
+			fmt.Printf("Rotated token for node %s and saved it to the credential store\n", nodeID)
+			return nil
+		}
+
+		fmt.Printf("Node token: %s\n", token)
+		fmt.Printf("Update node_token of cluster %s in %s; the old token no longer works\n", name, configPath)
+		return nil
+	}
+
+	fmt.Printf("Node token: %s\n", token)
+	return nil
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yaroslav/nebulagc/cmd/nebulagc/daemon"
+)
+
+var reloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Check for config updates now",
+	Long: `Ask the running daemon to check every cluster for a new config version now
+instead of at its next poll, then restart the poll interval. Sending SIGUSR2
+to the daemon does the same.
+
+Requests within a couple of seconds of the previous one are ignored.`,
+	RunE: runReload,
+}
+
+func init() {
+	rootCmd.AddCommand(reloadCmd)
+
+	reloadCmd.Flags().StringVar(&statusSocketPath, "socket", daemon.DefaultStatusSocket,
+		"Status socket of the running daemon")
+}
+
+func runReload(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Second)
+	defer cancel()
+
+	triggered, err := daemon.TriggerReload(ctx, statusSocketPath)
+	if err != nil {
+		return err
+	}
+
+	if !triggered {
+		fmt.Println("A reload just happened; request ignored")
+		return nil
+	}
+	fmt.Println("Config check triggered")
+	return nil
+}
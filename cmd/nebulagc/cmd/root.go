@@ -27,7 +27,11 @@ The daemon automatically:
   - Polls for configuration updates
   - Manages Nebula process lifecycle
   - Handles automatic restarts on crashes
-  - Supports multiple clusters per node`,
+  - Supports multiple clusters per node
+
+Admin commands (node, bundle) use the credentials of a cluster from the
+daemon configuration file; select it with --cluster when the file lists
+more than one.`,
 	SilenceUsage: true,
 }
 
@@ -36,8 +40,16 @@ func Execute() error {
 	return rootCmd.Execute()
 }
 
+var (
+	configPath  string
+	clusterName string
+)
+
 func init() {
-	// Global flags can be added here if needed
+	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "/etc/nebulagc/config.json",
+		"Path to daemon configuration file")
+	rootCmd.PersistentFlags().StringVar(&clusterName, "cluster", "",
+		"Cluster from the configuration file to use for admin commands")
 }
 
 // versionString returns formatted version information
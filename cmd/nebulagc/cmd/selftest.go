@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/yaroslav/nebulagc/cmd/nebulagc/daemon"
+	"github.com/yaroslav/nebulagc/sdk"
+)
+
+var selfTestCmd = &cobra.Command{
+	Use:   "self-test",
+	Short: "Check that this node is set up correctly",
+	Long: `Run a series of checks against the cluster selected with --cluster and print
+a pass/fail checklist:
+
+  - the configuration file loads
+  - the control plane master can be discovered
+  - the node token is accepted and belongs to the configured node
+  - the latest config version can be fetched
+  - the config bundle downloads, is signed by bundle_public_key when one is
+    configured, and is valid (it is not installed)
+  - the nebula binary is on PATH and runs
+
+Nothing on the node is changed. Every check runs even if an earlier one
+failed, and the command exits non-zero if any check failed.`,
+	Args: cobra.NoArgs,
+	RunE: runSelfTest,
+}
+
+func init() {
+	rootCmd.AddCommand(selfTestCmd)
+}
+
+func runSelfTest(cmd *cobra.Command, args []string) error {
+	client, config, err := loadSelfTestCluster()
+	if err != nil {
+		printSelfTestCheck(daemon.SelfTestCheck{Name: "Load configuration", Err: err})
+		return fmt.Errorf("self-test failed: configuration is unusable")
+	}
+	printSelfTestCheck(daemon.SelfTestCheck{Name: "Load configuration", Detail: configPath})
+
+	checks := daemon.RunSelfTest(cmd.Context(), client, config.BundlePublicKeyValue())
+
+	failed := 0
+	for _, check := range checks {
+		printSelfTestCheck(check)
+		if !check.Passed() {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("self-test failed: %d of %d checks failed", failed, len(checks)+1)
+	}
+	fmt.Printf("\nAll %d checks passed\n", len(checks)+1)
+	return nil
+}
+
+// loadSelfTestCluster returns the client and configuration of the cluster
+// selected with --cluster.
+func loadSelfTestCluster() (*sdk.Client, *daemon.ClusterConfig, error) {
+	d, name, err := loadCluster()
+	if err != nil {
+		return nil, nil, err
+	}
+	config, err := d.GetClusterConfig(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := d.GetClient(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, config, nil
+}
+
+// printSelfTestCheck prints one checklist line.
+func printSelfTestCheck(check daemon.SelfTestCheck) {
+	if check.Passed() {
+		fmt.Printf("[PASS] %s: %s\n", check.Name, check.Detail)
+		return
+	}
+	fmt.Printf("[FAIL] %s: %v\n", check.Name, check.Err)
+}
@@ -1,37 +1,100 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/yaroslav/nebulagc/cmd/nebulagc/daemon"
 )
 
+var statusSocketPath string
+
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show daemon status",
-	Long:  `Display the current status of the NebulaGC daemon including cluster health and process information.`,
-	RunE:  runStatus,
+	Long: `Display the current status of the running NebulaGC daemon, queried over its
+local status socket: config version, Nebula process state and control plane
+health for each cluster.`,
+	RunE: runStatus,
 }
 
 func init() {
 	rootCmd.AddCommand(statusCmd)
+
+	statusCmd.Flags().StringVar(&statusSocketPath, "socket", daemon.DefaultStatusSocket,
+		"Status socket of the running daemon")
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
-	// TODO: Implement status command
-	// This will require either:
-	// 1. A status file written by the daemon
-	// 2. An IPC mechanism (e.g., Unix socket) to query the running daemon
-	// 3. A simple HTTP endpoint on localhost
-	//
-	// For now, print placeholder message
-	fmt.Println("Daemon status command not yet implemented")
-	fmt.Println("Future implementation will show:")
-	fmt.Println("  - Cluster health (healthy/degraded)")
-	fmt.Println("  - Nebula process status (running/stopped)")
-	fmt.Println("  - Current config version")
-	fmt.Println("  - Last successful poll time")
-	fmt.Println("  - Control plane replica health")
+	ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Second)
+	defer cancel()
+
+	status, err := daemon.QueryStatus(ctx, statusSocketPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Daemon up since %s\n\n", status.StartedAt.Format(time.RFC3339))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tSTATE\tVERSION\tNEBULA\tCONTROL PLANE\tLAST CHECK\tLAST POLL")
+	for _, c := range status.Clusters {
+		if c.State == daemon.ClusterStateFailed {
+			fmt.Fprintf(w, "%s\t%s\t-\t-\t-\t-\t-\n", c.Name, c.State)
+			continue
+		}
+
+		nebula := "stopped"
+		if c.NebulaRunning {
+			nebula = fmt.Sprintf("running (pid %d)", c.NebulaPID)
+		}
+
+		controlPlane := fmt.Sprintf("%d/%d healthy", c.HealthyReplicas, c.TotalReplicas)
+		switch {
+		case c.DegradedReason == daemon.DegradedReasonTokenRejected:
+			controlPlane += ", degraded (node token rejected)"
+		case c.Degraded:
+			controlPlane += ", degraded"
+		}
+
+		lastCheck := "never"
+		if c.LastHealthCheck != nil {
+			lastCheck = c.LastHealthCheck.Format(time.RFC3339)
+		}
+
+		lastPoll := "never"
+		if c.LastPoll != nil {
+			lastPoll = c.LastPoll.Format(time.RFC3339)
+			if c.LastPollError != "" {
+				lastPoll += ", failed"
+			}
+		}
+		if c.TimedOutPolls > 0 {
+			lastPoll += fmt.Sprintf(" (%d timed out)", c.TimedOutPolls)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n", c.Name, c.State, c.ConfigVersion, nebula, controlPlane, lastCheck, lastPoll)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
 
+	// Full errors do not fit the table
+	for _, c := range status.Clusters {
+		if c.Error != "" {
+			fmt.Printf("\n%s: failed to start: %s\n", c.Name, c.Error)
+		}
+		if c.LastPollError != "" {
+			fmt.Printf("\n%s: last poll failed: %s\n", c.Name, c.LastPollError)
+		}
+		if c.DegradedReason == daemon.DegradedReasonTokenRejected {
+			fmt.Printf("%s: the node token was rotated or revoked; store the new token with\n"+
+				"  nebulagc credentials set <node_token_ref> (or update node_token and restart)\n", c.Name)
+		}
+	}
 	return nil
 }
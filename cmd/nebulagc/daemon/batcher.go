@@ -0,0 +1,207 @@
+package daemon
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yaroslav/nebulagc/sdk"
+	"go.uber.org/zap"
+)
+
+// versionNotice is a latest-version result delivered from a VersionBatcher to a Poller.
+type versionNotice struct {
+	// version is the latest config version, valid only if known is true
+	version int64
+
+	// known is false when the batch call failed or the cluster's entry was
+	// rejected; the poller then queries the control plane directly
+	known bool
+}
+
+// batchTarget is a cluster whose version is checked by a VersionBatcher.
+type batchTarget struct {
+	// ref identifies the cluster and carries its credentials
+	ref sdk.ClusterRef
+
+	// notices receives the cluster's latest version each round
+	notices chan versionNotice
+}
+
+// VersionBatcher checks the config versions of all managed clusters with a
+// single batched request per interval instead of one request per cluster,
+// and hands each result to that cluster's Poller.
+type VersionBatcher struct {
+	// client is the SDK client used for the batched call
+	// Any cluster's client works since credentials travel per cluster
+	client *sdk.Client
+
+	// logger is the structured logger
+	logger *zap.Logger
+
+	// interval is the time between batched checks
+	interval time.Duration
+
+	// targets are the clusters to check, in registration order
+	targets []*batchTarget
+
+	// mu guards the targets' credentials, which UpdateToken replaces while
+	// Run is checking versions
+	mu sync.Mutex
+}
+
+// NewVersionBatcher creates a new version batcher.
+//
+// Parameters:
+//   - client: SDK client used for the batched version call
+//   - logger: Structured logger
+//   - interval: Time between checks (default: 5 seconds)
+//
+// Returns:
+//   - *VersionBatcher: The initialized batcher
+func NewVersionBatcher(client *sdk.Client, logger *zap.Logger, interval time.Duration) *VersionBatcher {
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+
+	return &VersionBatcher{
+		client:   client,
+		logger:   logger,
+		interval: interval,
+	}
+}
+
+// Register adds a cluster to the batch and returns the channel on which its
+// latest version is delivered. Must be called before Run.
+//
+// Parameters:
+//   - config: Cluster configuration providing IDs and node token
+//
+// Returns:
+//   - <-chan versionNotice: Channel to pass to the cluster's Poller
+func (b *VersionBatcher) Register(config *ClusterConfig) <-chan versionNotice {
+	target := &batchTarget{
+		ref: sdk.ClusterRef{
+			TenantID:  config.TenantID,
+			ClusterID: config.ClusterID,
+			NodeToken: config.NodeToken,
+		},
+		// Buffer one notice so a busy poller never blocks the batcher
+		notices: make(chan versionNotice, 1),
+	}
+	b.targets = append(b.targets, target)
+	return target.notices
+}
+
+// UpdateToken replaces the node token sent for a registered cluster, e.g.
+// after the cluster's manager loaded a rotated token.
+//
+// Parameters:
+//   - clusterID: The registered cluster's ID
+//   - token: The new node token
+func (b *VersionBatcher) UpdateToken(clusterID, token string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, target := range b.targets {
+		if target.ref.ClusterID == clusterID {
+			target.ref.NodeToken = token
+		}
+	}
+}
+
+// Run starts the batched polling loop and blocks until context is cancelled.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+func (b *VersionBatcher) Run(ctx context.Context) {
+	b.logger.Info("Version batcher started",
+		zap.Int("clusters", len(b.targets)),
+		zap.Duration("interval", b.interval),
+	)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	// Run initial check immediately
+	b.checkVersions(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.logger.Info("Version batcher stopped")
+			return
+		case <-ticker.C:
+			b.checkVersions(ctx)
+		}
+	}
+}
+
+// checkVersions queries all targets, at most sdk.MaxVersionBatchSize per
+// request, and delivers the results.
+func (b *VersionBatcher) checkVersions(ctx context.Context) {
+	for start := 0; start < len(b.targets); start += sdk.MaxVersionBatchSize {
+		end := start + sdk.MaxVersionBatchSize
+		if end > len(b.targets) {
+			end = len(b.targets)
+		}
+		b.checkChunk(ctx, b.targets[start:end])
+	}
+}
+
+// checkChunk performs one batched call for targets and notifies each of them.
+func (b *VersionBatcher) checkChunk(ctx context.Context, targets []*batchTarget) {
+	b.mu.Lock()
+	refs := make([]sdk.ClusterRef, len(targets))
+	for i, target := range targets {
+		refs[i] = target.ref
+	}
+	b.mu.Unlock()
+
+	// Bound the call so a stuck request is cancelled before the next round
+	callCtx, cancel := context.WithTimeout(ctx, b.interval)
+	defer cancel()
+
+	versions, err := b.client.GetLatestVersions(callCtx, refs)
+	if err != nil || len(versions) != len(targets) {
+		if err == nil {
+			b.logger.Error("Batched version check returned wrong number of results",
+				zap.Int("expected", len(targets)),
+				zap.Int("got", len(versions)),
+			)
+		} else {
+			b.logger.Error("Batched version check failed", zap.Error(err))
+		}
+		// Let each poller fall back to an individual check
+		for _, target := range targets {
+			target.notify(versionNotice{})
+		}
+		return
+	}
+
+	// Results are returned in request order
+	for i, target := range targets {
+		result := versions[i]
+		if result.Error != "" || result.ClusterID != refs[i].ClusterID {
+			b.logger.Warn("Batched version check rejected cluster",
+				zap.String("cluster_id", refs[i].ClusterID),
+				zap.String("error", result.Error),
+			)
+			target.notify(versionNotice{})
+			continue
+		}
+		target.notify(versionNotice{version: result.Version, known: true})
+	}
+}
+
+// notify delivers notice, replacing any notice the poller has not consumed yet.
+func (t *batchTarget) notify(notice versionNotice) {
+	select {
+	case <-t.notices:
+	default:
+	}
+	select {
+	case t.notices <- notice:
+	default:
+	}
+}
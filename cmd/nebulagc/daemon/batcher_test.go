@@ -0,0 +1,105 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/yaroslav/nebulagc/sdk"
+	"go.uber.org/zap"
+)
+
+func TestVersionBatcher_CheckVersions(t *testing.T) {
+	var requests, fail atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/config/versions" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		requests.Add(1)
+		if fail.Load() == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var req struct {
+			Clusters []sdk.ClusterRef `json:"clusters"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		resp := sdk.VersionsResponse{}
+		for _, ref := range req.Clusters {
+			result := sdk.ClusterVersion{TenantID: ref.TenantID, ClusterID: ref.ClusterID, Version: 7}
+			if ref.NodeToken != "token-a" {
+				result = sdk.ClusterVersion{TenantID: ref.TenantID, ClusterID: ref.ClusterID, Error: "unauthorized"}
+			}
+			resp.Versions = append(resp.Versions, result)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client, err := sdk.NewClient(sdk.ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-1",
+		ClusterID:     "cluster-a",
+		NodeToken:     "token-a",
+		RetryAttempts: 0,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	batcher := NewVersionBatcher(client, zap.NewNop(), 0)
+	a := batcher.Register(&ClusterConfig{TenantID: "tenant-1", ClusterID: "cluster-a", NodeToken: "token-a"})
+	b := batcher.Register(&ClusterConfig{TenantID: "tenant-1", ClusterID: "cluster-b", NodeToken: "token-b"})
+
+	batcher.checkVersions(context.Background())
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected 1 batched request, got %d", got)
+	}
+	if notice := <-a; !notice.known || notice.version != 7 {
+		t.Errorf("Expected version 7 for cluster-a, got %+v", notice)
+	}
+	if notice := <-b; notice.known {
+		t.Errorf("Expected rejected cluster-b to fall back, got %+v", notice)
+	}
+
+	// A rotated token is sent from the next round on
+	batcher.UpdateToken("cluster-b", "token-a")
+	batcher.checkVersions(context.Background())
+	<-a
+	if notice := <-b; !notice.known || notice.version != 7 {
+		t.Errorf("Expected version 7 for cluster-b after its token was updated, got %+v", notice)
+	}
+
+	// A failed batch makes every poller fall back to an individual check
+	fail.Store(1)
+	batcher.checkVersions(context.Background())
+	for name, ch := range map[string]<-chan versionNotice{"cluster-a": a, "cluster-b": b} {
+		if notice := <-ch; notice.known {
+			t.Errorf("Expected fallback notice for %s, got %+v", name, notice)
+		}
+	}
+}
+
+func TestBatchTarget_NotifyReplacesPending(t *testing.T) {
+	target := &batchTarget{notices: make(chan versionNotice, 1)}
+
+	target.notify(versionNotice{version: 1, known: true})
+	target.notify(versionNotice{version: 2, known: true})
+
+	if notice := <-target.notices; notice.version != 2 {
+		t.Errorf("Expected latest notice (version 2), got %+v", notice)
+	}
+	select {
+	case notice := <-target.notices:
+		t.Errorf("Expected no further notices, got %+v", notice)
+	default:
+	}
+}
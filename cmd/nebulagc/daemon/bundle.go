@@ -5,10 +5,17 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // RequiredBundleFiles lists the files that must exist in a valid config bundle.
@@ -19,6 +26,45 @@ var RequiredBundleFiles = []string{
 	"host.key",
 }
 
+const (
+	// configDirMode is the permission mode for the config directory, which holds key material.
+	configDirMode os.FileMode = 0700
+
+	// keyFileMode is the permission mode for the Nebula host key.
+	keyFileMode os.FileMode = 0600
+
+	// keyFileName is the bundle file holding the Nebula host private key.
+	keyFileName = "host.key"
+
+	// manifestFileName records the checksum of every installed bundle file,
+	// so damage to the installed config can be detected on startup.
+	manifestFileName = ".nebulagc-manifest.json"
+
+	// versionFileName holds the version of the installed bundle, so a
+	// restarted daemon does not download a bundle it already has.
+	versionFileName = ".nebulagc-version"
+)
+
+var (
+	// ErrNoInstalledManifest indicates the config directory has no manifest
+	// (nothing installed yet, or installed by a daemon that did not write one),
+	// so its integrity cannot be checked.
+	ErrNoInstalledManifest = errors.New("no installed bundle manifest")
+
+	// ErrInstalledConfigDamaged indicates installed config files no longer
+	// match the checksums recorded when the bundle was installed.
+	ErrInstalledConfigDamaged = errors.New("installed config is damaged")
+)
+
+// bundleManifest lists the files of an installed bundle.
+type bundleManifest struct {
+	// Version is the config version that was installed
+	Version int64 `json:"version"`
+
+	// Files maps each installed file name to its SHA-256 (hex)
+	Files map[string]string `json:"files"`
+}
+
 // BundleManager handles config bundle operations: validation, extraction, and atomic replacement.
 type BundleManager struct {
 	// configDir is the target directory for config files
@@ -38,8 +84,14 @@ func NewBundleManager(configDir string) *BundleManager {
 // 1. Validate bundle format (tar.gz with required files)
 // 2. Create temporary directory
 // 3. Extract bundle to temporary directory
-// 4. Atomically rename temporary directory to config directory
-// 5. Clean up old directory
+// 4. Record file checksums in a manifest for VerifyInstalled, and the version
+// 5. Atomically rename temporary directory to config directory
+// 6. Clean up old directory
+//
+// If version is already installed and intact, nothing is written.
+//
+// The installed config directory is always mode 0700 and host.key mode 0600,
+// regardless of the modes recorded in the bundle or of the directory being replaced.
 //
 // Parameters:
 //   - ctx: Context for cancellation
@@ -54,11 +106,23 @@ func (bm *BundleManager) ApplyBundle(ctx context.Context, data []byte, version i
 		return fmt.Errorf("bundle validation failed: %w", err)
 	}
 
+	// Leave an identical install alone; a damaged one is replaced
+	if bm.LoadVersion() == version {
+		if installed, err := bm.VerifyInstalled(); err == nil && installed == version {
+			return nil
+		}
+	}
+
 	// Create temporary extraction directory
 	tempDir := fmt.Sprintf("%s.tmp.%d", bm.configDir, version)
-	if err := os.MkdirAll(tempDir, 0755); err != nil {
+	if err := os.MkdirAll(tempDir, configDirMode); err != nil {
 		return fmt.Errorf("failed to create temp directory: %w", err)
 	}
+	// MkdirAll leaves an existing leftover directory's mode untouched
+	if err := os.Chmod(tempDir, configDirMode); err != nil {
+		os.RemoveAll(tempDir) // Clean up on failure
+		return fmt.Errorf("failed to set temp directory permissions: %w", err)
+	}
 
 	// Extract bundle to temp directory
 	if err := bm.extractBundle(data, tempDir); err != nil {
@@ -72,6 +136,16 @@ func (bm *BundleManager) ApplyBundle(ctx context.Context, data []byte, version i
 		return fmt.Errorf("extracted files verification failed: %w", err)
 	}
 
+	// Record checksums so the installed files can be verified later
+	if err := writeManifest(tempDir, version); err != nil {
+		os.RemoveAll(tempDir) // Clean up on failure
+		return fmt.Errorf("failed to write bundle manifest: %w", err)
+	}
+	if err := writeVersionFile(tempDir, version); err != nil {
+		os.RemoveAll(tempDir) // Clean up on failure
+		return fmt.Errorf("failed to write bundle version: %w", err)
+	}
+
 	// Atomic replacement: rename old directory, move new directory into place
 	if err := bm.atomicReplace(tempDir); err != nil {
 		os.RemoveAll(tempDir) // Clean up on failure
@@ -83,6 +157,18 @@ func (bm *BundleManager) ApplyBundle(ctx context.Context, data []byte, version i
 
 // validateBundle checks that the bundle is valid tar.gz and contains required files.
 func (bm *BundleManager) validateBundle(data []byte) error {
+	return ValidateBundle(data)
+}
+
+// ValidateBundle checks that a bundle is a valid tar.gz archive containing
+// every file in RequiredBundleFiles, without extracting it.
+//
+// Parameters:
+//   - data: Bundle data (tar.gz format)
+//
+// Returns:
+//   - error: Nil if the bundle is valid, otherwise the first problem found
+func ValidateBundle(data []byte) error {
 	// Decompress gzip
 	gzReader, err := gzip.NewReader(bytes.NewReader(data))
 	if err != nil {
@@ -147,8 +233,12 @@ func (bm *BundleManager) extractBundle(data []byte, destDir string) error {
 
 		switch header.Typeflag {
 		case tar.TypeReg:
-			// Extract regular file
-			if err := bm.extractFile(tarReader, targetPath, os.FileMode(header.Mode)); err != nil {
+			// Extract regular file; the host key is never readable by other users
+			mode := os.FileMode(header.Mode).Perm()
+			if filepath.Base(targetPath) == keyFileName {
+				mode = keyFileMode
+			}
+			if err := bm.extractFile(tarReader, targetPath, mode); err != nil {
 				return fmt.Errorf("failed to extract %s: %w", header.Name, err)
 			}
 		case tar.TypeDir:
@@ -165,7 +255,7 @@ func (bm *BundleManager) extractBundle(data []byte, destDir string) error {
 // extractFile writes a single file from tar archive to disk.
 func (bm *BundleManager) extractFile(reader io.Reader, path string, mode os.FileMode) error {
 	// Create parent directory if needed
-	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(path), configDirMode); err != nil {
 		return err
 	}
 
@@ -181,6 +271,48 @@ func (bm *BundleManager) extractFile(reader io.Reader, path string, mode os.File
 		return err
 	}
 
+	// OpenFile only applies mode to new files and is subject to the umask
+	return file.Chmod(mode)
+}
+
+// CheckConfigDirPermissions reports whether a config directory or its host key
+// is accessible to users other than the owner.
+//
+// A directory that does not exist yet passes: ApplyBundle creates it with
+// safe permissions.
+//
+// Parameters:
+//   - dir: Config directory to check
+//
+// Returns:
+//   - error: Nil if permissions are safe, otherwise a description of each permissive path
+func CheckConfigDirPermissions(dir string) error {
+	var problems []string
+
+	checks := []struct {
+		path string
+		want os.FileMode
+	}{
+		{dir, configDirMode},
+		{filepath.Join(dir, keyFileName), keyFileMode},
+	}
+	for _, check := range checks {
+		info, err := os.Stat(check.path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", check.path, err)
+		}
+		if info.Mode().Perm()&0077 != 0 {
+			problems = append(problems, fmt.Sprintf("%s has mode %04o, want %04o",
+				check.path, info.Mode().Perm(), check.want))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("permissions too open: %s", strings.Join(problems, "; "))
+	}
 	return nil
 }
 
@@ -195,6 +327,121 @@ func (bm *BundleManager) verifyExtractedFiles(dir string) error {
 	return nil
 }
 
+// VerifyInstalled checks the installed config files against the checksums
+// recorded when their bundle was installed, so Nebula is not started with a
+// config damaged on disk since.
+//
+// Returns:
+//   - int64: The installed config version (0 if unknown)
+//   - error: ErrNoInstalledManifest if there is nothing to verify against,
+//     ErrInstalledConfigDamaged naming each missing or modified file, or an
+//     error if the manifest cannot be read
+func (bm *BundleManager) VerifyInstalled() (int64, error) {
+	data, err := os.ReadFile(filepath.Join(bm.configDir, manifestFileName))
+	if os.IsNotExist(err) {
+		return 0, ErrNoInstalledManifest
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read bundle manifest: %w", err)
+	}
+
+	var manifest bundleManifest
+	if err := json.Unmarshal(data, &manifest); err != nil || len(manifest.Files) == 0 {
+		return 0, fmt.Errorf("%w: manifest is unreadable", ErrInstalledConfigDamaged)
+	}
+
+	var damaged []string
+	for _, name := range sortedKeys(manifest.Files) {
+		sum, err := fileChecksum(filepath.Join(bm.configDir, name))
+		switch {
+		case os.IsNotExist(err):
+			damaged = append(damaged, name+" (missing)")
+		case err != nil:
+			damaged = append(damaged, fmt.Sprintf("%s (%v)", name, err))
+		case sum != manifest.Files[name]:
+			damaged = append(damaged, name+" (modified)")
+		}
+	}
+
+	if len(damaged) > 0 {
+		return manifest.Version, fmt.Errorf("%w: version %d: %s",
+			ErrInstalledConfigDamaged, manifest.Version, strings.Join(damaged, ", "))
+	}
+	return manifest.Version, nil
+}
+
+// writeManifest records the checksum of every regular file in dir.
+func writeManifest(dir string, version int64) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	manifest := bundleManifest{Version: version, Files: make(map[string]string)}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || entry.Name() == manifestFileName || entry.Name() == versionFileName {
+			continue
+		}
+		sum, err := fileChecksum(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		manifest.Files[entry.Name()] = sum
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, manifestFileName), data, keyFileMode)
+}
+
+// LoadVersion returns the version of the installed bundle, or 0 if nothing
+// is installed or the version file is missing or unreadable, in which case
+// the latest bundle is downloaded again.
+func (bm *BundleManager) LoadVersion() int64 {
+	data, err := os.ReadFile(filepath.Join(bm.configDir, versionFileName))
+	if err != nil {
+		return 0
+	}
+	version, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil || version < 0 {
+		return 0
+	}
+	return version
+}
+
+// writeVersionFile records the installed bundle version in dir.
+func writeVersionFile(dir string, version int64) error {
+	data := []byte(strconv.FormatInt(version, 10) + "\n")
+	return os.WriteFile(filepath.Join(dir, versionFileName), data, keyFileMode)
+}
+
+// fileChecksum returns the SHA-256 (hex) of a file's contents.
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// sortedKeys returns the keys of m in order, for stable error messages.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // atomicReplace performs an atomic directory replacement.
 // It renames the current config directory to a backup, then renames the new directory into place.
 func (bm *BundleManager) atomicReplace(tempDir string) error {
@@ -5,9 +5,17 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/yaroslav/nebulagc/sdk"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestBundleManager_ValidateBundle(t *testing.T) {
@@ -195,6 +203,197 @@ func TestBundleManager_ApplyBundle_InvalidBundle(t *testing.T) {
 	}
 }
 
+func TestBundleManager_ApplyBundle_TightensPermissions(t *testing.T) {
+	tempDir := t.TempDir()
+	configDir := filepath.Join(tempDir, "config")
+
+	// An existing world-readable config directory is reported
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	if err := os.Chmod(configDir, 0755); err != nil {
+		t.Fatalf("Failed to chmod config dir: %v", err)
+	}
+	if err := CheckConfigDirPermissions(configDir); err == nil {
+		t.Error("CheckConfigDirPermissions() expected error for 0755 directory")
+	}
+
+	bm := NewBundleManager(configDir)
+	if err := bm.ApplyBundle(context.Background(), createTestBundle(t, RequiredBundleFiles), 1); err != nil {
+		t.Fatalf("ApplyBundle() error = %v", err)
+	}
+
+	// Installing a bundle replaces it with a private directory and key
+	info, err := os.Stat(configDir)
+	if err != nil {
+		t.Fatalf("Failed to stat config dir: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0700 {
+		t.Errorf("Expected config dir mode 0700, got %04o", perm)
+	}
+
+	info, err = os.Stat(filepath.Join(configDir, "host.key"))
+	if err != nil {
+		t.Fatalf("Failed to stat host.key: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("Expected host.key mode 0600, got %04o", perm)
+	}
+
+	if err := CheckConfigDirPermissions(configDir); err != nil {
+		t.Errorf("CheckConfigDirPermissions() after install error = %v", err)
+	}
+}
+
+func TestBundleManager_VerifyInstalled(t *testing.T) {
+	configDir := filepath.Join(t.TempDir(), "config")
+	bm := NewBundleManager(configDir)
+
+	if _, err := bm.VerifyInstalled(); !errors.Is(err, ErrNoInstalledManifest) {
+		t.Fatalf("VerifyInstalled() before install error = %v, want ErrNoInstalledManifest", err)
+	}
+
+	if err := bm.ApplyBundle(context.Background(), createTestBundle(t, RequiredBundleFiles), 4); err != nil {
+		t.Fatalf("ApplyBundle() error = %v", err)
+	}
+	version, err := bm.VerifyInstalled()
+	if err != nil || version != 4 {
+		t.Fatalf("VerifyInstalled() = %d, %v; want 4, nil", version, err)
+	}
+
+	// Damage the installed config on disk
+	if err := os.WriteFile(filepath.Join(configDir, "host.crt"), []byte("garbage"), 0644); err != nil {
+		t.Fatalf("Failed to corrupt host.crt: %v", err)
+	}
+	if err := os.Remove(filepath.Join(configDir, "ca.crt")); err != nil {
+		t.Fatalf("Failed to remove ca.crt: %v", err)
+	}
+
+	_, err = bm.VerifyInstalled()
+	if !errors.Is(err, ErrInstalledConfigDamaged) {
+		t.Fatalf("VerifyInstalled() error = %v, want ErrInstalledConfigDamaged", err)
+	}
+	for _, want := range []string{"host.crt (modified)", "ca.crt (missing)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("VerifyInstalled() error = %v, want it to name %q", err, want)
+		}
+	}
+}
+
+func TestBundleManager_VersionFileRoundTrip(t *testing.T) {
+	configDir := t.TempDir()
+	bm := NewBundleManager(configDir)
+
+	if version := bm.LoadVersion(); version != 0 {
+		t.Errorf("LoadVersion() without a version file = %d, want 0", version)
+	}
+
+	if err := writeVersionFile(configDir, 42); err != nil {
+		t.Fatalf("writeVersionFile() error = %v", err)
+	}
+	if version := bm.LoadVersion(); version != 42 {
+		t.Errorf("LoadVersion() = %d, want 42", version)
+	}
+
+	for _, corrupt := range []string{"", "forty-two", "-3", "12abc"} {
+		if err := os.WriteFile(filepath.Join(configDir, versionFileName), []byte(corrupt), 0600); err != nil {
+			t.Fatalf("Failed to write version file: %v", err)
+		}
+		if version := bm.LoadVersion(); version != 0 {
+			t.Errorf("LoadVersion() with %q = %d, want 0", corrupt, version)
+		}
+	}
+}
+
+func TestBundleManager_ApplyBundleSkipsInstalledVersion(t *testing.T) {
+	configDir := filepath.Join(t.TempDir(), "config")
+	bm := NewBundleManager(configDir)
+	data := createTestBundle(t, RequiredBundleFiles)
+
+	if err := bm.ApplyBundle(context.Background(), data, 6); err != nil {
+		t.Fatalf("ApplyBundle() error = %v", err)
+	}
+	if version := bm.LoadVersion(); version != 6 {
+		t.Fatalf("LoadVersion() after install = %d, want 6", version)
+	}
+
+	// A marker in the directory shows whether it was replaced
+	marker := filepath.Join(configDir, "marker")
+	if err := os.WriteFile(marker, nil, 0600); err != nil {
+		t.Fatalf("Failed to write marker: %v", err)
+	}
+	if err := bm.ApplyBundle(context.Background(), data, 6); err != nil {
+		t.Fatalf("ApplyBundle() of the installed version error = %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("Expected the installed version to be left alone, stat error = %v", err)
+	}
+
+	// A damaged install of the same version is replaced
+	if err := os.WriteFile(filepath.Join(configDir, "host.crt"), []byte("garbage"), 0644); err != nil {
+		t.Fatalf("Failed to corrupt host.crt: %v", err)
+	}
+	if err := bm.ApplyBundle(context.Background(), data, 6); err != nil {
+		t.Fatalf("ApplyBundle() over a damaged install error = %v", err)
+	}
+	if _, err := bm.VerifyInstalled(); err != nil {
+		t.Errorf("VerifyInstalled() after reinstall error = %v", err)
+	}
+}
+
+func TestClusterManager_DamagedConfigNotStartedWhenOffline(t *testing.T) {
+	configDir := filepath.Join(t.TempDir(), "config")
+	bm := NewBundleManager(configDir)
+	if err := bm.ApplyBundle(context.Background(), createTestBundle(t, RequiredBundleFiles), 1); err != nil {
+		t.Fatalf("ApplyBundle() error = %v", err)
+	}
+
+	// A control plane that is down
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serverURL := server.URL
+	server.Close()
+
+	client, err := sdk.NewClient(sdk.ClientConfig{
+		BaseURLs:      []string{serverURL},
+		TenantID:      "tenant-1",
+		ClusterID:     "cluster-1",
+		NodeToken:     "token",
+		RetryAttempts: 0,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	core, logs := observer.New(zap.InfoLevel)
+	cm := &ClusterManager{
+		config: &ClusterConfig{Name: "prod", ConfigDir: configDir},
+		client: client,
+		logger: zap.New(core),
+	}
+
+	if !cm.verifyInstalledConfig(context.Background(), bm) {
+		t.Fatal("Expected an intact config to be started")
+	}
+
+	if err := os.WriteFile(filepath.Join(configDir, "config.yml"), []byte("pki: {"), 0600); err != nil {
+		t.Fatalf("Failed to corrupt config.yml: %v", err)
+	}
+	if cm.verifyInstalledConfig(context.Background(), bm) {
+		t.Fatal("Expected a damaged config not to be started")
+	}
+
+	entries := logs.FilterMessageSnippet("control plane is unreachable").All()
+	if len(entries) != 1 || entries[0].Level != zap.ErrorLevel {
+		t.Fatalf("Expected one error about the damaged config and unreachable control plane, got %v", logs.All())
+	}
+}
+
+func TestCheckConfigDirPermissions_MissingDir(t *testing.T) {
+	if err := CheckConfigDirPermissions(filepath.Join(t.TempDir(), "missing")); err != nil {
+		t.Errorf("CheckConfigDirPermissions() error = %v, want nil for missing dir", err)
+	}
+}
+
 // createTestBundle creates a valid tar.gz bundle with the specified files.
 func createTestBundle(t *testing.T, files []string) []byte {
 	var buf bytes.Buffer
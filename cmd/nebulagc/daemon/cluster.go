@@ -2,10 +2,14 @@ package daemon
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/yaroslav/nebulagc/sdk"
 	"go.uber.org/zap"
+	"nebulagc.io/pkg/token"
 )
 
 // ClusterManager manages the lifecycle of a single Nebula cluster instance.
@@ -20,6 +24,10 @@ type ClusterManager struct {
 	// client is the SDK client for this cluster
 	client *sdk.Client
 
+	// credentials is the store holding config.NodeTokenRef (nil if the
+	// token is inline), re-read when the control plane rejects the token
+	credentials CredentialStore
+
 	// logger is the structured logger with cluster context
 	logger *zap.Logger
 
@@ -37,6 +45,18 @@ type ClusterManager struct {
 
 	// healthChecker performs periodic health checks on the control plane
 	healthChecker *HealthChecker
+
+	// versions delivers latest versions from the Manager's VersionBatcher
+	// If nil, the poller checks this cluster's version on its own
+	versions <-chan versionNotice
+
+	// batcher is the VersionBatcher delivering versions, told about rotated
+	// node tokens (nil if versions is nil)
+	batcher *VersionBatcher
+
+	// mu guards currentVersion and the components created by Run,
+	// which the status socket reads from other goroutines
+	mu sync.RWMutex
 }
 
 // Run starts the cluster manager and blocks until context is cancelled.
@@ -59,11 +79,15 @@ func (cm *ClusterManager) Run(ctx context.Context) {
 	}
 
 	// Initialize bundle manager
-	cm.bundleManager = NewBundleManager(cm.config.ConfigDir)
+	bundleManager := NewBundleManager(cm.config.ConfigDir)
+	if err := CheckConfigDirPermissions(cm.config.ConfigDir); err != nil {
+		cm.logger.Warn("Config directory exposes key material; the next bundle install will tighten it",
+			zap.Error(err))
+	}
 
 	// Initialize supervisor
 	configPath := cm.config.ConfigDir + "/config.yml"
-	cm.supervisor = NewSupervisor(SupervisorConfig{
+	supervisor := NewSupervisor(SupervisorConfig{
 		ConfigPath:       configPath,
 		MinBackoff:       1 * time.Second,
 		MaxBackoff:       60 * time.Second,
@@ -71,33 +95,73 @@ func (cm *ClusterManager) Run(ctx context.Context) {
 		Logger:           cm.logger,
 	})
 
+	// Nebula is held back if the installed config is damaged, until the
+	// poller installs a good bundle; supervisor.Run must only be called once
+	var nebulaStarted atomic.Bool
+	startNebula := func() {
+		if !nebulaStarted.CompareAndSwap(false, true) {
+			return
+		}
+		go func() {
+			if err := supervisor.Run(); err != nil {
+				cm.logger.Error("Supervisor error", zap.Error(err))
+			}
+		}()
+	}
+
 	// onUpdate callback that applies bundle and restarts Nebula
 	onUpdate := func(ctx context.Context, data []byte, version int64) error {
 		// First apply the bundle
-		if err := cm.bundleManager.ApplyBundle(ctx, data, version); err != nil {
+		if err := bundleManager.ApplyBundle(ctx, data, version); err != nil {
 			return err
 		}
 
+		if !nebulaStarted.Load() {
+			cm.logger.Info("Starting Nebula with the newly installed config",
+				zap.Int64("version", version))
+			startNebula()
+			return nil
+		}
+
 		// Then restart Nebula to pick up new config
 		cm.logger.Info("Restarting Nebula after config update",
 			zap.Int64("version", version))
-		cm.supervisor.Restart()
+		supervisor.Restart()
 
 		return nil
 	}
 
 	// Initialize poller
-	cm.poller = NewPoller(PollerConfig{
+	poller := NewPoller(PollerConfig{
 		Client:            cm.client,
 		Logger:            cm.logger,
-		Interval:          5 * time.Second,
+		Interval:          cm.config.PollIntervalDuration(),
+		Jitter:            cm.config.PollJitter,
+		MaxBackoff:        cm.config.MaxPollBackoffDuration(),
+		PublicKey:         cm.config.BundlePublicKeyValue(),
 		OnUpdate:          onUpdate,
 		GetCurrentVersion: cm.GetCurrentVersion,
 		SetCurrentVersion: cm.SetCurrentVersion,
+		OnUnauthorized:    cm.reloadNodeToken,
+		Versions:          cm.versions,
 	})
 
 	// Initialize health checker
-	cm.healthChecker = NewHealthChecker(cm.client, cm.logger)
+	healthChecker := NewHealthChecker(cm.client, cm.logger)
+
+	cm.mu.Lock()
+	cm.bundleManager = bundleManager
+	cm.supervisor = supervisor
+	cm.poller = poller
+	cm.healthChecker = healthChecker
+	cm.mu.Unlock()
+
+	// Resume from the installed version so an unchanged bundle is not
+	// downloaded again; a damaged config stays at 0 and is replaced
+	intact := cm.verifyInstalledConfig(ctx, bundleManager)
+	if version := bundleManager.LoadVersion(); intact && version > 0 {
+		cm.SetCurrentVersion(version)
+	}
 
 	// Start config poller in goroutine
 	go cm.poller.Run(ctx)
@@ -105,12 +169,10 @@ func (cm *ClusterManager) Run(ctx context.Context) {
 	// Start health checker in goroutine
 	cm.healthChecker.Start(ctx)
 
-	// Start Nebula process supervisor in goroutine
-	go func() {
-		if err := cm.supervisor.Run(); err != nil {
-			cm.logger.Error("Supervisor error", zap.Error(err))
-		}
-	}()
+	// Start Nebula process supervisor, unless the installed config is damaged
+	if intact {
+		startNebula()
+	}
 
 	// Wait for shutdown signal
 	<-ctx.Done()
@@ -121,9 +183,48 @@ func (cm *ClusterManager) Run(ctx context.Context) {
 	cm.healthChecker.Stop()
 
 	// Gracefully stop Nebula process
-	if err := cm.supervisor.Stop(); err != nil {
-		cm.logger.Error("Error stopping supervisor", zap.Error(err))
+	if nebulaStarted.Load() {
+		if err := cm.supervisor.Stop(); err != nil {
+			cm.logger.Error("Error stopping supervisor", zap.Error(err))
+		}
+	}
+}
+
+// verifyInstalledConfig checks the installed bundle files against their
+// recorded checksums and reports whether Nebula may be started with them.
+//
+// A damaged config is never started: the poller's first successful poll
+// installs a fresh bundle and starts Nebula then. If the control plane cannot
+// be reached, that is logged as an error, since the node has no working
+// Nebula until it can be.
+func (cm *ClusterManager) verifyInstalledConfig(ctx context.Context, bundleManager *BundleManager) bool {
+	version, err := bundleManager.VerifyInstalled()
+	switch {
+	case err == nil:
+		cm.logger.Info("Installed config verified", zap.Int64("version", version))
+		return true
+	case errors.Is(err, ErrNoInstalledManifest):
+		// Nothing installed yet, or installed before manifests were written
+		return true
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	_, healthErr := cm.client.GetLatestVersion(checkCtx)
+	cancel()
+
+	if healthErr != nil {
+		cm.logger.Error("Installed config is damaged and the control plane is unreachable; not starting Nebula until a good bundle can be downloaded",
+			zap.Error(err),
+			zap.NamedError("control_plane_error", healthErr),
+			zap.String("config_dir", cm.config.ConfigDir),
+		)
+	} else {
+		cm.logger.Error("Installed config is damaged; not starting Nebula until a fresh bundle is installed",
+			zap.Error(err),
+			zap.String("config_dir", cm.config.ConfigDir),
+		)
 	}
+	return false
 }
 
 // discoverMaster attempts to discover and cache the control plane master.
@@ -142,29 +243,135 @@ func (cm *ClusterManager) discoverMaster(ctx context.Context) error {
 	return nil
 }
 
+// reloadNodeToken re-reads the node token from the credential store after
+// the control plane rejected the current one, so a token rotated by an admin
+// and written to the store is picked up without restarting the daemon.
+// It is only called from the poller goroutine.
+//
+// Returns:
+//   - bool: True if a different token was loaded and the client and version
+//     batcher now use it
+func (cm *ClusterManager) reloadNodeToken() bool {
+	if cm.credentials == nil || cm.config.NodeTokenRef == "" {
+		return false
+	}
+
+	rotated, err := cm.credentials.Get(cm.config.NodeTokenRef)
+	if err != nil {
+		cm.logger.Warn("Failed to read node token from credential store", zap.Error(err))
+		return false
+	}
+	if rotated == cm.config.NodeToken || len(rotated) < MinTokenLength {
+		return false
+	}
+
+	cm.config.NodeToken = rotated
+	cm.client.SetNodeToken(rotated)
+	if cm.batcher != nil {
+		cm.batcher.UpdateToken(cm.config.ClusterID, rotated)
+	}
+	cm.logger.Info("Loaded rotated node token from credential store",
+		zap.String("ref", cm.config.NodeTokenRef),
+		zap.String("token_fingerprint", token.Fingerprint(rotated)))
+	return true
+}
+
 // GetCurrentVersion returns the currently deployed config bundle version.
 func (cm *ClusterManager) GetCurrentVersion() int64 {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
 	return cm.currentVersion
 }
 
 // SetCurrentVersion updates the tracked config bundle version.
 func (cm *ClusterManager) SetCurrentVersion(version int64) {
+	cm.mu.Lock()
 	cm.currentVersion = version
+	cm.mu.Unlock()
 	cm.logger.Info("Updated config version", zap.Int64("version", version))
 }
 
 // IsDegraded returns true if the cluster is in degraded mode.
 func (cm *ClusterManager) IsDegraded() bool {
-	if cm.healthChecker == nil {
+	cm.mu.RLock()
+	healthChecker := cm.healthChecker
+	cm.mu.RUnlock()
+
+	if healthChecker == nil {
 		return false
 	}
-	return cm.healthChecker.IsDegraded()
+	return healthChecker.IsDegraded()
 }
 
 // GetHealthStatus returns the current health status of the cluster.
 func (cm *ClusterManager) GetHealthStatus() (healthy, total int, lastCheck time.Time) {
-	if cm.healthChecker == nil {
+	cm.mu.RLock()
+	healthChecker := cm.healthChecker
+	cm.mu.RUnlock()
+
+	if healthChecker == nil {
 		return 0, 0, time.Time{}
 	}
-	return cm.healthChecker.GetHealthStatus()
+	return healthChecker.GetHealthStatus()
+}
+
+// TriggerPoll requests an immediate config version check. It does nothing
+// before Run has started the poller.
+func (cm *ClusterManager) TriggerPoll() {
+	cm.mu.RLock()
+	poller := cm.poller
+	cm.mu.RUnlock()
+
+	if poller != nil {
+		poller.Trigger()
+	}
+}
+
+// Status returns a snapshot of the cluster's state for the status socket.
+func (cm *ClusterManager) Status() ClusterStatus {
+	cm.mu.RLock()
+	supervisor := cm.supervisor
+	poller := cm.poller
+	cm.mu.RUnlock()
+
+	status := ClusterStatus{
+		Name:          cm.name,
+		State:         ClusterStateStarting,
+		TenantID:      cm.config.TenantID,
+		ClusterID:     cm.config.ClusterID,
+		NodeID:        cm.config.NodeID,
+		ConfigVersion: cm.GetCurrentVersion(),
+		Degraded:      cm.IsDegraded(),
+	}
+
+	if supervisor != nil {
+		status.State = ClusterStateRunning
+		status.NebulaRunning = supervisor.IsRunning()
+		status.NebulaPID = supervisor.PID()
+	}
+
+	healthy, total, lastCheck := cm.GetHealthStatus()
+	status.HealthyReplicas = healthy
+	status.TotalReplicas = total
+	if !lastCheck.IsZero() {
+		status.LastHealthCheck = &lastCheck
+	}
+
+	if poller != nil {
+		poll := poller.Status()
+		if !poll.LastPollAt.IsZero() {
+			status.LastPoll = &poll.LastPollAt
+		}
+		status.LastPollError = poll.LastError
+		status.TimedOutPolls = poll.TimedOut
+		if poll.TokenRejected {
+			status.Degraded = true
+			status.DegradedReason = DegradedReasonTokenRejected
+		}
+	}
+	if status.Degraded && status.DegradedReason == "" {
+		status.DegradedReason = DegradedReasonUnreachable
+	}
+
+	return status
 }
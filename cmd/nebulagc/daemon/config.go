@@ -1,12 +1,23 @@
 package daemon
 
 import (
+	"bytes"
+	"crypto/ed25519"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"nebulagc.io/pkg/bundle"
+	"nebulagc.io/pkg/token"
 )
 
 // Config file locations
@@ -19,6 +30,20 @@ const (
 
 	// MinTokenLength is the minimum length for authentication tokens (HMAC-SHA256 = 41 chars)
 	MinTokenLength = 41
+
+	// DefaultPollInterval is the config poll interval when poll_interval is not set
+	DefaultPollInterval = 5 * time.Second
+
+	// MinPollInterval is the shortest poll_interval accepted
+	MinPollInterval = 1 * time.Second
+
+	// DefaultMaxPollBackoff is the longest wait between failing polls when
+	// max_poll_backoff is not set
+	DefaultMaxPollBackoff = 5 * time.Minute
+
+	// MaxPollJitter is the largest poll_jitter accepted, in percent, so every
+	// node still waits at least half the poll interval
+	MaxPollJitter = 50
 )
 
 // UUID validation regex (8-4-4-4-12 format)
@@ -27,34 +52,86 @@ var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4
 // DaemonConfig represents the complete daemon configuration.
 type DaemonConfig struct {
 	// ControlPlaneURLs is the list of control plane base URLs for HA support.
-	ControlPlaneURLs []string `json:"control_plane_urls"`
+	ControlPlaneURLs []string `json:"control_plane_urls" yaml:"control_plane_urls"`
 
 	// Clusters is the list of Nebula clusters this daemon manages.
-	Clusters []ClusterConfig `json:"clusters"`
+	Clusters []ClusterConfig `json:"clusters" yaml:"clusters"`
+
+	// CredentialStore is where node_token_ref and cluster_token_ref are looked up
+	// (optional, defaults to the file store at DefaultCredentialStorePath).
+	CredentialStore *CredentialStoreConfig `json:"credential_store,omitempty" yaml:"credential_store,omitempty"`
+
+	// LogFormat is the daemon log format, "json" or "console" (optional, defaults to "json").
+	// The --log-format flag overrides it.
+	LogFormat string `json:"log_format,omitempty" yaml:"log_format,omitempty"`
+}
+
+// String formats the config with every cluster's tokens redacted.
+func (c DaemonConfig) String() string {
+	type plain DaemonConfig // see token.Redact
+	return fmt.Sprintf("%+v", plain(c))
 }
 
 // ClusterConfig represents configuration for a single Nebula cluster.
 type ClusterConfig struct {
 	// Name is a human-readable identifier for this cluster (used in logs).
-	Name string `json:"name"`
+	Name string `json:"name" yaml:"name"`
 
 	// TenantID is the UUID of the tenant this cluster belongs to.
-	TenantID string `json:"tenant_id"`
+	TenantID string `json:"tenant_id" yaml:"tenant_id"`
 
 	// ClusterID is the UUID of the cluster.
-	ClusterID string `json:"cluster_id"`
+	ClusterID string `json:"cluster_id" yaml:"cluster_id"`
 
 	// NodeID is the UUID of this node in the cluster.
-	NodeID string `json:"node_id"`
+	NodeID string `json:"node_id" yaml:"node_id"`
 
 	// NodeToken is the authentication token for node operations.
-	NodeToken string `json:"node_token"`
+	// Leave empty when NodeTokenRef is set.
+	NodeToken string `json:"node_token" yaml:"node_token"`
+
+	// NodeTokenRef is the credential store key holding the node token, used instead of NodeToken.
+	NodeTokenRef string `json:"node_token_ref,omitempty" yaml:"node_token_ref,omitempty"`
 
 	// ClusterToken is the authentication token for cluster operations (optional, for admin nodes).
-	ClusterToken string `json:"cluster_token,omitempty"`
+	ClusterToken string `json:"cluster_token,omitempty" yaml:"cluster_token,omitempty"`
+
+	// ClusterTokenRef is the credential store key holding the cluster token, used instead of ClusterToken.
+	ClusterTokenRef string `json:"cluster_token_ref,omitempty" yaml:"cluster_token_ref,omitempty"`
 
 	// ConfigDir is the directory where Nebula config files will be written.
-	ConfigDir string `json:"config_dir"`
+	ConfigDir string `json:"config_dir" yaml:"config_dir"`
+
+	// PollInterval is how often the control plane is polled for config updates,
+	// as a duration such as "30s" (optional, defaults to DefaultPollInterval).
+	// Not used when the daemon manages several clusters, whose versions are
+	// checked together in one batched request.
+	PollInterval string `json:"poll_interval,omitempty" yaml:"poll_interval,omitempty"`
+
+	// PollJitter randomizes each wait between polls by up to this percentage
+	// of PollInterval in either direction, so nodes do not poll in lockstep
+	// (optional, 0 to MaxPollJitter).
+	PollJitter int `json:"poll_jitter,omitempty" yaml:"poll_jitter,omitempty"`
+
+	// MaxPollBackoff caps the wait between polls while the control plane keeps
+	// failing; the wait doubles with each consecutive failure, starting from
+	// PollInterval (optional, defaults to DefaultMaxPollBackoff). Setting it to
+	// PollInterval disables the backoff.
+	MaxPollBackoff string `json:"max_poll_backoff,omitempty" yaml:"max_poll_backoff,omitempty"`
+
+	// BundlePublicKey is the Ed25519 public key bundles must be signed with,
+	// as base64 of the raw key or a PEM "PUBLIC KEY" block (optional). When
+	// set, unsigned bundles and bundles with a bad signature are not installed.
+	BundlePublicKey string `json:"bundle_public_key,omitempty" yaml:"bundle_public_key,omitempty"`
+}
+
+// String formats the cluster config with its tokens redacted, so it can be
+// logged (for example with zap.Any or %v) without leaking credentials.
+func (c ClusterConfig) String() string {
+	type plain ClusterConfig // see token.Redact
+	c.NodeToken = token.Redact(c.NodeToken)
+	c.ClusterToken = token.Redact(c.ClusterToken)
+	return fmt.Sprintf("%+v", plain(c))
 }
 
 // LoadConfig loads the daemon configuration from disk.
@@ -64,16 +141,25 @@ type ClusterConfig struct {
 //   - *DaemonConfig: The loaded and validated configuration
 //   - error: Configuration loading or validation error
 func LoadConfig() (*DaemonConfig, error) {
-	// Try development config first
+	return loadConfigFromFile(defaultConfigPath())
+}
+
+// defaultConfigPath returns the development config if present, otherwise the
+// production config.
+func defaultConfigPath() string {
 	if _, err := os.Stat(DevelopmentConfigPath); err == nil {
-		return loadConfigFromFile(DevelopmentConfigPath)
+		return DevelopmentConfigPath
 	}
-
-	// Fall back to production config
-	return loadConfigFromFile(ProductionConfigPath)
+	return ProductionConfigPath
 }
 
 // LoadConfigFromPath loads configuration from a specific file path.
+// Files ending in .yml or .yaml are read as YAML, all others as JSON; both
+// formats use the same field names and are validated the same way.
+//
+// String fields may reference environment variables as ${NAME}, e.g.
+// "node_token": "${NEBULAGC_NODE_TOKEN}". Loading fails if a referenced
+// variable is unset.
 //
 // Parameters:
 //   - path: Absolute or relative path to the configuration file
@@ -85,107 +171,498 @@ func LoadConfigFromPath(path string) (*DaemonConfig, error) {
 	return loadConfigFromFile(path)
 }
 
-// loadConfigFromFile reads and parses a JSON configuration file.
+// SkippedCluster is a cluster left out of a partially loaded configuration.
+type SkippedCluster struct {
+	// Name is the cluster name, or its position (e.g., "clusters[2]") if unnamed
+	Name string
+
+	// Err lists the cluster's problems, with field paths relative to the cluster
+	Err error
+}
+
+// clusterFieldRegex splits a field path such as "clusters[1].node_token"
+// into the cluster index and the field within it.
+var clusterFieldRegex = regexp.MustCompile(`^clusters\[(\d+)\]\.(.+)$`)
+
+// LoadConfigPartial loads configuration like LoadConfigFromPath, except that
+// a cluster with invalid settings (including unset environment variables) is
+// left out and reported instead of failing the whole load, so one bad cluster
+// does not stop the daemon from managing the others.
+//
+// Problems outside the clusters, such as invalid control plane URLs, still
+// fail the load, as does a config in which no cluster is valid.
+//
+// Parameters:
+//   - path: Absolute or relative path to the configuration file
+//
+// Returns:
+//   - *DaemonConfig: The configuration with only the valid clusters
+//   - []SkippedCluster: The clusters left out and why
+//   - error: Read, parse, or validation error that affects every cluster
+func LoadConfigPartial(path string) (*DaemonConfig, []SkippedCluster, error) {
+	config, err := readConfigFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var problems ValidationErrors
+	for _, err := range []error{config.expandEnv(os.LookupEnv), config.Validate()} {
+		var errs ValidationErrors
+		if errors.As(err, &errs) {
+			problems = append(problems, errs...)
+		}
+	}
+
+	// Attribute each problem to its cluster; anything else affects all of them
+	clusterProblems := make(map[int]ValidationErrors)
+	for _, problem := range problems {
+		match := clusterFieldRegex.FindStringSubmatch(problem.Field)
+		if match == nil {
+			return nil, nil, fmt.Errorf("config validation failed: %w", problems)
+		}
+		index, _ := strconv.Atoi(match[1])
+		clusterProblems[index] = append(clusterProblems[index], FieldError{Field: match[2], Reason: problem.Reason})
+	}
+
+	var valid []ClusterConfig
+	var skipped []SkippedCluster
+	for i, cluster := range config.Clusters {
+		errs, bad := clusterProblems[i]
+		if !bad {
+			valid = append(valid, cluster)
+			continue
+		}
+		name := cluster.Name
+		if name == "" {
+			name = fmt.Sprintf("clusters[%d]", i)
+		}
+		skipped = append(skipped, SkippedCluster{Name: name, Err: errs})
+	}
+
+	if len(valid) == 0 {
+		return nil, nil, fmt.Errorf("config validation failed: %w", problems)
+	}
+
+	config.Clusters = valid
+	return config, skipped, nil
+}
+
+// loadConfigFromFile reads, parses and validates a configuration file.
 func loadConfigFromFile(path string) (*DaemonConfig, error) {
+	config, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve ${VAR} references so secrets can stay out of the file
+	if err := config.expandEnv(os.LookupEnv); err != nil {
+		return nil, fmt.Errorf("failed to resolve config variables: %w", err)
+	}
+
+	// Validate configuration
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	return config, nil
+}
+
+// readConfigFile reads and parses a configuration file without validating it.
+//
+// Files ending in .yml or .yaml are parsed as YAML; anything else is parsed
+// as JSON, the canonical format for generated configs.
+func readConfigFile(path string) (*DaemonConfig, error) {
 	// Read file
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	// Parse JSON
+	var config *DaemonConfig
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		config, err = parseConfigYAML(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config YAML: %w", err)
+		}
+	default:
+		config, err = parseConfigJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config JSON: %w", err)
+		}
+	}
+
+	return config, nil
+}
+
+// envRefRegex matches ${NAME} environment variable references.
+var envRefRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces ${NAME} references in string fields with the value of
+// the environment variable NAME, as returned by lookup. This lets tokens be
+// injected via systemd EnvironmentFile or a secrets mount instead of being
+// stored in the config file.
+//
+// Returns:
+//   - error: ValidationErrors naming each field that references an unset variable
+func (c *DaemonConfig) expandEnv(lookup func(string) (string, bool)) error {
+	var errs ValidationErrors
+
+	expand := func(field string, value *string) {
+		*value = envRefRegex.ReplaceAllStringFunc(*value, func(ref string) string {
+			name := envRefRegex.FindStringSubmatch(ref)[1]
+			resolved, ok := lookup(name)
+			if !ok {
+				errs.add(field, "references unset environment variable %s", name)
+			}
+			return resolved
+		})
+	}
+
+	for i := range c.ControlPlaneURLs {
+		expand(fmt.Sprintf("control_plane_urls[%d]", i), &c.ControlPlaneURLs[i])
+	}
+
+	for i := range c.Clusters {
+		cluster := &c.Clusters[i]
+		prefix := fmt.Sprintf("clusters[%d].", i)
+		expand(prefix+"name", &cluster.Name)
+		expand(prefix+"tenant_id", &cluster.TenantID)
+		expand(prefix+"cluster_id", &cluster.ClusterID)
+		expand(prefix+"node_id", &cluster.NodeID)
+		expand(prefix+"node_token", &cluster.NodeToken)
+		expand(prefix+"cluster_token", &cluster.ClusterToken)
+		expand(prefix+"node_token_ref", &cluster.NodeTokenRef)
+		expand(prefix+"cluster_token_ref", &cluster.ClusterTokenRef)
+		expand(prefix+"config_dir", &cluster.ConfigDir)
+		expand(prefix+"poll_interval", &cluster.PollInterval)
+		expand(prefix+"max_poll_backoff", &cluster.MaxPollBackoff)
+		expand(prefix+"bundle_public_key", &cluster.BundlePublicKey)
+	}
+
+	return errs.errOrNil()
+}
+
+// parseConfigJSON decodes a JSON config, rejecting unknown fields so typos
+// such as "contorl_plane_urls" are caught. Syntax, type and unknown field
+// errors are reported with the line and column they occur at.
+func parseConfigJSON(data []byte) (*DaemonConfig, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
 	var config DaemonConfig
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config JSON: %w", err)
+	if err := decoder.Decode(&config); err != nil {
+		return nil, jsonErrorWithPosition(data, err)
 	}
 
-	// Validate configuration
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
+	// Reject anything after the config object
+	rest := bytes.TrimLeft(data[decoder.InputOffset():], " \t\r\n")
+	if len(rest) > 0 {
+		line, column := lineColumn(data, len(data)-len(rest))
+		return nil, fmt.Errorf("line %d, column %d: unexpected data after config", line, column)
 	}
 
 	return &config, nil
 }
 
+// parseConfigYAML decodes a YAML config, rejecting unknown fields. YAML
+// errors already include the line they occur at.
+func parseConfigYAML(data []byte) (*DaemonConfig, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	// An empty document leaves the config empty for validation to report
+	var config DaemonConfig
+	if err := decoder.Decode(&config); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// jsonErrorWithPosition prefixes a decode error with the line and column it
+// refers to, when one can be determined.
+func jsonErrorWithPosition(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	offset := -1
+	switch {
+	case errors.As(err, &syntaxErr):
+		// Offset counts the offending byte; point at it rather than past it
+		offset = int(syntaxErr.Offset) - 1
+	case errors.As(err, &typeErr):
+		offset = int(typeErr.Offset) - 1
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// The decoder does not report where unknown fields are; find the key
+		name := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		if loc := regexp.MustCompile(regexp.QuoteMeta(name) + `\s*:`).FindIndex(data); loc != nil {
+			offset = loc[0]
+		}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		offset = len(data)
+	}
+
+	if offset < 0 {
+		return err
+	}
+
+	line, column := lineColumn(data, offset)
+	return fmt.Errorf("line %d, column %d: %w", line, column, err)
+}
+
+// lineColumn converts a byte offset in data to a 1-based line and column.
+func lineColumn(data []byte, offset int) (int, int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+
+	line := 1 + bytes.Count(data[:offset], []byte("\n"))
+	column := offset - bytes.LastIndexByte(data[:offset], '\n')
+	return line, column
+}
+
+// FieldError describes one invalid configuration field.
+type FieldError struct {
+	// Field is the JSON path of the field (e.g., "clusters[0].node_id").
+	Field string
+
+	// Reason describes what is wrong with the field.
+	Reason string
+}
+
+// Error implements the error interface for FieldError.
+func (e FieldError) Error() string {
+	return e.Field + " " + e.Reason
+}
+
+// ValidationErrors lists every problem found in a configuration.
+type ValidationErrors []FieldError
+
+// Error implements the error interface, listing one problem per line.
+func (e ValidationErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	lines := make([]string, len(e))
+	for i, fieldErr := range e {
+		lines[i] = "  - " + fieldErr.Error()
+	}
+	return fmt.Sprintf("%d problems:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// add records a problem with field.
+func (e *ValidationErrors) add(field, format string, args ...interface{}) {
+	*e = append(*e, FieldError{Field: field, Reason: fmt.Sprintf(format, args...)})
+}
+
+// errOrNil returns e as an error, or nil if no problems were recorded.
+func (e ValidationErrors) errOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
 // Validate checks that the daemon configuration is valid.
 //
+// Every problem is reported, not just the first, so a config can be fixed
+// in one pass.
+//
 // Returns:
-//   - error: Validation error describing what is wrong, or nil if valid
+//   - error: ValidationErrors listing each invalid field, or nil if valid
 func (c *DaemonConfig) Validate() error {
+	var errs ValidationErrors
+
 	// Validate control plane URLs
 	if len(c.ControlPlaneURLs) == 0 {
-		return fmt.Errorf("control_plane_urls cannot be empty")
+		errs.add("control_plane_urls", "cannot be empty")
 	}
 
 	for i, urlStr := range c.ControlPlaneURLs {
+		field := fmt.Sprintf("control_plane_urls[%d]", i)
 		if urlStr == "" {
-			return fmt.Errorf("control_plane_urls[%d] is empty", i)
+			errs.add(field, "is empty")
+			continue
 		}
 
 		// Validate URL format
 		if _, err := url.Parse(urlStr); err != nil {
-			return fmt.Errorf("control_plane_urls[%d] is invalid: %w", i, err)
+			errs.add(field, "is invalid: %v", err)
 		}
 	}
 
+	switch c.LogFormat {
+	case "", LogFormatJSON, LogFormatConsole:
+	default:
+		errs.add("log_format", "must be %q or %q", LogFormatJSON, LogFormatConsole)
+	}
+
 	// Validate clusters
 	if len(c.Clusters) == 0 {
-		return fmt.Errorf("clusters cannot be empty")
+		errs.add("clusters", "cannot be empty")
 	}
 
 	for i, cluster := range c.Clusters {
-		if err := cluster.Validate(); err != nil {
-			return fmt.Errorf("clusters[%d] (%s): %w", i, cluster.Name, err)
+		var clusterErrs ValidationErrors
+		if !errors.As(cluster.Validate(), &clusterErrs) {
+			continue
+		}
+		for _, fieldErr := range clusterErrs {
+			errs.add(fmt.Sprintf("clusters[%d].%s", i, fieldErr.Field), "%s", fieldErr.Reason)
 		}
 	}
 
-	return nil
+	return errs.errOrNil()
 }
 
 // Validate checks that the cluster configuration is valid.
 //
 // Returns:
-//   - error: Validation error describing what is wrong, or nil if valid
+//   - error: ValidationErrors listing each invalid field, or nil if valid
 func (c *ClusterConfig) Validate() error {
+	var errs ValidationErrors
+
 	// Validate name
 	if c.Name == "" {
-		return fmt.Errorf("name cannot be empty")
+		errs.add("name", "cannot be empty")
 	}
 
 	// Validate UUIDs
 	if !isValidUUID(c.TenantID) {
-		return fmt.Errorf("tenant_id is not a valid UUID: %s", c.TenantID)
+		errs.add("tenant_id", "is not a valid UUID: %s", c.TenantID)
 	}
 
 	if !isValidUUID(c.ClusterID) {
-		return fmt.Errorf("cluster_id is not a valid UUID: %s", c.ClusterID)
+		errs.add("cluster_id", "is not a valid UUID: %s", c.ClusterID)
 	}
 
 	if !isValidUUID(c.NodeID) {
-		return fmt.Errorf("node_id is not a valid UUID: %s", c.NodeID)
+		errs.add("node_id", "is not a valid UUID: %s", c.NodeID)
 	}
 
-	// Validate tokens
-	if len(c.NodeToken) < MinTokenLength {
-		return fmt.Errorf("node_token is too short (minimum %d characters, got %d)", MinTokenLength, len(c.NodeToken))
+	// Validate tokens; a token held in the credential store is checked when resolved
+	if c.NodeTokenRef != "" {
+		if c.NodeToken != "" {
+			errs.add("node_token", "cannot be set together with node_token_ref")
+		}
+	} else if len(c.NodeToken) < MinTokenLength {
+		errs.add("node_token", "is too short (minimum %d characters, got %d)", MinTokenLength, len(c.NodeToken))
 	}
 
 	// Cluster token is optional, but if provided must be valid
-	if c.ClusterToken != "" && len(c.ClusterToken) < MinTokenLength {
-		return fmt.Errorf("cluster_token is too short (minimum %d characters, got %d)", MinTokenLength, len(c.ClusterToken))
+	if c.ClusterTokenRef != "" {
+		if c.ClusterToken != "" {
+			errs.add("cluster_token", "cannot be set together with cluster_token_ref")
+		}
+	} else if c.ClusterToken != "" && len(c.ClusterToken) < MinTokenLength {
+		errs.add("cluster_token", "is too short (minimum %d characters, got %d)", MinTokenLength, len(c.ClusterToken))
 	}
 
 	// Validate config directory
 	if c.ConfigDir == "" {
-		return fmt.Errorf("config_dir cannot be empty")
+		errs.add("config_dir", "cannot be empty")
+	} else if !filepath.IsAbs(c.ConfigDir) {
+		// Check if config directory is an absolute path
+		errs.add("config_dir", "must be an absolute path: %s", c.ConfigDir)
+	}
+
+	// Validate polling
+	if c.PollInterval != "" {
+		interval, err := time.ParseDuration(c.PollInterval)
+		if err != nil {
+			errs.add("poll_interval", "is not a valid duration: %s", c.PollInterval)
+		} else if interval < MinPollInterval {
+			errs.add("poll_interval", "must be at least %s, got %s", MinPollInterval, interval)
+		}
+	}
+
+	if c.MaxPollBackoff != "" {
+		maxBackoff, err := time.ParseDuration(c.MaxPollBackoff)
+		if err != nil {
+			errs.add("max_poll_backoff", "is not a valid duration: %s", c.MaxPollBackoff)
+		} else if interval := c.PollIntervalDuration(); maxBackoff < interval {
+			errs.add("max_poll_backoff", "must be at least the poll interval (%s), got %s", interval, maxBackoff)
+		}
+	}
+
+	if c.PollJitter < 0 || c.PollJitter > MaxPollJitter {
+		errs.add("poll_jitter", "must be between 0 and %d percent, got %d", MaxPollJitter, c.PollJitter)
+	}
+
+	if c.BundlePublicKey != "" {
+		if _, err := bundle.ParsePublicKey(c.BundlePublicKey); err != nil {
+			errs.add("bundle_public_key", "%v", err)
+		}
+	}
+
+	return errs.errOrNil()
+}
+
+// PollIntervalDuration returns PollInterval as a duration, or
+// DefaultPollInterval if it is not set. The config must have been validated.
+func (c *ClusterConfig) PollIntervalDuration() time.Duration {
+	interval, err := time.ParseDuration(c.PollInterval)
+	if err != nil {
+		return DefaultPollInterval
 	}
+	return interval
+}
+
+// MaxPollBackoffDuration returns MaxPollBackoff as a duration, or
+// DefaultMaxPollBackoff if it is not set. The config must have been validated.
+func (c *ClusterConfig) MaxPollBackoffDuration() time.Duration {
+	maxBackoff, err := time.ParseDuration(c.MaxPollBackoff)
+	if err != nil {
+		return DefaultMaxPollBackoff
+	}
+	return maxBackoff
+}
 
-	// Check if config directory is an absolute path
-	if !filepath.IsAbs(c.ConfigDir) {
-		return fmt.Errorf("config_dir must be an absolute path: %s", c.ConfigDir)
+// BundlePublicKeyValue returns the parsed BundlePublicKey, or nil if bundle
+// signatures are not checked. The config must have been validated.
+func (c *ClusterConfig) BundlePublicKeyValue() ed25519.PublicKey {
+	key, err := bundle.ParsePublicKey(c.BundlePublicKey)
+	if err != nil {
+		return nil
 	}
+	return key
+}
 
-	return nil
+// UsesCredentialStore reports whether any token is held in the credential store.
+func (c *ClusterConfig) UsesCredentialStore() bool {
+	return c.NodeTokenRef != "" || c.ClusterTokenRef != ""
+}
+
+// ResolveCredentials fills NodeToken and ClusterToken from the credential
+// store for the tokens configured by reference.
+//
+// Parameters:
+//   - store: Credential store holding the referenced tokens
+//
+// Returns:
+//   - error: Error if a referenced token is missing, unreadable or too short
+func (c *ClusterConfig) ResolveCredentials(store CredentialStore) error {
+	resolve := func(field, ref string, token *string) error {
+		if ref == "" {
+			return nil
+		}
+		value, err := store.Get(ref)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+		if len(value) < MinTokenLength {
+			return fmt.Errorf("%s: stored token %s is too short (minimum %d characters, got %d)", field, ref, MinTokenLength, len(value))
+		}
+		*token = value
+		return nil
+	}
+
+	if err := resolve("node_token_ref", c.NodeTokenRef, &c.NodeToken); err != nil {
+		return err
+	}
+	return resolve("cluster_token_ref", c.ClusterTokenRef, &c.ClusterToken)
 }
 
 // isValidUUID checks if a string matches the UUID format (8-4-4-4-12).
@@ -1,10 +1,19 @@
 package daemon
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/yaroslav/nebulagc/sdk"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
 )
 
 func TestDaemonConfig_Validate(t *testing.T) {
@@ -132,6 +141,42 @@ func TestDaemonConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "console log format",
+			config: DaemonConfig{
+				ControlPlaneURLs: []string{"https://control1.example.com"},
+				Clusters: []ClusterConfig{
+					{
+						Name:      "test-cluster",
+						TenantID:  "12345678-1234-1234-1234-123456789012",
+						ClusterID: "87654321-4321-4321-4321-210987654321",
+						NodeID:    "abcdef12-3456-7890-abcd-ef1234567890",
+						NodeToken: "12345678901234567890123456789012345678901",
+						ConfigDir: "/etc/nebula/test",
+					},
+				},
+				LogFormat: LogFormatConsole,
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown log format",
+			config: DaemonConfig{
+				ControlPlaneURLs: []string{"https://control1.example.com"},
+				Clusters: []ClusterConfig{
+					{
+						Name:      "test-cluster",
+						TenantID:  "12345678-1234-1234-1234-123456789012",
+						ClusterID: "87654321-4321-4321-4321-210987654321",
+						NodeID:    "abcdef12-3456-7890-abcd-ef1234567890",
+						NodeToken: "12345678901234567890123456789012345678901",
+						ConfigDir: "/etc/nebula/test",
+					},
+				},
+				LogFormat: "logfmt",
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -259,6 +304,139 @@ func TestClusterConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid poll interval and jitter",
+			config: ClusterConfig{
+				Name:         "test-cluster",
+				TenantID:     "12345678-1234-1234-1234-123456789012",
+				ClusterID:    "87654321-4321-4321-4321-210987654321",
+				NodeID:       "abcdef12-3456-7890-abcd-ef1234567890",
+				NodeToken:    "12345678901234567890123456789012345678901",
+				ConfigDir:    "/etc/nebula/test",
+				PollInterval: "30s",
+				PollJitter:   20,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid poll interval",
+			config: ClusterConfig{
+				Name:         "test-cluster",
+				TenantID:     "12345678-1234-1234-1234-123456789012",
+				ClusterID:    "87654321-4321-4321-4321-210987654321",
+				NodeID:       "abcdef12-3456-7890-abcd-ef1234567890",
+				NodeToken:    "12345678901234567890123456789012345678901",
+				ConfigDir:    "/etc/nebula/test",
+				PollInterval: "often",
+			},
+			wantErr: true,
+		},
+		{
+			name: "poll interval too short",
+			config: ClusterConfig{
+				Name:         "test-cluster",
+				TenantID:     "12345678-1234-1234-1234-123456789012",
+				ClusterID:    "87654321-4321-4321-4321-210987654321",
+				NodeID:       "abcdef12-3456-7890-abcd-ef1234567890",
+				NodeToken:    "12345678901234567890123456789012345678901",
+				ConfigDir:    "/etc/nebula/test",
+				PollInterval: "100ms",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative poll jitter",
+			config: ClusterConfig{
+				Name:       "test-cluster",
+				TenantID:   "12345678-1234-1234-1234-123456789012",
+				ClusterID:  "87654321-4321-4321-4321-210987654321",
+				NodeID:     "abcdef12-3456-7890-abcd-ef1234567890",
+				NodeToken:  "12345678901234567890123456789012345678901",
+				ConfigDir:  "/etc/nebula/test",
+				PollJitter: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "poll jitter too large",
+			config: ClusterConfig{
+				Name:       "test-cluster",
+				TenantID:   "12345678-1234-1234-1234-123456789012",
+				ClusterID:  "87654321-4321-4321-4321-210987654321",
+				NodeID:     "abcdef12-3456-7890-abcd-ef1234567890",
+				NodeToken:  "12345678901234567890123456789012345678901",
+				ConfigDir:  "/etc/nebula/test",
+				PollJitter: MaxPollJitter + 1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid bundle public key",
+			config: ClusterConfig{
+				Name:            "test-cluster",
+				TenantID:        "12345678-1234-1234-1234-123456789012",
+				ClusterID:       "87654321-4321-4321-4321-210987654321",
+				NodeID:          "abcdef12-3456-7890-abcd-ef1234567890",
+				NodeToken:       "12345678901234567890123456789012345678901",
+				ConfigDir:       "/etc/nebula/test",
+				BundlePublicKey: "11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo=",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid bundle public key",
+			config: ClusterConfig{
+				Name:            "test-cluster",
+				TenantID:        "12345678-1234-1234-1234-123456789012",
+				ClusterID:       "87654321-4321-4321-4321-210987654321",
+				NodeID:          "abcdef12-3456-7890-abcd-ef1234567890",
+				NodeToken:       "12345678901234567890123456789012345678901",
+				ConfigDir:       "/etc/nebula/test",
+				BundlePublicKey: "c2hvcnQ=",
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid max poll backoff",
+			config: ClusterConfig{
+				Name:           "test-cluster",
+				TenantID:       "12345678-1234-1234-1234-123456789012",
+				ClusterID:      "87654321-4321-4321-4321-210987654321",
+				NodeID:         "abcdef12-3456-7890-abcd-ef1234567890",
+				NodeToken:      "12345678901234567890123456789012345678901",
+				ConfigDir:      "/etc/nebula/test",
+				PollInterval:   "10s",
+				MaxPollBackoff: "10m",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid max poll backoff",
+			config: ClusterConfig{
+				Name:           "test-cluster",
+				TenantID:       "12345678-1234-1234-1234-123456789012",
+				ClusterID:      "87654321-4321-4321-4321-210987654321",
+				NodeID:         "abcdef12-3456-7890-abcd-ef1234567890",
+				NodeToken:      "12345678901234567890123456789012345678901",
+				ConfigDir:      "/etc/nebula/test",
+				MaxPollBackoff: "forever",
+			},
+			wantErr: true,
+		},
+		{
+			name: "max poll backoff below poll interval",
+			config: ClusterConfig{
+				Name:           "test-cluster",
+				TenantID:       "12345678-1234-1234-1234-123456789012",
+				ClusterID:      "87654321-4321-4321-4321-210987654321",
+				NodeID:         "abcdef12-3456-7890-abcd-ef1234567890",
+				NodeToken:      "12345678901234567890123456789012345678901",
+				ConfigDir:      "/etc/nebula/test",
+				PollInterval:   "30s",
+				MaxPollBackoff: "10s",
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -271,6 +449,59 @@ func TestClusterConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestDaemonConfig_ValidateReportsAllErrors(t *testing.T) {
+	config := DaemonConfig{
+		ControlPlaneURLs: []string{""},
+		Clusters: []ClusterConfig{
+			{
+				Name:      "ok",
+				TenantID:  "12345678-1234-1234-1234-123456789012",
+				ClusterID: "87654321-4321-4321-4321-210987654321",
+				NodeID:    "abcdef12-3456-7890-abcd-ef1234567890",
+				NodeToken: "12345678901234567890123456789012345678901",
+				ConfigDir: "/etc/nebula/ok",
+			},
+			{
+				TenantID:  "12345678-1234-1234-1234-123456789012",
+				ClusterID: "not-a-uuid",
+				NodeID:    "abcdef12-3456-7890-abcd-ef1234567890",
+				NodeToken: "short",
+				ConfigDir: "relative/path",
+			},
+		},
+	}
+
+	err := config.Validate()
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Validate() error = %v, want ValidationErrors", err)
+	}
+
+	want := []string{
+		"control_plane_urls[0]",
+		"clusters[1].name",
+		"clusters[1].cluster_id",
+		"clusters[1].node_token",
+		"clusters[1].config_dir",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Validate() reported %d problems, want %d:\n%v", len(errs), len(want), err)
+	}
+	for i, field := range want {
+		if errs[i].Field != field {
+			t.Errorf("problem %d field = %q, want %q", i, errs[i].Field, field)
+		}
+	}
+
+	// Every problem is listed in the message
+	for _, field := range want {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("error message missing %q:\n%v", field, err)
+		}
+	}
+}
+
 func TestLoadConfig(t *testing.T) {
 	// Create temporary directory for test configs
 	tempDir := t.TempDir()
@@ -361,6 +592,223 @@ func TestLoadConfig(t *testing.T) {
 	})
 }
 
+func TestLoadConfig_JSONErrorPositions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "unknown field",
+			content: `{
+  "contorl_plane_urls": ["https://control1.example.com"],
+  "clusters": []
+}`,
+			want: []string{"line 2, column 3", `unknown field "contorl_plane_urls"`},
+		},
+		{
+			name: "unknown nested field",
+			content: `{
+  "control_plane_urls": ["https://control1.example.com"],
+  "clusters": [
+    {"name": "prod", "node_tokn": "x"}
+  ]
+}`,
+			want: []string{"line 4, column 22", `unknown field "node_tokn"`},
+		},
+		{
+			name: "trailing comma",
+			content: `{
+  "control_plane_urls": ["https://control1.example.com"],
+  "clusters": [],
+}`,
+			want: []string{"line 4, column 1", "invalid character '}'"},
+		},
+		{
+			name:    "wrong type",
+			content: "{\n  \"clusters\": \"prod\"\n}",
+			want:    []string{"line 2", "cannot unmarshal string"},
+		},
+		{
+			name:    "truncated",
+			content: "{\n  \"clusters\": [",
+			want:    []string{"line 2, column 16", "unexpected EOF"},
+		},
+		{
+			name:    "data after config",
+			content: "{}\n{}",
+			want:    []string{"line 2, column 1", "unexpected data after config"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			_, err := LoadConfigFromPath(path)
+			if err == nil {
+				t.Fatal("LoadConfigFromPath() expected error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("LoadConfigFromPath() error = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfig_RoundTrip(t *testing.T) {
+	config := DaemonConfig{
+		ControlPlaneURLs: []string{"https://control1.example.com", "https://control2.example.com"},
+		Clusters: []ClusterConfig{
+			{
+				Name:         "prod",
+				TenantID:     "12345678-1234-1234-1234-123456789012",
+				ClusterID:    "87654321-4321-4321-4321-210987654321",
+				NodeID:       "abcdef12-3456-7890-abcd-ef1234567890",
+				NodeToken:    "12345678901234567890123456789012345678901",
+				ClusterToken: "abcdefghijabcdefghijabcdefghijabcdefghijk",
+				ConfigDir:    "/etc/nebula/prod",
+			},
+		},
+	}
+
+	tests := []struct {
+		file    string
+		marshal func(interface{}) ([]byte, error)
+	}{
+		{"config.json", json.Marshal},
+		{"config.yaml", yaml.Marshal},
+		{"config.YML", yaml.Marshal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := tt.marshal(config)
+			if err != nil {
+				t.Fatalf("Failed to marshal config: %v", err)
+			}
+
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			loaded, err := LoadConfigFromPath(path)
+			if err != nil {
+				t.Fatalf("LoadConfigFromPath() error = %v", err)
+			}
+			if !reflect.DeepEqual(*loaded, config) {
+				t.Errorf("LoadConfigFromPath() = %+v, want %+v", *loaded, config)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_YAMLErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "unknown field",
+			content: "contorl_plane_urls:\n  - https://control1.example.com\n",
+			want:    "line 1: field contorl_plane_urls not found",
+		},
+		{
+			name:    "validation error",
+			content: "control_plane_urls: []\nclusters: []\n",
+			want:    "control_plane_urls cannot be empty",
+		},
+		{
+			name:    "empty file",
+			content: "",
+			want:    "clusters cannot be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			_, err := LoadConfigFromPath(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadConfigFromPath() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_EnvInterpolation(t *testing.T) {
+	content := `{
+  "control_plane_urls": ["https://${NEBULAGC_TEST_CP_HOST}"],
+  "clusters": [
+    {
+      "name": "prod",
+      "tenant_id": "12345678-1234-1234-1234-123456789012",
+      "cluster_id": "87654321-4321-4321-4321-210987654321",
+      "node_id": "abcdef12-3456-7890-abcd-ef1234567890",
+      "node_token": "${NEBULAGC_TEST_NODE_TOKEN}",
+      "cluster_token": "${NEBULAGC_TEST_CLUSTER_TOKEN}",
+      "config_dir": "/etc/nebula/prod"
+    }
+  ]
+}`
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	nodeToken := "12345678901234567890123456789012345678901"
+	clusterToken := "abcdefghijabcdefghijabcdefghijabcdefghijk"
+
+	t.Run("set variables", func(t *testing.T) {
+		t.Setenv("NEBULAGC_TEST_CP_HOST", "control1.example.com")
+		t.Setenv("NEBULAGC_TEST_NODE_TOKEN", nodeToken)
+		t.Setenv("NEBULAGC_TEST_CLUSTER_TOKEN", clusterToken)
+
+		config, err := LoadConfigFromPath(path)
+		if err != nil {
+			t.Fatalf("LoadConfigFromPath() error = %v", err)
+		}
+		if config.ControlPlaneURLs[0] != "https://control1.example.com" {
+			t.Errorf("ControlPlaneURLs[0] = %q", config.ControlPlaneURLs[0])
+		}
+		if config.Clusters[0].NodeToken != nodeToken || config.Clusters[0].ClusterToken != clusterToken {
+			t.Errorf("Tokens not resolved: %+v", config.Clusters[0])
+		}
+	})
+
+	t.Run("unset variables", func(t *testing.T) {
+		t.Setenv("NEBULAGC_TEST_CP_HOST", "control1.example.com")
+		for _, name := range []string{"NEBULAGC_TEST_NODE_TOKEN", "NEBULAGC_TEST_CLUSTER_TOKEN"} {
+			t.Setenv(name, "") // restores the variable after the test
+			os.Unsetenv(name)
+		}
+
+		_, err := LoadConfigFromPath(path)
+		if err == nil {
+			t.Fatal("LoadConfigFromPath() expected error for unset variables")
+		}
+		for _, want := range []string{
+			"clusters[0].node_token references unset environment variable NEBULAGC_TEST_NODE_TOKEN",
+			"clusters[0].cluster_token references unset environment variable NEBULAGC_TEST_CLUSTER_TOKEN",
+		} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("LoadConfigFromPath() error = %q, want it to contain %q", err, want)
+			}
+		}
+	})
+}
+
 func TestIsValidUUID(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -387,3 +835,62 @@ func TestIsValidUUID(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_LoggingRedactsTokens(t *testing.T) {
+	const nodeToken = "node-token-0123456789abcdefghijklmnopqrstuvwxyz"
+	const clusterToken = "cluster-token-0123456789abcdefghijklmnopqrstuvwxyz"
+
+	cluster := ClusterConfig{
+		Name:         "prod",
+		TenantID:     "12345678-1234-1234-1234-123456789012",
+		ClusterID:    "87654321-4321-4321-4321-210987654321",
+		NodeID:       "abcdef12-3456-7890-abcd-ef1234567890",
+		NodeToken:    nodeToken,
+		ClusterToken: clusterToken,
+		ConfigDir:    "/etc/nebula/prod",
+	}
+	config := DaemonConfig{
+		ControlPlaneURLs: []string{"https://control1.example.com"},
+		Clusters:         []ClusterConfig{cluster},
+	}
+	clientConfig := sdk.ClientConfig{
+		BaseURLs:     config.ControlPlaneURLs,
+		TenantID:     cluster.TenantID,
+		ClusterID:    cluster.ClusterID,
+		NodeToken:    nodeToken,
+		ClusterToken: clusterToken,
+	}
+	client, err := sdk.NewClient(clientConfig)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	for _, format := range []string{LogFormatJSON, LogFormatConsole} {
+		var encoder zapcore.Encoder
+		if format == LogFormatJSON {
+			encoder = zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+		} else {
+			encoder = zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
+		}
+		logger := zap.New(zapcore.NewCore(encoder, zapcore.AddSync(&buf), zap.DebugLevel))
+
+		logger.Info("config",
+			zap.Any("daemon", config),
+			zap.Any("daemon_ptr", &config),
+			zap.Any("cluster", cluster),
+			zap.Any("client_config", clientConfig),
+			zap.Any("client", client),
+			zap.Any("credentials", sdk.NodeCredentials{NodeID: cluster.NodeID, NodeToken: nodeToken}),
+			zap.Stringer("cluster_stringer", cluster))
+		logger.Sugar().Infof("config %v %+v", config, cluster)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, nodeToken) || strings.Contains(out, clusterToken) {
+		t.Fatalf("Logged output leaked a token:\n%s", out)
+	}
+	if !strings.Contains(out, "***") {
+		t.Fatalf("Logged output has no redacted tokens:\n%s", out)
+	}
+}
@@ -0,0 +1,247 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DefaultCredentialStorePath is where the file credential store keeps tokens
+// when credential_store.path is not set.
+const DefaultCredentialStorePath = "/etc/nebulagc/credentials.json"
+
+// credentialFileMode is the permission mode of the credential store file.
+const credentialFileMode os.FileMode = 0600
+
+// Credential store backends. Only the file backend is built in; the others
+// are names reserved for OS keychain backends, which register themselves
+// with RegisterCredentialBackend when compiled in.
+const (
+	// CredentialBackendFile stores tokens in a JSON file readable only by its owner
+	CredentialBackendFile = "file"
+
+	// CredentialBackendKeychain is the macOS Keychain
+	CredentialBackendKeychain = "keychain"
+
+	// CredentialBackendSecretService is the freedesktop Secret Service (GNOME Keyring, KWallet)
+	CredentialBackendSecretService = "secret-service"
+
+	// CredentialBackendWindows is the Windows Credential Manager
+	CredentialBackendWindows = "wincred"
+)
+
+// ErrCredentialNotFound is returned by CredentialStore.Get for an unknown key.
+var ErrCredentialNotFound = errors.New("credential not found")
+
+// CredentialStore holds tokens outside the daemon config, so the config can
+// refer to a token by key (node_token_ref, cluster_token_ref) instead of
+// embedding it.
+type CredentialStore interface {
+	// Get returns the token stored under key, or ErrCredentialNotFound.
+	Get(key string) (string, error)
+
+	// Set stores token under key, replacing any previous value.
+	Set(key, token string) error
+}
+
+// CredentialStoreConfig selects and configures the credential store.
+type CredentialStoreConfig struct {
+	// Backend is the store to use (optional, defaults to "file").
+	Backend string `json:"backend,omitempty" yaml:"backend,omitempty"`
+
+	// Path is the file backend's token file (optional, defaults to DefaultCredentialStorePath).
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+}
+
+// CredentialBackendFactory opens a credential store from its configuration.
+type CredentialBackendFactory func(config CredentialStoreConfig) (CredentialStore, error)
+
+var (
+	credentialBackendsMu sync.RWMutex
+	credentialBackends   = map[string]CredentialBackendFactory{
+		CredentialBackendFile: func(config CredentialStoreConfig) (CredentialStore, error) {
+			path := config.Path
+			if path == "" {
+				path = DefaultCredentialStorePath
+			}
+			return NewFileCredentialStore(path), nil
+		},
+	}
+)
+
+// RegisterCredentialBackend makes a credential store backend available under
+// name, replacing any backend already registered with that name. OS keychain
+// integrations call it from an init function.
+//
+// Parameters:
+//   - name: Backend name used in credential_store.backend
+//   - factory: Opens the store
+func RegisterCredentialBackend(name string, factory CredentialBackendFactory) {
+	credentialBackendsMu.Lock()
+	defer credentialBackendsMu.Unlock()
+	credentialBackends[name] = factory
+}
+
+// OpenCredentialStore opens the credential store described by config.
+//
+// Parameters:
+//   - config: Store configuration (nil selects the default file store)
+//
+// Returns:
+//   - CredentialStore: The opened store
+//   - error: Error if the backend is not available in this build or fails to open
+func OpenCredentialStore(config *CredentialStoreConfig) (CredentialStore, error) {
+	var cfg CredentialStoreConfig
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.Backend == "" {
+		cfg.Backend = CredentialBackendFile
+	}
+
+	credentialBackendsMu.RLock()
+	factory, ok := credentialBackends[cfg.Backend]
+	credentialBackendsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("credential backend %q is not available in this build", cfg.Backend)
+	}
+
+	store, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s credential store: %w", cfg.Backend, err)
+	}
+	return store, nil
+}
+
+// FileCredentialStore keeps tokens in a JSON object file mapping keys to
+// tokens. The file is written with mode 0600 and refused if it is readable
+// by group or others.
+type FileCredentialStore struct {
+	// path is the token file
+	path string
+
+	// mu serializes access to the file, so Set's read-modify-write is atomic
+	mu sync.Mutex
+}
+
+// NewFileCredentialStore creates a file-backed store. The file is created by
+// the first Set.
+func NewFileCredentialStore(path string) *FileCredentialStore {
+	return &FileCredentialStore{path: path}
+}
+
+// Get returns the token stored under key.
+//
+// Parameters:
+//   - key: Credential key
+//
+// Returns:
+//   - string: The token
+//   - error: ErrCredentialNotFound if the key (or the file) does not exist,
+//     or an error if the file is unreadable or too permissive
+func (s *FileCredentialStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return "", err
+	}
+
+	token, ok := tokens[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrCredentialNotFound, key)
+	}
+	return token, nil
+}
+
+// Set stores token under key. The file is replaced atomically, so a crash
+// leaves either the old or the new tokens.
+//
+// Parameters:
+//   - key: Credential key
+//   - token: Token to store
+//
+// Returns:
+//   - error: Error if the file cannot be read or written
+func (s *FileCredentialStore) Set(key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+	tokens[key] = token
+
+	return s.write(tokens)
+}
+
+// read loads the token file; a missing file is an empty store.
+func (s *FileCredentialStore) read() (map[string]string, error) {
+	info, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		return make(map[string]string), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat credential store: %w", err)
+	}
+	if mode := info.Mode().Perm(); mode&0077 != 0 {
+		return nil, fmt.Errorf("credential store %s permissions too open: %04o (must not be accessible by group or others)", s.path, mode)
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credential store: %w", err)
+	}
+
+	tokens := make(map[string]string)
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &tokens); err != nil {
+			return nil, fmt.Errorf("failed to parse credential store %s: %w", s.path, err)
+		}
+	}
+	return tokens, nil
+}
+
+// write replaces the token file with tokens.
+func (s *FileCredentialStore) write(tokens map[string]string) error {
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode credential store: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0750); err != nil {
+		return fmt.Errorf("failed to create credential store directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp.*")
+	if err != nil {
+		return fmt.Errorf("failed to create credential store: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op after a successful rename
+
+	if err := tmp.Chmod(credentialFileMode); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set credential store permissions: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write credential store: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync credential store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write credential store: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace credential store: %w", err)
+	}
+	return nil
+}
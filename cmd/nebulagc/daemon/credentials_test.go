@@ -0,0 +1,162 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const storedNodeToken = "stored-node-token-0123456789abcdefghijklmnop"
+
+func TestFileCredentialStore_SetGet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets", "credentials.json")
+	store := NewFileCredentialStore(path)
+
+	if _, err := store.Get("prod/node"); !errors.Is(err, ErrCredentialNotFound) {
+		t.Fatalf("Get() on missing file error = %v, want ErrCredentialNotFound", err)
+	}
+
+	if err := store.Set("prod/node", storedNodeToken); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := store.Set("prod/cluster", "other"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	token, err := store.Get("prod/node")
+	if err != nil || token != storedNodeToken {
+		t.Fatalf("Get() = %q, %v; want stored token", token, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("Credential file mode = %04o, want 0600", mode)
+	}
+
+	// A fresh store reads what the first one wrote
+	if token, err := NewFileCredentialStore(path).Get("prod/cluster"); err != nil || token != "other" {
+		t.Errorf("Get() from new store = %q, %v; want %q", token, err, "other")
+	}
+}
+
+func TestFileCredentialStore_RejectsOpenPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	data, _ := json.Marshal(map[string]string{"prod/node": storedNodeToken})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write credential file: %v", err)
+	}
+
+	_, err := NewFileCredentialStore(path).Get("prod/node")
+	if err == nil || !strings.Contains(err.Error(), "permissions too open") {
+		t.Fatalf("Get() error = %v, want permissions error", err)
+	}
+}
+
+func TestOpenCredentialStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	store, err := OpenCredentialStore(&CredentialStoreConfig{Path: path})
+	if err != nil {
+		t.Fatalf("OpenCredentialStore() error = %v", err)
+	}
+	if _, ok := store.(*FileCredentialStore); !ok {
+		t.Errorf("OpenCredentialStore() = %T, want *FileCredentialStore", store)
+	}
+
+	if _, err := OpenCredentialStore(&CredentialStoreConfig{Backend: CredentialBackendKeychain}); err == nil {
+		t.Error("OpenCredentialStore() expected error for a backend not in this build")
+	}
+
+	RegisterCredentialBackend("test-backend", func(CredentialStoreConfig) (CredentialStore, error) {
+		return NewFileCredentialStore(path), nil
+	})
+	if _, err := OpenCredentialStore(&CredentialStoreConfig{Backend: "test-backend"}); err != nil {
+		t.Errorf("OpenCredentialStore() registered backend error = %v", err)
+	}
+}
+
+func TestInitialize_ResolvesTokenRefs(t *testing.T) {
+	tempDir := t.TempDir()
+	storePath := filepath.Join(tempDir, "credentials.json")
+	if err := NewFileCredentialStore(storePath).Set("good/node", storedNodeToken); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	config := DaemonConfig{
+		ControlPlaneURLs: []string{"https://control1.example.com"},
+		CredentialStore:  &CredentialStoreConfig{Path: storePath},
+		Clusters: []ClusterConfig{
+			{
+				Name:         "good",
+				TenantID:     "12345678-1234-1234-1234-123456789012",
+				ClusterID:    "87654321-4321-4321-4321-210987654321",
+				NodeID:       "abcdef12-3456-7890-abcd-ef1234567890",
+				NodeTokenRef: "good/node",
+				ConfigDir:    filepath.Join(tempDir, "good"),
+			},
+			{
+				Name:         "missing",
+				TenantID:     "22345678-1234-1234-1234-123456789012",
+				ClusterID:    "97654321-4321-4321-4321-210987654321",
+				NodeID:       "bbcdef12-3456-7890-abcd-ef1234567890",
+				NodeTokenRef: "missing/node",
+				ConfigDir:    filepath.Join(tempDir, "missing"),
+			},
+		},
+	}
+	configPath := filepath.Join(tempDir, "config.json")
+	configData, _ := json.Marshal(config)
+	if err := os.WriteFile(configPath, configData, 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	d, err := Initialize(configPath)
+	if err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	client, err := d.GetClient("good")
+	if err != nil {
+		t.Fatalf("GetClient() error = %v", err)
+	}
+	if client.NodeToken != storedNodeToken {
+		t.Errorf("Client node token was not resolved from the store")
+	}
+	if err := d.Skipped["missing"]; !errors.Is(err, ErrCredentialNotFound) {
+		t.Errorf("Skipped[missing] = %v, want ErrCredentialNotFound", err)
+	}
+
+	// A rotated token is written back to the store
+	const rotated = "rotated-node-token-0123456789abcdefghijklmno"
+	stored, err := d.StoreNodeToken("good", rotated)
+	if err != nil || !stored {
+		t.Fatalf("StoreNodeToken() = %v, %v; want stored", stored, err)
+	}
+	if token, _ := NewFileCredentialStore(storePath).Get("good/node"); token != rotated {
+		t.Errorf("Stored token after rotation = %q, want rotated token", token)
+	}
+}
+
+func TestClusterConfig_ValidateTokenRef(t *testing.T) {
+	cluster := ClusterConfig{
+		Name:         "prod",
+		TenantID:     "12345678-1234-1234-1234-123456789012",
+		ClusterID:    "87654321-4321-4321-4321-210987654321",
+		NodeID:       "abcdef12-3456-7890-abcd-ef1234567890",
+		NodeTokenRef: "prod/node",
+		ConfigDir:    "/etc/nebula/prod",
+	}
+	if err := cluster.Validate(); err != nil {
+		t.Errorf("Validate() with node_token_ref error = %v", err)
+	}
+
+	cluster.NodeToken = storedNodeToken
+	if err := cluster.Validate(); err == nil || !strings.Contains(err.Error(), "node_token_ref") {
+		t.Errorf("Validate() with both node_token and node_token_ref error = %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"nebulagc.io/pkg/bundle"
+)
+
+// dryRunTimeout bounds the download of a cluster's bundle in a dry run.
+const dryRunTimeout = 60 * time.Second
+
+// DryRunReport describes what the daemon would do for a cluster.
+type DryRunReport struct {
+	// Cluster is the cluster name
+	Cluster string
+
+	// Version is the latest config version the node would install
+	Version int64
+
+	// InstalledVersion is the version currently installed (0 if none or unknown)
+	InstalledVersion int64
+
+	// Files lists the files the bundle would write, sorted by name
+	Files []string
+
+	// Size is the total uncompressed size of the bundle in bytes
+	Size int64
+
+	// UpToDate is true if the installed config is intact and already at Version
+	UpToDate bool
+}
+
+// DryRun downloads the cluster's latest bundle, verifies its signature if
+// the cluster has a bundle public key, validates it with the same checks the
+// control plane applies on upload, and writes its files into a temporary
+// directory that is removed afterwards. The installed config is
+// never touched and Nebula is never started or restarted.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//
+// Returns:
+//   - *DryRunReport: What installing the bundle would do
+//   - error: Error if the bundle cannot be downloaded, is not signed by the
+//     configured key, is invalid or cannot be written
+func (cm *ClusterManager) DryRun(ctx context.Context) (*DryRunReport, error) {
+	downloadCtx, cancel := context.WithTimeout(ctx, dryRunTimeout)
+	signed, err := cm.client.DownloadSignedBundle(downloadCtx, 0)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to download bundle: %w", err)
+	}
+	data, version := signed.Data, signed.Version
+
+	// The poller refuses bundles not signed by the configured key, so must a dry run
+	if publicKey := cm.config.BundlePublicKeyValue(); publicKey != nil {
+		if err := bundle.Verify(publicKey, data, signed.Signature); err != nil {
+			return nil, fmt.Errorf("bundle version %d signature verification failed: %w", version, err)
+		}
+	}
+
+	result := bundle.Validate(data)
+	if !result.Valid {
+		return nil, fmt.Errorf("bundle version %d is invalid: %w", version, result.Error)
+	}
+
+	// Write the files exactly as an install would, but out of the way
+	tempDir, err := os.MkdirTemp("", "nebulagc-dry-run-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	bundleManager := NewBundleManager(cm.config.ConfigDir)
+	if err := bundleManager.extractBundle(data, tempDir); err != nil {
+		return nil, fmt.Errorf("failed to extract bundle: %w", err)
+	}
+	if err := bundleManager.verifyExtractedFiles(tempDir); err != nil {
+		return nil, fmt.Errorf("extracted files verification failed: %w", err)
+	}
+
+	report := &DryRunReport{
+		Cluster: cm.name,
+		Version: version,
+		Files:   append([]string(nil), result.Files...),
+		Size:    result.Size,
+	}
+	sort.Strings(report.Files)
+
+	// A damaged or manifest-less install is replaced like any other
+	installed, err := bundleManager.VerifyInstalled()
+	if err != nil && !errors.Is(err, ErrNoInstalledManifest) {
+		cm.logger.Warn("Installed config failed verification", zap.Error(err))
+	}
+	report.InstalledVersion = installed
+	report.UpToDate = err == nil && installed == version
+
+	cm.logger.Info("Dry run: bundle is valid",
+		zap.Int64("version", report.Version),
+		zap.Int64("installed_version", report.InstalledVersion),
+		zap.Strings("files", report.Files),
+		zap.Int64("size_bytes", report.Size),
+		zap.Bool("up_to_date", report.UpToDate),
+	)
+
+	return report, nil
+}
+
+// DryRun runs a dry run for every configured cluster instead of starting the
+// daemon (see ClusterManager.DryRun). Clusters are checked one after another
+// in name order; a failing cluster does not stop the others from being
+// checked. Clusters skipped during initialization count as failed.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//
+// Returns:
+//   - []*DryRunReport: Reports for the clusters whose bundle is valid
+//   - error: Error naming how many clusters failed, or nil if all passed
+func (m *Manager) DryRun(ctx context.Context) ([]*DryRunReport, error) {
+	names := make([]string, 0, len(m.clusters))
+	for name := range m.clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	failed := len(m.daemon.Skipped)
+	for name, err := range m.daemon.Skipped {
+		m.logger.Error("Dry run: cluster failed to initialize",
+			zap.String("cluster", name), zap.Error(err))
+	}
+
+	var reports []*DryRunReport
+	for _, name := range names {
+		clusterMgr := m.clusters[name]
+		report, err := clusterMgr.DryRun(ctx)
+		if err != nil {
+			clusterMgr.logger.Error("Dry run failed", zap.Error(err))
+			failed++
+			continue
+		}
+		reports = append(reports, report)
+	}
+
+	if failed > 0 {
+		return reports, fmt.Errorf("dry run failed for %d of %d clusters", failed, len(m.clusters)+len(m.daemon.Skipped))
+	}
+	return reports, nil
+}
@@ -0,0 +1,142 @@
+package daemon
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"nebulagc.io/pkg/bundle"
+)
+
+// createNebulaBundle creates a bundle whose config.yml references the bundled
+// PKI files, so it passes the control plane's validation.
+func createNebulaBundle(t *testing.T) []byte {
+	var buf bytes.Buffer
+	gzWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzWriter)
+
+	for _, name := range bundle.RequiredFiles {
+		content := []byte("test content for " + name)
+		if name == "config.yml" {
+			content = []byte("pki:\n  ca: ca.crt\n  cert: host.crt\n  key: host.key\n")
+		}
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if _, err := tarWriter.Write(content); err != nil {
+			t.Fatalf("Failed to write tar content: %v", err)
+		}
+	}
+
+	tarWriter.Close()
+	gzWriter.Close()
+	return buf.Bytes()
+}
+
+func newDryRunClusterManager(t *testing.T, serverURL, configDir string) *ClusterManager {
+	return &ClusterManager{
+		name:   "prod",
+		config: &ClusterConfig{Name: "prod", ConfigDir: configDir},
+		client: newSelfTestClient(t, serverURL, "node-1"),
+		logger: zap.NewNop(),
+	}
+}
+
+func TestClusterManager_DryRun(t *testing.T) {
+	server := newSelfTestServer(t, createNebulaBundle(t))
+	defer server.Close()
+
+	configDir := filepath.Join(t.TempDir(), "config")
+	cm := newDryRunClusterManager(t, server.URL, configDir)
+
+	report, err := cm.DryRun(context.Background())
+	if err != nil {
+		t.Fatalf("DryRun() error = %v", err)
+	}
+	if report.Version != 3 || report.InstalledVersion != 0 || report.UpToDate {
+		t.Errorf("DryRun() = %+v, want version 3 over nothing installed", report)
+	}
+	if len(report.Files) != len(bundle.RequiredFiles) || report.Files[0] != "ca.crt" {
+		t.Errorf("DryRun() files = %v, want the required files sorted", report.Files)
+	}
+
+	// Nothing may be installed
+	if _, err := os.Stat(configDir); !os.IsNotExist(err) {
+		t.Errorf("Expected the config dir to be left alone, stat error = %v", err)
+	}
+
+	// Once the same version is installed, the report says so
+	if err := NewBundleManager(configDir).ApplyBundle(context.Background(), createNebulaBundle(t), 3); err != nil {
+		t.Fatalf("ApplyBundle() error = %v", err)
+	}
+	report, err = cm.DryRun(context.Background())
+	if err != nil {
+		t.Fatalf("DryRun() error = %v", err)
+	}
+	if !report.UpToDate || report.InstalledVersion != 3 {
+		t.Errorf("DryRun() = %+v, want version 3 already installed", report)
+	}
+}
+
+func TestClusterManager_DryRunInvalidBundle(t *testing.T) {
+	// config.yml without a pki section passes the daemon's own checks only
+	server := newSelfTestServer(t, createTestBundle(t, bundle.RequiredFiles))
+	defer server.Close()
+
+	cm := newDryRunClusterManager(t, server.URL, filepath.Join(t.TempDir(), "config"))
+
+	_, err := cm.DryRun(context.Background())
+	if !errors.Is(err, bundle.ErrInvalidConfigYAML) {
+		t.Errorf("DryRun() error = %v, want ErrInvalidConfigYAML", err)
+	}
+}
+
+func TestClusterManager_DryRunSignature(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	data := createNebulaBundle(t)
+	signature := ""
+
+	unsigned := newSelfTestServer(t, data)
+	defer unsigned.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if signature != "" {
+			w.Header().Set("X-Config-Signature", signature)
+		}
+		unsigned.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	cm := newDryRunClusterManager(t, server.URL, filepath.Join(t.TempDir(), "config"))
+	cm.config.BundlePublicKey = base64.StdEncoding.EncodeToString(publicKey)
+
+	// An unsigned bundle fails like it would in the poller
+	if _, err := cm.DryRun(context.Background()); !errors.Is(err, bundle.ErrUnsignedBundle) {
+		t.Errorf("DryRun() error = %v, want ErrUnsignedBundle", err)
+	}
+
+	// So does one signed by another key
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+	signature = base64.StdEncoding.EncodeToString(bundle.Sign(otherKey, data))
+	if _, err := cm.DryRun(context.Background()); !errors.Is(err, bundle.ErrInvalidSignature) {
+		t.Errorf("DryRun() error = %v, want ErrInvalidSignature", err)
+	}
+
+	signature = base64.StdEncoding.EncodeToString(bundle.Sign(privateKey, data))
+	if _, err := cm.DryRun(context.Background()); err != nil {
+		t.Errorf("DryRun() error = %v for a correctly signed bundle", err)
+	}
+}
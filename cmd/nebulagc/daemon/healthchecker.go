@@ -8,18 +8,24 @@ import (
 
 	"github.com/yaroslav/nebulagc/sdk"
 	"go.uber.org/zap"
+	"nebulagc.io/pkg/clock"
 )
 
 // HealthCheckInterval is the duration between health checks.
 const HealthCheckInterval = 60 * time.Second
 
+// HealthCheckTimeout bounds each control plane call made by a health check.
+const HealthCheckTimeout = 10 * time.Second
+
 // HealthChecker performs periodic health checks on control plane instances
 // and manages degraded mode state for a cluster.
 type HealthChecker struct {
-	client  *sdk.Client
-	logger  *zap.Logger
-	closeCh chan struct{}
-	wg      sync.WaitGroup
+	client     *sdk.Client
+	logger     *zap.Logger
+	clock      clock.Clock // Time source for heartbeat staleness and check timestamps
+	closeCh    chan struct{}
+	wg         sync.WaitGroup
+	configured []string // Base URLs from the configuration, kept as a fallback
 
 	mu              sync.RWMutex
 	isDegraded      bool
@@ -29,11 +35,14 @@ type HealthChecker struct {
 }
 
 // NewHealthChecker creates a new health checker for a control plane client.
+// Healthy replicas found by the checks are added to the client's base URLs.
 func NewHealthChecker(client *sdk.Client, logger *zap.Logger) *HealthChecker {
 	return &HealthChecker{
-		client:  client,
-		logger:  logger,
-		closeCh: make(chan struct{}),
+		client:     client,
+		logger:     logger,
+		clock:      clock.Real{},
+		closeCh:    make(chan struct{}),
+		configured: client.BaseURLList(),
 	}
 }
 
@@ -93,6 +102,9 @@ func (h *HealthChecker) run(ctx context.Context) {
 func (h *HealthChecker) performHealthCheck(ctx context.Context) {
 	h.logger.Debug("Performing health check")
 
+	ctx, cancel := context.WithTimeout(ctx, HealthCheckTimeout)
+	defer cancel()
+
 	// Try to discover master first
 	if err := h.client.DiscoverMaster(ctx); err != nil {
 		h.logger.Warn("Failed to discover master during health check",
@@ -112,7 +124,7 @@ func (h *HealthChecker) performHealthCheck(ctx context.Context) {
 	}
 
 	// Count healthy replicas
-	healthy := 0
+	var healthyReplicas []sdk.ReplicaInfo
 	total := len(replicas)
 	var masterFound bool
 
@@ -120,11 +132,12 @@ func (h *HealthChecker) performHealthCheck(ctx context.Context) {
 		if replica.IsMaster {
 			masterFound = true
 		}
-		// Check if replica is healthy (recent heartbeat)
-		if time.Since(replica.LastHeartbeat) < 2*HealthCheckInterval {
-			healthy++
+		if h.replicaHealthy(replica) {
+			healthyReplicas = append(healthyReplicas, replica)
 		}
 	}
+	healthy := len(healthyReplicas)
+	h.useReplicas(healthyReplicas)
 
 	if !masterFound {
 		h.logger.Warn("No master found in replica list")
@@ -146,6 +159,16 @@ func (h *HealthChecker) performHealthCheck(ctx context.Context) {
 		zap.Int("total_replicas", total))
 }
 
+// replicaHealthy reports whether a replica is healthy. The control plane's
+// verdict is used when it reports one; otherwise the replica is healthy if
+// its last heartbeat is within two health check intervals.
+func (h *HealthChecker) replicaHealthy(replica sdk.ReplicaInfo) bool {
+	if replica.Healthy != nil {
+		return *replica.Healthy
+	}
+	return h.clock.Now().Sub(replica.LastHeartbeat) < 2*HealthCheckInterval
+}
+
 // setDegraded updates the degraded mode state and logs state changes.
 func (h *HealthChecker) setDegraded(degraded bool, healthy, total int) {
 	h.mu.Lock()
@@ -156,7 +179,7 @@ func (h *HealthChecker) setDegraded(degraded bool, healthy, total int) {
 	h.isDegraded = degraded
 	h.healthyReplicas = healthy
 	h.totalReplicas = total
-	h.lastHealthCheck = time.Now()
+	h.lastHealthCheck = h.clock.Now()
 
 	// Log state changes
 	if degraded && !wasDegraded {
@@ -171,7 +194,31 @@ func (h *HealthChecker) setDegraded(degraded bool, healthy, total int) {
 	}
 }
 
-// RefreshReplicas forces an immediate refresh of the replica list.
+// useReplicas points the client at the healthy replicas, master first,
+// followed by the configured URLs so an instance that is unreachable at its
+// advertised address can still be reached the configured way.
+func (h *HealthChecker) useReplicas(healthy []sdk.ReplicaInfo) {
+	urls := sdk.ReplicaURLs(healthy)
+	if len(urls) == 0 {
+		return
+	}
+	seen := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		seen[url] = true
+	}
+	for _, url := range h.configured {
+		if !seen[url] {
+			urls = append(urls, url)
+		}
+	}
+
+	if err := h.client.SetBaseURLs(urls); err != nil {
+		h.logger.Warn("Ignoring replica URLs", zap.Strings("urls", urls), zap.Error(err))
+	}
+}
+
+// RefreshReplicas forces an immediate refresh of the replica list and points
+// the client at the healthy replicas (see useReplicas).
 // This can be called when connection errors occur to try to find healthy instances.
 func (h *HealthChecker) RefreshReplicas(ctx context.Context) error {
 	h.logger.Info("Forcing replica list refresh")
@@ -181,26 +228,26 @@ func (h *HealthChecker) RefreshReplicas(ctx context.Context) error {
 		return fmt.Errorf("failed to refresh replica list: %w", err)
 	}
 
-	// Extract URLs from replicas
-	var urls []string
+	// Extract URLs from replicas, including every address a replica is reachable at
+	var healthy []sdk.ReplicaInfo
 	for _, replica := range replicas {
 		// Only include healthy replicas
-		if time.Since(replica.LastHeartbeat) < 2*HealthCheckInterval {
-			urls = append(urls, replica.URL)
+		if h.replicaHealthy(replica) {
+			healthy = append(healthy, replica)
 		}
 	}
+	urls := sdk.ReplicaURLs(healthy)
 
 	if len(urls) == 0 {
 		return fmt.Errorf("no healthy replicas found")
 	}
 
 	h.logger.Info("Replica list refreshed",
-		zap.Int("healthy_count", len(urls)),
-		zap.Int("total_count", len(replicas)))
+		zap.Int("healthy_count", len(healthy)),
+		zap.Int("total_count", len(replicas)),
+		zap.Strings("urls", urls))
 
-	// Note: We don't update client.BaseURLs here because the client
-	// is immutable after creation. The daemon should recreate the client
-	// if it needs to use a different set of URLs.
+	h.useReplicas(healthy)
 
 	return nil
 }
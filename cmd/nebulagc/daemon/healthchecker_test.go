@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/yaroslav/nebulagc/sdk"
 	"go.uber.org/zap"
+	"nebulagc.io/pkg/clock"
 )
 
 func TestHealthChecker_Start_Stop(t *testing.T) {
@@ -289,12 +292,22 @@ func TestHealthChecker_RefreshReplicas(t *testing.T) {
 				Replicas []sdk.ReplicaInfo `json:"replicas"`
 			}{
 				Replicas: []sdk.ReplicaInfo{
+					{
+						InstanceID:    "replica-2",
+						URL:           "http://replica-2.example:8080",
+						LastHeartbeat: time.Now(),
+					},
 					{
 						InstanceID:    "replica-1",
 						URL:           serverURL,
 						IsMaster:      true,
 						LastHeartbeat: time.Now(),
 					},
+					{
+						InstanceID:    "replica-3",
+						URL:           "http://replica-3.example:8080",
+						LastHeartbeat: time.Now().Add(-time.Hour),
+					},
 				},
 			}
 			json.NewEncoder(w).Encode(resp)
@@ -307,7 +320,7 @@ func TestHealthChecker_RefreshReplicas(t *testing.T) {
 
 	// Create client
 	client, err := sdk.NewClient(sdk.ClientConfig{
-		BaseURLs:      []string{server.URL},
+		BaseURLs:      []string{server.URL, "http://seed.example:8080"},
 		TenantID:      "tenant-1",
 		ClusterID:     "cluster-1",
 		ClusterToken:  "test-token",
@@ -328,6 +341,12 @@ func TestHealthChecker_RefreshReplicas(t *testing.T) {
 	if err != nil {
 		t.Errorf("RefreshReplicas() unexpected error = %v", err)
 	}
+
+	// Healthy replicas are used master first, then the configured URLs
+	want := []string{server.URL, "http://replica-2.example:8080", "http://seed.example:8080"}
+	if got := client.BaseURLList(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BaseURLList() = %v, want %v", got, want)
+	}
 }
 
 func TestHealthChecker_NoHealthyReplicas(t *testing.T) {
@@ -396,3 +415,108 @@ func TestHealthChecker_NoHealthyReplicas(t *testing.T) {
 		t.Errorf("Expected 1 total replica, got %d", total)
 	}
 }
+
+func TestHealthChecker_TrustsServerHealth(t *testing.T) {
+	// The heartbeat looks stale to a node whose clock runs ahead, but the
+	// control plane judges it healthy against its own clock and threshold
+	heartbeat := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	var unhealthy atomic.Bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/check-master" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		healthy := !unhealthy.Load()
+		resp := struct {
+			Replicas []sdk.ReplicaInfo `json:"replicas"`
+		}{
+			Replicas: []sdk.ReplicaInfo{
+				{InstanceID: "replica-1", URL: "https://cp1.example.com", IsMaster: true,
+					LastHeartbeat: heartbeat, HeartbeatAge: 5 * time.Second, Healthy: &healthy},
+			},
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client, err := sdk.NewClient(sdk.ClientConfig{
+		BaseURLs:     []string{server.URL},
+		TenantID:     "tenant-1",
+		ClusterID:    "cluster-1",
+		ClusterToken: "test-token",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	hc := NewHealthChecker(client, zap.NewNop())
+	fake := clock.NewFake(heartbeat.Add(time.Hour))
+	hc.clock = fake
+
+	hc.performHealthCheck(context.Background())
+	if hc.IsDegraded() {
+		t.Fatal("Health checker should not be degraded while the control plane reports the replica healthy")
+	}
+	if _, _, lastCheck := hc.GetHealthStatus(); !lastCheck.Equal(fake.Now()) {
+		t.Errorf("Expected last check at %v, got %v", fake.Now(), lastCheck)
+	}
+
+	// Once the control plane reports it unhealthy, the cluster is degraded
+	unhealthy.Store(true)
+	hc.performHealthCheck(context.Background())
+	if !hc.IsDegraded() {
+		t.Error("Health checker should be degraded once the control plane reports the replica unhealthy")
+	}
+}
+
+func TestHealthChecker_StalenessWithFakeClock(t *testing.T) {
+	// The control plane reports no health, so the daemon judges staleness
+	heartbeat := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/check-master" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		resp := struct {
+			Replicas []sdk.ReplicaInfo `json:"replicas"`
+		}{
+			Replicas: []sdk.ReplicaInfo{
+				{InstanceID: "replica-1", URL: "https://cp1.example.com", IsMaster: true, LastHeartbeat: heartbeat},
+			},
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client, err := sdk.NewClient(sdk.ClientConfig{
+		BaseURLs:     []string{server.URL},
+		TenantID:     "tenant-1",
+		ClusterID:    "cluster-1",
+		ClusterToken: "test-token",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	hc := NewHealthChecker(client, zap.NewNop())
+	fake := clock.NewFake(heartbeat.Add(2*HealthCheckInterval - time.Second))
+	hc.clock = fake
+
+	// Just inside the staleness window the replica still counts as healthy
+	hc.performHealthCheck(context.Background())
+	if hc.IsDegraded() {
+		t.Fatal("Health checker should not be degraded before the heartbeat goes stale")
+	}
+	if _, _, lastCheck := hc.GetHealthStatus(); !lastCheck.Equal(fake.Now()) {
+		t.Errorf("Expected last check at %v, got %v", fake.Now(), lastCheck)
+	}
+
+	// One second later the heartbeat is stale
+	fake.Advance(time.Second)
+	hc.performHealthCheck(context.Background())
+	if !hc.IsDegraded() {
+		t.Error("Health checker should be degraded once the heartbeat is stale")
+	}
+}
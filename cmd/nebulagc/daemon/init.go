@@ -14,14 +14,26 @@ type Daemon struct {
 
 	// Clients maps cluster names to their SDK clients.
 	Clients map[string]*sdk.Client
+
+	// Skipped maps the names of clusters that could not be set up to the reason.
+	// They are left out of Config and Clients.
+	Skipped map[string]error
+
+	// Credentials is the credential store for clusters that reference their
+	// tokens (nil if no cluster does).
+	Credentials CredentialStore
 }
 
 // Initialize creates and initializes a new daemon instance from configuration.
 //
 // This function:
 //  1. Loads the daemon configuration
-//  2. Creates SDK clients for each cluster
-//  3. Validates connectivity to control plane
+//  2. Resolves tokens held in the credential store
+//  3. Creates SDK clients for each cluster
+//
+// A cluster with invalid settings, unresolvable tokens, or whose client
+// cannot be created, is recorded in Skipped rather than failing
+// initialization; only a config with no usable cluster is an error.
 //
 // Parameters:
 //   - configPath: Optional path to config file (uses default locations if empty)
@@ -31,15 +43,11 @@ type Daemon struct {
 //   - error: Initialization error
 func Initialize(configPath string) (*Daemon, error) {
 	// Load configuration
-	var config *DaemonConfig
-	var err error
-
-	if configPath != "" {
-		config, err = LoadConfigFromPath(configPath)
-	} else {
-		config, err = LoadConfig()
+	if configPath == "" {
+		configPath = defaultConfigPath()
 	}
 
+	config, skipped, err := LoadConfigPartial(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
@@ -48,16 +56,48 @@ func Initialize(configPath string) (*Daemon, error) {
 	daemon := &Daemon{
 		Config:  config,
 		Clients: make(map[string]*sdk.Client),
+		Skipped: make(map[string]error),
+	}
+	for _, cluster := range skipped {
+		daemon.Skipped[cluster.Name] = fmt.Errorf("invalid cluster config: %w", cluster.Err)
+	}
+
+	// The store is only opened when a cluster needs it
+	var storeErr error
+	for _, clusterConfig := range config.Clusters {
+		if clusterConfig.UsesCredentialStore() {
+			daemon.Credentials, storeErr = OpenCredentialStore(config.CredentialStore)
+			break
+		}
 	}
 
 	// Initialize SDK client for each cluster
+	usable := config.Clusters[:0]
 	for _, clusterConfig := range config.Clusters {
+		if clusterConfig.UsesCredentialStore() {
+			if storeErr != nil {
+				daemon.Skipped[clusterConfig.Name] = storeErr
+				continue
+			}
+			if err := clusterConfig.ResolveCredentials(daemon.Credentials); err != nil {
+				daemon.Skipped[clusterConfig.Name] = fmt.Errorf("failed to resolve credentials: %w", err)
+				continue
+			}
+		}
+
 		client, err := createSDKClient(config.ControlPlaneURLs, clusterConfig)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create SDK client for cluster %s: %w", clusterConfig.Name, err)
+			daemon.Skipped[clusterConfig.Name] = err
+			continue
 		}
 
 		daemon.Clients[clusterConfig.Name] = client
+		usable = append(usable, clusterConfig)
+	}
+	config.Clusters = usable
+
+	if len(config.Clusters) == 0 {
+		return nil, fmt.Errorf("no usable clusters: %d skipped", len(daemon.Skipped))
 	}
 
 	return daemon, nil
@@ -98,6 +138,9 @@ func createSDKClient(controlPlaneURLs []string, clusterConfig ClusterConfig) (*s
 func (d *Daemon) GetClient(clusterName string) (*sdk.Client, error) {
 	client, ok := d.Clients[clusterName]
 	if !ok {
+		if err, skipped := d.Skipped[clusterName]; skipped {
+			return nil, fmt.Errorf("cluster %s is unusable: %w", clusterName, err)
+		}
 		return nil, fmt.Errorf("cluster %s not found", clusterName)
 	}
 	return client, nil
@@ -120,6 +163,34 @@ func (d *Daemon) GetClusterConfig(clusterName string) (*ClusterConfig, error) {
 	return nil, fmt.Errorf("cluster %s not found", clusterName)
 }
 
+// StoreNodeToken records a rotated node token for a cluster. When the
+// cluster references its node token in the credential store, the new token
+// is written back to the store; an inline node_token cannot be rewritten, so
+// the caller must tell the operator to update the config file.
+//
+// Parameters:
+//   - clusterName: Name of the cluster (from config)
+//   - token: The new node token
+//
+// Returns:
+//   - bool: True if the token was persisted to the credential store
+//   - error: Error if the cluster is unknown or the store cannot be written
+func (d *Daemon) StoreNodeToken(clusterName, token string) (bool, error) {
+	clusterConfig, err := d.GetClusterConfig(clusterName)
+	if err != nil {
+		return false, err
+	}
+	clusterConfig.NodeToken = token
+
+	if clusterConfig.NodeTokenRef == "" {
+		return false, nil
+	}
+	if err := d.Credentials.Set(clusterConfig.NodeTokenRef, token); err != nil {
+		return false, fmt.Errorf("failed to store rotated token: %w", err)
+	}
+	return true, nil
+}
+
 // ClusterNames returns the list of all configured cluster names.
 func (d *Daemon) ClusterNames() []string {
 	names := make([]string, len(d.Config.Clusters))
@@ -0,0 +1,44 @@
+package daemon
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Log formats accepted by NewLogger and the log_format config setting.
+const (
+	// LogFormatJSON writes one JSON object per line, for log aggregation
+	LogFormatJSON = "json"
+
+	// LogFormatConsole writes human-readable, colored lines
+	LogFormatConsole = "console"
+)
+
+// NewLogger creates the daemon logger. The same logger is handed to the
+// supervisors, so Nebula's own output is written in the chosen format too.
+//
+// Parameters:
+//   - format: LogFormatJSON or LogFormatConsole
+//
+// Returns:
+//   - *zap.Logger: The logger
+//   - error: Error if the format is unknown or the logger cannot be built
+func NewLogger(format string) (*zap.Logger, error) {
+	var config zap.Config
+
+	switch format {
+	case LogFormatConsole:
+		config = zap.NewDevelopmentConfig()
+		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	case LogFormatJSON:
+		config = zap.NewProductionConfig()
+		config.EncoderConfig.TimeKey = "timestamp"
+		config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	default:
+		return nil, fmt.Errorf("unknown log format %q: must be %q or %q", format, LogFormatJSON, LogFormatConsole)
+	}
+
+	return config.Build()
+}
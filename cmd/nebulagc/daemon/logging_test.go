@@ -0,0 +1,18 @@
+package daemon
+
+import "testing"
+
+func TestNewLogger(t *testing.T) {
+	for _, format := range []string{LogFormatJSON, LogFormatConsole} {
+		logger, err := NewLogger(format)
+		if err != nil {
+			t.Errorf("NewLogger(%q) error = %v", format, err)
+			continue
+		}
+		logger.Sync()
+	}
+
+	if _, err := NewLogger("logfmt"); err == nil {
+		t.Error("NewLogger() expected error for unknown format")
+	}
+}
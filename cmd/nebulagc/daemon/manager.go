@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"sort"
 	"sync"
 	"syscall"
 	"time"
@@ -12,6 +13,10 @@ import (
 	"go.uber.org/zap"
 )
 
+// reloadDebounce is the minimum time between forced config checks, so
+// repeated SIGUSR2 signals or reload requests do not stampede the control plane.
+const reloadDebounce = 2 * time.Second
+
 // Manager coordinates the lifecycle of multiple Nebula cluster instances.
 // It spawns a ClusterManager for each configured cluster and handles
 // graceful shutdown on signals or errors.
@@ -28,11 +33,30 @@ type Manager struct {
 	// clusters maps cluster names to their managers
 	clusters map[string]*ClusterManager
 
+	// batcher checks all cluster versions in one request per interval
+	// Nil when only one cluster is managed
+	batcher *VersionBatcher
+
 	// wg tracks running cluster manager goroutines
 	wg sync.WaitGroup
 
 	// cancel is called to signal shutdown to all cluster managers
 	cancel context.CancelFunc
+
+	// statusSocket is the Unix socket path for status queries (empty disables it)
+	statusSocket string
+
+	// statusServer serves Status on statusSocket while the daemon runs
+	statusServer *StatusServer
+
+	// startedAt is when Run was called
+	startedAt time.Time
+
+	// reloadMu guards lastReload
+	reloadMu sync.Mutex
+
+	// lastReload is when Reload last triggered the cluster managers
+	lastReload time.Time
 }
 
 // ManagerConfig holds configuration for the Manager.
@@ -46,6 +70,10 @@ type ManagerConfig struct {
 	// ShutdownTimeout is the maximum time to wait for graceful shutdown
 	// Default: 30 seconds
 	ShutdownTimeout time.Duration
+
+	// StatusSocket is the Unix socket path served for `nebulagc status`
+	// Empty disables the status socket
+	StatusSocket string
 }
 
 // NewManager creates a new daemon manager.
@@ -80,18 +108,31 @@ func NewManager(config ManagerConfig) (*Manager, error) {
 		logger:          logger,
 		shutdownTimeout: shutdownTimeout,
 		clusters:        make(map[string]*ClusterManager),
+		statusSocket:    config.StatusSocket,
+	}
+
+	// Collapse per-cluster version polls into one batched request
+	clusterNames := daemon.ClusterNames()
+	if len(clusterNames) > 1 {
+		client, _ := daemon.GetClient(clusterNames[0])
+		manager.batcher = NewVersionBatcher(client, logger, 5*time.Second)
 	}
 
 	// Create cluster managers
-	for _, clusterName := range daemon.ClusterNames() {
+	for _, clusterName := range clusterNames {
 		clusterConfig, _ := daemon.GetClusterConfig(clusterName)
 		client, _ := daemon.GetClient(clusterName)
 
 		clusterManager := &ClusterManager{
-			name:   clusterName,
-			config: clusterConfig,
-			client: client,
-			logger: logger.With(zap.String("cluster", clusterName)),
+			name:        clusterName,
+			config:      clusterConfig,
+			client:      client,
+			credentials: daemon.Credentials,
+			logger:      logger.With(zap.String("cluster", clusterName)),
+		}
+		if manager.batcher != nil {
+			clusterManager.versions = manager.batcher.Register(clusterConfig)
+			clusterManager.batcher = manager.batcher
 		}
 
 		manager.clusters[clusterName] = clusterManager
@@ -106,14 +147,41 @@ func NewManager(config ManagerConfig) (*Manager, error) {
 // Returns:
 //   - error: Error during startup or shutdown
 func (m *Manager) Run() error {
+	m.start()
+
+	// Wait for shutdown signal
+	m.waitForSignal()
+
+	// Shutdown gracefully
+	return m.Shutdown()
+}
+
+// start launches the batcher, every cluster manager and the status socket.
+// Clusters skipped during initialization are logged and reported as failed
+// in the status; the others start normally.
+func (m *Manager) start() {
 	m.logger.Info("Starting NebulaGC daemon",
 		zap.Int("clusters", len(m.clusters)),
 		zap.Strings("cluster_names", m.daemon.ClusterNames()),
 	)
+	for name, err := range m.daemon.Skipped {
+		m.logger.Error("Skipping cluster that failed to initialize",
+			zap.String("cluster", name), zap.Error(err))
+	}
 
 	// Create cancellable context for all cluster managers
 	ctx, cancel := context.WithCancel(context.Background())
 	m.cancel = cancel
+	m.startedAt = time.Now()
+
+	// Start the shared version batcher
+	if m.batcher != nil {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			m.batcher.Run(ctx)
+		}()
+	}
 
 	// Start each cluster manager in a goroutine
 	for name, clusterMgr := range m.clusters {
@@ -125,11 +193,15 @@ func (m *Manager) Run() error {
 		m.logger.Info("Started cluster manager", zap.String("cluster", name))
 	}
 
-	// Wait for shutdown signal
-	m.waitForSignal()
-
-	// Shutdown gracefully
-	return m.Shutdown()
+	// The status socket is a convenience; the daemon runs without it
+	if m.statusSocket != "" {
+		server := NewStatusServer(m.statusSocket, m.Status, m.Reload, m.logger)
+		if err := server.Start(); err != nil {
+			m.logger.Warn("Status socket unavailable", zap.Error(err))
+		} else {
+			m.statusServer = server
+		}
+	}
 }
 
 // Shutdown gracefully stops all cluster managers.
@@ -139,6 +211,14 @@ func (m *Manager) Run() error {
 func (m *Manager) Shutdown() error {
 	m.logger.Info("Shutting down daemon", zap.Duration("timeout", m.shutdownTimeout))
 
+	if m.statusServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := m.statusServer.Stop(ctx); err != nil {
+			m.logger.Warn("Error stopping status socket", zap.Error(err))
+		}
+		cancel()
+	}
+
 	// Cancel context to signal all cluster managers to stop
 	if m.cancel != nil {
 		m.cancel()
@@ -161,13 +241,70 @@ func (m *Manager) Shutdown() error {
 	}
 }
 
-// waitForSignal blocks until SIGTERM or SIGINT is received.
+// Status returns a snapshot of the daemon and every configured cluster,
+// including clusters skipped because they failed to initialize.
+func (m *Manager) Status() *Status {
+	status := &Status{
+		StartedAt: m.startedAt,
+		Clusters:  make([]ClusterStatus, 0, len(m.clusters)),
+	}
+	for _, clusterMgr := range m.clusters {
+		status.Clusters = append(status.Clusters, clusterMgr.Status())
+	}
+	if m.daemon != nil {
+		for name, err := range m.daemon.Skipped {
+			status.Clusters = append(status.Clusters, ClusterStatus{
+				Name:  name,
+				State: ClusterStateFailed,
+				Error: err.Error(),
+			})
+		}
+	}
+	sort.Slice(status.Clusters, func(i, j int) bool {
+		return status.Clusters[i].Name < status.Clusters[j].Name
+	})
+	return status
+}
+
+// Reload makes every cluster manager check for a new config version now
+// instead of at its next poll. Calls within reloadDebounce of the last
+// accepted one are ignored.
+//
+// Returns:
+//   - bool: True if the check was triggered, false if it was debounced
+func (m *Manager) Reload() bool {
+	m.reloadMu.Lock()
+	if !m.lastReload.IsZero() && time.Since(m.lastReload) < reloadDebounce {
+		m.reloadMu.Unlock()
+		m.logger.Info("Ignoring reload request, last reload was too recent",
+			zap.Duration("debounce", reloadDebounce))
+		return false
+	}
+	m.lastReload = time.Now()
+	m.reloadMu.Unlock()
+
+	m.logger.Info("Reload requested, checking for config updates now")
+	for _, clusterMgr := range m.clusters {
+		clusterMgr.TriggerPoll()
+	}
+	return true
+}
+
+// waitForSignal blocks until SIGTERM or SIGINT is received. SIGUSR2
+// triggers a Reload and keeps waiting.
 func (m *Manager) waitForSignal() {
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
+	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT, syscall.SIGUSR2)
+	defer signal.Stop(sigChan)
 
-	sig := <-sigChan
-	m.logger.Info("Received shutdown signal", zap.String("signal", sig.String()))
+	for sig := range sigChan {
+		if sig == syscall.SIGUSR2 {
+			m.Reload()
+			continue
+		}
+		m.logger.Info("Received shutdown signal", zap.String("signal", sig.String()))
+		return
+	}
 }
 
 // Stop triggers a graceful shutdown (alias for Shutdown).
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -300,3 +301,71 @@ func TestClusterManager_VersionTracking(t *testing.T) {
 		t.Errorf("Expected version 42, got %d", v)
 	}
 }
+
+func TestManager_StartSkipsInvalidCluster(t *testing.T) {
+	tempDir := t.TempDir()
+
+	config := DaemonConfig{
+		ControlPlaneURLs: []string{"https://control1.example.com"},
+		Clusters: []ClusterConfig{
+			{
+				Name:      "good",
+				TenantID:  "12345678-1234-1234-1234-123456789012",
+				ClusterID: "87654321-4321-4321-4321-210987654321",
+				NodeID:    "abcdef12-3456-7890-abcd-ef1234567890",
+				NodeToken: "12345678901234567890123456789012345678901",
+				ConfigDir: filepath.Join(tempDir, "good"),
+			},
+			{
+				Name:      "bad",
+				TenantID:  "22345678-1234-1234-1234-123456789012",
+				ClusterID: "97654321-4321-4321-4321-210987654321",
+				NodeID:    "bbcdef12-3456-7890-abcd-ef1234567890",
+				NodeToken: "too-short",
+				ConfigDir: filepath.Join(tempDir, "bad"),
+			},
+		},
+	}
+
+	configPath := filepath.Join(tempDir, "config.json")
+	configData, _ := json.MarshalIndent(config, "", "  ")
+	if err := os.WriteFile(configPath, configData, 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	manager, err := NewManager(ManagerConfig{
+		ConfigPath:      configPath,
+		Logger:          zap.NewNop(),
+		ShutdownTimeout: 2 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if len(manager.clusters) != 1 || manager.clusters["good"] == nil {
+		t.Fatalf("Expected only the good cluster to be managed, got %d clusters", len(manager.clusters))
+	}
+
+	manager.start()
+	defer manager.Shutdown()
+
+	// The good cluster runs while the bad one is reported as failed
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		states := make(map[string]ClusterStatus)
+		for _, cluster := range manager.Status().Clusters {
+			states[cluster.Name] = cluster
+		}
+
+		bad := states["bad"]
+		if bad.State != ClusterStateFailed || !strings.Contains(bad.Error, "node_token") {
+			t.Fatalf("Expected bad cluster to fail on node_token, got %+v", bad)
+		}
+		if states["good"].State == ClusterStateRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Good cluster did not start, state %q", states["good"].State)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -2,10 +2,16 @@ package daemon
 
 import (
 	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
 	"time"
 
 	"github.com/yaroslav/nebulagc/sdk"
 	"go.uber.org/zap"
+	"nebulagc.io/pkg/bundle"
 )
 
 // Poller manages config version polling and updates for a cluster.
@@ -19,6 +25,19 @@ type Poller struct {
 	// interval is the time between polling attempts
 	interval time.Duration
 
+	// jitter is the percentage of interval by which each wait is randomized
+	jitter int
+
+	// maxBackoff caps the wait between polls after consecutive failures
+	maxBackoff time.Duration
+
+	// publicKey verifies bundle signatures (nil to accept unsigned bundles)
+	publicKey ed25519.PublicKey
+
+	// callTimeout bounds each control plane call so a stuck call is
+	// cancelled before the next poll is due
+	callTimeout time.Duration
+
 	// onUpdate is called when a new config version is available
 	// Returns the new version number and any error
 	onUpdate func(ctx context.Context, data []byte, version int64) error
@@ -28,6 +47,54 @@ type Poller struct {
 
 	// setCurrentVersion updates the tracked version
 	setCurrentVersion func(int64)
+
+	// onUnauthorized is called when the control plane rejects the node token
+	// and reports whether a replacement token was loaded
+	onUnauthorized func() bool
+
+	// versions delivers latest versions from a shared VersionBatcher
+	// If nil, the poller queries the control plane on its own schedule
+	versions <-chan versionNotice
+
+	// heartbeatInterval is the maximum time between unchanged health reports
+	heartbeatInterval time.Duration
+
+	// lastHeartbeat is the last health report successfully sent
+	lastHeartbeat heartbeat
+
+	// trigger requests an immediate version check (see Trigger)
+	trigger chan struct{}
+
+	// mu guards status, which the status socket reads, and failures
+	mu     sync.Mutex
+	status PollStatus
+
+	// failures counts consecutive failed polls; it resets on success
+	failures int
+}
+
+// PollStatus summarizes recent polls of a cluster.
+type PollStatus struct {
+	// LastPollAt is when the last poll finished (zero before the first poll)
+	LastPollAt time.Time
+
+	// LastError is why the last poll failed (empty if it succeeded)
+	LastError string
+
+	// TimedOut counts polls cancelled because a control plane call exceeded its timeout
+	TimedOut int64
+
+	// TokenRejected is true while the control plane rejects the node token,
+	// typically because an admin rotated or revoked it
+	TokenRejected bool
+}
+
+// heartbeat is a health report sent to the control plane.
+type heartbeat struct {
+	version int64
+	status  string
+	message string
+	sentAt  time.Time
 }
 
 // PollerConfig holds configuration for creating a Poller.
@@ -41,6 +108,21 @@ type PollerConfig struct {
 	// Interval is the polling interval (default: 5 seconds)
 	Interval time.Duration
 
+	// Jitter randomizes each wait by up to this percentage of Interval in
+	// either direction (default: 0, no jitter)
+	Jitter int
+
+	// MaxBackoff caps the wait between polls, which doubles with each
+	// consecutive failure (default: Interval, no backoff)
+	MaxBackoff time.Duration
+
+	// CallTimeout bounds each control plane call (default: Interval)
+	CallTimeout time.Duration
+
+	// PublicKey, if set, is the key every bundle must be signed with; bundles
+	// that are unsigned or fail verification are never passed to OnUpdate
+	PublicKey ed25519.PublicKey
+
 	// OnUpdate is called when new config is available
 	OnUpdate func(ctx context.Context, data []byte, version int64) error
 
@@ -49,6 +131,19 @@ type PollerConfig struct {
 
 	// SetCurrentVersion updates the version
 	SetCurrentVersion func(int64)
+
+	// OnUnauthorized is called when the control plane rejects the node token
+	// (optional). It returns true if it loaded a new token, in which case the
+	// poll is retried immediately.
+	OnUnauthorized func() bool
+
+	// Versions delivers latest versions from a VersionBatcher (optional)
+	// When set, Interval is ignored and the batcher drives polling
+	Versions <-chan versionNotice
+
+	// HeartbeatInterval is the maximum time between unchanged health
+	// reports (default: 30 seconds). Changes are reported immediately.
+	HeartbeatInterval time.Duration
 }
 
 // NewPoller creates a new config poller.
@@ -58,13 +153,45 @@ func NewPoller(config PollerConfig) *Poller {
 		interval = 5 * time.Second
 	}
 
+	callTimeout := config.CallTimeout
+	if callTimeout == 0 {
+		callTimeout = interval
+	}
+
+	maxBackoff := config.MaxBackoff
+	if maxBackoff < interval {
+		maxBackoff = interval
+	}
+
+	heartbeatInterval := config.HeartbeatInterval
+	if heartbeatInterval == 0 {
+		heartbeatInterval = 30 * time.Second
+	}
+
 	return &Poller{
 		client:            config.Client,
 		logger:            config.Logger,
 		interval:          interval,
+		jitter:            config.Jitter,
+		maxBackoff:        maxBackoff,
+		publicKey:         config.PublicKey,
+		callTimeout:       callTimeout,
 		onUpdate:          config.OnUpdate,
 		getCurrentVersion: config.GetCurrentVersion,
 		setCurrentVersion: config.SetCurrentVersion,
+		onUnauthorized:    config.OnUnauthorized,
+		versions:          config.Versions,
+		heartbeatInterval: heartbeatInterval,
+		trigger:           make(chan struct{}, 1),
+	}
+}
+
+// Trigger requests an immediate version check, after which the poll interval
+// restarts. Triggers arriving while one is pending are coalesced.
+func (p *Poller) Trigger() {
+	select {
+	case p.trigger <- struct{}{}:
+	default:
 	}
 }
 
@@ -74,25 +201,100 @@ func NewPoller(config PollerConfig) *Poller {
 // 1. Queries the latest config version from control plane
 // 2. Compares with current version
 // 3. Downloads and applies new config if available
-// 4. Waits for next interval or context cancellation
+// 4. Reports the running version and health to the control plane
+// 5. Waits for the next interval, randomized by jitter, or context cancellation
+//
+// After consecutive failed polls the wait doubles with each failure, up to
+// maxBackoff, and returns to the interval after the first successful poll.
 //
 // Parameters:
 //   - ctx: Context for cancellation
 func (p *Poller) Run(ctx context.Context) {
-	p.logger.Info("Config poller started", zap.Duration("interval", p.interval))
+	if p.versions != nil {
+		p.runBatched(ctx)
+		return
+	}
 
-	ticker := time.NewTicker(p.interval)
-	defer ticker.Stop()
+	p.logger.Info("Config poller started",
+		zap.Duration("interval", p.interval),
+		zap.Int("jitter_percent", p.jitter))
 
 	// Run initial check immediately
 	p.checkForUpdate(ctx)
 
+	// Each wait is drawn anew so nodes drift apart instead of polling in step
+	timer := time.NewTimer(p.nextWait())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.logger.Info("Config poller stopped")
+			return
+		case <-timer.C:
+			p.checkForUpdate(ctx)
+		case <-p.trigger:
+			p.logger.Info("Immediate config check requested")
+			p.checkForUpdate(ctx)
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		}
+		timer.Reset(p.nextWait())
+	}
+}
+
+// nextWait returns how long to wait before the next poll: the interval, or
+// after consecutive failures the interval doubled per failure, randomized by
+// jitter and capped at maxBackoff.
+func (p *Poller) nextWait() time.Duration {
+	p.mu.Lock()
+	failures := p.failures
+	p.mu.Unlock()
+
+	wait := p.interval
+	for i := 0; i < failures && wait < p.maxBackoff; i++ {
+		wait *= 2
+	}
+	wait = jitteredInterval(wait, p.jitter)
+	if failures > 0 && wait > p.maxBackoff {
+		wait = p.maxBackoff
+	}
+	return wait
+}
+
+// jitteredInterval returns interval moved by a random amount of up to
+// percent of it in either direction.
+func jitteredInterval(interval time.Duration, percent int) time.Duration {
+	spread := int64(interval) * int64(percent) / 100
+	if spread <= 0 {
+		return interval
+	}
+	return interval + time.Duration(rand.Int64N(2*spread+1)-spread)
+}
+
+// runBatched applies versions delivered by a VersionBatcher until context
+// is cancelled. Notices without a version (the batch call failed or this
+// cluster's entry was rejected) fall back to querying the control plane directly.
+func (p *Poller) runBatched(ctx context.Context) {
+	p.logger.Info("Config poller started (batched version checks)")
+
 	for {
 		select {
 		case <-ctx.Done():
 			p.logger.Info("Config poller stopped")
 			return
-		case <-ticker.C:
+		case notice := <-p.versions:
+			if notice.known {
+				p.applyLatest(ctx, notice.version)
+			} else {
+				p.checkForUpdate(ctx)
+			}
+		case <-p.trigger:
+			p.logger.Info("Immediate config check requested")
 			p.checkForUpdate(ctx)
 		}
 	}
@@ -100,21 +302,90 @@ func (p *Poller) Run(ctx context.Context) {
 
 // checkForUpdate checks if a new config version is available and applies it.
 func (p *Poller) checkForUpdate(ctx context.Context) {
-	currentVersion := p.getCurrentVersion()
-
 	// Query latest version from control plane
-	latestVersion, err := p.client.GetLatestVersion(ctx)
+	latestVersion, err := p.client.GetLatestVersion(ctx, sdk.WithTimeout(p.callTimeout))
 	if err != nil {
-		p.logger.Error("Failed to get latest version", zap.Error(err))
+		p.pollFailed(ctx, "Failed to get latest version", err)
 		return
 	}
 
+	p.applyLatest(ctx, latestVersion)
+}
+
+// Status returns a summary of recent polls.
+func (p *Poller) Status() PollStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status
+}
+
+// pollFailed logs a failed poll and records it in the status. A call that
+// exceeded callTimeout while ctx is still live counts as a timed out poll.
+//
+// When the control plane rejects the node token, onUnauthorized gets a
+// chance to load a rotated token; if it does, the poll is retried at once,
+// otherwise the token is reported as rejected until a poll succeeds.
+func (p *Poller) pollFailed(ctx context.Context, msg string, err error) {
+	timedOut := errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil
+	rejected := errors.Is(err, sdk.ErrUnauthorized)
+	switch {
+	case timedOut:
+		p.logger.Warn(msg+": control plane call timed out",
+			zap.Duration("timeout", p.callTimeout), zap.Error(err))
+	case rejected && p.onUnauthorized != nil && p.onUnauthorized():
+		p.logger.Info(msg+": node token rejected, retrying with the rotated token", zap.Error(err))
+		rejected = false
+		p.Trigger()
+	case rejected:
+		p.logger.Error(msg+": node token rejected; it was probably rotated or revoked, update the node's credentials",
+			zap.Error(err))
+	default:
+		p.logger.Error(msg, zap.Error(err))
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status.LastPollAt = time.Now()
+	p.status.LastError = err.Error()
+	p.status.TokenRejected = rejected
+	if timedOut {
+		p.status.TimedOut++
+	}
+	p.failures++
+}
+
+// pollSucceeded records a successful poll in the status.
+func (p *Poller) pollSucceeded() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status.LastPollAt = time.Now()
+	p.status.LastError = ""
+	p.status.TokenRejected = false
+	if p.failures > 0 {
+		p.logger.Info("Control plane reachable again, resuming normal polling",
+			zap.Int("failed_polls", p.failures))
+	}
+	p.failures = 0
+}
+
+// applyLatest downloads and applies the config bundle if latestVersion is
+// newer than the currently deployed version, then reports health.
+//
+// A lower latestVersion usually comes from a replica lagging the master and
+// is ignored. It is only applied when the master confirms that a canary
+// rollout was rolled back.
+func (p *Poller) applyLatest(ctx context.Context, latestVersion int64) {
+	currentVersion := p.getCurrentVersion()
+
 	// Check if update is needed
-	if latestVersion <= currentVersion {
+	rollback := latestVersion < currentVersion && p.rollbackConfirmed(ctx, currentVersion)
+	if latestVersion <= currentVersion && !rollback {
 		p.logger.Debug("No update available",
 			zap.Int64("current", currentVersion),
 			zap.Int64("latest", latestVersion),
 		)
+		p.pollSucceeded()
+		p.sendHeartbeat(ctx, currentVersion, sdk.HealthStatusOK, "")
 		return
 	}
 
@@ -124,17 +395,26 @@ func (p *Poller) checkForUpdate(ctx context.Context) {
 	)
 
 	// Download bundle
-	data, newVersion, err := p.client.DownloadBundle(ctx, currentVersion)
+	signed, err := p.client.DownloadSignedBundle(ctx, currentVersion, sdk.WithTimeout(p.callTimeout))
 	if err != nil {
-		p.logger.Error("Failed to download bundle", zap.Error(err))
+		var mismatch *sdk.ChecksumMismatchError
+		if errors.As(err, &mismatch) {
+			p.logger.Error("Control plane served a corrupt bundle",
+				zap.Int64("version", mismatch.Version),
+				zap.Strings("instances", mismatch.Instances),
+			)
+		}
+		p.pollFailed(ctx, "Failed to download bundle", err)
 		return
 	}
+	data, newVersion := signed.Data, signed.Version
 
 	// Handle 304 Not Modified (no data returned)
 	if data == nil {
 		p.logger.Debug("Bundle not modified (304)",
 			zap.Int64("version", newVersion),
 		)
+		p.pollSucceeded()
 		return
 	}
 
@@ -143,19 +423,77 @@ func (p *Poller) checkForUpdate(ctx context.Context) {
 		zap.Int("size_bytes", len(data)),
 	)
 
+	// Refuse bundles not signed by the configured key
+	if p.publicKey != nil {
+		if err := bundle.Verify(p.publicKey, data, signed.Signature); err != nil {
+			p.pollFailed(ctx, "Bundle signature verification failed", err)
+			p.sendHeartbeat(ctx, currentVersion, sdk.HealthStatusError,
+				fmt.Sprintf("bundle version %d rejected: %v", newVersion, err))
+			return
+		}
+	}
+
 	// Apply the update
 	if err := p.onUpdate(ctx, data, newVersion); err != nil {
-		p.logger.Error("Failed to apply config update",
-			zap.Error(err),
-			zap.Int64("version", newVersion),
-		)
+		p.pollFailed(ctx, "Failed to apply config update", err)
+		p.sendHeartbeat(ctx, newVersion, sdk.HealthStatusError, err.Error())
 		return
 	}
 
 	// Update tracked version
 	p.setCurrentVersion(newVersion)
+	p.pollSucceeded()
 
 	p.logger.Info("Config update applied successfully",
 		zap.Int64("version", newVersion),
 	)
+	p.sendHeartbeat(ctx, newVersion, sdk.HealthStatusOK, "")
+}
+
+// rollbackConfirmed asks the master whether the cluster's canary was rolled
+// back to a version below currentVersion. Errors are logged and treated as
+// no rollback; the next poll asks again.
+func (p *Poller) rollbackConfirmed(ctx context.Context, currentVersion int64) bool {
+	status, err := p.client.GetVersionStatus(ctx, sdk.WithTimeout(p.callTimeout))
+	if err != nil {
+		p.logger.Warn("Failed to confirm config rollback with the master", zap.Error(err))
+		return false
+	}
+	if !status.RolledBack || status.Version >= currentVersion {
+		p.logger.Debug("Ignoring older config version, the instance polled is probably lagging",
+			zap.Int64("current", currentVersion),
+			zap.Int64("master", status.Version),
+		)
+		return false
+	}
+
+	p.logger.Info("Master confirmed config rollback",
+		zap.Int64("current", currentVersion),
+		zap.Int64("stable", status.Version),
+	)
+	return true
+}
+
+// sendHeartbeat reports version and health to the control plane when they
+// changed since the last report or heartbeatInterval has elapsed.
+//
+// Failures are only logged; the report is retried on the next poll.
+func (p *Poller) sendHeartbeat(ctx context.Context, version int64, status, message string) {
+	// Keep messages within the control plane's limit
+	if len(message) > 1024 {
+		message = message[:1024]
+	}
+
+	last := p.lastHeartbeat
+	if last.version == version && last.status == status && last.message == message &&
+		time.Since(last.sentAt) < p.heartbeatInterval {
+		return
+	}
+
+	if err := p.client.SendHeartbeat(ctx, version, status, message, sdk.WithTimeout(p.callTimeout)); err != nil {
+		p.logger.Warn("Failed to send heartbeat", zap.Error(err))
+		return
+	}
+
+	p.lastHeartbeat = heartbeat{version: version, status: status, message: message, sentAt: time.Now()}
 }
@@ -0,0 +1,534 @@
+package daemon
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yaroslav/nebulagc/sdk"
+	"go.uber.org/zap"
+	"nebulagc.io/pkg/bundle"
+)
+
+func TestPoller_ApplyLatestRollbackAndHeartbeats(t *testing.T) {
+	type report struct {
+		Version int64  `json:"version"`
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}
+
+	var mu sync.Mutex
+	var reports []report
+	var rolledBack atomic.Bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/tenants/tenant-1/clusters/cluster-1/config/version":
+			json.NewEncoder(w).Encode(sdk.VersionResponse{Version: 4, RolledBack: rolledBack.Load()})
+		case "/api/v1/nodes/heartbeat":
+			var rep report
+			json.NewDecoder(r.Body).Decode(&rep)
+			mu.Lock()
+			reports = append(reports, rep)
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		case "/api/v1/tenants/tenant-1/clusters/cluster-1/config/bundle":
+			w.Header().Set("X-Config-Version", "4")
+			w.Write([]byte("bundle-4"))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := sdk.NewClient(sdk.ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-1",
+		ClusterID:     "cluster-1",
+		NodeToken:     "token",
+		RetryAttempts: 0,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	current := int64(5)
+	applyErr := errors.New("nebula failed to start")
+	poller := NewPoller(PollerConfig{
+		Client: client,
+		Logger: zap.NewNop(),
+		OnUpdate: func(ctx context.Context, data []byte, version int64) error {
+			return applyErr
+		},
+		GetCurrentVersion: func() int64 { return current },
+		SetCurrentVersion: func(v int64) { current = v },
+	})
+
+	ctx := context.Background()
+
+	// A lower version the master does not confirm as a rollback is ignored
+	poller.applyLatest(ctx, 4)
+	if current != 5 {
+		t.Fatalf("Expected unconfirmed lower version to be ignored, got %d", current)
+	}
+
+	// A confirmed rollback is applied; a failed apply reports an error
+	rolledBack.Store(true)
+	poller.applyLatest(ctx, 4)
+	if current != 5 {
+		t.Fatalf("Expected version to stay at 5 after failed apply, got %d", current)
+	}
+
+	applyErr = nil
+	poller.applyLatest(ctx, 4)
+	if current != 4 {
+		t.Fatalf("Expected rollback to version 4, got %d", current)
+	}
+
+	// Unchanged health within the heartbeat interval is not re-sent
+	poller.applyLatest(ctx, 4)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	want := []report{
+		{Version: 5, Status: sdk.HealthStatusOK},
+		{Version: 4, Status: sdk.HealthStatusError, Message: "nebula failed to start"},
+		{Version: 4, Status: sdk.HealthStatusOK},
+	}
+	if len(reports) != len(want) {
+		t.Fatalf("Expected %d heartbeats, got %+v", len(want), reports)
+	}
+	for i := range want {
+		if reports[i] != want[i] {
+			t.Errorf("Heartbeat %d: expected %+v, got %+v", i, want[i], reports[i])
+		}
+	}
+}
+
+func TestPoller_SlowServerPollTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hang until the client gives up or the test ends
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client, err := sdk.NewClient(sdk.ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-1",
+		ClusterID:     "cluster-1",
+		NodeToken:     "token",
+		RetryAttempts: 0,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	poller := NewPoller(PollerConfig{
+		Client:            client,
+		Logger:            zap.NewNop(),
+		Interval:          100 * time.Millisecond,
+		OnUpdate:          func(ctx context.Context, data []byte, version int64) error { return nil },
+		GetCurrentVersion: func() int64 { return 1 },
+		SetCurrentVersion: func(int64) {},
+	})
+
+	start := time.Now()
+	poller.checkForUpdate(context.Background())
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Poll was not cancelled in time, took %v", elapsed)
+	}
+
+	status := poller.Status()
+	if status.TimedOut != 1 {
+		t.Errorf("Expected 1 timed out poll, got %d", status.TimedOut)
+	}
+	if status.LastError == "" || status.LastPollAt.IsZero() {
+		t.Errorf("Expected the failed poll to be recorded, got %+v", status)
+	}
+}
+
+// newVersionCountingServer serves version 1 to GetLatestVersion, counting the
+// checks, and accepts heartbeats.
+func newVersionCountingServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	var checks atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/tenants/tenant-1/clusters/cluster-1/config/version":
+			checks.Add(1)
+			w.Write([]byte(`{"version":1}`))
+		case "/api/v1/nodes/heartbeat":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server, &checks
+}
+
+// newIdlePoller returns a poller for a cluster at version 1 whose interval is
+// too long to fire during a test.
+func newIdlePoller(t *testing.T, serverURL string) *Poller {
+	client, err := sdk.NewClient(sdk.ClientConfig{
+		BaseURLs:      []string{serverURL},
+		TenantID:      "tenant-1",
+		ClusterID:     "cluster-1",
+		NodeToken:     "token",
+		RetryAttempts: 0,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	return NewPoller(PollerConfig{
+		Client:            client,
+		Logger:            zap.NewNop(),
+		Interval:          time.Hour,
+		CallTimeout:       5 * time.Second,
+		OnUpdate:          func(ctx context.Context, data []byte, version int64) error { return nil },
+		GetCurrentVersion: func() int64 { return 1 },
+		SetCurrentVersion: func(int64) {},
+	})
+}
+
+// waitForChecks waits until checks reaches want.
+func waitForChecks(t *testing.T, checks *atomic.Int32, want int32) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for checks.Load() < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d version checks, got %d", want, checks.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPoller_TriggerChecksImmediately(t *testing.T) {
+	server, checks := newVersionCountingServer(t)
+	defer server.Close()
+
+	poller := newIdlePoller(t, server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go poller.Run(ctx)
+
+	// The initial check runs at startup; the next is an hour away
+	waitForChecks(t, checks, 1)
+
+	poller.Trigger()
+	waitForChecks(t, checks, 2)
+}
+
+func TestPoller_RejectedTokenReloadsFromCredentialStore(t *testing.T) {
+	const oldToken = "old-node-token-0123456789abcdefghijklmnopqrs"
+	const rotatedToken = "rotated-node-token-0123456789abcdefghijklmno"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(sdk.HeaderNodeToken) != rotatedToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"unauthorized","message":"Invalid node token"}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/tenants/tenant-1/clusters/cluster-1/config/version":
+			w.Write([]byte(`{"version":1}`))
+		case "/api/v1/nodes/heartbeat":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := sdk.NewClient(sdk.ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-1",
+		ClusterID:     "cluster-1",
+		NodeToken:     oldToken,
+		RetryAttempts: 0,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	store := NewFileCredentialStore(filepath.Join(t.TempDir(), "credentials.json"))
+	cm := &ClusterManager{
+		name:        "prod",
+		config:      &ClusterConfig{Name: "prod", ClusterID: "cluster-1", NodeToken: oldToken, NodeTokenRef: "prod/node"},
+		client:      client,
+		credentials: store,
+		logger:      zap.NewNop(),
+		batcher:     NewVersionBatcher(client, zap.NewNop(), 0),
+	}
+	cm.batcher.Register(cm.config)
+	cm.poller = NewPoller(PollerConfig{
+		Client:            client,
+		Logger:            zap.NewNop(),
+		Interval:          time.Hour,
+		CallTimeout:       5 * time.Second,
+		OnUpdate:          func(ctx context.Context, data []byte, version int64) error { return nil },
+		GetCurrentVersion: func() int64 { return 1 },
+		SetCurrentVersion: func(int64) {},
+		OnUnauthorized:    cm.reloadNodeToken,
+	})
+	ctx := context.Background()
+
+	// Nothing new in the store: the cluster reports the rejected token
+	cm.poller.checkForUpdate(ctx)
+	status := cm.Status()
+	if !status.Degraded || status.DegradedReason != DegradedReasonTokenRejected {
+		t.Fatalf("Expected degraded with %q, got %+v", DegradedReasonTokenRejected, status)
+	}
+
+	// The admin stores the rotated token; the next rejection picks it up and retries
+	if err := store.Set("prod/node", rotatedToken); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	cm.poller.checkForUpdate(ctx)
+	select {
+	case <-cm.poller.trigger:
+	default:
+		t.Fatal("Expected an immediate retry after loading the rotated token")
+	}
+
+	cm.poller.checkForUpdate(ctx)
+	status = cm.Status()
+	if status.Degraded || status.LastPollError != "" {
+		t.Fatalf("Expected recovery with the rotated token, got %+v", status)
+	}
+	if cm.config.NodeToken != rotatedToken {
+		t.Errorf("Cluster config still holds the old token")
+	}
+	if cm.batcher.targets[0].ref.NodeToken != rotatedToken {
+		t.Errorf("Version batcher still sends the old token")
+	}
+}
+
+func TestJitteredInterval_StaysWithinBounds(t *testing.T) {
+	interval := 10 * time.Second
+
+	if got := jitteredInterval(interval, 0); got != interval {
+		t.Errorf("jitteredInterval() without jitter = %v, want %v", got, interval)
+	}
+
+	low, high := 8*time.Second, 12*time.Second
+	var sawLow, sawHigh bool
+	for i := 0; i < 1000; i++ {
+		got := jitteredInterval(interval, 20)
+		if got < low || got > high {
+			t.Fatalf("jitteredInterval() = %v, want between %v and %v", got, low, high)
+		}
+		sawLow = sawLow || got < interval
+		sawHigh = sawHigh || got > interval
+	}
+	if !sawLow || !sawHigh {
+		t.Errorf("jitteredInterval() never spread to both sides of %v", interval)
+	}
+}
+
+func TestPoller_BacksOffOnRepeatedFailures(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/tenants/tenant-1/clusters/cluster-1/config/version":
+			if failing.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"version":1}`))
+		case "/api/v1/nodes/heartbeat":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := sdk.NewClient(sdk.ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-1",
+		ClusterID:     "cluster-1",
+		NodeToken:     "token",
+		RetryAttempts: 0,
+		RetryWaitMin:  time.Millisecond,
+		RetryWaitMax:  time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	poller := NewPoller(PollerConfig{
+		Client:            client,
+		Logger:            zap.NewNop(),
+		Interval:          5 * time.Second,
+		MaxBackoff:        30 * time.Second,
+		OnUpdate:          func(ctx context.Context, data []byte, version int64) error { return nil },
+		GetCurrentVersion: func() int64 { return 1 },
+		SetCurrentVersion: func(int64) {},
+	})
+
+	if got := poller.nextWait(); got != 5*time.Second {
+		t.Fatalf("nextWait() before any failure = %v, want 5s", got)
+	}
+
+	// The wait doubles per failure until it reaches MaxBackoff
+	for _, want := range []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second, 30 * time.Second} {
+		poller.checkForUpdate(context.Background())
+		if got := poller.nextWait(); got != want {
+			t.Errorf("nextWait() after %d failures = %v, want %v", poller.failures, got, want)
+		}
+	}
+
+	// The first success returns to the normal interval
+	failing.Store(false)
+	poller.checkForUpdate(context.Background())
+	if got := poller.nextWait(); got != 5*time.Second {
+		t.Errorf("nextWait() after recovery = %v, want 5s", got)
+	}
+	if status := poller.Status(); status.LastError != "" {
+		t.Errorf("Expected the recovered poll to clear the error, got %q", status.LastError)
+	}
+}
+
+func TestPoller_BackoffWithJitterStaysBelowMax(t *testing.T) {
+	poller := NewPoller(PollerConfig{
+		Logger:     zap.NewNop(),
+		Interval:   10 * time.Second,
+		Jitter:     MaxPollJitter,
+		MaxBackoff: time.Minute,
+	})
+	poller.failures = 10
+
+	for i := 0; i < 1000; i++ {
+		if got := poller.nextWait(); got > time.Minute || got < 30*time.Second {
+			t.Fatalf("nextWait() = %v, want between 30s and 1m", got)
+		}
+	}
+}
+
+func TestPoller_CorruptBundleIsNotApplied(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/tenants/tenant-1/clusters/cluster-1/config/bundle":
+			// A truncated body no longer matches the advertised checksum
+			sum := sha256.Sum256([]byte("bundle-2-complete"))
+			w.Header().Set("X-Config-Version", "2")
+			w.Header().Set("X-Config-Checksum", hex.EncodeToString(sum[:]))
+			w.Write([]byte("bundle-2"))
+		case "/api/v1/nodes/heartbeat":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	poller := newIdlePoller(t, server.URL)
+	applied := false
+	poller.onUpdate = func(ctx context.Context, data []byte, version int64) error {
+		applied = true
+		return nil
+	}
+	current := int64(1)
+	poller.setCurrentVersion = func(v int64) { current = v }
+
+	poller.applyLatest(context.Background(), 2)
+
+	if applied || current != 1 {
+		t.Fatalf("Expected the corrupt bundle not to be applied, applied = %v, version = %d", applied, current)
+	}
+	if status := poller.Status(); !strings.Contains(status.LastError, sdk.ErrChecksumMismatch.Error()) {
+		t.Errorf("Expected a checksum mismatch to be recorded, got %q", status.LastError)
+	}
+}
+
+func TestPoller_RejectsUnverifiedBundles(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	_, otherKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	data := []byte("bundle-2")
+
+	tests := []struct {
+		name      string
+		signature []byte
+		wantErr   error
+	}{
+		{name: "unsigned", signature: nil, wantErr: bundle.ErrUnsignedBundle},
+		{name: "signed with another key", signature: bundle.Sign(otherKey, data), wantErr: bundle.ErrInvalidSignature},
+		{name: "signed", signature: bundle.Sign(privateKey, data)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v1/tenants/tenant-1/clusters/cluster-1/config/bundle":
+					w.Header().Set("X-Config-Version", "2")
+					if tt.signature != nil {
+						w.Header().Set("X-Config-Signature", base64.StdEncoding.EncodeToString(tt.signature))
+					}
+					w.Write(data)
+				case "/api/v1/nodes/heartbeat":
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			poller := newIdlePoller(t, server.URL)
+			poller.publicKey = publicKey
+			applied := false
+			poller.onUpdate = func(ctx context.Context, data []byte, version int64) error {
+				applied = true
+				return nil
+			}
+
+			poller.applyLatest(context.Background(), 2)
+
+			status := poller.Status()
+			if tt.wantErr == nil {
+				if !applied || status.LastError != "" {
+					t.Errorf("Expected the signed bundle to be applied, applied = %v, error = %q", applied, status.LastError)
+				}
+				return
+			}
+			if applied {
+				t.Fatal("Expected the bundle not to be applied")
+			}
+			if !strings.Contains(status.LastError, tt.wantErr.Error()) {
+				t.Errorf("LastError = %q, want %q", status.LastError, tt.wantErr)
+			}
+		})
+	}
+}
@@ -4,18 +4,25 @@ package daemon
 import (
 	"bufio"
 	"context"
+	"fmt"
 	"io"
 	"os/exec"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"go.uber.org/zap"
+	"nebulagc.io/pkg/process"
 )
 
+// nebulaBinary is the Nebula executable, looked up on PATH.
+const nebulaBinary = "nebula"
+
 // Process wraps a Nebula process with monitoring and log capture.
 type Process struct {
-	cmd        *exec.Cmd
+	runner     process.Runner
+	handle     process.Handle
 	configPath string
 	logger     *zap.Logger
 
@@ -24,9 +31,14 @@ type Process struct {
 	pid     int
 }
 
-// NewProcess creates a new Nebula process wrapper.
-func NewProcess(configPath string, logger *zap.Logger) *Process {
+// NewProcess creates a new Nebula process wrapper. A nil runner starts real
+// processes through os/exec.
+func NewProcess(configPath string, runner process.Runner, logger *zap.Logger) *Process {
+	if runner == nil {
+		runner = process.ExecRunner{}
+	}
 	return &Process{
+		runner:     runner,
 		configPath: configPath,
 		logger:     logger,
 	}
@@ -41,35 +53,41 @@ func (p *Process) Start(ctx context.Context) error {
 		return nil
 	}
 
-	// Create command
-	p.cmd = exec.CommandContext(ctx, "nebula", "-config", p.configPath)
-
 	// Setup stdout/stderr capture
-	stdout, err := p.cmd.StdoutPipe()
-	if err != nil {
-		return err
-	}
-
-	stderr, err := p.cmd.StderrPipe()
-	if err != nil {
-		return err
-	}
+	stdoutR, stdoutW := io.Pipe()
+	stderrR, stderrW := io.Pipe()
 
 	// Start the process
-	if err := p.cmd.Start(); err != nil {
+	handle, err := p.runner.Start(ctx, process.Command{
+		Path:   nebulaBinary,
+		Args:   []string{"-config", p.configPath},
+		Stdout: stdoutW,
+		Stderr: stderrW,
+	})
+	if err != nil {
+		stdoutW.Close()
+		stderrW.Close()
 		return err
 	}
 
+	p.handle = handle
 	p.running = true
-	p.pid = p.cmd.Process.Pid
+	p.pid = handle.PID()
 
 	p.logger.Info("nebula process started",
 		zap.Int("pid", p.pid),
 		zap.String("config", p.configPath))
 
 	// Capture logs in background
-	go p.captureOutput(stdout, "stdout")
-	go p.captureOutput(stderr, "stderr")
+	go p.captureOutput(stdoutR, "stdout")
+	go p.captureOutput(stderrR, "stderr")
+
+	// All output has been copied once the process is reaped
+	go func() {
+		<-handle.Done()
+		stdoutW.Close()
+		stderrW.Close()
+	}()
 
 	return nil
 }
@@ -77,14 +95,14 @@ func (p *Process) Start(ctx context.Context) error {
 // Wait waits for the process to exit and returns the exit code.
 func (p *Process) Wait() error {
 	p.mu.RLock()
-	cmd := p.cmd
+	handle := p.handle
 	p.mu.RUnlock()
 
-	if cmd == nil {
+	if handle == nil {
 		return nil
 	}
 
-	err := cmd.Wait()
+	err := handle.Wait()
 
 	p.mu.Lock()
 	p.running = false
@@ -97,14 +115,13 @@ func (p *Process) Wait() error {
 		p.mu.RUnlock()
 
 		// Check if it was killed by signal
+		if sig, ok := terminatedBySignal(err); ok {
+			p.logger.Info("nebula process killed by signal",
+				zap.String("signal", sig.String()),
+				zap.Int("pid", pid))
+			return nil
+		}
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			status := exitErr.Sys().(syscall.WaitStatus)
-			if status.Signaled() {
-				p.logger.Info("nebula process killed by signal",
-					zap.String("signal", status.Signal().String()),
-					zap.Int("pid", pid))
-				return nil
-			}
 			p.logger.Error("nebula process exited with error",
 				zap.Int("exit_code", exitErr.ExitCode()),
 				zap.Int("pid", pid))
@@ -127,13 +144,13 @@ func (p *Process) Wait() error {
 func (p *Process) Stop() error {
 	p.mu.Lock()
 
-	if !p.running || p.cmd == nil || p.cmd.Process == nil {
+	if !p.running || p.handle == nil {
 		p.mu.Unlock()
 		return nil
 	}
 
 	// Get references under lock
-	proc := p.cmd.Process
+	handle := p.handle
 	pid := p.pid
 
 	p.mu.Unlock()
@@ -142,41 +159,32 @@ func (p *Process) Stop() error {
 		zap.Int("pid", pid))
 
 	// Send SIGTERM for graceful shutdown
-	if err := proc.Signal(syscall.SIGTERM); err != nil {
+	if err := handle.Signal(syscall.SIGTERM); err != nil {
 		return err
 	}
 
 	// Wait for process to exit (up to 10 seconds)
-	done := make(chan struct{})
-	var waitErr error
-
-	go func() {
-		// Call Wait without holding any locks
-		p.mu.RLock()
-		cmd := p.cmd
-		p.mu.RUnlock()
-
-		if cmd != nil {
-			waitErr = cmd.Wait()
-		}
-		close(done)
-	}()
-
 	select {
-	case <-done:
+	case <-handle.Done():
 		p.mu.Lock()
 		p.running = false
 		p.mu.Unlock()
 
 		p.logger.Info("nebula process stopped gracefully",
 			zap.Int("pid", pid))
-		return waitErr
+
+		// Exiting on the SIGTERM we sent is a clean stop
+		err := handle.Wait()
+		if _, ok := terminatedBySignal(err); ok {
+			return nil
+		}
+		return err
 	case <-time.After(10 * time.Second):
 		// Force kill if not responding
 		p.logger.Warn("nebula process not responding to SIGTERM, force killing",
 			zap.Int("pid", pid))
 
-		if err := proc.Kill(); err != nil {
+		if err := handle.Kill(); err != nil {
 			return err
 		}
 
@@ -188,6 +196,20 @@ func (p *Process) Stop() error {
 	}
 }
 
+// terminatedBySignal reports whether err is the exit error of a process that
+// was terminated by a signal, and which signal it was.
+func terminatedBySignal(err error) (syscall.Signal, bool) {
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return 0, false
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return 0, false
+	}
+	return status.Signal(), true
+}
+
 // IsRunning returns whether the process is currently running.
 func (p *Process) IsRunning() bool {
 	p.mu.RLock()
@@ -202,8 +224,9 @@ func (p *Process) PID() int {
 	return p.pid
 }
 
-// captureOutput captures and logs process output.
-func (p *Process) captureOutput(reader io.Reader, source string) {
+// captureOutput logs each line of Nebula output through the daemon logger,
+// tagged source=nebula to set it apart from the daemon's own logs.
+func (p *Process) captureOutput(reader io.Reader, stream string) {
 	scanner := bufio.NewScanner(reader)
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -214,7 +237,8 @@ func (p *Process) captureOutput(reader io.Reader, source string) {
 		p.mu.RUnlock()
 
 		p.logger.Info("nebula output",
-			zap.String("source", source),
+			zap.String("source", "nebula"),
+			zap.String("stream", stream),
 			zap.String("line", line),
 			zap.Int("pid", pid))
 	}
@@ -227,7 +251,32 @@ func (p *Process) captureOutput(reader io.Reader, source string) {
 
 		p.logger.Error("error reading nebula output",
 			zap.Error(err),
-			zap.String("source", source),
+			zap.String("stream", stream),
 			zap.Int("pid", pid))
+
+		// Keep draining so the process never blocks writing its output
+		_, _ = io.Copy(io.Discard, reader)
+	}
+}
+
+// CheckNebulaBinary checks that the Nebula binary is on PATH and runs.
+//
+// Parameters:
+//   - ctx: Bounds how long the binary may take to report its version
+//
+// Returns:
+//   - string: The binary path and the version it reports
+//   - error: Error if the binary is missing or fails to run
+func CheckNebulaBinary(ctx context.Context) (string, error) {
+	path, err := exec.LookPath(nebulaBinary)
+	if err != nil {
+		return "", fmt.Errorf("nebula binary not found: %w", err)
 	}
+
+	output, err := exec.CommandContext(ctx, path, "-version").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s failed to run: %w", path, err)
+	}
+
+	return fmt.Sprintf("%s (%s)", path, strings.TrimSpace(string(output))), nil
 }
@@ -5,10 +5,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestProcess_StartStop(t *testing.T) {
@@ -38,7 +41,7 @@ sleep 30
 	defer os.Setenv("PATH", oldPath)
 
 	// Create process
-	p := NewProcess(configPath, logger)
+	p := NewProcess(configPath, nil, logger)
 
 	// Start process
 	ctx := context.Background()
@@ -92,7 +95,7 @@ exit 0
 	os.Setenv("PATH", tmpDir+":"+oldPath)
 	defer os.Setenv("PATH", oldPath)
 
-	p := NewProcess(configPath, logger)
+	p := NewProcess(configPath, nil, logger)
 
 	ctx := context.Background()
 	if err := p.Start(ctx); err != nil {
@@ -132,7 +135,7 @@ sleep 30
 	os.Setenv("PATH", tmpDir+":"+oldPath)
 	defer os.Setenv("PATH", oldPath)
 
-	p := NewProcess(configPath, logger)
+	p := NewProcess(configPath, nil, logger)
 
 	ctx := context.Background()
 	if err := p.Start(ctx); err != nil {
@@ -175,7 +178,7 @@ sleep 0.1
 	os.Setenv("PATH", tmpDir+":"+oldPath)
 	defer os.Setenv("PATH", oldPath)
 
-	p := NewProcess(configPath, logger)
+	p := NewProcess(configPath, nil, logger)
 
 	ctx := context.Background()
 	if err := p.Start(ctx); err != nil {
@@ -198,7 +201,7 @@ func TestProcess_StopNotRunning(t *testing.T) {
 		t.Fatalf("Failed to write config: %v", err)
 	}
 
-	p := NewProcess(configPath, logger)
+	p := NewProcess(configPath, nil, logger)
 
 	// Try to stop without starting - should not error
 	if err := p.Stop(); err != nil {
@@ -222,7 +225,7 @@ func TestProcess_MissingNebulaBinary(t *testing.T) {
 	os.Setenv("PATH", emptyDir)
 	defer os.Setenv("PATH", oldPath)
 
-	p := NewProcess(configPath, logger)
+	p := NewProcess(configPath, nil, logger)
 
 	ctx := context.Background()
 	err := p.Start(ctx)
@@ -236,3 +239,24 @@ func TestProcess_MissingNebulaBinary(t *testing.T) {
 		t.Errorf("Expected exec.Error, got: %v", err)
 	}
 }
+
+func TestProcess_CaptureOutputTagsNebula(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	proc := NewProcess("/etc/nebula/config.yml", nil, zap.New(core))
+
+	proc.captureOutput(strings.NewReader("handshake complete\nlighthouse reachable\n"), "stderr")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 log entries, got %d", len(entries))
+	}
+	for _, entry := range entries {
+		fields := entry.ContextMap()
+		if fields["source"] != "nebula" || fields["stream"] != "stderr" {
+			t.Errorf("Expected source=nebula stream=stderr, got %v", fields)
+		}
+	}
+	if line := entries[0].ContextMap()["line"]; line != "handshake complete" {
+		t.Errorf("First line = %v, want %q", line, "handshake complete")
+	}
+}
@@ -0,0 +1,109 @@
+package daemon
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"time"
+
+	"github.com/yaroslav/nebulagc/sdk"
+	"nebulagc.io/pkg/bundle"
+)
+
+// selfTestStepTimeout bounds each self-test step.
+const selfTestStepTimeout = 10 * time.Second
+
+// SelfTestCheck is the outcome of one self-test step.
+type SelfTestCheck struct {
+	// Name describes what was checked
+	Name string
+
+	// Detail is what the check found when it passed
+	Detail string
+
+	// Err is why the check failed (nil if it passed)
+	Err error
+}
+
+// Passed reports whether the check succeeded.
+func (c SelfTestCheck) Passed() bool {
+	return c.Err == nil
+}
+
+// RunSelfTest checks that a node can reach its control plane and run Nebula,
+// without changing anything on the node. It discovers the master,
+// authenticates with the node token, fetches the latest config version,
+// downloads the bundle and checks it like the poller would (signature and
+// contents, without installing it) and checks the Nebula binary. Every step runs even if an earlier one failed, so a single
+// run reports all problems.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - client: SDK client for the cluster under test
+//   - publicKey: The cluster's bundle signing key (nil if bundles are not
+//     required to be signed)
+//
+// Returns:
+//   - []SelfTestCheck: One result per step, in the order run
+func RunSelfTest(ctx context.Context, client *sdk.Client, publicKey ed25519.PublicKey) []SelfTestCheck {
+	steps := []struct {
+		name string
+		run  func(ctx context.Context) (string, error)
+	}{
+		{"Discover control plane master", func(ctx context.Context) (string, error) {
+			if err := client.DiscoverMaster(ctx); err != nil {
+				return "", err
+			}
+			return client.MasterURL(), nil
+		}},
+		{"Authenticate with node token", func(ctx context.Context) (string, error) {
+			identity, err := client.WhoAmI(ctx)
+			if err != nil {
+				return "", err
+			}
+			if identity.NodeID != client.NodeID {
+				return "", fmt.Errorf("token belongs to node %s, config says %s", identity.NodeID, client.NodeID)
+			}
+			return fmt.Sprintf("node %s (%s)", identity.Name, identity.NodeID), nil
+		}},
+		{"Fetch latest config version", func(ctx context.Context) (string, error) {
+			version, err := client.GetLatestVersion(ctx)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("version %d", version), nil
+		}},
+		{"Download and validate bundle", func(ctx context.Context) (string, error) {
+			signed, err := client.DownloadSignedBundle(ctx, 0)
+			if err != nil {
+				return "", err
+			}
+			data, version := signed.Data, signed.Version
+
+			// The poller refuses bundles not signed by the configured key
+			detail := fmt.Sprintf("version %d, %d bytes", version, len(data))
+			if publicKey != nil {
+				if err := bundle.Verify(publicKey, data, signed.Signature); err != nil {
+					return "", fmt.Errorf("version %d signature verification failed: %w", version, err)
+				}
+				detail += ", signature verified"
+			}
+			if err := ValidateBundle(data); err != nil {
+				return "", fmt.Errorf("version %d is invalid: %w", version, err)
+			}
+			return detail, nil
+		}},
+		{"Nebula binary", CheckNebulaBinary},
+	}
+
+	checks := make([]SelfTestCheck, 0, len(steps))
+	for _, step := range steps {
+		stepCtx, cancel := context.WithTimeout(ctx, selfTestStepTimeout)
+		detail, err := step.run(stepCtx)
+		cancel()
+
+		checks = append(checks, SelfTestCheck{Name: step.name, Detail: detail, Err: err})
+	}
+
+	return checks
+}
@@ -0,0 +1,148 @@
+package daemon
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yaroslav/nebulagc/sdk"
+	"nebulagc.io/pkg/bundle"
+)
+
+// newSelfTestServer returns a control plane that serves bundle for node-1.
+func newSelfTestServer(t *testing.T, bundle []byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/check-master":
+			w.WriteHeader(http.StatusOK)
+		case "/api/v1/nodes/me":
+			w.Write([]byte(`{"tenant_id":"tenant-1","cluster_id":"cluster-1","node_id":"node-1","name":"edge-1"}`))
+		case "/api/v1/tenants/tenant-1/clusters/cluster-1/config/version":
+			w.Write([]byte(`{"version":3}`))
+		case "/api/v1/tenants/tenant-1/clusters/cluster-1/config/bundle":
+			w.Header().Set("X-Config-Version", "3")
+			w.Write(bundle)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+// installFakeNebula puts a nebula script that prints a version on PATH.
+func installFakeNebula(t *testing.T) {
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho 'Version: 1.9.0'\n"
+	if err := os.WriteFile(filepath.Join(dir, "nebula"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake nebula: %v", err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func newSelfTestClient(t *testing.T, serverURL, nodeID string) *sdk.Client {
+	client, err := sdk.NewClient(sdk.ClientConfig{
+		BaseURLs:      []string{serverURL},
+		TenantID:      "tenant-1",
+		ClusterID:     "cluster-1",
+		NodeID:        nodeID,
+		NodeToken:     "token",
+		RetryAttempts: 0,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return client
+}
+
+func TestRunSelfTest_AllPass(t *testing.T) {
+	installFakeNebula(t)
+	server := newSelfTestServer(t, createTestBundle(t, RequiredBundleFiles))
+	defer server.Close()
+
+	checks := RunSelfTest(context.Background(), newSelfTestClient(t, server.URL, "node-1"), nil)
+	if len(checks) != 5 {
+		t.Fatalf("Expected 5 checks, got %d", len(checks))
+	}
+	for _, check := range checks {
+		if !check.Passed() {
+			t.Errorf("Check %q failed: %v", check.Name, check.Err)
+		}
+	}
+	if !strings.Contains(checks[4].Detail, "Version: 1.9.0") {
+		t.Errorf("Nebula check detail = %q, want the reported version", checks[4].Detail)
+	}
+}
+
+func TestRunSelfTest_ReportsEveryFailure(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	server := newSelfTestServer(t, createTestBundle(t, []string{"config.yml"}))
+	defer server.Close()
+
+	// The token belongs to node-1 but the config names another node
+	checks := RunSelfTest(context.Background(), newSelfTestClient(t, server.URL, "node-2"), nil)
+
+	failed := make(map[string]error)
+	for _, check := range checks {
+		if !check.Passed() {
+			failed[check.Name] = check.Err
+		}
+	}
+	if len(failed) != 3 {
+		t.Fatalf("Expected 3 failed checks, got %v", failed)
+	}
+	if err := failed["Authenticate with node token"]; err == nil || !strings.Contains(err.Error(), "node-2") {
+		t.Errorf("Expected node ID mismatch, got %v", err)
+	}
+	if err := failed["Download and validate bundle"]; err == nil || !strings.Contains(err.Error(), "missing required file") {
+		t.Errorf("Expected invalid bundle, got %v", err)
+	}
+	if err := failed["Nebula binary"]; err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected missing binary, got %v", err)
+	}
+}
+
+func TestRunSelfTest_VerifiesBundleSignature(t *testing.T) {
+	installFakeNebula(t)
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	data := createTestBundle(t, RequiredBundleFiles)
+	signature := ""
+
+	unsigned := newSelfTestServer(t, data)
+	defer unsigned.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if signature != "" {
+			w.Header().Set("X-Config-Signature", signature)
+		}
+		unsigned.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	client := newSelfTestClient(t, server.URL, "node-1")
+
+	// An unsigned bundle fails like it would in the poller
+	if check := RunSelfTest(context.Background(), client, publicKey)[3]; !errors.Is(check.Err, bundle.ErrUnsignedBundle) {
+		t.Errorf("Bundle check error = %v, want ErrUnsignedBundle", check.Err)
+	}
+
+	// So does one signed by another key
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+	signature = base64.StdEncoding.EncodeToString(bundle.Sign(otherKey, data))
+	if check := RunSelfTest(context.Background(), client, publicKey)[3]; !errors.Is(check.Err, bundle.ErrInvalidSignature) {
+		t.Errorf("Bundle check error = %v, want ErrInvalidSignature", check.Err)
+	}
+
+	signature = base64.StdEncoding.EncodeToString(bundle.Sign(privateKey, data))
+	check := RunSelfTest(context.Background(), client, publicKey)[3]
+	if !check.Passed() || !strings.Contains(check.Detail, "signature verified") {
+		t.Errorf("Expected a verified bundle, got %+v", check)
+	}
+}
@@ -0,0 +1,304 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultStatusSocket is the Unix socket the daemon serves its status on.
+const DefaultStatusSocket = "/run/nebulagc/status.sock"
+
+// statusPath is the HTTP path of the status endpoint on the socket.
+const statusPath = "/status"
+
+// reloadPath is the HTTP path that triggers an immediate config check.
+const reloadPath = "/reload"
+
+// reloadResponse is the body returned by the reload endpoint.
+type reloadResponse struct {
+	// Triggered is false when the request was debounced
+	Triggered bool `json:"triggered"`
+}
+
+// Status is a snapshot of the running daemon, served on the status socket.
+type Status struct {
+	// StartedAt is when the daemon manager started
+	StartedAt time.Time `json:"started_at"`
+
+	// Clusters holds one entry per managed cluster, ordered by name
+	Clusters []ClusterStatus `json:"clusters"`
+}
+
+// Cluster states reported in ClusterStatus.
+const (
+	// ClusterStateStarting means the cluster manager has not finished starting
+	ClusterStateStarting = "starting"
+
+	// ClusterStateRunning means the cluster is polling and supervising Nebula
+	ClusterStateRunning = "running"
+
+	// ClusterStateFailed means the cluster could not be started; see Error
+	ClusterStateFailed = "failed"
+)
+
+// Reasons a cluster is degraded, reported in ClusterStatus.
+const (
+	// DegradedReasonUnreachable means no control plane replica is reachable
+	DegradedReasonUnreachable = "control_plane_unreachable"
+
+	// DegradedReasonTokenRejected means the control plane rejects the node
+	// token, usually because it was rotated; the node's credentials must be updated
+	DegradedReasonTokenRejected = "node_token_rejected"
+)
+
+// ClusterStatus is the state of one configured cluster.
+type ClusterStatus struct {
+	// Name is the cluster name from the daemon config
+	Name string `json:"name"`
+
+	// State is one of the ClusterState constants
+	State string `json:"state"`
+
+	// Error is why the cluster failed to start (only for ClusterStateFailed)
+	Error string `json:"error,omitempty"`
+
+	// TenantID, ClusterID and NodeID identify this node in the control plane
+	TenantID  string `json:"tenant_id"`
+	ClusterID string `json:"cluster_id"`
+	NodeID    string `json:"node_id"`
+
+	// ConfigVersion is the config bundle version currently installed
+	ConfigVersion int64 `json:"config_version"`
+
+	// NebulaRunning reports whether the Nebula process is up
+	NebulaRunning bool `json:"nebula_running"`
+
+	// NebulaPID is the Nebula process ID (0 when not running)
+	NebulaPID int `json:"nebula_pid,omitempty"`
+
+	// Degraded is true when the cluster cannot get config updates; see DegradedReason
+	Degraded bool `json:"degraded"`
+
+	// DegradedReason is one of the DegradedReason constants (empty unless Degraded)
+	DegradedReason string `json:"degraded_reason,omitempty"`
+
+	// HealthyReplicas and TotalReplicas are from the last control plane health check
+	HealthyReplicas int `json:"healthy_replicas"`
+	TotalReplicas   int `json:"total_replicas"`
+
+	// LastHealthCheck is when the control plane was last checked (nil before the first check)
+	LastHealthCheck *time.Time `json:"last_health_check,omitempty"`
+
+	// LastPoll is when the last config poll finished (nil before the first poll)
+	LastPoll *time.Time `json:"last_poll,omitempty"`
+
+	// LastPollError is why the last poll failed (empty if it succeeded)
+	LastPollError string `json:"last_poll_error,omitempty"`
+
+	// TimedOutPolls counts polls cancelled because a control plane call timed out
+	TimedOutPolls int64 `json:"timed_out_polls"`
+}
+
+// StatusServer serves the daemon status as JSON over a Unix socket, and
+// accepts requests to check for config updates immediately.
+type StatusServer struct {
+	socketPath string
+	status     func() *Status
+	reload     func() bool
+	logger     *zap.Logger
+	server     *http.Server
+}
+
+// NewStatusServer creates a status server. It does not listen until Start is called.
+//
+// Parameters:
+//   - socketPath: Path of the Unix socket to create
+//   - status: Returns the current daemon status for each request
+//   - reload: Triggers an immediate config check, returning false if debounced
+//     (nil disables the reload endpoint)
+//   - logger: Structured logger
+//
+// Returns:
+//   - *StatusServer: The status server
+func NewStatusServer(socketPath string, status func() *Status, reload func() bool, logger *zap.Logger) *StatusServer {
+	return &StatusServer{
+		socketPath: socketPath,
+		status:     status,
+		reload:     reload,
+		logger:     logger,
+	}
+}
+
+// Start creates the socket and serves status requests in the background.
+// A stale socket left by a previous daemon is replaced. The socket is only
+// accessible to its owner and group.
+//
+// Returns:
+//   - error: Error if the socket cannot be created
+func (s *StatusServer) Start() error {
+	if err := os.MkdirAll(filepath.Dir(s.socketPath), 0750); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	if err := os.Remove(s.socketPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.socketPath, err)
+	}
+	if err := os.Chmod(s.socketPath, 0660); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(statusPath, s.handleStatus)
+	if s.reload != nil {
+		mux.HandleFunc(reloadPath, s.handleReload)
+	}
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("Status socket stopped", zap.Error(err))
+		}
+	}()
+
+	s.logger.Info("Status socket listening", zap.String("socket", s.socketPath))
+	return nil
+}
+
+// Stop closes the socket and waits for in-flight requests to finish.
+//
+// Parameters:
+//   - ctx: Bounds how long to wait for in-flight requests
+//
+// Returns:
+//   - error: Shutdown error
+func (s *StatusServer) Stop(ctx context.Context) error {
+	if s.server == nil {
+		return nil
+	}
+	err := s.server.Shutdown(ctx)
+	os.Remove(s.socketPath)
+	return err
+}
+
+// handleStatus writes the current status as JSON.
+func (s *StatusServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.status()); err != nil {
+		s.logger.Debug("Failed to write status response", zap.Error(err))
+	}
+}
+
+// handleReload triggers an immediate config check.
+func (s *StatusServer) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(reloadResponse{Triggered: s.reload()}); err != nil {
+		s.logger.Debug("Failed to write reload response", zap.Error(err))
+	}
+}
+
+// socketClient returns an HTTP client that dials every request to socketPath.
+func socketClient(socketPath string) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+}
+
+// QueryStatus fetches the status of a running daemon from its status socket.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeouts
+//   - socketPath: Path of the daemon's status socket
+//
+// Returns:
+//   - *Status: The daemon status
+//   - error: Error if the daemon is not reachable or the response is invalid
+func QueryStatus(ctx context.Context, socketPath string) (*Status, error) {
+	// The host is ignored; every request is dialed to the socket
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://nebulagc"+statusPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := socketClient(socketPath).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("daemon not reachable on %s: %w", socketPath, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status response: %s", resp.Status)
+	}
+
+	var status Status
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("failed to decode status: %w", err)
+	}
+
+	return &status, nil
+}
+
+// TriggerReload asks a running daemon to check for config updates immediately,
+// as SIGUSR2 does.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeouts
+//   - socketPath: Path of the daemon's status socket
+//
+// Returns:
+//   - bool: False if the daemon ignored the request because a reload just happened
+//   - error: Error if the daemon is not reachable or the response is invalid
+func TriggerReload(ctx context.Context, socketPath string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://nebulagc"+reloadPath, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := socketClient(socketPath).Do(req)
+	if err != nil {
+		return false, fmt.Errorf("daemon not reachable on %s: %w", socketPath, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected reload response: %s", resp.Status)
+	}
+
+	var result reloadResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("failed to decode reload response: %w", err)
+	}
+
+	return result.Triggered, nil
+}
@@ -0,0 +1,128 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestStatusServer_QueryStatus(t *testing.T) {
+	// Unix socket paths are length limited, so avoid the long t.TempDir path
+	dir, err := os.MkdirTemp("", "ngc")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "run", "status.sock")
+
+	cm := &ClusterManager{
+		name: "prod",
+		config: &ClusterConfig{
+			Name:      "prod",
+			TenantID:  "12345678-1234-1234-1234-123456789012",
+			ClusterID: "87654321-4321-4321-4321-210987654321",
+			NodeID:    "abcdef12-3456-7890-abcd-ef1234567890",
+		},
+		logger: zap.NewNop(),
+	}
+	cm.SetCurrentVersion(7)
+
+	manager := &Manager{
+		logger:    zap.NewNop(),
+		clusters:  map[string]*ClusterManager{"prod": cm},
+		startedAt: time.Now(),
+	}
+
+	server := NewStatusServer(socketPath, manager.Status, nil, zap.NewNop())
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	status, err := QueryStatus(ctx, socketPath)
+	if err != nil {
+		t.Fatalf("QueryStatus() error = %v", err)
+	}
+	if len(status.Clusters) != 1 {
+		t.Fatalf("Expected 1 cluster, got %d", len(status.Clusters))
+	}
+	got := status.Clusters[0]
+	if got.Name != "prod" || got.ConfigVersion != 7 || got.NodeID != cm.config.NodeID {
+		t.Errorf("Unexpected cluster status: %+v", got)
+	}
+	if got.NebulaRunning || got.LastHealthCheck != nil {
+		t.Errorf("Expected a cluster that has not started, got %+v", got)
+	}
+
+	if err := server.Stop(ctx); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Error("Socket should be removed after Stop")
+	}
+	if _, err := QueryStatus(ctx, socketPath); err == nil {
+		t.Error("QueryStatus() expected error after Stop")
+	}
+}
+
+func TestStatusServer_ReloadTriggersPoll(t *testing.T) {
+	dir, err := os.MkdirTemp("", "ngc")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "status.sock")
+
+	server, checks := newVersionCountingServer(t)
+	defer server.Close()
+
+	cm := &ClusterManager{
+		name:   "prod",
+		config: &ClusterConfig{Name: "prod"},
+		logger: zap.NewNop(),
+		poller: newIdlePoller(t, server.URL),
+	}
+	manager := &Manager{
+		logger:   zap.NewNop(),
+		clusters: map[string]*ClusterManager{"prod": cm},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go cm.poller.Run(ctx)
+	waitForChecks(t, checks, 1)
+
+	statusServer := NewStatusServer(socketPath, manager.Status, manager.Reload, zap.NewNop())
+	if err := statusServer.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer statusServer.Stop(ctx)
+
+	triggered, err := TriggerReload(ctx, socketPath)
+	if err != nil {
+		t.Fatalf("TriggerReload() error = %v", err)
+	}
+	if !triggered {
+		t.Error("Expected the first reload to trigger a check")
+	}
+	waitForChecks(t, checks, 2)
+
+	// A second request right away is debounced
+	triggered, err = TriggerReload(ctx, socketPath)
+	if err != nil {
+		t.Fatalf("TriggerReload() error = %v", err)
+	}
+	if triggered {
+		t.Error("Expected the second reload to be debounced")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := checks.Load(); got != 2 {
+		t.Errorf("Expected no extra check after a debounced reload, got %d checks", got)
+	}
+}
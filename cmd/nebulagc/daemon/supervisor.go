@@ -7,15 +7,22 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+	"nebulagc.io/pkg/clock"
+	"nebulagc.io/pkg/process"
 )
 
 // Supervisor manages the lifecycle of a Nebula process with automatic restart.
 type Supervisor struct {
 	mu         sync.RWMutex // Protects process, currentBackoff fields
 	process    *Process
+	runner     process.Runner
 	configPath string
 	logger     *zap.Logger
 
+	// Time sources, replaced in tests so restarts need no real sleeps
+	clock clock.Clock
+	sleep func(ctx context.Context, d time.Duration) bool
+
 	// Restart backoff settings
 	minBackoff       time.Duration
 	maxBackoff       time.Duration
@@ -36,6 +43,9 @@ type SupervisorConfig struct {
 	MaxBackoff       time.Duration
 	SuccessThreshold time.Duration
 	Logger           *zap.Logger
+
+	// Runner starts the Nebula process (nil = os/exec).
+	Runner process.Runner
 }
 
 // NewSupervisor creates a new process supervisor.
@@ -54,8 +64,11 @@ func NewSupervisor(cfg SupervisorConfig) *Supervisor {
 	}
 
 	return &Supervisor{
+		runner:           cfg.Runner,
 		configPath:       cfg.ConfigPath,
 		logger:           cfg.Logger,
+		clock:            clock.Real{},
+		sleep:            sleepContext,
 		minBackoff:       cfg.MinBackoff,
 		maxBackoff:       cfg.MaxBackoff,
 		currentBackoff:   cfg.MinBackoff,
@@ -89,7 +102,7 @@ func (s *Supervisor) Run() error {
 		}
 
 		// Wait for process to exit or restart signal
-		startTime := time.Now()
+		startTime := s.clock.Now()
 
 		// Wait in goroutine so we can handle restart signals
 		waitCh := make(chan error, 1)
@@ -139,7 +152,7 @@ func (s *Supervisor) Run() error {
 
 		case err := <-waitCh:
 			// Process exited naturally
-			runDuration := time.Since(startTime)
+			runDuration := s.clock.Now().Sub(startTime)
 
 			if err != nil {
 				s.logger.Error("process exited with error",
@@ -188,7 +201,7 @@ func (s *Supervisor) Restart() {
 
 // startProcess starts a new Nebula process.
 func (s *Supervisor) startProcess() error {
-	proc := NewProcess(s.configPath, s.logger)
+	proc := NewProcess(s.configPath, s.runner, s.logger)
 	if err := proc.Start(s.ctx); err != nil {
 		return err
 	}
@@ -209,13 +222,7 @@ func (s *Supervisor) applyBackoff() {
 	s.logger.Info("applying restart backoff",
 		zap.Duration("delay", backoff))
 
-	timer := time.NewTimer(backoff)
-	defer timer.Stop()
-
-	select {
-	case <-timer.C:
-		// Backoff completed
-	case <-s.ctx.Done():
+	if !s.sleep(s.ctx, backoff) {
 		// Supervisor stopping
 		return
 	}
@@ -229,6 +236,19 @@ func (s *Supervisor) applyBackoff() {
 	s.mu.Unlock()
 }
 
+// sleepContext waits for d and reports whether it elapsed before ctx was done.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 // IsRunning returns whether the supervised process is running.
 func (s *Supervisor) IsRunning() bool {
 	s.mu.RLock()
@@ -1,375 +1,366 @@
 package daemon
 
 import (
-	"os"
-	"path/filepath"
+	"context"
+	"errors"
+	"syscall"
 	"testing"
 	"time"
 
 	"go.uber.org/zap/zaptest"
+	"nebulagc.io/pkg/clock"
+	"nebulagc.io/pkg/process"
 )
 
-func TestSupervisor_StartStop(t *testing.T) {
-	logger := zaptest.NewLogger(t)
+// errCrash is the exit error used to simulate Nebula crashing.
+var errCrash = errors.New("exit status 1")
+
+// supervisorHarness drives a Supervisor backed by a fake runner and clock.
+// Backoff delays are recorded on backoffs instead of being slept.
+type supervisorHarness struct {
+	s        *Supervisor
+	runner   *process.FakeRunner
+	clock    *clock.Fake
+	backoffs chan time.Duration
+}
+
+func newSupervisorHarness(t *testing.T, cfg SupervisorConfig) *supervisorHarness {
+	t.Helper()
 
-	tmpDir := t.TempDir()
-	configPath := filepath.Join(tmpDir, "config.yml")
-	if err := os.WriteFile(configPath, []byte("test: config\n"), 0644); err != nil {
-		t.Fatalf("Failed to write config: %v", err)
+	h := &supervisorHarness{
+		runner:   process.NewFakeRunner(),
+		clock:    clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)),
+		backoffs: make(chan time.Duration, 64),
 	}
 
-	// Mock nebula process
-	scriptPath := filepath.Join(tmpDir, "nebula")
-	script := `#!/bin/sh
-sleep 5
-`
-	if err := os.WriteFile(scriptPath, []byte(script), 0755); err != nil {
-		t.Fatalf("Failed to write script: %v", err)
+	cfg.ConfigPath = "/etc/nebula/config.yml"
+	cfg.Logger = zaptest.NewLogger(t)
+	cfg.Runner = h.runner
+	h.s = NewSupervisor(cfg)
+	h.s.clock = h.clock
+	h.s.sleep = func(ctx context.Context, d time.Duration) bool {
+		h.backoffs <- d
+		return ctx.Err() == nil
 	}
 
-	oldPath := os.Getenv("PATH")
-	os.Setenv("PATH", tmpDir+":"+oldPath)
-	defer os.Setenv("PATH", oldPath)
+	return h
+}
 
-	s := NewSupervisor(SupervisorConfig{
-		ConfigPath:       configPath,
-		MinBackoff:       100 * time.Millisecond,
-		MaxBackoff:       1 * time.Second,
-		SuccessThreshold: 1 * time.Second,
-		Logger:           logger,
-	})
+// run starts the supervisor loop and stops it when the test ends.
+func (h *supervisorHarness) run(t *testing.T) {
+	t.Helper()
 
-	// Start supervisor
-	go s.Run()
+	done := make(chan struct{})
+	go func() {
+		h.s.Run()
+		close(done)
+	}()
+	t.Cleanup(func() {
+		h.s.Stop()
+		<-done
+	})
+}
 
-	// Wait for process to start
-	time.Sleep(200 * time.Millisecond)
+// nextStart waits for the supervisor to start a process.
+func (h *supervisorHarness) nextStart(t *testing.T) *process.FakeProcess {
+	t.Helper()
 
-	// Verify running
-	if !s.IsRunning() {
-		t.Error("Supervisor should have started process")
+	select {
+	case p := <-h.runner.Starts():
+		if got := p.Command.Args; len(got) != 2 || got[0] != "-config" || got[1] != "/etc/nebula/config.yml" {
+			t.Errorf("Process args = %v, want [-config /etc/nebula/config.yml]", got)
+		}
+		return p
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the supervisor to start a process")
+		return nil
 	}
+}
 
-	if s.PID() <= 0 {
-		t.Error("PID should be positive")
-	}
+// nextBackoff waits for the supervisor to back off before a restart.
+func (h *supervisorHarness) nextBackoff(t *testing.T) time.Duration {
+	t.Helper()
 
-	// Stop supervisor
-	if err := s.Stop(); err != nil {
-		t.Fatalf("Failed to stop supervisor: %v", err)
+	select {
+	case d := <-h.backoffs:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the supervisor to back off")
+		return 0
 	}
+}
 
-	// Wait a bit
-	time.Sleep(200 * time.Millisecond)
+// waitForPID waits until the supervisor reports pid as its running process.
+func (h *supervisorHarness) waitForPID(t *testing.T, pid int) {
+	t.Helper()
 
-	// Should be stopped
-	if s.IsRunning() {
-		t.Error("Process should be stopped")
+	deadline := time.Now().Add(5 * time.Second)
+	for !(h.s.IsRunning() && h.s.PID() == pid) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Supervisor PID = %d (running %v), want %d", h.s.PID(), h.s.IsRunning(), pid)
+		}
+		time.Sleep(time.Millisecond)
 	}
 }
 
-func TestSupervisor_AutoRestart(t *testing.T) {
-	logger := zaptest.NewLogger(t)
-
-	tmpDir := t.TempDir()
-	configPath := filepath.Join(tmpDir, "config.yml")
-	if err := os.WriteFile(configPath, []byte("test: config\n"), 0644); err != nil {
-		t.Fatalf("Failed to write config: %v", err)
-	}
+// currentBackoff returns the delay the next crash will back off for.
+func (h *supervisorHarness) currentBackoff() time.Duration {
+	h.s.mu.RLock()
+	defer h.s.mu.RUnlock()
+	return h.s.currentBackoff
+}
 
-	// Script that exits immediately (simulating crash)
-	scriptPath := filepath.Join(tmpDir, "nebula")
-	script := `#!/bin/sh
-exit 1
-`
-	if err := os.WriteFile(scriptPath, []byte(script), 0755); err != nil {
-		t.Fatalf("Failed to write script: %v", err)
+// hasSignal reports whether sig was sent to p.
+func hasSignal(p *process.FakeProcess, sig syscall.Signal) bool {
+	for _, s := range p.Signals() {
+		if s == sig {
+			return true
+		}
 	}
+	return false
+}
 
-	oldPath := os.Getenv("PATH")
-	os.Setenv("PATH", tmpDir+":"+oldPath)
-	defer os.Setenv("PATH", oldPath)
-
-	s := NewSupervisor(SupervisorConfig{
-		ConfigPath:       configPath,
-		MinBackoff:       50 * time.Millisecond,
-		MaxBackoff:       500 * time.Millisecond,
+func TestSupervisor_StartStop(t *testing.T) {
+	h := newSupervisorHarness(t, SupervisorConfig{
+		MinBackoff:       100 * time.Millisecond,
+		MaxBackoff:       1 * time.Second,
 		SuccessThreshold: 1 * time.Second,
-		Logger:           logger,
 	})
 
-	// Start supervisor
-	go s.Run()
+	done := make(chan struct{})
+	go func() {
+		h.s.Run()
+		close(done)
+	}()
 
-	// Wait for multiple restart attempts
-	time.Sleep(300 * time.Millisecond)
+	p := h.nextStart(t)
+	h.waitForPID(t, p.PID())
 
 	// Stop supervisor
-	s.Stop()
+	if err := h.s.Stop(); err != nil {
+		t.Fatalf("Failed to stop supervisor: %v", err)
+	}
+	<-done
 
-	// Test passed if we got here without hanging
+	if !p.Exited() {
+		t.Error("Process should have been stopped")
+	}
+	if h.s.IsRunning() {
+		t.Error("Process should be stopped")
+	}
 }
 
-func TestSupervisor_BackoffIncreases(t *testing.T) {
-	logger := zaptest.NewLogger(t)
+func TestSupervisor_AutoRestart(t *testing.T) {
+	h := newSupervisorHarness(t, SupervisorConfig{
+		MinBackoff:       50 * time.Millisecond,
+		MaxBackoff:       500 * time.Millisecond,
+		SuccessThreshold: 1 * time.Second,
+	})
+	h.run(t)
 
-	tmpDir := t.TempDir()
-	configPath := filepath.Join(tmpDir, "config.yml")
-	if err := os.WriteFile(configPath, []byte("test: config\n"), 0644); err != nil {
-		t.Fatalf("Failed to write config: %v", err)
+	// Each crash is followed by a backoff and a new process
+	for i := 0; i < 3; i++ {
+		p := h.nextStart(t)
+		p.Exit(errCrash)
+		h.nextBackoff(t)
 	}
+	p := h.nextStart(t)
+	h.waitForPID(t, p.PID())
 
-	// Script that exits immediately
-	scriptPath := filepath.Join(tmpDir, "nebula")
-	script := `#!/bin/sh
-exit 1
-`
-	if err := os.WriteFile(scriptPath, []byte(script), 0755); err != nil {
-		t.Fatalf("Failed to write script: %v", err)
+	if got := len(h.runner.Started()); got != 4 {
+		t.Errorf("Expected 4 process starts, got %d", got)
 	}
+}
 
-	oldPath := os.Getenv("PATH")
-	os.Setenv("PATH", tmpDir+":"+oldPath)
-	defer os.Setenv("PATH", oldPath)
-
+func TestSupervisor_BackoffIncreases(t *testing.T) {
 	minBackoff := 10 * time.Millisecond
 	maxBackoff := 100 * time.Millisecond
 
-	s := NewSupervisor(SupervisorConfig{
-		ConfigPath:       configPath,
+	h := newSupervisorHarness(t, SupervisorConfig{
 		MinBackoff:       minBackoff,
 		MaxBackoff:       maxBackoff,
 		SuccessThreshold: 1 * time.Second,
-		Logger:           logger,
 	})
-
-	// Start supervisor
-	go s.Run()
-
-	// Let it crash and restart a few times
-	time.Sleep(300 * time.Millisecond)
-
-	// Check that backoff increased
-	if s.currentBackoff < minBackoff*2 {
-		t.Error("Backoff should have increased after crashes")
+	h.run(t)
+
+	// Backoff doubles after every crash and is capped at the maximum
+	want := []time.Duration{
+		10 * time.Millisecond,
+		20 * time.Millisecond,
+		40 * time.Millisecond,
+		80 * time.Millisecond,
+		100 * time.Millisecond,
+		100 * time.Millisecond,
 	}
-
-	// Should not exceed max
-	if s.currentBackoff > maxBackoff {
-		t.Error("Backoff should not exceed max")
+	for i, w := range want {
+		h.nextStart(t).Exit(errCrash)
+		if got := h.nextBackoff(t); got != w {
+			t.Errorf("Backoff after crash %d = %v, want %v", i+1, got, w)
+		}
 	}
+	h.nextStart(t)
 
-	s.Stop()
+	if got := h.currentBackoff(); got != maxBackoff {
+		t.Errorf("Backoff should stay at max %v, got %v", maxBackoff, got)
+	}
 }
 
-func TestSupervisor_Restart(t *testing.T) {
-	logger := zaptest.NewLogger(t)
+func TestSupervisor_StartFailureBacksOff(t *testing.T) {
+	h := newSupervisorHarness(t, SupervisorConfig{
+		MinBackoff:       10 * time.Millisecond,
+		MaxBackoff:       100 * time.Millisecond,
+		SuccessThreshold: 1 * time.Second,
+	})
 
-	tmpDir := t.TempDir()
-	configPath := filepath.Join(tmpDir, "config.yml")
-	if err := os.WriteFile(configPath, []byte("test: config\n"), 0644); err != nil {
-		t.Fatalf("Failed to write config: %v", err)
-	}
+	// The binary cannot be started until it is fixed
+	h.runner.FailStarts(errors.New("exec: \"nebula\": executable file not found in $PATH"))
+	h.run(t)
 
-	scriptPath := filepath.Join(tmpDir, "nebula")
-	script := `#!/bin/sh
-sleep 5
-`
-	if err := os.WriteFile(scriptPath, []byte(script), 0755); err != nil {
-		t.Fatalf("Failed to write script: %v", err)
+	if got := h.nextBackoff(t); got != 10*time.Millisecond {
+		t.Errorf("First backoff = %v, want 10ms", got)
+	}
+	if got := h.nextBackoff(t); got != 20*time.Millisecond {
+		t.Errorf("Second backoff = %v, want 20ms", got)
 	}
 
-	oldPath := os.Getenv("PATH")
-	os.Setenv("PATH", tmpDir+":"+oldPath)
-	defer os.Setenv("PATH", oldPath)
+	h.runner.FailStarts(nil)
+	p := h.nextStart(t)
+	h.waitForPID(t, p.PID())
+}
 
-	s := NewSupervisor(SupervisorConfig{
-		ConfigPath:       configPath,
+func TestSupervisor_Restart(t *testing.T) {
+	h := newSupervisorHarness(t, SupervisorConfig{
 		MinBackoff:       100 * time.Millisecond,
 		MaxBackoff:       1 * time.Second,
 		SuccessThreshold: 1 * time.Second,
-		Logger:           logger,
 	})
+	h.run(t)
 
-	go s.Run()
-
-	// Wait for process to start
-	time.Sleep(200 * time.Millisecond)
-
-	firstPID := s.PID()
-	if firstPID <= 0 {
-		t.Fatal("Process should be running")
-	}
+	first := h.nextStart(t)
+	h.waitForPID(t, first.PID())
 
 	// Request restart
-	s.Restart()
+	h.s.Restart()
 
-	// Wait for restart to complete
-	time.Sleep(500 * time.Millisecond)
+	second := h.nextStart(t)
+	h.waitForPID(t, second.PID())
 
-	// Should have a new PID
-	newPID := s.PID()
-	if newPID <= 0 {
-		t.Error("Process should be running after restart")
+	if !hasSignal(first, syscall.SIGTERM) || !first.Exited() {
+		t.Error("First process should have been stopped with SIGTERM")
 	}
-
-	// PID may or may not change depending on timing, but process should be running
-	// The important thing is that restart was triggered and completed
-	if !s.IsRunning() {
-		t.Error("Process should be running after restart")
+	if second.PID() == first.PID() {
+		t.Error("Restarted process should have a new PID")
 	}
 
-	// Clean stop
-	s.Stop()
+	// A requested restart does not back off
+	select {
+	case d := <-h.backoffs:
+		t.Errorf("Unexpected backoff of %v on requested restart", d)
+	default:
+	}
 }
 
 func TestSupervisor_BackoffReset(t *testing.T) {
-	logger := zaptest.NewLogger(t)
-
-	tmpDir := t.TempDir()
-	configPath := filepath.Join(tmpDir, "config.yml")
-	if err := os.WriteFile(configPath, []byte("test: config\n"), 0644); err != nil {
-		t.Fatalf("Failed to write config: %v", err)
-	}
-
-	// Script that runs successfully for a while
-	scriptPath := filepath.Join(tmpDir, "nebula")
-	script := `#!/bin/sh
-sleep 1
-`
-	if err := os.WriteFile(scriptPath, []byte(script), 0755); err != nil {
-		t.Fatalf("Failed to write script: %v", err)
-	}
-
-	oldPath := os.Getenv("PATH")
-	os.Setenv("PATH", tmpDir+":"+oldPath)
-	defer os.Setenv("PATH", oldPath)
-
 	minBackoff := 10 * time.Millisecond
-	s := NewSupervisor(SupervisorConfig{
-		ConfigPath:       configPath,
+	successThreshold := 500 * time.Millisecond
+
+	h := newSupervisorHarness(t, SupervisorConfig{
 		MinBackoff:       minBackoff,
 		MaxBackoff:       100 * time.Millisecond,
-		SuccessThreshold: 500 * time.Millisecond,
-		Logger:           logger,
+		SuccessThreshold: successThreshold,
 	})
 
 	// Manually set high backoff
-	s.currentBackoff = 100 * time.Millisecond
-
-	go s.Run()
-	defer s.Stop()
-
-	// Wait for process to run successfully long enough to reset backoff
-	// The process runs for 1 second, success threshold is 500ms
-	time.Sleep(1500 * time.Millisecond)
-
-	// Backoff should be reset to min after successful run
-	// Allow some tolerance since this is timing-dependent
-	if s.currentBackoff > minBackoff*2 {
-		t.Errorf("Backoff should be reset close to %v, got %v", minBackoff, s.currentBackoff)
+	h.s.currentBackoff = 100 * time.Millisecond
+	h.run(t)
+
+	// A crash after running past the success threshold still backs off, but
+	// the next crash starts again from the minimum
+	p := h.nextStart(t)
+	h.waitForPID(t, p.PID())
+	h.clock.Advance(successThreshold)
+	p.Exit(errCrash)
+
+	if got := h.nextBackoff(t); got != 100*time.Millisecond {
+		t.Errorf("Backoff after long run = %v, want 100ms", got)
 	}
-}
 
-func TestSupervisor_GracefulShutdown(t *testing.T) {
-	logger := zaptest.NewLogger(t)
-
-	tmpDir := t.TempDir()
-	configPath := filepath.Join(tmpDir, "config.yml")
-	if err := os.WriteFile(configPath, []byte("test: config\n"), 0644); err != nil {
-		t.Fatalf("Failed to write config: %v", err)
+	p = h.nextStart(t)
+	h.waitForPID(t, p.PID())
+	if got := h.currentBackoff(); got != minBackoff {
+		t.Errorf("Backoff should be reset to %v, got %v", minBackoff, got)
 	}
 
-	scriptPath := filepath.Join(tmpDir, "nebula")
-	script := `#!/bin/sh
-# Trap SIGTERM and exit cleanly
-trap 'exit 0' TERM
-sleep 30 &
-wait $!
-`
-	if err := os.WriteFile(scriptPath, []byte(script), 0755); err != nil {
-		t.Fatalf("Failed to write script: %v", err)
+	// A crash shortly after starting does not reset the backoff
+	h.clock.Advance(successThreshold / 2)
+	p.Exit(errCrash)
+	if got := h.nextBackoff(t); got != minBackoff {
+		t.Errorf("Backoff after short run = %v, want %v", got, minBackoff)
 	}
+	h.nextStart(t)
+	if got := h.currentBackoff(); got != 2*minBackoff {
+		t.Errorf("Backoff should have doubled to %v, got %v", 2*minBackoff, got)
+	}
+}
 
-	oldPath := os.Getenv("PATH")
-	os.Setenv("PATH", tmpDir+":"+oldPath)
-	defer os.Setenv("PATH", oldPath)
-
-	s := NewSupervisor(SupervisorConfig{
-		ConfigPath:       configPath,
+func TestSupervisor_GracefulShutdown(t *testing.T) {
+	h := newSupervisorHarness(t, SupervisorConfig{
 		MinBackoff:       100 * time.Millisecond,
 		MaxBackoff:       1 * time.Second,
 		SuccessThreshold: 1 * time.Second,
-		Logger:           logger,
 	})
 
-	go s.Run()
+	go h.s.Run()
 
-	// Wait for process to start
-	time.Sleep(200 * time.Millisecond)
+	p := h.nextStart(t)
+	h.waitForPID(t, p.PID())
 
 	// Stop should complete quickly (graceful shutdown)
 	done := make(chan struct{})
 	go func() {
-		s.Stop()
+		h.s.Stop()
 		close(done)
 	}()
 
 	select {
 	case <-done:
 		// Success
-	case <-time.After(15 * time.Second):
-		t.Fatal("Stop took too long (should be < 15 seconds)")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop took too long")
 	}
-}
-
-func TestSupervisor_MultipleRestarts(t *testing.T) {
-	logger := zaptest.NewLogger(t)
 
-	tmpDir := t.TempDir()
-	configPath := filepath.Join(tmpDir, "config.yml")
-	if err := os.WriteFile(configPath, []byte("test: config\n"), 0644); err != nil {
-		t.Fatalf("Failed to write config: %v", err)
+	if !p.Exited() {
+		t.Error("Process should have exited on shutdown")
 	}
-
-	scriptPath := filepath.Join(tmpDir, "nebula")
-	script := `#!/bin/sh
-sleep 5
-`
-	if err := os.WriteFile(scriptPath, []byte(script), 0755); err != nil {
-		t.Fatalf("Failed to write script: %v", err)
+	if got := len(h.runner.Started()); got != 1 {
+		t.Errorf("Process should not be restarted on shutdown, got %d starts", got)
 	}
+}
 
-	oldPath := os.Getenv("PATH")
-	os.Setenv("PATH", tmpDir+":"+oldPath)
-	defer os.Setenv("PATH", oldPath)
-
-	s := NewSupervisor(SupervisorConfig{
-		ConfigPath:       configPath,
+func TestSupervisor_MultipleRestarts(t *testing.T) {
+	h := newSupervisorHarness(t, SupervisorConfig{
 		MinBackoff:       50 * time.Millisecond,
 		MaxBackoff:       500 * time.Millisecond,
 		SuccessThreshold: 1 * time.Second,
-		Logger:           logger,
 	})
+	h.run(t)
 
-	go s.Run()
-
-	// Wait for initial start
-	time.Sleep(100 * time.Millisecond)
-
-	// Request a couple of restarts (not too many to avoid race)
-	s.Restart()
-	time.Sleep(100 * time.Millisecond)
-	s.Restart()
+	procs := []*process.FakeProcess{h.nextStart(t)}
+	h.waitForPID(t, procs[0].PID())
 
-	// Wait for restart to settle
-	time.Sleep(300 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		h.s.Restart()
+		p := h.nextStart(t)
+		h.waitForPID(t, p.PID())
+		procs = append(procs, p)
+	}
 
-	// Should still be running
-	if !s.IsRunning() {
+	for i, p := range procs[:3] {
+		if !hasSignal(p, syscall.SIGTERM) || !p.Exited() {
+			t.Errorf("Process %d should have been stopped with SIGTERM", i)
+		}
+	}
+	if procs[3].Exited() || !h.s.IsRunning() {
 		t.Error("Process should be running after restarts")
 	}
-
-	// Clean stop
-	s.Stop()
 }
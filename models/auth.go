@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// AuthLockout describes a source temporarily locked out after repeated
+// failed token validations.
+//
+// Lockouts are held in memory by each control plane instance, so every
+// instance reports only the lockouts it enforces.
+type AuthLockout struct {
+	// SourceIP is the client IP address the failures came from
+	SourceIP string `json:"source_ip"`
+
+	// TokenFingerprint identifies the token prefix the lockout is scoped to
+	// Empty when lockouts are scoped to the source IP only
+	TokenFingerprint string `json:"token_fingerprint,omitempty"`
+
+	// TokenType is the type of token that last failed ("cluster" or "node")
+	TokenType string `json:"token_type"`
+
+	// Failures is the number of failed validations in the window that triggered the lockout
+	Failures int `json:"failures"`
+
+	// LockedUntil is when the source may authenticate again
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// AuthLockoutReport lists the lockouts active on one control plane instance.
+type AuthLockoutReport struct {
+	// InstanceID is the UUID of the reporting control plane instance
+	InstanceID string `json:"instance_id"`
+
+	// Scope is how failures are grouped: "ip" or "ip_token"
+	// Empty when lockout is disabled on this instance
+	Scope string `json:"scope,omitempty"`
+
+	// Lockouts lists the active lockouts, soonest to expire first
+	Lockouts []AuthLockout `json:"lockouts"`
+}
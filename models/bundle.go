@@ -26,16 +26,34 @@ type ConfigBundle struct {
 
 	// Data is the raw tar.gz bundle data
 	// Maximum size: 10 MiB (10,485,760 bytes)
+	// Not populated when only metadata is requested
 	Data []byte `json:"-" db:"data"`
 
+	// Size is the size of Data in bytes
+	Size int64 `json:"size" db:"size"`
+
+	// Checksum is the SHA-256 of Data (hex)
+	// Empty for bundles uploaded before checksums were recorded
+	Checksum string `json:"checksum,omitempty" db:"checksum"`
+
 	// CreatedBy is the UUID of the node that uploaded this bundle
 	// May be null if the node was deleted after upload
 	CreatedBy *string `json:"created_by,omitempty" db:"created_by"`
 
+	// Description is an optional note supplied by the uploader
+	Description string `json:"description,omitempty" db:"description"`
+
+	// Signature is the uploader's detached Ed25519 signature of Data
+	// Empty for unsigned bundles
+	Signature []byte `json:"signature,omitempty" db:"signature"`
+
 	// CreatedAt is the timestamp when this bundle was uploaded
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
+// MaxBundleDescriptionLength is the maximum length of a bundle description.
+const MaxBundleDescriptionLength = 256
+
 // BundleVersionResponse represents the response for checking the latest bundle version.
 type BundleVersionResponse struct {
 	// LatestVersion is the most recent configuration version available
@@ -52,6 +70,26 @@ type BundleUploadResponse struct {
 	UploadedAt time.Time `json:"uploaded_at"`
 }
 
+// BundleUploadResult describes a stored bundle, derived while validating it.
+type BundleUploadResult struct {
+	// Version is the version number assigned to the bundle
+	Version int64 `json:"version"`
+
+	// CompressedSize is the size of the uploaded tar.gz in bytes
+	// Uploads are rejected above bundle.MaxBundleSize
+	CompressedSize int64 `json:"compressed_size"`
+
+	// UncompressedSize is the total size of the files in the bundle in bytes
+	UncompressedSize int64 `json:"uncompressed_size"`
+
+	// FileCount is the number of files in the bundle
+	FileCount int `json:"file_count"`
+
+	// Checksum is the SHA-256 of the bundle (hex), which downloads carry in
+	// the X-Config-Checksum header
+	Checksum string `json:"checksum"`
+}
+
 // BundleValidationError represents an error during bundle validation.
 type BundleValidationError struct {
 	// Message is the human-readable error message
@@ -69,3 +107,164 @@ func (e *BundleValidationError) Error() string {
 	}
 	return e.Message
 }
+
+// BundleRetentionRequest represents the request body for setting how many
+// bundle versions of a cluster are kept.
+type BundleRetentionRequest struct {
+	// Keep is the number of latest versions kept by the scheduled prune (required)
+	// Zero keeps every version
+	Keep *int `json:"keep" binding:"required,min=0"`
+}
+
+// Change types of a file in a bundle diff.
+const (
+	// BundleFileAdded is a file only present in the newer version
+	BundleFileAdded = "added"
+
+	// BundleFileRemoved is a file only present in the older version
+	BundleFileRemoved = "removed"
+
+	// BundleFileChanged is a file whose content differs between versions
+	BundleFileChanged = "changed"
+)
+
+// BundleDiff lists the files that differ between two bundle versions.
+type BundleDiff struct {
+	// FromVersion is the version compared against
+	FromVersion int64 `json:"from_version"`
+
+	// ToVersion is the version whose changes are reported
+	ToVersion int64 `json:"to_version"`
+
+	// Files are the added, removed and changed files, sorted by name
+	// Unchanged files are omitted; file contents are never included
+	Files []BundleFileChange `json:"files"`
+}
+
+// BundleFileChange is a file that differs between two bundle versions.
+type BundleFileChange struct {
+	// Name is the file's path within the bundle (e.g., "config.yml")
+	Name string `json:"name"`
+
+	// Change is BundleFileAdded, BundleFileRemoved or BundleFileChanged
+	Change string `json:"change"`
+
+	// FromSize is the file's size in bytes in FromVersion
+	// Zero for added files
+	FromSize int64 `json:"from_size"`
+
+	// ToSize is the file's size in bytes in ToVersion
+	// Zero for removed files
+	ToSize int64 `json:"to_size"`
+}
+
+// MaxVersionBatchSize is the maximum number of clusters in one batched
+// version check.
+const MaxVersionBatchSize = 100
+
+// VersionBatchRequest represents the request body for checking the current
+// config version of several clusters in one call.
+type VersionBatchRequest struct {
+	// Clusters is the list of clusters to check (required)
+	// At most MaxVersionBatchSize entries
+	Clusters []VersionBatchEntry `json:"clusters" binding:"required"`
+}
+
+// VersionBatchEntry identifies one cluster in a batched version check.
+//
+// Each entry is authenticated on its own with either a node token belonging
+// to the cluster or the cluster token. Entries that fail authentication are
+// reported individually and do not fail the whole batch. Entries carry no
+// request signature, so clusters that require signed requests are refused.
+type VersionBatchEntry struct {
+	// TenantID is the UUID of the tenant owning the cluster
+	TenantID string `json:"tenant_id"`
+
+	// ClusterID is the UUID of the cluster
+	ClusterID string `json:"cluster_id"`
+
+	// NodeToken is a node token for a node in the cluster
+	NodeToken string `json:"node_token,omitempty"`
+
+	// ClusterToken is the cluster token, used when NodeToken is empty
+	ClusterToken string `json:"cluster_token,omitempty"`
+}
+
+// ClusterVersion is the result for one cluster in a batched version check.
+type ClusterVersion struct {
+	// TenantID is the UUID of the tenant owning the cluster
+	TenantID string `json:"tenant_id"`
+
+	// ClusterID is the UUID of the cluster
+	ClusterID string `json:"cluster_id"`
+
+	// Version is the current config version
+	// Zero when Error is set
+	Version int64 `json:"version"`
+
+	// Error is the error code if the version could not be returned
+	// "unauthorized" for bad credentials or an unknown cluster, so cluster
+	// existence is not disclosed, or "signed_requests_required" if the
+	// cluster requires signed requests (use GET /api/v1/config/version)
+	Error string `json:"error,omitempty"`
+}
+
+// CanaryRollout is a bundle version made available to a subset of a cluster's
+// nodes before it is promoted cluster-wide.
+type CanaryRollout struct {
+	// ClusterID is the UUID of the cluster
+	ClusterID string `json:"cluster_id"`
+
+	// Version is the bundle version under canary
+	Version int64 `json:"version"`
+
+	// StableVersion is the version served to nodes outside NodeIDs
+	// Replacing a canary keeps the stable version of the one it replaces
+	StableVersion int64 `json:"stable_version"`
+
+	// NodeIDs are the nodes that receive Version
+	// Empty after a rollback, when every node is served StableVersion
+	NodeIDs []string `json:"node_ids"`
+
+	// CreatedAt is when the canary was defined
+	CreatedAt time.Time `json:"created_at"`
+
+	// RolledBackAt is when the canary was automatically rolled back
+	// Nil while the canary is active
+	RolledBackAt *time.Time `json:"rolled_back_at,omitempty"`
+
+	// RollbackReason explains why the canary was rolled back
+	RollbackReason string `json:"rollback_reason,omitempty"`
+}
+
+// CanaryNodeHealth is a canary node's health as reported by its daemon.
+type CanaryNodeHealth struct {
+	// NodeID is the UUID of the canary node
+	NodeID string
+
+	// HealthyBefore is true if the node was healthy when the canary was defined
+	// Nodes that were already unhealthy are not held against the canary
+	HealthyBefore bool
+
+	// LastHeartbeatAt is when the node last reported (nil if never)
+	LastHeartbeatAt *time.Time
+
+	// ReportedVersion is the config version the node reported running
+	ReportedVersion int64
+
+	// Status is the last reported health status
+	Status string
+
+	// Message is the error detail reported with an error status
+	Message string
+}
+
+// CanaryRequest represents the request body for defining a canary rollout.
+type CanaryRequest struct {
+	// Version is the bundle version to roll out (required)
+	// Must be the latest uploaded bundle
+	Version int64 `json:"version" binding:"required,min=1"`
+
+	// NodeIDs are the canary nodes (required, at least one)
+	NodeIDs []string `json:"node_ids" binding:"required,min=1"`
+}
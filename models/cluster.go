@@ -33,6 +33,38 @@ type Cluster struct {
 	// Only used if ProvideLighthouse is true
 	LighthousePort int `json:"lighthouse_port,omitempty" db:"lighthouse_port"`
 
+	// MaxRelayHops limits how many relays traffic may traverse in this cluster
+	// Default: 1 (single relay hop)
+	// Valid range: 0-4, where 0 disables relaying
+	MaxRelayHops int `json:"max_relay_hops" db:"max_relay_hops"`
+
+	// LighthouseDNSEnabled indicates whether lighthouses serve DNS for node names
+	// Default: false
+	LighthouseDNSEnabled bool `json:"lighthouse_dns_enabled" db:"lighthouse_dns_enabled"`
+
+	// LighthouseDNSHost is the address lighthouse DNS listens on
+	// Default: 0.0.0.0
+	LighthouseDNSHost string `json:"lighthouse_dns_host,omitempty" db:"lighthouse_dns_host"`
+
+	// LighthouseDNSPort is the port lighthouse DNS listens on
+	// Default: 53
+	// Must differ from the Nebula UDP port
+	LighthouseDNSPort int `json:"lighthouse_dns_port,omitempty" db:"lighthouse_dns_port"`
+
+	// Cipher is the Nebula cipher used by every node in the cluster
+	// Default: aes
+	// Valid values: aes, chachapoly
+	Cipher string `json:"cipher" db:"cipher"`
+
+	// Handshake holds optional Nebula handshake tuning
+	// Nil means Nebula defaults are used
+	Handshake *HandshakeConfig `json:"handshake,omitempty" db:"-"`
+
+	// RequireSignedRequests indicates whether node requests must be signed
+	// with the node token (timestamp + nonce) to prevent replay
+	// Default: false
+	RequireSignedRequests bool `json:"require_signed_requests" db:"require_signed_requests"`
+
 	// ConfigVersion is the current configuration version for this cluster
 	// Incremented whenever PKI changes, node topology changes, or routes are updated
 	// Nodes compare this against their local version to detect updates
@@ -74,6 +106,71 @@ type ClusterCreateRequest struct {
 	LighthousePort int `json:"lighthouse_port,omitempty"`
 }
 
+// LighthouseDNSConfig represents a cluster's lighthouse DNS settings.
+// Used both as the request body and the response for the DNS endpoints.
+type LighthouseDNSConfig struct {
+	// Enabled indicates whether lighthouses serve DNS for node names
+	Enabled bool `json:"enabled"`
+
+	// Host is the DNS listen address
+	// Default: 0.0.0.0
+	Host string `json:"host,omitempty"`
+
+	// Port is the DNS listen port
+	// Default: 53
+	// Must differ from the Nebula UDP port
+	Port int `json:"port,omitempty"`
+}
+
+// HandshakeConfig represents a cluster's Nebula handshake tuning.
+// Zero values mean the Nebula default is used.
+type HandshakeConfig struct {
+	// TryInterval is the delay between handshake attempts (e.g., "100ms")
+	// Valid range: 10ms-5s
+	TryInterval string `json:"try_interval,omitempty"`
+
+	// Retries is the number of handshake attempts before giving up
+	// Valid range: 0-100 (0 = Nebula default)
+	Retries int `json:"retries,omitempty"`
+
+	// TriggerBuffer is the size of the pending handshake trigger queue
+	// Valid range: 0-4096 (0 = Nebula default)
+	TriggerBuffer int `json:"trigger_buffer,omitempty"`
+}
+
+// ClusterCipherRequest represents the request body for changing a cluster's cipher.
+type ClusterCipherRequest struct {
+	// Cipher is the new Nebula cipher (required)
+	// Valid values: aes, chachapoly
+	Cipher string `json:"cipher" binding:"required"`
+
+	// Force confirms the change
+	// Nodes on different ciphers cannot talk to each other until all of them
+	// have picked up the new config, so the change must be explicitly confirmed
+	Force bool `json:"force"`
+}
+
+// ClusterRequestSigningRequest represents the request body for requiring
+// or no longer requiring signed node requests in a cluster.
+type ClusterRequestSigningRequest struct {
+	// Required turns request signing on or off (required)
+	// Enable it only after every node's client signs its requests
+	Required *bool `json:"required" binding:"required"`
+}
+
+// RoutePolicy holds the rules node routes must follow in a cluster.
+type RoutePolicy struct {
+	// IPv4Only rejects IPv6 routes
+	// Set it for clusters whose nodes cannot route IPv6 networks
+	IPv4Only bool `json:"ipv4_only"`
+
+	// AllowOverlappingRoutes lets nodes advertise routes that overlap another
+	// node's routes (e.g., 10.0.0.0/8 and 10.1.0.0/16)
+	// Nebula cannot pick a single gateway for overlapping routes, so only
+	// enable it when the ambiguity is handled outside NebulaGC
+	AllowOverlappingRoutes bool `json:"allow_overlapping_routes"`
+}
+
 // ClusterCreateResponse represents the response after creating a cluster.
 type ClusterCreateResponse struct {
 	// Cluster is the created cluster (without sensitive fields)
@@ -93,6 +190,40 @@ type ClusterListResponse struct {
 
 	// Total is the total number of clusters
 	Total int `json:"total"`
+
+	// Page is the current page number (if pagination is used)
+	Page int `json:"page,omitempty"`
+
+	// PerPage is the number of clusters per page (if pagination is used)
+	PerPage int `json:"per_page,omitempty"`
+}
+
+// ClusterStats summarizes a cluster for dashboards without listing its nodes.
+type ClusterStats struct {
+	// ClusterID is the UUID of the cluster
+	ClusterID string `json:"cluster_id"`
+
+	// NodeCount is the total number of nodes in the cluster
+	NodeCount int `json:"node_count"`
+
+	// AdminCount is the number of admin nodes
+	AdminCount int `json:"admin_count"`
+
+	// LighthouseCount is the number of nodes acting as lighthouses
+	LighthouseCount int `json:"lighthouse_count"`
+
+	// RelayCount is the number of nodes acting as relays
+	RelayCount int `json:"relay_count"`
+
+	// RouteCount is the total number of routes advertised by all nodes
+	RouteCount int `json:"route_count"`
+
+	// ConfigVersion is the cluster's current config version
+	ConfigVersion int64 `json:"config_version"`
+
+	// LastChangeAt is when the config version last changed
+	// Nil if the cluster has not changed since it was created
+	LastChangeAt *time.Time `json:"last_change_at,omitempty"`
 }
 
 // ClusterState tracks the running configuration version for each control plane instance.
@@ -112,3 +243,84 @@ type ClusterState struct {
 	// UpdatedAt is the timestamp when this state was last updated
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
+
+// Config change types recorded in a cluster's config history.
+const (
+	// ConfigChangeBundleUploaded is a config bundle upload
+	ConfigChangeBundleUploaded = "bundle_uploaded"
+
+	// ConfigChangeNodeCreated is a node joining the cluster
+	ConfigChangeNodeCreated = "node_created"
+
+	// ConfigChangeNodesCreated is a batch of nodes joining the cluster;
+	// Detail is the number of nodes
+	ConfigChangeNodesCreated = "nodes_created"
+
+	// ConfigChangeNodeDeleted is a node being removed from the cluster
+	ConfigChangeNodeDeleted = "node_deleted"
+
+	// ConfigChangeNodeRestored is a deleted node being restored
+	ConfigChangeNodeRestored = "node_restored"
+
+	// ConfigChangeNodeMTU is a change to a node's MTU
+	ConfigChangeNodeMTU = "mtu_updated"
+
+	// ConfigChangeNodeTokenRotated is a node token rotation
+	ConfigChangeNodeTokenRotated = "node_token_rotated"
+
+	// ConfigChangePreferredRelays is a change to a node's preferred relays
+	ConfigChangePreferredRelays = "preferred_relays_updated"
+
+	// ConfigChangeRoutes is a change to a node's advertised routes
+	ConfigChangeRoutes = "routes_updated"
+
+	// ConfigChangeLighthouseSet is a node being made a lighthouse
+	ConfigChangeLighthouseSet = "lighthouse_set"
+
+	// ConfigChangeLighthouseUnset is a node losing lighthouse status
+	ConfigChangeLighthouseUnset = "lighthouse_unset"
+
+	// ConfigChangeRelaySet is a node being made a relay
+	ConfigChangeRelaySet = "relay_set"
+
+	// ConfigChangeRelayUnset is a node losing relay status
+	ConfigChangeRelayUnset = "relay_unset"
+
+	// ConfigChangeClusterSettings is a change to cluster-wide settings
+	// (relay hops, lighthouse DNS, subnet, static hosts, preferred ranges,
+	// cipher or handshake tuning); Detail names the setting
+	ConfigChangeClusterSettings = "cluster_settings_updated"
+
+	// ConfigChangeSnapshotImported is a topology snapshot import
+	ConfigChangeSnapshotImported = "snapshot_imported"
+)
+
+// ConfigChange is one entry in a cluster's config history: a config version
+// and what produced it.
+type ConfigChange struct {
+	// Version is the config version the change produced
+	Version int64 `json:"version"`
+
+	// Type is what triggered the change (see the ConfigChange constants)
+	Type string `json:"type"`
+
+	// Actor is the ID of the node that made the change
+	// Empty when the change was not made by an authenticated node
+	Actor string `json:"actor,omitempty"`
+
+	// Detail identifies the subject of the change, such as the affected node
+	// ID or the changed setting (optional)
+	Detail string `json:"detail,omitempty"`
+
+	// CreatedAt is when the change was made
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ConfigHistoryResponse is the response body for a cluster's config history.
+type ConfigHistoryResponse struct {
+	// ClusterID is the UUID of the cluster
+	ClusterID string `json:"cluster_id"`
+
+	// Changes lists config changes newest first
+	Changes []ConfigChange `json:"changes"`
+}
@@ -1,6 +1,10 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 // Common error types used throughout the NebulaGC application.
 // These errors provide semantic meaning and enable consistent error handling
@@ -63,10 +67,44 @@ var (
 	// HTTP equivalent: 400 Bad Request
 	ErrInvalidCIDR = errors.New("invalid CIDR notation")
 
+	// ErrIPv6NotAllowed indicates an IPv6 route was advertised in a cluster
+	// restricted to IPv4 routes. Returned errors wrap it with the offending route.
+	// HTTP equivalent: 400 Bad Request
+	ErrIPv6NotAllowed = errors.New("IPv6 routes are not allowed in this cluster")
+
+	// ErrRouteOverlap indicates a route overlaps a route advertised by another
+	// node in the cluster. Returned errors wrap it with the conflicting node and CIDR.
+	// HTTP equivalent: 409 Conflict
+	ErrRouteOverlap = errors.New("route overlaps another node's route")
+
 	// ErrInvalidMTU indicates the MTU value is outside the valid range (1280-9000).
 	// HTTP equivalent: 400 Bad Request
 	ErrInvalidMTU = errors.New("MTU must be between 1280 and 9000 bytes")
 
+	// ErrSnapshotNodesMissing indicates a topology snapshot references nodes
+	// that do not exist in the target cluster.
+	// HTTP equivalent: 400 Bad Request
+	ErrSnapshotNodesMissing = errors.New("snapshot references nodes missing from cluster")
+
+	// ErrRelayMeshSaturated indicates a relay assignment would make every node
+	// in the cluster a relay, leaving no regular nodes to relay for.
+	// HTTP equivalent: 400 Bad Request
+	ErrRelayMeshSaturated = errors.New("every node in the cluster would be a relay")
+
+	// ErrNotRelay indicates a referenced node is not a relay.
+	// HTTP equivalent: 400 Bad Request
+	ErrNotRelay = errors.New("referenced node is not a relay")
+
+	// ErrDNSPortConflict indicates the lighthouse DNS port collides with the
+	// Nebula UDP port.
+	// HTTP equivalent: 400 Bad Request
+	ErrDNSPortConflict = errors.New("lighthouse DNS port conflicts with the Nebula UDP port")
+
+	// ErrConfirmationRequired indicates a disruptive change was requested
+	// without the force flag.
+	// HTTP equivalent: 400 Bad Request
+	ErrConfirmationRequired = errors.New("change is disruptive and requires confirmation")
+
 	// ErrConflict indicates the resource already exists.
 	// HTTP equivalent: 409 Conflict
 	ErrConflict = errors.New("resource already exists")
@@ -83,6 +121,16 @@ var (
 	// HTTP equivalent: 413 Payload Too Large
 	ErrBundleTooLarge = errors.New("config bundle exceeds 10 MiB limit")
 
+	// ErrInvalidBundleFormat indicates the uploaded bundle is not gzip-compressed
+	// (e.g., a zip archive or plain text file was uploaded instead of a .tar.gz).
+	// HTTP equivalent: 400 Bad Request
+	ErrInvalidBundleFormat = errors.New("config bundle is not a gzip-compressed tar archive (.tar.gz)")
+
+	// ErrUploadConflict indicates a bundle upload collided with a concurrent
+	// upload to the same cluster. The upload was not stored and can be retried.
+	// HTTP equivalent: 503 Service Unavailable
+	ErrUploadConflict = errors.New("concurrent bundle upload conflict, retry the upload")
+
 	// ErrRateLimitExceeded indicates too many requests from this client.
 	// HTTP equivalent: 429 Too Many Requests
 	ErrRateLimitExceeded = errors.New("rate limit exceeded")
@@ -104,6 +152,43 @@ var (
 	ErrServiceUnavailable = errors.New("service unavailable")
 )
 
+// BatchError reports which item of a batch request failed. The whole batch
+// was rejected; Err is the failure of the item at Index.
+type BatchError struct {
+	// Index is the position of the failing item in the request (0-based)
+	Index int
+
+	// Err is the error for that item
+	Err error
+}
+
+// Error implements the error interface for BatchError.
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch item %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the error of the failing item.
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// AuthLockedOutError reports that a source was locked out after repeated
+// failed token validations. It wraps ErrRateLimitExceeded.
+type AuthLockedOutError struct {
+	// RetryAfter is how long until the source may authenticate again
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface for AuthLockedOutError.
+func (e *AuthLockedOutError) Error() string {
+	return fmt.Sprintf("too many failed authentication attempts, retry after %s", e.RetryAfter)
+}
+
+// Unwrap returns ErrRateLimitExceeded.
+func (e *AuthLockedOutError) Unwrap() error {
+	return ErrRateLimitExceeded
+}
+
 // ErrorResponse represents a standardized API error response.
 type ErrorResponse struct {
 	// Error is the human-readable error message
@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"nebulagc.io/pkg/token"
+)
 
 // Node represents a machine enrolled in a Nebula cluster.
 // Each node belongs to exactly one tenant and one cluster.
@@ -59,6 +64,11 @@ type Node struct {
 	// Relays forward traffic for nodes that cannot establish direct connections
 	IsRelay bool `json:"is_relay" db:"is_relay"`
 
+	// PreferredRelays is a JSON array of relay node IDs this node should use
+	// (e.g., ["uuid-1", "uuid-2"]). When empty, all cluster relays are used.
+	// Each entry must reference a relay node in the same cluster
+	PreferredRelays string `json:"preferred_relays,omitempty" db:"preferred_relays"`
+
 	// LighthouseRelayUpdatedAt is the timestamp when lighthouse/relay status was last modified
 	LighthouseRelayUpdatedAt *time.Time `json:"lighthouse_relay_updated_at,omitempty" db:"lighthouse_relay_updated_at"`
 
@@ -82,6 +92,17 @@ type NodeCreateRequest struct {
 	MTU int `json:"mtu,omitempty"`
 }
 
+// MaxNodeBatchSize is the maximum number of nodes created in one batch.
+const MaxNodeBatchSize = 500
+
+// NodeBatchCreateRequest represents the request body for creating several
+// nodes at once. Either every node is created or none is.
+type NodeBatchCreateRequest struct {
+	// Nodes is the list of nodes to create (required)
+	// At most MaxNodeBatchSize entries
+	Nodes []NodeCreateRequest `json:"nodes" binding:"required,min=1,dive"`
+}
+
 // NodeCredentials represents the response after creating a node.
 // This is the only time the node_token is returned.
 type NodeCredentials struct {
@@ -102,6 +123,15 @@ type NodeCredentials struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// String formats the credentials with both tokens redacted, so they can be
+// logged without leaking secrets. JSON encoding is unaffected.
+func (c NodeCredentials) String() string {
+	type plain NodeCredentials // see token.Redact
+	c.NodeToken = token.Redact(c.NodeToken)
+	c.ClusterToken = token.Redact(c.ClusterToken)
+	return fmt.Sprintf("%+v", plain(c))
+}
+
 // NodeSummary represents a node in list responses (without sensitive fields).
 type NodeSummary struct {
 	// NodeID is the UUID of the node
@@ -125,6 +155,9 @@ type NodeSummary struct {
 	// Routes is the list of CIDR strings this node advertises
 	Routes []string `json:"routes,omitempty"`
 
+	// PreferredRelays is the list of relay node IDs this node prefers
+	PreferredRelays []string `json:"preferred_relays,omitempty"`
+
 	// CreatedAt is the timestamp when this node was created
 	CreatedAt time.Time `json:"created_at"`
 
@@ -132,6 +165,48 @@ type NodeSummary struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// NodeIdentity describes the node that owns the token used for a request.
+// It lets a node confirm its credentials without admin privileges.
+type NodeIdentity struct {
+	// TenantID is the UUID of the tenant the node belongs to
+	TenantID string `json:"tenant_id"`
+
+	// ClusterID is the UUID of the cluster the node belongs to
+	ClusterID string `json:"cluster_id"`
+
+	// NodeID is the UUID of the node
+	NodeID string `json:"node_id"`
+
+	// Name is the human-readable node name
+	Name string `json:"name"`
+
+	// IsAdmin indicates whether this node has admin privileges
+	IsAdmin bool `json:"is_admin"`
+
+	// IsLighthouse indicates whether this node acts as a lighthouse
+	IsLighthouse bool `json:"is_lighthouse"`
+
+	// IsRelay indicates whether this node acts as a relay
+	IsRelay bool `json:"is_relay"`
+}
+
+// NodeListFilter restricts the nodes returned when listing. Zero-valued
+// fields do not filter, so an empty filter lists every node.
+type NodeListFilter struct {
+	// NameContains keeps nodes whose name contains this substring
+	// (case-insensitive for ASCII letters)
+	NameContains string
+
+	// IsAdmin keeps nodes whose admin flag matches
+	IsAdmin *bool
+
+	// IsLighthouse keeps nodes whose lighthouse flag matches
+	IsLighthouse *bool
+
+	// IsRelay keeps nodes whose relay flag matches
+	IsRelay *bool
+}
+
 // NodeListResponse represents the response for listing nodes.
 type NodeListResponse struct {
 	// ClusterID is the UUID of the cluster these nodes belong to
@@ -204,8 +279,38 @@ type NodeLighthouseRequest struct {
 	LighthousePort int `json:"lighthouse_port,omitempty"`
 }
 
+// NodePreferredRelaysRequest represents the request body for setting a node's preferred relays.
+type NodePreferredRelaysRequest struct {
+	// RelayNodeIDs is the ordered list of relay node IDs this node should use
+	// Empty array clears the preference (all cluster relays are used)
+	// Each ID must reference a relay node in the same cluster
+	RelayNodeIDs []string `json:"relay_node_ids" binding:"required"`
+}
+
 // NodeRelayRequest represents the request body for setting relay status.
 type NodeRelayRequest struct {
 	// IsRelay indicates whether to enable or disable relay status
 	IsRelay bool `json:"is_relay"`
 }
+
+// Node health statuses reported by daemons.
+const (
+	// NodeHealthOK means the node is running its config version normally
+	NodeHealthOK = "ok"
+
+	// NodeHealthError means the node failed to apply or run its config version
+	NodeHealthError = "error"
+)
+
+// NodeHeartbeatRequest represents the request body for a node health report.
+type NodeHeartbeatRequest struct {
+	// Version is the config version the node is running
+	Version int64 `json:"version" binding:"min=0"`
+
+	// Status is the node's health: "ok" or "error"
+	Status string `json:"status" binding:"required,oneof=ok error"`
+
+	// Message describes the failure when Status is "error" (optional)
+	// Maximum length: 1024 characters
+	Message string `json:"message,omitempty" binding:"max=1024"`
+}
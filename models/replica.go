@@ -10,6 +10,10 @@ type Replica struct {
 	// Set via NEBULAGC_INSTANCE_ID environment variable or auto-generated UUID
 	ID string `json:"id" db:"id"`
 
+	// Name is a human-friendly name for this instance (e.g., "cp-eu-west-1")
+	// Set via NEBULAGC_INSTANCE_NAME; defaults to the host name
+	Name string `json:"name,omitempty" db:"name"`
+
 	// Address is the full URL for this control plane instance
 	// Examples: "https://control1.example.com", "https://10.0.1.5:8080"
 	// Must include protocol (http/https) and port if non-standard
@@ -31,6 +35,9 @@ type Replica struct {
 
 // ReplicaInfo represents replica information in API responses.
 type ReplicaInfo struct {
+	// Name is the human-friendly name of this control plane instance
+	Name string `json:"name,omitempty"`
+
 	// Address is the full URL for this control plane instance
 	Address string `json:"address"`
 
@@ -58,3 +65,42 @@ type CheckMasterResponse struct {
 	// false = this is a replica (read-only)
 	Master bool `json:"master"`
 }
+
+// ClusterConsistency summarizes the data one control plane instance holds for
+// a cluster, for comparison across instances.
+type ClusterConsistency struct {
+	// ClusterID is the cluster's unique identifier
+	ClusterID string `json:"cluster_id"`
+
+	// ConfigVersion is the cluster's config version on this instance
+	ConfigVersion int64 `json:"config_version"`
+
+	// LatestBundleVersion is the newest stored bundle version (0 if none)
+	LatestBundleVersion int64 `json:"latest_bundle_version"`
+
+	// ContentHash is a SHA-256 (hex) over the cluster's settings, nodes,
+	// bundles and canary rollout. Instances that are in sync report the same
+	// hash; node health reports are excluded since they change constantly.
+	ContentHash string `json:"content_hash"`
+}
+
+// ConsistencyReport is the response for GET /api/v1/admin/consistency.
+// An external checker fetches it from every instance and compares the
+// per-cluster versions and hashes to detect replication lag or divergence.
+type ConsistencyReport struct {
+	// InstanceID is the control plane instance that produced the report
+	InstanceID string `json:"instance_id"`
+
+	// IsMaster indicates whether the instance is the master
+	IsMaster bool `json:"is_master"`
+
+	// LastApplied is the replication position last applied to this
+	// instance's database, as recorded by the replication tool (empty if unknown)
+	LastApplied string `json:"last_applied,omitempty"`
+
+	// GeneratedAt is when the report was produced
+	GeneratedAt time.Time `json:"generated_at"`
+
+	// Clusters holds one entry per cluster of the tenant, ordered by ID
+	Clusters []ClusterConsistency `json:"clusters"`
+}
@@ -31,4 +31,29 @@ type TenantListResponse struct {
 
 	// Total is the total number of tenants
 	Total int `json:"total"`
+
+	// Page is the current page number (if pagination is used)
+	Page int `json:"page,omitempty"`
+
+	// PerPage is the number of tenants per page (if pagination is used)
+	PerPage int `json:"per_page,omitempty"`
+}
+
+// TenantStats summarizes resource usage across all of a tenant's clusters.
+type TenantStats struct {
+	// TenantID is the UUID of the tenant
+	TenantID string `json:"tenant_id"`
+
+	// ClusterCount is the number of clusters owned by the tenant
+	ClusterCount int `json:"cluster_count"`
+
+	// NodeCount is the total number of nodes across the tenant's clusters
+	NodeCount int `json:"node_count"`
+
+	// BundleCount is the number of stored config bundle versions
+	BundleCount int `json:"bundle_count"`
+
+	// BundleStorageBytes is the total size of all stored config bundles
+	// Useful for tracking database growth, since every upload is kept
+	BundleStorageBytes int64 `json:"bundle_storage_bytes"`
 }
@@ -103,3 +103,53 @@ type ClusterTokenRotateResponse struct {
 	// RotatedAt is the timestamp when the token was rotated
 	RotatedAt time.Time `json:"rotated_at"`
 }
+
+// Topology change actions accepted by TopologyWhatIfRequest.
+const (
+	// TopologyChangeSetLighthouse makes a node a lighthouse (PublicIP required).
+	TopologyChangeSetLighthouse = "set_lighthouse"
+
+	// TopologyChangeUnsetLighthouse demotes a lighthouse.
+	TopologyChangeUnsetLighthouse = "unset_lighthouse"
+
+	// TopologyChangeSetRelay makes a node a relay.
+	TopologyChangeSetRelay = "set_relay"
+
+	// TopologyChangeUnsetRelay demotes a relay.
+	TopologyChangeUnsetRelay = "unset_relay"
+
+	// TopologyChangeSetMaxRelayHops changes the cluster's relay hop limit.
+	TopologyChangeSetMaxRelayHops = "set_max_relay_hops"
+)
+
+// TopologyChange is one proposed change to a cluster's topology.
+type TopologyChange struct {
+	// Action is one of the TopologyChange* actions (required)
+	Action string `json:"action" binding:"required"`
+
+	// NodeID is the node the change applies to (all actions but set_max_relay_hops)
+	NodeID string `json:"node_id,omitempty"`
+
+	// PublicIP is the lighthouse's public IP address (set_lighthouse)
+	PublicIP string `json:"public_ip,omitempty"`
+
+	// Port is the lighthouse's UDP port (set_lighthouse, 0 = cluster default)
+	Port int `json:"port,omitempty"`
+
+	// MaxRelayHops is the new relay hop limit (set_max_relay_hops)
+	MaxRelayHops int `json:"max_relay_hops,omitempty"`
+}
+
+// TopologyWhatIfRequest represents the request body for simulating topology
+// changes. The changes are applied in order to a copy of the cluster's
+// topology and never persisted.
+type TopologyWhatIfRequest struct {
+	// Proto is the protocol to evaluate: tcp, udp or icmp (default icmp)
+	Proto string `json:"proto,omitempty"`
+
+	// Port is the destination port to evaluate (ignored for icmp)
+	Port int `json:"port,omitempty"`
+
+	// Changes is the list of proposed changes (required)
+	Changes []TopologyChange `json:"changes" binding:"required,min=1,dive"`
+}
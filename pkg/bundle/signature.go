@@ -0,0 +1,115 @@
+package bundle
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// Sign returns the detached Ed25519 signature of a bundle.
+//
+// Parameters:
+//   - key: The uploading admin's private key
+//   - data: The bundle data as bytes
+//
+// Returns:
+//   - []byte: The signature (ed25519.SignatureSize bytes)
+func Sign(key ed25519.PrivateKey, data []byte) []byte {
+	return ed25519.Sign(key, data)
+}
+
+// Verify checks a bundle's detached Ed25519 signature.
+//
+// Parameters:
+//   - key: The cluster's bundle signing public key
+//   - data: The bundle data as bytes
+//   - signature: The detached signature served with the bundle
+//
+// Returns:
+//   - error: ErrUnsignedBundle if signature is empty, ErrInvalidSignature if
+//     it does not match, or nil if the bundle is authentic
+func Verify(key ed25519.PublicKey, data, signature []byte) error {
+	if len(signature) == 0 {
+		return ErrUnsignedBundle
+	}
+	if len(signature) != ed25519.SignatureSize || !ed25519.Verify(key, data, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ParsePublicKey parses an Ed25519 public key, given either as base64 of the
+// 32 raw key bytes or as a PEM "PUBLIC KEY" block (as written by
+// `openssl pkey -pubout`).
+//
+// Parameters:
+//   - s: The encoded public key
+//
+// Returns:
+//   - ed25519.PublicKey: The parsed key
+//   - error: Error if s is not an Ed25519 public key
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	s = strings.TrimSpace(s)
+	if block, _ := pem.Decode([]byte(s)); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("invalid public key: not an Ed25519 key")
+		}
+		return edKey, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key: want %d bytes, got %d", ed25519.PublicKeySize, len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// ParsePrivateKey parses an Ed25519 private key, given either as base64 of
+// the 32-byte seed or the 64-byte key, or as a PEM "PRIVATE KEY" block (as
+// written by `openssl genpkey -algorithm ed25519`).
+//
+// Parameters:
+//   - s: The encoded private key
+//
+// Returns:
+//   - ed25519.PrivateKey: The parsed key
+//   - error: Error if s is not an Ed25519 private key
+func ParsePrivateKey(s string) (ed25519.PrivateKey, error) {
+	s = strings.TrimSpace(s)
+	if block, _ := pem.Decode([]byte(s)); block != nil {
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %w", err)
+		}
+		edKey, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("invalid private key: not an Ed25519 key")
+		}
+		return edKey, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	default:
+		return nil, fmt.Errorf("invalid private key: want %d or %d bytes, got %d",
+			ed25519.SeedSize, ed25519.PrivateKeySize, len(raw))
+	}
+}
@@ -0,0 +1,97 @@
+package bundle
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	otherPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	data := []byte("bundle data")
+	signature := Sign(private, data)
+
+	if err := Verify(public, data, signature); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if err := Verify(public, []byte("tampered data"), signature); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() of tampered data error = %v, want ErrInvalidSignature", err)
+	}
+	if err := Verify(otherPublic, data, signature); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() with another key error = %v, want ErrInvalidSignature", err)
+	}
+	if err := Verify(public, data, signature[:10]); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() of a short signature error = %v, want ErrInvalidSignature", err)
+	}
+	if err := Verify(public, data, nil); !errors.Is(err, ErrUnsignedBundle) {
+		t.Errorf("Verify() without a signature error = %v, want ErrUnsignedBundle", err)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	pkix, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey() error = %v", err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+
+	publicEncodings := map[string]string{
+		"base64": base64.StdEncoding.EncodeToString(public),
+		"pem":    string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix})),
+	}
+	for name, encoded := range publicEncodings {
+		key, err := ParsePublicKey(encoded)
+		if err != nil {
+			t.Errorf("ParsePublicKey(%s) error = %v", name, err)
+			continue
+		}
+		if !key.Equal(public) {
+			t.Errorf("ParsePublicKey(%s) returned a different key", name)
+		}
+	}
+
+	privateEncodings := map[string]string{
+		"base64 seed": base64.StdEncoding.EncodeToString(private.Seed()),
+		"base64 key":  base64.StdEncoding.EncodeToString(private),
+		"pem":         string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})),
+	}
+	for name, encoded := range privateEncodings {
+		key, err := ParsePrivateKey(encoded)
+		if err != nil {
+			t.Errorf("ParsePrivateKey(%s) error = %v", name, err)
+			continue
+		}
+		if !key.Equal(private) {
+			t.Errorf("ParsePrivateKey(%s) returned a different key", name)
+		}
+	}
+
+	for _, invalid := range []string{"", "not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := ParsePublicKey(invalid); err == nil {
+			t.Errorf("ParsePublicKey(%q) expected error", invalid)
+		}
+		if _, err := ParsePrivateKey(invalid); err == nil {
+			t.Errorf("ParsePrivateKey(%q) expected error", invalid)
+		}
+	}
+}
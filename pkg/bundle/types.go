@@ -50,11 +50,24 @@ var (
 	// ErrMissingRequiredFile indicates a required file is missing from the bundle.
 	ErrMissingRequiredFile = errors.New("bundle is missing required file")
 
-	// ErrInvalidYAML indicates the config.yml file contains invalid YAML.
-	ErrInvalidYAML = errors.New("config.yml contains invalid YAML")
+	// ErrInvalidConfigYAML indicates the config.yml file is not valid YAML or
+	// its pki section does not reference the bundled certificates and key.
+	ErrInvalidConfigYAML = errors.New("config.yml is invalid")
+
+	// ErrInvalidYAML is the former name of ErrInvalidConfigYAML.
+	//
+	// Deprecated: Use ErrInvalidConfigYAML.
+	ErrInvalidYAML = ErrInvalidConfigYAML
 
 	// ErrEmptyBundle indicates the bundle contains no files.
 	ErrEmptyBundle = errors.New("bundle contains no files")
+
+	// ErrUnsignedBundle indicates a bundle that must be signed has no signature.
+	ErrUnsignedBundle = errors.New("bundle is not signed")
+
+	// ErrInvalidSignature indicates a bundle's signature does not match the
+	// signing public key.
+	ErrInvalidSignature = errors.New("bundle signature is invalid")
 )
 
 // ValidationResult holds the result of bundle validation.
@@ -17,6 +17,7 @@ import (
 // - Archive format (must be valid gzip tar)
 // - Required files presence
 // - YAML syntax in config.yml
+// - pki.ca, pki.cert and pki.key in config.yml referencing the bundled files
 //
 // Parameters:
 //   - data: The bundle data as bytes
@@ -108,15 +109,12 @@ func Validate(data []byte) *ValidationResult {
 		}
 	}
 
-	// Validate config.yml YAML syntax
-	if len(configYAML) > 0 {
-		var config interface{}
-		if err := yaml.Unmarshal(configYAML, &config); err != nil {
-			return &ValidationResult{
-				Valid: false,
-				Error: fmt.Errorf("%w: %v", ErrInvalidYAML, err),
-				Size:  totalSize,
-			}
+	// Validate config.yml syntax and its references to the bundled PKI files
+	if err := validateConfigYAML(configYAML); err != nil {
+		return &ValidationResult{
+			Valid: false,
+			Error: err,
+			Size:  totalSize,
 		}
 	}
 
@@ -132,3 +130,57 @@ func Validate(data []byte) *ValidationResult {
 		Size:  totalSize,
 	}
 }
+
+// pkiReference ties a pki setting in config.yml to the bundled file it must
+// reference.
+type pkiReference struct {
+	setting string
+	value   string
+	file    string
+}
+
+// validateConfigYAML parses config.yml and checks that its pki section
+// references the bundled CA certificate, host certificate and host key.
+//
+// Each setting must be a path whose file name is the bundled file (the
+// directory is where the daemon installs the bundle, so it is not checked)
+// or inline PEM data, which Nebula also accepts.
+//
+// Parameters:
+//   - data: The contents of config.yml
+//
+// Returns:
+//   - error: ErrInvalidConfigYAML with the parse error or the offending
+//     setting, or nil if the config is valid
+func validateConfigYAML(data []byte) error {
+	var config struct {
+		PKI struct {
+			CA   string `yaml:"ca"`
+			Cert string `yaml:"cert"`
+			Key  string `yaml:"key"`
+		} `yaml:"pki"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConfigYAML, err)
+	}
+
+	refs := []pkiReference{
+		{setting: "pki.ca", value: config.PKI.CA, file: RequiredFileCACert},
+		{setting: "pki.cert", value: config.PKI.Cert, file: RequiredFileHostCert},
+		{setting: "pki.key", value: config.PKI.Key, file: RequiredFileHostKey},
+	}
+	for _, ref := range refs {
+		value := strings.TrimSpace(ref.value)
+		switch {
+		case value == "":
+			return fmt.Errorf("%w: %s is not set", ErrInvalidConfigYAML, ref.setting)
+		case strings.HasPrefix(value, "-----BEGIN"):
+			// Inline PEM data
+		case value[strings.LastIndexAny(value, `/\`)+1:] != ref.file:
+			return fmt.Errorf("%w: %s is %q, must reference the bundled %s",
+				ErrInvalidConfigYAML, ref.setting, ref.value, ref.file)
+		}
+	}
+
+	return nil
+}
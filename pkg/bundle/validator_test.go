@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"errors"
+	"strings"
 	"testing"
 )
 
@@ -149,14 +150,97 @@ func TestValidate_InvalidYAML(t *testing.T) {
 		t.Error("Expected invalid bundle due to YAML syntax")
 	}
 
-	if !errors.Is(result.Error, ErrInvalidYAML) {
-		t.Errorf("Expected ErrInvalidYAML, got %v", result.Error)
+	if !errors.Is(result.Error, ErrInvalidConfigYAML) {
+		t.Errorf("Expected ErrInvalidConfigYAML, got %v", result.Error)
+	}
+}
+
+func TestValidate_ConfigYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name:   "relative paths",
+			config: "pki:\n  ca: ca.crt\n  cert: host.crt\n  key: host.key\n",
+		},
+		{
+			name:   "windows paths",
+			config: "pki:\n  ca: 'C:\\nebula\\ca.crt'\n  cert: 'C:\\nebula\\host.crt'\n  key: 'C:\\nebula\\host.key'\n",
+		},
+		{
+			name:   "inline ca",
+			config: "pki:\n  ca: |\n    -----BEGIN NEBULA CERTIFICATE-----\n    ca\n    -----END NEBULA CERTIFICATE-----\n  cert: /etc/nebula/host.crt\n  key: /etc/nebula/host.key\n",
+		},
+		{
+			name:    "unclosed quote",
+			config:  "pki:\n  ca: \"/etc/nebula/ca.crt\n",
+			wantErr: "found unexpected end of stream",
+		},
+		{
+			name:    "bad indentation",
+			config:  "pki:\n  ca: /etc/nebula/ca.crt\n cert: /etc/nebula/host.crt\n",
+			wantErr: "yaml:",
+		},
+		{
+			name:    "not a mapping",
+			config:  "- pki\n- listen\n",
+			wantErr: "cannot unmarshal",
+		},
+		{
+			name:    "empty config",
+			config:  "",
+			wantErr: "pki.ca is not set",
+		},
+		{
+			name:    "missing key",
+			config:  "pki:\n  ca: /etc/nebula/ca.crt\n  cert: /etc/nebula/host.crt\n",
+			wantErr: "pki.key is not set",
+		},
+		{
+			name:    "cert not in bundle",
+			config:  "pki:\n  ca: /etc/nebula/ca.crt\n  cert: /etc/nebula/node1.crt\n  key: /etc/nebula/host.key\n",
+			wantErr: `pki.cert is "/etc/nebula/node1.crt", must reference the bundled host.crt`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle := createTestBundle(map[string]string{
+				RequiredFileConfig:   tt.config,
+				RequiredFileCACert:   "ca cert",
+				RequiredFileCRL:      "crl",
+				RequiredFileHostCert: "host cert",
+				RequiredFileHostKey:  "key",
+			})
+
+			result := Validate(bundle)
+
+			if tt.wantErr == "" {
+				if !result.Valid {
+					t.Errorf("Expected valid bundle, got error: %v", result.Error)
+				}
+				return
+			}
+			if result.Valid {
+				t.Fatal("Expected invalid bundle")
+			}
+			if !errors.Is(result.Error, ErrInvalidConfigYAML) {
+				t.Errorf("Expected ErrInvalidConfigYAML, got %v", result.Error)
+			}
+			if !strings.Contains(result.Error.Error(), tt.wantErr) {
+				t.Errorf("Error %q does not contain %q", result.Error, tt.wantErr)
+			}
+		})
 	}
 }
 
 func TestValidate_ValidBundleWithExtraFiles(t *testing.T) {
 	validYAML := `pki:
   ca: /etc/nebula/ca.crt
+  cert: /etc/nebula/host.crt
+  key: /etc/nebula/host.key
 `
 
 	// Bundle with extra files (should still be valid)
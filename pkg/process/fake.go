@@ -0,0 +1,186 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// ErrKilled is the exit error of a FakeProcess that was killed.
+var ErrKilled = errors.New("signal: killed")
+
+// FakeRunner is a Runner for tests. It starts no real processes: each Start
+// returns a FakeProcess that runs until the test makes it exit, or until it is
+// signalled to terminate or killed. It is safe for concurrent use.
+type FakeRunner struct {
+	mu       sync.Mutex
+	nextPID  int
+	startErr error
+	started  []*FakeProcess
+	starts   chan *FakeProcess
+}
+
+// NewFakeRunner returns a FakeRunner with no processes started.
+func NewFakeRunner() *FakeRunner {
+	return &FakeRunner{
+		nextPID: 1000,
+		starts:  make(chan *FakeProcess, 256),
+	}
+}
+
+// Start records cmd and returns a running FakeProcess, or the error set by
+// FailStarts. Cancelling ctx kills the process, as with ExecRunner.
+func (r *FakeRunner) Start(ctx context.Context, cmd Command) (Handle, error) {
+	r.mu.Lock()
+	if r.startErr != nil {
+		err := r.startErr
+		r.mu.Unlock()
+		return nil, err
+	}
+	r.nextPID++
+	p := &FakeProcess{Command: cmd, pid: r.nextPID, done: make(chan struct{})}
+	r.started = append(r.started, p)
+	r.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = p.Kill()
+		case <-p.done:
+		}
+	}()
+
+	r.starts <- p
+	return p, nil
+}
+
+// FailStarts makes subsequent Start calls return err; nil lets them succeed again.
+func (r *FakeRunner) FailStarts(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.startErr = err
+}
+
+// Starts delivers each process as it is started, so tests can wait for a
+// start instead of sleeping.
+func (r *FakeRunner) Starts() <-chan *FakeProcess {
+	return r.starts
+}
+
+// Started returns every process started so far, oldest first.
+func (r *FakeRunner) Started() []*FakeProcess {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*FakeProcess(nil), r.started...)
+}
+
+// FakeProcess is the Handle returned by FakeRunner.
+type FakeProcess struct {
+	// Command is the command the process was started with.
+	Command Command
+
+	pid  int
+	done chan struct{}
+
+	mu            sync.Mutex
+	err           error
+	exited        bool
+	killed        bool
+	ignoreSignals bool
+	signals       []os.Signal
+}
+
+// PID returns the fake process ID.
+func (p *FakeProcess) PID() int {
+	return p.pid
+}
+
+// Wait blocks until the process exits and returns its exit error.
+func (p *FakeProcess) Wait() error {
+	<-p.done
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// Done is closed once the process has exited.
+func (p *FakeProcess) Done() <-chan struct{} {
+	return p.done
+}
+
+// Signal records sig. Interrupt and SIGTERM make the process exit cleanly
+// unless IgnoreSignals was set; other signals (such as SIGHUP) are only
+// recorded. Signalling an exited process returns os.ErrProcessDone.
+func (p *FakeProcess) Signal(sig os.Signal) error {
+	p.mu.Lock()
+	if p.exited {
+		p.mu.Unlock()
+		return os.ErrProcessDone
+	}
+	p.signals = append(p.signals, sig)
+	terminate := !p.ignoreSignals && (sig == os.Interrupt || sig == syscall.SIGTERM)
+	p.mu.Unlock()
+
+	if terminate {
+		p.Exit(nil)
+	}
+	return nil
+}
+
+// Kill makes the process exit with ErrKilled.
+func (p *FakeProcess) Kill() error {
+	p.mu.Lock()
+	if p.exited {
+		p.mu.Unlock()
+		return os.ErrProcessDone
+	}
+	p.killed = true
+	p.mu.Unlock()
+
+	p.Exit(ErrKilled)
+	return nil
+}
+
+// Exit makes the process exit with err (nil for a clean exit, non-nil to
+// simulate a crash). Only the first call has an effect.
+func (p *FakeProcess) Exit(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.exited {
+		return
+	}
+	p.exited = true
+	p.err = err
+	close(p.done)
+}
+
+// IgnoreSignals makes the process keep running when asked to terminate,
+// like a hung process that has to be killed.
+func (p *FakeProcess) IgnoreSignals() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ignoreSignals = true
+}
+
+// Signals returns the signals sent to the process, oldest first.
+func (p *FakeProcess) Signals() []os.Signal {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]os.Signal(nil), p.signals...)
+}
+
+// Killed reports whether the process was killed.
+func (p *FakeProcess) Killed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.killed
+}
+
+// Exited reports whether the process has exited.
+func (p *FakeProcess) Exited() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.exited
+}
@@ -0,0 +1,132 @@
+// Package process abstracts starting and controlling OS processes so that
+// code supervising Nebula (the daemon supervisor and the control plane's
+// lighthouse manager) can be tested without spawning real processes.
+//
+// Production code uses ExecRunner, which is backed by os/exec:
+//
+//	h, err := process.ExecRunner{}.Start(ctx, process.Command{
+//	    Path: "nebula",
+//	    Args: []string{"-config", configPath},
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//	_ = h.Signal(syscall.SIGTERM)
+//	err = h.Wait()
+//
+// Tests substitute a FakeRunner and decide when each process exits.
+package process
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// outputWaitDelay bounds how long a process's output is still copied once it
+// has exited, so children that inherited its stdout or stderr cannot hold up
+// Wait.
+const outputWaitDelay = time.Second
+
+// Command describes a process to start.
+type Command struct {
+	// Path is the executable to run, looked up on PATH if it has no separator.
+	Path string
+
+	// Args are the arguments passed to the executable.
+	Args []string
+
+	// Stdout receives the process's standard output (nil = discarded).
+	Stdout io.Writer
+
+	// Stderr receives the process's standard error (nil = discarded).
+	Stderr io.Writer
+}
+
+// Runner starts processes. It is the ProcessRunner abstraction shared by the
+// daemon supervisor and the lighthouse manager.
+type Runner interface {
+	// Start starts cmd. Cancelling ctx kills the process.
+	Start(ctx context.Context, cmd Command) (Handle, error)
+}
+
+// Handle controls a started process. All methods are safe for concurrent use.
+type Handle interface {
+	// PID returns the process ID.
+	PID() int
+
+	// Wait blocks until the process exits and returns its exit error
+	// (nil on a clean exit). It may be called any number of times.
+	Wait() error
+
+	// Done is closed once the process has exited.
+	Done() <-chan struct{}
+
+	// Signal sends sig to the process.
+	Signal(sig os.Signal) error
+
+	// Kill forcibly terminates the process.
+	Kill() error
+}
+
+// ExecRunner is a Runner backed by os/exec.
+type ExecRunner struct{}
+
+// Start starts cmd with exec.CommandContext.
+//
+// Parameters:
+//   - ctx: Kills the process when cancelled
+//   - cmd: The process to start
+//
+// Returns:
+//   - Handle: Control over the started process
+//   - error: Error if the process could not be started
+func (ExecRunner) Start(ctx context.Context, cmd Command) (Handle, error) {
+	c := exec.CommandContext(ctx, cmd.Path, cmd.Args...)
+	c.Stdout = cmd.Stdout
+	c.Stderr = cmd.Stderr
+	c.WaitDelay = outputWaitDelay
+
+	if err := c.Start(); err != nil {
+		return nil, err
+	}
+
+	h := &execHandle{cmd: c, done: make(chan struct{})}
+	// Reap the process as soon as it exits so Wait and Done never race
+	go func() {
+		h.err = c.Wait()
+		close(h.done)
+	}()
+
+	return h, nil
+}
+
+// execHandle is the Handle of a process started by ExecRunner.
+type execHandle struct {
+	cmd  *exec.Cmd
+	done chan struct{}
+	err  error // Set before done is closed
+}
+
+func (h *execHandle) PID() int {
+	return h.cmd.Process.Pid
+}
+
+func (h *execHandle) Wait() error {
+	<-h.done
+	return h.err
+}
+
+func (h *execHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+func (h *execHandle) Signal(sig os.Signal) error {
+	return h.cmd.Process.Signal(sig)
+}
+
+func (h *execHandle) Kill() error {
+	return h.cmd.Process.Kill()
+}
@@ -0,0 +1,198 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestHelperProcess is not a real test: it is the child process started by
+// the ExecRunner tests, selected through the GO_PROCESS_HELPER variable.
+func TestHelperProcess(t *testing.T) {
+	switch os.Getenv("GO_PROCESS_HELPER") {
+	case "echo":
+		fmt.Fprintln(os.Stdout, "hello")
+		fmt.Fprintln(os.Stderr, "oops")
+		os.Exit(0)
+	case "fail":
+		os.Exit(3)
+	case "sleep":
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	}
+}
+
+// startHelper starts this test binary as a helper process in the given mode.
+func startHelper(t *testing.T, ctx context.Context, mode string, stdout, stderr *bytes.Buffer) Handle {
+	t.Helper()
+	t.Setenv("GO_PROCESS_HELPER", mode)
+
+	cmd := Command{Path: os.Args[0], Args: []string{"-test.run=^TestHelperProcess$"}}
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+	if stderr != nil {
+		cmd.Stderr = stderr
+	}
+
+	h, err := ExecRunner{}.Start(ctx, cmd)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	return h
+}
+
+func TestExecRunner_Output(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	h := startHelper(t, context.Background(), "echo", &stdout, &stderr)
+
+	if h.PID() <= 0 {
+		t.Errorf("PID() = %d, want a positive PID", h.PID())
+	}
+	if err := h.Wait(); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	// Wait may be called again once the process is reaped
+	if err := h.Wait(); err != nil {
+		t.Errorf("second Wait() error = %v", err)
+	}
+	if stdout.String() != "hello\n" || stderr.String() != "oops\n" {
+		t.Errorf("output = %q / %q, want %q / %q", stdout.String(), stderr.String(), "hello\n", "oops\n")
+	}
+}
+
+func TestExecRunner_ExitError(t *testing.T) {
+	h := startHelper(t, context.Background(), "fail", nil, nil)
+
+	err := h.Wait()
+	var exitErr interface{ ExitCode() int }
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("Wait() error = %v, want exit code 3", err)
+	}
+}
+
+func TestExecRunner_Signal(t *testing.T) {
+	h := startHelper(t, context.Background(), "sleep", nil, nil)
+
+	if err := h.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("Signal() error = %v", err)
+	}
+	select {
+	case <-h.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("process did not exit after SIGTERM")
+	}
+	if err := h.Wait(); err == nil {
+		t.Error("Wait() error = nil, want the termination signal")
+	}
+}
+
+func TestExecRunner_ContextCancelKills(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := startHelper(t, ctx, "sleep", nil, nil)
+
+	cancel()
+	select {
+	case <-h.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("process did not exit after the context was cancelled")
+	}
+}
+
+func TestExecRunner_StartError(t *testing.T) {
+	_, err := ExecRunner{}.Start(context.Background(), Command{Path: "/nonexistent/nebula"})
+	if err == nil {
+		t.Error("Start() error = nil, want an error for a missing binary")
+	}
+}
+
+func TestFakeRunner_SignalsAndExit(t *testing.T) {
+	runner := NewFakeRunner()
+	h, err := runner.Start(context.Background(), Command{Path: "nebula", Args: []string{"-config", "c.yml"}})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	p := <-runner.Starts()
+	if p != h || p.Command.Args[1] != "c.yml" {
+		t.Fatalf("Starts() delivered %+v, want the started process", p)
+	}
+
+	// SIGHUP is recorded but does not stop the process
+	if err := h.Signal(syscall.SIGHUP); err != nil {
+		t.Fatalf("Signal(SIGHUP) error = %v", err)
+	}
+	if p.Exited() {
+		t.Fatal("process exited on SIGHUP")
+	}
+
+	if err := h.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("Signal(SIGTERM) error = %v", err)
+	}
+	if err := h.Wait(); err != nil {
+		t.Errorf("Wait() error = %v, want a clean exit", err)
+	}
+	if got := p.Signals(); len(got) != 2 || got[0] != syscall.SIGHUP || got[1] != syscall.SIGTERM {
+		t.Errorf("Signals() = %v, want [SIGHUP SIGTERM]", got)
+	}
+	if err := h.Signal(syscall.SIGTERM); !errors.Is(err, os.ErrProcessDone) {
+		t.Errorf("Signal() after exit error = %v, want os.ErrProcessDone", err)
+	}
+}
+
+func TestFakeRunner_CrashAndKill(t *testing.T) {
+	runner := NewFakeRunner()
+	crash := errors.New("exit status 1")
+
+	h, _ := runner.Start(context.Background(), Command{Path: "nebula"})
+	h.(*FakeProcess).Exit(crash)
+	if err := h.Wait(); !errors.Is(err, crash) {
+		t.Errorf("Wait() error = %v, want %v", err, crash)
+	}
+
+	h, _ = runner.Start(context.Background(), Command{Path: "nebula"})
+	p := h.(*FakeProcess)
+	p.IgnoreSignals()
+	_ = h.Signal(syscall.SIGTERM)
+	if p.Exited() {
+		t.Fatal("process ignoring signals exited on SIGTERM")
+	}
+	_ = h.Kill()
+	if err := h.Wait(); !errors.Is(err, ErrKilled) || !p.Killed() {
+		t.Errorf("Wait() error = %v, want ErrKilled", err)
+	}
+
+	if got := runner.Started(); len(got) != 2 || got[0].PID() == got[1].PID() {
+		t.Errorf("Started() = %v, want two processes with distinct PIDs", got)
+	}
+}
+
+func TestFakeRunner_FailStartsAndCancel(t *testing.T) {
+	runner := NewFakeRunner()
+	startErr := errors.New("exec: not found")
+
+	runner.FailStarts(startErr)
+	if _, err := runner.Start(context.Background(), Command{Path: "nebula"}); !errors.Is(err, startErr) {
+		t.Errorf("Start() error = %v, want %v", err, startErr)
+	}
+	runner.FailStarts(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h, err := runner.Start(ctx, Command{Path: "nebula"})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	cancel()
+	if err := h.Wait(); !errors.Is(err, ErrKilled) {
+		t.Errorf("Wait() after cancel error = %v, want ErrKilled", err)
+	}
+}
+
+var _ Runner = ExecRunner{}
+var _ Runner = (*FakeRunner)(nil)
+var _ Handle = (*FakeProcess)(nil)
@@ -14,6 +14,17 @@
 //	}
 //	// token is a 44-character base64-URL-encoded string
 //
+// GenerateN takes the entropy in bits (a multiple of 8, at least 128) for
+// integrations with other size needs. A token of n bits is EncodedLen(n) =
+// 4 * ceil(n / 24) characters long, so 384 bits fit a 64-character field:
+//
+//	token, err := token.GenerateN(512) // 88 characters
+//
+// GenerateContext honors a request's cancellation, and GenerateWithReader
+// takes the entropy source explicitly so tests can use a fixed reader:
+//
+//	token, err := token.GenerateWithReader(bytes.NewReader(fixedBytes))
+//
 // # Token Hashing
 //
 // Tokens are never stored in plaintext. Instead, they are hashed using HMAC-SHA256:
@@ -22,6 +33,20 @@
 //	hash := token.Hash(token, secret)
 //	// Store hash in database, never store token
 //
+// Where HMAC-SHA512 is required, HashWithAlgorithm produces a hash prefixed
+// with its algorithm, which Validate recognizes; unprefixed hashes are
+// HMAC-SHA256. ValidateWithAlgorithm additionally rejects hashes of any other
+// algorithm:
+//
+//	hash, err := token.HashWithAlgorithm(token, secret, token.SHA512)
+//	// hash is "sha512:<128 hex characters>"
+//
+// The NebulaGC server does not use HashWithAlgorithm or ValidateWithAlgorithm:
+// it stores node and cluster token hashes made with Hash and finds a token by
+// looking up Hash(provided, secret), so a token stored with a "sha512:" hash
+// never authenticates against the server. They are for callers that keep and
+// check their own hashes.
+//
 // # Token Validation
 //
 // Validation uses constant-time comparison to prevent timing attacks:
@@ -32,6 +57,20 @@
 //	    // Authentication failed
 //	}
 //
+// # Request Signing
+//
+// Clusters that require signed requests expect node requests to carry an
+// HMAC of the method, path, body, timestamp, and a nonce, keyed by the node
+// token. A captured request then cannot be replayed or altered:
+//
+//	nonce, _ := token.GenerateNonce()
+//	sig := token.SignRequest(nodeToken, method, requestURI, body, time.Now().Unix(), nonce)
+//
+//	err := token.VerifyRequest(nodeToken, sig, method, requestURI, body, ts, nonce,
+//	    time.Now(), token.DefaultSignatureMaxSkew)
+//
+// VerifyRequest rejects stale timestamps; the caller rejects repeated nonces.
+//
 // # Security Properties
 //
 //   - Minimum 41 characters (enforced)
@@ -41,6 +80,16 @@
 //   - Constant-time comparison (prevents timing attacks)
 //   - Never logs token values (only hashes)
 //
+// To correlate log entries about a token, such as a rotation, log its
+// Fingerprint: a short SHA-256 prefix that needs no secret. It only identifies
+// a token; it is not an authenticator and must never be accepted as one.
+//
+// Structs that carry tokens elsewhere (sdk.ClientConfig, sdk.Client,
+// sdk.NodeCredentials, models.NodeCredentials and the daemon's ClusterConfig)
+// implement fmt.Stringer with their tokens rendered by Redact as "***", so
+// logging them with zap.Any or %v cannot leak a token. zap.Reflect bypasses
+// String and must not be used on them.
+//
 // # Usage in NebulaGC
 //
 // This package is used for two types of tokens:
@@ -7,12 +7,17 @@
 package token
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"hash"
+	"io"
+	"strings"
 )
 
 const (
@@ -23,6 +28,9 @@ const (
 	// DefaultTokenBytes is the number of random bytes to generate for tokens.
 	// 32 bytes = 256 bits of entropy, which base64-encodes to 44 characters.
 	DefaultTokenBytes = 32
+
+	// MinTokenBits is the least entropy GenerateN accepts.
+	MinTokenBits = 128
 )
 
 // Generate creates a cryptographically secure random token suitable for authentication.
@@ -40,7 +48,42 @@ const (
 //	}
 //	// token is now a 44-character string suitable for authentication
 func Generate() (string, error) {
-	return GenerateWithLength(DefaultTokenBytes)
+	return GenerateWithReader(rand.Reader)
+}
+
+// GenerateContext creates a token like Generate, first checking that ctx is
+// still live so request-scoped cancellation and deadlines are honored.
+//
+// Parameters:
+//   - ctx: Request context
+//
+// Returns:
+//   - string: A base64-URL-encoded token
+//   - error: ctx.Err() if the context is done, or an error if random number generation fails
+func GenerateContext(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return Generate()
+}
+
+// GenerateWithReader creates a token of DefaultTokenBytes random bytes read
+// from r. Production code should use Generate, which reads crypto/rand; tests
+// can pass a fixed reader for deterministic tokens or a failing one to
+// exercise error paths.
+//
+// Parameters:
+//   - r: Source of random bytes
+//
+// Returns:
+//   - string: A base64-URL-encoded token
+//   - error: An error if r cannot supply enough bytes
+//
+// Example:
+//
+//	token, err := token.GenerateWithReader(bytes.NewReader(make([]byte, 32)))
+func GenerateWithReader(r io.Reader) (string, error) {
+	return generate(r, DefaultTokenBytes)
 }
 
 // GenerateWithLength creates a cryptographically secure random token of specified byte length.
@@ -60,19 +103,62 @@ func Generate() (string, error) {
 //	    return fmt.Errorf("failed to generate token: %w", err)
 //	}
 func GenerateWithLength(numBytes int) (string, error) {
+	return generate(rand.Reader, numBytes)
+}
+
+// GenerateN creates a cryptographically secure random token with the given
+// number of bits of entropy, for integrations that need shorter or longer
+// tokens than Generate produces. The token is EncodedLen(bits) characters long
+// and works with Hash and Validate like any other token.
+//
+// Tokens used as NebulaGC node or cluster credentials must still pass
+// ValidateLength, which takes at least 248 bits.
+//
+// Parameters:
+//   - bits: Entropy in bits (a multiple of 8, at least MinTokenBits)
+//
+// Returns:
+//   - string: A base64-URL-encoded token
+//   - error: An error if bits is invalid or random number generation fails
+//
+// Example:
+//
+//	token, err := token.GenerateN(384) // 64 characters
+//	if err != nil {
+//	    return fmt.Errorf("failed to generate token: %w", err)
+//	}
+func GenerateN(bits int) (string, error) {
+	if bits < MinTokenBits || bits%8 != 0 {
+		return "", fmt.Errorf("token entropy must be a multiple of 8 bits and at least %d bits, got %d", MinTokenBits, bits)
+	}
+	return readToken(rand.Reader, bits/8)
+}
+
+// EncodedLen returns the length in characters of a token with the given bits
+// of entropy: 4 * ceil(bits / 24), since base64 encodes every 3 bytes as 4
+// padded characters. For example, 128 bits take 24 characters, 256 bits 44,
+// 384 bits 64 and 512 bits 88. Use it to size columns that store tokens.
+//
+// Parameters:
+//   - bits: Entropy in bits (a multiple of 8)
+//
+// Returns:
+//   - int: Token length in characters
+func EncodedLen(bits int) int {
+	return base64.URLEncoding.EncodedLen(bits / 8)
+}
+
+// generate creates a token from numBytes bytes read from r.
+func generate(r io.Reader, numBytes int) (string, error) {
 	if numBytes < DefaultTokenBytes {
 		return "", fmt.Errorf("token length must be at least %d bytes", DefaultTokenBytes)
 	}
 
-	// Generate cryptographically secure random bytes
-	b := make([]byte, numBytes)
-	if _, err := rand.Read(b); err != nil {
-		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	token, err := readToken(r, numBytes)
+	if err != nil {
+		return "", err
 	}
 
-	// Encode to base64-URL for safe string representation
-	token := base64.URLEncoding.EncodeToString(b)
-
 	// Verify length meets minimum requirement
 	if len(token) < MinTokenLength {
 		return "", fmt.Errorf("generated token too short: got %d, need %d", len(token), MinTokenLength)
@@ -81,6 +167,18 @@ func GenerateWithLength(numBytes int) (string, error) {
 	return token, nil
 }
 
+// readToken reads numBytes bytes from r and base64-URL-encodes them.
+func readToken(r io.Reader, numBytes int) (string, error) {
+	// Read the random bytes; a short read is an error, never a weaker token
+	b := make([]byte, numBytes)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+
+	// Encode to base64-URL for safe string representation
+	return base64.URLEncoding.EncodeToString(b), nil
+}
+
 // Hash produces an HMAC-SHA256 hash of the token using the provided secret.
 // The hash is returned as a hex-encoded string suitable for database storage.
 //
@@ -102,16 +200,177 @@ func GenerateWithLength(numBytes int) (string, error) {
 //	hash := token.Hash(token, secret)
 //	// Store hash in database, never store token
 func Hash(token, secret string) string {
-	h := hmac.New(sha256.New, []byte(secret))
-	h.Write([]byte(token))
+	return hmacHex(sha256.New, token, secret)
+}
+
+// FingerprintLength is the length in characters of a token fingerprint.
+const FingerprintLength = 8
+
+// Fingerprint returns a short identifier of a token for correlating log
+// entries, such as a rotation logged by both the server and a node, without
+// writing the token itself: the first FingerprintLength hex characters of the
+// token's SHA-256.
+//
+// A fingerprint is NOT an authenticator. It needs no secret, anyone holding
+// the token can compute it, and at 32 bits it collides easily; never store it
+// in place of Hash or accept it as a credential.
+//
+// Parameters:
+//   - token: The plaintext token
+//
+// Returns:
+//   - string: FingerprintLength hex characters
+//
+// Example:
+//
+//	logger.Info("Rotated node token", zap.String("token_fingerprint", token.Fingerprint(newToken)))
+func Fingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])[:FingerprintLength]
+}
+
+// Redacted replaces token values in formatted output.
+const Redacted = "***"
+
+// Redact returns Redacted for a set token and "" for an unset one, so logs
+// still show whether a token was configured.
+//
+// Types carrying tokens implement String by redacting the tokens of their
+// (by-value) receiver and formatting it through a local type with the same
+// fields but no methods, declared as "type plain T". Formatting the receiver
+// itself would call String again and recurse forever.
+//
+// Parameters:
+//   - token: The plaintext token
+//
+// Returns:
+//   - string: Redacted, or "" if token is empty
+//
+// Example:
+//
+//	func (c Credentials) String() string {
+//	    type plain Credentials // see token.Redact
+//	    c.Token = token.Redact(c.Token)
+//	    return fmt.Sprintf("%+v", plain(c))
+//	}
+func Redact(token string) string {
+	if token == "" {
+		return ""
+	}
+	return Redacted
+}
+
+// HashAlgo selects the HMAC hash function used by HashWithAlgorithm.
+type HashAlgo int
+
+const (
+	// SHA256 selects HMAC-SHA256, the algorithm Hash uses.
+	SHA256 HashAlgo = iota
+
+	// SHA512 selects HMAC-SHA512.
+	SHA512
+)
+
+// String returns the algorithm identifier used as a hash prefix.
+func (a HashAlgo) String() string {
+	switch a {
+	case SHA256:
+		return "sha256"
+	case SHA512:
+		return "sha512"
+	default:
+		return fmt.Sprintf("HashAlgo(%d)", int(a))
+	}
+}
+
+// newHash returns the hash constructor for a, or nil if a is unknown.
+func (a HashAlgo) newHash() func() hash.Hash {
+	switch a {
+	case SHA256:
+		return sha256.New
+	case SHA512:
+		return sha512.New
+	default:
+		return nil
+	}
+}
+
+// HashWithAlgorithm produces an HMAC of the token with the chosen algorithm,
+// prefixed with the algorithm identifier (e.g. "sha512:<hex>") so Validate can
+// verify it without being told the algorithm. Hashes without a prefix, such as
+// those produced by Hash, are HMAC-SHA256. The server's token lookup only
+// matches Hash output (see the package documentation).
+//
+// Parameters:
+//   - token: The plaintext token to hash
+//   - secret: The server-side secret key used for HMAC
+//   - algo: Hash algorithm (SHA256 or SHA512)
+//
+// Returns:
+//   - string: "<algo>:" followed by the hex-encoded HMAC (135 characters for SHA512)
+//   - error: An error if algo is unknown
+//
+// Example:
+//
+//	hash, err := token.HashWithAlgorithm(token, secret, token.SHA512)
+func HashWithAlgorithm(token, secret string, algo HashAlgo) (string, error) {
+	newHash := algo.newHash()
+	if newHash == nil {
+		return "", fmt.Errorf("unsupported hash algorithm: %v", algo)
+	}
+	return algo.String() + ":" + hmacHex(newHash, token, secret), nil
+}
+
+// ValidateWithAlgorithm compares a provided token against a stored hash that
+// must use algo, using constant-time comparison. Use it to enforce an
+// algorithm; a stored hash of any other algorithm is rejected. For SHA256,
+// unprefixed legacy hashes from Hash are accepted too.
+//
+// Parameters:
+//   - provided: The plaintext token provided in the authentication request
+//   - secret: The server-side secret key (same one used for hashing)
+//   - storedHash: The stored hash
+//   - algo: Algorithm the stored hash must use
+//
+// Returns:
+//   - bool: true if storedHash uses algo and matches the provided token
+func ValidateWithAlgorithm(provided, secret, storedHash string, algo HashAlgo) bool {
+	stored, ok := parseHashAlgo(storedHash)
+	if !ok || stored != algo {
+		return false
+	}
+	return Validate(provided, secret, storedHash)
+}
+
+// parseHashAlgo returns the algorithm of a stored hash: the one named by its
+// prefix, or SHA256 for legacy hashes without one. ok is false for an unknown
+// prefix.
+func parseHashAlgo(storedHash string) (HashAlgo, bool) {
+	name, _, found := strings.Cut(storedHash, ":")
+	if !found {
+		return SHA256, true
+	}
+	for _, algo := range []HashAlgo{SHA256, SHA512} {
+		if name == algo.String() {
+			return algo, true
+		}
+	}
+	return 0, false
+}
+
+// hmacHex returns the hex-encoded HMAC of message keyed by secret.
+func hmacHex(newHash func() hash.Hash, message, secret string) string {
+	h := hmac.New(newHash, []byte(secret))
+	h.Write([]byte(message))
 	return hex.EncodeToString(h.Sum(nil))
 }
 
 // Validate compares a provided token against a stored hash using constant-time comparison.
 // This prevents timing attacks that could be used to determine valid token values.
 //
-// The function first hashes the provided token with the secret, then uses hmac.Equal
-// for constant-time comparison. This ensures that token validation always takes the
+// The function first hashes the provided token with the secret, using the algorithm
+// named by the stored hash's prefix (HMAC-SHA256 for unprefixed hashes from Hash),
+// then uses hmac.Equal for constant-time comparison. This ensures that token validation always takes the
 // same amount of time regardless of whether the token is correct or not.
 //
 // Parameters:
@@ -139,11 +398,54 @@ func Hash(token, secret string) string {
 //	    // Authentication failed
 //	}
 func Validate(provided, secret, storedHash string) bool {
-	providedHash := Hash(provided, secret)
+	providedHash := hashLike(provided, secret, storedHash)
 	// Use constant-time comparison to prevent timing attacks
 	return hmac.Equal([]byte(providedHash), []byte(storedHash))
 }
 
+// hashLike hashes a provided token the way storedHash was produced, with the
+// algorithm named by its prefix (SHA256 if unprefixed). It returns "" for an
+// unknown prefix, which matches no stored hash.
+func hashLike(provided, secret, storedHash string) string {
+	if !strings.Contains(storedHash, ":") {
+		return Hash(provided, secret)
+	}
+	algo, ok := parseHashAlgo(storedHash)
+	if !ok {
+		return ""
+	}
+	providedHash, _ := HashWithAlgorithm(provided, secret, algo)
+	return providedHash
+}
+
+// ValidateWithPrevious compares a provided token against a current hash and,
+// during a rotation overlap window, the hash it replaced. Both hashes are
+// always compared so the result does not leak which one matched.
+//
+// Parameters:
+//   - provided: The plaintext token provided in the authentication request
+//   - secret: The server-side secret key (same one used for Hash)
+//   - currentHash: The hex-encoded hash of the current token
+//   - previousHash: The hex-encoded hash of the previous token ("" if none is accepted)
+//
+// Returns:
+//   - bool: true if the provided token matches either hash, false otherwise
+//
+// Example:
+//
+//	previous := ""
+//	if time.Now().Before(cluster.PreviousTokenExpiresAt) {
+//	    previous = cluster.PreviousTokenHash
+//	}
+//	if token.ValidateWithPrevious(providedToken, secret, cluster.TokenHash, previous) {
+//	    // Authentication successful
+//	}
+func ValidateWithPrevious(provided, secret, currentHash, previousHash string) bool {
+	current := hmac.Equal([]byte(hashLike(provided, secret, currentHash)), []byte(currentHash))
+	previous := previousHash != "" && hmac.Equal([]byte(hashLike(provided, secret, previousHash)), []byte(previousHash))
+	return current || previous
+}
+
 // ValidateLength checks if a token meets the minimum length requirement.
 // This is a quick check that can be performed before attempting authentication.
 //
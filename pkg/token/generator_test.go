@@ -1,6 +1,11 @@
 package token
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"strings"
 	"testing"
 )
@@ -83,6 +88,97 @@ func TestGenerateWithLength(t *testing.T) {
 	}
 }
 
+// failingReader returns err from every Read.
+type failingReader struct {
+	err error
+}
+
+func (r failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func TestGenerateWithReader(t *testing.T) {
+	fixed := bytes.Repeat([]byte{0xAB}, DefaultTokenBytes)
+
+	token1, err := GenerateWithReader(bytes.NewReader(fixed))
+	if err != nil {
+		t.Fatalf("GenerateWithReader() unexpected error = %v", err)
+	}
+	token2, _ := GenerateWithReader(bytes.NewReader(fixed))
+	if token1 != token2 {
+		t.Errorf("GenerateWithReader() with the same bytes = %q and %q, want equal", token1, token2)
+	}
+	if len(token1) < MinTokenLength {
+		t.Errorf("GenerateWithReader() token length = %d, want >= %d", len(token1), MinTokenLength)
+	}
+
+	errEntropy := errors.New("entropy source failed")
+	if _, err := GenerateWithReader(failingReader{err: errEntropy}); !errors.Is(err, errEntropy) {
+		t.Errorf("GenerateWithReader() error = %v, want the reader's error", err)
+	}
+
+	// A reader running dry must fail rather than produce a weaker token
+	if _, err := GenerateWithReader(bytes.NewReader(fixed[:DefaultTokenBytes-1])); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("GenerateWithReader() error = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestGenerateContext(t *testing.T) {
+	token, err := GenerateContext(context.Background())
+	if err != nil || len(token) < MinTokenLength {
+		t.Errorf("GenerateContext() = %q, %v; want a valid token", token, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := GenerateContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("GenerateContext() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestGenerateN(t *testing.T) {
+	tests := []struct {
+		bits    int
+		length  int
+		wantErr bool
+	}{
+		{bits: 128, length: 24},
+		{bits: 256, length: 44},
+		{bits: 384, length: 64},
+		{bits: 512, length: 88},
+		{bits: 120, wantErr: true},
+		{bits: 132, wantErr: true},
+		{bits: 0, wantErr: true},
+		{bits: -256, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d bits", tt.bits), func(t *testing.T) {
+			token, err := GenerateN(tt.bits)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateN() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(token) != tt.length || EncodedLen(tt.bits) != tt.length {
+				t.Errorf("GenerateN() length = %d, EncodedLen() = %d, want %d", len(token), EncodedLen(tt.bits), tt.length)
+			}
+
+			hash := Hash(token, "secret")
+			if !Validate(token, "secret", hash) {
+				t.Error("Validate() rejected a GenerateN token")
+			}
+		})
+	}
+
+	// 248 bits is the least entropy that passes ValidateLength
+	if EncodedLen(248) < MinTokenLength || EncodedLen(240) >= MinTokenLength {
+		t.Errorf("EncodedLen(248) = %d, EncodedLen(240) = %d around MinTokenLength %d",
+			EncodedLen(248), EncodedLen(240), MinTokenLength)
+	}
+}
+
 func TestHash(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -132,6 +228,93 @@ func TestHash(t *testing.T) {
 	}
 }
 
+func TestHashWithAlgorithm(t *testing.T) {
+	secret := "test-secret-key"
+	token := "valid-token-value-123456789012345678901"
+
+	sha512Hash, err := HashWithAlgorithm(token, secret, SHA512)
+	if err != nil {
+		t.Fatalf("HashWithAlgorithm(SHA512) error = %v", err)
+	}
+	if !strings.HasPrefix(sha512Hash, "sha512:") || len(sha512Hash) != len("sha512:")+128 {
+		t.Errorf("HashWithAlgorithm(SHA512) = %q, want sha512: and 128 hex characters", sha512Hash)
+	}
+
+	sha256Hash, err := HashWithAlgorithm(token, secret, SHA256)
+	if err != nil {
+		t.Fatalf("HashWithAlgorithm(SHA256) error = %v", err)
+	}
+	if sha256Hash != "sha256:"+Hash(token, secret) {
+		t.Errorf("HashWithAlgorithm(SHA256) = %q, want prefixed Hash()", sha256Hash)
+	}
+
+	if _, err := HashWithAlgorithm(token, secret, HashAlgo(99)); err == nil {
+		t.Error("HashWithAlgorithm() accepted an unknown algorithm")
+	}
+
+	legacy := Hash(token, secret)
+	tests := []struct {
+		name       string
+		storedHash string
+		provided   string
+		validate   bool
+		sha256     bool
+		sha512     bool
+	}{
+		{name: "legacy hash", storedHash: legacy, provided: token, validate: true, sha256: true},
+		{name: "prefixed sha256", storedHash: sha256Hash, provided: token, validate: true, sha256: true},
+		{name: "sha512", storedHash: sha512Hash, provided: token, validate: true, sha512: true},
+		{name: "sha512 wrong token", storedHash: sha512Hash, provided: "wrong-token"},
+		{name: "unknown prefix", storedHash: "md5:" + legacy, provided: token},
+		{name: "mislabeled hash", storedHash: "sha512:" + legacy, provided: token},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Validate(tt.provided, secret, tt.storedHash); got != tt.validate {
+				t.Errorf("Validate() = %v, want %v", got, tt.validate)
+			}
+			if got := ValidateWithAlgorithm(tt.provided, secret, tt.storedHash, SHA256); got != tt.sha256 {
+				t.Errorf("ValidateWithAlgorithm(SHA256) = %v, want %v", got, tt.sha256)
+			}
+			if got := ValidateWithAlgorithm(tt.provided, secret, tt.storedHash, SHA512); got != tt.sha512 {
+				t.Errorf("ValidateWithAlgorithm(SHA512) = %v, want %v", got, tt.sha512)
+			}
+		})
+	}
+
+	if !ValidateWithPrevious(token, secret, Hash("new-token", secret), sha512Hash) {
+		t.Error("ValidateWithPrevious() rejected a token matching a sha512 previous hash")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	token := "valid-token-value-123456789012345678901"
+
+	fp := Fingerprint(token)
+	if len(fp) != FingerprintLength {
+		t.Errorf("Fingerprint() = %q, want %d characters", fp, FingerprintLength)
+	}
+	if fp != Fingerprint(token) {
+		t.Error("Fingerprint() is not deterministic")
+	}
+	if fp == Fingerprint(token+"x") {
+		t.Error("Fingerprint() is the same for different tokens")
+	}
+	if strings.Contains(token, fp) {
+		t.Errorf("Fingerprint() = %q reveals the token", fp)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	if got := Redact("valid-token-value-123456789012345678901"); got != Redacted {
+		t.Errorf("Redact() = %q, want %q", got, Redacted)
+	}
+	if got := Redact(""); got != "" {
+		t.Errorf("Redact(\"\") = %q, want empty", got)
+	}
+}
+
 func TestValidate(t *testing.T) {
 	secret := "test-secret-key-for-validation"
 	token := "valid-token-value-123456789012345678901"
@@ -190,6 +373,36 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestValidateWithPrevious(t *testing.T) {
+	secret := "test-secret-key-for-validation"
+	current := "current-token-value-12345678901234567890"
+	previous := "previous-token-value-1234567890123456789"
+	currentHash := Hash(current, secret)
+	previousHash := Hash(previous, secret)
+
+	tests := []struct {
+		name         string
+		provided     string
+		previousHash string
+		want         bool
+	}{
+		{"current token", current, previousHash, true},
+		{"previous token in overlap", previous, previousHash, true},
+		{"previous token after overlap", previous, "", false},
+		{"current token without previous", current, "", true},
+		{"unknown token", "unknown-token-value-123456789012345678901", previousHash, false},
+		{"empty token", "", previousHash, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidateWithPrevious(tt.provided, secret, currentHash, tt.previousHash); got != tt.want {
+				t.Errorf("ValidateWithPrevious() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestValidateLength(t *testing.T) {
 	tests := []struct {
 		name    string
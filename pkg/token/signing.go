@@ -0,0 +1,137 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultSignatureMaxSkew is how far a signed request's timestamp may be
+	// from the verifier's clock before the request is rejected as stale.
+	DefaultSignatureMaxSkew = 5 * time.Minute
+
+	// MaxNonceLength is the longest nonce accepted in a signed request.
+	MaxNonceLength = 64
+
+	// nonceBytes is the number of random bytes in a generated nonce.
+	nonceBytes = 16
+)
+
+var (
+	// ErrSignatureInvalid is returned when a request signature does not match
+	// the request or its nonce is malformed.
+	ErrSignatureInvalid = errors.New("invalid request signature")
+
+	// ErrSignatureExpired is returned when a signed request's timestamp is
+	// outside the allowed clock skew.
+	ErrSignatureExpired = errors.New("request signature expired")
+)
+
+// GenerateNonce creates a random nonce for a signed request.
+//
+// Returns:
+//   - string: A 32-character hex-encoded nonce
+//   - error: An error if random number generation fails
+func GenerateNonce() (string, error) {
+	b := make([]byte, nonceBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CanonicalRequest builds the string that is signed for a request.
+//
+// The canonical form is the method, request URI (escaped path and query),
+// hex SHA-256 of the body, Unix timestamp, and nonce, one per line. Clients
+// that sign requests without this package must build exactly the same string.
+//
+// Parameters:
+//   - method: HTTP method (e.g., "GET")
+//   - requestURI: Escaped path and query as sent (e.g., "/api/v1/config/bundle?x=1")
+//   - body: Request body (nil for none)
+//   - timestamp: Unix time the request was signed at
+//   - nonce: Unique value for this request
+//
+// Returns:
+//   - string: The canonical request
+func CanonicalRequest(method, requestURI string, body []byte, timestamp int64, nonce string) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		requestURI,
+		hex.EncodeToString(bodyHash[:]),
+		strconv.FormatInt(timestamp, 10),
+		nonce,
+	}, "\n")
+}
+
+// SignRequest signs a request with the token that authenticates it.
+//
+// Parameters:
+//   - token: The plaintext token the request is sent with
+//   - method: HTTP method
+//   - requestURI: Escaped path and query as sent
+//   - body: Request body (nil for none)
+//   - timestamp: Unix time the request is signed at
+//   - nonce: Unique value for this request (see GenerateNonce)
+//
+// Returns:
+//   - string: Hex-encoded HMAC-SHA256 of the canonical request keyed by the token
+//
+// Example:
+//
+//	nonce, _ := token.GenerateNonce()
+//	ts := time.Now().Unix()
+//	sig := token.SignRequest(nodeToken, "POST", "/api/v1/routes", body, ts, nonce)
+func SignRequest(token, method, requestURI string, body []byte, timestamp int64, nonce string) string {
+	h := hmac.New(sha256.New, []byte(token))
+	h.Write([]byte(CanonicalRequest(method, requestURI, body, timestamp, nonce)))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyRequest checks a request signature and that its timestamp is within
+// maxSkew of now, in either direction.
+//
+// VerifyRequest does not detect replays within the skew window; callers must
+// also reject nonces they have already seen.
+//
+// Parameters:
+//   - token: The plaintext token the request was authenticated with
+//   - signature: Hex-encoded signature sent with the request
+//   - method: HTTP method
+//   - requestURI: Escaped path and query as received
+//   - body: Request body (nil for none)
+//   - timestamp: Unix time sent with the request
+//   - nonce: Nonce sent with the request
+//   - now: The verifier's current time
+//   - maxSkew: Largest accepted difference between timestamp and now
+//
+// Returns:
+//   - error: ErrSignatureExpired, ErrSignatureInvalid, or nil if valid
+func VerifyRequest(token, signature, method, requestURI string, body []byte, timestamp int64, nonce string, now time.Time, maxSkew time.Duration) error {
+	if nonce == "" || len(nonce) > MaxNonceLength {
+		return ErrSignatureInvalid
+	}
+
+	skew := now.Sub(time.Unix(timestamp, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > maxSkew {
+		return ErrSignatureExpired
+	}
+
+	expected := SignRequest(token, method, requestURI, body, timestamp, nonce)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
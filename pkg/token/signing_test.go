@@ -0,0 +1,72 @@
+package token
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSignRequest_KnownAnswer(t *testing.T) {
+	// The SDK signs requests without importing this package; its tests use
+	// the same vector to keep both implementations in step.
+	got := SignRequest("test-node-token-with-enough-characters-1234", "POST", "/api/v1/routes?x=1",
+		[]byte(`{"routes":["10.0.0.0/24"]}`), 1700000000, "0123456789abcdef0123456789abcdef")
+	want := "f02956cfffdfe03cbb6136a947d20252e04db7fc1fef55f95360d03fb97c0c41"
+	if got != want {
+		t.Errorf("SignRequest() = %s, want %s", got, want)
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	tok := "test-node-token-with-enough-characters-1234"
+	body := []byte(`{"mtu":1300}`)
+	now := time.Unix(1700000000, 0)
+	nonce, err := GenerateNonce()
+	if err != nil {
+		t.Fatalf("GenerateNonce() error = %v", err)
+	}
+	sig := SignRequest(tok, "PATCH", "/api/v1/nodes/n1/mtu", body, now.Unix(), nonce)
+
+	tests := []struct {
+		name    string
+		token   string
+		method  string
+		uri     string
+		body    []byte
+		nonce   string
+		now     time.Time
+		wantErr error
+	}{
+		{"valid", tok, "PATCH", "/api/v1/nodes/n1/mtu", body, nonce, now, nil},
+		{"valid within skew", tok, "PATCH", "/api/v1/nodes/n1/mtu", body, nonce, now.Add(DefaultSignatureMaxSkew), nil},
+		{"valid with client clock ahead", tok, "PATCH", "/api/v1/nodes/n1/mtu", body, nonce, now.Add(-DefaultSignatureMaxSkew), nil},
+		{"stale", tok, "PATCH", "/api/v1/nodes/n1/mtu", body, nonce, now.Add(DefaultSignatureMaxSkew + time.Second), ErrSignatureExpired},
+		{"from the future", tok, "PATCH", "/api/v1/nodes/n1/mtu", body, nonce, now.Add(-DefaultSignatureMaxSkew - time.Second), ErrSignatureExpired},
+		{"wrong token", "other-token-with-enough-characters-to-matter", "PATCH", "/api/v1/nodes/n1/mtu", body, nonce, now, ErrSignatureInvalid},
+		{"wrong method", tok, "PUT", "/api/v1/nodes/n1/mtu", body, nonce, now, ErrSignatureInvalid},
+		{"wrong path", tok, "PATCH", "/api/v1/nodes/n2/mtu", body, nonce, now, ErrSignatureInvalid},
+		{"tampered body", tok, "PATCH", "/api/v1/nodes/n1/mtu", []byte(`{"mtu":9000}`), nonce, now, ErrSignatureInvalid},
+		{"wrong nonce", tok, "PATCH", "/api/v1/nodes/n1/mtu", body, "other", now, ErrSignatureInvalid},
+		{"missing nonce", tok, "PATCH", "/api/v1/nodes/n1/mtu", body, "", now, ErrSignatureInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyRequest(tt.token, sig, tt.method, tt.uri, tt.body, now.Unix(), tt.nonce, tt.now, DefaultSignatureMaxSkew)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyRequest() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateNonce(t *testing.T) {
+	a, err := GenerateNonce()
+	if err != nil {
+		t.Fatalf("GenerateNonce() error = %v", err)
+	}
+	b, _ := GenerateNonce()
+	if len(a) != 2*nonceBytes || a == b {
+		t.Errorf("GenerateNonce() = %q, %q; want distinct %d-character nonces", a, b, 2*nonceBytes)
+	}
+}
@@ -0,0 +1,150 @@
+package sdk
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bundleArchiveCompleteTrailer is the trailer the server sets once a bundle
+// archive has been streamed in full.
+const bundleArchiveCompleteTrailer = "X-Bundle-Archive-Complete"
+
+var (
+	// errArchiveIncomplete reports an archive stream that ended without the
+	// completion trailer.
+	errArchiveIncomplete = errors.New("bundle archive ended before completion")
+
+	// errArchiveWrite wraps failures writing to the caller's writer, which
+	// are not retried.
+	errArchiveWrite = errors.New("failed to write bundle archive")
+)
+
+// ArchiveBundles writes every stored config bundle version of the cluster to
+// w as a tar archive, one "<version>.tar.gz" entry per version in ascending
+// order. It is meant for backup tooling that wants the full bundle history.
+//
+// The archive is streamed, and an entry is only written to w once it has been
+// received in full. If the stream is interrupted, the download resumes after
+// the last complete version (on another instance if needed), so w always ends
+// up holding a single well-formed archive. Interruptions that make no progress
+// count against RetryAttempts.
+//
+// This operation requires cluster token authentication and can be executed on
+// any control plane instance (master or replica).
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - w: Destination for the tar archive
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - int: Number of bundle versions written
+//   - error: ErrUnauthorized if the cluster token is invalid, ErrRateLimited if
+//     rate limited, or other errors for network issues
+func (c *Client) ArchiveBundles(ctx context.Context, w io.Writer, opts ...RequestOption) (int, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	tw := tar.NewWriter(w)
+	var afterVersion int64
+	written := 0
+
+	var lastErr error
+	for attempt := 0; attempt <= c.RetryAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return written, ctx.Err()
+			case <-time.After(c.calculateBackoff(attempt - 1)):
+			}
+		}
+
+		n, done, err := c.copyArchive(ctx, tw, &afterVersion)
+		written += n
+		if done {
+			if err := tw.Close(); err != nil {
+				return written, fmt.Errorf("%w: %w", errArchiveWrite, err)
+			}
+			return written, nil
+		}
+
+		var apiErr *APIError
+		if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrRateLimited) ||
+			errors.Is(err, ErrNoBaseURLs) || errors.Is(err, errArchiveWrite) ||
+			errors.As(err, &apiErr) || ctx.Err() != nil {
+			return written, fmt.Errorf("failed to archive bundles: %w", err)
+		}
+		lastErr = err
+
+		// Progress was made, so the next attempt starts a fresh retry budget
+		if n > 0 {
+			attempt = -1
+		}
+	}
+
+	return written, fmt.Errorf("failed to archive bundles: %w", lastErr)
+}
+
+// copyArchive requests the archive after version *last and copies complete
+// entries into tw, advancing *last to the newest version copied. It reports
+// done once the server confirmed the archive was sent in full.
+func (c *Client) copyArchive(ctx context.Context, tw *tar.Writer, last *int64) (int, bool, error) {
+	path := fmt.Sprintf("/api/v1/clusters/%s/bundles/archive?after_version=%d", c.ClusterID, *last)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, AuthTypeCluster, false)
+	if err != nil {
+		return 0, false, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, false, c.parseErrorResponse(resp)
+	}
+	defer drainAndCloseBody(resp)
+
+	written := 0
+	tr := tar.NewReader(resp.Body)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return written, false, fmt.Errorf("failed to read bundle archive: %w", err)
+		}
+
+		version, err := strconv.ParseInt(strings.TrimSuffix(hdr.Name, ".tar.gz"), 10, 64)
+		if err != nil {
+			return written, false, fmt.Errorf("unexpected bundle archive entry %q", hdr.Name)
+		}
+
+		// Buffer the entry so a truncated one never reaches the caller
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return written, false, fmt.Errorf("failed to read bundle archive: %w", err)
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return written, false, fmt.Errorf("%w: %w", errArchiveWrite, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return written, false, fmt.Errorf("%w: %w", errArchiveWrite, err)
+		}
+
+		*last = version
+		written++
+	}
+
+	// Trailers are only available once the body has been read to the end
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return written, false, fmt.Errorf("failed to read bundle archive: %w", err)
+	}
+	if resp.Trailer.Get(bundleArchiveCompleteTrailer) != "true" {
+		return written, false, errArchiveIncomplete
+	}
+
+	return written, true, nil
+}
@@ -0,0 +1,104 @@
+package sdk
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// archiveTestServer serves versions 1-3 as a bundle archive. The first
+// response stops after one entry without the completion trailer.
+func archiveTestServer(t *testing.T, afters *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/clusters/cluster-456/bundles/archive" {
+			t.Errorf("Request path = %s", r.URL.Path)
+		}
+		if r.Header.Get("X-NebulaGC-Cluster-Token") == "" {
+			t.Error("Expected X-NebulaGC-Cluster-Token header to be present")
+		}
+		*afters = append(*afters, r.URL.Query().Get("after_version"))
+		after, _ := strconv.ParseInt(r.URL.Query().Get("after_version"), 10, 64)
+
+		w.Header().Set("Trailer", bundleArchiveCompleteTrailer)
+		tw := tar.NewWriter(w)
+		for v := after + 1; v <= 3; v++ {
+			data := []byte(fmt.Sprintf("bundle %d", v))
+			tw.WriteHeader(&tar.Header{Name: fmt.Sprintf("%d.tar.gz", v), Mode: 0600, Size: int64(len(data))})
+			tw.Write(data)
+			if len(*afters) == 1 {
+				// Interrupted: no end-of-archive marker and no trailer
+				tw.Flush()
+				return
+			}
+		}
+		tw.Close()
+		w.Header().Set(bundleArchiveCompleteTrailer, "true")
+	}))
+}
+
+func TestClient_ArchiveBundles_Resumes(t *testing.T) {
+	var afters []string
+	server := archiveTestServer(t, &afters)
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		ClusterToken:  "test-cluster-token",
+		RetryAttempts: 1,
+	})
+
+	var buf bytes.Buffer
+	n, err := client.ArchiveBundles(context.Background(), &buf)
+	if err != nil {
+		t.Fatalf("ArchiveBundles() error = %v", err)
+	}
+	if n != 3 {
+		t.Errorf("ArchiveBundles() = %d versions, want 3", n)
+	}
+	if len(afters) != 2 || afters[0] != "0" || afters[1] != "1" {
+		t.Errorf("after_version requests = %v, want [0 1]", afters)
+	}
+
+	// The output is one archive holding every version exactly once
+	tr := tar.NewReader(&buf)
+	for v := 1; v <= 3; v++ {
+		hdr, err := tr.Next()
+		if err != nil {
+			t.Fatalf("Failed to read entry %d: %v", v, err)
+		}
+		data, _ := io.ReadAll(tr)
+		if hdr.Name != fmt.Sprintf("%d.tar.gz", v) || string(data) != fmt.Sprintf("bundle %d", v) {
+			t.Errorf("Entry %d = %s %q", v, hdr.Name, data)
+		}
+	}
+	if _, err := tr.Next(); err != io.EOF {
+		t.Errorf("Expected end of archive, got %v", err)
+	}
+}
+
+func TestClient_ArchiveBundles_Unauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:     []string{server.URL},
+		TenantID:     "tenant-123",
+		ClusterID:    "cluster-456",
+		ClusterToken: "bad-token",
+	})
+
+	if _, err := client.ArchiveBundles(context.Background(), io.Discard); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("ArchiveBundles() error = %v, want ErrUnauthorized", err)
+	}
+}
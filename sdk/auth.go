@@ -1,6 +1,17 @@
 package sdk
 
-import "net/http"
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Authentication header constants matching the server expectations.
 const (
@@ -9,6 +20,23 @@ const (
 
 	// HeaderClusterToken is the header name for cluster authentication.
 	HeaderClusterToken = "X-NebulaGC-Cluster-Token"
+
+	// HeaderOperatorToken is the header name for operator authentication.
+	HeaderOperatorToken = "X-NebulaGC-Operator-Token"
+
+	// HeaderClusterID is the header naming the cluster a node or cluster
+	// token is meant for. It grants nothing; the server only uses it to
+	// attribute failed authentications to a cluster.
+	HeaderClusterID = "X-NebulaGC-Cluster-ID"
+
+	// HeaderRequestTimestamp is the header carrying the Unix time a signed request was signed at.
+	HeaderRequestTimestamp = "X-NebulaGC-Timestamp"
+
+	// HeaderRequestNonce is the header carrying a signed request's unique nonce.
+	HeaderRequestNonce = "X-NebulaGC-Nonce"
+
+	// HeaderRequestSignature is the header carrying a signed request's signature.
+	HeaderRequestSignature = "X-NebulaGC-Request-Signature"
 )
 
 // AuthType represents the type of authentication to use for a request.
@@ -23,6 +51,9 @@ const (
 
 	// AuthTypeCluster indicates cluster token authentication should be used.
 	AuthTypeCluster
+
+	// AuthTypeOperator indicates operator token authentication should be used.
+	AuthTypeOperator
 )
 
 // addAuthHeaders adds the appropriate authentication headers to the request based on the auth type.
@@ -30,18 +61,95 @@ const (
 func (c *Client) addAuthHeaders(req *http.Request, authType AuthType) error {
 	switch authType {
 	case AuthTypeNode:
-		if c.NodeToken == "" {
+		c.mu.RLock()
+		nodeToken := c.NodeToken
+		c.mu.RUnlock()
+		if nodeToken == "" {
 			return ErrMissingAuth
 		}
-		req.Header.Set(HeaderNodeToken, c.NodeToken)
+		req.Header.Set(HeaderNodeToken, nodeToken)
+		c.setClusterIDHeader(req)
 	case AuthTypeCluster:
 		if c.ClusterToken == "" {
 			return ErrMissingAuth
 		}
 		req.Header.Set(HeaderClusterToken, c.ClusterToken)
+		c.setClusterIDHeader(req)
+	case AuthTypeOperator:
+		if c.OperatorToken == "" {
+			return ErrMissingAuth
+		}
+		req.Header.Set(HeaderOperatorToken, c.OperatorToken)
 	case AuthTypeNone:
 		// No authentication required
 	}
 
 	return nil
 }
+
+// setClusterIDHeader names the client's cluster in the request, if known.
+func (c *Client) setClusterIDHeader(req *http.Request) {
+	if c.ClusterID != "" {
+		req.Header.Set(HeaderClusterID, c.ClusterID)
+	}
+}
+
+// signRequest signs a node-authenticated request when SignRequests is set.
+//
+// It is called before every attempt, so retries and failover to other
+// instances carry a fresh timestamp and nonce instead of being rejected as
+// replays. The signature is the hex HMAC-SHA256, keyed by the node token, of
+// the method, request URI, hex SHA-256 of the body, Unix timestamp, and nonce,
+// joined by newlines (the server's token.SignRequest).
+func (c *Client) signRequest(req *http.Request) error {
+	nodeToken := req.Header.Get(HeaderNodeToken)
+	if !c.SignRequests || nodeToken == "" {
+		return nil
+	}
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return fmt.Errorf("cannot sign request: body cannot be re-read")
+		}
+		reader, err := req.GetBody()
+		if err != nil {
+			return fmt.Errorf("failed to read request body for signing: %w", err)
+		}
+		body, err = io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read request body for signing: %w", err)
+		}
+	}
+
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	nonce := hex.EncodeToString(nonceBytes)
+	timestamp := time.Now().Unix()
+
+	req.Header.Set(HeaderRequestTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderRequestNonce, nonce)
+	req.Header.Set(HeaderRequestSignature,
+		requestSignature(nodeToken, req.Method, req.URL.RequestURI(), body, timestamp, nonce))
+	return nil
+}
+
+// requestSignature computes a request signature. It must match the server's
+// token.SignRequest.
+func requestSignature(token, method, requestURI string, body []byte, timestamp int64, nonce string) string {
+	bodyHash := sha256.Sum256(body)
+	canonical := strings.Join([]string{
+		strings.ToUpper(method),
+		requestURI,
+		hex.EncodeToString(bodyHash[:]),
+		strconv.FormatInt(timestamp, 10),
+		nonce,
+	}, "\n")
+
+	h := hmac.New(sha256.New, []byte(token))
+	h.Write([]byte(canonical))
+	return hex.EncodeToString(h.Sum(nil))
+}
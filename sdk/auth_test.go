@@ -0,0 +1,129 @@
+package sdk
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRequestSignature_KnownAnswer(t *testing.T) {
+	// Same vector as the server's token.SignRequest test
+	got := requestSignature("test-node-token-with-enough-characters-1234", "POST", "/api/v1/routes?x=1",
+		[]byte(`{"routes":["10.0.0.0/24"]}`), 1700000000, "0123456789abcdef0123456789abcdef")
+	want := "f02956cfffdfe03cbb6136a947d20252e04db7fc1fef55f95360d03fb97c0c41"
+	if got != want {
+		t.Errorf("requestSignature() = %s, want %s", got, want)
+	}
+}
+
+func TestClient_SignRequests(t *testing.T) {
+	nodeToken := "test-node-token-with-enough-characters-1234"
+	var nonces []string
+	var attempts int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+
+		timestamp, err := strconv.ParseInt(r.Header.Get(HeaderRequestTimestamp), 10, 64)
+		if err != nil || time.Since(time.Unix(timestamp, 0)) > time.Minute {
+			t.Errorf("Attempt %d: bad timestamp %q", attempts, r.Header.Get(HeaderRequestTimestamp))
+		}
+		nonce := r.Header.Get(HeaderRequestNonce)
+		want := requestSignature(nodeToken, r.Method, r.URL.RequestURI(), body, timestamp, nonce)
+		if got := r.Header.Get(HeaderRequestSignature); got != want {
+			t.Errorf("Attempt %d: signature = %q, want %q", attempts, got, want)
+		}
+		nonces = append(nonces, nonce)
+
+		// Fail the first attempt so the retry must be signed again
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		NodeID:        "node-789",
+		NodeToken:     nodeToken,
+		SignRequests:  true,
+		RetryAttempts: 1,
+		RetryWaitMin:  time.Millisecond,
+		RetryWaitMax:  time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	if err := client.RegisterRoutes(context.Background(), []string{"10.0.0.0/24"}); err != nil {
+		t.Fatalf("RegisterRoutes() error = %v", err)
+	}
+
+	if len(nonces) != 2 || nonces[0] == "" || nonces[0] == nonces[1] {
+		t.Errorf("Expected a fresh nonce per attempt, got %q", nonces)
+	}
+}
+
+func TestClient_SignRequestsDisabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderRequestSignature) != "" {
+			t.Error("Expected no signature when SignRequests is off")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{
+		BaseURLs:  []string{server.URL},
+		TenantID:  "tenant-123",
+		ClusterID: "cluster-456",
+		NodeID:    "node-789",
+		NodeToken: "test-node-token-with-enough-characters-1234",
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	if err := client.RegisterRoutes(context.Background(), []string{"10.0.0.0/24"}); err != nil {
+		t.Fatalf("RegisterRoutes() error = %v", err)
+	}
+}
+
+func TestClient_ClusterIDHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get(HeaderClusterID); got != "cluster-456" {
+			t.Errorf("%s = %q, want cluster-456", HeaderClusterID, got)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{
+		BaseURLs:     []string{server.URL},
+		TenantID:     "tenant-123",
+		ClusterID:    "cluster-456",
+		NodeID:       "node-789",
+		NodeToken:    "test-node-token-with-enough-characters-1234",
+		ClusterToken: "test-cluster-token-with-enough-characters-12",
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	for _, authType := range []AuthType{AuthTypeNode, AuthTypeCluster} {
+		resp, err := client.doRequest(context.Background(), http.MethodGet, "/api/v1/test", nil, authType, false)
+		if err != nil {
+			t.Fatalf("doRequest() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+}
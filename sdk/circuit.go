@@ -0,0 +1,82 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultCircuitCooldown is how long an open circuit short-circuits requests
+// when ClientConfig.CircuitCooldown is not set.
+const DefaultCircuitCooldown = 30 * time.Second
+
+// circuitBreaker stops a client from hammering a control plane that is down.
+//
+// Each request round tries every instance; a round in which none of them
+// answered counts as a failure. After threshold consecutive failures the
+// circuit opens and requests fail immediately with ErrCircuitOpen until the
+// cooldown has passed. The next request then probes the control plane: any
+// answer closes the circuit, another failure reopens it for a new cooldown.
+//
+// A nil *circuitBreaker is disabled and lets every request through.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// newCircuitBreaker returns a breaker, or nil if threshold is zero.
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultCircuitCooldown
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// allow returns an error wrapping ErrAllInstancesFailed and ErrCircuitOpen
+// while the circuit is open.
+func (b *circuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if remaining := b.openUntil.Sub(b.now()); remaining > 0 {
+		return fmt.Errorf("%w: %w (retrying in %s)", ErrAllInstancesFailed, ErrCircuitOpen, remaining.Round(time.Millisecond))
+	}
+	return nil
+}
+
+// success records that a control plane instance answered, closing the circuit.
+func (b *circuitBreaker) success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.mu.Unlock()
+}
+
+// failure records a round in which no instance answered. Rounds cut short by
+// the caller's context are not the control plane's fault and are ignored.
+func (b *circuitBreaker) failure(ctx context.Context) {
+	if b == nil || ctx.Err() != nil {
+		return
+	}
+	b.mu.Lock()
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+	b.mu.Unlock()
+}
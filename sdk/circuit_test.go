@@ -0,0 +1,142 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newCircuitBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	b.failure(ctx)
+	if err := b.allow(); err != nil {
+		t.Fatalf("allow() after one failure = %v, want nil", err)
+	}
+
+	// Rounds cut short by the caller do not count
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	b.failure(cancelled)
+	if err := b.allow(); err != nil {
+		t.Fatalf("allow() after a cancelled round = %v, want nil", err)
+	}
+
+	b.failure(ctx)
+	err := b.allow()
+	if !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, ErrAllInstancesFailed) {
+		t.Fatalf("allow() at the threshold = %v, want ErrCircuitOpen and ErrAllInstancesFailed", err)
+	}
+
+	// After the cooldown one probe is let through; its failure reopens the circuit
+	now = now.Add(time.Minute)
+	if err := b.allow(); err != nil {
+		t.Fatalf("allow() after the cooldown = %v, want nil", err)
+	}
+	b.failure(ctx)
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow() after a failed probe = %v, want ErrCircuitOpen", err)
+	}
+
+	b.success()
+	if err := b.allow(); err != nil {
+		t.Fatalf("allow() after a success = %v, want nil", err)
+	}
+
+	// A nil breaker is disabled
+	var disabled *circuitBreaker
+	disabled.failure(ctx)
+	disabled.success()
+	if err := disabled.allow(); err != nil {
+		t.Fatalf("allow() on a disabled breaker = %v, want nil", err)
+	}
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	var requests atomic.Int32
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/check-master" {
+			if healthy.Load() {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			return
+		}
+		requests.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"version":7}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{
+		BaseURLs:         []string{server.URL},
+		TenantID:         "tenant-123",
+		ClusterID:        "cluster-456",
+		NodeToken:        "valid-node-token",
+		RetryAttempts:    1,
+		RetryWaitMin:     time.Millisecond,
+		RetryWaitMax:     time.Millisecond,
+		CircuitThreshold: 2,
+		CircuitCooldown:  time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetLatestVersion(ctx); !errors.Is(err, ErrAllInstancesFailed) || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("GetLatestVersion() #%d error = %v, want a real failure", i+1, err)
+		}
+	}
+	sent := requests.Load()
+
+	// The circuit is open: calls fail fast without reaching the server
+	if _, err := client.GetLatestVersion(ctx); !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, ErrAllInstancesFailed) {
+		t.Fatalf("GetLatestVersion() error = %v, want ErrCircuitOpen", err)
+	}
+	if _, _, err := client.DownloadBundle(ctx, 0); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("DownloadBundle() error = %v, want ErrCircuitOpen", err)
+	}
+	if got := requests.Load(); got != sent {
+		t.Fatalf("Expected no requests while the circuit is open, got %d more", got-sent)
+	}
+
+	// Discovering the master closes the circuit before the cooldown ends
+	healthy.Store(true)
+	if err := client.DiscoverMaster(ctx); err != nil {
+		t.Fatalf("DiscoverMaster() error = %v", err)
+	}
+	version, err := client.GetLatestVersion(ctx)
+	if err != nil || version != 7 {
+		t.Fatalf("GetLatestVersion() = %d, %v, want 7", version, err)
+	}
+}
+
+func TestClientConfig_ValidateCircuit(t *testing.T) {
+	base := ClientConfig{BaseURLs: []string{"https://cp1.example.com"}, TenantID: "t", ClusterID: "c"}
+
+	config := base
+	config.CircuitThreshold = -1
+	if err := config.Validate(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Validate() with a negative threshold = %v, want ErrInvalidConfig", err)
+	}
+
+	config = base
+	config.CircuitCooldown = -time.Second
+	if err := config.Validate(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Validate() with a negative cooldown = %v, want ErrInvalidConfig", err)
+	}
+}
@@ -3,10 +3,18 @@ package sdk
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -32,9 +40,24 @@ type Client struct {
 	// ClusterToken is the authentication token for cluster operations (optional).
 	ClusterToken string
 
+	// OperatorToken is the authentication token for operator operations (optional).
+	OperatorToken string
+
 	// HTTPClient is the HTTP client used for requests.
 	HTTPClient *http.Client
 
+	// OnRequest is called before each HTTP attempt (optional).
+	OnRequest func(method, url string)
+
+	// OnResponse is called after each HTTP attempt (optional).
+	OnResponse func(method, url string, status int, dur time.Duration, err error)
+
+	// OnRequestComplete is called after each HTTP attempt (optional).
+	OnRequestComplete func(info RequestInfo)
+
+	// SignRequests signs node-authenticated requests (see ClientConfig.SignRequests).
+	SignRequests bool
+
 	// RetryAttempts is the number of times to retry failed requests.
 	RetryAttempts int
 
@@ -44,32 +67,92 @@ type Client struct {
 	// RetryWaitMax is the maximum wait time between retries.
 	RetryWaitMax time.Duration
 
+	// BackoffJitter is the fraction of each retry wait that is randomized,
+	// from 0 (no jitter) to 1 (see ClientConfig.BackoffJitter).
+	BackoffJitter float64
+
+	// headers are the custom headers added to every request (see ClientConfig.Headers).
+	headers http.Header
+
+	// userAgent replaces the User-Agent header when set.
+	userAgent string
+
+	// rng draws backoff jitter (nil uses the global source; see WithRandSource).
+	rng *lockedRand
+
 	// masterURL is the cached URL of the current master (protected by mutex).
 	masterURL string
 
-	// mu protects concurrent access to masterURL.
+	// breaker short-circuits requests while the control plane is down
+	// (nil if ClientConfig.CircuitThreshold is not set).
+	breaker *circuitBreaker
+
+	// mu protects concurrent access to masterURL, and to NodeToken and
+	// BaseURLs once the client is in use (see SetNodeToken and SetBaseURLs).
 	mu sync.RWMutex
 }
 
+// String describes the client with its tokens redacted.
+func (c *Client) String() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return fmt.Sprintf("{BaseURLs:%v TenantID:%s ClusterID:%s NodeID:%s NodeToken:%s ClusterToken:%s OperatorToken:%s}",
+		c.BaseURLs, c.TenantID, c.ClusterID, c.NodeID, redactToken(c.NodeToken), redactToken(c.ClusterToken),
+		redactToken(c.OperatorToken))
+}
+
+// ClientOption customizes a Client beyond what ClientConfig covers.
+type ClientOption func(*Client)
+
+// WithRandSource makes the client draw backoff jitter from src instead of the
+// global random source, so that retry waits are reproducible (for example in
+// tests or simulations). The client serializes access to src.
+//
+// Parameters:
+//   - src: Random source for backoff jitter
+//
+// Returns:
+//   - ClientOption: Option to pass to NewClient
+func WithRandSource(src rand.Source) ClientOption {
+	return func(c *Client) {
+		c.rng = &lockedRand{rand: rand.New(src)}
+	}
+}
+
 // NewClient creates a new SDK client with the given configuration.
 // It validates the configuration and optionally discovers the master instance.
-func NewClient(config ClientConfig) (*Client, error) {
+func NewClient(config ClientConfig, opts ...ClientOption) (*Client, error) {
 	// Validate and set defaults
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
 
 	client := &Client{
-		BaseURLs:      config.BaseURLs,
-		TenantID:      config.TenantID,
-		ClusterID:     config.ClusterID,
-		NodeID:        config.NodeID,
-		NodeToken:     config.NodeToken,
-		ClusterToken:  config.ClusterToken,
-		HTTPClient:    config.HTTPClient,
-		RetryAttempts: config.RetryAttempts,
-		RetryWaitMin:  config.RetryWaitMin,
-		RetryWaitMax:  config.RetryWaitMax,
+		BaseURLs:          config.BaseURLs,
+		TenantID:          config.TenantID,
+		ClusterID:         config.ClusterID,
+		NodeID:            config.NodeID,
+		NodeToken:         config.NodeToken,
+		ClusterToken:      config.ClusterToken,
+		OperatorToken:     config.OperatorToken,
+		HTTPClient:        config.HTTPClient,
+		OnRequest:         config.OnRequest,
+		OnResponse:        config.OnResponse,
+		OnRequestComplete: config.OnRequestComplete,
+		SignRequests:      config.SignRequests,
+		RetryAttempts:     config.RetryAttempts,
+		RetryWaitMin:      config.RetryWaitMin,
+		RetryWaitMax:      config.RetryWaitMax,
+		BackoffJitter:     config.backoffJitter(),
+		headers:           make(http.Header, len(config.Headers)),
+		userAgent:         config.UserAgent,
+		breaker:           newCircuitBreaker(config.CircuitThreshold, config.CircuitCooldown),
+	}
+	for name, value := range config.Headers {
+		client.headers.Set(name, value)
+	}
+	for _, opt := range opts {
+		opt(client)
 	}
 
 	return client, nil
@@ -77,8 +160,15 @@ func NewClient(config ClientConfig) (*Client, error) {
 
 // DiscoverMaster attempts to discover which control plane instance is the master.
 // It caches the result for future requests. Returns ErrNoMasterFound if no master is available.
-func (c *Client) DiscoverMaster(ctx context.Context) error {
-	for _, baseURL := range c.BaseURLs {
+//
+// Every base URL is probed in order, so an instance reachable at several
+// addresses (see ReplicaURLs) is found through whichever one answers.
+// Discovery ignores an open circuit breaker and closes it when it succeeds.
+func (c *Client) DiscoverMaster(ctx context.Context, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	for _, baseURL := range c.BaseURLList() {
 		url := fmt.Sprintf("%s/api/v1/check-master", baseURL)
 
 		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -86,7 +176,7 @@ func (c *Client) DiscoverMaster(ctx context.Context) error {
 			continue
 		}
 
-		resp, err := c.HTTPClient.Do(req)
+		resp, err := c.do(req, baseURL, 1)
 		if err != nil {
 			continue
 		}
@@ -97,6 +187,7 @@ func (c *Client) DiscoverMaster(ctx context.Context) error {
 			c.mu.Lock()
 			c.masterURL = baseURL
 			c.mu.Unlock()
+			c.breaker.success()
 			return nil
 		}
 	}
@@ -104,6 +195,65 @@ func (c *Client) DiscoverMaster(ctx context.Context) error {
 	return ErrNoMasterFound
 }
 
+// MasterURL returns the master found by DiscoverMaster, or an empty string
+// if no master has been discovered.
+func (c *Client) MasterURL() string {
+	return c.getMasterURL()
+}
+
+// SetNodeToken replaces the node token used for node-authenticated requests,
+// for example after the token was rotated. Unlike assigning NodeToken, it is
+// safe while requests are in flight; requests already sent keep the old token.
+func (c *Client) SetNodeToken(token string) {
+	c.mu.Lock()
+	c.NodeToken = token
+	c.mu.Unlock()
+}
+
+// SetBaseURLs replaces the control plane URLs requests are sent to, for
+// example with the addresses of replicas discovered through
+// GetClusterReplicas (see ReplicaURLs). Unlike assigning BaseURLs, it is safe
+// while requests are in flight. A cached master that is not in urls is
+// forgotten and rediscovered.
+//
+// Parameters:
+//   - urls: The new base URLs, tried in order
+//
+// Returns:
+//   - error: ErrInvalidConfig if urls is empty or a URL is not http(s),
+//     leaving the current URLs in place
+func (c *Client) SetBaseURLs(urls []string) error {
+	if len(urls) == 0 {
+		return fmt.Errorf("%w: at least one base URL is required", ErrInvalidConfig)
+	}
+	normalized := make([]string, len(urls))
+	for i, url := range urls {
+		url = strings.TrimSuffix(strings.TrimSpace(url), "/")
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			return fmt.Errorf("%w: base URL must start with http:// or https://", ErrInvalidConfig)
+		}
+		normalized[i] = url
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.BaseURLs = normalized
+	for _, url := range normalized {
+		if url == c.masterURL {
+			return nil
+		}
+	}
+	c.masterURL = ""
+	return nil
+}
+
+// BaseURLList returns a copy of the control plane URLs requests are sent to.
+func (c *Client) BaseURLList() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]string(nil), c.BaseURLs...)
+}
+
 // getMasterURL returns the cached master URL, or empty string if not discovered.
 func (c *Client) getMasterURL() string {
 	c.mu.RLock()
@@ -129,6 +279,19 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body io.Rea
 		return nil, ErrNoBaseURLs
 	}
 
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	// Buffer the body so every instance and retry can send it again
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = io.ReadAll(body); err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+
 	var lastErr error
 
 	for _, baseURL := range urls {
@@ -136,7 +299,11 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body io.Rea
 		fullURL := fmt.Sprintf("%s%s", baseURL, path)
 
 		// Create request
-		req, err := http.NewRequestWithContext(ctx, method, fullURL, body)
+		var reqBody io.Reader
+		if payload != nil {
+			reqBody = bytes.NewReader(payload)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, fullURL, reqBody)
 		if err != nil {
 			lastErr = fmt.Errorf("failed to create request: %w", err)
 			continue
@@ -150,9 +317,13 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body io.Rea
 		// Set common headers
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("Accept", "application/json")
+		setIdempotencyKey(ctx, req)
 
 		// Perform request with retry logic
-		resp, err := c.doRequestWithRetry(ctx, req)
+		resp, err := c.doRequestWithRetry(ctx, req, baseURL)
+		if errors.Is(err, errNotRetried) {
+			return nil, err
+		}
 		if err != nil {
 			lastErr = err
 			// If this was the master URL and it failed, clear the cache
@@ -161,6 +332,7 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body io.Rea
 			}
 			continue
 		}
+		c.breaker.success()
 
 		// Check for authentication errors
 		if resp.StatusCode == http.StatusUnauthorized {
@@ -179,8 +351,9 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body io.Rea
 	}
 
 	// All instances failed
+	c.breaker.failure(ctx)
 	if lastErr != nil {
-		return nil, fmt.Errorf("%w: %v", ErrAllInstancesFailed, lastErr)
+		return nil, fmt.Errorf("%w: %w", ErrAllInstancesFailed, lastErr)
 	}
 
 	return nil, ErrAllInstancesFailed
@@ -189,18 +362,18 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body io.Rea
 // buildURLList builds a prioritized list of URLs to try for a request.
 // If preferMaster is true and a master is cached, it will be first in the list.
 func (c *Client) buildURLList(preferMaster bool) []string {
-	if preferMaster {
-		masterURL := c.getMasterURL()
-		if masterURL != "" {
-			// Master URL first, then others
-			urls := []string{masterURL}
-			for _, url := range c.BaseURLs {
-				if url != masterURL {
-					urls = append(urls, url)
-				}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if preferMaster && c.masterURL != "" {
+		// Master URL first, then others
+		urls := []string{c.masterURL}
+		for _, url := range c.BaseURLs {
+			if url != c.masterURL {
+				urls = append(urls, url)
 			}
-			return urls
 		}
+		return urls
 	}
 
 	// Return all URLs in order
@@ -223,19 +396,28 @@ func (c *Client) parseJSONResponse(resp *http.Response, dest interface{}) error
 	return nil
 }
 
-// parseErrorResponse attempts to parse an error response from the server.
+// parseErrorResponse converts an error response from the server into an *APIError,
+// wrapped in a *BatchError when the server names the rejected item of a batch.
+// A body that is not a JSON error leaves only the status code to classify the error.
 func (c *Client) parseErrorResponse(resp *http.Response) error {
-	var apiErr APIResponse
-	if err := c.parseJSONResponse(resp, &apiErr); err != nil {
-		// Failed to parse error response, return generic error
-		return fmt.Errorf("request failed with status %d", resp.StatusCode)
-	}
+	apiErr := &APIError{StatusCode: resp.StatusCode}
 
-	if apiErr.Error != "" {
-		return fmt.Errorf("API error: %s", apiErr.Error)
+	var body struct {
+		Error     string `json:"error"`
+		Message   string `json:"message"`
+		RequestID string `json:"request_id"`
+		Index     *int   `json:"index"`
+	}
+	if err := c.parseJSONResponse(resp, &body); err == nil {
+		apiErr.Code = body.Error
+		apiErr.Message = body.Message
+		apiErr.RequestID = body.RequestID
+		if body.Index != nil {
+			return &BatchError{Index: *body.Index, Err: apiErr}
+		}
 	}
 
-	return fmt.Errorf("request failed with status %d", resp.StatusCode)
+	return apiErr
 }
 
 // doJSONRequest is a convenience method that performs a request with JSON body and parses the JSON response.
@@ -277,6 +459,9 @@ func (c *Client) doJSONRequest(ctx context.Context, method, path string, reqBody
 // It returns the node credentials including the node ID, node token, and Nebula IP.
 // The node token is only returned once and must be stored securely.
 //
+// A request that may have reached the server is not retried, since that could
+// create the node twice; use WithIdempotencyKey to make it retryable.
+//
 // This operation requires cluster token authentication and is executed on the master instance.
 //
 // Parameters:
@@ -284,12 +469,16 @@ func (c *Client) doJSONRequest(ctx context.Context, method, path string, reqBody
 //   - name: Human-readable name for the node (1-255 characters)
 //   - isAdmin: Whether the node should have administrative privileges
 //   - mtu: Maximum Transmission Unit for the node (576-9000)
+//   - opts: Optional per-request settings, such as WithTimeout
 //
 // Returns:
 //   - *NodeCredentials: The created node's credentials (ID, token, IP)
 //   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
 //     or other errors for validation failures or network issues
-func (c *Client) CreateNode(ctx context.Context, name string, isAdmin bool, mtu int) (*NodeCredentials, error) {
+func (c *Client) CreateNode(ctx context.Context, name string, isAdmin bool, mtu int, opts ...RequestOption) (*NodeCredentials, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
 	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes", c.TenantID, c.ClusterID)
 
 	reqBody := map[string]interface{}{
@@ -306,19 +495,65 @@ func (c *Client) CreateNode(ctx context.Context, name string, isAdmin bool, mtu
 	return &credentials, nil
 }
 
+// CreateNodes creates several nodes in the cluster in one request. The server
+// inserts them in a single transaction and bumps the config version once, so
+// enrolling many nodes is much cheaper than calling CreateNode repeatedly.
+// Credentials are returned in the order of reqs.
+//
+// If any node is rejected, none are created and the error is a *BatchError
+// naming the rejected request, e.g. a duplicate name (which also matches
+// ErrConflict).
+//
+// A request that may have reached the server is not retried, since that could
+// create the nodes twice; use WithIdempotencyKey to make it retryable.
+//
+// This operation requires cluster token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - reqs: Nodes to create
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - []*NodeCredentials: The created nodes' credentials, in request order
+//   - error: *BatchError if a node was rejected, ErrUnauthorized if cluster token is
+//     invalid, ErrRateLimited if rate limited, or other errors for network issues
+func (c *Client) CreateNodes(ctx context.Context, reqs []NodeCreateRequest, opts ...RequestOption) ([]*NodeCredentials, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes/batch", c.TenantID, c.ClusterID)
+
+	reqBody := map[string]interface{}{
+		"nodes": reqs,
+	}
+
+	var credentials []*NodeCredentials
+	if err := c.doJSONRequest(ctx, http.MethodPost, path, reqBody, &credentials, AuthTypeCluster, true); err != nil {
+		return nil, fmt.Errorf("failed to create nodes: %w", err)
+	}
+
+	return credentials, nil
+}
+
 // DeleteNode removes a node from the cluster.
-// This operation is irreversible and will invalidate the node's authentication token.
+// The node's authentication token stops working immediately. The deletion can
+// be undone with RestoreNode until the server purges deleted nodes.
 //
 // This operation requires cluster token authentication and is executed on the master instance.
 //
 // Parameters:
 //   - ctx: Request context for cancellation and timeouts
 //   - nodeID: The unique identifier of the node to delete
+//   - opts: Optional per-request settings, such as WithTimeout
 //
 // Returns:
 //   - error: ErrUnauthorized if cluster token is invalid, ErrNotFound if node doesn't exist,
 //     ErrRateLimited if rate limited, or other errors for network issues
-func (c *Client) DeleteNode(ctx context.Context, nodeID string) error {
+func (c *Client) DeleteNode(ctx context.Context, nodeID string, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
 	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes/%s", c.TenantID, c.ClusterID, nodeID)
 
 	if err := c.doJSONRequest(ctx, http.MethodDelete, path, nil, nil, AuthTypeCluster, true); err != nil {
@@ -328,7 +563,95 @@ func (c *Client) DeleteNode(ctx context.Context, nodeID string) error {
 	return nil
 }
 
-// ListNodes retrieves a paginated list of nodes in the cluster.
+// RestoreNode undoes the deletion of a node that has not been purged yet.
+// The node regains its previous configuration and authentication token.
+//
+// This operation requires cluster token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - nodeID: The unique identifier of the deleted node
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - *NodeSummary: The restored node
+//   - error: ErrUnauthorized if cluster token is invalid, ErrNotFound if no deleted node
+//     with that ID exists, or other errors for network issues
+func (c *Client) RestoreNode(ctx context.Context, nodeID string, opts ...RequestOption) (*NodeSummary, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes/%s/restore", c.TenantID, c.ClusterID, nodeID)
+
+	var summary NodeSummary
+	if err := c.doJSONRequest(ctx, http.MethodPost, path, nil, &summary, AuthTypeCluster, true); err != nil {
+		return nil, fmt.Errorf("failed to restore node: %w", err)
+	}
+
+	return &summary, nil
+}
+
+// GetNode retrieves a single node in the cluster by ID, including its
+// lighthouse and relay roles and advertised routes.
+// This operation can be executed on any control plane instance (master or replica).
+//
+// This operation requires cluster token authentication.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - nodeID: The unique identifier of the node
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - *NodeSummary: The node's details
+//   - error: ErrNotFound if the node does not exist in the cluster, ErrUnauthorized if
+//     cluster token is invalid, ErrRateLimited if rate limited, or other errors for network issues
+func (c *Client) GetNode(ctx context.Context, nodeID string, opts ...RequestOption) (*NodeSummary, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes/%s", c.TenantID, c.ClusterID, nodeID)
+
+	var node NodeSummary
+	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &node, AuthTypeCluster, false); err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+
+	return &node, nil
+}
+
+// NodeFilter restricts the nodes returned by ListNodes. Filters are
+// RequestOptions, so they can be combined with options such as WithTimeout.
+type NodeFilter func(query url.Values)
+
+func (f NodeFilter) applyRequest(o *requestOptions) {
+	o.filters = append(o.filters, f)
+}
+
+// FilterNameContains keeps nodes whose name contains substr (case-insensitive
+// for ASCII letters).
+func FilterNameContains(substr string) NodeFilter {
+	return func(query url.Values) { query.Set("name_contains", substr) }
+}
+
+// FilterAdmin keeps admin nodes if isAdmin is true, non-admin nodes otherwise.
+func FilterAdmin(isAdmin bool) NodeFilter {
+	return func(query url.Values) { query.Set("is_admin", strconv.FormatBool(isAdmin)) }
+}
+
+// FilterLighthouse keeps lighthouses if isLighthouse is true, other nodes otherwise.
+func FilterLighthouse(isLighthouse bool) NodeFilter {
+	return func(query url.Values) { query.Set("is_lighthouse", strconv.FormatBool(isLighthouse)) }
+}
+
+// FilterRelay keeps relays if isRelay is true, other nodes otherwise.
+func FilterRelay(isRelay bool) NodeFilter {
+	return func(query url.Values) { query.Set("is_relay", strconv.FormatBool(isRelay)) }
+}
+
+// ListNodes retrieves a paginated list of nodes in the cluster, optionally
+// restricted by filters such as FilterNameContains or FilterRelay. Filters
+// combine: a node must match all of them. Pagination applies to the filtered list.
 // This operation can be executed on any control plane instance (master or replica).
 //
 // This operation requires cluster token authentication.
@@ -337,14 +660,26 @@ func (c *Client) DeleteNode(ctx context.Context, nodeID string) error {
 //   - ctx: Request context for cancellation and timeouts
 //   - page: Page number (1-based, use 1 for first page)
 //   - pageSize: Number of nodes per page (1-1000)
+//   - opts: Optional filters restricting the nodes returned, and per-request
+//     settings such as WithTimeout
 //
 // Returns:
 //   - []NodeSummary: List of nodes in the cluster
 //   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
 //     or other errors for validation failures or network issues
-func (c *Client) ListNodes(ctx context.Context, page, pageSize int) ([]NodeSummary, error) {
-	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes?page=%d&page_size=%d",
-		c.TenantID, c.ClusterID, page, pageSize)
+func (c *Client) ListNodes(ctx context.Context, page, pageSize int, opts ...RequestOption) ([]NodeSummary, error) {
+	options := collectRequestOptions(opts)
+	ctx, cancel := options.context(ctx)
+	defer cancel()
+
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(page))
+	query.Set("page_size", strconv.Itoa(pageSize))
+	for _, filter := range options.filters {
+		filter(query)
+	}
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes?%s",
+		c.TenantID, c.ClusterID, query.Encode())
 
 	var nodes []NodeSummary
 	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &nodes, AuthTypeCluster, false); err != nil {
@@ -354,456 +689,1919 @@ func (c *Client) ListNodes(ctx context.Context, page, pageSize int) ([]NodeSumma
 	return nodes, nil
 }
 
-// UpdateMTU updates the Maximum Transmission Unit for a specific node.
-// The new MTU must be between 576 and 9000 bytes.
+// GetClusterStats retrieves summary counts for the cluster: nodes, admins,
+// lighthouses, relays and advertised routes, along with the current config
+// version and last change time. Use this instead of ListNodes when only
+// counts are needed.
 //
-// This operation requires cluster token authentication and is executed on the master instance.
+// This operation can be executed on any control plane instance (master or replica).
+//
+// This operation requires cluster token authentication.
 //
 // Parameters:
 //   - ctx: Request context for cancellation and timeouts
-//   - nodeID: The unique identifier of the node to update
-//   - mtu: The new MTU value (576-9000)
+//   - opts: Optional per-request settings, such as WithTimeout
 //
 // Returns:
-//   - error: ErrUnauthorized if cluster token is invalid, ErrNotFound if node doesn't exist,
-//     ErrRateLimited if rate limited, or other errors for validation failures or network issues
-func (c *Client) UpdateMTU(ctx context.Context, nodeID string, mtu int) error {
-	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes/%s/mtu", c.TenantID, c.ClusterID, nodeID)
-
-	reqBody := map[string]interface{}{
-		"mtu": mtu,
+//   - *ClusterStats: Cluster summary counts
+//   - error: ErrUnauthorized if cluster token is invalid, ErrNotFound if the cluster
+//     does not exist, ErrRateLimited if rate limited, or other errors for network issues
+func (c *Client) GetClusterStats(ctx context.Context, opts ...RequestOption) (*ClusterStats, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/clusters/%s/stats", c.ClusterID)
+
+	var stats ClusterStats
+	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &stats, AuthTypeCluster, false); err != nil {
+		return nil, fmt.Errorf("failed to get cluster stats: %w", err)
 	}
 
-	if err := c.doJSONRequest(ctx, http.MethodPut, path, reqBody, nil, AuthTypeCluster, true); err != nil {
-		return fmt.Errorf("failed to update MTU: %w", err)
+	return &stats, nil
+}
+
+// GetConfigHistory retrieves the cluster's config change timeline, newest
+// first: one entry per config version saying what triggered it (a node being
+// added, a route change, a bundle upload, ...) and which node made it. The
+// server returns the 50 most recent changes.
+//
+// This operation can be executed on any control plane instance (master or replica).
+//
+// This operation requires cluster token authentication.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - []ConfigChange: Config changes, newest first
+//   - error: ErrUnauthorized if cluster token is invalid, ErrNotFound if the cluster
+//     does not exist, ErrRateLimited if rate limited, or other errors for network issues
+func (c *Client) GetConfigHistory(ctx context.Context, opts ...RequestOption) ([]ConfigChange, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/clusters/%s/history", c.ClusterID)
+
+	var resp struct {
+		Changes []ConfigChange `json:"changes"`
+	}
+	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &resp, AuthTypeCluster, false); err != nil {
+		return nil, fmt.Errorf("failed to get config history: %w", err)
 	}
 
-	return nil
+	return resp.Changes, nil
 }
 
-// RotateNodeToken generates a new authentication token for the specified node.
-// The old token is immediately invalidated. The new token is only returned once
-// and must be provided to the node daemon to maintain connectivity.
+// GetTenantStats retrieves usage totals across all clusters of the client's
+// tenant: clusters, nodes and bundle storage. Use it for capacity planning and
+// to watch database growth from retained bundle versions.
 //
-// This operation requires cluster token authentication and is executed on the master instance.
+// This operation can be executed on any control plane instance (master or replica).
+//
+// This operation requires admin node token authentication.
 //
 // Parameters:
 //   - ctx: Request context for cancellation and timeouts
-//   - nodeID: The unique identifier of the node whose token should be rotated
+//   - opts: Optional per-request settings, such as WithTimeout
 //
 // Returns:
-//   - string: The new authentication token (store securely, only returned once)
-//   - error: ErrUnauthorized if cluster token is invalid, ErrNotFound if node doesn't exist,
+//   - *TenantStats: Tenant usage totals
+//   - error: ErrUnauthorized if node token is invalid or not admin,
 //     ErrRateLimited if rate limited, or other errors for network issues
-func (c *Client) RotateNodeToken(ctx context.Context, nodeID string) (string, error) {
-	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes/%s/rotate-token", c.TenantID, c.ClusterID, nodeID)
+func (c *Client) GetTenantStats(ctx context.Context, opts ...RequestOption) (*TenantStats, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
 
-	var response TokenRotationResponse
-	if err := c.doJSONRequest(ctx, http.MethodPost, path, nil, &response, AuthTypeCluster, true); err != nil {
-		return "", fmt.Errorf("failed to rotate node token: %w", err)
+	path := fmt.Sprintf("/api/v1/tenants/%s/stats", c.TenantID)
+
+	var stats TenantStats
+	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &stats, AuthTypeNode, false); err != nil {
+		return nil, fmt.Errorf("failed to get tenant stats: %w", err)
 	}
 
-	return response.Token, nil
+	return &stats, nil
 }
 
-// ============================================================================
-// Config Bundle Methods
-// ============================================================================
-
-// GetLatestVersion retrieves the current config bundle version for the cluster.
-// This is used by the daemon to check if a new config version is available without
-// downloading the full bundle.
+// CreateTenant provisions a tenant.
 //
-// This operation requires node token authentication and can be executed on any
-// control plane instance (master or replica).
+// This operation requires operator token authentication and is executed on the master instance.
 //
 // Parameters:
 //   - ctx: Request context for cancellation and timeouts
+//   - name: Tenant name (1-255 characters, unique across all tenants)
+//   - opts: Optional per-request settings, such as WithTimeout
 //
 // Returns:
-//   - int64: The current config bundle version number
-//   - error: ErrUnauthorized if node token is invalid, ErrRateLimited if rate limited,
-//     or other errors for network issues
-func (c *Client) GetLatestVersion(ctx context.Context) (int64, error) {
-	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/config/version", c.TenantID, c.ClusterID)
+//   - *Tenant: The created tenant
+//   - error: ErrUnauthorized if the operator token is invalid, ErrConflict if
+//     a tenant with this name exists, or other errors for network issues
+func (c *Client) CreateTenant(ctx context.Context, name string, opts ...RequestOption) (*Tenant, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
 
-	var versionResp VersionResponse
-	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &versionResp, AuthTypeNode, false); err != nil {
-		return 0, fmt.Errorf("failed to get latest version: %w", err)
+	reqBody := map[string]interface{}{
+		"name": name,
 	}
 
-	return versionResp.Version, nil
+	var tenant Tenant
+	if err := c.doJSONRequest(ctx, http.MethodPost, "/api/v1/admin/tenants", reqBody, &tenant, AuthTypeOperator, true); err != nil {
+		return nil, fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	return &tenant, nil
 }
 
-// DownloadBundle downloads the config bundle if a newer version is available.
-// It supports HTTP 304 Not Modified responses to avoid unnecessary downloads.
+// ListTenants retrieves a page of all tenants, ordered by name.
+// This operation can be executed on any control plane instance (master or replica).
 //
-// This operation requires node token authentication and can be executed on any
-// control plane instance (master or replica).
+// This operation requires operator token authentication.
 //
-// If the server returns 304 Not Modified (currentVersion is up to date):
-//   - Returns (nil, currentVersion, nil)
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - page: Page number (1-based, use 1 for first page)
+//   - pageSize: Number of tenants per page (max 500)
+//   - opts: Optional per-request settings, such as WithTimeout
 //
-// If a new version is available:
-//   - Returns (bundleData, newVersion, nil)
+// Returns:
+//   - *TenantList: The page of tenants and the total tenant count
+//   - error: ErrUnauthorized if the operator token is invalid,
+//     ErrRateLimited if rate limited, or other errors for network issues
+func (c *Client) ListTenants(ctx context.Context, page, pageSize int, opts ...RequestOption) (*TenantList, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(page))
+	query.Set("page_size", strconv.Itoa(pageSize))
+	path := "/api/v1/admin/tenants?" + query.Encode()
+
+	var list TenantList
+	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &list, AuthTypeOperator, false); err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	return &list, nil
+}
+
+// GetTenant retrieves a tenant by ID.
+// This operation can be executed on any control plane instance (master or replica).
+//
+// This operation requires operator token authentication.
 //
 // Parameters:
 //   - ctx: Request context for cancellation and timeouts
-//   - currentVersion: The version currently installed on the node
+//   - tenantID: The tenant's unique identifier
+//   - opts: Optional per-request settings, such as WithTimeout
 //
 // Returns:
-//   - []byte: The bundle data as a tar.gz archive, or nil if no update
-//   - int64: The new version number, or currentVersion if no update
-//   - error: ErrUnauthorized if node token is invalid, ErrRateLimited if rate limited,
-//     or other errors for network issues
-func (c *Client) DownloadBundle(ctx context.Context, currentVersion int64) ([]byte, int64, error) {
-	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/config/bundle?current_version=%d",
-		c.TenantID, c.ClusterID, currentVersion)
-
-	// Build URL list
-	urls := c.buildURLList(false)
-	if len(urls) == 0 {
-		return nil, 0, ErrNoBaseURLs
+//   - *Tenant: The tenant
+//   - error: ErrUnauthorized if the operator token is invalid, ErrNotFound if
+//     the tenant doesn't exist, or other errors for network issues
+func (c *Client) GetTenant(ctx context.Context, tenantID string, opts ...RequestOption) (*Tenant, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/admin/tenants/%s", tenantID)
+
+	var tenant Tenant
+	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &tenant, AuthTypeOperator, false); err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
 	}
 
-	var lastErr error
+	return &tenant, nil
+}
 
-	for _, baseURL := range urls {
-		fullURL := fmt.Sprintf("%s%s", baseURL, path)
+// DeleteTenant permanently deletes a tenant with all of its clusters, nodes
+// and config bundles. This cannot be undone.
+//
+// This operation requires operator token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - tenantID: The tenant's unique identifier
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - error: ErrUnauthorized if the operator token is invalid, ErrNotFound if
+//     the tenant doesn't exist, or other errors for network issues
+func (c *Client) DeleteTenant(ctx context.Context, tenantID string, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
 
-		// Create request
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
-		if err != nil {
-			lastErr = fmt.Errorf("failed to create request: %w", err)
-			continue
-		}
+	path := fmt.Sprintf("/api/v1/admin/tenants/%s", tenantID)
 
-		// Add authentication headers
-		if err := c.addAuthHeaders(req, AuthTypeNode); err != nil {
-			return nil, 0, err
-		}
+	if err := c.doJSONRequest(ctx, http.MethodDelete, path, nil, nil, AuthTypeOperator, true); err != nil {
+		return fmt.Errorf("failed to delete tenant: %w", err)
+	}
 
-		// Set headers
-		req.Header.Set("Accept", "application/octet-stream")
+	return nil
+}
 
-		// Perform request with retry
-		resp, err := c.doRequestWithRetry(ctx, req)
-		if err != nil {
-			lastErr = err
-			if baseURL == c.getMasterURL() {
-				c.clearMasterCache()
-			}
-			continue
-		}
+// CreateCluster creates a cluster in the client's tenant and returns it with
+// its cluster token. The token is only returned here; store it securely and
+// distribute it to the cluster's nodes.
+//
+// This operation requires operator token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - name: Cluster name (1-255 characters, unique within the tenant)
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - *Cluster: The created cluster
+//   - string: The cluster token
+//   - error: ErrUnauthorized if the operator token is invalid, ErrConflict if
+//     the tenant already has a cluster with this name, or other errors for network issues
+func (c *Client) CreateCluster(ctx context.Context, name string, opts ...RequestOption) (*Cluster, string, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
 
-		// Check for 304 Not Modified
-		if resp.StatusCode == http.StatusNotModified {
-			drainAndCloseBody(resp)
-			return nil, currentVersion, nil
-		}
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters", c.TenantID)
 
-		// Check for authentication errors
-		if resp.StatusCode == http.StatusUnauthorized {
-			drainAndCloseBody(resp)
-			return nil, 0, ErrUnauthorized
-		}
+	reqBody := map[string]interface{}{
+		"name": name,
+	}
 
-		// Check for rate limiting
-		if resp.StatusCode == http.StatusTooManyRequests {
-			drainAndCloseBody(resp)
-			return nil, 0, ErrRateLimited
-		}
+	var resp struct {
+		Cluster      Cluster `json:"cluster"`
+		ClusterToken string  `json:"cluster_token"`
+	}
+	if err := c.doJSONRequest(ctx, http.MethodPost, path, reqBody, &resp, AuthTypeOperator, true); err != nil {
+		return nil, "", fmt.Errorf("failed to create cluster: %w", err)
+	}
 
-		// Check for success
-		if resp.StatusCode != http.StatusOK {
-			err := c.parseErrorResponse(resp)
-			lastErr = err
-			continue
-		}
+	return &resp.Cluster, resp.ClusterToken, nil
+}
 
-		// Read bundle data
-		data, err := io.ReadAll(resp.Body)
-		drainAndCloseBody(resp)
-		if err != nil {
-			lastErr = fmt.Errorf("failed to read bundle data: %w", err)
-			continue
-		}
+// ListClusters retrieves a page of the clusters in the client's tenant, oldest first.
+// This operation can be executed on any control plane instance (master or replica).
+//
+// This operation requires operator token authentication.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - page: Page number (1-based, use 1 for first page)
+//   - pageSize: Number of clusters per page (max 500)
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - *ClusterList: The page of clusters and the tenant's total cluster count
+//   - error: ErrUnauthorized if the operator token is invalid,
+//     ErrRateLimited if rate limited, or other errors for network issues
+func (c *Client) ListClusters(ctx context.Context, page, pageSize int, opts ...RequestOption) (*ClusterList, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(page))
+	query.Set("page_size", strconv.Itoa(pageSize))
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters?%s", c.TenantID, query.Encode())
+
+	var list ClusterList
+	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &list, AuthTypeOperator, false); err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
 
-		// Get new version from header
-		versionHeader := resp.Header.Get("X-Config-Version")
-		if versionHeader == "" {
-			lastErr = fmt.Errorf("missing X-Config-Version header in response")
-			continue
+	return &list, nil
+}
+
+// GetCluster retrieves a cluster of the client's tenant by ID.
+// This operation can be executed on any control plane instance (master or replica).
+//
+// This operation requires operator token authentication.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - clusterID: The cluster's unique identifier
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - *Cluster: The cluster
+//   - error: ErrUnauthorized if the operator token is invalid, ErrNotFound if
+//     the tenant has no such cluster, or other errors for network issues
+func (c *Client) GetCluster(ctx context.Context, clusterID string, opts ...RequestOption) (*Cluster, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s", c.TenantID, clusterID)
+
+	var cluster Cluster
+	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &cluster, AuthTypeOperator, false); err != nil {
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	return &cluster, nil
+}
+
+// DeleteCluster permanently deletes a cluster of the client's tenant together
+// with its nodes and config bundles. This cannot be undone.
+//
+// This operation requires operator token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - clusterID: The cluster's unique identifier
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - error: ErrUnauthorized if the operator token is invalid, ErrNotFound if
+//     the tenant has no such cluster, or other errors for network issues
+func (c *Client) DeleteCluster(ctx context.Context, clusterID string, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s", c.TenantID, clusterID)
+
+	if err := c.doJSONRequest(ctx, http.MethodDelete, path, nil, nil, AuthTypeOperator, true); err != nil {
+		return fmt.Errorf("failed to delete cluster: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateMTU updates the Maximum Transmission Unit for a specific node.
+// The new MTU must be between 576 and 9000 bytes.
+//
+// This operation requires cluster token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - nodeID: The unique identifier of the node to update
+//   - mtu: The new MTU value (576-9000)
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - error: ErrUnauthorized if cluster token is invalid, ErrNotFound if node doesn't exist,
+//     ErrRateLimited if rate limited, or other errors for validation failures or network issues
+func (c *Client) UpdateMTU(ctx context.Context, nodeID string, mtu int, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes/%s/mtu", c.TenantID, c.ClusterID, nodeID)
+
+	reqBody := map[string]interface{}{
+		"mtu": mtu,
+	}
+
+	if err := c.doJSONRequest(ctx, http.MethodPut, path, reqBody, nil, AuthTypeCluster, true); err != nil {
+		return fmt.Errorf("failed to update MTU: %w", err)
+	}
+
+	return nil
+}
+
+// SetPreferredRelays sets the ordered list of relays a node should use. Each
+// relay must be a relay node in the same cluster. An empty list clears the
+// preference so the node uses every relay in the cluster.
+//
+// This operation requires cluster token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - nodeID: The unique identifier of the node to update
+//   - relayNodeIDs: Ordered relay node IDs (nil or empty to clear)
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - error: ErrUnauthorized if cluster token is invalid, ErrNotFound if node doesn't exist,
+//     ErrRateLimited if rate limited, or other errors for validation failures or network issues
+func (c *Client) SetPreferredRelays(ctx context.Context, nodeID string, relayNodeIDs []string, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes/%s/relays", c.TenantID, c.ClusterID, nodeID)
+
+	if relayNodeIDs == nil {
+		relayNodeIDs = []string{}
+	}
+	reqBody := map[string]interface{}{
+		"relay_node_ids": relayNodeIDs,
+	}
+
+	if err := c.doJSONRequest(ctx, http.MethodPut, path, reqBody, nil, AuthTypeCluster, true); err != nil {
+		return fmt.Errorf("failed to set preferred relays: %w", err)
+	}
+
+	return nil
+}
+
+// RotateNodeToken generates a new authentication token for the specified node.
+// The old token is immediately invalidated. The new token is only returned once
+// and must be provided to the node daemon to maintain connectivity.
+//
+// This operation requires cluster token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - nodeID: The unique identifier of the node whose token should be rotated
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - string: The new authentication token (store securely, only returned once)
+//   - error: ErrUnauthorized if cluster token is invalid, ErrNotFound if node doesn't exist,
+//     ErrRateLimited if rate limited, or other errors for network issues
+func (c *Client) RotateNodeToken(ctx context.Context, nodeID string, opts ...RequestOption) (string, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes/%s/rotate-token", c.TenantID, c.ClusterID, nodeID)
+
+	var response TokenRotationResponse
+	if err := c.doJSONRequest(ctx, http.MethodPost, path, nil, &response, AuthTypeCluster, true); err != nil {
+		return "", fmt.Errorf("failed to rotate node token: %w", err)
+	}
+
+	return response.Token, nil
+}
+
+// ============================================================================
+// Config Bundle Methods
+// ============================================================================
+
+// GetLatestVersion retrieves the current config bundle version for the cluster.
+// This is used by the daemon to check if a new config version is available without
+// downloading the full bundle.
+//
+// This operation requires node token authentication and can be executed on any
+// control plane instance (master or replica).
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - int64: The current config bundle version number
+//   - error: ErrUnauthorized if node token is invalid, ErrRateLimited if rate limited,
+//     or other errors for network issues
+func (c *Client) GetLatestVersion(ctx context.Context, opts ...RequestOption) (int64, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/config/version", c.TenantID, c.ClusterID)
+
+	var versionResp VersionResponse
+	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &versionResp, AuthTypeNode, false); err != nil {
+		return 0, fmt.Errorf("failed to get latest version: %w", err)
+	}
+
+	return versionResp.Version, nil
+}
+
+// GetVersionStatus retrieves the current config bundle version for the
+// cluster from the master instance, along with whether a canary rollout was
+// rolled back. Replicas may lag the master, so daemons confirm a version lower
+// than the one they run with this call before going back to it.
+//
+// This operation requires node token authentication and is executed on the
+// master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - *VersionResponse: The current version and rollback state
+//   - error: ErrUnauthorized if node token is invalid, ErrRateLimited if rate limited,
+//     or other errors for network issues
+func (c *Client) GetVersionStatus(ctx context.Context, opts ...RequestOption) (*VersionResponse, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/config/version", c.TenantID, c.ClusterID)
+
+	var versionResp VersionResponse
+	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &versionResp, AuthTypeNode, true); err != nil {
+		return nil, fmt.Errorf("failed to get version status: %w", err)
+	}
+
+	return &versionResp, nil
+}
+
+// HeadLatestVersion retrieves the current config bundle version for the
+// cluster with a HEAD request, reading it from the X-Config-Version header.
+// It returns the same version as GetLatestVersion without a response body,
+// which makes it the cheaper choice for frequent polling.
+//
+// This operation requires node token authentication and can be executed on any
+// control plane instance (master or replica).
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - int64: The current config bundle version number
+//   - error: ErrUnauthorized if node token is invalid, ErrRateLimited if rate limited,
+//     or other errors for network issues
+func (c *Client) HeadLatestVersion(ctx context.Context, opts ...RequestOption) (int64, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/config/bundle", c.TenantID, c.ClusterID)
+
+	resp, err := c.doRequest(ctx, http.MethodHead, path, nil, AuthTypeNode, false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest version: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to get latest version: %w", c.parseErrorResponse(resp))
+	}
+	drainAndCloseBody(resp)
+
+	versionHeader := resp.Header.Get("X-Config-Version")
+	if versionHeader == "" {
+		return 0, fmt.Errorf("missing X-Config-Version header in response")
+	}
+	version, err := parseVersion(versionHeader)
+	if err != nil {
+		return 0, fmt.Errorf("invalid version header: %w", err)
+	}
+
+	return version, nil
+}
+
+// WaitForVersion blocks until the cluster's latest config version is at least
+// target, polling GetLatestVersion every pollInterval. Deploy scripts use it
+// after UploadBundle to wait until the upload is live on the control plane.
+//
+// A bundle scheduled for later with WithEffectiveAt only counts once it is active.
+// Failed polls are retried on the next tick, except for ErrUnauthorized, which
+// is returned immediately.
+//
+// Parameters:
+//   - ctx: Request context; its deadline bounds the whole wait
+//   - target: The version to wait for
+//   - pollInterval: Time between polls (one second if zero or negative)
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - error: nil once the version is available, ErrUnauthorized if the node
+//     token is invalid, or the last poll error (or the context's error if no
+//     poll failed) when ctx is done first
+func (c *Client) WaitForVersion(ctx context.Context, target int64, pollInterval time.Duration, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return fmt.Errorf("version %d not available: %w", target, lastErr)
+			}
+			return fmt.Errorf("version %d not available: %w", target, ctx.Err())
+		case <-timer.C:
 		}
 
-		newVersion, err := parseVersion(versionHeader)
-		if err != nil {
-			lastErr = fmt.Errorf("invalid version header: %w", err)
-			continue
+		version, err := c.GetLatestVersion(ctx)
+		switch {
+		case errors.Is(err, ErrUnauthorized):
+			return err
+		case err != nil:
+			// A poll cut short by ctx says nothing new; keep the earlier cause
+			if ctx.Err() == nil || lastErr == nil {
+				lastErr = err
+			}
+		case version >= target:
+			return nil
+		default:
+			lastErr = nil
 		}
 
-		return data, newVersion, nil
-	}
+		timer.Reset(pollInterval)
+	}
+}
+
+// GetLatestVersions retrieves the current config bundle version for several
+// clusters in one request. A daemon managing many clusters uses this to
+// collapse its per-cluster version polls into a single call.
+//
+// Credentials are carried per cluster in the request body, so the client's own
+// tenant, cluster and tokens are not used. A cluster whose credentials are
+// rejected is reported through its result's Error field rather than failing
+// the whole call. Batches are not signed, so clusters that require signed
+// requests are rejected too and must be checked with GetLatestVersion.
+//
+// This operation can be executed on any control plane instance (master or replica).
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - clusters: Clusters to check, at most MaxVersionBatchSize
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - []ClusterVersion: One result per cluster, in request order
+//   - error: ErrBadRequest if the batch is empty or exceeds MaxVersionBatchSize,
+//     ErrRateLimited if rate limited, or other errors for network issues
+func (c *Client) GetLatestVersions(ctx context.Context, clusters []ClusterRef, opts ...RequestOption) ([]ClusterVersion, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	if len(clusters) == 0 || len(clusters) > MaxVersionBatchSize {
+		return nil, fmt.Errorf("failed to get latest versions: %w: batch must contain 1 to %d clusters",
+			ErrBadRequest, MaxVersionBatchSize)
+	}
+
+	reqBody := map[string]interface{}{
+		"clusters": clusters,
+	}
+
+	var versionsResp VersionsResponse
+	if err := c.doJSONRequest(ctx, http.MethodPost, "/api/v1/config/versions", reqBody, &versionsResp, AuthTypeNone, false); err != nil {
+		return nil, fmt.Errorf("failed to get latest versions: %w", err)
+	}
+
+	return versionsResp.Versions, nil
+}
+
+// DownloadBundle downloads the config bundle if a newer version is available.
+// It supports HTTP 304 Not Modified responses to avoid unnecessary downloads.
+// The whole bundle is held in memory; use DownloadBundleTo to stream it into a
+// file instead.
+//
+// This operation requires node token authentication and can be executed on any
+// control plane instance (master or replica).
+//
+// When currentVersion is set it is sent both as current_version and as an
+// If-None-Match entity tag, so HTTP caches in front of the control plane can
+// answer the request.
+//
+// If the server returns 304 Not Modified (currentVersion is up to date):
+//   - Returns (nil, currentVersion, nil)
+//
+// If a new version is available:
+//   - Returns (bundleData, newVersion, nil)
+//
+// The bundle is verified against the control plane's X-Config-Checksum header.
+// An instance that serves a corrupt bundle (e.g., a replica with a disk
+// problem) is skipped and the download is retried on the next instance, so
+// the call only fails if every instance that answered served bad data.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - currentVersion: The version currently installed on the node
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - []byte: The bundle data as a tar.gz archive, or nil if no update
+//   - int64: The new version number, or currentVersion if no update
+//   - error: ErrUnauthorized if node token is invalid, ErrRateLimited if rate limited,
+//     a *ChecksumMismatchError if every instance that answered served a corrupt
+//     bundle, or other errors for network issues
+func (c *Client) DownloadBundle(ctx context.Context, currentVersion int64, opts ...RequestOption) ([]byte, int64, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	signed, err := c.DownloadSignedBundle(ctx, currentVersion)
+	if err != nil {
+		return nil, 0, err
+	}
+	return signed.Data, signed.Version, nil
+}
+
+// DownloadSignedBundle downloads the latest config bundle like DownloadBundle,
+// along with the uploader's detached Ed25519 signature if the bundle was
+// signed. The signature is returned as served; verifying it against the
+// cluster's public key is up to the caller.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - currentVersion: The version currently installed on the node
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - *SignedBundle: The bundle, its version and signature; Data is nil and
+//     Version is currentVersion if no update is available
+//   - error: The same errors as DownloadBundle, or an error if the signature
+//     header is not valid base64
+func (c *Client) DownloadSignedBundle(ctx context.Context, currentVersion int64, opts ...RequestOption) (*SignedBundle, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/config/bundle?current_version=%d",
+		c.TenantID, c.ClusterID, currentVersion)
+
+	// Build URL list
+	urls := c.buildURLList(false)
+	if len(urls) == 0 {
+		return nil, ErrNoBaseURLs
+	}
+
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	var mismatch *ChecksumMismatchError
+	answered := false
+
+	for _, baseURL := range urls {
+		fullURL := fmt.Sprintf("%s%s", baseURL, path)
+
+		// Create request
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to create request: %w", err)
+			continue
+		}
+
+		// Add authentication headers
+		if err := c.addAuthHeaders(req, AuthTypeNode); err != nil {
+			return nil, err
+		}
+
+		// Set headers
+		req.Header.Set("Accept", "application/octet-stream")
+		if currentVersion > 0 {
+			req.Header.Set("If-None-Match", c.versionETag(currentVersion))
+		}
+
+		// Perform request with retry
+		resp, err := c.doRequestWithRetry(ctx, req, baseURL)
+		if err != nil {
+			lastErr = err
+			if baseURL == c.getMasterURL() {
+				c.clearMasterCache()
+			}
+			continue
+		}
+		answered = true
+		c.breaker.success()
+
+		// Check for 304 Not Modified
+		if resp.StatusCode == http.StatusNotModified {
+			drainAndCloseBody(resp)
+			return &SignedBundle{Version: currentVersion}, nil
+		}
+
+		// Check for authentication errors
+		if resp.StatusCode == http.StatusUnauthorized {
+			drainAndCloseBody(resp)
+			return nil, ErrUnauthorized
+		}
+
+		// Check for rate limiting
+		if resp.StatusCode == http.StatusTooManyRequests {
+			drainAndCloseBody(resp)
+			return nil, ErrRateLimited
+		}
+
+		// Check for success
+		if resp.StatusCode != http.StatusOK {
+			err := c.parseErrorResponse(resp)
+			lastErr = err
+			continue
+		}
+
+		// Read bundle data
+		data, err := io.ReadAll(resp.Body)
+		drainAndCloseBody(resp)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to read bundle data: %w", err)
+			continue
+		}
+
+		// Get new version from header
+		versionHeader := resp.Header.Get("X-Config-Version")
+		if versionHeader == "" {
+			lastErr = fmt.Errorf("missing X-Config-Version header in response")
+			continue
+		}
+
+		newVersion, err := parseVersion(versionHeader)
+		if err != nil {
+			lastErr = fmt.Errorf("invalid version header: %w", err)
+			continue
+		}
+
+		// Verify integrity; a corrupt instance is skipped in favor of the next
+		if checksum := resp.Header.Get("X-Config-Checksum"); checksum != "" {
+			sum := sha256.Sum256(data)
+			if !strings.EqualFold(hex.EncodeToString(sum[:]), checksum) {
+				if mismatch == nil {
+					mismatch = &ChecksumMismatchError{Version: newVersion}
+				}
+				mismatch.Instances = append(mismatch.Instances, baseURL)
+				continue
+			}
+		}
+
+		signature, err := parseSignature(resp.Header.Get("X-Config-Signature"))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		return &SignedBundle{Data: data, Version: newVersion, Signature: signature}, nil
+	}
+
+	if !answered {
+		c.breaker.failure(ctx)
+	}
+
+	// A corrupt bundle is reported over other failures so it can be alerted on
+	if mismatch != nil {
+		return nil, fmt.Errorf("failed to download bundle: %w", mismatch)
+	}
+
+	// All instances failed
+	if lastErr != nil {
+		return nil, fmt.Errorf("failed to download bundle: %w", lastErr)
+	}
+
+	return nil, ErrAllInstancesFailed
+}
+
+// UploadBundle uploads a new config bundle to the control plane.
+// This operation is typically restricted to admin nodes.
+//
+// This operation requires node token authentication and is executed on the master instance.
+//
+// The bundle must be a valid tar.gz archive containing the required Nebula configuration files.
+// The server will validate the bundle and increment the version number automatically.
+//
+// To roll out changes in a maintenance window, pass WithEffectiveAt: the
+// version is reserved immediately, but nodes keep receiving the previous
+// version until that time. The cutover is evaluated against the control plane
+// clock, so daemons pick it up on their first poll afterwards; node clocks only
+// need to be roughly in sync with the control plane for the window to hold.
+//
+// To roll out a bundle to a few nodes first, pass WithCanaryNodes: the bundle
+// and its canary rollout are stored together, so no other node receives it
+// before PromoteCanary.
+//
+// An upload that may have reached the server is not retried, since that could
+// store the bundle twice; use WithIdempotencyKey to make it retryable.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - data: The bundle data as a tar.gz archive
+//   - opts: Optional per-request settings, such as WithTimeout, WithEffectiveAt
+//     or WithCanaryNodes
+//
+// Returns:
+//   - *BundleUploadResult: The version assigned to this bundle, with its
+//     compressed and uncompressed sizes and file count
+//   - error: ErrUnauthorized if node token is invalid or node lacks admin privileges,
+//     ErrRateLimited if rate limited, or other errors for validation failures or network issues
+func (c *Client) UploadBundle(ctx context.Context, data []byte, opts ...RequestOption) (*BundleUploadResult, error) {
+	options := collectRequestOptions(opts)
+	ctx, cancel := options.context(ctx)
+	defer cancel()
+
+	return c.uploadBundle(ctx, data, nil, options)
+}
+
+// UploadSignedBundle uploads a config bundle like UploadBundle, together with
+// a detached Ed25519 signature of it. The control plane stores the signature
+// and serves it with every download, so daemons configured with the matching
+// public key can reject bundles that were not signed by the key holder.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - data: The bundle data as a tar.gz archive
+//   - signature: The Ed25519 signature of data
+//   - opts: Optional per-request settings, as for UploadBundle
+//
+// Returns:
+//   - *BundleUploadResult: The version assigned to this bundle, with its
+//     compressed and uncompressed sizes and file count
+//   - error: The same errors as UploadBundle
+func (c *Client) UploadSignedBundle(ctx context.Context, data, signature []byte, opts ...RequestOption) (*BundleUploadResult, error) {
+	options := collectRequestOptions(opts)
+	ctx, cancel := options.context(ctx)
+	defer cancel()
+
+	return c.uploadBundle(ctx, data, signature, options)
+}
+
+// uploadBundle implements UploadBundle and UploadSignedBundle; signature is
+// sent in the X-Config-Signature header when set.
+func (c *Client) uploadBundle(ctx context.Context, data, signature []byte, options requestOptions) (*BundleUploadResult, error) {
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/config/bundle", c.TenantID, c.ClusterID)
+	query := url.Values{}
+	if !options.effectiveAt.IsZero() {
+		query.Set("effective_at", options.effectiveAt.UTC().Format(time.RFC3339))
+	}
+	if len(options.canaryNodeIDs) > 0 {
+		query.Set("canary_nodes", strings.Join(options.canaryNodeIDs, ","))
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	// Build URL list preferring master
+	urls := c.buildURLList(true)
+	if len(urls) == 0 {
+		return nil, ErrNoBaseURLs
+	}
+
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	answered := false
+
+	for _, baseURL := range urls {
+		fullURL := fmt.Sprintf("%s%s", baseURL, path)
+
+		// Create request with binary body
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, fullURL, bytes.NewReader(data))
+		if err != nil {
+			lastErr = fmt.Errorf("failed to create request: %w", err)
+			continue
+		}
+
+		// Add authentication headers
+		if err := c.addAuthHeaders(req, AuthTypeNode); err != nil {
+			return nil, err
+		}
+
+		// Set headers for binary upload
+		req.Header.Set("Content-Type", "application/octet-stream")
+		req.Header.Set("Accept", "application/json")
+		if len(signature) > 0 {
+			req.Header.Set("X-Config-Signature", base64.StdEncoding.EncodeToString(signature))
+		}
+		setIdempotencyKey(ctx, req)
+
+		// Perform request with retry
+		resp, err := c.doRequestWithRetry(ctx, req, baseURL)
+		if errors.Is(err, errNotRetried) {
+			return nil, fmt.Errorf("failed to upload bundle: %w", err)
+		}
+		if err != nil {
+			lastErr = err
+			if baseURL == c.getMasterURL() {
+				c.clearMasterCache()
+			}
+			continue
+		}
+		answered = true
+		c.breaker.success()
+
+		// Check for authentication errors
+		if resp.StatusCode == http.StatusUnauthorized {
+			drainAndCloseBody(resp)
+			return nil, ErrUnauthorized
+		}
+
+		// Check for rate limiting
+		if resp.StatusCode == http.StatusTooManyRequests {
+			drainAndCloseBody(resp)
+			return nil, ErrRateLimited
+		}
+
+		// A server error may have stored the bundle; only keyed uploads fail over
+		if resp.StatusCode >= 500 && !isIdempotent(req) {
+			return nil, fmt.Errorf("failed to upload bundle: %w", c.parseErrorResponse(resp))
+		}
+
+		// Check for success
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+			err := c.parseErrorResponse(resp)
+			lastErr = err
+			continue
+		}
+
+		// Parse response to get new version and sizes
+		var result BundleUploadResult
+		if err := c.parseJSONResponse(resp, &result); err != nil {
+			lastErr = fmt.Errorf("failed to parse response: %w", err)
+			continue
+		}
+
+		return &result, nil
+	}
+
+	// All instances failed
+	if !answered {
+		c.breaker.failure(ctx)
+	}
+	if lastErr != nil {
+		return nil, fmt.Errorf("failed to upload bundle: %w", lastErr)
+	}
+
+	return nil, ErrAllInstancesFailed
+}
+
+// DiffBundle reports which files were added, removed or changed between two
+// config bundle versions, compared by content hash. Only file names, change
+// types and sizes are returned, never file contents.
+//
+// This operation requires cluster token authentication and can be executed on any
+// control plane instance (master or replica).
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - fromVersion: The version compared against
+//   - toVersion: The version whose changes are reported
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - *BundleDiff: Changed files sorted by name
+//   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
+//     or other errors for versions that are not stored or network issues
+func (c *Client) DiffBundle(ctx context.Context, fromVersion, toVersion int64, opts ...RequestOption) (*BundleDiff, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/config/diff?from=%d&to=%d",
+		c.TenantID, c.ClusterID, fromVersion, toVersion)
+
+	var diff BundleDiff
+	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &diff, AuthTypeCluster, false); err != nil {
+		return nil, fmt.Errorf("failed to diff bundles: %w", err)
+	}
+
+	return &diff, nil
+}
+
+// effectiveAtOption is the RequestOption returned by WithEffectiveAt.
+type effectiveAtOption time.Time
+
+func (t effectiveAtOption) applyRequest(o *requestOptions) {
+	o.effectiveAt = time.Time(t)
+}
+
+// WithEffectiveAt schedules an uploaded bundle to become active at t instead
+// of immediately. The version is reserved on upload, but nodes keep receiving
+// the previous version until t. A zero t activates the bundle immediately.
+//
+// Parameters:
+//   - t: When the bundle becomes active
+//
+// Returns:
+//   - RequestOption: Option to pass to UploadBundle or UploadSignedBundle
+func WithEffectiveAt(t time.Time) RequestOption {
+	return effectiveAtOption(t)
+}
+
+// canaryNodesOption is the RequestOption returned by WithCanaryNodes.
+type canaryNodesOption []string
+
+func (n canaryNodesOption) applyRequest(o *requestOptions) {
+	o.canaryNodeIDs = append(o.canaryNodeIDs, n...)
+}
+
+// WithCanaryNodes uploads a bundle as a canary rollout to the given nodes,
+// defined in the same transaction as the upload, so every other node stays on
+// the previous version until PromoteCanary.
+//
+// Parameters:
+//   - nodeIDs: The canary nodes
+//
+// Returns:
+//   - RequestOption: Option to pass to UploadBundle or UploadSignedBundle
+func WithCanaryNodes(nodeIDs ...string) RequestOption {
+	return canaryNodesOption(nodeIDs)
+}
+
+// SetCanary makes a bundle version available only to the given nodes. Their
+// daemons download it while every other node stays on the previous version,
+// until PromoteCanary makes it cluster-wide. Defining a canary replaces any
+// existing one for the cluster.
+//
+// The version must be the latest uploaded bundle. Nodes that poll between
+// UploadBundle and SetCanary may already receive it; to hold a new bundle
+// back from them, upload it with WithCanaryNodes instead.
+//
+// This operation requires admin node token authentication and is executed on
+// the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - version: The bundle version to roll out
+//   - nodeIDs: The canary nodes (at least one)
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - *CanaryRollout: The active canary
+//   - error: ErrBadRequest if the version is not the latest bundle or a node is not
+//     in the cluster, ErrUnauthorized if node token is invalid or not admin,
+//     or other errors for network issues
+func (c *Client) SetCanary(ctx context.Context, version int64, nodeIDs []string, opts ...RequestOption) (*CanaryRollout, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/config/canary", c.TenantID, c.ClusterID)
+
+	reqBody := map[string]interface{}{
+		"version":  version,
+		"node_ids": nodeIDs,
+	}
+
+	var canary CanaryRollout
+	if err := c.doJSONRequest(ctx, http.MethodPut, path, reqBody, &canary, AuthTypeNode, true); err != nil {
+		return nil, fmt.Errorf("failed to set canary: %w", err)
+	}
+
+	return &canary, nil
+}
+
+// PromoteCanary ends the cluster's canary rollout, making the canary version
+// available to every node.
+//
+// This operation requires admin node token authentication and is executed on
+// the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - int64: The promoted version
+//   - error: ErrNotFound if no canary is active, ErrUnauthorized if node token is
+//     invalid or not admin, or other errors for network issues
+func (c *Client) PromoteCanary(ctx context.Context, opts ...RequestOption) (int64, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/config/canary/promote", c.TenantID, c.ClusterID)
+
+	var versionResp VersionResponse
+	if err := c.doJSONRequest(ctx, http.MethodPost, path, nil, &versionResp, AuthTypeNode, true); err != nil {
+		return 0, fmt.Errorf("failed to promote canary: %w", err)
+	}
+
+	return versionResp.Version, nil
+}
+
+// SendHeartbeat reports this node's applied config version and health.
+//
+// The control plane uses heartbeats to evaluate canary rollouts: a canary is
+// rolled back automatically when its nodes report an error for the canary
+// version or stop reporting. Nodes should send heartbeats periodically and
+// whenever their version or health changes.
+//
+// This operation requires node token authentication and is executed on the
+// master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - version: The config version the node is running
+//   - status: HealthStatusOK or HealthStatusError
+//   - message: Optional detail, such as the error that occurred (max 1024 bytes)
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - error: ErrUnauthorized if node token is invalid, or other errors for network issues
+func (c *Client) SendHeartbeat(ctx context.Context, version int64, status, message string, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	reqBody := map[string]interface{}{
+		"version": version,
+		"status":  status,
+		"message": message,
+	}
+
+	if err := c.doJSONRequest(ctx, http.MethodPost, "/api/v1/nodes/heartbeat", reqBody, nil, AuthTypeNode, true); err != nil {
+		return fmt.Errorf("failed to send heartbeat: %w", err)
+	}
+
+	return nil
+}
+
+// WhoAmI returns the identity of the node that owns the client's node token.
+// It is a cheap way to confirm the node credentials are valid.
+//
+// This operation can be executed on any control plane instance (master or replica).
+//
+// This operation requires node token authentication.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - *NodeIdentity: The authenticated node
+//   - error: ErrUnauthorized if node token is invalid, ErrNotFound if the node
+//     was deleted, or other errors for network issues
+func (c *Client) WhoAmI(ctx context.Context, opts ...RequestOption) (*NodeIdentity, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	var identity NodeIdentity
+	if err := c.doJSONRequest(ctx, http.MethodGet, "/api/v1/nodes/me", nil, &identity, AuthTypeNode, false); err != nil {
+		return nil, fmt.Errorf("failed to identify node: %w", err)
+	}
+
+	return &identity, nil
+}
+
+// parseVersion parses a version string into an int64.
+func parseVersion(versionStr string) (int64, error) {
+	version, err := parseInt64(versionStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid version format: %w", err)
+	}
+	return version, nil
+}
+
+// versionETag returns the entity tag the control plane uses for a bundle
+// version of the client's cluster.
+func (c *Client) versionETag(version int64) string {
+	return fmt.Sprintf("\"%s-v%d\"", c.ClusterID, version)
+}
+
+// parseSignature decodes the X-Config-Signature header; an empty header means
+// the bundle is unsigned.
+func parseSignature(header string) ([]byte, error) {
+	if header == "" {
+		return nil, nil
+	}
+	signature, err := base64.StdEncoding.DecodeString(header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature header: %w", err)
+	}
+	return signature, nil
+}
+
+// parseInt64 parses a string into an int64.
+func parseInt64(s string) (int64, error) {
+	var result int64
+	_, err := fmt.Sscanf(s, "%d", &result)
+	if err != nil {
+		return 0, err
+	}
+	return result, nil
+}
+
+// ============================================================================
+// Topology Management Methods
+// ============================================================================
+
+// RegisterRoutes registers or updates the routes advertised by this node.
+// Routes should be specified in CIDR notation (e.g., "10.100.0.0/24").
+//
+// This operation requires node token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - routes: List of CIDR routes this node will advertise to the cluster
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - error: ErrUnauthorized if node token is invalid, ErrRateLimited if rate limited,
+//     or other errors for validation failures or network issues
+func (c *Client) RegisterRoutes(ctx context.Context, routes []string, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes/%s/routes", c.TenantID, c.ClusterID, c.NodeID)
+
+	reqBody := map[string]interface{}{
+		"routes": routes,
+	}
+
+	if err := c.doJSONRequest(ctx, http.MethodPut, path, reqBody, nil, AuthTypeNode, true); err != nil {
+		return fmt.Errorf("failed to register routes: %w", err)
+	}
+
+	return nil
+}
+
+// GetRoutes retrieves the routes currently advertised by this node.
+//
+// This operation requires node token authentication and can be executed on any
+// control plane instance (master or replica).
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - []string: List of CIDR routes advertised by this node
+//   - error: ErrUnauthorized if node token is invalid, ErrRateLimited if rate limited,
+//     or other errors for network issues
+func (c *Client) GetRoutes(ctx context.Context, opts ...RequestOption) ([]string, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes/%s/routes", c.TenantID, c.ClusterID, c.NodeID)
+
+	var response struct {
+		Routes []string `json:"routes"`
+	}
+
+	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &response, AuthTypeNode, false); err != nil {
+		return nil, fmt.Errorf("failed to get routes: %w", err)
+	}
+
+	return response.Routes, nil
+}
+
+// ListClusterRoutes retrieves all routes advertised by all nodes in the cluster.
+// This provides a complete view of the cluster's routing table.
+//
+// This operation requires cluster token authentication and can be executed on any
+// control plane instance (master or replica).
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - []NodeRoutes: List of all nodes and their advertised routes
+//   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
+//     or other errors for network issues
+func (c *Client) ListClusterRoutes(ctx context.Context, opts ...RequestOption) ([]NodeRoutes, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/routes", c.TenantID, c.ClusterID)
+
+	var routes []NodeRoutes
+	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &routes, AuthTypeCluster, false); err != nil {
+		return nil, fmt.Errorf("failed to list cluster routes: %w", err)
+	}
+
+	return routes, nil
+}
+
+// SetLighthouse configures a node as a lighthouse or removes lighthouse status.
+// Lighthouses must have a publicly accessible IP address and port.
+//
+// This operation requires cluster token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - nodeID: The unique identifier of the node to configure
+//   - enabled: True to enable lighthouse, false to disable
+//   - publicIP: The publicly accessible IP address (required if enabled is true)
+//   - port: The UDP port number (required if enabled is true, typically 4242)
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - error: ErrUnauthorized if cluster token is invalid, ErrNotFound if node doesn't exist,
+//     ErrRateLimited if rate limited, or other errors for validation failures or network issues
+func (c *Client) SetLighthouse(ctx context.Context, nodeID string, enabled bool, publicIP string, port int, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes/%s/lighthouse", c.TenantID, c.ClusterID, nodeID)
+
+	reqBody := map[string]interface{}{
+		"enabled": enabled,
+	}
+
+	if enabled {
+		reqBody["public_ip"] = publicIP
+		reqBody["port"] = port
+	}
+
+	if err := c.doJSONRequest(ctx, http.MethodPut, path, reqBody, nil, AuthTypeCluster, true); err != nil {
+		return fmt.Errorf("failed to set lighthouse: %w", err)
+	}
+
+	return nil
+}
+
+// SetRelay configures a node as a relay or removes relay status.
+// Relays help nodes behind restrictive NATs communicate with each other.
+//
+// This operation requires cluster token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - nodeID: The unique identifier of the node to configure
+//   - enabled: True to enable relay, false to disable
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - error: ErrUnauthorized if cluster token is invalid, ErrNotFound if node doesn't exist,
+//     ErrRateLimited if rate limited, or other errors for network issues
+func (c *Client) SetRelay(ctx context.Context, nodeID string, enabled bool, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes/%s/relay", c.TenantID, c.ClusterID, nodeID)
+
+	reqBody := map[string]interface{}{
+		"enabled": enabled,
+	}
+
+	if err := c.doJSONRequest(ctx, http.MethodPut, path, reqBody, nil, AuthTypeCluster, true); err != nil {
+		return fmt.Errorf("failed to set relay: %w", err)
+	}
+
+	return nil
+}
+
+// SetMaxRelayHops sets the relay hop limit for the cluster. A limit of 0 disables
+// relaying; relay nodes only use other relays when the limit is above 1.
+//
+// This operation requires cluster token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - hops: Maximum relay hops (0-4)
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
+//     or other errors for invalid limits or network issues
+func (c *Client) SetMaxRelayHops(ctx context.Context, hops int, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/relay/max-hops", c.TenantID, c.ClusterID)
+
+	reqBody := map[string]interface{}{
+		"max_relay_hops": hops,
+	}
+
+	if err := c.doJSONRequest(ctx, http.MethodPut, path, reqBody, nil, AuthTypeCluster, true); err != nil {
+		return fmt.Errorf("failed to set max relay hops: %w", err)
+	}
+
+	return nil
+}
+
+// GetLighthouseDNS retrieves the cluster's lighthouse DNS settings.
+//
+// This operation requires cluster token authentication and can be executed on any
+// control plane instance (master or replica).
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - *LighthouseDNSConfig: Current lighthouse DNS settings
+//   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
+//     or other errors for network issues
+func (c *Client) GetLighthouseDNS(ctx context.Context, opts ...RequestOption) (*LighthouseDNSConfig, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/topology/dns", c.TenantID, c.ClusterID)
+
+	var dns LighthouseDNSConfig
+	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &dns, AuthTypeCluster, false); err != nil {
+		return nil, fmt.Errorf("failed to get lighthouse DNS: %w", err)
+	}
+
+	return &dns, nil
+}
+
+// SetLighthouseDNS updates the cluster's lighthouse DNS settings. Lighthouses
+// serve DNS for node names when enabled; the server rejects a port that collides
+// with the Nebula UDP port.
+//
+// This operation requires cluster token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - dns: Lighthouse DNS settings (empty host and zero port use server defaults)
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
+//     or other errors for invalid settings or network issues
+func (c *Client) SetLighthouseDNS(ctx context.Context, dns LighthouseDNSConfig, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/topology/dns", c.TenantID, c.ClusterID)
+
+	if err := c.doJSONRequest(ctx, http.MethodPut, path, dns, nil, AuthTypeCluster, true); err != nil {
+		return fmt.Errorf("failed to set lighthouse DNS: %w", err)
+	}
+
+	return nil
+}
+
+// SetNebulaSubnet sets the cluster's overlay network CIDR. Static host map
+// entries must fall inside this subnet.
+//
+// This operation requires cluster token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - subnet: Overlay network CIDR (e.g., "10.42.0.0/16")
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
+//     or other errors for invalid subnets or network issues
+func (c *Client) SetNebulaSubnet(ctx context.Context, subnet string, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/topology/subnet", c.TenantID, c.ClusterID)
+
+	reqBody := map[string]interface{}{
+		"subnet": subnet,
+	}
+
+	if err := c.doJSONRequest(ctx, http.MethodPut, path, reqBody, nil, AuthTypeCluster, true); err != nil {
+		return fmt.Errorf("failed to set subnet: %w", err)
+	}
+
+	return nil
+}
+
+// SetStaticHostMap replaces the cluster's static host map. Each key is a Nebula
+// IP inside the cluster subnet and each value a list of "ip:port" endpoints.
+// The entries are emitted into every node's config; an empty map clears them.
+//
+// This operation requires cluster token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - hosts: Map of Nebula IP to public endpoints
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
+//     or other errors for invalid entries or network issues
+func (c *Client) SetStaticHostMap(ctx context.Context, hosts map[string][]string, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/topology/static-hosts", c.TenantID, c.ClusterID)
+
+	if hosts == nil {
+		hosts = map[string][]string{}
+	}
+	reqBody := map[string]interface{}{
+		"static_host_map": hosts,
+	}
+
+	if err := c.doJSONRequest(ctx, http.MethodPut, path, reqBody, nil, AuthTypeCluster, true); err != nil {
+		return fmt.Errorf("failed to set static host map: %w", err)
+	}
+
+	return nil
+}
+
+// SetPreferredRanges replaces the cluster's Nebula preferred_ranges. Nodes try
+// local addresses in these networks first, which speeds up connection setup in
+// multi-datacenter clusters. A nil or empty list clears the setting.
+//
+// This operation requires cluster token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - ranges: Network CIDRs (e.g., "192.168.0.0/16")
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
+//     or other errors for invalid CIDRs or network issues
+func (c *Client) SetPreferredRanges(ctx context.Context, ranges []string, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/topology/preferred-ranges", c.TenantID, c.ClusterID)
+
+	if ranges == nil {
+		ranges = []string{}
+	}
+	reqBody := map[string]interface{}{
+		"preferred_ranges": ranges,
+	}
+
+	if err := c.doJSONRequest(ctx, http.MethodPut, path, reqBody, nil, AuthTypeCluster, true); err != nil {
+		return fmt.Errorf("failed to set preferred ranges: %w", err)
+	}
+
+	return nil
+}
+
+// SetCipher changes the cluster's Nebula cipher. Nodes on different ciphers
+// cannot establish tunnels until every node has picked up the new config, so
+// the server refuses the change unless force is true.
+//
+// This operation requires cluster token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - cipher: CipherAES or CipherChachaPoly
+//   - force: Confirms the disruptive change
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - error: ErrInvalidCipher for unknown ciphers, ErrUnauthorized if cluster token is invalid,
+//     ErrRateLimited if rate limited, or other errors for unconfirmed changes or network issues
+func (c *Client) SetCipher(ctx context.Context, cipher string, force bool, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	if cipher != CipherAES && cipher != CipherChachaPoly {
+		return fmt.Errorf("%w: %q", ErrInvalidCipher, cipher)
+	}
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/topology/cipher", c.TenantID, c.ClusterID)
+
+	reqBody := map[string]interface{}{
+		"cipher": cipher,
+		"force":  force,
+	}
+
+	if err := c.doJSONRequest(ctx, http.MethodPut, path, reqBody, nil, AuthTypeCluster, true); err != nil {
+		return fmt.Errorf("failed to set cipher: %w", err)
+	}
+
+	return nil
+}
+
+// SetHandshakeConfig replaces the cluster's Nebula handshake tuning. Zero
+// values reset a setting to the Nebula default.
+//
+// This operation requires cluster token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - hs: Handshake tuning
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
+//     or other errors for out-of-range values or network issues
+func (c *Client) SetHandshakeConfig(ctx context.Context, hs HandshakeConfig, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/topology/handshake", c.TenantID, c.ClusterID)
 
-	// All instances failed
-	if lastErr != nil {
-		return nil, 0, fmt.Errorf("failed to download bundle: %w", lastErr)
+	if err := c.doJSONRequest(ctx, http.MethodPut, path, hs, nil, AuthTypeCluster, true); err != nil {
+		return fmt.Errorf("failed to set handshake config: %w", err)
 	}
 
-	return nil, 0, ErrAllInstancesFailed
+	return nil
 }
 
-// UploadBundle uploads a new config bundle to the control plane.
-// This operation is typically restricted to admin nodes.
-//
-// This operation requires node token authentication and is executed on the master instance.
+// SetRequestSigning requires or stops requiring signed node requests in the
+// cluster. Enable it only after every node's client has SignRequests set;
+// unsigned node requests are rejected from then on.
 //
-// The bundle must be a valid tar.gz archive containing the required Nebula configuration files.
-// The server will validate the bundle and increment the version number automatically.
+// This operation requires cluster token authentication and is executed on the master instance.
 //
 // Parameters:
 //   - ctx: Request context for cancellation and timeouts
-//   - data: The bundle data as a tar.gz archive
+//   - required: Whether node requests must be signed
+//   - opts: Optional per-request settings, such as WithTimeout
 //
 // Returns:
-//   - int64: The new version number assigned to this bundle
-//   - error: ErrUnauthorized if node token is invalid or node lacks admin privileges,
-//     ErrRateLimited if rate limited, or other errors for validation failures or network issues
-func (c *Client) UploadBundle(ctx context.Context, data []byte) (int64, error) {
-	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/config/bundle", c.TenantID, c.ClusterID)
-
-	// Build URL list preferring master
-	urls := c.buildURLList(true)
-	if len(urls) == 0 {
-		return 0, ErrNoBaseURLs
-	}
-
-	var lastErr error
-
-	for _, baseURL := range urls {
-		fullURL := fmt.Sprintf("%s%s", baseURL, path)
-
-		// Create request with binary body
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, fullURL, bytes.NewReader(data))
-		if err != nil {
-			lastErr = fmt.Errorf("failed to create request: %w", err)
-			continue
-		}
-
-		// Add authentication headers
-		if err := c.addAuthHeaders(req, AuthTypeNode); err != nil {
-			return 0, err
-		}
-
-		// Set headers for binary upload
-		req.Header.Set("Content-Type", "application/octet-stream")
-		req.Header.Set("Accept", "application/json")
-
-		// Perform request with retry
-		resp, err := c.doRequestWithRetry(ctx, req)
-		if err != nil {
-			lastErr = err
-			if baseURL == c.getMasterURL() {
-				c.clearMasterCache()
-			}
-			continue
-		}
-
-		// Check for authentication errors
-		if resp.StatusCode == http.StatusUnauthorized {
-			drainAndCloseBody(resp)
-			return 0, ErrUnauthorized
-		}
-
-		// Check for rate limiting
-		if resp.StatusCode == http.StatusTooManyRequests {
-			drainAndCloseBody(resp)
-			return 0, ErrRateLimited
-		}
-
-		// Check for success
-		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-			err := c.parseErrorResponse(resp)
-			lastErr = err
-			continue
-		}
+//   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
+//     or other errors for network issues
+func (c *Client) SetRequestSigning(ctx context.Context, required bool, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
 
-		// Parse response to get new version
-		var versionResp VersionResponse
-		if err := c.parseJSONResponse(resp, &versionResp); err != nil {
-			lastErr = fmt.Errorf("failed to parse response: %w", err)
-			continue
-		}
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/topology/request-signing", c.TenantID, c.ClusterID)
 
-		return versionResp.Version, nil
+	reqBody := map[string]interface{}{
+		"required": required,
 	}
 
-	// All instances failed
-	if lastErr != nil {
-		return 0, fmt.Errorf("failed to upload bundle: %w", lastErr)
+	if err := c.doJSONRequest(ctx, http.MethodPut, path, reqBody, nil, AuthTypeCluster, true); err != nil {
+		return fmt.Errorf("failed to set request signing: %w", err)
 	}
 
-	return 0, ErrAllInstancesFailed
+	return nil
 }
 
-// parseVersion parses a version string into an int64.
-func parseVersion(versionStr string) (int64, error) {
-	version, err := parseInt64(versionStr)
-	if err != nil {
-		return 0, fmt.Errorf("invalid version format: %w", err)
-	}
-	return version, nil
-}
+// SetRoutePolicy replaces the rules node routes must follow in the cluster.
+// Making the cluster IPv4-only fails while any node still advertises an IPv6
+// route; afterwards, route updates containing IPv6 CIDRs are rejected. Unless
+// AllowOverlappingRoutes is set, route updates that overlap another node's
+// routes are rejected.
+//
+// This operation requires cluster token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - policy: Route policy
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
+//     or other errors for existing routes that violate the policy or network issues
+func (c *Client) SetRoutePolicy(ctx context.Context, policy RoutePolicy, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
 
-// parseInt64 parses a string into an int64.
-func parseInt64(s string) (int64, error) {
-	var result int64
-	_, err := fmt.Sscanf(s, "%d", &result)
-	if err != nil {
-		return 0, err
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/topology/route-policy", c.TenantID, c.ClusterID)
+
+	if err := c.doJSONRequest(ctx, http.MethodPut, path, policy, nil, AuthTypeCluster, true); err != nil {
+		return fmt.Errorf("failed to set route policy: %w", err)
 	}
-	return result, nil
-}
 
-// ============================================================================
-// Topology Management Methods
-// ============================================================================
+	return nil
+}
 
-// RegisterRoutes registers or updates the routes advertised by this node.
-// Routes should be specified in CIDR notation (e.g., "10.100.0.0/24").
+// SetBundleRetention sets how many config bundle versions the server keeps
+// for the cluster. A scheduled prune deletes older versions, except any still
+// in use; keep 0 turns pruning off and keeps every version.
 //
-// This operation requires node token authentication and is executed on the master instance.
+// This operation requires cluster token authentication and is executed on the master instance.
 //
 // Parameters:
 //   - ctx: Request context for cancellation and timeouts
-//   - routes: List of CIDR routes this node will advertise to the cluster
+//   - keep: Number of latest versions to keep (0 = all)
+//   - opts: Optional per-request settings, such as WithTimeout
 //
 // Returns:
-//   - error: ErrUnauthorized if node token is invalid, ErrRateLimited if rate limited,
-//     or other errors for validation failures or network issues
-func (c *Client) RegisterRoutes(ctx context.Context, routes []string) error {
-	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes/%s/routes", c.TenantID, c.ClusterID, c.NodeID)
+//   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
+//     or other errors for a negative keep or network issues
+func (c *Client) SetBundleRetention(ctx context.Context, keep int, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
 
+	path := fmt.Sprintf("/api/v1/clusters/%s/bundles/retention", c.ClusterID)
 	reqBody := map[string]interface{}{
-		"routes": routes,
+		"keep": keep,
 	}
 
-	if err := c.doJSONRequest(ctx, http.MethodPut, path, reqBody, nil, AuthTypeNode, true); err != nil {
-		return fmt.Errorf("failed to register routes: %w", err)
+	if err := c.doJSONRequest(ctx, http.MethodPut, path, reqBody, nil, AuthTypeCluster, true); err != nil {
+		return fmt.Errorf("failed to set bundle retention: %w", err)
 	}
 
 	return nil
 }
 
-// GetRoutes retrieves the routes currently advertised by this node.
+// ValidateConfig asks the server to generate a config for a representative
+// node against the cluster's current topology and report any problems, such as
+// a missing lighthouse, overlapping routes, or a missing CA.
 //
-// This operation requires node token authentication and can be executed on any
+// This operation requires admin node token authentication and can be executed on any
 // control plane instance (master or replica).
 //
 // Parameters:
 //   - ctx: Request context for cancellation and timeouts
+//   - opts: Optional per-request settings, such as WithTimeout
 //
 // Returns:
-//   - []string: List of CIDR routes advertised by this node
-//   - error: ErrUnauthorized if node token is invalid, ErrRateLimited if rate limited,
-//     or other errors for network issues
-func (c *Client) GetRoutes(ctx context.Context) ([]string, error) {
-	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes/%s/routes", c.TenantID, c.ClusterID, c.NodeID)
-
-	var response struct {
-		Routes []string `json:"routes"`
+//   - *ConfigValidationReport: Structured list of errors and warnings
+//   - error: ErrUnauthorized if node token is invalid or not admin, ErrRateLimited if
+//     rate limited, or other errors for network issues
+func (c *Client) ValidateConfig(ctx context.Context, opts ...RequestOption) (*ConfigValidationReport, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/config/validate", c.TenantID, c.ClusterID)
+
+	var report ConfigValidationReport
+	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &report, AuthTypeNode, false); err != nil {
+		return nil, fmt.Errorf("failed to validate config: %w", err)
 	}
 
-	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &response, AuthTypeNode, false); err != nil {
-		return nil, fmt.Errorf("failed to get routes: %w", err)
-	}
-
-	return response.Routes, nil
+	return &report, nil
 }
 
-// ListClusterRoutes retrieves all routes advertised by all nodes in the cluster.
-// This provides a complete view of the cluster's routing table.
+// GetConnectivityMatrix asks the server which nodes should be able to reach which
+// for one kind of traffic. Peers must be discoverable through a lighthouse and the
+// flow must pass the sender's outbound and the receiver's inbound firewall rules,
+// which deny anything they do not match. Use it to verify intended reachability
+// and catch isolated nodes before deploying.
 //
-// This operation requires cluster token authentication and can be executed on any
+// This operation requires admin node token authentication and can be executed on any
 // control plane instance (master or replica).
 //
 // Parameters:
 //   - ctx: Request context for cancellation and timeouts
+//   - proto: Protocol to evaluate ("tcp", "udp" or "icmp")
+//   - port: Destination port (ignored for icmp)
+//   - opts: Optional per-request settings, such as WithTimeout
 //
 // Returns:
-//   - []NodeRoutes: List of all nodes and their advertised routes
-//   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
-//     or other errors for network issues
-func (c *Client) ListClusterRoutes(ctx context.Context) ([]NodeRoutes, error) {
-	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/routes", c.TenantID, c.ClusterID)
-
-	var routes []NodeRoutes
-	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &routes, AuthTypeCluster, false); err != nil {
-		return nil, fmt.Errorf("failed to list cluster routes: %w", err)
+//   - *ConnectivityMatrix: Reachability between every pair of nodes
+//   - error: ErrUnauthorized if node token is invalid or not admin, ErrRateLimited if
+//     rate limited, or other errors for validation failures or network issues
+func (c *Client) GetConnectivityMatrix(ctx context.Context, proto string, port int, opts ...RequestOption) (*ConnectivityMatrix, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/config/connectivity?proto=%s&port=%d",
+		c.TenantID, c.ClusterID, url.QueryEscape(proto), port)
+
+	var matrix ConnectivityMatrix
+	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &matrix, AuthTypeNode, false); err != nil {
+		return nil, fmt.Errorf("failed to get connectivity matrix: %w", err)
 	}
 
-	return routes, nil
+	return &matrix, nil
 }
 
-// SetLighthouse configures a node as a lighthouse or removes lighthouse status.
-// Lighthouses must have a publicly accessible IP address and port.
+// WhatIfTopology simulates topology changes, such as demoting a relay or promoting
+// a lighthouse, and reports how connectivity would change without persisting
+// anything. The changes are applied in order to a copy of the cluster's topology.
 //
-// This operation requires cluster token authentication and is executed on the master instance.
+// This operation requires admin node token authentication and can be executed on any
+// control plane instance (master or replica).
 //
 // Parameters:
 //   - ctx: Request context for cancellation and timeouts
-//   - nodeID: The unique identifier of the node to configure
-//   - enabled: True to enable lighthouse, false to disable
-//   - publicIP: The publicly accessible IP address (required if enabled is true)
-//   - port: The UDP port number (required if enabled is true, typically 4242)
+//   - proto: Protocol to evaluate ("tcp", "udp" or "icmp")
+//   - port: Destination port (ignored for icmp)
+//   - changes: Proposed changes (see the TopologyChange* actions)
+//   - opts: Optional per-request settings, such as WithTimeout
 //
 // Returns:
-//   - error: ErrUnauthorized if cluster token is invalid, ErrNotFound if node doesn't exist,
-//     ErrRateLimited if rate limited, or other errors for validation failures or network issues
-func (c *Client) SetLighthouse(ctx context.Context, nodeID string, enabled bool, publicIP string, port int) error {
-	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes/%s/lighthouse", c.TenantID, c.ClusterID, nodeID)
+//   - *TopologyWhatIf: Connectivity before and after the changes, and their difference
+//   - error: ErrUnauthorized if node token is invalid or not admin, ErrRateLimited if
+//     rate limited, or other errors for validation failures or network issues
+func (c *Client) WhatIfTopology(ctx context.Context, proto string, port int, changes []TopologyChange, opts ...RequestOption) (*TopologyWhatIf, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/config/connectivity/what-if", c.TenantID, c.ClusterID)
 
 	reqBody := map[string]interface{}{
-		"enabled": enabled,
+		"proto":   proto,
+		"port":    port,
+		"changes": changes,
 	}
 
-	if enabled {
-		reqBody["public_ip"] = publicIP
-		reqBody["port"] = port
+	var result TopologyWhatIf
+	if err := c.doJSONRequest(ctx, http.MethodPost, path, reqBody, &result, AuthTypeNode, false); err != nil {
+		return nil, fmt.Errorf("failed to simulate topology changes: %w", err)
 	}
 
-	if err := c.doJSONRequest(ctx, http.MethodPut, path, reqBody, nil, AuthTypeCluster, true); err != nil {
-		return fmt.Errorf("failed to set lighthouse: %w", err)
+	return &result, nil
+}
+
+// GetEffectiveConfig retrieves a node's fully-resolved Nebula configuration as
+// computed by the server's config generator. Use it to diagnose what a node
+// should be running; peers are referenced by node ID where Nebula would use an
+// overlay IP.
+//
+// This operation requires admin node token authentication and can be executed on any
+// control plane instance (master or replica).
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - nodeID: The unique identifier of the node
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - *EffectiveConfig: The node's resolved configuration
+//   - error: ErrUnauthorized if node token is invalid or not admin, ErrNotFound if the
+//     node doesn't exist, ErrRateLimited if rate limited, or other errors for network issues
+func (c *Client) GetEffectiveConfig(ctx context.Context, nodeID string, opts ...RequestOption) (*EffectiveConfig, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes/%s/effective-config", c.TenantID, c.ClusterID, nodeID)
+
+	var effective EffectiveConfig
+	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &effective, AuthTypeNode, false); err != nil {
+		return nil, fmt.Errorf("failed to get effective config: %w", err)
 	}
 
-	return nil
+	return &effective, nil
 }
 
-// SetRelay configures a node as a relay or removes relay status.
-// Relays help nodes behind restrictive NATs communicate with each other.
+// GetTopologyDOT retrieves the cluster topology as a Graphviz DOT graph showing
+// every node, the lighthouses and relays each node uses, and advertised routes.
+// The result renders directly with `dot -Tpng`.
 //
-// This operation requires cluster token authentication and is executed on the master instance.
+// This operation requires cluster token authentication and can be executed on any
+// control plane instance (master or replica).
 //
 // Parameters:
 //   - ctx: Request context for cancellation and timeouts
-//   - nodeID: The unique identifier of the node to configure
-//   - enabled: True to enable relay, false to disable
+//   - opts: Optional per-request settings, such as WithTimeout
 //
 // Returns:
-//   - error: ErrUnauthorized if cluster token is invalid, ErrNotFound if node doesn't exist,
-//     ErrRateLimited if rate limited, or other errors for network issues
-func (c *Client) SetRelay(ctx context.Context, nodeID string, enabled bool) error {
-	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/nodes/%s/relay", c.TenantID, c.ClusterID, nodeID)
+//   - string: DOT source
+//   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
+//     or other errors for network issues
+func (c *Client) GetTopologyDOT(ctx context.Context, opts ...RequestOption) (string, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
 
-	reqBody := map[string]interface{}{
-		"enabled": enabled,
+	path := fmt.Sprintf("/api/v1/clusters/%s/topology.dot", c.ClusterID)
+
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, AuthTypeCluster, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to get topology graph: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("failed to get topology graph: %w", c.parseErrorResponse(resp))
 	}
+	defer drainAndCloseBody(resp)
 
-	if err := c.doJSONRequest(ctx, http.MethodPut, path, reqBody, nil, AuthTypeCluster, true); err != nil {
-		return fmt.Errorf("failed to set relay: %w", err)
+	dot, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read topology graph: %w", err)
 	}
 
-	return nil
+	return string(dot), nil
 }
 
 // GetTopology retrieves the complete cluster topology including all lighthouses,
@@ -815,12 +2613,16 @@ func (c *Client) SetRelay(ctx context.Context, nodeID string, enabled bool) erro
 //
 // Parameters:
 //   - ctx: Request context for cancellation and timeouts
+//   - opts: Optional per-request settings, such as WithTimeout
 //
 // Returns:
 //   - *ClusterTopology: Complete cluster topology information
 //   - error: ErrUnauthorized if node token is invalid, ErrRateLimited if rate limited,
 //     or other errors for network issues
-func (c *Client) GetTopology(ctx context.Context) (*ClusterTopology, error) {
+func (c *Client) GetTopology(ctx context.Context, opts ...RequestOption) (*ClusterTopology, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
 	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/topology", c.TenantID, c.ClusterID)
 
 	var topology ClusterTopology
@@ -831,21 +2633,105 @@ func (c *Client) GetTopology(ctx context.Context) (*ClusterTopology, error) {
 	return &topology, nil
 }
 
+// ExportTopologySnapshot retrieves a portable snapshot of the cluster topology.
+// In addition to the regular topology, the snapshot keys routes by node name so
+// it can be imported into a different cluster with ImportTopologySnapshot.
+//
+// This operation requires cluster token authentication and can be executed on any
+// control plane instance (master or replica).
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - *ClusterTopology: Topology snapshot including RoutesByName
+//   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
+//     or other errors for network issues
+func (c *Client) ExportTopologySnapshot(ctx context.Context, opts ...RequestOption) (*ClusterTopology, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/topology/snapshot", c.TenantID, c.ClusterID)
+
+	var snapshot ClusterTopology
+	if err := c.doJSONRequest(ctx, http.MethodGet, path, nil, &snapshot, AuthTypeCluster, false); err != nil {
+		return nil, fmt.Errorf("failed to export topology snapshot: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// ImportTopologySnapshot replaces the cluster topology with a snapshot, typically
+// one produced by ExportTopologySnapshot on another cluster. Nodes are matched by
+// name. If the snapshot references nodes that do not exist in this cluster, the
+// import is rejected and nothing is applied.
+//
+// This operation requires cluster token authentication and is executed on the master instance.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - snapshot: The topology snapshot to apply
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - error: ErrUnauthorized if cluster token is invalid, an API error naming the missing
+//     nodes if the snapshot does not match this cluster, or other errors for network issues
+func (c *Client) ImportTopologySnapshot(ctx context.Context, snapshot *ClusterTopology, opts ...RequestOption) error {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/topology/snapshot", c.TenantID, c.ClusterID)
+
+	if err := c.doJSONRequest(ctx, http.MethodPut, path, snapshot, nil, AuthTypeCluster, true); err != nil {
+		return fmt.Errorf("failed to import topology snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// revokePreviousOption is the RequestOption returned by WithRevokePrevious.
+type revokePreviousOption struct{}
+
+func (revokePreviousOption) applyRequest(o *requestOptions) {
+	o.revokePrevious = true
+}
+
+// WithRevokePrevious makes RotateClusterToken stop accepting the replaced
+// token immediately instead of at the end of the server's overlap window,
+// e.g. when the old token leaked.
+//
+// Returns:
+//   - RequestOption: Option to pass to RotateClusterToken
+func WithRevokePrevious() RequestOption {
+	return revokePreviousOption{}
+}
+
 // RotateClusterToken generates a new authentication token for the cluster.
-// The old token is immediately invalidated. The new token is only returned once
-// and must be distributed to all administrators.
+// The old token keeps working until the server's overlap window ends, unless
+// WithRevokePrevious is passed, but cannot be used to rotate again. The new
+// token is only returned once and must be distributed to all administrators.
 //
 // This operation requires cluster token authentication and is executed on the master instance.
 //
 // Parameters:
 //   - ctx: Request context for cancellation and timeouts
+//   - opts: Optional per-request settings, such as WithTimeout or
+//     WithRevokePrevious
 //
 // Returns:
 //   - string: The new cluster authentication token (store securely, only returned once)
 //   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
 //     or other errors for network issues
-func (c *Client) RotateClusterToken(ctx context.Context) (string, error) {
+func (c *Client) RotateClusterToken(ctx context.Context, opts ...RequestOption) (string, error) {
+	options := collectRequestOptions(opts)
+	ctx, cancel := options.context(ctx)
+	defer cancel()
+
 	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/rotate-token", c.TenantID, c.ClusterID)
+	if options.revokePrevious {
+		path += "?revoke_previous=true"
+	}
 
 	var response TokenRotationResponse
 	if err := c.doJSONRequest(ctx, http.MethodPost, path, nil, &response, AuthTypeCluster, true); err != nil {
@@ -863,11 +2749,15 @@ func (c *Client) RotateClusterToken(ctx context.Context) (string, error) {
 // Parameters:
 //   - ctx: Request context for cancellation and timeouts
 //   - baseURL: The control plane URL to check (e.g., "https://control1.example.com")
+//   - opts: Optional per-request settings, such as WithTimeout
 //
 // Returns:
 //   - bool: True if the queried instance is the master, false otherwise
 //   - error: Returns error if the instance is unreachable or returns an invalid response
-func (c *Client) CheckMaster(ctx context.Context, baseURL string) (bool, error) {
+func (c *Client) CheckMaster(ctx context.Context, baseURL string, opts ...RequestOption) (bool, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
 	// Build request URL
 	reqURL := fmt.Sprintf("%s/health/master", baseURL)
 
@@ -878,7 +2768,7 @@ func (c *Client) CheckMaster(ctx context.Context, baseURL string) (bool, error)
 	}
 
 	// Execute request (no authentication required for health check)
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do(req, baseURL, 1)
 	if err != nil {
 		return false, fmt.Errorf("request failed: %w", err)
 	}
@@ -906,12 +2796,17 @@ func (c *Client) CheckMaster(ctx context.Context, baseURL string) (bool, error)
 //
 // Parameters:
 //   - ctx: Request context for cancellation and timeouts
+//   - opts: Optional per-request settings, such as WithTimeout
 //
 // Returns:
-//   - []ReplicaInfo: List of replica instances with their status
+//   - []ReplicaInfo: List of replica instances with their heartbeat age and,
+//     if the control plane reports it, their health
 //   - error: ErrUnauthorized if cluster token is invalid, ErrRateLimited if rate limited,
 //     or other errors for network issues
-func (c *Client) GetClusterReplicas(ctx context.Context) ([]ReplicaInfo, error) {
+func (c *Client) GetClusterReplicas(ctx context.Context, opts ...RequestOption) ([]ReplicaInfo, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
 	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/replicas", c.TenantID, c.ClusterID)
 
 	var response struct {
@@ -921,5 +2816,44 @@ func (c *Client) GetClusterReplicas(ctx context.Context) ([]ReplicaInfo, error)
 		return nil, fmt.Errorf("failed to get cluster replicas: %w", err)
 	}
 
+	for i := range response.Replicas {
+		response.Replicas[i].URLs = response.Replicas[i].AllURLs()
+	}
+
 	return response.Replicas, nil
 }
+
+// ReplicaURLs flattens the URLs of the given replicas into a failover list
+// suitable for ClientConfig.BaseURLs. The master's URLs come first so it is
+// tried before the others; duplicates are dropped.
+//
+// Parameters:
+//   - replicas: Replicas as returned by GetClusterReplicas
+//
+// Returns:
+//   - []string: Every replica URL, master first
+func ReplicaURLs(replicas []ReplicaInfo) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	add := func(r ReplicaInfo) {
+		for _, u := range r.AllURLs() {
+			if !seen[u] {
+				seen[u] = true
+				urls = append(urls, u)
+			}
+		}
+	}
+
+	for _, r := range replicas {
+		if r.IsMaster {
+			add(r)
+		}
+	}
+	for _, r := range replicas {
+		if !r.IsMaster {
+			add(r)
+		}
+	}
+
+	return urls
+}
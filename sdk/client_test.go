@@ -1,10 +1,20 @@
 package sdk
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"math"
+	"math/rand"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -322,6 +332,74 @@ func TestClient_DoRequest_Authentication(t *testing.T) {
 	}
 }
 
+func TestClient_RequestHooks(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health/master" {
+			w.Write([]byte(`{"is_master":true}`))
+			return
+		}
+		// Fail the first attempt so the retry is observed too
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"version":3}`))
+	}))
+	defer server.Close()
+
+	const secret = "super-secret-node-token"
+	var requests, responses []string
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		NodeToken:     secret,
+		RetryAttempts: 1,
+		RetryWaitMin:  time.Millisecond,
+		RetryWaitMax:  time.Millisecond,
+		OnRequest: func(method, url string) {
+			requests = append(requests, method+" "+url)
+		},
+		OnResponse: func(method, url string, status int, dur time.Duration, err error) {
+			if dur < 0 {
+				t.Errorf("OnResponse duration = %v, want non-negative", dur)
+			}
+			responses = append(responses, fmt.Sprintf("%s %s %d %v", method, url, status, err))
+		},
+	})
+
+	if _, err := client.GetLatestVersion(context.Background()); err != nil {
+		t.Fatalf("GetLatestVersion() unexpected error = %v", err)
+	}
+	if _, err := client.CheckMaster(context.Background(), server.URL); err != nil {
+		t.Fatalf("CheckMaster() unexpected error = %v", err)
+	}
+
+	versionURL := server.URL + "/api/v1/tenants/tenant-123/clusters/cluster-456/config/version"
+	wantRequests := []string{
+		"GET " + versionURL,
+		"GET " + versionURL,
+		"GET " + server.URL + "/health/master",
+	}
+	wantResponses := []string{
+		"GET " + versionURL + " 500 <nil>",
+		"GET " + versionURL + " 200 <nil>",
+		"GET " + server.URL + "/health/master 200 <nil>",
+	}
+	if !reflect.DeepEqual(requests, wantRequests) {
+		t.Errorf("OnRequest calls = %q, want %q", requests, wantRequests)
+	}
+	if !reflect.DeepEqual(responses, wantResponses) {
+		t.Errorf("OnResponse calls = %q, want %q", responses, wantResponses)
+	}
+	for _, call := range append(requests, responses...) {
+		if strings.Contains(call, secret) {
+			t.Errorf("Hook saw token value: %q", call)
+		}
+	}
+}
+
 func TestClient_CalculateBackoff(t *testing.T) {
 	client, err := NewClient(ClientConfig{
 		BaseURLs:     []string{"https://cp1.example.com"},
@@ -378,6 +456,87 @@ func TestClient_CalculateBackoff(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("no jitter", func(t *testing.T) {
+		noJitter := 0.0
+		client, err := NewClient(ClientConfig{
+			BaseURLs:      []string{"https://cp1.example.com"},
+			TenantID:      "tenant-123",
+			ClusterID:     "cluster-456",
+			RetryWaitMin:  1 * time.Second,
+			RetryWaitMax:  10 * time.Second,
+			BackoffJitter: &noJitter,
+		})
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+
+		want := []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second}
+		for attempt, wantBackoff := range want {
+			if backoff := client.calculateBackoff(attempt); backoff != wantBackoff {
+				t.Errorf("calculateBackoff(%d) = %v, want %v", attempt, backoff, wantBackoff)
+			}
+		}
+	})
+
+	t.Run("seeded partial jitter", func(t *testing.T) {
+		halfJitter := 0.5
+		config := ClientConfig{
+			BaseURLs:      []string{"https://cp1.example.com"},
+			TenantID:      "tenant-123",
+			ClusterID:     "cluster-456",
+			RetryWaitMin:  1 * time.Second,
+			RetryWaitMax:  10 * time.Second,
+			BackoffJitter: &halfJitter,
+		}
+		client, err := NewClient(config, WithRandSource(rand.NewSource(42)))
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+
+		// The same seed draws the same jitter
+		expected := rand.New(rand.NewSource(42))
+		for attempt := 0; attempt < 3; attempt++ {
+			base := time.Second << attempt
+			want := time.Duration(float64(base) - expected.Float64()*0.5*float64(base))
+			backoff := client.calculateBackoff(attempt)
+			if backoff != want {
+				t.Errorf("calculateBackoff(%d) = %v, want %v", attempt, backoff, want)
+			}
+			if backoff < base/2 || backoff > base {
+				t.Errorf("calculateBackoff(%d) = %v, want between %v and %v", attempt, backoff, base/2, base)
+			}
+		}
+	})
+}
+
+func TestClientConfig_ValidateBackoffJitter(t *testing.T) {
+	for _, jitter := range []float64{-0.5, -1, 1.5, math.NaN()} {
+		config := ClientConfig{
+			BaseURLs:      []string{"https://cp1.example.com"},
+			TenantID:      "tenant-123",
+			ClusterID:     "cluster-456",
+			BackoffJitter: &jitter,
+		}
+		if err := config.Validate(); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("Validate() with BackoffJitter %v = %v, want ErrInvalidConfig", jitter, err)
+		}
+	}
+
+	// Unset keeps the full default jitter; 0 and 1 are both valid
+	config := ClientConfig{BaseURLs: []string{"https://cp1.example.com"}, TenantID: "tenant-123", ClusterID: "cluster-456"}
+	if got := config.backoffJitter(); got != DefaultBackoffJitter {
+		t.Errorf("backoffJitter() unset = %v, want %v", got, DefaultBackoffJitter)
+	}
+	for _, jitter := range []float64{0, 1} {
+		config.BackoffJitter = &jitter
+		if err := config.Validate(); err != nil {
+			t.Errorf("Validate() with BackoffJitter %v = %v, want nil", jitter, err)
+		}
+		if got := config.backoffJitter(); got != jitter {
+			t.Errorf("backoffJitter() = %v, want %v", got, jitter)
+		}
+	}
 }
 
 // ============================================================================
@@ -490,6 +649,136 @@ func TestClient_CreateNode(t *testing.T) {
 	}
 }
 
+func TestClient_CreateNodes(t *testing.T) {
+	var failIndex = -1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/tenants/tenant-123/clusters/cluster-456/nodes/batch" {
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		var body struct {
+			Nodes []NodeCreateRequest `json:"nodes"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if len(body.Nodes) != 2 || body.Nodes[1].Name != "edge-2" || body.Nodes[1].MTU != 1400 {
+			t.Errorf("Unexpected request body: %+v", body)
+		}
+		if failIndex >= 0 {
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprintf(w, `{"error":"conflict","message":"Resource already exists","index":%d}`, failIndex)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`[{"node_id":"node-1","node_token":"token-1"},{"node_id":"node-2","node_token":"token-2"}]`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		ClusterToken:  "valid-token",
+		RetryAttempts: 0,
+	})
+	reqs := []NodeCreateRequest{{Name: "edge-1"}, {Name: "edge-2", MTU: 1400}}
+
+	creds, err := client.CreateNodes(context.Background(), reqs)
+	if err != nil {
+		t.Fatalf("CreateNodes() unexpected error = %v", err)
+	}
+	if len(creds) != 2 || creds[0].NodeID != "node-1" || creds[1].NodeID != "node-2" {
+		t.Errorf("CreateNodes() = %v", creds)
+	}
+
+	failIndex = 1
+	_, err = client.CreateNodes(context.Background(), reqs)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 {
+		t.Fatalf("CreateNodes() error = %v, want BatchError at index 1", err)
+	}
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("CreateNodes() error = %v, want ErrConflict", err)
+	}
+}
+
+func TestClient_GetNode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Expected GET request, got %s", r.Method)
+		}
+		if r.Header.Get(HeaderClusterToken) == "" {
+			t.Error("Cluster token header missing")
+		}
+		switch r.URL.Path {
+		case "/api/v1/tenants/tenant-123/clusters/cluster-456/nodes/node-123":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id":"node-123","name":"edge","mtu":1300,"is_lighthouse":true,"is_relay":true,"routes":["10.1.0.0/16"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not_found","message":"Resource not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		ClusterToken:  "valid-token",
+		RetryAttempts: 0,
+	})
+
+	node, err := client.GetNode(context.Background(), "node-123")
+	if err != nil {
+		t.Fatalf("GetNode() unexpected error = %v", err)
+	}
+	if node.ID != "node-123" || !node.IsLighthouse || !node.IsRelay || len(node.Routes) != 1 {
+		t.Errorf("GetNode() = %+v", node)
+	}
+
+	if _, err := client.GetNode(context.Background(), "node-999"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetNode() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClient_RestoreNode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected POST request, got %s", r.Method)
+		}
+		switch r.URL.Path {
+		case "/api/v1/tenants/tenant-123/clusters/cluster-456/nodes/node-123/restore":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id":"node-123","name":"edge","mtu":1300}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not_found","message":"Node not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		ClusterToken:  "valid-token",
+		RetryAttempts: 0,
+	})
+
+	node, err := client.RestoreNode(context.Background(), "node-123")
+	if err != nil {
+		t.Fatalf("RestoreNode() unexpected error = %v", err)
+	}
+	if node.ID != "node-123" || node.Name != "edge" {
+		t.Errorf("RestoreNode() = %+v", node)
+	}
+
+	if _, err := client.RestoreNode(context.Background(), "node-999"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RestoreNode() error = %v, want ErrNotFound", err)
+	}
+}
+
 func TestClient_DeleteNode(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -661,6 +950,41 @@ func TestClient_ListNodes(t *testing.T) {
 	}
 }
 
+func TestClient_ListNodesFilters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("name_contains") != "edge 1" || query.Get("is_relay") != "true" || query.Get("is_admin") != "false" {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+		if query.Has("is_lighthouse") {
+			t.Error("Unset filter sent")
+		}
+		if query.Get("page") != "2" || query.Get("page_size") != "25" {
+			t.Errorf("Pagination lost: %s", r.URL.RawQuery)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[{"id":"node-1","name":"edge 1"}]`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		ClusterToken:  "valid-token",
+		RetryAttempts: 0,
+	})
+
+	nodes, err := client.ListNodes(context.Background(), 2, 25,
+		FilterNameContains("edge 1"), FilterRelay(true), FilterAdmin(false))
+	if err != nil {
+		t.Fatalf("ListNodes() unexpected error = %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].Name != "edge 1" {
+		t.Errorf("ListNodes() = %+v", nodes)
+	}
+}
+
 func TestClient_UpdateMTU(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -757,6 +1081,79 @@ func TestClient_UpdateMTU(t *testing.T) {
 	}
 }
 
+func TestClient_SetPreferredRelays(t *testing.T) {
+	tests := []struct {
+		name         string
+		relayNodeIDs []string
+		serverStatus int
+		serverBody   string
+		wantBody     string
+		wantErr      bool
+	}{
+		{
+			name:         "set relays",
+			relayNodeIDs: []string{"relay-1", "relay-2"},
+			serverStatus: http.StatusOK,
+			serverBody:   `{"data":{"node_id":"node-123"}}`,
+			wantBody:     `{"relay_node_ids":["relay-1","relay-2"]}`,
+			wantErr:      false,
+		},
+		{
+			name:         "clear relays",
+			relayNodeIDs: nil,
+			serverStatus: http.StatusOK,
+			serverBody:   `{"data":{"node_id":"node-123"}}`,
+			wantBody:     `{"relay_node_ids":[]}`,
+			wantErr:      false,
+		},
+		{
+			name:         "target is not a relay",
+			relayNodeIDs: []string{"node-456"},
+			serverStatus: http.StatusBadRequest,
+			serverBody:   `{"error":"invalid_request","message":"Preferred relay is not a relay node"}`,
+			wantBody:     `{"relay_node_ids":["node-456"]}`,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPut {
+					t.Errorf("Expected PUT request, got %s", r.Method)
+				}
+				if r.URL.Path != "/api/v1/tenants/tenant-123/clusters/cluster-456/nodes/node-123/relays" {
+					t.Errorf("Unexpected path %s", r.URL.Path)
+				}
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != tt.wantBody {
+					t.Errorf("Expected body %s, got %s", tt.wantBody, body)
+				}
+				w.WriteHeader(tt.serverStatus)
+				w.Write([]byte(tt.serverBody))
+			}))
+			defer server.Close()
+
+			client, _ := NewClient(ClientConfig{
+				BaseURLs:      []string{server.URL},
+				TenantID:      "tenant-123",
+				ClusterID:     "cluster-456",
+				ClusterToken:  "valid-cluster-token",
+				RetryAttempts: 0,
+			})
+
+			err := client.SetPreferredRelays(context.Background(), "node-123", tt.relayNodeIDs)
+
+			if tt.wantErr && err == nil {
+				t.Error("SetPreferredRelays() expected error but got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("SetPreferredRelays() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
 func TestClient_RotateNodeToken(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -927,85 +1324,54 @@ func TestClient_GetLatestVersion(t *testing.T) {
 	}
 }
 
-func TestClient_DownloadBundle(t *testing.T) {
-	bundleData := []byte("mock-bundle-data-tar-gz")
-
+func TestClient_HeadLatestVersion(t *testing.T) {
 	tests := []struct {
-		name           string
-		currentVersion int64
-		serverStatus   int
-		serverBody     []byte
-		versionHeader  string
-		wantData       []byte
-		wantVersion    int64
-		wantErr        bool
+		name          string
+		serverStatus  int
+		versionHeader string
+		wantVersion   int64
+		wantErr       bool
 	}{
 		{
-			name:           "successful download - new version",
-			currentVersion: 1,
-			serverStatus:   http.StatusOK,
-			serverBody:     bundleData,
-			versionHeader:  "2",
-			wantData:       bundleData,
-			wantVersion:    2,
-			wantErr:        false,
+			name:          "successful version retrieval",
+			serverStatus:  http.StatusOK,
+			versionHeader: "42",
+			wantVersion:   42,
 		},
 		{
-			name:           "304 not modified - no update",
-			currentVersion: 5,
-			serverStatus:   http.StatusNotModified,
-			serverBody:     nil,
-			versionHeader:  "",
-			wantData:       nil,
-			wantVersion:    5,
-			wantErr:        false,
+			name:         "missing version header",
+			serverStatus: http.StatusOK,
+			wantErr:      true,
 		},
 		{
-			name:           "unauthorized",
-			currentVersion: 1,
-			serverStatus:   http.StatusUnauthorized,
-			serverBody:     []byte(`{"error":"invalid node token"}`),
-			versionHeader:  "",
-			wantErr:        true,
+			name:         "unauthorized",
+			serverStatus: http.StatusUnauthorized,
+			wantErr:      true,
 		},
 		{
-			name:           "rate limited",
-			currentVersion: 1,
-			serverStatus:   http.StatusTooManyRequests,
-			serverBody:     []byte(`{"error":"rate limit exceeded"}`),
-			versionHeader:  "",
-			wantErr:        true,
+			name:         "not found",
+			serverStatus: http.StatusNotFound,
+			wantErr:      true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				// Verify method
-				if r.Method != http.MethodGet {
-					t.Errorf("Expected GET request, got %s", r.Method)
+				if r.Method != http.MethodHead {
+					t.Errorf("Expected HEAD request, got %s", r.Method)
+				}
+				if r.URL.Path != "/api/v1/tenants/tenant-123/clusters/cluster-456/config/bundle" {
+					t.Errorf("Unexpected path: %s", r.URL.Path)
 				}
-
-				// Verify node token header
 				if r.Header.Get(HeaderNodeToken) == "" {
 					t.Error("Node token header missing")
 				}
 
-				// Verify current_version query parameter
-				currentVersion := r.URL.Query().Get("current_version")
-				if currentVersion == "" {
-					t.Error("current_version query parameter missing")
-				}
-
-				// Set version header if provided
 				if tt.versionHeader != "" {
 					w.Header().Set("X-Config-Version", tt.versionHeader)
 				}
-
 				w.WriteHeader(tt.serverStatus)
-				if tt.serverBody != nil {
-					w.Write(tt.serverBody)
-				}
 			}))
 			defer server.Close()
 
@@ -1020,28 +1386,664 @@ func TestClient_DownloadBundle(t *testing.T) {
 				t.Fatalf("NewClient() error = %v", err)
 			}
 
-			ctx := context.Background()
-			data, version, err := client.DownloadBundle(ctx, tt.currentVersion)
-
+			version, err := client.HeadLatestVersion(context.Background())
 			if tt.wantErr {
 				if err == nil {
-					t.Errorf("DownloadBundle() expected error but got nil")
-				}
-			} else {
-				if err != nil {
-					t.Errorf("DownloadBundle() unexpected error = %v", err)
-				}
-				if version != tt.wantVersion {
-					t.Errorf("DownloadBundle() returned version %d, want %d", version, tt.wantVersion)
+					t.Errorf("HeadLatestVersion() expected error but got nil")
 				}
-				if tt.wantData == nil && data != nil {
-					t.Errorf("DownloadBundle() returned data, want nil")
-				}
-				if tt.wantData != nil {
-					if data == nil {
-						t.Error("DownloadBundle() returned nil data, want bundle data")
-					} else if string(data) != string(tt.wantData) {
-						t.Errorf("DownloadBundle() returned wrong data")
+				return
+			}
+			if err != nil {
+				t.Fatalf("HeadLatestVersion() unexpected error = %v", err)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("HeadLatestVersion() returned version %d, want %d", version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestClient_GetClusterStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Expected GET request, got %s", r.Method)
+		}
+		if r.URL.Path != "/api/v1/clusters/cluster-456/stats" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get(HeaderClusterToken) == "" {
+			t.Error("Cluster token header missing")
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"cluster_id":"cluster-456","node_count":5,"admin_count":1,"lighthouse_count":2,"relay_count":1,"route_count":3,"config_version":9,"last_change_at":"2025-01-01T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		ClusterToken:  "valid-token",
+		RetryAttempts: 0,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	stats, err := client.GetClusterStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetClusterStats() unexpected error = %v", err)
+	}
+	if stats.NodeCount != 5 || stats.LighthouseCount != 2 || stats.RouteCount != 3 || stats.ConfigVersion != 9 {
+		t.Errorf("GetClusterStats() = %+v, unexpected counts", stats)
+	}
+	if stats.LastChangeAt == nil || stats.LastChangeAt.Year() != 2025 {
+		t.Errorf("GetClusterStats() LastChangeAt = %v, want 2025-01-01", stats.LastChangeAt)
+	}
+}
+
+func TestClient_GetConfigHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Expected GET request, got %s", r.Method)
+		}
+		if r.URL.Path != "/api/v1/clusters/cluster-456/history" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get(HeaderClusterToken) == "" {
+			t.Error("Cluster token header missing")
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"cluster_id":"cluster-456","changes":[` +
+			`{"version":3,"type":"routes_updated","actor":"node-1","detail":"node-1","created_at":"2025-01-02T00:00:00Z"},` +
+			`{"version":2,"type":"node_created","detail":"node-1","created_at":"2025-01-01T00:00:00Z"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		ClusterToken:  "valid-token",
+		RetryAttempts: 0,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	changes, err := client.GetConfigHistory(context.Background())
+	if err != nil {
+		t.Fatalf("GetConfigHistory() unexpected error = %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("GetConfigHistory() returned %d changes, want 2", len(changes))
+	}
+	if changes[0].Version != 3 || changes[0].Type != "routes_updated" || changes[0].Actor != "node-1" {
+		t.Errorf("GetConfigHistory()[0] = %+v, unexpected entry", changes[0])
+	}
+	if changes[1].Actor != "" || changes[1].CreatedAt.Day() != 1 {
+		t.Errorf("GetConfigHistory()[1] = %+v, unexpected entry", changes[1])
+	}
+}
+
+func TestClient_TenantCRUD(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderOperatorToken) != "operator-token" {
+			t.Error("Operator token header missing")
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /api/v1/admin/tenants":
+			body, _ := io.ReadAll(r.Body)
+			if string(body) != `{"name":"Acme"}` {
+				t.Errorf("Unexpected request body: %s", body)
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"tenant-789","name":"Acme","created_at":"2025-01-21T10:30:00Z"}`))
+		case "GET /api/v1/admin/tenants":
+			if r.URL.Query().Get("page") != "1" || r.URL.Query().Get("page_size") != "20" {
+				t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"tenants":[{"id":"tenant-789","name":"Acme"}],"total":1,"page":1,"per_page":20}`))
+		case "GET /api/v1/admin/tenants/tenant-789":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id":"tenant-789","name":"Acme"}`))
+		case "DELETE /api/v1/admin/tenants/tenant-789":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not_found","message":"Resource not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		OperatorToken: "operator-token",
+		RetryAttempts: 0,
+	})
+	ctx := context.Background()
+
+	tenant, err := client.CreateTenant(ctx, "Acme")
+	if err != nil {
+		t.Fatalf("CreateTenant() unexpected error = %v", err)
+	}
+	if tenant.ID != "tenant-789" || tenant.Name != "Acme" || tenant.CreatedAt.IsZero() {
+		t.Errorf("CreateTenant() = %+v", tenant)
+	}
+
+	list, err := client.ListTenants(ctx, 1, 20)
+	if err != nil {
+		t.Fatalf("ListTenants() unexpected error = %v", err)
+	}
+	if list.Total != 1 || len(list.Tenants) != 1 || list.Tenants[0].Name != "Acme" {
+		t.Errorf("ListTenants() = %+v", list)
+	}
+
+	if got, err := client.GetTenant(ctx, "tenant-789"); err != nil || got.Name != "Acme" {
+		t.Errorf("GetTenant() = %+v, %v", got, err)
+	}
+	if _, err := client.GetTenant(ctx, "tenant-999"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetTenant() error = %v, want ErrNotFound", err)
+	}
+
+	if err := client.DeleteTenant(ctx, "tenant-789"); err != nil {
+		t.Errorf("DeleteTenant() unexpected error = %v", err)
+	}
+}
+
+func TestClient_ClusterCRUD(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderOperatorToken) != "operator-token" {
+			t.Error("Operator token header missing")
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /api/v1/tenants/tenant-123/clusters":
+			body, _ := io.ReadAll(r.Body)
+			if string(body) != `{"name":"prod"}` {
+				t.Errorf("Unexpected request body: %s", body)
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"cluster":{"id":"cluster-789","tenant_id":"tenant-123","name":"prod","config_version":0},"cluster_token":"secret-token"}`))
+		case "GET /api/v1/tenants/tenant-123/clusters":
+			if r.URL.Query().Get("page") != "2" || r.URL.Query().Get("page_size") != "10" {
+				t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"clusters":[{"id":"cluster-789","name":"prod"}],"total":11,"page":2,"per_page":10}`))
+		case "GET /api/v1/tenants/tenant-123/clusters/cluster-789":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id":"cluster-789","tenant_id":"tenant-123","name":"prod","cipher":"aes"}`))
+		case "DELETE /api/v1/tenants/tenant-123/clusters/cluster-789":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not_found","message":"Resource not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		OperatorToken: "operator-token",
+		RetryAttempts: 0,
+	})
+	ctx := context.Background()
+
+	cluster, clusterToken, err := client.CreateCluster(ctx, "prod")
+	if err != nil {
+		t.Fatalf("CreateCluster() unexpected error = %v", err)
+	}
+	if cluster.ID != "cluster-789" || cluster.Name != "prod" || clusterToken != "secret-token" {
+		t.Errorf("CreateCluster() = %+v, %q", cluster, clusterToken)
+	}
+
+	list, err := client.ListClusters(ctx, 2, 10)
+	if err != nil {
+		t.Fatalf("ListClusters() unexpected error = %v", err)
+	}
+	if list.Total != 11 || len(list.Clusters) != 1 || list.Clusters[0].ID != "cluster-789" {
+		t.Errorf("ListClusters() = %+v", list)
+	}
+
+	got, err := client.GetCluster(ctx, "cluster-789")
+	if err != nil {
+		t.Fatalf("GetCluster() unexpected error = %v", err)
+	}
+	if got.Cipher != "aes" || got.TenantID != "tenant-123" {
+		t.Errorf("GetCluster() = %+v", got)
+	}
+	if _, err := client.GetCluster(ctx, "cluster-999"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetCluster() error = %v, want ErrNotFound", err)
+	}
+
+	if err := client.DeleteCluster(ctx, "cluster-789"); err != nil {
+		t.Errorf("DeleteCluster() unexpected error = %v", err)
+	}
+	if err := client.DeleteCluster(ctx, "cluster-999"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteCluster() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClient_GetTenantStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/tenants/tenant-123/stats" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get(HeaderNodeToken) == "" {
+			t.Error("Node token header missing")
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"tenant_id":"tenant-123","cluster_count":2,"node_count":7,"bundle_count":4,"bundle_storage_bytes":4096}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		NodeToken:     "valid-node-token",
+		RetryAttempts: 0,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	stats, err := client.GetTenantStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetTenantStats() unexpected error = %v", err)
+	}
+	want := TenantStats{TenantID: "tenant-123", ClusterCount: 2, NodeCount: 7, BundleCount: 4, BundleStorageBytes: 4096}
+	if *stats != want {
+		t.Errorf("GetTenantStats() = %+v, want %+v", *stats, want)
+	}
+}
+
+func TestClient_WhoAmI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/v1/nodes/me" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get(HeaderNodeToken) != "valid-node-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"unauthorized","message":"Invalid node token"}`))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"tenant_id":"tenant-123","cluster_id":"cluster-456","node_id":"node-789","name":"edge-1","is_admin":false,"is_lighthouse":true,"is_relay":false}`))
+	}))
+	defer server.Close()
+
+	newClient := func(token string) *Client {
+		client, err := NewClient(ClientConfig{
+			BaseURLs:      []string{server.URL},
+			TenantID:      "tenant-123",
+			ClusterID:     "cluster-456",
+			NodeToken:     token,
+			RetryAttempts: 0,
+		})
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		return client
+	}
+
+	identity, err := newClient("valid-node-token").WhoAmI(context.Background())
+	if err != nil {
+		t.Fatalf("WhoAmI() unexpected error = %v", err)
+	}
+	want := NodeIdentity{TenantID: "tenant-123", ClusterID: "cluster-456", NodeID: "node-789", Name: "edge-1", IsLighthouse: true}
+	if *identity != want {
+		t.Errorf("WhoAmI() = %+v, want %+v", *identity, want)
+	}
+
+	if _, err := newClient("wrong-node-token").WhoAmI(context.Background()); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("WhoAmI() with wrong token error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestClient_SetNodeToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderNodeToken) != "rotated-node-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"version":4}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		NodeToken:     "old-node-token",
+		RetryAttempts: 0,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	if _, err := client.GetLatestVersion(context.Background()); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("GetLatestVersion() with old token error = %v, want ErrUnauthorized", err)
+	}
+
+	client.SetNodeToken("rotated-node-token")
+	if version, err := client.GetLatestVersion(context.Background()); err != nil || version != 4 {
+		t.Errorf("GetLatestVersion() after SetNodeToken = %d, %v; want 4", version, err)
+	}
+}
+
+func TestClient_SetBaseURLs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/check-master" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.Write([]byte(`{"version":4}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{
+		BaseURLs:      []string{"http://127.0.0.1:1"},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		NodeToken:     "valid-node-token",
+		RetryAttempts: 0,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	// Invalid lists leave the current URLs in place
+	for _, urls := range [][]string{nil, {"ftp://replica.example"}} {
+		if err := client.SetBaseURLs(urls); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("SetBaseURLs(%v) error = %v, want ErrInvalidConfig", urls, err)
+		}
+	}
+	if got := client.BaseURLList(); !reflect.DeepEqual(got, []string{"http://127.0.0.1:1"}) {
+		t.Errorf("BaseURLList() = %v after invalid updates", got)
+	}
+
+	if err := client.SetBaseURLs([]string{server.URL + "/"}); err != nil {
+		t.Fatalf("SetBaseURLs() error = %v", err)
+	}
+	if got := client.BaseURLList(); !reflect.DeepEqual(got, []string{server.URL}) {
+		t.Errorf("BaseURLList() = %v, want [%s]", got, server.URL)
+	}
+	if version, err := client.GetLatestVersion(context.Background()); err != nil || version != 4 {
+		t.Errorf("GetLatestVersion() after SetBaseURLs = %d, %v; want 4", version, err)
+	}
+
+	// A cached master outside the new list is forgotten
+	if err := client.DiscoverMaster(context.Background()); err != nil {
+		t.Fatalf("DiscoverMaster() error = %v", err)
+	}
+	if err := client.SetBaseURLs([]string{"http://replica.example"}); err != nil {
+		t.Fatalf("SetBaseURLs() error = %v", err)
+	}
+	if master := client.MasterURL(); master != "" {
+		t.Errorf("MasterURL() = %q, want it cleared", master)
+	}
+}
+
+func TestClient_WaitForVersion(t *testing.T) {
+	newClient := func(t *testing.T, handler http.HandlerFunc) *Client {
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+		client, err := NewClient(ClientConfig{
+			BaseURLs:      []string{server.URL},
+			TenantID:      "tenant-123",
+			ClusterID:     "cluster-456",
+			NodeToken:     "valid-node-token",
+			RetryAttempts: 0,
+			RetryWaitMin:  time.Millisecond,
+			RetryWaitMax:  time.Millisecond,
+		})
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		return client
+	}
+
+	t.Run("version becomes available", func(t *testing.T) {
+		var polls atomic.Int32
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"version":%d}`, polls.Add(1))
+		})
+
+		if err := client.WaitForVersion(context.Background(), 3, time.Millisecond); err != nil {
+			t.Fatalf("WaitForVersion() error = %v", err)
+		}
+		if got := polls.Load(); got != 3 {
+			t.Errorf("Expected 3 polls, got %d", got)
+		}
+	})
+
+	t.Run("deadline returns the last poll error", func(t *testing.T) {
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"service_unavailable","message":"database unavailable"}`))
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err := client.WaitForVersion(ctx, 3, 5*time.Millisecond)
+		if err == nil || !strings.Contains(err.Error(), "status code 503") {
+			t.Errorf("WaitForVersion() error = %v, want the last poll error", err)
+		}
+	})
+
+	t.Run("deadline without poll errors", func(t *testing.T) {
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"version":1}`))
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if err := client.WaitForVersion(ctx, 3, 5*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("WaitForVersion() error = %v, want context.DeadlineExceeded", err)
+		}
+	})
+
+	t.Run("unauthorized fails fast", func(t *testing.T) {
+		var polls atomic.Int32
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			polls.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+
+		if err := client.WaitForVersion(context.Background(), 3, time.Millisecond); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("WaitForVersion() error = %v, want ErrUnauthorized", err)
+		}
+		if got := polls.Load(); got != 1 {
+			t.Errorf("Expected a single poll, got %d", got)
+		}
+	})
+}
+
+func TestClient_GetLatestVersions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected POST request, got %s", r.Method)
+		}
+		if r.URL.Path != "/api/v1/config/versions" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get(HeaderNodeToken) != "" || r.Header.Get(HeaderClusterToken) != "" {
+			t.Error("Batched version check should carry credentials in the body, not headers")
+		}
+		body, _ := io.ReadAll(r.Body)
+		want := `{"clusters":[{"tenant_id":"tenant-1","cluster_id":"cluster-1","node_token":"node-token-1"},{"tenant_id":"tenant-1","cluster_id":"cluster-2","cluster_token":"cluster-token-2"}]}`
+		if string(body) != want {
+			t.Errorf("Unexpected body: %s", body)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"versions":[{"tenant_id":"tenant-1","cluster_id":"cluster-1","version":42},{"tenant_id":"tenant-1","cluster_id":"cluster-2","version":0,"error":"unauthorized"}]}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		NodeToken:     "valid-node-token",
+		RetryAttempts: 0,
+	})
+
+	versions, err := client.GetLatestVersions(context.Background(), []ClusterRef{
+		{TenantID: "tenant-1", ClusterID: "cluster-1", NodeToken: "node-token-1"},
+		{TenantID: "tenant-1", ClusterID: "cluster-2", ClusterToken: "cluster-token-2"},
+	})
+	if err != nil {
+		t.Fatalf("GetLatestVersions() unexpected error = %v", err)
+	}
+
+	want := []ClusterVersion{
+		{TenantID: "tenant-1", ClusterID: "cluster-1", Version: 42},
+		{TenantID: "tenant-1", ClusterID: "cluster-2", Error: "unauthorized"},
+	}
+	if !reflect.DeepEqual(versions, want) {
+		t.Errorf("GetLatestVersions() = %+v, want %+v", versions, want)
+	}
+
+	// Batch size is enforced before contacting the server
+	for _, size := range []int{0, MaxVersionBatchSize + 1} {
+		_, err := client.GetLatestVersions(context.Background(), make([]ClusterRef, size))
+		if !errors.Is(err, ErrBadRequest) {
+			t.Errorf("GetLatestVersions() with %d clusters error = %v, want ErrBadRequest", size, err)
+		}
+	}
+}
+
+func TestClient_DownloadBundle(t *testing.T) {
+	bundleData := []byte("mock-bundle-data-tar-gz")
+
+	tests := []struct {
+		name           string
+		currentVersion int64
+		serverStatus   int
+		serverBody     []byte
+		versionHeader  string
+		wantData       []byte
+		wantVersion    int64
+		wantErr        bool
+	}{
+		{
+			name:           "successful download - new version",
+			currentVersion: 1,
+			serverStatus:   http.StatusOK,
+			serverBody:     bundleData,
+			versionHeader:  "2",
+			wantData:       bundleData,
+			wantVersion:    2,
+			wantErr:        false,
+		},
+		{
+			name:           "304 not modified - no update",
+			currentVersion: 5,
+			serverStatus:   http.StatusNotModified,
+			serverBody:     nil,
+			versionHeader:  "",
+			wantData:       nil,
+			wantVersion:    5,
+			wantErr:        false,
+		},
+		{
+			name:           "unauthorized",
+			currentVersion: 1,
+			serverStatus:   http.StatusUnauthorized,
+			serverBody:     []byte(`{"error":"invalid node token"}`),
+			versionHeader:  "",
+			wantErr:        true,
+		},
+		{
+			name:           "rate limited",
+			currentVersion: 1,
+			serverStatus:   http.StatusTooManyRequests,
+			serverBody:     []byte(`{"error":"rate limit exceeded"}`),
+			versionHeader:  "",
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// Verify method
+				if r.Method != http.MethodGet {
+					t.Errorf("Expected GET request, got %s", r.Method)
+				}
+
+				// Verify node token header
+				if r.Header.Get(HeaderNodeToken) == "" {
+					t.Error("Node token header missing")
+				}
+
+				// Verify current_version query parameter
+				currentVersion := r.URL.Query().Get("current_version")
+				if currentVersion == "" {
+					t.Error("current_version query parameter missing")
+				}
+
+				// Verify the current version is also sent as an entity tag
+				wantETag := fmt.Sprintf("\"cluster-456-v%d\"", tt.currentVersion)
+				if got := r.Header.Get("If-None-Match"); got != wantETag {
+					t.Errorf("If-None-Match = %q, want %q", got, wantETag)
+				}
+
+				// Set version header if provided
+				if tt.versionHeader != "" {
+					w.Header().Set("X-Config-Version", tt.versionHeader)
+				}
+
+				w.WriteHeader(tt.serverStatus)
+				if tt.serverBody != nil {
+					w.Write(tt.serverBody)
+				}
+			}))
+			defer server.Close()
+
+			client, err := NewClient(ClientConfig{
+				BaseURLs:      []string{server.URL},
+				TenantID:      "tenant-123",
+				ClusterID:     "cluster-456",
+				NodeToken:     "valid-node-token",
+				RetryAttempts: 0,
+			})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			ctx := context.Background()
+			data, version, err := client.DownloadBundle(ctx, tt.currentVersion)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("DownloadBundle() expected error but got nil")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("DownloadBundle() unexpected error = %v", err)
+				}
+				if version != tt.wantVersion {
+					t.Errorf("DownloadBundle() returned version %d, want %d", version, tt.wantVersion)
+				}
+				if tt.wantData == nil && data != nil {
+					t.Errorf("DownloadBundle() returned data, want nil")
+				}
+				if tt.wantData != nil {
+					if data == nil {
+						t.Error("DownloadBundle() returned nil data, want bundle data")
+					} else if string(data) != string(tt.wantData) {
+						t.Errorf("DownloadBundle() returned wrong data")
 					}
 				}
 			}
@@ -1049,52 +2051,598 @@ func TestClient_DownloadBundle(t *testing.T) {
 	}
 }
 
-func TestClient_UploadBundle(t *testing.T) {
-	bundleData := []byte("test-bundle-data")
-
+func TestClient_UploadBundle(t *testing.T) {
+	bundleData := []byte("test-bundle-data")
+
+	tests := []struct {
+		name         string
+		bundleData   []byte
+		serverStatus int
+		serverBody   string
+		effectiveAt  time.Time
+		canaryNodes  []string
+		want         BundleUploadResult
+		wantErr      bool
+	}{
+		{
+			name:         "successful upload",
+			bundleData:   bundleData,
+			serverStatus: http.StatusCreated,
+			serverBody:   `{"version":10,"compressed_size":2048,"uncompressed_size":8192,"file_count":5,"checksum":"abc123"}`,
+			want:         BundleUploadResult{Version: 10, CompressedSize: 2048, UncompressedSize: 8192, FileCount: 5, Checksum: "abc123"},
+			wantErr:      false,
+		},
+		{
+			name:         "successful upload - 200 OK",
+			bundleData:   bundleData,
+			serverStatus: http.StatusOK,
+			serverBody:   `{"version":11}`,
+			want:         BundleUploadResult{Version: 11},
+			wantErr:      false,
+		},
+		{
+			name:         "scheduled upload",
+			bundleData:   bundleData,
+			serverStatus: http.StatusOK,
+			serverBody:   `{"version":12,"effective_at":"2025-01-21T02:00:00Z"}`,
+			effectiveAt:  time.Date(2025, 1, 21, 3, 0, 0, 0, time.FixedZone("CET", 3600)),
+			want:         BundleUploadResult{Version: 12},
+			wantErr:      false,
+		},
+		{
+			name:         "canary upload",
+			bundleData:   bundleData,
+			serverStatus: http.StatusCreated,
+			serverBody:   `{"version":13}`,
+			canaryNodes:  []string{"node-1", "node-2"},
+			want:         BundleUploadResult{Version: 13},
+			wantErr:      false,
+		},
+		{
+			name:         "unauthorized - not admin",
+			bundleData:   bundleData,
+			serverStatus: http.StatusUnauthorized,
+			serverBody:   `{"error":"admin privileges required"}`,
+			wantErr:      true,
+		},
+		{
+			name:         "invalid bundle format",
+			bundleData:   bundleData,
+			serverStatus: http.StatusBadRequest,
+			serverBody:   `{"error":"invalid bundle format"}`,
+			wantErr:      true,
+		},
+		{
+			name:         "rate limited",
+			bundleData:   bundleData,
+			serverStatus: http.StatusTooManyRequests,
+			serverBody:   `{"error":"rate limit exceeded"}`,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// Verify method
+				if r.Method != http.MethodPost {
+					t.Errorf("Expected POST request, got %s", r.Method)
+				}
+
+				// Verify node token header
+				if r.Header.Get(HeaderNodeToken) == "" {
+					t.Error("Node token header missing")
+				}
+
+				// Verify activation time is sent in UTC only when scheduled
+				wantEffective := ""
+				if !tt.effectiveAt.IsZero() {
+					wantEffective = "2025-01-21T02:00:00Z"
+				}
+				if got := r.URL.Query().Get("effective_at"); got != wantEffective {
+					t.Errorf("Expected effective_at %q, got %q", wantEffective, got)
+				}
+
+				// Verify canary nodes are sent with the upload
+				if got, want := r.URL.Query().Get("canary_nodes"), strings.Join(tt.canaryNodes, ","); got != want {
+					t.Errorf("Expected canary_nodes %q, got %q", want, got)
+				}
+
+				// Verify content type
+				if r.Header.Get("Content-Type") != "application/octet-stream" {
+					t.Errorf("Expected Content-Type application/octet-stream, got %s", r.Header.Get("Content-Type"))
+				}
+
+				// Read and verify body
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Errorf("Failed to read request body: %v", err)
+				}
+				if string(body) != string(tt.bundleData) {
+					t.Error("Request body doesn't match bundle data")
+				}
+
+				w.WriteHeader(tt.serverStatus)
+				w.Write([]byte(tt.serverBody))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(ClientConfig{
+				BaseURLs:      []string{server.URL},
+				TenantID:      "tenant-123",
+				ClusterID:     "cluster-456",
+				NodeToken:     "valid-node-token",
+				RetryAttempts: 0,
+			})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			ctx := context.Background()
+			result, err := client.UploadBundle(ctx, tt.bundleData, WithEffectiveAt(tt.effectiveAt), WithCanaryNodes(tt.canaryNodes...))
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("UploadBundle() expected error but got nil")
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("UploadBundle() unexpected error = %v", err)
+				}
+				if *result != tt.want {
+					t.Errorf("UploadBundle() returned %+v, want %+v", *result, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_SignedBundleRoundTrip(t *testing.T) {
+	bundleData := []byte("signed-bundle-data")
+	signature := bytes.Repeat([]byte{0x5a}, 64)
+	var stored string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			stored = r.Header.Get("X-Config-Signature")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"version":3}`))
+		case http.MethodGet:
+			w.Header().Set("X-Config-Version", "3")
+			if stored != "" {
+				w.Header().Set("X-Config-Signature", stored)
+			}
+			w.Write(bundleData)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		NodeToken:     "valid-node-token",
+		RetryAttempts: 0,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	ctx := context.Background()
+
+	// Unsigned bundles come back without a signature
+	signed, err := client.DownloadSignedBundle(ctx, 0)
+	if err != nil {
+		t.Fatalf("DownloadSignedBundle() error = %v", err)
+	}
+	if signed.Signature != nil {
+		t.Errorf("DownloadSignedBundle() signature = %x, want none", signed.Signature)
+	}
+
+	if _, err := client.UploadSignedBundle(ctx, bundleData, signature); err != nil {
+		t.Fatalf("UploadSignedBundle() error = %v", err)
+	}
+	if want := base64.StdEncoding.EncodeToString(signature); stored != want {
+		t.Errorf("X-Config-Signature = %q, want %q", stored, want)
+	}
+
+	signed, err = client.DownloadSignedBundle(ctx, 0)
+	if err != nil {
+		t.Fatalf("DownloadSignedBundle() error = %v", err)
+	}
+	if signed.Version != 3 || !bytes.Equal(signed.Data, bundleData) || !bytes.Equal(signed.Signature, signature) {
+		t.Errorf("DownloadSignedBundle() = %+v, want version 3 with the uploaded signature", signed)
+	}
+
+	// A malformed signature header is an error, not an unsigned bundle
+	stored = "not base64!"
+	if _, err := client.DownloadSignedBundle(ctx, 0); err == nil {
+		t.Error("DownloadSignedBundle() expected error for a malformed signature header")
+	}
+}
+
+func TestClient_Canary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderNodeToken) == "" {
+			t.Error("Node token header missing")
+		}
+
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v1/tenants/tenant-123/clusters/cluster-456/config/canary":
+			var req struct {
+				Version int64    `json:"version"`
+				NodeIDs []string `json:"node_ids"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Version == 8 {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_request","message":"version 8 is not the latest bundle"}`))
+				return
+			}
+			fmt.Fprintf(w, `{"cluster_id":"cluster-456","version":%d,"node_ids":["%s"],"created_at":"2025-01-01T00:00:00Z"}`,
+				req.Version, strings.Join(req.NodeIDs, `","`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/tenants/tenant-123/clusters/cluster-456/config/canary/promote":
+			w.Write([]byte(`{"version":9}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		NodeToken:     "valid-node-token",
+		RetryAttempts: 0,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ctx := context.Background()
+
+	canary, err := client.SetCanary(ctx, 9, []string{"node-1", "node-2"})
+	if err != nil {
+		t.Fatalf("SetCanary() unexpected error = %v", err)
+	}
+	if canary.Version != 9 || len(canary.NodeIDs) != 2 || canary.NodeIDs[1] != "node-2" {
+		t.Errorf("SetCanary() = %+v, unexpected rollout", canary)
+	}
+
+	if _, err := client.SetCanary(ctx, 8, []string{"node-1"}); err == nil {
+		t.Error("SetCanary() expected error for a stale version but got nil")
+	}
+
+	version, err := client.PromoteCanary(ctx)
+	if err != nil {
+		t.Fatalf("PromoteCanary() unexpected error = %v", err)
+	}
+	if version != 9 {
+		t.Errorf("PromoteCanary() = %d, want 9", version)
+	}
+}
+
+func TestClient_SendHeartbeat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/nodes/heartbeat" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get(HeaderNodeToken) == "" {
+			t.Error("Node token header missing")
+		}
+
+		var req struct {
+			Version int64  `json:"version"`
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Version != 7 || req.Status != HealthStatusError || req.Message != "nebula exited" {
+			t.Errorf("Unexpected heartbeat %+v", req)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		NodeToken:     "valid-node-token",
+		RetryAttempts: 0,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	if err := client.SendHeartbeat(context.Background(), 7, HealthStatusError, "nebula exited"); err != nil {
+		t.Errorf("SendHeartbeat() unexpected error = %v", err)
+	}
+}
+
+// ============================================================================
+// Topology Management Methods Tests
+// ============================================================================
+
+func TestClient_RegisterRoutes(t *testing.T) {
+	tests := []struct {
+		name         string
+		routes       []string
+		serverStatus int
+		serverBody   string
+		wantErr      bool
+	}{
+		{
+			name:         "successful registration",
+			routes:       []string{"10.100.0.0/24", "10.101.0.0/24"},
+			serverStatus: http.StatusOK,
+			serverBody:   `{"message":"routes updated"}`,
+			wantErr:      false,
+		},
+		{
+			name:         "empty routes",
+			routes:       []string{},
+			serverStatus: http.StatusOK,
+			serverBody:   `{"message":"routes cleared"}`,
+			wantErr:      false,
+		},
+		{
+			name:         "invalid CIDR format",
+			routes:       []string{"invalid-cidr"},
+			serverStatus: http.StatusBadRequest,
+			serverBody:   `{"error":"invalid CIDR format"}`,
+			wantErr:      true,
+		},
+		{
+			name:         "unauthorized",
+			routes:       []string{"10.100.0.0/24"},
+			serverStatus: http.StatusUnauthorized,
+			serverBody:   `{"error":"invalid node token"}`,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPut {
+					t.Errorf("Expected PUT request, got %s", r.Method)
+				}
+				if r.Header.Get(HeaderNodeToken) == "" {
+					t.Error("Node token header missing")
+				}
+				w.WriteHeader(tt.serverStatus)
+				w.Write([]byte(tt.serverBody))
+			}))
+			defer server.Close()
+
+			client, _ := NewClient(ClientConfig{
+				BaseURLs:      []string{server.URL},
+				TenantID:      "tenant-123",
+				ClusterID:     "cluster-456",
+				NodeID:        "node-789",
+				NodeToken:     "valid-node-token",
+				RetryAttempts: 0,
+			})
+
+			ctx := context.Background()
+			err := client.RegisterRoutes(ctx, tt.routes)
+
+			if tt.wantErr && err == nil {
+				t.Error("RegisterRoutes() expected error but got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("RegisterRoutes() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestClient_GetRoutes(t *testing.T) {
+	tests := []struct {
+		name         string
+		serverStatus int
+		serverBody   string
+		wantRoutes   []string
+		wantErr      bool
+	}{
+		{
+			name:         "successful retrieval",
+			serverStatus: http.StatusOK,
+			serverBody:   `{"routes":["10.100.0.0/24","10.101.0.0/24"]}`,
+			wantRoutes:   []string{"10.100.0.0/24", "10.101.0.0/24"},
+			wantErr:      false,
+		},
+		{
+			name:         "no routes",
+			serverStatus: http.StatusOK,
+			serverBody:   `{"routes":[]}`,
+			wantRoutes:   []string{},
+			wantErr:      false,
+		},
+		{
+			name:         "unauthorized",
+			serverStatus: http.StatusUnauthorized,
+			serverBody:   `{"error":"invalid node token"}`,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet {
+					t.Errorf("Expected GET request, got %s", r.Method)
+				}
+				if r.Header.Get(HeaderNodeToken) == "" {
+					t.Error("Node token header missing")
+				}
+				w.WriteHeader(tt.serverStatus)
+				w.Write([]byte(tt.serverBody))
+			}))
+			defer server.Close()
+
+			client, _ := NewClient(ClientConfig{
+				BaseURLs:      []string{server.URL},
+				TenantID:      "tenant-123",
+				ClusterID:     "cluster-456",
+				NodeID:        "node-789",
+				NodeToken:     "valid-node-token",
+				RetryAttempts: 0,
+			})
+
+			ctx := context.Background()
+			routes, err := client.GetRoutes(ctx)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Error("GetRoutes() expected error but got nil")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("GetRoutes() unexpected error = %v", err)
+				}
+				if len(routes) != len(tt.wantRoutes) {
+					t.Errorf("GetRoutes() returned %d routes, want %d", len(routes), len(tt.wantRoutes))
+				}
+			}
+		})
+	}
+}
+
+func TestClient_ListClusterRoutes(t *testing.T) {
+	tests := []struct {
+		name         string
+		serverStatus int
+		serverBody   string
+		wantCount    int
+		wantErr      bool
+	}{
+		{
+			name:         "successful listing",
+			serverStatus: http.StatusOK,
+			serverBody:   `[{"node_id":"node-1","routes":["10.100.0.0/24"]},{"node_id":"node-2","routes":["10.101.0.0/24"]}]`,
+			wantCount:    2,
+			wantErr:      false,
+		},
+		{
+			name:         "no routes in cluster",
+			serverStatus: http.StatusOK,
+			serverBody:   `[]`,
+			wantCount:    0,
+			wantErr:      false,
+		},
+		{
+			name:         "unauthorized",
+			serverStatus: http.StatusUnauthorized,
+			serverBody:   `{"error":"invalid cluster token"}`,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet {
+					t.Errorf("Expected GET request, got %s", r.Method)
+				}
+				if r.Header.Get(HeaderClusterToken) == "" {
+					t.Error("Cluster token header missing")
+				}
+				w.WriteHeader(tt.serverStatus)
+				w.Write([]byte(tt.serverBody))
+			}))
+			defer server.Close()
+
+			client, _ := NewClient(ClientConfig{
+				BaseURLs:      []string{server.URL},
+				TenantID:      "tenant-123",
+				ClusterID:     "cluster-456",
+				ClusterToken:  "valid-cluster-token",
+				RetryAttempts: 0,
+			})
+
+			ctx := context.Background()
+			routes, err := client.ListClusterRoutes(ctx)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Error("ListClusterRoutes() expected error but got nil")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("ListClusterRoutes() unexpected error = %v", err)
+				}
+				if len(routes) != tt.wantCount {
+					t.Errorf("ListClusterRoutes() returned %d routes, want %d", len(routes), tt.wantCount)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_SetLighthouse(t *testing.T) {
 	tests := []struct {
 		name         string
-		bundleData   []byte
+		nodeID       string
+		enabled      bool
+		publicIP     string
+		port         int
 		serverStatus int
 		serverBody   string
-		wantVersion  int64
 		wantErr      bool
 	}{
 		{
-			name:         "successful upload",
-			bundleData:   bundleData,
-			serverStatus: http.StatusCreated,
-			serverBody:   `{"version":10}`,
-			wantVersion:  10,
+			name:         "enable lighthouse",
+			nodeID:       "node-123",
+			enabled:      true,
+			publicIP:     "203.0.113.10",
+			port:         4242,
+			serverStatus: http.StatusOK,
+			serverBody:   `{"message":"lighthouse enabled"}`,
 			wantErr:      false,
 		},
 		{
-			name:         "successful upload - 200 OK",
-			bundleData:   bundleData,
+			name:         "disable lighthouse",
+			nodeID:       "node-123",
+			enabled:      false,
+			publicIP:     "",
+			port:         0,
 			serverStatus: http.StatusOK,
-			serverBody:   `{"version":11}`,
-			wantVersion:  11,
+			serverBody:   `{"message":"lighthouse disabled"}`,
 			wantErr:      false,
 		},
 		{
-			name:         "unauthorized - not admin",
-			bundleData:   bundleData,
-			serverStatus: http.StatusUnauthorized,
-			serverBody:   `{"error":"admin privileges required"}`,
+			name:         "invalid public IP",
+			nodeID:       "node-123",
+			enabled:      true,
+			publicIP:     "invalid-ip",
+			port:         4242,
+			serverStatus: http.StatusBadRequest,
+			serverBody:   `{"error":"invalid public IP"}`,
 			wantErr:      true,
 		},
 		{
-			name:         "invalid bundle format",
-			bundleData:   bundleData,
-			serverStatus: http.StatusBadRequest,
-			serverBody:   `{"error":"invalid bundle format"}`,
+			name:         "node not found",
+			nodeID:       "node-999",
+			enabled:      true,
+			publicIP:     "203.0.113.10",
+			port:         4242,
+			serverStatus: http.StatusNotFound,
+			serverBody:   `{"error":"node not found"}`,
 			wantErr:      true,
 		},
 		{
-			name:         "rate limited",
-			bundleData:   bundleData,
-			serverStatus: http.StatusTooManyRequests,
-			serverBody:   `{"error":"rate limit exceeded"}`,
+			name:         "unauthorized",
+			nodeID:       "node-123",
+			enabled:      true,
+			publicIP:     "203.0.113.10",
+			port:         4242,
+			serverStatus: http.StatusUnauthorized,
+			serverBody:   `{"error":"invalid cluster token"}`,
 			wantErr:      true,
 		},
 	}
@@ -1102,103 +2650,272 @@ func TestClient_UploadBundle(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				// Verify method
-				if r.Method != http.MethodPost {
-					t.Errorf("Expected POST request, got %s", r.Method)
+				if r.Method != http.MethodPut {
+					t.Errorf("Expected PUT request, got %s", r.Method)
+				}
+				if r.Header.Get(HeaderClusterToken) == "" {
+					t.Error("Cluster token header missing")
 				}
+				w.WriteHeader(tt.serverStatus)
+				w.Write([]byte(tt.serverBody))
+			}))
+			defer server.Close()
 
-				// Verify node token header
-				if r.Header.Get(HeaderNodeToken) == "" {
-					t.Error("Node token header missing")
+			client, _ := NewClient(ClientConfig{
+				BaseURLs:      []string{server.URL},
+				TenantID:      "tenant-123",
+				ClusterID:     "cluster-456",
+				ClusterToken:  "valid-cluster-token",
+				RetryAttempts: 0,
+			})
+
+			ctx := context.Background()
+			err := client.SetLighthouse(ctx, tt.nodeID, tt.enabled, tt.publicIP, tt.port)
+
+			if tt.wantErr && err == nil {
+				t.Error("SetLighthouse() expected error but got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("SetLighthouse() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestClient_SetRelay(t *testing.T) {
+	tests := []struct {
+		name         string
+		nodeID       string
+		enabled      bool
+		serverStatus int
+		serverBody   string
+		wantErr      bool
+	}{
+		{
+			name:         "enable relay",
+			nodeID:       "node-123",
+			enabled:      true,
+			serverStatus: http.StatusOK,
+			serverBody:   `{"message":"relay enabled"}`,
+			wantErr:      false,
+		},
+		{
+			name:         "disable relay",
+			nodeID:       "node-123",
+			enabled:      false,
+			serverStatus: http.StatusOK,
+			serverBody:   `{"message":"relay disabled"}`,
+			wantErr:      false,
+		},
+		{
+			name:         "node not found",
+			nodeID:       "node-999",
+			enabled:      true,
+			serverStatus: http.StatusNotFound,
+			serverBody:   `{"error":"node not found"}`,
+			wantErr:      true,
+		},
+		{
+			name:         "unauthorized",
+			nodeID:       "node-123",
+			enabled:      true,
+			serverStatus: http.StatusUnauthorized,
+			serverBody:   `{"error":"invalid cluster token"}`,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPut {
+					t.Errorf("Expected PUT request, got %s", r.Method)
+				}
+				if r.Header.Get(HeaderClusterToken) == "" {
+					t.Error("Cluster token header missing")
 				}
+				w.WriteHeader(tt.serverStatus)
+				w.Write([]byte(tt.serverBody))
+			}))
+			defer server.Close()
 
-				// Verify content type
-				if r.Header.Get("Content-Type") != "application/octet-stream" {
-					t.Errorf("Expected Content-Type application/octet-stream, got %s", r.Header.Get("Content-Type"))
+			client, _ := NewClient(ClientConfig{
+				BaseURLs:      []string{server.URL},
+				TenantID:      "tenant-123",
+				ClusterID:     "cluster-456",
+				ClusterToken:  "valid-cluster-token",
+				RetryAttempts: 0,
+			})
+
+			ctx := context.Background()
+			err := client.SetRelay(ctx, tt.nodeID, tt.enabled)
+
+			if tt.wantErr && err == nil {
+				t.Error("SetRelay() expected error but got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("SetRelay() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestClient_SetMaxRelayHops(t *testing.T) {
+	tests := []struct {
+		name         string
+		hops         int
+		serverStatus int
+		serverBody   string
+		wantErr      bool
+	}{
+		{
+			name:         "set limit",
+			hops:         2,
+			serverStatus: http.StatusOK,
+			serverBody:   `{"message":"Max relay hops updated"}`,
+			wantErr:      false,
+		},
+		{
+			name:         "out of range",
+			hops:         9,
+			serverStatus: http.StatusBadRequest,
+			serverBody:   `{"error":"invalid_request","message":"Invalid request parameters"}`,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPut {
+					t.Errorf("Expected PUT request, got %s", r.Method)
+				}
+				if r.Header.Get(HeaderClusterToken) == "" {
+					t.Error("Cluster token header missing")
 				}
+				w.WriteHeader(tt.serverStatus)
+				w.Write([]byte(tt.serverBody))
+			}))
+			defer server.Close()
 
-				// Read and verify body
-				body, err := io.ReadAll(r.Body)
-				if err != nil {
-					t.Errorf("Failed to read request body: %v", err)
+			client, _ := NewClient(ClientConfig{
+				BaseURLs:      []string{server.URL},
+				TenantID:      "tenant-123",
+				ClusterID:     "cluster-456",
+				ClusterToken:  "valid-cluster-token",
+				RetryAttempts: 0,
+			})
+
+			err := client.SetMaxRelayHops(context.Background(), tt.hops)
+
+			if tt.wantErr && err == nil {
+				t.Error("SetMaxRelayHops() expected error but got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("SetMaxRelayHops() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestClient_LighthouseDNS(t *testing.T) {
+	tests := []struct {
+		name         string
+		serverStatus int
+		serverBody   string
+		wantErr      bool
+	}{
+		{
+			name:         "update settings",
+			serverStatus: http.StatusOK,
+			serverBody:   `{"message":"Lighthouse DNS updated"}`,
+			wantErr:      false,
+		},
+		{
+			name:         "port conflict",
+			serverStatus: http.StatusBadRequest,
+			serverBody:   `{"error":"invalid_request","message":"Lighthouse DNS port conflicts with the Nebula UDP port"}`,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get(HeaderClusterToken) == "" {
+					t.Error("Cluster token header missing")
+				}
+				if r.Method == http.MethodGet {
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(`{"enabled":true,"host":"0.0.0.0","port":5353}`))
+					return
 				}
-				if string(body) != string(tt.bundleData) {
-					t.Error("Request body doesn't match bundle data")
+				if r.Method != http.MethodPut {
+					t.Errorf("Expected PUT request, got %s", r.Method)
 				}
-
 				w.WriteHeader(tt.serverStatus)
 				w.Write([]byte(tt.serverBody))
 			}))
 			defer server.Close()
 
-			client, err := NewClient(ClientConfig{
+			client, _ := NewClient(ClientConfig{
 				BaseURLs:      []string{server.URL},
 				TenantID:      "tenant-123",
 				ClusterID:     "cluster-456",
-				NodeToken:     "valid-node-token",
+				ClusterToken:  "valid-cluster-token",
 				RetryAttempts: 0,
 			})
-			if err != nil {
-				t.Fatalf("NewClient() error = %v", err)
-			}
 
-			ctx := context.Background()
-			version, err := client.UploadBundle(ctx, tt.bundleData)
+			err := client.SetLighthouseDNS(context.Background(), LighthouseDNSConfig{Enabled: true, Port: 5353})
 
-			if tt.wantErr {
-				if err == nil {
-					t.Errorf("UploadBundle() expected error but got nil")
-				}
-			} else {
-				if err != nil {
-					t.Errorf("UploadBundle() unexpected error = %v", err)
-				}
-				if version != tt.wantVersion {
-					t.Errorf("UploadBundle() returned version %d, want %d", version, tt.wantVersion)
-				}
+			if tt.wantErr && err == nil {
+				t.Error("SetLighthouseDNS() expected error but got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("SetLighthouseDNS() unexpected error = %v", err)
+			}
+
+			dns, err := client.GetLighthouseDNS(context.Background())
+			if err != nil {
+				t.Fatalf("GetLighthouseDNS() unexpected error = %v", err)
+			}
+			if !dns.Enabled || dns.Port != 5353 {
+				t.Errorf("GetLighthouseDNS() = %+v", dns)
 			}
 		})
 	}
 }
 
-// ============================================================================
-// Topology Management Methods Tests
-// ============================================================================
-
-func TestClient_RegisterRoutes(t *testing.T) {
+func TestClient_SetStaticHostMap(t *testing.T) {
 	tests := []struct {
 		name         string
-		routes       []string
+		hosts        map[string][]string
 		serverStatus int
 		serverBody   string
+		wantBody     string
 		wantErr      bool
 	}{
 		{
-			name:         "successful registration",
-			routes:       []string{"10.100.0.0/24", "10.101.0.0/24"},
+			name:         "set entries",
+			hosts:        map[string][]string{"10.42.0.50": {"198.51.100.50:4242"}},
 			serverStatus: http.StatusOK,
-			serverBody:   `{"message":"routes updated"}`,
+			serverBody:   `{"message":"Static host map updated"}`,
+			wantBody:     `{"static_host_map":{"10.42.0.50":["198.51.100.50:4242"]}}`,
 			wantErr:      false,
 		},
 		{
-			name:         "empty routes",
-			routes:       []string{},
+			name:         "clear entries",
+			hosts:        nil,
 			serverStatus: http.StatusOK,
-			serverBody:   `{"message":"routes cleared"}`,
+			serverBody:   `{"message":"Static host map updated"}`,
+			wantBody:     `{"static_host_map":{}}`,
 			wantErr:      false,
 		},
 		{
-			name:         "invalid CIDR format",
-			routes:       []string{"invalid-cidr"},
+			name:         "outside subnet",
+			hosts:        map[string][]string{"10.99.0.1": {"198.51.100.1:4242"}},
 			serverStatus: http.StatusBadRequest,
-			serverBody:   `{"error":"invalid CIDR format"}`,
-			wantErr:      true,
-		},
-		{
-			name:         "unauthorized",
-			routes:       []string{"10.100.0.0/24"},
-			serverStatus: http.StatusUnauthorized,
-			serverBody:   `{"error":"invalid node token"}`,
+			serverBody:   `{"error":"invalid_request","message":"invalid request: Nebula IP 10.99.0.1 is outside subnet 10.42.0.0/16"}`,
 			wantErr:      true,
 		},
 	}
@@ -1209,8 +2926,12 @@ func TestClient_RegisterRoutes(t *testing.T) {
 				if r.Method != http.MethodPut {
 					t.Errorf("Expected PUT request, got %s", r.Method)
 				}
-				if r.Header.Get(HeaderNodeToken) == "" {
-					t.Error("Node token header missing")
+				if r.Header.Get(HeaderClusterToken) == "" {
+					t.Error("Cluster token header missing")
+				}
+				body, _ := io.ReadAll(r.Body)
+				if tt.wantBody != "" && string(body) != tt.wantBody {
+					t.Errorf("Request body = %s, want %s", body, tt.wantBody)
 				}
 				w.WriteHeader(tt.serverStatus)
 				w.Write([]byte(tt.serverBody))
@@ -1221,50 +2942,52 @@ func TestClient_RegisterRoutes(t *testing.T) {
 				BaseURLs:      []string{server.URL},
 				TenantID:      "tenant-123",
 				ClusterID:     "cluster-456",
-				NodeID:        "node-789",
-				NodeToken:     "valid-node-token",
+				ClusterToken:  "valid-cluster-token",
 				RetryAttempts: 0,
 			})
 
-			ctx := context.Background()
-			err := client.RegisterRoutes(ctx, tt.routes)
+			err := client.SetStaticHostMap(context.Background(), tt.hosts)
 
 			if tt.wantErr && err == nil {
-				t.Error("RegisterRoutes() expected error but got nil")
+				t.Error("SetStaticHostMap() expected error but got nil")
 			}
 			if !tt.wantErr && err != nil {
-				t.Errorf("RegisterRoutes() unexpected error = %v", err)
+				t.Errorf("SetStaticHostMap() unexpected error = %v", err)
 			}
 		})
 	}
 }
 
-func TestClient_GetRoutes(t *testing.T) {
+func TestClient_SetPreferredRanges(t *testing.T) {
 	tests := []struct {
 		name         string
+		ranges       []string
 		serverStatus int
 		serverBody   string
-		wantRoutes   []string
+		wantBody     string
 		wantErr      bool
 	}{
 		{
-			name:         "successful retrieval",
+			name:         "set ranges",
+			ranges:       []string{"192.168.0.0/16"},
 			serverStatus: http.StatusOK,
-			serverBody:   `{"routes":["10.100.0.0/24","10.101.0.0/24"]}`,
-			wantRoutes:   []string{"10.100.0.0/24", "10.101.0.0/24"},
+			serverBody:   `{"message":"Preferred ranges updated"}`,
+			wantBody:     `{"preferred_ranges":["192.168.0.0/16"]}`,
 			wantErr:      false,
 		},
 		{
-			name:         "no routes",
+			name:         "clear ranges",
+			ranges:       nil,
 			serverStatus: http.StatusOK,
-			serverBody:   `{"routes":[]}`,
-			wantRoutes:   []string{},
+			serverBody:   `{"message":"Preferred ranges updated"}`,
+			wantBody:     `{"preferred_ranges":[]}`,
 			wantErr:      false,
 		},
 		{
-			name:         "unauthorized",
-			serverStatus: http.StatusUnauthorized,
-			serverBody:   `{"error":"invalid node token"}`,
+			name:         "host bits set",
+			ranges:       []string{"192.168.1.5/16"},
+			serverStatus: http.StatusBadRequest,
+			serverBody:   `{"error":"invalid_request","message":"invalid request: \"192.168.1.5/16\" is not a network address"}`,
 			wantErr:      true,
 		},
 	}
@@ -1272,11 +2995,12 @@ func TestClient_GetRoutes(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				if r.Method != http.MethodGet {
-					t.Errorf("Expected GET request, got %s", r.Method)
+				if r.Method != http.MethodPut {
+					t.Errorf("Expected PUT request, got %s", r.Method)
 				}
-				if r.Header.Get(HeaderNodeToken) == "" {
-					t.Error("Node token header missing")
+				body, _ := io.ReadAll(r.Body)
+				if tt.wantBody != "" && string(body) != tt.wantBody {
+					t.Errorf("Request body = %s, want %s", body, tt.wantBody)
 				}
 				w.WriteHeader(tt.serverStatus)
 				w.Write([]byte(tt.serverBody))
@@ -1287,68 +3011,70 @@ func TestClient_GetRoutes(t *testing.T) {
 				BaseURLs:      []string{server.URL},
 				TenantID:      "tenant-123",
 				ClusterID:     "cluster-456",
-				NodeID:        "node-789",
-				NodeToken:     "valid-node-token",
+				ClusterToken:  "valid-cluster-token",
 				RetryAttempts: 0,
 			})
 
-			ctx := context.Background()
-			routes, err := client.GetRoutes(ctx)
+			err := client.SetPreferredRanges(context.Background(), tt.ranges)
 
-			if tt.wantErr {
-				if err == nil {
-					t.Error("GetRoutes() expected error but got nil")
-				}
-			} else {
-				if err != nil {
-					t.Errorf("GetRoutes() unexpected error = %v", err)
-				}
-				if len(routes) != len(tt.wantRoutes) {
-					t.Errorf("GetRoutes() returned %d routes, want %d", len(routes), len(tt.wantRoutes))
-				}
+			if tt.wantErr && err == nil {
+				t.Error("SetPreferredRanges() expected error but got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("SetPreferredRanges() unexpected error = %v", err)
 			}
 		})
 	}
 }
 
-func TestClient_ListClusterRoutes(t *testing.T) {
+func TestClient_SetCipher(t *testing.T) {
 	tests := []struct {
 		name         string
+		cipher       string
+		force        bool
 		serverStatus int
 		serverBody   string
-		wantCount    int
+		wantBody     string
 		wantErr      bool
+		wantErrIs    error
 	}{
 		{
-			name:         "successful listing",
+			name:         "forced change",
+			cipher:       CipherChachaPoly,
+			force:        true,
 			serverStatus: http.StatusOK,
-			serverBody:   `[{"node_id":"node-1","routes":["10.100.0.0/24"]},{"node_id":"node-2","routes":["10.101.0.0/24"]}]`,
-			wantCount:    2,
+			serverBody:   `{"message":"Cipher updated"}`,
+			wantBody:     `{"cipher":"chachapoly","force":true}`,
 			wantErr:      false,
 		},
 		{
-			name:         "no routes in cluster",
-			serverStatus: http.StatusOK,
-			serverBody:   `[]`,
-			wantCount:    0,
-			wantErr:      false,
+			name:         "unconfirmed change",
+			cipher:       CipherChachaPoly,
+			force:        false,
+			serverStatus: http.StatusBadRequest,
+			serverBody:   `{"error":"confirmation_required","message":"Change is disruptive; retry with force to confirm"}`,
+			wantErr:      true,
 		},
 		{
-			name:         "unauthorized",
-			serverStatus: http.StatusUnauthorized,
-			serverBody:   `{"error":"invalid cluster token"}`,
-			wantErr:      true,
+			name:      "unknown cipher",
+			cipher:    "des",
+			force:     true,
+			wantErr:   true,
+			wantErrIs: ErrInvalidCipher,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			called := false
 			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				if r.Method != http.MethodGet {
-					t.Errorf("Expected GET request, got %s", r.Method)
+				called = true
+				if r.Method != http.MethodPut {
+					t.Errorf("Expected PUT request, got %s", r.Method)
 				}
-				if r.Header.Get(HeaderClusterToken) == "" {
-					t.Error("Cluster token header missing")
+				body, _ := io.ReadAll(r.Body)
+				if tt.wantBody != "" && string(body) != tt.wantBody {
+					t.Errorf("Request body = %s, want %s", body, tt.wantBody)
 				}
 				w.WriteHeader(tt.serverStatus)
 				w.Write([]byte(tt.serverBody))
@@ -1363,84 +3089,343 @@ func TestClient_ListClusterRoutes(t *testing.T) {
 				RetryAttempts: 0,
 			})
 
-			ctx := context.Background()
-			routes, err := client.ListClusterRoutes(ctx)
+			err := client.SetCipher(context.Background(), tt.cipher, tt.force)
 
-			if tt.wantErr {
-				if err == nil {
-					t.Error("ListClusterRoutes() expected error but got nil")
-				}
-			} else {
-				if err != nil {
-					t.Errorf("ListClusterRoutes() unexpected error = %v", err)
+			if tt.wantErr && err == nil {
+				t.Error("SetCipher() expected error but got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("SetCipher() unexpected error = %v", err)
+			}
+			if tt.wantErrIs != nil {
+				if !errors.Is(err, tt.wantErrIs) {
+					t.Errorf("SetCipher() error = %v, want %v", err, tt.wantErrIs)
 				}
-				if len(routes) != tt.wantCount {
-					t.Errorf("ListClusterRoutes() returned %d routes, want %d", len(routes), tt.wantCount)
+				if called {
+					t.Error("SetCipher() sent a request for an invalid cipher")
 				}
 			}
 		})
 	}
 }
 
-func TestClient_SetLighthouse(t *testing.T) {
+func TestClient_SetHandshakeConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("Expected PUT request, got %s", r.Method)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"try_interval":"200ms","retries":30}` {
+			t.Errorf("Unexpected request body: %s", body)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message":"Handshake config updated"}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		ClusterToken:  "valid-cluster-token",
+		RetryAttempts: 0,
+	})
+
+	if err := client.SetHandshakeConfig(context.Background(), HandshakeConfig{TryInterval: "200ms", Retries: 30}); err != nil {
+		t.Errorf("SetHandshakeConfig() unexpected error = %v", err)
+	}
+}
+
+func TestClient_SetRoutePolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("Expected PUT request, got %s", r.Method)
+		}
+		if r.URL.Path != "/api/v1/tenants/tenant-123/clusters/cluster-456/topology/route-policy" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"ipv4_only":true,"allow_overlapping_routes":false}` {
+			t.Errorf("Unexpected request body: %s", body)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message":"Route policy updated"}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		ClusterToken:  "valid-cluster-token",
+		RetryAttempts: 0,
+	})
+
+	if err := client.SetRoutePolicy(context.Background(), RoutePolicy{IPv4Only: true}); err != nil {
+		t.Errorf("SetRoutePolicy() unexpected error = %v", err)
+	}
+}
+
+func TestClient_SetBundleRetention(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("Expected PUT request, got %s", r.Method)
+		}
+		if r.URL.Path != "/api/v1/clusters/cluster-456/bundles/retention" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"keep":20}` {
+			t.Errorf("Unexpected request body: %s", body)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message":"Bundle retention updated"}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		ClusterToken:  "valid-cluster-token",
+		RetryAttempts: 0,
+	})
+
+	if err := client.SetBundleRetention(context.Background(), 20); err != nil {
+		t.Errorf("SetBundleRetention() unexpected error = %v", err)
+	}
+}
+
+func TestClient_DiffBundle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Expected GET request, got %s", r.Method)
+		}
+		if r.URL.Path != "/api/v1/tenants/tenant-123/clusters/cluster-456/config/diff" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("from") != "41" || r.URL.Query().Get("to") != "42" {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+		if r.Header.Get(HeaderClusterToken) == "" {
+			t.Error("Cluster token header missing")
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"from_version":41,"to_version":42,"files":[` +
+			`{"name":"config.yml","change":"changed","from_size":812,"to_size":845},` +
+			`{"name":"firewall.yml","change":"added","from_size":0,"to_size":120}]}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		ClusterToken:  "valid-cluster-token",
+		RetryAttempts: 0,
+	})
+
+	diff, err := client.DiffBundle(context.Background(), 41, 42)
+	if err != nil {
+		t.Fatalf("DiffBundle() unexpected error = %v", err)
+	}
+	if diff.FromVersion != 41 || diff.ToVersion != 42 {
+		t.Errorf("Unexpected versions: %d..%d", diff.FromVersion, diff.ToVersion)
+	}
+	if len(diff.Files) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(diff.Files))
+	}
+	if diff.Files[1].Name != "firewall.yml" || diff.Files[1].Change != BundleFileAdded || diff.Files[1].ToSize != 120 {
+		t.Errorf("Unexpected file: %+v", diff.Files[1])
+	}
+}
+
+func TestClient_ValidateConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Expected GET request, got %s", r.Method)
+		}
+		if r.URL.Path != "/api/v1/tenants/tenant-123/clusters/cluster-456/config/validate" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get(HeaderNodeToken) == "" {
+			t.Error("Node token header missing")
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"valid":false,"representative_node_id":"node-1","errors":[{"severity":"error","code":"no_lighthouse","message":"cluster has no lighthouse"}],"warnings":[]}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		NodeToken:     "valid-node-token",
+		RetryAttempts: 0,
+	})
+
+	report, err := client.ValidateConfig(context.Background())
+	if err != nil {
+		t.Fatalf("ValidateConfig() unexpected error = %v", err)
+	}
+	if report.Valid {
+		t.Error("ValidateConfig() expected invalid report")
+	}
+	if len(report.Errors) != 1 || report.Errors[0].Code != "no_lighthouse" {
+		t.Errorf("ValidateConfig() errors = %+v", report.Errors)
+	}
+}
+
+func TestClient_GetConnectivityMatrix(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/tenants/tenant-123/clusters/cluster-456/config/connectivity" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("proto") != "tcp" || r.URL.Query().Get("port") != "443" {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+		if r.Header.Get(HeaderNodeToken) == "" {
+			t.Error("Node token header missing")
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"flow":{"proto":"tcp","port":443},"nodes":[{"node_id":"node-1","name":"a"},{"node_id":"node-2","name":"b"}],` +
+			`"reachable":[[false,true],[false,false]],"isolated":[]}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		NodeToken:     "valid-node-token",
+		RetryAttempts: 0,
+	})
+
+	matrix, err := client.GetConnectivityMatrix(context.Background(), "tcp", 443)
+	if err != nil {
+		t.Fatalf("GetConnectivityMatrix() unexpected error = %v", err)
+	}
+	if len(matrix.Nodes) != 2 || !matrix.Reachable[0][1] || matrix.Reachable[1][0] {
+		t.Errorf("GetConnectivityMatrix() = %+v", matrix)
+	}
+}
+
+func TestClient_WhatIfTopology(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected POST, got %s", r.Method)
+		}
+		if r.URL.Path != "/api/v1/tenants/tenant-123/clusters/cluster-456/config/connectivity/what-if" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		var body struct {
+			Proto   string           `json:"proto"`
+			Changes []TopologyChange `json:"changes"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if body.Proto != "icmp" || len(body.Changes) != 1 || body.Changes[0].Action != TopologyChangeUnsetRelay {
+			t.Errorf("Unexpected request body: %+v", body)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"current":{"relay_isolated":["relay"]},"proposed":{"relay_isolated":["a","b","relay"]},` +
+			`"lost":[{"from":"a","to":"b","relayed":true}],"gained":[],"newly_isolated":[],"newly_relay_isolated":["a","b"]}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		NodeToken:     "valid-node-token",
+		RetryAttempts: 0,
+	})
+
+	result, err := client.WhatIfTopology(context.Background(), "icmp", 0, []TopologyChange{
+		{Action: TopologyChangeUnsetRelay, NodeID: "relay"},
+	})
+	if err != nil {
+		t.Fatalf("WhatIfTopology() unexpected error = %v", err)
+	}
+	if len(result.Lost) != 1 || !result.Lost[0].Relayed || len(result.NewlyRelayIsolated) != 2 {
+		t.Errorf("WhatIfTopology() = %+v", result)
+	}
+}
+
+func TestClient_GetEffectiveConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Expected GET request, got %s", r.Method)
+		}
+		if r.URL.Path != "/api/v1/tenants/tenant-123/clusters/cluster-456/nodes/node-3/effective-config" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get(HeaderNodeToken) == "" {
+			t.Error("Node token header missing")
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"node_id":"node-3","name":"worker","cluster_id":"cluster-456","config_version":7,"routes":[],` +
+			`"config":{"static_host_map":{"node-1":["203.0.113.10:4242"]},"lighthouse":{"am_lighthouse":false,"interval":60,"hosts":["node-1"]},` +
+			`"relay":{"relays":["node-2"],"am_relay":false,"use_relays":true},"tun":{"dev":"nebula1","mtu":1300,"unsafe_routes":[{"route":"10.1.0.0/24","via":"node-2"}]},` +
+			`"firewall":{"outbound":[{"port":"any","proto":"any","host":"any"}],"inbound":[{"port":"any","proto":"icmp","host":"any"}]}}}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		NodeToken:     "valid-node-token",
+		RetryAttempts: 0,
+	})
+
+	effective, err := client.GetEffectiveConfig(context.Background(), "node-3")
+	if err != nil {
+		t.Fatalf("GetEffectiveConfig() unexpected error = %v", err)
+	}
+	if effective.ConfigVersion != 7 || effective.Config.Tun.MTU != 1300 {
+		t.Errorf("GetEffectiveConfig() = %+v", effective)
+	}
+	if len(effective.Config.Lighthouse.Hosts) != 1 || effective.Config.Lighthouse.Hosts[0] != "node-1" {
+		t.Errorf("GetEffectiveConfig() lighthouse hosts = %v", effective.Config.Lighthouse.Hosts)
+	}
+	if len(effective.Config.Tun.UnsafeRoutes) != 1 || effective.Config.Tun.UnsafeRoutes[0].Via != "node-2" {
+		t.Errorf("GetEffectiveConfig() unsafe routes = %+v", effective.Config.Tun.UnsafeRoutes)
+	}
+}
+
+func TestClient_GetTopology(t *testing.T) {
 	tests := []struct {
 		name         string
-		nodeID       string
-		enabled      bool
-		publicIP     string
-		port         int
 		serverStatus int
 		serverBody   string
+		wantStatic   map[string][]string
 		wantErr      bool
 	}{
 		{
-			name:         "enable lighthouse",
-			nodeID:       "node-123",
-			enabled:      true,
-			publicIP:     "203.0.113.10",
-			port:         4242,
+			name:         "successful retrieval",
 			serverStatus: http.StatusOK,
-			serverBody:   `{"message":"lighthouse enabled"}`,
+			serverBody:   `{"lighthouses":[{"node_id":"node-1","name":"lighthouse-1","public_ip":"203.0.113.10","port":4242}],"relays":[{"node_id":"node-2","name":"relay-1"}],"routes":{"node-3":["10.100.0.0/24"]}}`,
 			wantErr:      false,
 		},
 		{
-			name:         "disable lighthouse",
-			nodeID:       "node-123",
-			enabled:      false,
-			publicIP:     "",
-			port:         0,
+			name:         "with static hosts",
 			serverStatus: http.StatusOK,
-			serverBody:   `{"message":"lighthouse disabled"}`,
+			serverBody:   `{"lighthouses":[],"relays":[],"routes":{},"static_host_map":{"10.42.0.50":["198.51.100.50:4242"]}}`,
+			wantStatic:   map[string][]string{"10.42.0.50": {"198.51.100.50:4242"}},
 			wantErr:      false,
 		},
 		{
-			name:         "invalid public IP",
-			nodeID:       "node-123",
-			enabled:      true,
-			publicIP:     "invalid-ip",
-			port:         4242,
-			serverStatus: http.StatusBadRequest,
-			serverBody:   `{"error":"invalid public IP"}`,
-			wantErr:      true,
-		},
-		{
-			name:         "node not found",
-			nodeID:       "node-999",
-			enabled:      true,
-			publicIP:     "203.0.113.10",
-			port:         4242,
-			serverStatus: http.StatusNotFound,
-			serverBody:   `{"error":"node not found"}`,
-			wantErr:      true,
+			name:         "empty topology",
+			serverStatus: http.StatusOK,
+			serverBody:   `{"lighthouses":[],"relays":[],"routes":{}}`,
+			wantErr:      false,
 		},
 		{
 			name:         "unauthorized",
-			nodeID:       "node-123",
-			enabled:      true,
-			publicIP:     "203.0.113.10",
-			port:         4242,
 			serverStatus: http.StatusUnauthorized,
-			serverBody:   `{"error":"invalid cluster token"}`,
+			serverBody:   `{"error":"invalid node token"}`,
 			wantErr:      true,
 		},
 	}
@@ -1448,11 +3433,11 @@ func TestClient_SetLighthouse(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				if r.Method != http.MethodPut {
-					t.Errorf("Expected PUT request, got %s", r.Method)
+				if r.Method != http.MethodGet {
+					t.Errorf("Expected GET request, got %s", r.Method)
 				}
-				if r.Header.Get(HeaderClusterToken) == "" {
-					t.Error("Cluster token header missing")
+				if r.Header.Get(HeaderNodeToken) == "" {
+					t.Error("Node token header missing")
 				}
 				w.WriteHeader(tt.serverStatus)
 				w.Write([]byte(tt.serverBody))
@@ -1463,62 +3448,124 @@ func TestClient_SetLighthouse(t *testing.T) {
 				BaseURLs:      []string{server.URL},
 				TenantID:      "tenant-123",
 				ClusterID:     "cluster-456",
-				ClusterToken:  "valid-cluster-token",
+				NodeToken:     "valid-node-token",
 				RetryAttempts: 0,
 			})
 
 			ctx := context.Background()
-			err := client.SetLighthouse(ctx, tt.nodeID, tt.enabled, tt.publicIP, tt.port)
+			topology, err := client.GetTopology(ctx)
 
-			if tt.wantErr && err == nil {
-				t.Error("SetLighthouse() expected error but got nil")
-			}
-			if !tt.wantErr && err != nil {
-				t.Errorf("SetLighthouse() unexpected error = %v", err)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("GetTopology() expected error but got nil")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("GetTopology() unexpected error = %v", err)
+				}
+				if topology == nil {
+					t.Error("GetTopology() returned nil topology")
+				}
+				if tt.wantStatic != nil && !reflect.DeepEqual(topology.StaticHostMap, tt.wantStatic) {
+					t.Errorf("GetTopology() static hosts = %v, want %v", topology.StaticHostMap, tt.wantStatic)
+				}
 			}
 		})
 	}
 }
 
-func TestClient_SetRelay(t *testing.T) {
+func TestClient_GetTopologyDOT(t *testing.T) {
+	const graph = "digraph \"nebula-cluster-456\" {\n}\n"
+	fail := false
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/clusters/cluster-456/topology.dot" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get(HeaderClusterToken) == "" {
+			t.Error("Cluster token header missing")
+		}
+		if fail {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		w.Write([]byte(graph))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		ClusterToken:  "valid-cluster-token",
+		RetryAttempts: 0,
+	})
+
+	dot, err := client.GetTopologyDOT(context.Background())
+	if err != nil {
+		t.Fatalf("GetTopologyDOT() unexpected error = %v", err)
+	}
+	if dot != graph {
+		t.Errorf("GetTopologyDOT() = %q, want %q", dot, graph)
+	}
+
+	fail = true
+	if _, err := client.GetTopologyDOT(context.Background()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetTopologyDOT() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClient_ExportTopologySnapshot(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Expected GET request, got %s", r.Method)
+		}
+		if r.URL.Path != "/api/v1/tenants/tenant-123/clusters/cluster-456/topology/snapshot" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get(HeaderClusterToken) == "" {
+			t.Error("Cluster token header missing")
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"lighthouses":[],"relays":[{"node_id":"node-2","name":"relay-1"}],"routes":{"node-2":["10.0.1.0/24"]},"routes_by_name":{"relay-1":["10.0.1.0/24"]}}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:      []string{server.URL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		ClusterToken:  "valid-cluster-token",
+		RetryAttempts: 0,
+	})
+
+	snapshot, err := client.ExportTopologySnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("ExportTopologySnapshot() unexpected error = %v", err)
+	}
+	if len(snapshot.RoutesByName["relay-1"]) != 1 {
+		t.Errorf("ExportTopologySnapshot() RoutesByName = %v", snapshot.RoutesByName)
+	}
+}
+
+func TestClient_ImportTopologySnapshot(t *testing.T) {
 	tests := []struct {
 		name         string
-		nodeID       string
-		enabled      bool
 		serverStatus int
 		serverBody   string
 		wantErr      bool
 	}{
 		{
-			name:         "enable relay",
-			nodeID:       "node-123",
-			enabled:      true,
-			serverStatus: http.StatusOK,
-			serverBody:   `{"message":"relay enabled"}`,
-			wantErr:      false,
-		},
-		{
-			name:         "disable relay",
-			nodeID:       "node-123",
-			enabled:      false,
+			name:         "successful import",
 			serverStatus: http.StatusOK,
-			serverBody:   `{"message":"relay disabled"}`,
+			serverBody:   `{"message":"Topology snapshot imported"}`,
 			wantErr:      false,
 		},
 		{
-			name:         "node not found",
-			nodeID:       "node-999",
-			enabled:      true,
-			serverStatus: http.StatusNotFound,
-			serverBody:   `{"error":"node not found"}`,
-			wantErr:      true,
-		},
-		{
-			name:         "unauthorized",
-			nodeID:       "node-123",
-			enabled:      true,
-			serverStatus: http.StatusUnauthorized,
-			serverBody:   `{"error":"invalid cluster token"}`,
+			name:         "missing nodes",
+			serverStatus: http.StatusBadRequest,
+			serverBody:   `{"error":"snapshot_nodes_missing","message":"snapshot references nodes missing from cluster: relay-1"}`,
 			wantErr:      true,
 		},
 	}
@@ -1532,6 +3579,10 @@ func TestClient_SetRelay(t *testing.T) {
 				if r.Header.Get(HeaderClusterToken) == "" {
 					t.Error("Cluster token header missing")
 				}
+				body, _ := io.ReadAll(r.Body)
+				if len(body) == 0 {
+					t.Error("Expected snapshot request body")
+				}
 				w.WriteHeader(tt.serverStatus)
 				w.Write([]byte(tt.serverBody))
 			}))
@@ -1545,84 +3596,49 @@ func TestClient_SetRelay(t *testing.T) {
 				RetryAttempts: 0,
 			})
 
-			ctx := context.Background()
-			err := client.SetRelay(ctx, tt.nodeID, tt.enabled)
+			snapshot := &ClusterTopology{
+				Relays:       []RelayInfo{{Name: "relay-1"}},
+				RoutesByName: map[string][]string{"relay-1": {"10.0.1.0/24"}},
+			}
+			err := client.ImportTopologySnapshot(context.Background(), snapshot)
 
 			if tt.wantErr && err == nil {
-				t.Error("SetRelay() expected error but got nil")
+				t.Error("ImportTopologySnapshot() expected error but got nil")
 			}
 			if !tt.wantErr && err != nil {
-				t.Errorf("SetRelay() unexpected error = %v", err)
+				t.Errorf("ImportTopologySnapshot() unexpected error = %v", err)
 			}
 		})
 	}
 }
 
-func TestClient_GetTopology(t *testing.T) {
-	tests := []struct {
-		name         string
-		serverStatus int
-		serverBody   string
-		wantErr      bool
-	}{
-		{
-			name:         "successful retrieval",
-			serverStatus: http.StatusOK,
-			serverBody:   `{"lighthouses":[{"node_id":"node-1","name":"lighthouse-1","public_ip":"203.0.113.10","port":4242}],"relays":[{"node_id":"node-2","name":"relay-1"}],"routes":{"node-3":["10.100.0.0/24"]}}`,
-			wantErr:      false,
-		},
-		{
-			name:         "empty topology",
-			serverStatus: http.StatusOK,
-			serverBody:   `{"lighthouses":[],"relays":[],"routes":{}}`,
-			wantErr:      false,
-		},
-		{
-			name:         "unauthorized",
-			serverStatus: http.StatusUnauthorized,
-			serverBody:   `{"error":"invalid node token"}`,
-			wantErr:      true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				if r.Method != http.MethodGet {
-					t.Errorf("Expected GET request, got %s", r.Method)
-				}
-				if r.Header.Get(HeaderNodeToken) == "" {
-					t.Error("Node token header missing")
-				}
-				w.WriteHeader(tt.serverStatus)
-				w.Write([]byte(tt.serverBody))
-			}))
-			defer server.Close()
+func TestClient_RotateClusterTokenRevokePrevious(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"token":"new-cluster-token-abc123xyz"}`))
+	}))
+	defer server.Close()
 
-			client, _ := NewClient(ClientConfig{
-				BaseURLs:      []string{server.URL},
-				TenantID:      "tenant-123",
-				ClusterID:     "cluster-456",
-				NodeToken:     "valid-node-token",
-				RetryAttempts: 0,
-			})
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:     []string{server.URL},
+		TenantID:     "tenant-123",
+		ClusterID:    "cluster-456",
+		ClusterToken: "valid-cluster-token",
+	})
 
-			ctx := context.Background()
-			topology, err := client.GetTopology(ctx)
+	if _, err := client.RotateClusterToken(context.Background()); err != nil {
+		t.Fatalf("RotateClusterToken() unexpected error = %v", err)
+	}
+	if query != "" {
+		t.Errorf("query = %q, want none without WithRevokePrevious", query)
+	}
 
-			if tt.wantErr {
-				if err == nil {
-					t.Error("GetTopology() expected error but got nil")
-				}
-			} else {
-				if err != nil {
-					t.Errorf("GetTopology() unexpected error = %v", err)
-				}
-				if topology == nil {
-					t.Error("GetTopology() returned nil topology")
-				}
-			}
-		})
+	if _, err := client.RotateClusterToken(context.Background(), WithRevokePrevious()); err != nil {
+		t.Fatalf("RotateClusterToken() unexpected error = %v", err)
+	}
+	if query != "revoke_previous=true" {
+		t.Errorf("query = %q, want revoke_previous=true", query)
 	}
 }
 
@@ -1900,3 +3916,92 @@ func TestClient_GetClusterReplicas(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_GetClusterReplicas_HealthAndMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"replicas": [
+				{"instance_id": "replica-1", "url": "https://cp1.example.com", "heartbeat_age": 5000000000, "healthy": true,
+				 "metadata": {"version": "0.1.0", "region": "eu-west-1"}},
+				{"instance_id": "replica-2", "url": "https://cp2.example.com", "heartbeat_age": 90000000000, "healthy": false},
+				{"instance_id": "replica-3", "url": "https://cp3.example.com"}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:     []string{server.URL},
+		TenantID:     "tenant-123",
+		ClusterID:    "cluster-456",
+		ClusterToken: "test-cluster-token",
+	})
+
+	replicas, err := client.GetClusterReplicas(context.Background())
+	if err != nil {
+		t.Fatalf("GetClusterReplicas() unexpected error = %v", err)
+	}
+
+	if h := replicas[0].Healthy; h == nil || !*h || replicas[0].HeartbeatAge != 5*time.Second {
+		t.Errorf("replica-1 = %+v, want healthy with a 5s heartbeat age", replicas[0])
+	}
+	if replicas[0].Metadata["version"] != "0.1.0" || replicas[0].Metadata["region"] != "eu-west-1" {
+		t.Errorf("replica-1 metadata = %v, want version and region", replicas[0].Metadata)
+	}
+	if h := replicas[1].Healthy; h == nil || *h || replicas[1].HeartbeatAge != 90*time.Second {
+		t.Errorf("replica-2 = %+v, want unhealthy with a 90s heartbeat age", replicas[1])
+	}
+	// A server that omits the field leaves health unknown
+	if replicas[2].Healthy != nil {
+		t.Errorf("replica-3 = %+v, want unknown health when the server omits the field", replicas[2])
+	}
+}
+
+func TestClient_GetClusterReplicas_MultipleURLs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"replicas": [
+				{
+					"instance_id": "replica-2",
+					"url": "https://cp2.example.com",
+					"is_master": false
+				},
+				{
+					"instance_id": "replica-1",
+					"name": "cp-eu-west-1",
+					"url": "https://cp1.example.com",
+					"urls": ["https://cp1.example.com", "https://cp1.internal:8080"],
+					"is_master": true
+				}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(ClientConfig{
+		BaseURLs:     []string{server.URL},
+		TenantID:     "tenant-123",
+		ClusterID:    "cluster-456",
+		ClusterToken: "test-cluster-token",
+	})
+
+	replicas, err := client.GetClusterReplicas(context.Background())
+	if err != nil {
+		t.Fatalf("GetClusterReplicas() unexpected error = %v", err)
+	}
+
+	if replicas[1].Name != "cp-eu-west-1" || replicas[0].Name != "" {
+		t.Errorf("unexpected replica names: %q, %q", replicas[0].Name, replicas[1].Name)
+	}
+
+	// Replicas from servers that only report url get it as their URL list
+	if got := replicas[0].URLs; len(got) != 1 || got[0] != "https://cp2.example.com" {
+		t.Errorf("replicas[0].URLs = %v, want [https://cp2.example.com]", got)
+	}
+
+	got := ReplicaURLs(replicas)
+	want := []string{"https://cp1.example.com", "https://cp1.internal:8080", "https://cp2.example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReplicaURLs() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,343 @@
+package sdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// bundleDownload tracks the progress of a resumable bundle download.
+type bundleDownload struct {
+	// w receives the bundle bytes
+	w io.Writer
+
+	// hash accumulates the SHA-256 of everything written to w
+	hash hash.Hash
+
+	// version is the version being downloaded, known after the first response
+	version int64
+
+	// checksum is the expected SHA-256 (hex) reported by the control plane
+	checksum string
+
+	// written is the number of bytes written to w so far
+	written int64
+
+	// servedBy lists the base URLs that supplied the bytes written to w
+	servedBy []string
+
+	// skip holds base URLs that served a corrupt bundle and are not asked again
+	skip map[string]bool
+}
+
+// bundleRewinder is a destination that can be emptied to restart a download,
+// such as an *os.File.
+type bundleRewinder interface {
+	io.Seeker
+	Truncate(size int64) error
+}
+
+// errWriteFailed marks errors from the destination writer, which cannot be
+// fixed by retrying the download.
+var errWriteFailed = errors.New("failed to write bundle data")
+
+// errNoInstanceLeft means every instance served a corrupt bundle and is skipped.
+var errNoInstanceLeft = errors.New("no instance left that has not served a corrupt bundle")
+
+// DownloadBundleTo downloads the config bundle into w if a newer version is
+// available, resuming interrupted transfers instead of starting over.
+//
+// Once the first response identifies the version being downloaded, retries
+// request that same immutable version with "Range: bytes=N-", where N is the
+// number of bytes already written to w, so only the missing tail is
+// transferred. The SHA-256 of the complete bundle is verified against the
+// control plane's X-Config-Checksum header before returning.
+//
+// If the bundle is corrupt and w can be rewound (it implements Seek and
+// Truncate, like *os.File), w is emptied and the download is repeated
+// without the instances that served the corrupt data, until an instance
+// serves a good bundle or none is left.
+//
+// This operation requires node token authentication and can be executed on any
+// control plane instance (master or replica).
+//
+// If the server returns 304 Not Modified (currentVersion is up to date),
+// nothing is written and currentVersion is returned.
+//
+// Parameters:
+//   - ctx: Request context for cancellation and timeouts
+//   - currentVersion: The version currently installed on the node
+//   - w: Destination for the bundle bytes (e.g., a temporary file)
+//   - opts: Optional per-request settings, such as WithTimeout
+//
+// Returns:
+//   - int64: The downloaded version number, or currentVersion if no update
+//   - error: a *ChecksumMismatchError (matching ErrChecksumMismatch) naming the
+//     instances that served a corrupt bundle, ErrUnauthorized if node token is
+//     invalid, ErrRateLimited if rate limited, or other errors for network
+//     issues. On error, w may hold a partial bundle and must be discarded.
+func (c *Client) DownloadBundleTo(ctx context.Context, currentVersion int64, w io.Writer, opts ...RequestOption) (int64, error) {
+	ctx, cancel := requestContext(ctx, opts)
+	defer cancel()
+
+	dl := &bundleDownload{w: w, hash: sha256.New(), skip: make(map[string]bool)}
+
+	var mismatch *ChecksumMismatchError
+	for {
+		notModified, err := c.fetchBundle(ctx, currentVersion, dl)
+		if err != nil {
+			// A corrupt bundle is reported over later failures so it can be alerted on
+			if mismatch == nil {
+				return 0, err
+			}
+			if errors.Is(err, errNoInstanceLeft) {
+				return 0, fmt.Errorf("failed to download bundle: %w", mismatch)
+			}
+			return 0, fmt.Errorf("failed to download bundle: %w; retry on other instances failed: %w", mismatch, err)
+		}
+		if notModified {
+			return currentVersion, nil
+		}
+
+		if dl.checksum == "" || hex.EncodeToString(dl.hash.Sum(nil)) == dl.checksum {
+			return dl.version, nil
+		}
+
+		if mismatch == nil {
+			mismatch = &ChecksumMismatchError{Version: dl.version}
+		}
+		mismatch.Instances = append(mismatch.Instances, dl.servedBy...)
+
+		rewinder, ok := w.(bundleRewinder)
+		if !ok {
+			return 0, fmt.Errorf("failed to download bundle: %w", mismatch)
+		}
+		if err := dl.rewind(rewinder); err != nil {
+			return 0, fmt.Errorf("failed to download bundle: %w", err)
+		}
+	}
+}
+
+// fetchBundle runs a resumable download into dl, retrying with backoff.
+// It reports true if the server answered 304 Not Modified.
+func (c *Client) fetchBundle(ctx context.Context, currentVersion int64, dl *bundleDownload) (bool, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.RetryAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return false, ctx.Err()
+			case <-time.After(c.calculateBackoff(attempt - 1)):
+			}
+		}
+
+		resp, err := c.openBundle(ctx, currentVersion, dl)
+		if err != nil {
+			if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrRateLimited) ||
+				errors.Is(err, ErrNoBaseURLs) || errors.Is(err, errNoInstanceLeft) ||
+				errors.Is(err, ErrCircuitOpen) {
+				return false, err
+			}
+			lastErr = err
+			continue
+		}
+
+		// Check for 304 Not Modified
+		if resp.StatusCode == http.StatusNotModified {
+			drainAndCloseBody(resp)
+			return true, nil
+		}
+
+		err = dl.consume(resp)
+		drainAndCloseBody(resp)
+		if err == nil {
+			return false, nil
+		}
+		if errors.Is(err, errWriteFailed) {
+			return false, err
+		}
+		lastErr = err
+		if dl.written > 0 {
+			lastErr = fmt.Errorf("download interrupted after %d bytes: %w", dl.written, err)
+		}
+	}
+
+	if lastErr == nil {
+		lastErr = ErrAllInstancesFailed
+	}
+	return false, fmt.Errorf("failed to download bundle: %w", lastErr)
+}
+
+// rewind empties the destination after a corrupt download and excludes the
+// instances that served it. The version and checksum are kept, so the next
+// attempt fetches the same immutable version.
+func (dl *bundleDownload) rewind(w bundleRewinder) error {
+	if _, err := w.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("%w: %v", errWriteFailed, err)
+	}
+	if err := w.Truncate(0); err != nil {
+		return fmt.Errorf("%w: %v", errWriteFailed, err)
+	}
+
+	for _, baseURL := range dl.servedBy {
+		dl.skip[baseURL] = true
+	}
+	dl.servedBy = nil
+	dl.hash.Reset()
+	dl.written = 0
+	return nil
+}
+
+// openBundle requests the bundle from the first control plane instance that
+// answers. Before the version is known it asks for anything newer than
+// currentVersion; afterwards it asks for the remaining bytes of that version.
+func (c *Client) openBundle(ctx context.Context, currentVersion int64, dl *bundleDownload) (*http.Response, error) {
+	path := fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/config/bundle?current_version=%d",
+		c.TenantID, c.ClusterID, currentVersion)
+	if dl.version > 0 {
+		path = fmt.Sprintf("/api/v1/tenants/%s/clusters/%s/config/bundle?version=%d",
+			c.TenantID, c.ClusterID, dl.version)
+	}
+
+	urls := c.buildURLList(false)
+	if len(urls) == 0 {
+		return nil, ErrNoBaseURLs
+	}
+
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	if len(dl.skip) >= len(urls) {
+		return nil, errNoInstanceLeft
+	}
+
+	var lastErr error
+	answered := false
+
+	for _, baseURL := range urls {
+		if dl.skip[baseURL] {
+			continue
+		}
+		fullURL := fmt.Sprintf("%s%s", baseURL, path)
+
+		// Create request
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to create request: %w", err)
+			continue
+		}
+
+		// Add authentication headers
+		if err := c.addAuthHeaders(req, AuthTypeNode); err != nil {
+			return nil, err
+		}
+
+		// Set headers
+		req.Header.Set("Accept", "application/octet-stream")
+		if dl.version == 0 && currentVersion > 0 {
+			req.Header.Set("If-None-Match", c.versionETag(currentVersion))
+		}
+		if dl.version > 0 && dl.written > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", dl.written))
+			req.Header.Set("If-Range", c.versionETag(dl.version))
+		}
+
+		// Perform request with retry
+		resp, err := c.doRequestWithRetry(ctx, req, baseURL)
+		if err != nil {
+			lastErr = err
+			if baseURL == c.getMasterURL() {
+				c.clearMasterCache()
+			}
+			continue
+		}
+		answered = true
+		c.breaker.success()
+
+		switch resp.StatusCode {
+		case http.StatusOK, http.StatusPartialContent, http.StatusNotModified:
+			if n := len(dl.servedBy); n == 0 || dl.servedBy[n-1] != baseURL {
+				dl.servedBy = append(dl.servedBy, baseURL)
+			}
+			return resp, nil
+		case http.StatusUnauthorized:
+			drainAndCloseBody(resp)
+			return nil, ErrUnauthorized
+		case http.StatusTooManyRequests:
+			drainAndCloseBody(resp)
+			return nil, ErrRateLimited
+		default:
+			lastErr = c.parseErrorResponse(resp)
+		}
+	}
+
+	if !answered {
+		c.breaker.failure(ctx)
+	}
+	if lastErr == nil {
+		lastErr = ErrAllInstancesFailed
+	}
+	return nil, lastErr
+}
+
+// consume copies a bundle response into the destination, skipping bytes that
+// were already written by an earlier attempt.
+func (dl *bundleDownload) consume(resp *http.Response) error {
+	// Identify the version on the first response; later ones must match
+	versionHeader := resp.Header.Get("X-Config-Version")
+	if dl.version == 0 {
+		if versionHeader == "" {
+			return fmt.Errorf("missing X-Config-Version header in response")
+		}
+		version, err := parseVersion(versionHeader)
+		if err != nil {
+			return fmt.Errorf("invalid version header: %w", err)
+		}
+		dl.version = version
+		dl.checksum = strings.ToLower(resp.Header.Get("X-Config-Checksum"))
+	} else if versionHeader != "" && versionHeader != fmt.Sprintf("%d", dl.version) {
+		return fmt.Errorf("version changed during download: got %s, want %d", versionHeader, dl.version)
+	}
+
+	body := io.Reader(resp.Body)
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		prefix := fmt.Sprintf("bytes %d-", dl.written)
+		if !strings.HasPrefix(resp.Header.Get("Content-Range"), prefix) {
+			return fmt.Errorf("unexpected Content-Range %q, want %q", resp.Header.Get("Content-Range"), prefix)
+		}
+	case http.StatusOK:
+		// The server sent the whole bundle; skip what we already have
+		if dl.written > 0 {
+			if _, err := io.CopyN(io.Discard, body, dl.written); err != nil {
+				return fmt.Errorf("failed to skip already downloaded bytes: %w", err)
+			}
+		}
+	}
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := body.Read(buf)
+		if n > 0 {
+			if _, err := dl.w.Write(buf[:n]); err != nil {
+				return fmt.Errorf("%w: %v", errWriteFailed, err)
+			}
+			dl.hash.Write(buf[:n])
+			dl.written += int64(n)
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read bundle data: %w", readErr)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// bundleTestServer serves data as version 5, aborting the first response
+// after interruptAt bytes when interruptAt > 0.
+func bundleTestServer(t *testing.T, data []byte, checksum string, interruptAt int, requests *atomic.Int32, ranges *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		*ranges = append(*ranges, r.Header.Get("Range"))
+
+		w.Header().Set("X-Config-Version", "5")
+		w.Header().Set("X-Config-Checksum", checksum)
+		w.Header().Set("ETag", `"cluster-456-v5"`)
+
+		if n == 1 && interruptAt > 0 {
+			if got := r.URL.Query().Get("current_version"); got != "2" {
+				t.Errorf("Expected current_version=2 on first request, got %q", got)
+			}
+			w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+			w.WriteHeader(http.StatusOK)
+			w.Write(data[:interruptAt])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler) // drop the connection mid-body
+		}
+
+		if n > 1 {
+			if got := r.URL.Query().Get("version"); got != "5" {
+				t.Errorf("Expected resume to request version=5, got %q", got)
+			}
+			if got := r.Header.Get("If-Range"); got != `"cluster-456-v5"` {
+				t.Errorf("Expected If-Range \"cluster-456-v5\", got %q", got)
+			}
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}))
+}
+
+func newDownloadTestClient(t *testing.T, baseURL string) *Client {
+	client, err := NewClient(ClientConfig{
+		BaseURLs:      []string{baseURL},
+		TenantID:      "tenant-123",
+		ClusterID:     "cluster-456",
+		NodeToken:     "valid-node-token",
+		RetryAttempts: 2,
+		RetryWaitMin:  time.Millisecond,
+		RetryWaitMax:  time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client
+}
+
+func TestClient_DownloadBundleTo_Resume(t *testing.T) {
+	data := bytes.Repeat([]byte("nebula-bundle-"), 10000)
+	sum := sha256.Sum256(data)
+
+	var requests atomic.Int32
+	var ranges []string
+	server := bundleTestServer(t, data, hex.EncodeToString(sum[:]), 50000, &requests, &ranges)
+	defer server.Close()
+
+	client := newDownloadTestClient(t, server.URL)
+
+	var out bytes.Buffer
+	version, err := client.DownloadBundleTo(context.Background(), 2, &out)
+	if err != nil {
+		t.Fatalf("DownloadBundleTo() unexpected error = %v", err)
+	}
+	if version != 5 {
+		t.Errorf("DownloadBundleTo() version = %d, want 5", version)
+	}
+	if !bytes.Equal(out.Bytes(), data) {
+		t.Errorf("DownloadBundleTo() wrote %d bytes, want the %d-byte bundle", out.Len(), len(data))
+	}
+	if requests.Load() != 2 {
+		t.Errorf("Expected 2 requests (initial + resume), got %d", requests.Load())
+	}
+	if len(ranges) != 2 || ranges[0] != "" || ranges[1] != "bytes=50000-" {
+		t.Errorf("Unexpected Range headers: %q", ranges)
+	}
+}
+
+func TestClient_DownloadBundleTo_ChecksumMismatch(t *testing.T) {
+	data := []byte("bundle-bytes")
+	wrong := sha256.Sum256([]byte("other-bytes"))
+
+	var requests atomic.Int32
+	var ranges []string
+	server := bundleTestServer(t, data, hex.EncodeToString(wrong[:]), 0, &requests, &ranges)
+	defer server.Close()
+
+	client := newDownloadTestClient(t, server.URL)
+
+	var out bytes.Buffer
+	_, err := client.DownloadBundleTo(context.Background(), 2, &out)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("DownloadBundleTo() error = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestClient_DownloadBundleTo_NotModified(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("If-None-Match"); got != `"cluster-456-v7"` {
+			t.Errorf("If-None-Match = %q, want \"cluster-456-v7\"", got)
+		}
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer server.Close()
+
+	client := newDownloadTestClient(t, server.URL)
+
+	var out strings.Builder
+	version, err := client.DownloadBundleTo(context.Background(), 7, &out)
+	if err != nil {
+		t.Fatalf("DownloadBundleTo() unexpected error = %v", err)
+	}
+	if version != 7 || out.Len() != 0 {
+		t.Errorf("DownloadBundleTo() = %d with %d bytes written, want 7 with none", version, out.Len())
+	}
+}
+
+// corruptibleBundleServer serves data as version 5 with its checksum; when
+// corrupt is set the body is altered, as a replica with a bad disk would.
+func corruptibleBundleServer(data []byte, corrupt bool, requests *atomic.Int32) *httptest.Server {
+	sum := sha256.Sum256(data)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("X-Config-Version", "5")
+		w.Header().Set("X-Config-Checksum", hex.EncodeToString(sum[:]))
+		body := data
+		if corrupt {
+			body = bytes.ToUpper(data)
+		}
+		w.Write(body)
+	}))
+}
+
+func newMultiInstanceClient(t *testing.T, baseURLs ...string) *Client {
+	client := newDownloadTestClient(t, baseURLs[0])
+	client.BaseURLs = baseURLs
+	return client
+}
+
+func TestClient_DownloadBundle_ChecksumFailover(t *testing.T) {
+	data := []byte("nebula-bundle-data")
+
+	var corruptRequests, goodRequests atomic.Int32
+	corrupt := corruptibleBundleServer(data, true, &corruptRequests)
+	defer corrupt.Close()
+	good := corruptibleBundleServer(data, false, &goodRequests)
+	defer good.Close()
+
+	client := newMultiInstanceClient(t, corrupt.URL, good.URL)
+
+	got, version, err := client.DownloadBundle(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("DownloadBundle() unexpected error = %v", err)
+	}
+	if version != 5 || !bytes.Equal(got, data) {
+		t.Errorf("DownloadBundle() = %q v%d, want the good bundle v5", got, version)
+	}
+	if corruptRequests.Load() != 1 || goodRequests.Load() != 1 {
+		t.Errorf("Expected one request per instance, got corrupt=%d good=%d", corruptRequests.Load(), goodRequests.Load())
+	}
+}
+
+func TestClient_DownloadBundle_AllInstancesCorrupt(t *testing.T) {
+	data := []byte("nebula-bundle-data")
+
+	var requests atomic.Int32
+	first := corruptibleBundleServer(data, true, &requests)
+	defer first.Close()
+	second := corruptibleBundleServer(data, true, &requests)
+	defer second.Close()
+
+	client := newMultiInstanceClient(t, first.URL, second.URL)
+
+	_, _, err := client.DownloadBundle(context.Background(), 2)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("DownloadBundle() error = %v, want ErrChecksumMismatch", err)
+	}
+	var mismatch *ChecksumMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("DownloadBundle() error = %v, want *ChecksumMismatchError", err)
+	}
+	if mismatch.Version != 5 || len(mismatch.Instances) != 2 ||
+		mismatch.Instances[0] != first.URL || mismatch.Instances[1] != second.URL {
+		t.Errorf("ChecksumMismatchError = %+v, want both instances for version 5", mismatch)
+	}
+}
+
+func TestClient_DownloadBundleTo_ChecksumFailover(t *testing.T) {
+	data := bytes.Repeat([]byte("nebula-bundle-"), 1000)
+
+	var corruptRequests, goodRequests atomic.Int32
+	corrupt := corruptibleBundleServer(data, true, &corruptRequests)
+	defer corrupt.Close()
+	good := corruptibleBundleServer(data, false, &goodRequests)
+	defer good.Close()
+
+	client := newMultiInstanceClient(t, corrupt.URL, good.URL)
+
+	// A file can be rewound, so the corrupt download is replaced
+	file, err := os.CreateTemp(t.TempDir(), "bundle-*.tar.gz")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer file.Close()
+
+	version, err := client.DownloadBundleTo(context.Background(), 2, file)
+	if err != nil {
+		t.Fatalf("DownloadBundleTo() unexpected error = %v", err)
+	}
+	written, _ := os.ReadFile(file.Name())
+	if version != 5 || !bytes.Equal(written, data) {
+		t.Errorf("DownloadBundleTo() = v%d with %d bytes, want the good %d-byte bundle", version, len(written), len(data))
+	}
+	if corruptRequests.Load() != 1 || goodRequests.Load() != 1 {
+		t.Errorf("Expected one request per instance, got corrupt=%d good=%d", corruptRequests.Load(), goodRequests.Load())
+	}
+
+	// A buffer cannot be rewound, so the corrupt instance is reported
+	var out bytes.Buffer
+	_, err = client.DownloadBundleTo(context.Background(), 2, &out)
+	var mismatch *ChecksumMismatchError
+	if !errors.As(err, &mismatch) || len(mismatch.Instances) != 1 || mismatch.Instances[0] != corrupt.URL {
+		t.Errorf("DownloadBundleTo() error = %v, want mismatch from %s", err, corrupt.URL)
+	}
+}
@@ -1,6 +1,11 @@
 package sdk
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
 
 // Common SDK errors that clients can check for specific error handling.
 var (
@@ -13,12 +18,21 @@ var (
 	// ErrAllInstancesFailed indicates all control plane instances are unreachable.
 	ErrAllInstancesFailed = errors.New("all control plane instances failed")
 
+	// ErrCircuitOpen indicates a request was not sent because the circuit
+	// breaker is open after repeated rounds in which no control plane instance
+	// answered (see ClientConfig.CircuitThreshold). It is always wrapped
+	// together with ErrAllInstancesFailed.
+	ErrCircuitOpen = errors.New("circuit breaker open")
+
 	// ErrNoMasterFound indicates no master instance could be discovered.
 	ErrNoMasterFound = errors.New("no master instance found")
 
 	// ErrUnauthorized indicates the provided credentials are invalid.
 	ErrUnauthorized = errors.New("unauthorized: invalid credentials")
 
+	// ErrForbidden indicates the credentials are valid but lack the required privileges.
+	ErrForbidden = errors.New("forbidden: insufficient privileges")
+
 	// ErrNotFound indicates the requested resource does not exist.
 	ErrNotFound = errors.New("resource not found")
 
@@ -28,12 +42,184 @@ var (
 	// ErrServerError indicates an internal server error occurred.
 	ErrServerError = errors.New("internal server error")
 
+	// ErrServiceUnavailable indicates the control plane instance cannot serve the
+	// request right now (e.g., a read-only replica or a concurrent upload); retry later.
+	ErrServiceUnavailable = errors.New("service unavailable")
+
 	// ErrBadRequest indicates the request was malformed or invalid.
+	// Server-side validation failures match both ErrBadRequest and ErrValidation.
 	ErrBadRequest = errors.New("bad request")
 
+	// ErrValidation indicates the control plane rejected the request parameters or payload.
+	ErrValidation = errors.New("validation failed")
+
 	// ErrConflict indicates the request conflicts with existing state.
 	ErrConflict = errors.New("conflict with existing resource")
 
 	// ErrMissingAuth indicates required authentication credentials were not provided.
 	ErrMissingAuth = errors.New("missing authentication credentials")
+
+	// ErrChecksumMismatch indicates a downloaded bundle does not match the
+	// checksum reported by the control plane (e.g., a truncated or corrupted transfer).
+	ErrChecksumMismatch = errors.New("bundle checksum mismatch")
+
+	// ErrInvalidCipher indicates a cipher other than CipherAES or CipherChachaPoly was requested.
+	ErrInvalidCipher = errors.New("invalid cipher")
 )
+
+// APIError is an error response from the control plane.
+//
+// It unwraps to the SDK sentinel for its error code, so callers can use
+// errors.Is(err, ErrNotFound) and similar checks regardless of which operation
+// failed, and errors.As to read the code, message and request ID.
+//
+// Server error codes map to sentinels as follows; unknown codes fall back
+// to the HTTP status:
+//
+//	Code                                   Status  Sentinel
+//	not_found                              404     ErrNotFound
+//	unauthorized                           401     ErrUnauthorized
+//	forbidden                              403     ErrForbidden
+//	conflict                               409     ErrConflict
+//	invalid_request, invalid_format,       400     ErrValidation (and ErrBadRequest)
+//	invalid_version, invalid_yaml,
+//	invalid_effective_at,
+//	invalid_content_type,
+//	missing_required_file,
+//	snapshot_nodes_missing,
+//	confirmation_required
+//	payload_too_large, bundle_too_large    413     ErrValidation (and ErrBadRequest)
+//	rate_limit_exceeded                    429     ErrRateLimited
+//	internal_error                         500     ErrServerError
+//	service_unavailable, upload_conflict,  503     ErrServiceUnavailable
+//	not_master, master_check_failed,
+//	unhealthy
+type APIError struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+
+	// Code is the machine-readable error code (e.g., "not_found"); empty if the
+	// response had no JSON body.
+	Code string
+
+	// Message is the human-readable message from the server.
+	Message string
+
+	// RequestID identifies the request in server logs, if provided.
+	RequestID string
+}
+
+// errorCodeSentinels maps control plane error codes to SDK sentinels.
+var errorCodeSentinels = map[string]error{
+	"not_found":              ErrNotFound,
+	"unauthorized":           ErrUnauthorized,
+	"forbidden":              ErrForbidden,
+	"conflict":               ErrConflict,
+	"invalid_request":        ErrValidation,
+	"invalid_format":         ErrValidation,
+	"invalid_version":        ErrValidation,
+	"invalid_yaml":           ErrValidation,
+	"invalid_effective_at":   ErrValidation,
+	"invalid_content_type":   ErrValidation,
+	"missing_required_file":  ErrValidation,
+	"snapshot_nodes_missing": ErrValidation,
+	"confirmation_required":  ErrValidation,
+	"payload_too_large":      ErrValidation,
+	"bundle_too_large":       ErrValidation,
+	"rate_limit_exceeded":    ErrRateLimited,
+	"internal_error":         ErrServerError,
+	"service_unavailable":    ErrServiceUnavailable,
+	"upload_conflict":        ErrServiceUnavailable,
+	"not_master":             ErrServiceUnavailable,
+	"master_check_failed":    ErrServiceUnavailable,
+	"unhealthy":              ErrServiceUnavailable,
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	switch {
+	case e.Code != "" && e.Message != "":
+		return fmt.Sprintf("API error %d %s: %s", e.StatusCode, e.Code, e.Message)
+	case e.Code != "":
+		return fmt.Sprintf("API error %d %s", e.StatusCode, e.Code)
+	default:
+		return fmt.Sprintf("request failed with status %d", e.StatusCode)
+	}
+}
+
+// Unwrap returns the sentinel for the error's code, falling back to its HTTP
+// status. It returns nil if neither is recognized.
+func (e *APIError) Unwrap() error {
+	if sentinel, ok := errorCodeSentinels[e.Code]; ok {
+		return sentinel
+	}
+
+	switch {
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return ErrForbidden
+	case e.StatusCode == http.StatusConflict:
+		return ErrConflict
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusServiceUnavailable:
+		return ErrServiceUnavailable
+	case e.StatusCode >= 500:
+		return ErrServerError
+	case e.StatusCode >= 400:
+		return ErrValidation
+	default:
+		return nil
+	}
+}
+
+// Is reports whether target is ErrBadRequest for a validation failure, keeping
+// ErrBadRequest checks working alongside ErrValidation.
+func (e *APIError) Is(target error) bool {
+	return target == ErrBadRequest && e.Unwrap() == ErrValidation
+}
+
+// BatchError reports which item of a batch request the control plane rejected.
+// The whole batch was rolled back. It unwraps to the *APIError for that item, so
+// errors.Is(err, ErrConflict) and similar checks keep working.
+type BatchError struct {
+	// Index is the position of the rejected item in the request (0-based).
+	Index int
+
+	// Err is the error for that item.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch item %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the error for the rejected item.
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// ChecksumMismatchError reports the control plane instances that served a
+// bundle not matching its X-Config-Checksum header, so a corrupt replica can
+// be identified and alerted on. It unwraps to ErrChecksumMismatch.
+type ChecksumMismatchError struct {
+	// Version is the bundle version that failed verification.
+	Version int64
+
+	// Instances are the base URLs that served corrupt data, in the order tried.
+	Instances []string
+}
+
+// Error implements the error interface.
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("%v: version %d from %s", ErrChecksumMismatch, e.Version, strings.Join(e.Instances, ", "))
+}
+
+// Unwrap returns ErrChecksumMismatch.
+func (e *ChecksumMismatchError) Unwrap() error {
+	return ErrChecksumMismatch
+}
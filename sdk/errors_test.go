@@ -0,0 +1,134 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIError_Mapping(t *testing.T) {
+	tests := []struct {
+		code   string
+		status int
+		want   error
+	}{
+		{"not_found", http.StatusNotFound, ErrNotFound},
+		{"unauthorized", http.StatusUnauthorized, ErrUnauthorized},
+		{"forbidden", http.StatusForbidden, ErrForbidden},
+		{"conflict", http.StatusConflict, ErrConflict},
+		{"invalid_request", http.StatusBadRequest, ErrValidation},
+		{"invalid_format", http.StatusBadRequest, ErrValidation},
+		{"invalid_version", http.StatusBadRequest, ErrValidation},
+		{"invalid_yaml", http.StatusBadRequest, ErrValidation},
+		{"invalid_effective_at", http.StatusBadRequest, ErrValidation},
+		{"invalid_content_type", http.StatusBadRequest, ErrValidation},
+		{"missing_required_file", http.StatusBadRequest, ErrValidation},
+		{"snapshot_nodes_missing", http.StatusBadRequest, ErrValidation},
+		{"confirmation_required", http.StatusBadRequest, ErrValidation},
+		{"payload_too_large", http.StatusRequestEntityTooLarge, ErrValidation},
+		{"bundle_too_large", http.StatusRequestEntityTooLarge, ErrValidation},
+		{"rate_limit_exceeded", http.StatusTooManyRequests, ErrRateLimited},
+		{"internal_error", http.StatusInternalServerError, ErrServerError},
+		{"service_unavailable", http.StatusServiceUnavailable, ErrServiceUnavailable},
+		{"upload_conflict", http.StatusServiceUnavailable, ErrServiceUnavailable},
+		{"not_master", http.StatusServiceUnavailable, ErrServiceUnavailable},
+		{"master_check_failed", http.StatusServiceUnavailable, ErrServiceUnavailable},
+		{"unhealthy", http.StatusServiceUnavailable, ErrServiceUnavailable},
+
+		// Unknown or missing codes fall back to the status
+		{"", http.StatusNotFound, ErrNotFound},
+		{"", http.StatusConflict, ErrConflict},
+		{"some_new_code", http.StatusUnprocessableEntity, ErrValidation},
+		{"", http.StatusBadGateway, ErrServerError},
+		{"", http.StatusServiceUnavailable, ErrServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code+"/"+http.StatusText(tt.status), func(t *testing.T) {
+			err := error(&APIError{StatusCode: tt.status, Code: tt.code})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.want)
+			}
+			if got := errors.Is(err, ErrBadRequest); got != (tt.want == ErrValidation) {
+				t.Errorf("errors.Is(%v, ErrBadRequest) = %v", err, got)
+			}
+		})
+	}
+}
+
+func TestAPIError_FromOperations(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		call   func(c *Client) error
+		want   error
+	}{
+		{
+			name:   "delete missing node",
+			status: http.StatusNotFound,
+			body:   `{"error":"not_found","message":"Resource not found","request_id":"req-1"}`,
+			call:   func(c *Client) error { return c.DeleteNode(context.Background(), "node-999") },
+			want:   ErrNotFound,
+		},
+		{
+			name:   "create duplicate node",
+			status: http.StatusConflict,
+			body:   `{"error":"conflict","message":"Resource already exists","request_id":"req-1"}`,
+			call: func(c *Client) error {
+				_, err := c.CreateNode(context.Background(), "dup", false, 1300)
+				return err
+			},
+			want: ErrConflict,
+		},
+		{
+			name:   "upload invalid bundle",
+			status: http.StatusBadRequest,
+			body:   `{"error":"invalid_format","message":"Bundle must be a gzip-compressed tar archive (.tar.gz)","request_id":"req-1"}`,
+			call: func(c *Client) error {
+				_, err := c.UploadBundle(context.Background(), []byte("not a bundle"))
+				return err
+			},
+			want: ErrValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(ClientConfig{
+				BaseURLs:      []string{server.URL},
+				TenantID:      "tenant-123",
+				ClusterID:     "cluster-456",
+				NodeID:        "node-123",
+				NodeToken:     "node-token",
+				ClusterToken:  "cluster-token",
+				RetryAttempts: 0,
+			})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			err = tt.call(client)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("errors.Is(%v, %v) = false", err, tt.want)
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("errors.As(%v, *APIError) = false", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.RequestID != "req-1" || apiErr.Message == "" {
+				t.Errorf("Unexpected APIError: %+v", apiErr)
+			}
+		})
+	}
+}
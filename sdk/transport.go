@@ -2,29 +2,193 @@ package sdk
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"math/rand"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 )
 
+// HeaderIdempotencyKey is the header carrying a request's idempotency key.
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// errNotRetried marks a failure that was not retried, or failed over to another
+// instance, because the request may already have been processed.
+var errNotRetried = errors.New("request may have reached the server and is not safe to retry")
+
+// idempotencyKeyContextKey is the context key for WithIdempotencyKey.
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey returns a context that sends key as the Idempotency-Key
+// header on requests made with it.
+//
+// POST requests are normally not retried once they may have reached the
+// server, since repeating them could, for example, create a node twice. A
+// keyed POST is retried like GET, PUT and DELETE requests: the control plane
+// processes the first request with a key and answers repeats from the same
+// credentials with the stored response for 24 hours, or rejects them with 422
+// if the request differs. Reuse a key only for retries of the same operation.
+// Keys are remembered by the master that processed the request, so a retry
+// after a master failover is processed again.
+//
+// Parameters:
+//   - ctx: Parent context
+//   - key: Unique key for one logical operation
+//
+// Returns:
+//   - context.Context: Context carrying the key
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// setIdempotencyKey copies the context's idempotency key, if any, onto req.
+func setIdempotencyKey(ctx context.Context, req *http.Request) {
+	if key, ok := ctx.Value(idempotencyKeyContextKey{}).(string); ok && key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+}
+
+// RequestOption customizes a single SDK method call.
+type RequestOption interface {
+	applyRequest(o *requestOptions)
+}
+
+// requestOptions collects the RequestOptions passed to one call.
+type requestOptions struct {
+	timeout        time.Duration
+	filters        []NodeFilter
+	revokePrevious bool
+	canaryNodeIDs  []string
+	effectiveAt    time.Time
+}
+
+// timeoutOption is the RequestOption returned by WithTimeout.
+type timeoutOption time.Duration
+
+func (t timeoutOption) applyRequest(o *requestOptions) {
+	o.timeout = time.Duration(t)
+}
+
+// WithTimeout bounds a single SDK call, including its retries, failover to
+// other instances and reading the response, so callers need not derive and
+// cancel a context of their own. This lets a daemon poll with a short timeout
+// while uploads on the same client get a long one.
+//
+// The effective timeout is the shorter of d and the deadline of the context
+// passed to the call; ClientConfig.Timeout still limits each HTTP attempt.
+// A zero or negative d leaves the call unbounded.
+//
+// Parameters:
+//   - d: Maximum duration of the call
+//
+// Returns:
+//   - RequestOption: Option to pass to any Client method
+func WithTimeout(d time.Duration) RequestOption {
+	return timeoutOption(d)
+}
+
+// collectRequestOptions applies opts in order.
+func collectRequestOptions(opts []RequestOption) requestOptions {
+	var o requestOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt.applyRequest(&o)
+		}
+	}
+	return o
+}
+
+// context derives the context a call runs with. The returned cancel func must
+// be called once the response has been read.
+func (o requestOptions) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, o.timeout)
+}
+
+// requestContext applies opts to ctx (see requestOptions.context).
+func requestContext(ctx context.Context, opts []RequestOption) (context.Context, context.CancelFunc) {
+	return collectRequestOptions(opts).context(ctx)
+}
+
+// isIdempotent reports whether repeating req has the same effect as sending it
+// once: safe and idempotent methods, or any request with an idempotency key.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(HeaderIdempotencyKey) != ""
+}
+
+// notSent reports whether a transport error happened before the request could
+// reach the server: a failed DNS lookup or a connection that was never
+// established (refused, unreachable, or timed out while dialing).
+func notSent(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// canRetry reports whether a failed attempt of req may be repeated, on the same
+// instance or another one. err is the transport error, or nil for a 5xx response.
+func canRetry(req *http.Request, err error) bool {
+	if isIdempotent(req) {
+		return true
+	}
+	return err != nil && notSent(err)
+}
+
 // doRequestWithRetry performs an HTTP request with exponential backoff retry logic.
-// It will retry on network errors and 5xx server errors.
-func (c *Client) doRequestWithRetry(ctx context.Context, req *http.Request) (*http.Response, error) {
+//
+// Idempotent requests (see isIdempotent) are retried on network errors and 5xx
+// server errors. Other requests are only retried when the connection failed
+// before anything was sent; otherwise the error is wrapped with errNotRetried,
+// or a 5xx response is returned as is, so callers do not fail over either.
+func (c *Client) doRequestWithRetry(ctx context.Context, req *http.Request, baseURL string) (*http.Response, error) {
 	var resp *http.Response
 	var err error
 
 	for attempt := 0; attempt <= c.RetryAttempts; attempt++ {
+		// Earlier attempts consumed the body; start each retry from a fresh copy
+		if attempt > 0 && req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %w", bodyErr)
+			}
+			req.Body = body
+		}
+
+		// Sign each attempt separately; a repeated nonce is rejected as a replay
+		if signErr := c.signRequest(req); signErr != nil {
+			return nil, signErr
+		}
+
 		// Perform the request
-		resp, err = c.HTTPClient.Do(req.WithContext(ctx))
+		resp, err = c.do(req.WithContext(ctx), baseURL, attempt+1)
 
 		// If successful (2xx or 4xx), return immediately
 		if err == nil && resp.StatusCode < 500 {
 			return resp, nil
 		}
 
+		if !canRetry(req, err) {
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", errNotRetried, err)
+			}
+			return resp, nil
+		}
+
 		// If this was the last attempt, return the error
 		if attempt == c.RetryAttempts {
 			if resp != nil {
@@ -48,10 +212,6 @@ func (c *Client) doRequestWithRetry(ctx context.Context, req *http.Request) (*ht
 		case <-time.After(backoff):
 			// Continue to next attempt
 		}
-
-		// If the request had a body, we need to recreate it for the retry
-		// (The original body reader may have been consumed)
-		// This is handled by the caller through GetBody
 	}
 
 	// Return the last error
@@ -67,6 +227,118 @@ func (c *Client) doRequestWithRetry(ctx context.Context, req *http.Request) (*ht
 	return resp, err
 }
 
+// RequestInfo describes one HTTP attempt made by the client, for
+// ClientConfig.OnRequestComplete.
+type RequestInfo struct {
+	// Method is the HTTP method.
+	Method string
+
+	// Path is the API path, without the base URL and query string
+	// (e.g., "/api/v1/tenants/.../config/version").
+	Path string
+
+	// BaseURL is the control plane URL the attempt was sent to, with any
+	// userinfo redacted.
+	BaseURL string
+
+	// StatusCode is the response status, or 0 if no response was received.
+	StatusCode int
+
+	// Attempt counts the attempts of the request on BaseURL, starting at 1.
+	// It restarts at 1 when the request fails over to another instance.
+	Attempt int
+
+	// Duration is how long the attempt took, up to the response headers.
+	Duration time.Duration
+
+	// Err is the transport error, if any. It is nil for any HTTP response,
+	// including 4xx and 5xx ones.
+	Err error
+}
+
+// do performs a single HTTP attempt against baseURL, invoking the OnRequest,
+// OnResponse and OnRequestComplete hooks around it. Hooks receive URLs with
+// any userinfo redacted and never see request headers, so token values are
+// not exposed.
+func (c *Client) do(req *http.Request, baseURL string, attempt int) (*http.Response, error) {
+	c.setCustomHeaders(req)
+
+	url := req.URL.Redacted()
+	if c.OnRequest != nil {
+		c.OnRequest(req.Method, url)
+	}
+
+	start := time.Now()
+	resp, err := c.HTTPClient.Do(req)
+	dur := time.Since(start)
+
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	if c.OnResponse != nil {
+		c.OnResponse(req.Method, url, status, dur, err)
+	}
+	if c.OnRequestComplete != nil {
+		c.OnRequestComplete(RequestInfo{
+			Method:     req.Method,
+			Path:       requestPath(req.URL, baseURL),
+			BaseURL:    redactURL(baseURL),
+			StatusCode: status,
+			Attempt:    attempt,
+			Duration:   dur,
+			Err:        err,
+		})
+	}
+
+	return resp, err
+}
+
+// requestPath returns the part of u's path below baseURL.
+func requestPath(u *url.URL, baseURL string) string {
+	if base, err := url.Parse(baseURL); err == nil {
+		if path, ok := strings.CutPrefix(u.Path, strings.TrimSuffix(base.Path, "/")); ok {
+			return path
+		}
+	}
+	return u.Path
+}
+
+// redactURL returns rawURL with any password replaced, like url.URL.Redacted.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Redacted()
+}
+
+// lockedRand is a rand.Rand that is safe for concurrent use.
+type lockedRand struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// Float64 returns a pseudo-random number in [0.0,1.0).
+func (r *lockedRand) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rand.Float64()
+}
+
+// setCustomHeaders adds the configured headers and User-Agent to req, without
+// replacing headers the request already carries.
+func (c *Client) setCustomHeaders(req *http.Request) {
+	for name, values := range c.headers {
+		if _, ok := req.Header[name]; !ok {
+			req.Header[name] = append([]string(nil), values...)
+		}
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+}
+
 // calculateBackoff calculates the backoff duration for a retry attempt.
 // It uses exponential backoff with jitter to avoid thundering herd.
 func (c *Client) calculateBackoff(attempt int) time.Duration {
@@ -78,10 +350,18 @@ func (c *Client) calculateBackoff(attempt int) time.Duration {
 		backoff = float64(c.RetryWaitMax)
 	}
 
-	// Add jitter (random value between 0 and backoff)
-	jitter := rand.Float64() * backoff
+	if c.BackoffJitter <= 0 {
+		return time.Duration(backoff)
+	}
+
+	// Subtract jitter (random share of up to BackoffJitter of the backoff)
+	random := rand.Float64
+	if c.rng != nil {
+		random = c.rng.Float64
+	}
+	jitter := random() * c.BackoffJitter * backoff
 
-	return time.Duration(jitter)
+	return time.Duration(backoff - jitter)
 }
 
 // drainAndCloseBody reads and closes the response body to ensure connection reuse.
@@ -1,6 +1,9 @@
 package sdk
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // NodeCredentials contains the credentials returned after creating a node.
 // These credentials must be stored securely and provided to the node daemon.
@@ -15,6 +18,25 @@ type NodeCredentials struct {
 	NebulaIP string `json:"nebula_ip"`
 }
 
+// String formats the credentials with the node token redacted.
+func (c NodeCredentials) String() string {
+	type plain NodeCredentials // see redactToken
+	c.NodeToken = redactToken(c.NodeToken)
+	return fmt.Sprintf("%+v", plain(c))
+}
+
+// NodeCreateRequest describes one node to create with Client.CreateNodes.
+type NodeCreateRequest struct {
+	// Name is the human-readable node name (1-255 characters).
+	Name string `json:"name"`
+
+	// IsAdmin indicates whether the node should have administrative privileges.
+	IsAdmin bool `json:"is_admin"`
+
+	// MTU is the Maximum Transmission Unit for the node (0 = server default).
+	MTU int `json:"mtu,omitempty"`
+}
+
 // NodeSummary represents a node in list responses.
 type NodeSummary struct {
 	// ID is the unique identifier for the node.
@@ -32,8 +54,47 @@ type NodeSummary struct {
 	// MTU is the Maximum Transmission Unit for the node.
 	MTU int `json:"mtu"`
 
+	// IsLighthouse indicates if this node acts as a lighthouse.
+	IsLighthouse bool `json:"is_lighthouse"`
+
+	// IsRelay indicates if this node acts as a relay.
+	IsRelay bool `json:"is_relay"`
+
+	// Routes is the list of CIDR subnets this node advertises.
+	Routes []string `json:"routes,omitempty"`
+
+	// PreferredRelays is the ordered list of relay node IDs this node prefers.
+	PreferredRelays []string `json:"preferred_relays,omitempty"`
+
 	// CreatedAt is the node creation timestamp.
 	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt is the timestamp of the node's last modification.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NodeIdentity describes the node that owns the client's node token.
+type NodeIdentity struct {
+	// TenantID is the tenant the node belongs to.
+	TenantID string `json:"tenant_id"`
+
+	// ClusterID is the cluster the node belongs to.
+	ClusterID string `json:"cluster_id"`
+
+	// NodeID is the unique identifier for the node.
+	NodeID string `json:"node_id"`
+
+	// Name is the human-readable node name.
+	Name string `json:"name"`
+
+	// IsAdmin indicates if this node has administrative privileges.
+	IsAdmin bool `json:"is_admin"`
+
+	// IsLighthouse indicates if this node acts as a lighthouse.
+	IsLighthouse bool `json:"is_lighthouse"`
+
+	// IsRelay indicates if this node acts as a relay.
+	IsRelay bool `json:"is_relay"`
 }
 
 // NodeRoutes represents routes advertised by a node.
@@ -79,6 +140,562 @@ type ClusterTopology struct {
 
 	// Routes maps node IDs to their advertised routes.
 	Routes map[string][]string `json:"routes"`
+
+	// RoutesByName maps node names to their advertised routes.
+	// Only populated in topology snapshots.
+	RoutesByName map[string][]string `json:"routes_by_name,omitempty"`
+
+	// RelayStats summarises relay usage in the cluster.
+	RelayStats RelayStats `json:"relay_stats"`
+
+	// StaticHostMap maps Nebula IPs of fixed-endpoint peers to their
+	// public "ip:port" endpoints.
+	StaticHostMap map[string][]string `json:"static_host_map,omitempty"`
+
+	// PreferredRanges lists networks nodes try first when connecting to peers.
+	PreferredRanges []string `json:"preferred_ranges,omitempty"`
+
+	// Cipher is the Nebula cipher used by the cluster.
+	Cipher string `json:"cipher,omitempty"`
+
+	// Handshake holds handshake tuning (nil = Nebula defaults).
+	Handshake *HandshakeConfig `json:"handshake,omitempty"`
+}
+
+// Supported Nebula ciphers. Every node in a cluster must use the same one.
+const (
+	CipherAES        = "aes"
+	CipherChachaPoly = "chachapoly"
+)
+
+// HandshakeConfig contains a cluster's Nebula handshake tuning.
+// Zero values mean the Nebula default is used.
+type HandshakeConfig struct {
+	// TryInterval is the delay between handshake attempts (e.g., "100ms").
+	TryInterval string `json:"try_interval,omitempty"`
+
+	// Retries is the number of handshake attempts before giving up.
+	Retries int `json:"retries,omitempty"`
+
+	// TriggerBuffer is the size of the pending handshake trigger queue.
+	TriggerBuffer int `json:"trigger_buffer,omitempty"`
+}
+
+// RoutePolicy holds the rules node routes must follow in a cluster.
+type RoutePolicy struct {
+	// IPv4Only rejects IPv6 routes, for clusters whose nodes cannot route them.
+	IPv4Only bool `json:"ipv4_only"`
+
+	// AllowOverlappingRoutes lets nodes advertise routes that overlap another
+	// node's routes. Nebula cannot pick a single gateway for such routes.
+	AllowOverlappingRoutes bool `json:"allow_overlapping_routes"`
+}
+
+// RelayStats contains relay statistics for a cluster.
+type RelayStats struct {
+	// RelayCount is the number of relay nodes.
+	RelayCount int `json:"relay_count"`
+
+	// NodeCount is the total number of nodes in the cluster.
+	NodeCount int `json:"node_count"`
+
+	// RelayFraction is the share of nodes acting as relays (0-1).
+	RelayFraction float64 `json:"relay_fraction"`
+
+	// MaxRelayHops is the cluster's relay hop limit (0 disables relaying).
+	MaxRelayHops int `json:"max_relay_hops"`
+}
+
+// ClusterStats contains summary counts for a cluster.
+type ClusterStats struct {
+	// ClusterID is the cluster's unique identifier.
+	ClusterID string `json:"cluster_id"`
+
+	// NodeCount is the total number of nodes in the cluster.
+	NodeCount int `json:"node_count"`
+
+	// AdminCount is the number of admin nodes.
+	AdminCount int `json:"admin_count"`
+
+	// LighthouseCount is the number of lighthouse nodes.
+	LighthouseCount int `json:"lighthouse_count"`
+
+	// RelayCount is the number of relay nodes.
+	RelayCount int `json:"relay_count"`
+
+	// RouteCount is the total number of routes advertised by all nodes.
+	RouteCount int `json:"route_count"`
+
+	// ConfigVersion is the cluster's current config version.
+	ConfigVersion int64 `json:"config_version"`
+
+	// LastChangeAt is when the config version last changed (nil if never).
+	LastChangeAt *time.Time `json:"last_change_at,omitempty"`
+}
+
+// ConfigChange is one entry in a cluster's config history.
+type ConfigChange struct {
+	// Version is the config version the change produced.
+	Version int64 `json:"version"`
+
+	// Type is what triggered the change, e.g. "node_created",
+	// "routes_updated" or "bundle_uploaded".
+	Type string `json:"type"`
+
+	// Actor is the ID of the node that made the change (empty if unknown).
+	Actor string `json:"actor,omitempty"`
+
+	// Detail identifies the subject of the change, such as the affected
+	// node ID or the changed setting (optional).
+	Detail string `json:"detail,omitempty"`
+
+	// CreatedAt is when the change was made.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TenantStats contains usage totals across a tenant's clusters.
+type TenantStats struct {
+	// TenantID is the tenant's unique identifier.
+	TenantID string `json:"tenant_id"`
+
+	// ClusterCount is the number of clusters owned by the tenant.
+	ClusterCount int `json:"cluster_count"`
+
+	// NodeCount is the total number of nodes across all clusters.
+	NodeCount int `json:"node_count"`
+
+	// BundleCount is the number of stored config bundle versions.
+	BundleCount int `json:"bundle_count"`
+
+	// BundleStorageBytes is the total size of all stored config bundles.
+	BundleStorageBytes int64 `json:"bundle_storage_bytes"`
+}
+
+// Tenant describes an organization that owns clusters.
+type Tenant struct {
+	// ID is the tenant's unique identifier.
+	ID string `json:"id"`
+
+	// Name is the tenant name, unique across all tenants.
+	Name string `json:"name"`
+
+	// CreatedAt is when the tenant was created.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TenantList is a page of tenants.
+type TenantList struct {
+	// Tenants is the page of tenants, ordered by name.
+	Tenants []Tenant `json:"tenants"`
+
+	// Total is the total number of tenants.
+	Total int `json:"total"`
+
+	// Page is the page number.
+	Page int `json:"page,omitempty"`
+
+	// PerPage is the number of tenants per page.
+	PerPage int `json:"per_page,omitempty"`
+}
+
+// Cluster describes a cluster. Secrets such as the cluster token are never
+// included.
+type Cluster struct {
+	// ID is the cluster's unique identifier.
+	ID string `json:"id"`
+
+	// TenantID is the owning tenant's unique identifier.
+	TenantID string `json:"tenant_id"`
+
+	// Name is the cluster name, unique within the tenant.
+	Name string `json:"name"`
+
+	// ProvideLighthouse indicates whether the control plane acts as a lighthouse.
+	ProvideLighthouse bool `json:"provide_lighthouse"`
+
+	// LighthousePort is the UDP port used for lighthouse traffic.
+	LighthousePort int `json:"lighthouse_port,omitempty"`
+
+	// MaxRelayHops limits how many relays traffic may traverse.
+	MaxRelayHops int `json:"max_relay_hops"`
+
+	// Cipher is the Nebula cipher used by every node in the cluster.
+	Cipher string `json:"cipher"`
+
+	// RequireSignedRequests indicates whether node requests must be signed.
+	RequireSignedRequests bool `json:"require_signed_requests"`
+
+	// ConfigVersion is the cluster's current config version.
+	ConfigVersion int64 `json:"config_version"`
+
+	// CreatedAt is when the cluster was created.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ClusterList is a page of a tenant's clusters.
+type ClusterList struct {
+	// Clusters is the page of clusters, oldest first.
+	Clusters []Cluster `json:"clusters"`
+
+	// Total is the tenant's total number of clusters.
+	Total int `json:"total"`
+
+	// Page is the page number.
+	Page int `json:"page,omitempty"`
+
+	// PerPage is the number of clusters per page.
+	PerPage int `json:"per_page,omitempty"`
+}
+
+// CanaryRollout describes a bundle version available only to a subset of nodes.
+type CanaryRollout struct {
+	// ClusterID is the cluster's unique identifier.
+	ClusterID string `json:"cluster_id"`
+
+	// Version is the bundle version under canary.
+	Version int64 `json:"version"`
+
+	// NodeIDs are the nodes that receive Version; all others stay on the
+	// previous version until the canary is promoted.
+	NodeIDs []string `json:"node_ids"`
+
+	// CreatedAt is when the canary was defined.
+	CreatedAt time.Time `json:"created_at"`
+
+	// StableVersion is the version served to every node outside the canary.
+	StableVersion int64 `json:"stable_version"`
+
+	// RolledBackAt is set when the canary was rolled back after its nodes
+	// failed health checks; every node is then served StableVersion.
+	RolledBackAt *time.Time `json:"rolled_back_at,omitempty"`
+
+	// RollbackReason describes why the canary was rolled back.
+	RollbackReason string `json:"rollback_reason,omitempty"`
+}
+
+// Change types of a file in a BundleDiff.
+const (
+	// BundleFileAdded is a file only present in the newer version.
+	BundleFileAdded = "added"
+
+	// BundleFileRemoved is a file only present in the older version.
+	BundleFileRemoved = "removed"
+
+	// BundleFileChanged is a file whose content differs between versions.
+	BundleFileChanged = "changed"
+)
+
+// BundleDiff lists the files that differ between two bundle versions.
+type BundleDiff struct {
+	// FromVersion is the version compared against.
+	FromVersion int64 `json:"from_version"`
+
+	// ToVersion is the version whose changes are reported.
+	ToVersion int64 `json:"to_version"`
+
+	// Files are the added, removed and changed files, sorted by name.
+	// Unchanged files are omitted.
+	Files []BundleFileChange `json:"files"`
+}
+
+// BundleFileChange is a file that differs between two bundle versions.
+type BundleFileChange struct {
+	// Name is the file's path within the bundle (e.g., "config.yml").
+	Name string `json:"name"`
+
+	// Change is BundleFileAdded, BundleFileRemoved or BundleFileChanged.
+	Change string `json:"change"`
+
+	// FromSize is the file's size in bytes in FromVersion (0 if added).
+	FromSize int64 `json:"from_size"`
+
+	// ToSize is the file's size in bytes in ToVersion (0 if removed).
+	ToSize int64 `json:"to_size"`
+}
+
+// Health statuses reported by nodes in heartbeats.
+const (
+	// HealthStatusOK reports that the node's current config is working.
+	HealthStatusOK = "ok"
+
+	// HealthStatusError reports that the node failed to apply or run its config.
+	HealthStatusError = "error"
+)
+
+// LighthouseDNSConfig contains a cluster's lighthouse DNS settings.
+type LighthouseDNSConfig struct {
+	// Enabled indicates whether lighthouses serve DNS for node names.
+	Enabled bool `json:"enabled"`
+
+	// Host is the DNS listen address (server default: 0.0.0.0).
+	Host string `json:"host,omitempty"`
+
+	// Port is the DNS listen port (server default: 53).
+	// Must differ from the Nebula UDP port.
+	Port int `json:"port,omitempty"`
+}
+
+// ConfigValidationReport is the result of validating a cluster's generated config.
+type ConfigValidationReport struct {
+	// Valid is true when the report contains no errors.
+	Valid bool `json:"valid"`
+
+	// RepresentativeNodeID is the node the server generated a config for.
+	RepresentativeNodeID string `json:"representative_node_id,omitempty"`
+
+	// Errors lists problems that break generated configs.
+	Errors []ValidationIssue `json:"errors"`
+
+	// Warnings lists problems that may degrade connectivity.
+	Warnings []ValidationIssue `json:"warnings"`
+}
+
+// ValidationIssue is a single finding in a ConfigValidationReport.
+type ValidationIssue struct {
+	// Severity is "error" or "warning".
+	Severity string `json:"severity"`
+
+	// Code is a stable machine-readable identifier (e.g., "no_lighthouse",
+	// "overlapping_routes", "missing_ca").
+	Code string `json:"code"`
+
+	// Message is a human-readable description.
+	Message string `json:"message"`
+
+	// NodeIDs lists the nodes the issue relates to, if any.
+	NodeIDs []string `json:"node_ids,omitempty"`
+}
+
+// ConnectivityFlow is the kind of traffic a connectivity matrix evaluates.
+type ConnectivityFlow struct {
+	// Proto is the protocol: "tcp", "udp" or "icmp".
+	Proto string `json:"proto"`
+
+	// Port is the destination port (ignored for icmp).
+	Port int `json:"port"`
+}
+
+// ConnectivityNode identifies a row and column of a ConnectivityMatrix.
+type ConnectivityNode struct {
+	// NodeID is the node's unique identifier.
+	NodeID string `json:"node_id"`
+
+	// Name is the node's name.
+	Name string `json:"name"`
+}
+
+// ConnectivityMatrix reports which nodes of a cluster should be able to reach
+// which for one kind of traffic, according to their generated configs.
+type ConnectivityMatrix struct {
+	// Flow is the traffic evaluated.
+	Flow ConnectivityFlow `json:"flow"`
+
+	// Nodes lists the cluster's nodes, sorted by name.
+	Nodes []ConnectivityNode `json:"nodes"`
+
+	// Reachable[i][j] reports whether Nodes[i] can reach Nodes[j].
+	Reachable [][]bool `json:"reachable"`
+
+	// Relayed[i][j] reports whether Nodes[i] can still reach Nodes[j] through
+	// a relay when a direct connection between them is impossible.
+	Relayed [][]bool `json:"relayed"`
+
+	// Isolated lists the IDs of nodes that can neither reach nor be reached
+	// by any other node.
+	Isolated []string `json:"isolated"`
+
+	// RelayIsolated lists the IDs of nodes that have no relayed path to or
+	// from any other node.
+	RelayIsolated []string `json:"relay_isolated"`
+}
+
+// Topology change actions accepted by Client.WhatIfTopology.
+const (
+	// TopologyChangeSetLighthouse makes a node a lighthouse (PublicIP required).
+	TopologyChangeSetLighthouse = "set_lighthouse"
+
+	// TopologyChangeUnsetLighthouse demotes a lighthouse.
+	TopologyChangeUnsetLighthouse = "unset_lighthouse"
+
+	// TopologyChangeSetRelay makes a node a relay.
+	TopologyChangeSetRelay = "set_relay"
+
+	// TopologyChangeUnsetRelay demotes a relay.
+	TopologyChangeUnsetRelay = "unset_relay"
+
+	// TopologyChangeSetMaxRelayHops changes the cluster's relay hop limit.
+	TopologyChangeSetMaxRelayHops = "set_max_relay_hops"
+)
+
+// TopologyChange is one proposed change to a cluster's topology.
+type TopologyChange struct {
+	// Action is one of the TopologyChange* actions.
+	Action string `json:"action"`
+
+	// NodeID is the node the change applies to (all actions but set_max_relay_hops).
+	NodeID string `json:"node_id,omitempty"`
+
+	// PublicIP is the lighthouse's public IP address (set_lighthouse).
+	PublicIP string `json:"public_ip,omitempty"`
+
+	// Port is the lighthouse's UDP port (set_lighthouse, 0 = cluster default).
+	Port int `json:"port,omitempty"`
+
+	// MaxRelayHops is the new relay hop limit (set_max_relay_hops).
+	MaxRelayHops int `json:"max_relay_hops,omitempty"`
+}
+
+// ConnectivityPair is an ordered pair of nodes in a connectivity diff.
+type ConnectivityPair struct {
+	// From is the ID of the node initiating traffic.
+	From string `json:"from"`
+
+	// To is the ID of the node receiving traffic.
+	To string `json:"to"`
+
+	// Relayed is true when the pair is about reachability through a relay
+	// rather than direct reachability.
+	Relayed bool `json:"relayed"`
+}
+
+// TopologyWhatIf is the simulated effect of topology changes on connectivity.
+type TopologyWhatIf struct {
+	// Current is the connectivity of the cluster as stored.
+	Current *ConnectivityMatrix `json:"current"`
+
+	// Proposed is the connectivity after the proposed changes.
+	Proposed *ConnectivityMatrix `json:"proposed"`
+
+	// Lost lists the pairs reachable now but not after the changes.
+	Lost []ConnectivityPair `json:"lost"`
+
+	// Gained lists the pairs reachable after the changes but not now.
+	Gained []ConnectivityPair `json:"gained"`
+
+	// NewlyIsolated lists the nodes the changes isolate.
+	NewlyIsolated []string `json:"newly_isolated"`
+
+	// NewlyRelayIsolated lists the nodes the changes leave without any
+	// relayed path.
+	NewlyRelayIsolated []string `json:"newly_relay_isolated"`
+}
+
+// EffectiveConfig is a node's fully-resolved Nebula configuration as computed
+// by the server's config generator.
+//
+// The server does not track overlay IPs (they come from each node's
+// certificate in the config bundle), so peers are referenced by node ID
+// wherever Nebula would use an overlay IP: lighthouse hosts, relays, unsafe
+// route gateways, and the lighthouse entries of the static host map.
+type EffectiveConfig struct {
+	// NodeID is the node the config was generated for.
+	NodeID string `json:"node_id"`
+
+	// Name is the node's name.
+	Name string `json:"name"`
+
+	// ClusterID is the node's cluster.
+	ClusterID string `json:"cluster_id"`
+
+	// ConfigVersion is the cluster config version the config reflects.
+	ConfigVersion int64 `json:"config_version"`
+
+	// Routes lists the networks the node itself advertises.
+	Routes []string `json:"routes"`
+
+	// Config is the generated Nebula configuration.
+	Config NebulaConfig `json:"config"`
+}
+
+// NebulaConfig mirrors the structure of a Nebula config.yml.
+type NebulaConfig struct {
+	PKI             NebulaPKIConfig        `json:"pki"`
+	StaticHostMap   map[string][]string    `json:"static_host_map"`
+	Lighthouse      NebulaLighthouseConfig `json:"lighthouse"`
+	Listen          NebulaListenConfig     `json:"listen"`
+	Punchy          NebulaPunchyConfig     `json:"punchy"`
+	PreferredRanges []string               `json:"preferred_ranges,omitempty"`
+	Cipher          string                 `json:"cipher,omitempty"`
+	Handshakes      *HandshakeConfig       `json:"handshakes,omitempty"`
+	Relay           NebulaRelayConfig      `json:"relay"`
+	Tun             NebulaTunConfig        `json:"tun"`
+	Logging         NebulaLoggingConfig    `json:"logging"`
+	Firewall        NebulaFirewallConfig   `json:"firewall"`
+}
+
+// NebulaPKIConfig holds the PKI file paths of a Nebula config.
+type NebulaPKIConfig struct {
+	CA   string `json:"ca"`
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+	CRL  string `json:"crl,omitempty"`
+}
+
+// NebulaLighthouseConfig holds the lighthouse block of a Nebula config.
+type NebulaLighthouseConfig struct {
+	AmLighthouse bool             `json:"am_lighthouse"`
+	ServeDNS     bool             `json:"serve_dns,omitempty"`
+	DNS          *NebulaDNSConfig `json:"dns,omitempty"`
+	Interval     int              `json:"interval"`
+	Hosts        []string         `json:"hosts"`
+}
+
+// NebulaDNSConfig holds the lighthouse DNS listener of a Nebula config.
+type NebulaDNSConfig struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+// NebulaListenConfig holds the listen block of a Nebula config.
+type NebulaListenConfig struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+// NebulaPunchyConfig holds the NAT traversal block of a Nebula config.
+type NebulaPunchyConfig struct {
+	Punch   bool `json:"punch"`
+	Respond bool `json:"respond"`
+}
+
+// NebulaRelayConfig holds the relay block of a Nebula config.
+type NebulaRelayConfig struct {
+	Relays    []string `json:"relays,omitempty"`
+	AmRelay   bool     `json:"am_relay"`
+	UseRelays bool     `json:"use_relays"`
+}
+
+// NebulaTunConfig holds the TUN device block of a Nebula config.
+type NebulaTunConfig struct {
+	Disabled     bool                `json:"disabled"`
+	Dev          string              `json:"dev"`
+	MTU          int                 `json:"mtu"`
+	UnsafeRoutes []NebulaUnsafeRoute `json:"unsafe_routes,omitempty"`
+}
+
+// NebulaUnsafeRoute routes a non-overlay network through a Nebula peer.
+type NebulaUnsafeRoute struct {
+	Route string `json:"route"`
+	Via   string `json:"via"`
+}
+
+// NebulaLoggingConfig holds the logging block of a Nebula config.
+type NebulaLoggingConfig struct {
+	Level  string `json:"level"`
+	Format string `json:"format"`
+}
+
+// NebulaFirewallConfig holds the firewall rules of a Nebula config.
+type NebulaFirewallConfig struct {
+	Outbound []NebulaFirewallRule `json:"outbound"`
+	Inbound  []NebulaFirewallRule `json:"inbound"`
+}
+
+// NebulaFirewallRule is a single Nebula firewall rule.
+type NebulaFirewallRule struct {
+	Port  interface{} `json:"port"`  // "any" or a port number
+	Proto string      `json:"proto"` // "any", "tcp", "udp", "icmp"
+	Host  string      `json:"host"`  // "any" or CIDR
 }
 
 // ReplicaInfo represents a control plane replica instance.
@@ -86,14 +703,48 @@ type ReplicaInfo struct {
 	// InstanceID is the unique identifier for this replica.
 	InstanceID string `json:"instance_id"`
 
-	// URL is the public URL for this replica.
+	// Name is the replica's human-friendly name (e.g., "cp-eu-west-1"), if set.
+	Name string `json:"name,omitempty"`
+
+	// URL is the primary public URL for this replica.
 	URL string `json:"url"`
 
+	// URLs lists every public URL the replica is reachable at, primary first.
+	// Split-horizon deployments expose the same replica under different names
+	// to internal and external clients. Servers that predate this field omit it.
+	URLs []string `json:"urls,omitempty"`
+
 	// IsMaster indicates if this instance is currently the master.
 	IsMaster bool `json:"is_master"`
 
 	// LastHeartbeat is the last time this replica sent a heartbeat.
 	LastHeartbeat time.Time `json:"last_heartbeat"`
+
+	// HeartbeatAge is how long before the response LastHeartbeat was, as
+	// measured by the control plane clock (nanoseconds on the wire), so it is
+	// not skewed by the local clock.
+	HeartbeatAge time.Duration `json:"heartbeat_age"`
+
+	// Healthy indicates the replica's heartbeat is within the control plane's
+	// configured staleness threshold. Nil if the control plane did not report
+	// it; callers should then judge staleness from LastHeartbeat.
+	Healthy *bool `json:"healthy,omitempty"`
+
+	// Metadata is what the replica reported about itself, such as its
+	// software version ("version") and region ("region"). Nil if none.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// AllURLs returns every URL the replica can be reached at, primary first.
+// It falls back to URL when the server did not report a URL list.
+func (r ReplicaInfo) AllURLs() []string {
+	if len(r.URLs) > 0 {
+		return r.URLs
+	}
+	if r.URL == "" {
+		return nil
+	}
+	return []string{r.URL}
 }
 
 // MasterStatusResponse represents the response from /health/master endpoint.
@@ -104,6 +755,9 @@ type MasterStatusResponse struct {
 	// InstanceID is the unique identifier for the queried instance.
 	InstanceID string `json:"instance_id"`
 
+	// InstanceName is the queried instance's human-friendly name, if set.
+	InstanceName string `json:"instance_name,omitempty"`
+
 	// MasterURL is the URL of the master instance (if this instance is not master).
 	MasterURL string `json:"master_url,omitempty"`
 }
@@ -133,4 +787,86 @@ type APIResponse struct {
 type VersionResponse struct {
 	// Version is the current config bundle version number.
 	Version int64 `json:"version"`
+
+	// RolledBack is set after a canary rollback, when Version may be lower
+	// than the version canary nodes are running and they should go back to it.
+	RolledBack bool `json:"rolled_back,omitempty"`
+}
+
+// BundleUploadResult describes an uploaded config bundle.
+type BundleUploadResult struct {
+	// Version is the version number assigned to the bundle.
+	Version int64 `json:"version"`
+
+	// CompressedSize is the size of the uploaded tar.gz in bytes. The server
+	// rejects bundles larger than 10 MiB.
+	CompressedSize int64 `json:"compressed_size"`
+
+	// UncompressedSize is the total size of the files in the bundle in bytes.
+	UncompressedSize int64 `json:"uncompressed_size"`
+
+	// FileCount is the number of files in the bundle.
+	FileCount int `json:"file_count"`
+
+	// Checksum is the SHA-256 of the bundle (hex). Downloads are verified
+	// against it.
+	Checksum string `json:"checksum"`
+}
+
+// SignedBundle is a downloaded config bundle together with the signature the
+// uploader attached to it.
+type SignedBundle struct {
+	// Data is the bundle as a tar.gz archive, or nil if no update is available.
+	Data []byte
+
+	// Version is the bundle's version number.
+	Version int64
+
+	// Signature is the detached Ed25519 signature of Data, or nil if the
+	// bundle was uploaded unsigned.
+	Signature []byte
+}
+
+// MaxVersionBatchSize is the maximum number of clusters GetLatestVersions
+// accepts in one call.
+const MaxVersionBatchSize = 100
+
+// ClusterRef identifies a cluster in a batched version check, along with the
+// credentials used to authenticate for it.
+type ClusterRef struct {
+	// TenantID is the unique identifier for the tenant owning the cluster.
+	TenantID string `json:"tenant_id"`
+
+	// ClusterID is the unique identifier for the cluster.
+	ClusterID string `json:"cluster_id"`
+
+	// NodeToken is a node token for a node in the cluster.
+	NodeToken string `json:"node_token,omitempty"`
+
+	// ClusterToken is the cluster token, used when NodeToken is empty.
+	ClusterToken string `json:"cluster_token,omitempty"`
+}
+
+// ClusterVersion is the result for one cluster in a batched version check.
+type ClusterVersion struct {
+	// TenantID is the unique identifier for the tenant owning the cluster.
+	TenantID string `json:"tenant_id"`
+
+	// ClusterID is the unique identifier for the cluster.
+	ClusterID string `json:"cluster_id"`
+
+	// Version is the current config bundle version number (zero when Error is set).
+	Version int64 `json:"version"`
+
+	// Error is the error code if the version could not be returned
+	// (e.g., "unauthorized" for bad credentials or an unknown cluster, or
+	// "signed_requests_required" for a cluster that requires signed requests,
+	// whose version must be checked with GetLatestVersion).
+	Error string `json:"error,omitempty"`
+}
+
+// VersionsResponse contains the results of a batched version check.
+type VersionsResponse struct {
+	// Versions holds one result per requested cluster, in request order.
+	Versions []ClusterVersion `json:"versions"`
 }
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"nebulagc.io/server/internal/service"
+)
+
+// ExecuteMigrateBundles moves config bundle data between bundle stores.
+func ExecuteMigrateBundles(args []string) error {
+	fs := flag.NewFlagSet("migrate-bundles", flag.ExitOnError)
+	dbPath := fs.String("db", getEnv("NEBULAGC_DB_PATH", "./nebula.db"), "Path to SQLite database")
+	from := fs.String("from", service.BundleStoreDB, "Store currently holding bundle data (db, fs or cas)")
+	to := fs.String("to", service.BundleStoreFS, "Store to move bundle data to (db, fs or cas)")
+	bundleDir := fs.String("bundle-dir", getEnv("NEBULAGC_BUNDLE_DIR", ""), "Directory of the fs bundle store, or of the cas store's blobs")
+	verbose := fs.Bool("verbose", false, "Enable verbose output")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *from == *to {
+		return fmt.Errorf("--from and --to must be different stores")
+	}
+
+	// Setup logger
+	logConfig := zap.NewDevelopmentConfig()
+	if !*verbose {
+		logConfig.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
+	}
+	logger, err := logConfig.Build()
+	if err != nil {
+		return fmt.Errorf("failed to create logger: %w", err)
+	}
+	defer logger.Sync()
+
+	// Open database
+	db, err := OpenDatabase(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	source, err := service.OpenBundleStore(*from, db, *bundleDir)
+	if err != nil {
+		return err
+	}
+	destination, err := service.OpenBundleStore(*to, db, *bundleDir)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("migrating bundles",
+		zap.String("from", *from),
+		zap.String("to", *to),
+		zap.String("bundle_dir", *bundleDir),
+	)
+
+	result, err := service.MigrateBundles(context.Background(), db, source, destination, logger)
+	fmt.Printf("Moved %d bundle(s), skipped %d already moved\n", result.Moved, result.Skipped)
+	if result.Collected > 0 {
+		fmt.Printf("Removed %d unreferenced blob(s) from the %s store\n", result.Collected, *from)
+	}
+	if err != nil {
+		return fmt.Errorf("migration stopped: %w (run again to resume)", err)
+	}
+
+	if *from == service.BundleStoreDB {
+		fmt.Println("Run 'nebulagc-server util compact-db' to reclaim the space freed in the database")
+	}
+	fmt.Printf("Restart the control plane instances with -bundle-store=%s\n", *to)
+
+	return nil
+}
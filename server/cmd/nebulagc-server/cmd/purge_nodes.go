@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"nebulagc.io/server/internal/service"
+)
+
+// ExecutePurgeNodes permanently removes nodes that were deleted longer ago
+// than the retention period. Purged nodes can no longer be restored.
+func ExecutePurgeNodes(args []string) error {
+	fs := flag.NewFlagSet("purge-nodes", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "Preview deletions without modifying database")
+	olderThan := fs.Duration("older-than", 30*24*time.Hour, "Purge nodes deleted longer ago than this duration")
+	dbPath := fs.String("db", getEnv("NEBULAGC_DB_PATH", "./nebula.db"), "Path to SQLite database")
+	verbose := fs.Bool("verbose", false, "Enable verbose output")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Setup logger
+	logConfig := zap.NewDevelopmentConfig()
+	if !*verbose {
+		logConfig.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
+	}
+	logger, err := logConfig.Build()
+	if err != nil {
+		return fmt.Errorf("failed to create logger: %w", err)
+	}
+	defer logger.Sync()
+
+	// Open database
+	db, err := OpenDatabase(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	logger.Info("purging deleted nodes",
+		zap.Duration("older_than", *olderThan),
+		zap.Bool("dry_run", *dryRun),
+	)
+
+	if *dryRun {
+		var count int
+		cutoff := time.Now().Add(-*olderThan).Unix()
+		if err := db.QueryRow(`
+			SELECT COUNT(*) FROM nodes WHERE deleted_at IS NOT NULL AND deleted_at <= ?
+		`, cutoff).Scan(&count); err != nil {
+			return fmt.Errorf("failed to count deleted nodes: %w", err)
+		}
+		fmt.Printf("\n[DRY RUN] Would purge %d deleted node(s)\n", count)
+		return nil
+	}
+
+	// The HMAC secret is only needed for token operations, not purging
+	purged, err := service.NewNodeService(db, logger, "").PurgeDeleted(*olderThan)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✓ Successfully purged %d deleted node(s)\n", purged)
+	return nil
+}
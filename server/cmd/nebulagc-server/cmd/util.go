@@ -35,7 +35,7 @@ func OpenDatabase(path string) (*sql.DB, error) {
 // ExecuteUtil runs a utility command with the given arguments.
 func ExecuteUtil(args []string) error {
 	if len(args) < 1 {
-		return fmt.Errorf("util command requires a subcommand\n\nAvailable subcommands:\n  prune-replicas    Remove stale replica entries\n  verify-bundles    Verify bundle integrity\n  compact-db        Compact and optimize database\n  check-lighthouses Check lighthouse process health\n  verify-token      Verify token authentication")
+		return fmt.Errorf("util command requires a subcommand\n\nAvailable subcommands:\n  prune-replicas    Remove stale replica entries\n  purge-nodes       Permanently remove deleted nodes\n  verify-bundles    Verify bundle integrity\n  migrate-bundles   Move bundle data between bundle stores\n  compact-db        Compact and optimize database\n  check-lighthouses Check lighthouse process health\n  verify-token      Verify token authentication")
 	}
 
 	subcommand := args[0]
@@ -44,8 +44,12 @@ func ExecuteUtil(args []string) error {
 	switch subcommand {
 	case "prune-replicas":
 		return ExecutePruneReplicas(subArgs)
+	case "purge-nodes":
+		return ExecutePurgeNodes(subArgs)
 	case "verify-bundles":
 		return ExecuteVerifyBundles(subArgs)
+	case "migrate-bundles":
+		return ExecuteMigrateBundles(subArgs)
 	case "compact-db":
 		return ExecuteCompactDB(subArgs)
 	case "check-lighthouses":
@@ -3,11 +3,15 @@ package cmd
 import (
 	"archive/tar"
 	"compress/gzip"
+	"context"
 	"flag"
 	"fmt"
 	"io"
+	"time"
 
 	"go.uber.org/zap"
+
+	"nebulagc.io/server/internal/service"
 )
 
 // ExecuteVerifyBundles verifies the integrity of configuration bundles.
@@ -15,6 +19,8 @@ func ExecuteVerifyBundles(args []string) error {
 	fs := flag.NewFlagSet("verify-bundles", flag.ExitOnError)
 	clusterID := fs.String("cluster-id", "", "Verify bundles for specific cluster (default: all clusters)")
 	dbPath := fs.String("db", getEnv("NEBULAGC_DB_PATH", "./nebula.db"), "Path to SQLite database")
+	bundleStore := fs.String("bundle-store", getEnv("NEBULAGC_BUNDLE_STORE", service.BundleStoreDB), "Where bundle data is kept (db or fs)")
+	bundleDir := fs.String("bundle-dir", getEnv("NEBULAGC_BUNDLE_DIR", ""), "Directory of the fs bundle store")
 	verbose := fs.Bool("verbose", false, "Enable verbose output")
 	fix := fs.Bool("fix", false, "Attempt to fix corrupted bundles (not implemented)")
 
@@ -44,6 +50,11 @@ func ExecuteVerifyBundles(args []string) error {
 	}
 	defer db.Close()
 
+	store, err := service.OpenBundleStore(*bundleStore, db, *bundleDir)
+	if err != nil {
+		return err
+	}
+
 	logger.Info("verifying bundles", zap.String("cluster_id", *clusterID))
 
 	// Query bundles
@@ -52,7 +63,7 @@ func ExecuteVerifyBundles(args []string) error {
 
 	if *clusterID != "" {
 		query = `
-			SELECT id, cluster_id, version, config_data, activated_at
+			SELECT cluster_id, version, effective_at
 			FROM config_bundles
 			WHERE cluster_id = ?
 			ORDER BY version DESC
@@ -60,7 +71,7 @@ func ExecuteVerifyBundles(args []string) error {
 		queryArgs = []interface{}{*clusterID}
 	} else {
 		query = `
-			SELECT id, cluster_id, version, config_data, activated_at
+			SELECT cluster_id, version, effective_at
 			FROM config_bundles
 			ORDER BY cluster_id, version DESC
 		`
@@ -73,17 +84,16 @@ func ExecuteVerifyBundles(args []string) error {
 	defer rows.Close()
 
 	type bundleInfo struct {
-		ID          string
 		ClusterID   string
-		Version     int
-		ConfigData  []byte
-		ActivatedAt *string
+		Version     int64
+		Data        []byte
+		EffectiveAt *int64
 	}
 
 	var bundles []bundleInfo
 	for rows.Next() {
 		var b bundleInfo
-		if err := rows.Scan(&b.ID, &b.ClusterID, &b.Version, &b.ConfigData, &b.ActivatedAt); err != nil {
+		if err := rows.Scan(&b.ClusterID, &b.Version, &b.EffectiveAt); err != nil {
 			return fmt.Errorf("failed to scan bundle: %w", err)
 		}
 		bundles = append(bundles, b)
@@ -107,17 +117,22 @@ func ExecuteVerifyBundles(args []string) error {
 	fmt.Println("=====================================")
 
 	for _, b := range bundles {
-		fmt.Printf("\nBundle: %s (cluster: %s, version: %d)\n", b.ID, b.ClusterID, b.Version)
-		fmt.Printf("  Size: %d bytes\n", len(b.ConfigData))
+		fmt.Printf("\nBundle: cluster %s, version %d\n", b.ClusterID, b.Version)
+
+		b.Data, err = store.Get(context.Background(), b.ClusterID, b.Version)
+		if err != nil {
+			fmt.Printf("  ✗ INVALID: %v\n", err)
+			invalid++
+			continue
+		}
+		fmt.Printf("  Size: %d bytes\n", len(b.Data))
 
-		if b.ActivatedAt != nil {
-			fmt.Printf("  Status: Active (activated: %s)\n", *b.ActivatedAt)
-		} else {
-			fmt.Printf("  Status: Inactive\n")
+		if b.EffectiveAt != nil {
+			fmt.Printf("  Scheduled: %s\n", time.Unix(*b.EffectiveAt, 0).UTC().Format(time.RFC3339))
 		}
 
 		// Verify tar.gz format
-		if err := verifyTarGz(b.ConfigData); err != nil {
+		if err := verifyTarGz(b.Data); err != nil {
 			fmt.Printf("  ✗ INVALID: %v\n", err)
 			invalid++
 			continue
@@ -125,7 +140,7 @@ func ExecuteVerifyBundles(args []string) error {
 
 		// Check required files
 		requiredFiles := []string{"config.yml"}
-		missing, err := checkRequiredFiles(b.ConfigData, requiredFiles)
+		missing, err := checkRequiredFiles(b.Data, requiredFiles)
 		if err != nil {
 			fmt.Printf("  ✗ INVALID: %v\n", err)
 			invalid++
@@ -62,7 +62,7 @@ func ExecuteVerifyToken(args []string) error {
 		entityID = *nodeID
 		logger.Info("verifying node token", zap.String("node_id", *nodeID))
 
-		query := `SELECT token_hash FROM nodes WHERE id = ?`
+		query := `SELECT token_hash FROM nodes WHERE id = ? AND deleted_at IS NULL`
 		if err := db.QueryRow(query, *nodeID).Scan(&hash); err != nil {
 			return fmt.Errorf("node not found or error querying: %w", err)
 		}
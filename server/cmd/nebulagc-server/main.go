@@ -7,8 +7,11 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -22,15 +25,33 @@ import (
 	"go.uber.org/zap"
 	_ "modernc.org/sqlite"
 
+	"nebulagc.io/pkg/token"
 	"nebulagc.io/server/cmd/nebulagc-server/cmd"
 	"nebulagc.io/server/internal/api"
+	"nebulagc.io/server/internal/api/middleware"
+	"nebulagc.io/server/internal/canary"
+	"nebulagc.io/server/internal/events"
 	"nebulagc.io/server/internal/ha"
 	"nebulagc.io/server/internal/lighthouse"
 	"nebulagc.io/server/internal/logging"
 	"nebulagc.io/server/internal/metrics"
+	"nebulagc.io/server/internal/retention"
 	"nebulagc.io/server/internal/service"
 )
 
+// serverVersion is the nebulagc-server release, logged at startup and
+// reported in the replica registry.
+const serverVersion = "0.1.0"
+
+// Process exit codes. logger.Fatal also exits with exitRuntimeError.
+const (
+	// exitRuntimeError means the server failed while starting or running.
+	exitRuntimeError = 1
+
+	// exitConfigError means the configuration is invalid; nothing was started.
+	exitConfigError = 2
+)
+
 // Config holds server configuration from flags and environment variables.
 type Config struct {
 	// ListenAddr is the address to listen on (e.g., ":8080").
@@ -42,9 +63,21 @@ type Config struct {
 	// HMACSecret is the secret key for token validation.
 	HMACSecret string
 
+	// OperatorToken authenticates the operator for endpoints spanning all
+	// tenants, such as tenant provisioning (empty disables them).
+	OperatorToken string
+
 	// InstanceID is this control plane instance's UUID.
 	InstanceID string
 
+	// InstanceName is a human-friendly name for this instance shown in HA
+	// status and replica listings (defaults to the host name).
+	InstanceName string
+
+	// Region is the region or data center this instance runs in, reported in
+	// replica listings (optional).
+	Region string
+
 	// LogLevel is the logging level (debug, info, warn, error).
 	LogLevel string
 
@@ -54,13 +87,19 @@ type Config struct {
 	// AllowOrigins is comma-separated list of allowed CORS origins.
 	AllowOrigins string
 
+	// TrustedProxies is a comma-separated list of proxy IPs or CIDRs whose
+	// X-Forwarded-For and X-Real-IP headers name the client address.
+	TrustedProxies string
+
 	// DisableWriteGuard disables replica write guard (for single-instance mode).
 	DisableWriteGuard bool
 
 	// Mode indicates whether the server runs as master or replica.
 	Mode ha.Mode
 
-	// PublicURL is the externally reachable URL for this instance.
+	// PublicURL is the externally reachable URL for this instance. It may be
+	// a comma-separated list when internal and external clients reach the
+	// instance at different addresses; the first entry is the primary one.
 	PublicURL string
 
 	// Rate limiting configuration
@@ -69,6 +108,49 @@ type Config struct {
 	RateLimitRequests      int
 	RateLimitBundleUploads int
 	RateLimitHealthChecks  int
+
+	// Canary auto-rollback configuration
+	CanaryHealthWindow    time.Duration
+	CanaryMaxHeartbeatAge time.Duration
+	CanaryRollbackOnError bool
+	DisableCanaryRollback bool
+
+	// Scheduled bundle pruning
+	BundlePruneInterval time.Duration
+	DisableBundlePrune  bool
+
+	// Cluster token rotation
+	ClusterTokenOverlap time.Duration
+
+	// Event webhook
+	EventWebhookURL    string
+	EventWebhookSecret string
+
+	// Auth failure alerting
+	AuthFailureAlertThreshold int
+	AuthFailureAlertWindow    time.Duration
+
+	// Auth lockout
+	AuthLockoutThreshold int
+	AuthLockoutWindow    time.Duration
+	AuthLockoutCooldown  time.Duration
+	AuthLockoutScope     string
+
+	// Signed request clock skew
+	RequestSignatureMaxSkew time.Duration
+
+	// ReplicationPositionFile is written by the external replication tool
+	ReplicationPositionFile string
+
+	// BundleStore is where config bundle data is kept ("db", "fs" or "cas")
+	BundleStore string
+
+	// BundleDir is the directory of the "fs" bundle store, or of the "cas"
+	// store's blobs (empty keeps them in the database)
+	BundleDir string
+
+	// CheckConfig validates the configuration and exits without starting the server
+	CheckConfig bool
 }
 
 // parseFlags parses command-line flags and environment variables.
@@ -85,19 +167,27 @@ func parseFlags() *Config {
 		"Path to SQLite database file")
 	flag.StringVar(&config.HMACSecret, "secret", getEnv("NEBULAGC_HMAC_SECRET", ""),
 		"HMAC secret for token validation (required, min 32 bytes)")
+	flag.StringVar(&config.OperatorToken, "operator-token", getEnv("NEBULAGC_OPERATOR_TOKEN", ""),
+		"Operator token for tenant provisioning and other cross-tenant endpoints (min 32 bytes; empty disables them)")
 	flag.StringVar(&config.InstanceID, "instance-id", getEnv("NEBULAGC_INSTANCE_ID", ""),
 		"Control plane instance UUID (auto-generated if not provided)")
+	flag.StringVar(&config.InstanceName, "instance-name", getEnv("NEBULAGC_INSTANCE_NAME", ""),
+		"Human-friendly instance name, e.g. cp-eu-west-1 (defaults to the host name)")
+	flag.StringVar(&config.Region, "region", getEnv("NEBULAGC_REGION", ""),
+		"Region or data center of this instance, shown in replica listings")
 	flag.StringVar(&config.LogLevel, "log-level", getEnv("NEBULAGC_LOG_LEVEL", "info"),
 		"Log level (debug, info, warn, error)")
 	flag.StringVar(&config.LogFormat, "log-format", getEnv("NEBULAGC_LOG_FORMAT", "console"),
 		"Log format (json, console)")
 	flag.StringVar(&config.AllowOrigins, "cors-origins", getEnv("NEBULAGC_CORS_ORIGINS", ""),
 		"Comma-separated list of allowed CORS origins (* for all)")
+	flag.StringVar(&config.TrustedProxies, "trusted-proxies", getEnv("NEBULAGC_TRUSTED_PROXIES", ""),
+		"Comma-separated proxy IPs or CIDRs trusted to report the client address in X-Forwarded-For (none by default)")
 	flag.BoolVar(&config.DisableWriteGuard, "disable-write-guard",
 		getEnv("NEBULAGC_DISABLE_WRITE_GUARD", "") == "true",
 		"Disable replica write guard (single-instance mode)")
 	flag.StringVar(&config.PublicURL, "public-url", getEnv("NEBULAGC_PUBLIC_URL", ""),
-		"Public URL for this instance (e.g., https://cp1.example.com)")
+		"Public URL(s) for this instance, comma-separated (e.g., https://cp1.example.com,https://cp1.internal)")
 
 	// Rate limiting flags
 	config.RateLimitAuthFailures = getEnvInt("NEBULAGC_RATELIMIT_AUTH_FAILURES_PER_MIN", 10)
@@ -106,6 +196,49 @@ func parseFlags() *Config {
 	config.RateLimitBundleUploads = getEnvInt("NEBULAGC_RATELIMIT_BUNDLE_UPLOADS_PER_MIN", 10)
 	config.RateLimitHealthChecks = getEnvInt("NEBULAGC_RATELIMIT_HEALTH_CHECKS_PER_MIN", 30)
 
+	// Canary auto-rollback
+	config.CanaryHealthWindow = getEnvDuration("NEBULAGC_CANARY_HEALTH_WINDOW", 5*time.Minute)
+	config.CanaryMaxHeartbeatAge = getEnvDuration("NEBULAGC_CANARY_MAX_HEARTBEAT_AGE", 2*time.Minute)
+	config.CanaryRollbackOnError = getEnv("NEBULAGC_CANARY_ROLLBACK_ON_ERROR", "true") == "true"
+	config.DisableCanaryRollback = getEnv("NEBULAGC_DISABLE_CANARY_ROLLBACK", "") == "true"
+
+	// Scheduled bundle pruning (clusters opt in with a bundle retention)
+	config.BundlePruneInterval = getEnvDuration("NEBULAGC_BUNDLE_PRUNE_INTERVAL", time.Hour)
+	config.DisableBundlePrune = getEnv("NEBULAGC_DISABLE_BUNDLE_PRUNE", "") == "true"
+
+	// Cluster token rotation
+	config.ClusterTokenOverlap = getEnvDuration("NEBULAGC_CLUSTER_TOKEN_OVERLAP", service.DefaultClusterTokenOverlap)
+
+	// Event webhook
+	flag.StringVar(&config.EventWebhookURL, "event-webhook-url", getEnv("NEBULAGC_EVENT_WEBHOOK_URL", ""),
+		"URL that receives control plane events such as cluster token rotation (HTTPS recommended)")
+	config.EventWebhookSecret = getEnv("NEBULAGC_EVENT_WEBHOOK_SECRET", "")
+
+	// Auth failure alerting (0 = disabled)
+	config.AuthFailureAlertThreshold = getEnvInt("NEBULAGC_AUTH_FAILURE_ALERT_THRESHOLD", 0)
+	config.AuthFailureAlertWindow = getEnvDuration("NEBULAGC_AUTH_FAILURE_ALERT_WINDOW", time.Minute)
+
+	// Auth lockout (0 = disabled)
+	config.AuthLockoutThreshold = getEnvInt("NEBULAGC_AUTH_LOCKOUT_THRESHOLD", 0)
+	config.AuthLockoutWindow = getEnvDuration("NEBULAGC_AUTH_LOCKOUT_WINDOW", time.Minute)
+	config.AuthLockoutCooldown = getEnvDuration("NEBULAGC_AUTH_LOCKOUT_COOLDOWN", 15*time.Minute)
+	config.AuthLockoutScope = getEnv("NEBULAGC_AUTH_LOCKOUT_SCOPE", middleware.LockoutScopeIP)
+
+	// Signed request clock skew (for clusters that require signed requests)
+	config.RequestSignatureMaxSkew = getEnvDuration("NEBULAGC_REQUEST_SIGNATURE_MAX_SKEW", token.DefaultSignatureMaxSkew)
+
+	// Replication position reported by GET /api/v1/admin/consistency
+	config.ReplicationPositionFile = getEnv("NEBULAGC_REPLICATION_POSITION_FILE", "")
+
+	// Bundle data storage
+	flag.StringVar(&config.BundleStore, "bundle-store", getEnv("NEBULAGC_BUNDLE_STORE", service.BundleStoreDB),
+		"Where config bundle data is kept: db (in SQLite), fs (files in -bundle-dir) or cas (deduplicated, in SQLite or -bundle-dir)")
+	flag.StringVar(&config.BundleDir, "bundle-dir", getEnv("NEBULAGC_BUNDLE_DIR", ""),
+		"Directory for bundle data with -bundle-store=fs or cas (shared by all instances)")
+
+	flag.BoolVar(&config.CheckConfig, "check-config", false,
+		"Validate configuration, report all problems, and exit without starting the server")
+
 	masterFlag := flag.Bool("master", defaultMaster, "Run in master mode (write-enabled)")
 	replicaFlag := flag.Bool("replica", defaultReplica, "Run in replica mode (read-only)")
 
@@ -141,14 +274,37 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvDuration retrieves a duration environment variable (e.g. "90s") with a default value.
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
 // validateConfig validates the server configuration.
+//
+// Every problem is reported, not just the first, so operators can fix a
+// deployment in one pass. The returned error joins the individual problems
+// (see errors.Join).
 func validateConfig(config *Config) error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
 	// Validate HMAC secret
 	if config.HMACSecret == "" {
-		return fmt.Errorf("HMAC secret is required (set NEBULAGC_HMAC_SECRET or use -secret flag)")
+		fail("HMAC secret is required (set NEBULAGC_HMAC_SECRET or use -secret flag)")
+	} else if len(config.HMACSecret) < 32 {
+		fail("HMAC secret must be at least 32 bytes (got %d)", len(config.HMACSecret))
 	}
-	if len(config.HMACSecret) < 32 {
-		return fmt.Errorf("HMAC secret must be at least 32 bytes (got %d)", len(config.HMACSecret))
+
+	// Validate operator token
+	if config.OperatorToken != "" && len(config.OperatorToken) < 32 {
+		fail("operator token must be at least 32 bytes (got %d)", len(config.OperatorToken))
 	}
 
 	// Generate instance ID if not provided
@@ -158,25 +314,115 @@ func validateConfig(config *Config) error {
 
 	// Validate instance ID format
 	if _, err := uuid.Parse(config.InstanceID); err != nil {
-		return fmt.Errorf("invalid instance ID format: %w", err)
+		fail("invalid instance ID format: %w", err)
+	}
+
+	// Default the instance name to the host name
+	if config.InstanceName == "" {
+		if hostname, err := os.Hostname(); err == nil {
+			config.InstanceName = hostname
+		}
 	}
 
 	// Validate HA mode
 	if !ha.ValidateMode(config.Mode) {
-		return fmt.Errorf("must specify exactly one of --master or --replica (or NEBULAGC_MODE)")
+		fail("must specify exactly one of --master or --replica (or NEBULAGC_MODE)")
+	}
+
+	// Validate public URLs
+	publicURLs := parsePublicURLs(config.PublicURL)
+	if len(publicURLs) == 0 {
+		fail("public URL is required for replica registry (set NEBULAGC_PUBLIC_URL or use -public-url)")
+	}
+	for _, publicURL := range publicURLs {
+		if parsedURL, err := url.Parse(publicURL); err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" {
+			fail("invalid public URL %q: must include scheme and host", publicURL)
+		}
+	}
+
+	// Validate cluster token overlap
+	if config.ClusterTokenOverlap < 0 {
+		fail("cluster token overlap must not be negative (got %s)", config.ClusterTokenOverlap)
+	}
+
+	// Validate bundle prune interval
+	if !config.DisableBundlePrune && config.BundlePruneInterval <= 0 {
+		fail("bundle prune interval must be positive (got %s)", config.BundlePruneInterval)
 	}
 
-	// Validate public URL
-	if config.PublicURL == "" {
-		return fmt.Errorf("public URL is required for replica registry (set NEBULAGC_PUBLIC_URL or use -public-url)")
+	// Validate event webhook URL
+	if config.EventWebhookURL != "" {
+		webhookURL, err := url.Parse(config.EventWebhookURL)
+		if err != nil || webhookURL.Scheme == "" || webhookURL.Host == "" {
+			fail("invalid event webhook URL %q: must include scheme and host", config.EventWebhookURL)
+		}
 	}
 
-	parsedURL, err := url.Parse(config.PublicURL)
-	if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" {
-		return fmt.Errorf("invalid public URL %q: must include scheme and host", config.PublicURL)
+	// Validate auth failure alerting
+	if config.AuthFailureAlertThreshold < 0 {
+		fail("auth failure alert threshold must not be negative (got %d)", config.AuthFailureAlertThreshold)
+	}
+	if config.AuthFailureAlertThreshold > 0 {
+		if config.AuthFailureAlertWindow <= 0 {
+			fail("auth failure alert window must be positive (got %s)", config.AuthFailureAlertWindow)
+		}
+		if config.EventWebhookURL == "" {
+			fail("auth failure alerts require an event webhook URL (set NEBULAGC_EVENT_WEBHOOK_URL)")
+		}
 	}
 
-	return nil
+	// Validate signed request clock skew
+	if config.RequestSignatureMaxSkew <= 0 {
+		fail("request signature max skew must be positive (got %s)", config.RequestSignatureMaxSkew)
+	}
+
+	// Validate trusted proxies
+	for _, proxy := range parseCommaList(config.TrustedProxies) {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			fail("invalid trusted proxy %q: must be an IP address or CIDR", proxy)
+		}
+	}
+
+	// Validate auth lockout
+	if config.AuthLockoutThreshold < 0 {
+		fail("auth lockout threshold must not be negative (got %d)", config.AuthLockoutThreshold)
+	}
+	if config.AuthLockoutThreshold > 0 {
+		if config.AuthLockoutWindow <= 0 || config.AuthLockoutCooldown <= 0 {
+			fail("auth lockout window and cooldown must be positive (got %s, %s)",
+				config.AuthLockoutWindow, config.AuthLockoutCooldown)
+		}
+		if config.AuthLockoutScope != middleware.LockoutScopeIP && config.AuthLockoutScope != middleware.LockoutScopeIPToken {
+			fail("invalid auth lockout scope %q: must be %q or %q",
+				config.AuthLockoutScope, middleware.LockoutScopeIP, middleware.LockoutScopeIPToken)
+		}
+	}
+
+	// Validate bundle store
+	switch config.BundleStore {
+	case "", service.BundleStoreDB, service.BundleStoreCAS:
+	case service.BundleStoreFS:
+		if config.BundleDir == "" {
+			fail("bundle directory is required with the fs bundle store (set NEBULAGC_BUNDLE_DIR or use -bundle-dir)")
+		}
+	default:
+		fail("invalid bundle store %q: must be %q, %q or %q", config.BundleStore, service.BundleStoreDB, service.BundleStoreFS, service.BundleStoreCAS)
+	}
+
+	return errors.Join(errs...)
+}
+
+// reportConfigErrors prints every problem found by validateConfig, one per line.
+func reportConfigErrors(w io.Writer, err error) {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	fmt.Fprintf(w, "Configuration errors (%d):\n", len(errs))
+	for _, e := range errs {
+		fmt.Fprintf(w, "  - %v\n", e)
+	}
 }
 
 // setupLogger creates a structured logger based on configuration.
@@ -232,13 +478,30 @@ func openDatabase(path string, logger *zap.Logger) (*sql.DB, error) {
 	return db, nil
 }
 
-// parseCORSOrigins parses the comma-separated CORS origins string.
-func parseCORSOrigins(origins string) []string {
-	if origins == "" {
+// parsePublicURLs parses the comma-separated public URL list, dropping empty
+// entries and duplicates while keeping the primary URL first.
+func parsePublicURLs(urls string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, publicURL := range strings.Split(urls, ",") {
+		trimmed := strings.TrimSpace(publicURL)
+		if trimmed != "" && !seen[trimmed] {
+			seen[trimmed] = true
+			result = append(result, trimmed)
+		}
+	}
+
+	return result
+}
+
+// parseCommaList parses a comma-separated list such as the CORS origins,
+// dropping empty entries.
+func parseCommaList(list string) []string {
+	if list == "" {
 		return nil
 	}
 
-	parts := strings.Split(origins, ",")
+	parts := strings.Split(list, ",")
 	var result []string
 	for _, origin := range parts {
 		trimmed := strings.TrimSpace(origin)
@@ -265,15 +528,21 @@ func main() {
 
 	// Validate configuration
 	if err := validateConfig(config); err != nil {
-		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
-		os.Exit(1)
+		reportConfigErrors(os.Stderr, err)
+		os.Exit(exitConfigError)
+	}
+
+	// With --check-config, stop after validation (for CI)
+	if config.CheckConfig {
+		fmt.Println("Configuration OK")
+		return
 	}
 
 	// Setup logger
 	logger, err := setupLogger(config)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to setup logger: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitRuntimeError)
 	}
 	defer logger.Sync()
 
@@ -283,10 +552,12 @@ func main() {
 	}
 
 	logger.Info("starting nebulagc-server",
-		zap.String("version", "0.1.0"),
+		zap.String("version", serverVersion),
 		zap.String("instance_id", config.InstanceID),
+		zap.String("instance_name", config.InstanceName),
+		zap.String("region", config.Region),
 		zap.String("mode", string(config.Mode)),
-		zap.String("public_url", config.PublicURL),
+		zap.Strings("public_urls", parsePublicURLs(config.PublicURL)),
 		zap.String("listen_addr", config.ListenAddr),
 		zap.String("log_level", config.LogLevel),
 		zap.Bool("write_guard", !config.DisableWriteGuard),
@@ -299,10 +570,25 @@ func main() {
 	}
 	defer db.Close()
 
+	bundleStore, err := service.OpenBundleStore(config.BundleStore, db, config.BundleDir)
+	if err != nil {
+		logger.Fatal("failed to open bundle store", zap.Error(err))
+	}
+	logger.Info("bundle store ready",
+		zap.String("bundle_store", config.BundleStore),
+		zap.String("bundle_dir", config.BundleDir))
+
 	// Initialize services
 	replicaService := service.NewReplicaService(db, logger)
 
-	haConfig := ha.DefaultConfig(config.InstanceID, config.PublicURL, config.Mode)
+	publicURLs := parsePublicURLs(config.PublicURL)
+	haConfig := ha.DefaultConfig(config.InstanceID, publicURLs[0], config.Mode)
+	haConfig.Addresses = publicURLs
+	haConfig.Name = config.InstanceName
+	haConfig.Metadata = map[string]string{ha.MetadataVersion: serverVersion}
+	if config.Region != "" {
+		haConfig.Metadata[ha.MetadataRegion] = config.Region
+	}
 	haManager := ha.NewManager(haConfig, replicaService, logger)
 
 	if err := haManager.Start(); err != nil {
@@ -317,15 +603,61 @@ func main() {
 		logger.Fatal("failed to start lighthouse manager", zap.Error(err))
 	}
 
+	// One bundle service for the API, canary reconciler and pruner, so their
+	// changes to a cluster's bundles are serialized
+	bundleService := service.NewBundleServiceWithStore(db, bundleStore, logger)
+
+	// Initialize canary reconciler (rolls back canaries whose nodes fail health checks)
+	canaryConfig := canary.DefaultConfig()
+	canaryConfig.HealthWindow = config.CanaryHealthWindow
+	canaryConfig.MaxHeartbeatAge = config.CanaryMaxHeartbeatAge
+	canaryConfig.RollbackOnError = config.CanaryRollbackOnError
+	canaryConfig.Enabled = !config.DisableCanaryRollback
+	canaryReconciler := canary.NewReconciler(canaryConfig, bundleService, haManager.IsMaster, logger)
+
+	if err := canaryReconciler.Start(); err != nil {
+		logger.Fatal("failed to start canary reconciler", zap.Error(err))
+	}
+
+	// Initialize bundle pruner (deletes bundles beyond each cluster's retention)
+	pruneConfig := retention.DefaultConfig()
+	pruneConfig.CheckInterval = config.BundlePruneInterval
+	pruneConfig.Enabled = !config.DisableBundlePrune
+	bundlePruner := retention.NewPruner(pruneConfig, bundleService, haManager.IsMaster, logger)
+
+	if err := bundlePruner.Start(); err != nil {
+		logger.Fatal("failed to start bundle pruner", zap.Error(err))
+	}
+
+	// Deliver control plane events to the webhook, if configured
+	var notifier events.Notifier
+	if config.EventWebhookURL != "" {
+		notifier = events.NewWebhookNotifier(config.EventWebhookURL, config.EventWebhookSecret, logger)
+	}
+
 	// Setup HTTP router
 	router := api.SetupRouter(&api.RouterConfig{
-		DB:                db,
-		Logger:            logger,
-		HMACSecret:        config.HMACSecret,
-		InstanceID:        config.InstanceID,
-		AllowOrigins:      parseCORSOrigins(config.AllowOrigins),
-		DisableWriteGuard: config.DisableWriteGuard,
-		HAManager:         haManager,
+		DB:                        db,
+		Logger:                    logger,
+		HMACSecret:                config.HMACSecret,
+		InstanceID:                config.InstanceID,
+		InstanceName:              config.InstanceName,
+		AllowOrigins:              parseCommaList(config.AllowOrigins),
+		TrustedProxies:            parseCommaList(config.TrustedProxies),
+		DisableWriteGuard:         config.DisableWriteGuard,
+		HAManager:                 haManager,
+		ClusterTokenOverlap:       config.ClusterTokenOverlap,
+		Events:                    notifier,
+		AuthFailureAlertThreshold: config.AuthFailureAlertThreshold,
+		AuthFailureAlertWindow:    config.AuthFailureAlertWindow,
+		AuthLockoutThreshold:      config.AuthLockoutThreshold,
+		AuthLockoutWindow:         config.AuthLockoutWindow,
+		AuthLockoutCooldown:       config.AuthLockoutCooldown,
+		AuthLockoutScope:          config.AuthLockoutScope,
+		RequestSignatureMaxSkew:   config.RequestSignatureMaxSkew,
+		ReplicationPositionFile:   config.ReplicationPositionFile,
+		BundleService:             bundleService,
+		OperatorToken:             config.OperatorToken,
 	})
 
 	// Start HTTP server
@@ -356,6 +688,14 @@ func main() {
 		logger.Error("server shutdown failed", zap.Error(err))
 	}
 
+	if err := bundlePruner.Stop(); err != nil {
+		logger.Error("failed to stop bundle pruner", zap.Error(err))
+	}
+
+	if err := canaryReconciler.Stop(); err != nil {
+		logger.Error("failed to stop canary reconciler", zap.Error(err))
+	}
+
 	if err := lighthouseManager.Stop(); err != nil {
 		logger.Error("failed to stop lighthouse manager", zap.Error(err))
 	}
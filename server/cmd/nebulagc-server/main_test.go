@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"nebulagc.io/pkg/token"
+
+	"nebulagc.io/server/internal/api/middleware"
+	"nebulagc.io/server/internal/ha"
+)
+
+// validConfig returns a configuration that passes validateConfig.
+func validConfig() *Config {
+	return &Config{
+		HMACSecret:              strings.Repeat("s", 32),
+		Mode:                    ha.ModeMaster,
+		PublicURL:               "https://cp1.example.com",
+		RequestSignatureMaxSkew: token.DefaultSignatureMaxSkew,
+		AuthLockoutScope:        middleware.LockoutScopeIP,
+		BundlePruneInterval:     time.Hour,
+	}
+}
+
+func TestValidateConfig_Valid(t *testing.T) {
+	config := validConfig()
+	if err := validateConfig(config); err != nil {
+		t.Fatalf("validateConfig() error = %v", err)
+	}
+	if config.InstanceID == "" {
+		t.Error("Expected an instance ID to be generated")
+	}
+}
+
+func TestValidateConfig_ReportsAllProblems(t *testing.T) {
+	config := validConfig()
+	config.HMACSecret = ""
+	config.Mode = ""
+	config.PublicURL = "cp1.example.com"
+	config.AuthLockoutThreshold = 5
+	config.AuthLockoutWindow = time.Minute
+	config.AuthLockoutCooldown = time.Minute
+	config.AuthLockoutScope = "subnet"
+
+	err := validateConfig(config)
+	if err == nil {
+		t.Fatal("Expected validateConfig() to fail")
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("Expected a joined error, got %T", err)
+	}
+	errs := joined.Unwrap()
+
+	wantSubstrings := []string{
+		"HMAC secret is required",
+		"exactly one of --master or --replica",
+		"invalid public URL",
+		"invalid auth lockout scope",
+	}
+	if len(errs) != len(wantSubstrings) {
+		t.Fatalf("Expected %d problems, got %d: %v", len(wantSubstrings), len(errs), errs)
+	}
+	for i, want := range wantSubstrings {
+		if !strings.Contains(errs[i].Error(), want) {
+			t.Errorf("Problem %d = %q, want it to contain %q", i, errs[i], want)
+		}
+	}
+}
+
+func TestValidateConfig_NoDuplicateProblemsPerSetting(t *testing.T) {
+	config := validConfig()
+	config.PublicURL = ""
+
+	err := validateConfig(config)
+	if err == nil {
+		t.Fatal("Expected validateConfig() to fail")
+	}
+	if errs := err.(interface{ Unwrap() []error }).Unwrap(); len(errs) != 1 {
+		t.Errorf("Expected only the missing public URL to be reported, got %v", errs)
+	}
+}
+
+func TestValidateConfig_MultiplePublicURLs(t *testing.T) {
+	config := validConfig()
+	config.PublicURL = "https://cp1.example.com, https://cp1.internal:8080"
+	if err := validateConfig(config); err != nil {
+		t.Fatalf("validateConfig() error = %v", err)
+	}
+
+	config.PublicURL = "https://cp1.example.com,cp1.internal,,ftp://"
+	err := validateConfig(config)
+	if err == nil {
+		t.Fatal("Expected validateConfig() to fail")
+	}
+	errs := err.(interface{ Unwrap() []error }).Unwrap()
+	if len(errs) != 2 {
+		t.Fatalf("Expected one problem per bad URL, got %v", errs)
+	}
+	if !strings.Contains(errs[0].Error(), `"cp1.internal"`) || !strings.Contains(errs[1].Error(), `"ftp://"`) {
+		t.Errorf("Unexpected problems: %v", errs)
+	}
+}
+
+func TestValidateConfig_BundleStore(t *testing.T) {
+	config := validConfig()
+	config.BundleStore = "fs"
+	config.BundleDir = "/var/lib/nebulagc/bundles"
+	if err := validateConfig(config); err != nil {
+		t.Fatalf("validateConfig() error = %v", err)
+	}
+
+	config.BundleDir = ""
+	if err := validateConfig(config); err == nil || !strings.Contains(err.Error(), "bundle directory is required") {
+		t.Errorf("validateConfig() error = %v, want missing bundle directory", err)
+	}
+
+	// The content-addressed store keeps blobs in the database without a directory
+	config.BundleStore = "cas"
+	if err := validateConfig(config); err != nil {
+		t.Errorf("validateConfig() error = %v, want cas without a directory to be valid", err)
+	}
+
+	config.BundleStore = "s3"
+	if err := validateConfig(config); err == nil || !strings.Contains(err.Error(), `invalid bundle store "s3"`) {
+		t.Errorf("validateConfig() error = %v, want invalid bundle store", err)
+	}
+}
+
+func TestParsePublicURLs(t *testing.T) {
+	got := parsePublicURLs(" https://a.example.com ,https://b.internal,, https://a.example.com")
+	want := []string{"https://a.example.com", "https://b.internal"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("parsePublicURLs() = %v, want %v", got, want)
+	}
+	if got := parsePublicURLs(" , "); len(got) != 0 {
+		t.Errorf("parsePublicURLs() = %v, want empty", got)
+	}
+}
+
+func TestReportConfigErrors(t *testing.T) {
+	var out bytes.Buffer
+	reportConfigErrors(&out, errors.Join(errors.New("first problem"), errors.New("second problem")))
+
+	want := "Configuration errors (2):\n  - first problem\n  - second problem\n"
+	if out.String() != want {
+		t.Errorf("reportConfigErrors() wrote %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	reportConfigErrors(&out, errors.New("only problem"))
+	if want := "Configuration errors (1):\n  - only problem\n"; out.String() != want {
+		t.Errorf("reportConfigErrors() wrote %q, want %q", out.String(), want)
+	}
+}
@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"nebulagc.io/models"
 	"nebulagc.io/pkg/bundle"
 	"nebulagc.io/server/internal/service"
 )
@@ -31,12 +36,16 @@ func NewBundleHandler(service *service.BundleService) *BundleHandler {
 
 // GetVersion handles GET /api/v1/config/version
 //
-// Returns the current config version for the authenticated cluster.
+// Returns the current config version for the authenticated node. During a
+// canary rollout, only canary nodes are given the canary version. rolled_back
+// is set after a canary rollback, telling daemons on the canary version to
+// go back to the lower version.
 //
 // Response:
 //
 //	{
-//	  "version": 42
+//	  "version": 42,
+//	  "rolled_back": false
 //	}
 func (h *BundleHandler) GetVersion(c *gin.Context) {
 	clusterID := getClusterID(c)
@@ -45,30 +54,83 @@ func (h *BundleHandler) GetVersion(c *gin.Context) {
 		return
 	}
 
-	version, err := h.service.GetCurrentVersion(clusterID)
+	version, err := h.service.GetCurrentVersion(clusterID, getNodeID(c))
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+	rolledBack, err := h.service.IsRolledBack(clusterID)
 	if err != nil {
 		mapErrorToResponse(c, err)
 		return
 	}
 
 	respondSuccess(c, http.StatusOK, gin.H{
-		"version": version,
+		"version":     version,
+		"rolled_back": rolledBack,
 	})
 }
 
+// HeadBundle handles HEAD /api/v1/config/bundle
+//
+// Reports the config version the authenticated node would download, without
+// a body. Cheaper than GetVersion for daemons polling for new configs.
+//
+// Response headers:
+//   - X-Config-Version: Version of the bundle the node would download
+//   - ETag: "{cluster_id}-v{version}"
+//   - Cache-Control and Vary: see setBundleCacheHeaders
+//
+// Returns:
+//   - 200 with no body
+func (h *BundleHandler) HeadBundle(c *gin.Context) {
+	clusterID := getClusterID(c)
+	if clusterID == "" {
+		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+		return
+	}
+
+	version, err := h.service.GetCurrentVersion(clusterID, getNodeID(c))
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	setBundleCacheHeaders(c, clusterID, version)
+	c.Header("X-Config-Version", fmt.Sprintf("%d", version))
+	c.Status(http.StatusOK)
+}
+
 // DownloadBundle handles GET /api/v1/config/bundle
 //
 // Downloads the config bundle for the authenticated cluster.
 // Supports conditional requests via If-None-Match header.
 //
+// A specific version can be requested with the version parameter. Stored
+// versions are immutable, so Range requests against them are safe and let
+// clients resume interrupted downloads.
+//
 // Query Parameters:
 //   - current_version: Client's current version (optional)
+//   - version: Download this specific version instead of the latest (optional)
 //
 // Headers:
-//   - If-None-Match: "v{version}" for conditional requests
+//   - If-None-Match: "{cluster_id}-v{version}" for conditional requests; takes the place
+//     of current_version for HTTP caches and proxies. A 304 is returned if
+//     any listed tag, weak or strong, names the current version
+//   - Range: "bytes={offset}-" to resume a download of a specific version
+//   - If-Range: "{cluster_id}-v{version}" to only honor Range if the version matches
+//
+// Response headers:
+//   - ETag: "{cluster_id}-v{version}" of the returned bundle, also sent with 304
+//   - Cache-Control and Vary: see setBundleCacheHeaders, also sent with 304
+//   - X-Config-Version: Version of the returned bundle
+//   - X-Config-Checksum: SHA-256 of the complete bundle (hex)
+//   - X-Config-Signature: Uploader's Ed25519 signature (base64), if signed
 //
 // Returns:
 //   - 200 with bundle data if update available
+//   - 206 with the requested byte range of a specific version
 //   - 304 Not Modified if client has current version
 func (h *BundleHandler) DownloadBundle(c *gin.Context) {
 	clusterID := getClusterID(c)
@@ -77,6 +139,25 @@ func (h *BundleHandler) DownloadBundle(c *gin.Context) {
 		return
 	}
 
+	// A specific version is immutable and can be served with Range support
+	if versionStr := c.Query("version"); versionStr != "" {
+		v, err := strconv.ParseInt(versionStr, 10, 64)
+		if err != nil || v <= 0 {
+			respondError(c, http.StatusBadRequest, "invalid_version", "Invalid version parameter")
+			return
+		}
+
+		stream, version, err := h.service.DownloadStream(clusterID, getNodeID(c), v)
+		if err != nil {
+			mapErrorToResponse(c, err)
+			return
+		}
+		defer stream.Close()
+
+		h.serveBundle(c, stream, clusterID, version)
+		return
+	}
+
 	// Check if client provided their current version
 	var clientVersion int64
 	if versionStr := c.Query("current_version"); versionStr != "" {
@@ -88,45 +169,86 @@ func (h *BundleHandler) DownloadBundle(c *gin.Context) {
 		clientVersion = v
 	}
 
-	// Also check If-None-Match header (format: "v123")
-	if etag := c.GetHeader("If-None-Match"); etag != "" {
-		var v int64
-		if _, err := fmt.Sscanf(etag, "\"v%d\"", &v); err == nil {
-			if v > clientVersion {
-				clientVersion = v
-			}
-		}
-	}
-
 	// Check if client is up-to-date
-	isCurrent, currentVersion, err := h.service.CheckVersion(clusterID, clientVersion)
+	isCurrent, currentVersion, err := h.service.CheckVersion(clusterID, getNodeID(c), clientVersion)
 	if err != nil {
 		mapErrorToResponse(c, err)
 		return
 	}
 
-	if isCurrent && clientVersion > 0 {
+	// The client is current if current_version or any If-None-Match tag
+	// (format: "<cluster_id>-v123") names the version it would download
+	if (isCurrent && clientVersion > 0) || etagMatches(c.GetHeader("If-None-Match"), clusterID, currentVersion) {
 		// Client has current version - return 304 Not Modified
-		c.Header("ETag", fmt.Sprintf("\"v%d\"", currentVersion))
+		setBundleCacheHeaders(c, clusterID, currentVersion)
 		c.Status(http.StatusNotModified)
 		return
 	}
 
 	// Download bundle
-	data, version, err := h.service.Download(clusterID, 0) // 0 = latest
+	stream, version, err := h.service.DownloadStream(clusterID, getNodeID(c), 0) // 0 = latest
 	if err != nil {
 		mapErrorToResponse(c, err)
 		return
 	}
+	defer stream.Close()
+
+	h.serveBundle(c, stream, clusterID, version)
+}
 
+// bundleETag returns the entity tag of a cluster's bundle version. The
+// cluster ID keeps tags of different clusters distinct for shared caches.
+func bundleETag(clusterID string, version int64) string {
+	return fmt.Sprintf("\"%s-v%d\"", clusterID, version)
+}
+
+// setBundleCacheHeaders sets the ETag of a bundle version and keeps caches
+// from serving it to other nodes: the version a node receives depends on its
+// token (canary nodes get a different one), so responses are private and
+// must be revalidated.
+func setBundleCacheHeaders(c *gin.Context, clusterID string, version int64) {
+	c.Header("ETag", bundleETag(clusterID, version))
+	c.Header("Cache-Control", "private, no-cache")
+	c.Header("Vary", "X-NebulaGC-Node-Token")
+}
+
+// etagMatches reports whether an If-None-Match header names the given
+// bundle version of the cluster. The header may list several entity tags and
+// matches if any of them does, not only the highest: a node may still hold a
+// newer version after a canary rollback moved the cluster back. Weak tags
+// (W/"<cluster_id>-v123") are compared as their strong form, as caches and
+// proxies may send them.
+func etagMatches(header, clusterID string, version int64) bool {
+	if version <= 0 {
+		return false
+	}
+	want := bundleETag(clusterID, version)
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// serveBundle writes a bundle with its version and checksum headers.
+//
+// The bundle is copied from the stream as it is sent, so it is never held in
+// memory. Range and If-Range requests are handled by http.ServeContent, so a
+// client can resume a partial download of the same version.
+func (h *BundleHandler) serveBundle(c *gin.Context, stream *service.BundleStream, clusterID string, version int64) {
 	// Set headers
 	c.Header("Content-Type", "application/gzip")
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"config-v%d.tar.gz\"", version))
-	c.Header("ETag", fmt.Sprintf("\"v%d\"", version))
+	setBundleCacheHeaders(c, clusterID, version)
 	c.Header("X-Config-Version", fmt.Sprintf("%d", version))
+	c.Header("X-Config-Checksum", stream.Checksum)
+	if len(stream.Signature) > 0 {
+		c.Header("X-Config-Signature", base64.StdEncoding.EncodeToString(stream.Signature))
+	}
 
 	// Send bundle
-	c.Data(http.StatusOK, "application/gzip", data)
+	http.ServeContent(c.Writer, c.Request, "", time.Time{}, stream)
 }
 
 // UploadBundle handles POST /api/v1/config/bundle
@@ -134,12 +256,31 @@ func (h *BundleHandler) DownloadBundle(c *gin.Context) {
 // Uploads a new config bundle for the authenticated cluster.
 // Requires admin node authentication.
 //
+// Query Parameters:
+//   - effective_at: RFC 3339 time at which the bundle becomes active (optional)
+//   - description: Note stored with the bundle, up to 256 characters (optional)
+//   - canary_nodes: Comma-separated node IDs that alone receive the bundle,
+//     as a canary rollout defined together with the upload (optional)
+//
+// Headers:
+//   - X-Config-Signature: Detached Ed25519 signature of the bundle (base64, optional)
+//
+// A bundle with a future effective_at reserves its version immediately, but
+// nodes keep receiving the previous version until that time. The cutover is
+// evaluated against the control plane clock when nodes poll, so nodes pick
+// it up on their first poll after effective_at.
+//
 // Request body: application/gzip (tar.gz bundle)
 //
 // Response:
 //
 //	{
 //	  "version": 43,
+//	  "compressed_size": 5120,
+//	  "uncompressed_size": 20480,
+//	  "file_count": 5,
+//	  "checksum": "9f86d081884c7d65...",
+//	  "effective_at": "2025-01-21T02:00:00Z",
 //	  "message": "Bundle uploaded successfully"
 //	}
 func (h *BundleHandler) UploadBundle(c *gin.Context) {
@@ -149,6 +290,40 @@ func (h *BundleHandler) UploadBundle(c *gin.Context) {
 		return
 	}
 
+	// Parse optional activation time
+	var effectiveAt time.Time
+	if value := c.Query("effective_at"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "invalid_effective_at",
+				"effective_at must be an RFC 3339 timestamp")
+			return
+		}
+		effectiveAt = t
+	}
+
+	// Parse optional signature
+	var signature []byte
+	if value := c.GetHeader("X-Config-Signature"); value != "" {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "invalid_signature",
+				"X-Config-Signature must be base64")
+			return
+		}
+		signature = decoded
+	}
+
+	// Parse optional canary nodes
+	var canaryNodeIDs []string
+	if value := c.Query("canary_nodes"); value != "" {
+		for _, nodeID := range strings.Split(value, ",") {
+			if nodeID = strings.TrimSpace(nodeID); nodeID != "" {
+				canaryNodeIDs = append(canaryNodeIDs, nodeID)
+			}
+		}
+	}
+
 	// Check Content-Type
 	contentType := c.GetHeader("Content-Type")
 	if contentType != "application/gzip" && contentType != "application/x-gzip" {
@@ -172,17 +347,28 @@ func (h *BundleHandler) UploadBundle(c *gin.Context) {
 	}
 
 	// Upload bundle
-	version, err := h.service.Upload(clusterID, data)
+	result, err := h.service.UploadWithOptions(clusterID, data, service.UploadOptions{
+		EffectiveAt:   effectiveAt,
+		CreatedBy:     getNodeID(c),
+		Description:   c.Query("description"),
+		Signature:     signature,
+		CanaryNodeIDs: canaryNodeIDs,
+	})
 	if err != nil {
 		// Map bundle validation errors to appropriate HTTP responses
-		switch err {
-		case bundle.ErrBundleTooLarge:
+		if errors.Is(err, models.ErrInvalidBundleFormat) {
+			respondError(c, http.StatusBadRequest, "invalid_format", err.Error())
+			return
+		}
+		// Validation errors carry their detail wrapped around the sentinel
+		switch {
+		case errors.Is(err, bundle.ErrBundleTooLarge):
 			respondError(c, http.StatusRequestEntityTooLarge, "bundle_too_large", err.Error())
-		case bundle.ErrInvalidFormat, bundle.ErrEmptyBundle:
+		case errors.Is(err, bundle.ErrInvalidFormat), errors.Is(err, bundle.ErrEmptyBundle):
 			respondError(c, http.StatusBadRequest, "invalid_format", err.Error())
-		case bundle.ErrMissingRequiredFile:
+		case errors.Is(err, bundle.ErrMissingRequiredFile):
 			respondError(c, http.StatusBadRequest, "missing_required_file", err.Error())
-		case bundle.ErrInvalidYAML:
+		case errors.Is(err, bundle.ErrInvalidConfigYAML):
 			respondError(c, http.StatusBadRequest, "invalid_yaml", err.Error())
 		default:
 			mapErrorToResponse(c, err)
@@ -190,8 +376,159 @@ func (h *BundleHandler) UploadBundle(c *gin.Context) {
 		return
 	}
 
-	respondSuccess(c, http.StatusOK, gin.H{
-		"version": version,
-		"message": "Bundle uploaded successfully",
-	})
+	response := gin.H{
+		"version":           result.Version,
+		"compressed_size":   result.CompressedSize,
+		"uncompressed_size": result.UncompressedSize,
+		"file_count":        result.FileCount,
+		"checksum":          result.Checksum,
+		"message":           "Bundle uploaded successfully",
+	}
+	if effectiveAt.After(time.Now()) {
+		response["effective_at"] = effectiveAt.UTC()
+		response["message"] = "Bundle scheduled successfully"
+	}
+	respondSuccess(c, http.StatusOK, response)
+}
+
+// GetArchive handles GET /api/v1/clusters/:cluster_id/bundles/archive
+//
+// Streams a tar archive holding every stored bundle version of the cluster,
+// one "<version>.tar.gz" entry per version in ascending order, for backup
+// tooling. The archive is written as it is read from the database, so the
+// response has no Content-Length and memory use does not grow with history.
+//
+// Query Parameters:
+//   - after_version: Only include versions greater than this (optional).
+//     A client whose download was interrupted resumes by passing the last
+//     version it received completely.
+//
+// The archive is only complete when the response carries the
+// X-Bundle-Archive-Complete trailer; if streaming fails part-way the trailer
+// is omitted, so clients can tell a truncated archive from a full one.
+//
+// Response: application/x-tar
+func (h *BundleHandler) GetArchive(c *gin.Context) {
+	clusterID := c.Param("cluster_id")
+	if clusterID != getClusterID(c) {
+		respondError(c, http.StatusNotFound, "not_found", "Cluster not found")
+		return
+	}
+
+	var afterVersion int64
+	if versionStr := c.Query("after_version"); versionStr != "" {
+		v, err := strconv.ParseInt(versionStr, 10, 64)
+		if err != nil || v < 0 {
+			respondError(c, http.StatusBadRequest, "invalid_version", "Invalid after_version parameter")
+			return
+		}
+		afterVersion = v
+	}
+
+	c.Header("Content-Type", "application/x-tar")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s-bundles.tar\"", clusterID))
+	c.Header("Trailer", bundleArchiveCompleteTrailer)
+
+	if _, err := h.service.WriteArchive(c.Request.Context(), clusterID, afterVersion, c.Writer); err != nil {
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			c.Writer.Header().Del("Trailer")
+			mapErrorToResponse(c, err)
+			return
+		}
+		// Headers are already sent; leave the trailer out to flag the truncation
+		_ = c.Error(err)
+		c.Abort()
+		return
+	}
+
+	c.Writer.Header().Set(bundleArchiveCompleteTrailer, "true")
+}
+
+// DiffBundle handles GET /api/v1/tenants/:tenant_id/clusters/:cluster_id/config/diff
+//
+// Reports which files were added, removed or changed between two bundle
+// versions, compared by content hash. File contents are not included.
+// Requires cluster token authentication.
+//
+// Query Parameters:
+//   - from: The version compared against (required)
+//   - to: The version whose changes are reported (required)
+//
+// Response:
+//
+//	{
+//	  "from_version": 41,
+//	  "to_version": 42,
+//	  "files": [
+//	    {"name": "config.yml", "change": "changed", "from_size": 812, "to_size": 845}
+//	  ]
+//	}
+func (h *BundleHandler) DiffBundle(c *gin.Context) {
+	if c.Param("tenant_id") != getTenantID(c) || c.Param("cluster_id") != getClusterID(c) {
+		respondError(c, http.StatusNotFound, "not_found", "Cluster not found")
+		return
+	}
+
+	from, err := strconv.ParseInt(c.Query("from"), 10, 64)
+	if err != nil || from < 1 {
+		respondError(c, http.StatusBadRequest, "invalid_version", "Invalid from parameter")
+		return
+	}
+	to, err := strconv.ParseInt(c.Query("to"), 10, 64)
+	if err != nil || to < 1 {
+		respondError(c, http.StatusBadRequest, "invalid_version", "Invalid to parameter")
+		return
+	}
+
+	diff, err := h.service.Diff(getClusterID(c), from, to)
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, diff)
 }
+
+// SetRetention handles PUT /api/v1/clusters/:cluster_id/bundles/retention
+//
+// Sets how many bundle versions of the cluster the scheduled prune keeps.
+// Older versions are deleted unless still in use. Requires cluster token
+// authentication.
+//
+// Request body:
+//
+//	{
+//	  "keep": 20
+//	}
+//
+// Response:
+//
+//	{
+//	  "message": "Bundle retention updated"
+//	}
+func (h *BundleHandler) SetRetention(c *gin.Context) {
+	clusterID := c.Param("cluster_id")
+	if clusterID != getClusterID(c) {
+		respondError(c, http.StatusNotFound, "not_found", "Cluster not found")
+		return
+	}
+
+	var req models.BundleRetentionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	if err := h.service.SetRetention(clusterID, *req.Keep); err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccessWithMessage(c, http.StatusOK, "Bundle retention updated")
+}
+
+// bundleArchiveCompleteTrailer is the trailer set once a bundle archive has
+// been written in full.
+const bundleArchiveCompleteTrailer = "X-Bundle-Archive-Complete"
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"nebulagc.io/models"
+)
+
+// GetCanary handles GET /api/v1/config/canary
+//
+// Returns the authenticated cluster's active canary rollout.
+// Requires admin node authentication.
+//
+// Response:
+//
+//	{
+//	  "cluster_id": "...",
+//	  "version": 43,
+//	  "node_ids": ["..."],
+//	  "created_at": "2025-01-21T10:30:00Z"
+//	}
+func (h *BundleHandler) GetCanary(c *gin.Context) {
+	canary, err := h.service.GetCanary(getClusterID(c))
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, canary)
+}
+
+// SetCanary handles PUT /api/v1/config/canary
+//
+// Makes the latest bundle version available only to the listed nodes; all
+// other nodes stay on the previous version until the canary is promoted.
+// Requires admin node authentication.
+//
+// Request body:
+//
+//	{
+//	  "version": 43,
+//	  "node_ids": ["...", "..."]
+//	}
+func (h *BundleHandler) SetCanary(c *gin.Context) {
+	var req models.CanaryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	clusterID := getClusterID(c)
+	if err := h.service.SetCanary(clusterID, req.Version, req.NodeIDs); err != nil {
+		if errors.Is(err, models.ErrInvalidRequest) {
+			respondError(c, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	canary, err := h.service.GetCanary(clusterID)
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, canary)
+}
+
+// PromoteCanary handles POST /api/v1/config/canary/promote
+//
+// Ends the active canary, making its version available to every node.
+// Requires admin node authentication.
+//
+// Response:
+//
+//	{
+//	  "version": 43
+//	}
+func (h *BundleHandler) PromoteCanary(c *gin.Context) {
+	version, err := h.service.PromoteCanary(getClusterID(c))
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, gin.H{
+		"version": version,
+	})
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"nebulagc.io/models"
+	"nebulagc.io/server/internal/service"
+)
+
+// ClusterHandler handles cluster-level endpoints.
+type ClusterHandler struct {
+	service *service.ClusterService
+}
+
+// NewClusterHandler creates a new cluster handler.
+//
+// Parameters:
+//   - service: Cluster service for business logic
+//
+// Returns:
+//   - Configured ClusterHandler
+func NewClusterHandler(service *service.ClusterService) *ClusterHandler {
+	return &ClusterHandler{
+		service: service,
+	}
+}
+
+// GetStats handles GET /api/v1/clusters/:cluster_id/stats
+//
+// Returns node, role and route counts for the authenticated cluster along
+// with its config version and last change time. The cluster ID in the path
+// must match the cluster token; other clusters are reported as not found.
+//
+// Response:
+//
+//	{
+//	  "cluster_id": "...",
+//	  "node_count": 12,
+//	  "admin_count": 1,
+//	  "lighthouse_count": 2,
+//	  "relay_count": 1,
+//	  "route_count": 5,
+//	  "config_version": 42,
+//	  "last_change_at": "2025-01-21T10:30:00Z"
+//	}
+func (h *ClusterHandler) GetStats(c *gin.Context) {
+	clusterID := c.Param("cluster_id")
+	if clusterID != getClusterID(c) {
+		respondError(c, http.StatusNotFound, "not_found", "Cluster not found")
+		return
+	}
+
+	stats, err := h.service.Stats(clusterID)
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, stats)
+}
+
+// GetHistory handles GET /api/v1/clusters/:cluster_id/history
+//
+// Returns the cluster's config change timeline, newest first: one entry per
+// config version with what triggered it and the node that made the change.
+// The optional limit query parameter caps the number of entries (default 50,
+// at most 500).
+//
+// Response:
+//
+//	{
+//	  "cluster_id": "...",
+//	  "changes": [
+//	    {
+//	      "version": 42,
+//	      "type": "routes_updated",
+//	      "actor": "...",
+//	      "detail": "...",
+//	      "created_at": "2025-01-21T10:30:00Z"
+//	    }
+//	  ]
+//	}
+func (h *ClusterHandler) GetHistory(c *gin.Context) {
+	clusterID := c.Param("cluster_id")
+	if clusterID != getClusterID(c) {
+		respondError(c, http.StatusNotFound, "not_found", "Cluster not found")
+		return
+	}
+
+	var limit int
+	if limitStr := c.Query("limit"); limitStr != "" {
+		v, err := strconv.Atoi(limitStr)
+		if err != nil || v < 1 {
+			respondError(c, http.StatusBadRequest, "invalid_limit", "Invalid limit parameter")
+			return
+		}
+		limit = v
+	}
+
+	changes, err := h.service.History(c.Request.Context(), clusterID, limit)
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, models.ConfigHistoryResponse{
+		ClusterID: clusterID,
+		Changes:   changes,
+	})
+}
+
+// CreateCluster handles POST /api/v1/tenants/:tenant_id/clusters
+//
+// Creates a cluster in the tenant. Requires the operator token. The cluster
+// token is only returned in this response.
+//
+// Request body:
+//
+//	{
+//	  "name": "prod-eu-west"
+//	}
+//
+// Response (201):
+//
+//	{
+//	  "cluster": {"id": "...", "tenant_id": "...", "name": "prod-eu-west", ...},
+//	  "cluster_token": "..."
+//	}
+func (h *ClusterHandler) CreateCluster(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+
+	var req struct {
+		Name string `json:"name" binding:"required,min=1,max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	cluster, clusterToken, err := h.service.CreateCluster(c.Request.Context(), tenantID, req.Name)
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusCreated, models.ClusterCreateResponse{
+		Cluster:      *cluster,
+		ClusterToken: clusterToken,
+	})
+}
+
+// ListClusters handles GET /api/v1/tenants/:tenant_id/clusters
+//
+// Lists the tenant's clusters, oldest first. Requires the operator token.
+//
+// Query parameters:
+//   - page: Page number (default 1)
+//   - page_size: Clusters per page (default 50, max 500)
+func (h *ClusterHandler) ListClusters(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+
+	resp, err := h.service.ListClusters(c.Request.Context(), tenantID, page, pageSize)
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, resp)
+}
+
+// GetCluster handles GET /api/v1/tenants/:tenant_id/clusters/:cluster_id
+//
+// Returns a cluster of the tenant. Requires the operator token.
+func (h *ClusterHandler) GetCluster(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+
+	cluster, err := h.service.GetCluster(c.Request.Context(), tenantID, c.Param("cluster_id"))
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, cluster)
+}
+
+// DeleteCluster handles DELETE /api/v1/tenants/:tenant_id/clusters/:cluster_id
+//
+// Permanently deletes a cluster with its nodes and config bundles. Requires
+// the operator token.
+func (h *ClusterHandler) DeleteCluster(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+
+	if err := h.service.DeleteCluster(c.Request.Context(), tenantID, c.Param("cluster_id")); err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
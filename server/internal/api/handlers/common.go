@@ -6,6 +6,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -25,8 +26,16 @@ type ErrorResponse struct {
 
 	// RequestID is the unique request ID for tracing.
 	RequestID string `json:"request_id,omitempty"`
+
+	// Index is the position of the failing item when a batch request was
+	// rejected (see models.BatchError).
+	Index *int `json:"index,omitempty"`
 }
 
+// batchIndexKey is the context key under which mapErrorToResponse stores the
+// failing item of a batch request for respondError.
+const batchIndexKey = "batch_index"
+
 // SuccessResponse represents a standardized success response with data.
 //
 // This is used for successful API responses that return data to the client.
@@ -56,10 +65,18 @@ func respondError(c *gin.Context, statusCode int, errorCode string, message stri
 		}
 	}
 
+	var index *int
+	if val, exists := c.Get(batchIndexKey); exists {
+		if i, ok := val.(int); ok {
+			index = &i
+		}
+	}
+
 	c.JSON(statusCode, ErrorResponse{
 		Error:     errorCode,
 		Message:   message,
 		RequestID: requestID,
+		Index:     index,
 	})
 }
 
@@ -90,51 +107,83 @@ func respondSuccessWithMessage(c *gin.Context, statusCode int, message string) {
 // mapErrorToResponse converts a models package error to an HTTP response.
 //
 // This function maps domain errors (from the models package) to appropriate
-// HTTP status codes and error responses. It uses generic error messages to
-// prevent information disclosure that could aid attackers.
+// HTTP status codes and error responses. Wrapped errors are matched with
+// errors.Is, so services may add context with fmt.Errorf("%w: ..."). It uses
+// generic error messages to prevent information disclosure that could aid attackers.
 //
 // Parameters:
 //   - c: Gin context
 //   - err: Error from models package or other source
 func mapErrorToResponse(c *gin.Context, err error) {
-	switch err {
+	var batchErr *models.BatchError
+	if errors.As(err, &batchErr) {
+		c.Set(batchIndexKey, batchErr.Index)
+	}
+
+	switch {
 	// 404 Not Found errors
-	case models.ErrNotFound, models.ErrClusterNotFound, models.ErrTenantNotFound,
-		models.ErrNodeNotFound, models.ErrBundleNotFound, models.ErrReplicaNotFound:
+	case errors.Is(err, models.ErrNotFound), errors.Is(err, models.ErrClusterNotFound),
+		errors.Is(err, models.ErrTenantNotFound), errors.Is(err, models.ErrNodeNotFound),
+		errors.Is(err, models.ErrBundleNotFound), errors.Is(err, models.ErrReplicaNotFound):
 		respondError(c, http.StatusNotFound, "not_found", "Resource not found")
 
 	// 401 Unauthorized errors
-	case models.ErrUnauthorized, models.ErrInvalidToken,
-		models.ErrInvalidNodeToken, models.ErrInvalidClusterToken:
+	case errors.Is(err, models.ErrUnauthorized), errors.Is(err, models.ErrInvalidToken),
+		errors.Is(err, models.ErrInvalidNodeToken), errors.Is(err, models.ErrInvalidClusterToken):
 		// Generic message to prevent token enumeration
 		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication failed")
 
 	// 403 Forbidden errors
-	case models.ErrForbidden, models.ErrNotAdmin:
+	case errors.Is(err, models.ErrForbidden), errors.Is(err, models.ErrNotAdmin):
 		respondError(c, http.StatusForbidden, "forbidden", "Access denied")
 
 	// 400 Bad Request errors
-	case models.ErrInvalidRequest, models.ErrInvalidCIDR, models.ErrInvalidMTU:
+	case errors.Is(err, models.ErrInvalidRequest), errors.Is(err, models.ErrInvalidCIDR),
+		errors.Is(err, models.ErrInvalidMTU):
 		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request parameters")
 
+	case errors.Is(err, models.ErrIPv6NotAllowed):
+		respondError(c, http.StatusBadRequest, "invalid_request", err.Error())
+
+	case errors.Is(err, models.ErrRelayMeshSaturated):
+		respondError(c, http.StatusBadRequest, "invalid_request", "Every node in the cluster would be a relay")
+
+	case errors.Is(err, models.ErrNotRelay):
+		respondError(c, http.StatusBadRequest, "invalid_request", "Preferred relay is not a relay node")
+
+	case errors.Is(err, models.ErrDNSPortConflict):
+		respondError(c, http.StatusBadRequest, "invalid_request", "Lighthouse DNS port conflicts with the Nebula UDP port")
+
+	case errors.Is(err, models.ErrInvalidBundleFormat):
+		respondError(c, http.StatusBadRequest, "invalid_format", "Bundle must be a gzip-compressed tar archive (.tar.gz)")
+
+	case errors.Is(err, models.ErrConfirmationRequired):
+		respondError(c, http.StatusBadRequest, "confirmation_required", "Change is disruptive; retry with force to confirm")
+
 	// 409 Conflict errors
-	case models.ErrConflict, models.ErrDuplicateName:
+	case errors.Is(err, models.ErrConflict), errors.Is(err, models.ErrDuplicateName):
 		respondError(c, http.StatusConflict, "conflict", "Resource already exists")
 
+	case errors.Is(err, models.ErrRouteOverlap):
+		respondError(c, http.StatusConflict, "conflict", err.Error())
+
 	// 413 Payload Too Large errors
-	case models.ErrPayloadTooLarge, models.ErrBundleTooLarge:
+	case errors.Is(err, models.ErrPayloadTooLarge), errors.Is(err, models.ErrBundleTooLarge):
 		respondError(c, http.StatusRequestEntityTooLarge, "payload_too_large", "Payload exceeds size limit")
 
 	// 429 Rate Limit errors
-	case models.ErrRateLimitExceeded:
+	case errors.Is(err, models.ErrRateLimitExceeded):
 		respondError(c, http.StatusTooManyRequests, "rate_limit_exceeded", "Rate limit exceeded")
 
 	// 500 Internal Server Error
-	case models.ErrInternalError, models.ErrDatabaseError:
+	case errors.Is(err, models.ErrInternalError), errors.Is(err, models.ErrDatabaseError):
 		respondError(c, http.StatusInternalServerError, "internal_error", "An internal error occurred")
 
 	// 503 Service Unavailable errors
-	case models.ErrReplicaReadOnly, models.ErrServiceUnavailable:
+	case errors.Is(err, models.ErrUploadConflict):
+		respondError(c, http.StatusServiceUnavailable, "upload_conflict", "Concurrent bundle upload in progress; retry the upload")
+
+	case errors.Is(err, models.ErrReplicaReadOnly), errors.Is(err, models.ErrServiceUnavailable):
 		respondError(c, http.StatusServiceUnavailable, "service_unavailable", "Service temporarily unavailable")
 
 	default:
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"nebulagc.io/models"
+	"nebulagc.io/server/internal/service"
+)
+
+// ConsistencyHandler serves the replica consistency report.
+type ConsistencyHandler struct {
+	service      *service.ConsistencyService
+	instanceID   string
+	isMaster     func() (bool, string, error) // Function to check if this instance is master
+	positionFile string                       // File holding the last applied replication position ("" = unknown)
+}
+
+// NewConsistencyHandler creates a new consistency handler.
+//
+// Parameters:
+//   - service: Consistency service for business logic
+//   - instanceID: This control plane instance's UUID
+//   - isMaster: Function that returns (isMaster bool, masterURL string, err error)
+//   - positionFile: File the replication tool writes its last applied position to ("" if none)
+//
+// Returns:
+//   - Configured ConsistencyHandler
+func NewConsistencyHandler(service *service.ConsistencyService, instanceID string, isMaster func() (bool, string, error), positionFile string) *ConsistencyHandler {
+	return &ConsistencyHandler{
+		service:      service,
+		instanceID:   instanceID,
+		isMaster:     isMaster,
+		positionFile: positionFile,
+	}
+}
+
+// GetConsistency handles GET /api/v1/admin/consistency
+//
+// Reports this instance's config version, latest bundle version and content
+// hash for every cluster of the caller's tenant. An external checker fetches
+// the report from each instance and compares them to detect replicas that lag
+// or have diverged before routing reads to them. Requires an admin node.
+//
+// Response:
+//
+//	{
+//	  "instance_id": "...",
+//	  "is_master": false,
+//	  "last_applied": "0000000000000003/00000000000a1f20",
+//	  "generated_at": "2025-01-01T12:00:00Z",
+//	  "clusters": [
+//	    {"cluster_id": "...", "config_version": 12, "latest_bundle_version": 4, "content_hash": "..."}
+//	  ]
+//	}
+func (h *ConsistencyHandler) GetConsistency(c *gin.Context) {
+	clusters, err := h.service.Clusters(getTenantID(c))
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	isMaster, _, err := h.isMaster()
+	if err != nil {
+		respondError(c, http.StatusServiceUnavailable, "master_check_failed", "Unable to determine master status")
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, models.ConsistencyReport{
+		InstanceID:  h.instanceID,
+		IsMaster:    isMaster,
+		LastApplied: h.lastApplied(),
+		GeneratedAt: time.Now().UTC(),
+		Clusters:    clusters,
+	})
+}
+
+// lastApplied reads the replication position file, returning "" if it is
+// not configured or cannot be read.
+func (h *ConsistencyHandler) lastApplied() string {
+	if h.positionFile == "" {
+		return ""
+	}
+	data, err := os.ReadFile(h.positionFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"nebulagc.io/server/internal/ha"
+)
+
+// MasterStepper hands the master role over to another replica.
+type MasterStepper interface {
+	// StepDown moves the master role to the next-oldest healthy replica.
+	StepDown() (*ha.MasterInfo, error)
+}
+
+// HAHandler serves control plane high availability operations.
+type HAHandler struct {
+	stepper    MasterStepper // nil in single-instance mode
+	instanceID string
+}
+
+// StepDownResponse reports the outcome of a master step-down.
+type StepDownResponse struct {
+	PreviousMaster string   `json:"previous_master"`
+	Master         string   `json:"master"`
+	MasterName     string   `json:"master_name,omitempty"`
+	MasterURLs     []string `json:"master_urls"`
+}
+
+// NewHAHandler creates a new HA handler.
+//
+// Parameters:
+//   - stepper: The HA manager (nil if HA is disabled)
+//   - instanceID: This control plane instance's UUID
+//
+// Returns:
+//   - Configured HAHandler
+func NewHAHandler(stepper MasterStepper, instanceID string) *HAHandler {
+	return &HAHandler{
+		stepper:    stepper,
+		instanceID: instanceID,
+	}
+}
+
+// StepDown handles POST /api/v1/ha/step-down
+//
+// Makes this instance, which must be the current master, give up the master
+// role to the next-oldest healthy replica without restarting. Writes are
+// routed to the master, so the request reaches it from any instance. Requires
+// the operator token.
+//
+// Response:
+//
+//	{
+//	  "previous_master": "...",
+//	  "master": "...",
+//	  "master_name": "cp-eu-west-2",
+//	  "master_urls": ["https://cp2.example.com"]
+//	}
+//
+// Errors:
+//   - 409 ha_disabled: The control plane runs as a single instance
+//   - 409 no_standby: No other healthy replica can take over
+//   - 503 not_master: This instance stopped being master before the request
+func (h *HAHandler) StepDown(c *gin.Context) {
+	if h.stepper == nil {
+		respondError(c, http.StatusConflict, "ha_disabled", "High availability is not enabled")
+		return
+	}
+
+	master, err := h.stepper.StepDown()
+	if err != nil {
+		switch {
+		case errors.Is(err, ha.ErrNoStandby):
+			respondError(c, http.StatusConflict, "no_standby", err.Error())
+		case errors.Is(err, ha.ErrNotMaster):
+			respondError(c, http.StatusServiceUnavailable, "not_master", err.Error())
+		default:
+			respondError(c, http.StatusInternalServerError, "step_down_failed", "Failed to step down")
+		}
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, StepDownResponse{
+		PreviousMaster: h.instanceID,
+		Master:         master.InstanceID,
+		MasterName:     master.Name,
+		MasterURLs:     master.Addresses,
+	})
+}
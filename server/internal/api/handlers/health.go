@@ -12,9 +12,10 @@ import (
 // This handler provides liveness, readiness, and master status checks
 // for Kubernetes and load balancer health monitoring.
 type HealthHandler struct {
-	db         *sql.DB
-	instanceID string
-	isMaster   func() (bool, string, error) // Function to check if this instance is master
+	db           *sql.DB
+	instanceID   string
+	instanceName string
+	isMaster     func() (bool, string, error) // Function to check if this instance is master
 }
 
 // NewHealthHandler creates a new health check handler.
@@ -22,12 +23,14 @@ type HealthHandler struct {
 // Parameters:
 //   - db: Database connection for readiness checks
 //   - instanceID: This control plane instance's UUID
+//   - instanceName: This instance's human-friendly name (may be empty)
 //   - isMaster: Function that returns (isMaster bool, masterURL string, err error)
-func NewHealthHandler(db *sql.DB, instanceID string, isMaster func() (bool, string, error)) *HealthHandler {
+func NewHealthHandler(db *sql.DB, instanceID, instanceName string, isMaster func() (bool, string, error)) *HealthHandler {
 	return &HealthHandler{
-		db:         db,
-		instanceID: instanceID,
-		isMaster:   isMaster,
+		db:           db,
+		instanceID:   instanceID,
+		instanceName: instanceName,
+		isMaster:     isMaster,
 	}
 }
 
@@ -46,9 +49,10 @@ type ReadinessResponse struct {
 
 // MasterResponse represents the master status response.
 type MasterResponse struct {
-	IsMaster   bool   `json:"is_master"`
-	InstanceID string `json:"instance_id"`
-	MasterURL  string `json:"master_url,omitempty"`
+	IsMaster     bool   `json:"is_master"`
+	InstanceID   string `json:"instance_id"`
+	InstanceName string `json:"instance_name,omitempty"`
+	MasterURL    string `json:"master_url,omitempty"`
 }
 
 // Liveness handles GET /health/live for Kubernetes liveness probes.
@@ -98,6 +102,7 @@ func (h *HealthHandler) Readiness(c *gin.Context) {
 // Response includes:
 //   - is_master: true if this instance is the master
 //   - instance_id: this instance's UUID
+//   - instance_name: this instance's human-friendly name (if set)
 //   - master_url: URL of the master instance (if this is not the master)
 func (h *HealthHandler) Master(c *gin.Context) {
 	isMaster, masterURL, err := h.isMaster()
@@ -107,8 +112,9 @@ func (h *HealthHandler) Master(c *gin.Context) {
 	}
 
 	response := MasterResponse{
-		IsMaster:   isMaster,
-		InstanceID: h.instanceID,
+		IsMaster:     isMaster,
+		InstanceID:   h.instanceID,
+		InstanceName: h.instanceName,
 	}
 
 	// Include master URL if we're not the master
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"nebulagc.io/models"
+)
+
+// LockoutLister reports the auth lockouts enforced by this instance.
+type LockoutLister interface {
+	// Scope returns how failures are grouped ("ip" or "ip_token").
+	Scope() string
+
+	// Active returns the current lockouts.
+	Active() []models.AuthLockout
+}
+
+// LockoutHandler serves the auth lockout listing.
+type LockoutHandler struct {
+	lockouts   LockoutLister // nil when lockout is disabled
+	instanceID string
+}
+
+// NewLockoutHandler creates a new lockout handler.
+//
+// Parameters:
+//   - lockouts: Source of active lockouts (nil if lockout is disabled)
+//   - instanceID: This control plane instance's UUID
+//
+// Returns:
+//   - Configured LockoutHandler
+func NewLockoutHandler(lockouts LockoutLister, instanceID string) *LockoutHandler {
+	return &LockoutHandler{
+		lockouts:   lockouts,
+		instanceID: instanceID,
+	}
+}
+
+// GetLockouts handles GET /api/v1/admin/lockouts
+//
+// Lists the sources this instance currently rejects after repeated failed
+// token validations. Lockouts are kept in memory per instance, so query each
+// instance behind the load balancer. Requires the operator token.
+//
+// Response:
+//
+//	{
+//	  "instance_id": "...",
+//	  "scope": "ip",
+//	  "lockouts": [
+//	    {"source_ip": "203.0.113.7", "token_type": "node", "failures": 10, "locked_until": "2025-01-01T12:15:00Z"}
+//	  ]
+//	}
+func (h *LockoutHandler) GetLockouts(c *gin.Context) {
+	report := models.AuthLockoutReport{
+		InstanceID: h.instanceID,
+		Lockouts:   []models.AuthLockout{},
+	}
+	if h.lockouts != nil {
+		report.Scope = h.lockouts.Scope()
+		report.Lockouts = h.lockouts.Active()
+	}
+
+	respondSuccess(c, http.StatusOK, report)
+}
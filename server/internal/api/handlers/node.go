@@ -31,7 +31,36 @@ func (h *NodeHandler) CreateNode(c *gin.Context) {
 		return
 	}
 
-	creds, err := h.service.CreateNode(c.Request.Context(), tenantID, clusterID, clusterToken, &req)
+	creds, err := h.service.WithActor(getNodeID(c)).CreateNode(c.Request.Context(), tenantID, clusterID, clusterToken, &req)
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusCreated, creds)
+}
+
+// CreateNodes handles POST /api/v1/nodes/batch to create several nodes in one
+// transaction (admin only). Credentials are returned in request order. If any
+// node is rejected none are created, and the error response's index field
+// names the first rejected node.
+func (h *NodeHandler) CreateNodes(c *gin.Context) {
+	tenantID := getTenantID(c)
+	clusterID := getClusterID(c)
+	clusterToken := c.GetHeader("X-NebulaGC-Cluster-Token")
+
+	var req models.NodeBatchCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		mapErrorToResponse(c, models.ErrInvalidRequest)
+		return
+	}
+
+	reqs := make([]*models.NodeCreateRequest, len(req.Nodes))
+	for i := range req.Nodes {
+		reqs[i] = &req.Nodes[i]
+	}
+
+	creds, err := h.service.WithActor(getNodeID(c)).CreateNodes(c.Request.Context(), tenantID, clusterID, clusterToken, reqs)
 	if err != nil {
 		mapErrorToResponse(c, err)
 		return
@@ -41,6 +70,8 @@ func (h *NodeHandler) CreateNode(c *gin.Context) {
 }
 
 // ListNodes handles GET /api/v1/nodes to list cluster nodes (admin only).
+// The name_contains, is_admin, is_lighthouse and is_relay query parameters
+// filter the nodes listed.
 func (h *NodeHandler) ListNodes(c *gin.Context) {
 	tenantID := getTenantID(c)
 	clusterID := getClusterID(c)
@@ -48,7 +79,22 @@ func (h *NodeHandler) ListNodes(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	perPage, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
 
-	resp, err := h.service.ListNodes(c.Request.Context(), tenantID, clusterID, page, perPage)
+	filter := models.NodeListFilter{NameContains: c.Query("name_contains")}
+	var err error
+	if filter.IsAdmin, err = boolQuery(c, "is_admin"); err != nil {
+		mapErrorToResponse(c, models.ErrInvalidRequest)
+		return
+	}
+	if filter.IsLighthouse, err = boolQuery(c, "is_lighthouse"); err != nil {
+		mapErrorToResponse(c, models.ErrInvalidRequest)
+		return
+	}
+	if filter.IsRelay, err = boolQuery(c, "is_relay"); err != nil {
+		mapErrorToResponse(c, models.ErrInvalidRequest)
+		return
+	}
+
+	resp, err := h.service.ListNodes(c.Request.Context(), tenantID, clusterID, filter, page, perPage)
 	if err != nil {
 		mapErrorToResponse(c, err)
 		return
@@ -57,6 +103,17 @@ func (h *NodeHandler) ListNodes(c *gin.Context) {
 	respondSuccess(c, http.StatusOK, resp)
 }
 
+// GetNode handles GET /api/v1/nodes/:id to fetch a single node (admin only).
+func (h *NodeHandler) GetNode(c *gin.Context) {
+	summary, err := h.service.GetNode(c.Request.Context(), getTenantID(c), getClusterID(c), c.Param("id"))
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, summary)
+}
+
 // UpdateMTU handles PATCH /api/v1/nodes/:id/mtu to update MTU (admin only).
 func (h *NodeHandler) UpdateMTU(c *gin.Context) {
 	tenantID := getTenantID(c)
@@ -69,7 +126,28 @@ func (h *NodeHandler) UpdateMTU(c *gin.Context) {
 		return
 	}
 
-	summary, err := h.service.UpdateMTU(c.Request.Context(), tenantID, clusterID, nodeID, req.MTU)
+	summary, err := h.service.WithActor(getNodeID(c)).UpdateMTU(c.Request.Context(), tenantID, clusterID, nodeID, req.MTU)
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, summary)
+}
+
+// SetPreferredRelays handles PUT /api/v1/nodes/:id/relays to set a node's preferred relays (admin only).
+func (h *NodeHandler) SetPreferredRelays(c *gin.Context) {
+	tenantID := getTenantID(c)
+	clusterID := getClusterID(c)
+	nodeID := c.Param("id")
+
+	var req models.NodePreferredRelaysRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		mapErrorToResponse(c, models.ErrInvalidRequest)
+		return
+	}
+
+	summary, err := h.service.WithActor(getNodeID(c)).SetPreferredRelays(c.Request.Context(), tenantID, clusterID, nodeID, req.RelayNodeIDs)
 	if err != nil {
 		mapErrorToResponse(c, err)
 		return
@@ -84,7 +162,7 @@ func (h *NodeHandler) RotateNodeToken(c *gin.Context) {
 	clusterID := getClusterID(c)
 	nodeID := c.Param("id")
 
-	resp, err := h.service.RotateNodeToken(c.Request.Context(), tenantID, clusterID, nodeID)
+	resp, err := h.service.WithActor(getNodeID(c)).RotateNodeToken(c.Request.Context(), tenantID, clusterID, nodeID)
 	if err != nil {
 		mapErrorToResponse(c, err)
 		return
@@ -93,13 +171,57 @@ func (h *NodeHandler) RotateNodeToken(c *gin.Context) {
 	respondSuccess(c, http.StatusOK, resp)
 }
 
+// Heartbeat handles POST /api/v1/nodes/heartbeat to record the calling node's health.
+func (h *NodeHandler) Heartbeat(c *gin.Context) {
+	tenantID := getTenantID(c)
+	clusterID := getClusterID(c)
+	nodeID := getNodeID(c)
+
+	var req models.NodeHeartbeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		mapErrorToResponse(c, models.ErrInvalidRequest)
+		return
+	}
+
+	if err := h.service.RecordHeartbeat(c.Request.Context(), tenantID, clusterID, nodeID, &req); err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// WhoAmI handles GET /api/v1/nodes/me to identify the calling node.
+func (h *NodeHandler) WhoAmI(c *gin.Context) {
+	identity, err := h.service.WhoAmI(c.Request.Context(), getTenantID(c), getClusterID(c), getNodeID(c))
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, identity)
+}
+
+// RestoreNode handles POST /api/v1/nodes/:id/restore to undo a node deletion
+// that has not been purged yet (admin only). Restoring fails with 409
+// Conflict if the node's routes now overlap another node's.
+func (h *NodeHandler) RestoreNode(c *gin.Context) {
+	summary, err := h.service.WithActor(getNodeID(c)).RestoreNode(c.Request.Context(), getTenantID(c), getClusterID(c), c.Param("id"))
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, summary)
+}
+
 // DeleteNode handles DELETE /api/v1/nodes/:id to remove a node (admin only).
 func (h *NodeHandler) DeleteNode(c *gin.Context) {
 	tenantID := getTenantID(c)
 	clusterID := getClusterID(c)
 	nodeID := c.Param("id")
 
-	if err := h.service.DeleteNode(c.Request.Context(), tenantID, clusterID, nodeID); err != nil {
+	if err := h.service.WithActor(getNodeID(c)).DeleteNode(c.Request.Context(), tenantID, clusterID, nodeID); err != nil {
 		mapErrorToResponse(c, err)
 		return
 	}
@@ -124,3 +246,17 @@ func getClusterID(c *gin.Context) string {
 	}
 	return ""
 }
+
+// boolQuery parses an optional boolean query parameter, returning nil when it
+// is absent.
+func boolQuery(c *gin.Context, name string) (*bool, error) {
+	value, ok := c.GetQuery(name)
+	if !ok {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
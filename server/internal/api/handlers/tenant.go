@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"nebulagc.io/models"
+	"nebulagc.io/server/internal/service"
+)
+
+// TenantHandler handles tenant-level endpoints.
+type TenantHandler struct {
+	service *service.TenantService
+}
+
+// NewTenantHandler creates a new tenant handler.
+//
+// Parameters:
+//   - service: Tenant service for business logic
+//
+// Returns:
+//   - Configured TenantHandler
+func NewTenantHandler(service *service.TenantService) *TenantHandler {
+	return &TenantHandler{
+		service: service,
+	}
+}
+
+// GetStats handles GET /api/v1/tenants/:tenant_id/stats
+//
+// Returns cluster, node and bundle storage totals across the tenant's
+// clusters. Requires an admin node of the tenant; other tenants are
+// reported as not found.
+//
+// Response:
+//
+//	{
+//	  "tenant_id": "...",
+//	  "cluster_count": 3,
+//	  "node_count": 40,
+//	  "bundle_count": 57,
+//	  "bundle_storage_bytes": 1048576
+//	}
+func (h *TenantHandler) GetStats(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	if tenantID != getTenantID(c) {
+		respondError(c, http.StatusNotFound, "not_found", "Tenant not found")
+		return
+	}
+
+	stats, err := h.service.Stats(tenantID)
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, stats)
+}
+
+// CreateTenant handles POST /api/v1/admin/tenants
+//
+// Provisions a tenant. Requires the operator token.
+//
+// Request body:
+//
+//	{
+//	  "name": "Acme Corporation"
+//	}
+//
+// Response (201):
+//
+//	{
+//	  "id": "...",
+//	  "name": "Acme Corporation",
+//	  "created_at": "2025-01-21T10:30:00Z"
+//	}
+func (h *TenantHandler) CreateTenant(c *gin.Context) {
+	var req models.TenantCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	tenant, err := h.service.CreateTenant(c.Request.Context(), req.Name)
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusCreated, tenant)
+}
+
+// ListTenants handles GET /api/v1/admin/tenants
+//
+// Lists all tenants, ordered by name. Requires the operator token.
+//
+// Query parameters:
+//   - page: Page number (default 1)
+//   - page_size: Tenants per page (default 50, max 500)
+func (h *TenantHandler) ListTenants(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+
+	resp, err := h.service.ListTenants(c.Request.Context(), page, pageSize)
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, resp)
+}
+
+// GetTenant handles GET /api/v1/admin/tenants/:tenant_id
+//
+// Returns a single tenant. Requires the operator token.
+func (h *TenantHandler) GetTenant(c *gin.Context) {
+	tenant, err := h.service.GetTenant(c.Request.Context(), c.Param("tenant_id"))
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, tenant)
+}
+
+// DeleteTenant handles DELETE /api/v1/admin/tenants/:tenant_id
+//
+// Permanently deletes a tenant with all of its clusters, nodes and config
+// bundles. Requires the operator token.
+func (h *TenantHandler) DeleteTenant(c *gin.Context) {
+	if err := h.service.DeleteTenant(c.Request.Context(), c.Param("tenant_id")); err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
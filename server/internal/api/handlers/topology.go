@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"nebulagc.io/models"
+	"nebulagc.io/server/internal/config"
 	"nebulagc.io/server/internal/service"
 )
 
@@ -12,6 +16,12 @@ type TopologyHandler struct {
 	service *service.TopologyService
 }
 
+// AssignRelayResponse reports the outcome of a relay assignment.
+type AssignRelayResponse struct {
+	// Warnings lists concerns about the resulting relay mesh (empty if none).
+	Warnings []config.Issue `json:"warnings"`
+}
+
 // NewTopologyHandler creates a new topology handler.
 //
 // Parameters:
@@ -58,7 +68,7 @@ func (h *TopologyHandler) UpdateRoutes(c *gin.Context) {
 	}
 
 	// Update routes
-	if err := h.service.UpdateRoutes(nodeID, req.Routes); err != nil {
+	if err := h.service.WithActor(getNodeID(c)).UpdateRoutes(nodeID, req.Routes); err != nil {
 		mapErrorToResponse(c, err)
 		return
 	}
@@ -159,7 +169,7 @@ func (h *TopologyHandler) AssignLighthouse(c *gin.Context) {
 	}
 
 	// Assign lighthouse
-	if err := h.service.SetLighthouse(clusterID, req.NodeID, req.PublicIP, req.Port); err != nil {
+	if err := h.service.WithActor(getNodeID(c)).SetLighthouse(clusterID, req.NodeID, req.PublicIP, req.Port); err != nil {
 		mapErrorToResponse(c, err)
 		return
 	}
@@ -189,7 +199,7 @@ func (h *TopologyHandler) UnassignLighthouse(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.UnsetLighthouse(clusterID, nodeID); err != nil {
+	if err := h.service.WithActor(getNodeID(c)).UnsetLighthouse(clusterID, nodeID); err != nil {
 		mapErrorToResponse(c, err)
 		return
 	}
@@ -210,6 +220,11 @@ func (h *TopologyHandler) UnassignLighthouse(c *gin.Context) {
 // Response:
 //
 //	{
+//	  "data": {
+//	    "warnings": [
+//	      {"severity": "warning", "code": "too_many_relays", "message": "..."}
+//	    ]
+//	  },
 //	  "message": "Relay status assigned"
 //	}
 func (h *TopologyHandler) AssignRelay(c *gin.Context) {
@@ -229,12 +244,16 @@ func (h *TopologyHandler) AssignRelay(c *gin.Context) {
 	}
 
 	// Assign relay
-	if err := h.service.SetRelay(clusterID, req.NodeID); err != nil {
+	warnings, err := h.service.WithActor(getNodeID(c)).SetRelay(clusterID, req.NodeID)
+	if err != nil {
 		mapErrorToResponse(c, err)
 		return
 	}
 
-	respondSuccessWithMessage(c, http.StatusOK, "Relay status assigned")
+	c.JSON(http.StatusOK, SuccessResponse{
+		Data:    AssignRelayResponse{Warnings: warnings},
+		Message: "Relay status assigned",
+	})
 }
 
 // UnassignRelay handles DELETE /api/v1/topology/relay/:node_id
@@ -259,7 +278,7 @@ func (h *TopologyHandler) UnassignRelay(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.UnsetRelay(clusterID, nodeID); err != nil {
+	if err := h.service.WithActor(getNodeID(c)).UnsetRelay(clusterID, nodeID); err != nil {
 		mapErrorToResponse(c, err)
 		return
 	}
@@ -267,6 +286,629 @@ func (h *TopologyHandler) UnassignRelay(c *gin.Context) {
 	respondSuccessWithMessage(c, http.StatusOK, "Relay status removed")
 }
 
+// SetMaxRelayHops handles PUT /api/v1/topology/relay/max-hops
+//
+// Sets the cluster's relay hop limit. Requires cluster token authentication.
+// A limit of 0 disables relaying in generated configs.
+//
+// Request body:
+//
+//	{
+//	  "max_relay_hops": 2
+//	}
+//
+// Response:
+//
+//	{
+//	  "message": "Max relay hops updated"
+//	}
+func (h *TopologyHandler) SetMaxRelayHops(c *gin.Context) {
+	clusterID := getClusterID(c)
+	if clusterID == "" {
+		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+		return
+	}
+
+	// Parse request
+	var req struct {
+		MaxRelayHops *int `json:"max_relay_hops" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	if err := h.service.WithActor(getNodeID(c)).SetMaxRelayHops(clusterID, *req.MaxRelayHops); err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccessWithMessage(c, http.StatusOK, "Max relay hops updated")
+}
+
+// GetLighthouseDNS handles GET /api/v1/topology/dns
+//
+// Returns the cluster's lighthouse DNS settings. Requires cluster token authentication.
+//
+// Response:
+//
+//	{
+//	  "data": {
+//	    "enabled": true,
+//	    "host": "0.0.0.0",
+//	    "port": 53
+//	  }
+//	}
+func (h *TopologyHandler) GetLighthouseDNS(c *gin.Context) {
+	clusterID := getClusterID(c)
+	if clusterID == "" {
+		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+		return
+	}
+
+	dns, err := h.service.GetLighthouseDNS(clusterID)
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, dns)
+}
+
+// SetLighthouseDNS handles PUT /api/v1/topology/dns
+//
+// Updates the cluster's lighthouse DNS settings. Requires cluster token authentication.
+// Host defaults to 0.0.0.0 and port to 53; the port must not collide with the
+// Nebula UDP port used by the cluster's lighthouses.
+//
+// Request body:
+//
+//	{
+//	  "enabled": true,
+//	  "host": "0.0.0.0",
+//	  "port": 53
+//	}
+//
+// Response:
+//
+//	{
+//	  "message": "Lighthouse DNS updated"
+//	}
+func (h *TopologyHandler) SetLighthouseDNS(c *gin.Context) {
+	clusterID := getClusterID(c)
+	if clusterID == "" {
+		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+		return
+	}
+
+	// Parse request
+	var req models.LighthouseDNSConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	if err := h.service.WithActor(getNodeID(c)).SetLighthouseDNS(clusterID, &req); err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccessWithMessage(c, http.StatusOK, "Lighthouse DNS updated")
+}
+
+// SetNebulaSubnet handles PUT /api/v1/topology/subnet
+//
+// Sets the cluster's overlay network CIDR. Requires cluster token authentication.
+// Static host map entries must fall inside this subnet.
+//
+// Request body:
+//
+//	{
+//	  "subnet": "10.42.0.0/16"
+//	}
+//
+// Response:
+//
+//	{
+//	  "message": "Cluster subnet updated"
+//	}
+func (h *TopologyHandler) SetNebulaSubnet(c *gin.Context) {
+	clusterID := getClusterID(c)
+	if clusterID == "" {
+		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+		return
+	}
+
+	// Parse request
+	var req struct {
+		Subnet string `json:"subnet" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	if err := h.service.WithActor(getNodeID(c)).SetNebulaSubnet(clusterID, req.Subnet); err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccessWithMessage(c, http.StatusOK, "Cluster subnet updated")
+}
+
+// GetStaticHostMap handles GET /api/v1/topology/static-hosts
+//
+// Returns the cluster's static host map. Requires cluster token authentication.
+//
+// Response:
+//
+//	{
+//	  "data": {
+//	    "10.42.0.50": ["198.51.100.50:4242"]
+//	  }
+//	}
+func (h *TopologyHandler) GetStaticHostMap(c *gin.Context) {
+	clusterID := getClusterID(c)
+	if clusterID == "" {
+		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+		return
+	}
+
+	hosts, err := h.service.GetStaticHostMap(clusterID)
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, hosts)
+}
+
+// SetStaticHostMap handles PUT /api/v1/topology/static-hosts
+//
+// Replaces the cluster's static host map. Requires cluster token authentication.
+// Nebula IPs must belong to the cluster subnet and endpoints must be "ip:port".
+// An empty map clears all entries.
+//
+// Request body:
+//
+//	{
+//	  "static_host_map": {
+//	    "10.42.0.50": ["198.51.100.50:4242"]
+//	  }
+//	}
+//
+// Response:
+//
+//	{
+//	  "message": "Static host map updated"
+//	}
+func (h *TopologyHandler) SetStaticHostMap(c *gin.Context) {
+	clusterID := getClusterID(c)
+	if clusterID == "" {
+		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+		return
+	}
+
+	// Parse request
+	var req struct {
+		StaticHostMap map[string][]string `json:"static_host_map" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	if err := h.service.WithActor(getNodeID(c)).SetStaticHostMap(clusterID, req.StaticHostMap); err != nil {
+		if errors.Is(err, models.ErrInvalidRequest) {
+			respondError(c, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccessWithMessage(c, http.StatusOK, "Static host map updated")
+}
+
+// SetPreferredRanges handles PUT /api/v1/topology/preferred-ranges
+//
+// Replaces the cluster's Nebula preferred_ranges. Requires cluster token authentication.
+// Each entry must be a network CIDR; an empty list clears the setting.
+//
+// Request body:
+//
+//	{
+//	  "preferred_ranges": ["192.168.0.0/16", "10.10.0.0/24"]
+//	}
+//
+// Response:
+//
+//	{
+//	  "message": "Preferred ranges updated"
+//	}
+func (h *TopologyHandler) SetPreferredRanges(c *gin.Context) {
+	clusterID := getClusterID(c)
+	if clusterID == "" {
+		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+		return
+	}
+
+	// Parse request
+	var req struct {
+		PreferredRanges []string `json:"preferred_ranges" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	if err := h.service.WithActor(getNodeID(c)).SetPreferredRanges(clusterID, req.PreferredRanges); err != nil {
+		if errors.Is(err, models.ErrInvalidRequest) {
+			respondError(c, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccessWithMessage(c, http.StatusOK, "Preferred ranges updated")
+}
+
+// SetCipher handles PUT /api/v1/topology/cipher
+//
+// Changes the cluster's Nebula cipher. Requires cluster token authentication.
+// Nodes on different ciphers cannot talk to each other, so the change is
+// rejected with "confirmation_required" unless force is true.
+//
+// Request body:
+//
+//	{
+//	  "cipher": "chachapoly",
+//	  "force": true
+//	}
+//
+// Response:
+//
+//	{
+//	  "message": "Cipher updated"
+//	}
+func (h *TopologyHandler) SetCipher(c *gin.Context) {
+	clusterID := getClusterID(c)
+	if clusterID == "" {
+		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+		return
+	}
+
+	// Parse request
+	var req models.ClusterCipherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	if err := h.service.WithActor(getNodeID(c)).SetCipher(clusterID, req.Cipher, req.Force); err != nil {
+		if errors.Is(err, models.ErrInvalidRequest) {
+			respondError(c, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccessWithMessage(c, http.StatusOK, "Cipher updated")
+}
+
+// SetHandshakeConfig handles PUT /api/v1/topology/handshake
+//
+// Replaces the cluster's Nebula handshake tuning. Requires cluster token authentication.
+// Omitted or zero values reset a setting to the Nebula default.
+//
+// Request body:
+//
+//	{
+//	  "try_interval": "100ms",
+//	  "retries": 20,
+//	  "trigger_buffer": 64
+//	}
+//
+// Response:
+//
+//	{
+//	  "message": "Handshake config updated"
+//	}
+func (h *TopologyHandler) SetHandshakeConfig(c *gin.Context) {
+	clusterID := getClusterID(c)
+	if clusterID == "" {
+		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+		return
+	}
+
+	// Parse request
+	var req models.HandshakeConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	if err := h.service.WithActor(getNodeID(c)).SetHandshakeConfig(clusterID, &req); err != nil {
+		if errors.Is(err, models.ErrInvalidRequest) {
+			respondError(c, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccessWithMessage(c, http.StatusOK, "Handshake config updated")
+}
+
+// SetRequestSigning handles PUT /api/v1/topology/request-signing
+//
+// Requires or stops requiring signed node requests in the cluster. Requires
+// cluster token authentication. Enable it only once every node's client signs
+// its requests; unsigned node requests are rejected from then on.
+//
+// Request body:
+//
+//	{
+//	  "required": true
+//	}
+//
+// Response:
+//
+//	{
+//	  "message": "Request signing updated"
+//	}
+func (h *TopologyHandler) SetRequestSigning(c *gin.Context) {
+	clusterID := getClusterID(c)
+	if clusterID == "" {
+		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+		return
+	}
+
+	// Parse request
+	var req models.ClusterRequestSigningRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	if err := h.service.SetRequestSigning(clusterID, *req.Required); err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccessWithMessage(c, http.StatusOK, "Request signing updated")
+}
+
+// SetRoutePolicy handles PUT /api/v1/topology/route-policy
+//
+// Replaces the rules node routes must follow in the cluster. Requires cluster
+// token authentication. Making the cluster IPv4-only fails while any node
+// still advertises an IPv6 route, and disallowing overlapping routes fails
+// with 409 Conflict while two nodes' routes overlap.
+//
+// Request body:
+//
+//	{
+//	  "ipv4_only": true
+//	}
+//
+// Response:
+//
+//	{
+//	  "message": "Route policy updated"
+//	}
+func (h *TopologyHandler) SetRoutePolicy(c *gin.Context) {
+	clusterID := getClusterID(c)
+	if clusterID == "" {
+		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+		return
+	}
+
+	// Parse request
+	var req models.RoutePolicy
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	if err := h.service.SetRoutePolicy(clusterID, &req); err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccessWithMessage(c, http.StatusOK, "Route policy updated")
+}
+
+// ValidateConfig handles GET /api/v1/config/validate
+//
+// Runs the config generator against the cluster's current topology and reports
+// problems that would produce a broken or degraded Nebula network. Requires an
+// admin node token.
+//
+// Response:
+//
+//	{
+//	  "data": {
+//	    "valid": false,
+//	    "representative_node_id": "uuid",
+//	    "errors": [
+//	      {
+//	        "severity": "error",
+//	        "code": "no_lighthouse",
+//	        "message": "cluster has no lighthouse; nodes behind NAT cannot discover each other"
+//	      }
+//	    ],
+//	    "warnings": []
+//	  }
+//	}
+func (h *TopologyHandler) ValidateConfig(c *gin.Context) {
+	clusterID := getClusterID(c)
+	if clusterID == "" {
+		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+		return
+	}
+
+	report, err := h.service.ValidateConfig(clusterID)
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, report)
+}
+
+// GetConnectivity handles GET /api/v1/config/connectivity
+//
+// Evaluates which nodes should be able to reach which for one kind of traffic,
+// from each node's generated config: peers must be discoverable through a
+// lighthouse and the flow must pass the sender's outbound and the receiver's
+// inbound firewall rules (deny-by-default). Reachability through relays, for
+// when direct connections fail, is reported separately. The proto query parameter selects
+// tcp, udp or icmp (default icmp) and port the destination port. Requires an
+// admin node token.
+//
+// Response:
+//
+//	{
+//	  "data": {
+//	    "flow": {"proto": "tcp", "port": 443},
+//	    "nodes": [{"node_id": "uuid-a", "name": "a"}, {"node_id": "uuid-b", "name": "b"}],
+//	    "reachable": [[false, true], [true, false]],
+//	    "relayed": [[false, true], [true, false]],
+//	    "isolated": [],
+//	    "relay_isolated": []
+//	  }
+//	}
+func (h *TopologyHandler) GetConnectivity(c *gin.Context) {
+	clusterID := getClusterID(c)
+	if clusterID == "" {
+		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+		return
+	}
+
+	flow := config.Flow{Proto: c.DefaultQuery("proto", config.ProtoICMP)}
+	if portStr := c.Query("port"); portStr != "" {
+		v, err := strconv.Atoi(portStr)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "invalid_request", "Invalid port parameter")
+			return
+		}
+		flow.Port = v
+	}
+	if err := flow.Validate(); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	matrix, err := h.service.ConnectivityMatrix(clusterID, flow)
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, matrix)
+}
+
+// WhatIf handles POST /api/v1/config/connectivity/what-if
+//
+// Simulates proposed topology changes (promoting or demoting lighthouses and
+// relays, changing the relay hop limit) against a copy of the cluster's
+// topology and returns the connectivity matrix before and after, with the
+// pairs of nodes that lose or gain reachability. Nothing is persisted.
+// Requires an admin node token.
+//
+// Request Body:
+//
+//	{
+//	  "proto": "icmp",
+//	  "changes": [{"action": "unset_relay", "node_id": "uuid"}]
+//	}
+//
+// Response:
+//
+//	{
+//	  "data": {
+//	    "current": {...},
+//	    "proposed": {...},
+//	    "lost": [{"from": "uuid-a", "to": "uuid-b", "relayed": true}],
+//	    "gained": [],
+//	    "newly_isolated": [],
+//	    "newly_relay_isolated": ["uuid-a", "uuid-b"]
+//	  }
+//	}
+func (h *TopologyHandler) WhatIf(c *gin.Context) {
+	clusterID := getClusterID(c)
+	if clusterID == "" {
+		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+		return
+	}
+
+	var req models.TopologyWhatIfRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	flow := config.Flow{Proto: req.Proto, Port: req.Port}
+	if flow.Proto == "" {
+		flow.Proto = config.ProtoICMP
+	}
+	if err := flow.Validate(); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	result, err := h.service.WhatIf(clusterID, flow, req.Changes)
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, result)
+}
+
+// GetEffectiveConfig handles GET /api/v1/nodes/:id/effective-config
+//
+// Returns a node's fully-resolved Nebula configuration as computed by the
+// server-side config generator, for diagnosing what a node should be running.
+// Peers are referenced by node ID where Nebula would use an overlay IP.
+// Requires an admin node token; the node must be in the caller's cluster.
+//
+// Response:
+//
+//	{
+//	  "data": {
+//	    "node_id": "uuid",
+//	    "name": "web-1",
+//	    "cluster_id": "uuid",
+//	    "config_version": 12,
+//	    "routes": ["10.1.0.0/24"],
+//	    "config": {
+//	      "lighthouse": {"am_lighthouse": false, "interval": 60, "hosts": ["lighthouse-uuid"]},
+//	      "relay": {"relays": ["relay-uuid"], "am_relay": false, "use_relays": true},
+//	      "tun": {"disabled": false, "dev": "nebula1", "mtu": 1300},
+//	      ...
+//	    }
+//	  }
+//	}
+func (h *TopologyHandler) GetEffectiveConfig(c *gin.Context) {
+	clusterID := getClusterID(c)
+	if clusterID == "" {
+		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+		return
+	}
+
+	effective, err := h.service.EffectiveConfig(clusterID, c.Param("id"))
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, effective)
+}
+
 // GetTopology handles GET /api/v1/topology
 //
 // Returns the complete topology for the cluster including lighthouses, relays, and routes.
@@ -290,6 +932,21 @@ func (h *TopologyHandler) UnassignRelay(c *gin.Context) {
 //	  ],
 //	  "routes": {
 //	    "node-id-1": ["10.0.1.0/24"]
+//	  },
+//	  "relay_stats": {
+//	    "relay_count": 1,
+//	    "node_count": 5,
+//	    "relay_fraction": 0.2,
+//	    "max_relay_hops": 1
+//	  },
+//	  "static_host_map": {
+//	    "10.42.0.50": ["198.51.100.50:4242"]
+//	  },
+//	  "preferred_ranges": ["192.168.0.0/16"],
+//	  "cipher": "aes",
+//	  "handshake": {
+//	    "try_interval": "100ms",
+//	    "retries": 20
 //	  }
 //	}
 func (h *TopologyHandler) GetTopology(c *gin.Context) {
@@ -308,15 +965,119 @@ func (h *TopologyHandler) GetTopology(c *gin.Context) {
 	respondSuccess(c, http.StatusOK, topology)
 }
 
+// GetTopologyDOT handles GET /api/v1/clusters/:cluster_id/topology.dot
+//
+// Returns the cluster topology as a Graphviz DOT graph (nodes, lighthouse
+// links, relay links and advertised routes) that renders with `dot -Tpng`.
+// Requires the cluster token.
+//
+// Response: text/vnd.graphviz
+//
+//	digraph "nebula-<cluster_id>" {
+//		"node-a" [label="lighthouse\n203.0.113.1:4242", shape=doubleoctagon];
+//		"node-b" -> "node-a" [style=dashed, label="lighthouse"];
+//	}
+func (h *TopologyHandler) GetTopologyDOT(c *gin.Context) {
+	clusterID := c.Param("cluster_id")
+	if clusterID != getClusterID(c) {
+		respondError(c, http.StatusNotFound, "not_found", "Cluster not found")
+		return
+	}
+
+	dot, err := h.service.TopologyDOT(clusterID)
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(dot))
+}
+
+// ExportSnapshot handles GET /api/v1/topology/snapshot
+//
+// Returns a portable topology snapshot for the cluster. Routes are additionally
+// keyed by node name so the snapshot can be imported into another cluster.
+//
+// Response:
+//
+//	{
+//	  "lighthouses": [...],
+//	  "relays": [...],
+//	  "routes": {
+//	    "node-id-1": ["10.0.1.0/24"]
+//	  },
+//	  "routes_by_name": {
+//	    "node-1": ["10.0.1.0/24"]
+//	  }
+//	}
+func (h *TopologyHandler) ExportSnapshot(c *gin.Context) {
+	clusterID := getClusterID(c)
+	if clusterID == "" {
+		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+		return
+	}
+
+	snapshot, err := h.service.ExportSnapshot(clusterID)
+	if err != nil {
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, snapshot)
+}
+
+// ImportSnapshot handles PUT /api/v1/topology/snapshot
+//
+// Replaces the cluster topology with the provided snapshot, matching nodes
+// by name. Requires cluster token authentication. If the snapshot references
+// nodes that do not exist in the cluster, nothing is applied and the missing
+// names are returned in the error message.
+//
+// Request body: a snapshot as returned by GET /api/v1/topology/snapshot.
+//
+// Response:
+//
+//	{
+//	  "message": "Topology snapshot imported"
+//	}
+func (h *TopologyHandler) ImportSnapshot(c *gin.Context) {
+	clusterID := getClusterID(c)
+	if clusterID == "" {
+		respondError(c, http.StatusUnauthorized, "unauthorized", "Authentication required")
+		return
+	}
+
+	var snapshot service.TopologyInfo
+	if err := c.ShouldBindJSON(&snapshot); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	if err := h.service.WithActor(getNodeID(c)).ImportSnapshot(clusterID, &snapshot); err != nil {
+		if errors.Is(err, models.ErrSnapshotNodesMissing) {
+			respondError(c, http.StatusBadRequest, "snapshot_nodes_missing", err.Error())
+			return
+		}
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccessWithMessage(c, http.StatusOK, "Topology snapshot imported")
+}
+
 // RotateClusterToken handles POST /api/v1/tokens/cluster/rotate
 //
-// Rotates the cluster token. Requires cluster token authentication.
-// Returns the new plaintext token (only time it's visible).
+// Rotates the cluster token. Requires authentication with the current
+// cluster token: a rotated-out token still valid during the overlap window
+// cannot rotate again. Returns the new plaintext token (only time it's
+// visible). The old token keeps working until previous_token_expires_at, or
+// stops immediately with ?revoke_previous=true.
 //
 // Response:
 //
 //	{
 //	  "token": "new-cluster-token-string",
+//	  "previous_token_expires_at": "2025-01-02T15:04:05Z",
 //	  "message": "Cluster token rotated successfully"
 //	}
 func (h *TopologyHandler) RotateClusterToken(c *gin.Context) {
@@ -326,15 +1087,31 @@ func (h *TopologyHandler) RotateClusterToken(c *gin.Context) {
 		return
 	}
 
-	newToken, err := h.service.RotateClusterToken(clusterID)
+	if previous, _ := c.Get("previous_cluster_token"); previous == true {
+		respondError(c, http.StatusForbidden, "forbidden", "The previous cluster token cannot rotate the token")
+		return
+	}
+
+	revokePrevious := false
+	if value := c.Query("revoke_previous"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "invalid_request", "Invalid revoke_previous parameter")
+			return
+		}
+		revokePrevious = parsed
+	}
+
+	newToken, expiresAt, err := h.service.RotateClusterToken(clusterID, revokePrevious)
 	if err != nil {
 		mapErrorToResponse(c, err)
 		return
 	}
 
 	respondSuccess(c, http.StatusOK, gin.H{
-		"token":   newToken,
-		"message": "Cluster token rotated successfully",
+		"token":                     newToken,
+		"previous_token_expires_at": expiresAt.UTC(),
+		"message":                   "Cluster token rotated successfully",
 	})
 }
 
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"nebulagc.io/models"
+	"nebulagc.io/server/internal/service"
+)
+
+// VersionHandler handles batched config version endpoints.
+type VersionHandler struct {
+	service *service.VersionService
+	guard   func(c *gin.Context) service.AuthGuard
+}
+
+// NewVersionHandler creates a new version handler.
+//
+// Parameters:
+//   - service: Version service for business logic
+//   - guard: Returns the auth lockout and failure accounting for a request (nil = none)
+//
+// Returns:
+//   - Configured VersionHandler
+func NewVersionHandler(service *service.VersionService, guard func(c *gin.Context) service.AuthGuard) *VersionHandler {
+	return &VersionHandler{
+		service: service,
+		guard:   guard,
+	}
+}
+
+// GetVersions handles POST /api/v1/config/versions
+//
+// Returns the current config version of up to models.MaxVersionBatchSize
+// clusters. Credentials are carried per entry rather than in headers, so
+// entries that fail authentication are reported individually. Each of them
+// counts toward the auth lockout, and a locked out source gets 429 Too Many
+// Requests with Retry-After for the whole batch.
+//
+// Request body:
+//
+//	{
+//	  "clusters": [
+//	    {"tenant_id": "...", "cluster_id": "...", "node_token": "..."},
+//	    {"tenant_id": "...", "cluster_id": "...", "cluster_token": "..."}
+//	  ]
+//	}
+//
+// Response:
+//
+//	{
+//	  "versions": [
+//	    {"tenant_id": "...", "cluster_id": "...", "version": 42},
+//	    {"tenant_id": "...", "cluster_id": "...", "version": 0, "error": "unauthorized"}
+//	  ]
+//	}
+func (h *VersionHandler) GetVersions(c *gin.Context) {
+	var req models.VersionBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	var guard service.AuthGuard
+	if h.guard != nil {
+		guard = h.guard(c)
+	}
+
+	versions, err := h.service.GetVersions(req.Clusters, guard)
+	if err != nil {
+		var lockedOut *models.AuthLockedOutError
+		if errors.As(err, &lockedOut) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedOut.RetryAfter.Seconds()))))
+			respondError(c, http.StatusTooManyRequests, "too_many_auth_failures", "Too many failed authentication attempts")
+			return
+		}
+		if errors.Is(err, models.ErrInvalidRequest) {
+			respondError(c, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+		mapErrorToResponse(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, gin.H{
+		"versions": versions,
+	})
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"database/sql"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"nebulagc.io/pkg/token"
@@ -14,6 +15,14 @@ const (
 
 	// HeaderNodeToken is the header name for node token authentication.
 	HeaderNodeToken = "X-NebulaGC-Node-Token"
+
+	// HeaderOperatorToken is the header name for operator token authentication.
+	HeaderOperatorToken = "X-NebulaGC-Operator-Token"
+
+	// HeaderClusterID is the header naming the cluster a node or cluster token
+	// is meant for. It grants nothing; it only attributes failed
+	// authentications on routes without a cluster in their path.
+	HeaderClusterID = "X-NebulaGC-Cluster-ID"
 )
 
 // AuthConfig holds configuration for authentication middleware.
@@ -23,6 +32,20 @@ type AuthConfig struct {
 
 	// Secret is the HMAC secret for token validation.
 	Secret string
+
+	// FailureAlerter publishes an event when validation failures spike (nil = no alerts).
+	FailureAlerter *AuthFailureAlerter
+
+	// Lockout temporarily rejects sources with repeated failures (nil = no lockout).
+	Lockout *AuthLockout
+
+	// Signatures verifies signed node requests for clusters that require them.
+	// If nil, node requests to such clusters are rejected.
+	Signatures *RequestVerifier
+
+	// OperatorToken authenticates the control plane operator for endpoints
+	// spanning all tenants ("" = those endpoints are disabled).
+	OperatorToken string
 }
 
 // respondAuthError sends an authentication error response.
@@ -41,10 +64,14 @@ func respondAuthError(c *gin.Context) {
 //
 // This middleware:
 // - Extracts cluster token from X-NebulaGC-Cluster-Token header
+// - Rejects sources locked out after repeated failures (429)
 // - Validates token length (minimum 41 characters)
 // - Queries database for cluster by token hash
 // - Validates token using constant-time comparison
+// - Counts failures in metrics and reports spikes to the failure alerter
+// - Accepts the previous token until its rotation overlap window ends
 // - Sets tenant_id and cluster_id in context on success
+// - Sets previous_cluster_token when the previous token was used
 //
 // Usage: For endpoints that require cluster-level authentication
 // (e.g., topology management, cluster-wide operations)
@@ -58,43 +85,60 @@ func RequireClusterToken(config *AuthConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Extract token from header
 		providedToken := c.GetHeader(HeaderClusterToken)
+
+		// Reject locked out sources before looking the token up
+		if config.Lockout != nil {
+			if retryAfter, locked := config.Lockout.lockedOut(c, providedToken); locked {
+				respondLockedOut(c, retryAfter)
+				return
+			}
+		}
 		if providedToken == "" {
-			respondAuthError(c)
+			config.rejectAuth(c, tokenTypeCluster, authFailureMissing)
 			return
 		}
 
 		// Validate token length
 		if err := token.ValidateLength(providedToken); err != nil {
-			respondAuthError(c)
+			config.rejectAuth(c, tokenTypeCluster, authFailureMalformed)
 			return
 		}
 
-		// Query database for cluster with this token hash
+		// Query database for cluster with this token hash, or with it as the
+		// previous token while the rotation overlap window is open
 		var cluster struct {
-			ID             string
-			TenantID       string
-			ClusterTokenHash string
+			ID                string
+			TenantID          string
+			ClusterTokenHash  string
+			PreviousTokenHash string
+			PreviousExpiresAt int64
 		}
 
 		query := `
-			SELECT id, tenant_id, cluster_token_hash
+			SELECT id, tenant_id, cluster_token_hash,
+			       COALESCE(previous_cluster_token_hash, ''),
+			       COALESCE(previous_cluster_token_expires_at, 0)
 			FROM clusters
 			WHERE cluster_token_hash = ?
+			   OR (previous_cluster_token_hash = ? AND previous_cluster_token_expires_at > ?)
 			LIMIT 1
 		`
 
 		// Hash the provided token for lookup
 		providedHash := token.Hash(providedToken, config.Secret)
+		now := time.Now().Unix()
 
-		err := config.DB.QueryRow(query, providedHash).Scan(
+		err := config.DB.QueryRow(query, providedHash, providedHash, now).Scan(
 			&cluster.ID,
 			&cluster.TenantID,
 			&cluster.ClusterTokenHash,
+			&cluster.PreviousTokenHash,
+			&cluster.PreviousExpiresAt,
 		)
 
 		if err == sql.ErrNoRows {
 			// No cluster found with this token hash
-			respondAuthError(c)
+			config.rejectAuth(c, tokenTypeCluster, authFailureInvalid)
 			return
 		} else if err != nil {
 			// Database error
@@ -107,14 +151,19 @@ func RequireClusterToken(config *AuthConfig) gin.HandlerFunc {
 		}
 
 		// Validate token using constant-time comparison
-		if !token.Validate(providedToken, config.Secret, cluster.ClusterTokenHash) {
-			respondAuthError(c)
+		previousHash := ""
+		if cluster.PreviousExpiresAt > now {
+			previousHash = cluster.PreviousTokenHash
+		}
+		if !token.ValidateWithPrevious(providedToken, config.Secret, cluster.ClusterTokenHash, previousHash) {
+			config.rejectAuth(c, tokenTypeCluster, authFailureInvalid)
 			return
 		}
 
 		// Set authenticated context
 		c.Set("tenant_id", cluster.TenantID)
 		c.Set("cluster_id", cluster.ID)
+		c.Set("previous_cluster_token", !token.Validate(providedToken, config.Secret, cluster.ClusterTokenHash))
 
 		c.Next()
 	}
@@ -124,9 +173,12 @@ func RequireClusterToken(config *AuthConfig) gin.HandlerFunc {
 //
 // This middleware:
 // - Extracts node token from X-NebulaGC-Node-Token header
+// - Rejects sources locked out after repeated failures (429)
 // - Validates token length (minimum 41 characters)
 // - Queries database for node by token hash
 // - Validates token using constant-time comparison
+// - Verifies the request signature if the node's cluster requires signing
+// - Counts failures in metrics and reports spikes to the failure alerter
 // - Sets tenant_id, cluster_id, node_id, and is_admin in context on success
 //
 // Usage: For endpoints that require node-level authentication
@@ -141,30 +193,40 @@ func RequireNodeToken(config *AuthConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Extract token from header
 		providedToken := c.GetHeader(HeaderNodeToken)
+
+		// Reject locked out sources before looking the token up
+		if config.Lockout != nil {
+			if retryAfter, locked := config.Lockout.lockedOut(c, providedToken); locked {
+				respondLockedOut(c, retryAfter)
+				return
+			}
+		}
 		if providedToken == "" {
-			respondAuthError(c)
+			config.rejectAuth(c, tokenTypeNode, authFailureMissing)
 			return
 		}
 
 		// Validate token length
 		if err := token.ValidateLength(providedToken); err != nil {
-			respondAuthError(c)
+			config.rejectAuth(c, tokenTypeNode, authFailureMalformed)
 			return
 		}
 
 		// Query database for node with this token hash
 		var node struct {
-			ID        string
-			TenantID  string
-			ClusterID string
-			TokenHash string
-			IsAdmin   bool
+			ID            string
+			TenantID      string
+			ClusterID     string
+			TokenHash     string
+			IsAdmin       bool
+			RequireSigned bool
 		}
 
 		query := `
-			SELECT id, tenant_id, cluster_id, token_hash, is_admin
-			FROM nodes
-			WHERE token_hash = ?
+			SELECT n.id, n.tenant_id, n.cluster_id, n.token_hash, n.is_admin, c.require_signed_requests
+			FROM nodes n
+			JOIN clusters c ON c.id = n.cluster_id
+			WHERE n.token_hash = ? AND n.deleted_at IS NULL
 			LIMIT 1
 		`
 
@@ -177,11 +239,12 @@ func RequireNodeToken(config *AuthConfig) gin.HandlerFunc {
 			&node.ClusterID,
 			&node.TokenHash,
 			&node.IsAdmin,
+			&node.RequireSigned,
 		)
 
 		if err == sql.ErrNoRows {
 			// No node found with this token hash
-			respondAuthError(c)
+			config.rejectAuth(c, tokenTypeNode, authFailureInvalid)
 			return
 		} else if err != nil {
 			// Database error
@@ -195,10 +258,22 @@ func RequireNodeToken(config *AuthConfig) gin.HandlerFunc {
 
 		// Validate token using constant-time comparison
 		if !token.Validate(providedToken, config.Secret, node.TokenHash) {
-			respondAuthError(c)
+			config.rejectAuth(c, tokenTypeNode, authFailureInvalid)
 			return
 		}
 
+		// Verify the request signature if the cluster requires one
+		if node.RequireSigned {
+			reason := authFailureUnsigned
+			if config.Signatures != nil {
+				reason = config.Signatures.verify(c, node.ID, providedToken)
+			}
+			if reason != "" {
+				config.rejectAuth(c, tokenTypeNode, reason)
+				return
+			}
+		}
+
 		// Set authenticated context
 		c.Set("tenant_id", node.TenantID)
 		c.Set("cluster_id", node.ClusterID)
@@ -231,3 +306,59 @@ func RequireAdminNode() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireOperatorToken creates middleware that requires the operator token.
+//
+// Operator endpoints act across tenants (tenant provisioning, auth lockouts,
+// master step-down), so no node or cluster token grants access to them, not
+// even one of an admin node. The operator token is configured on the server
+// rather than stored per tenant.
+//
+// This middleware:
+// - Rejects every request with 403 if no operator token is configured
+// - Rejects sources locked out after repeated failures (429)
+// - Extracts the token from the X-NebulaGC-Operator-Token header
+// - Validates it using constant-time comparison
+// - Counts failures like node and cluster token failures
+//
+// Parameters:
+//   - config: Authentication configuration
+//
+// Returns:
+//   - Gin middleware handler function
+func RequireOperatorToken(config *AuthConfig) gin.HandlerFunc {
+	operatorHash := token.Hash(config.OperatorToken, config.Secret)
+
+	return func(c *gin.Context) {
+		if config.OperatorToken == "" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "operator_disabled",
+				"message": "Operator endpoints are disabled on this server",
+			})
+			c.Abort()
+			return
+		}
+
+		providedToken := c.GetHeader(HeaderOperatorToken)
+
+		// Reject locked out sources before checking the token
+		if config.Lockout != nil {
+			if retryAfter, locked := config.Lockout.lockedOut(c, providedToken); locked {
+				respondLockedOut(c, retryAfter)
+				return
+			}
+		}
+		if providedToken == "" {
+			config.rejectAuth(c, tokenTypeOperator, authFailureMissing)
+			return
+		}
+
+		if !token.Validate(providedToken, config.Secret, operatorHash) {
+			config.rejectAuth(c, tokenTypeOperator, authFailureInvalid)
+			return
+		}
+
+		c.Set("is_operator", true)
+		c.Next()
+	}
+}
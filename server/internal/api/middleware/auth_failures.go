@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"nebulagc.io/server/internal/events"
+	"nebulagc.io/server/internal/metrics"
+)
+
+// Token types used as the token_type label of auth failure metrics.
+const (
+	tokenTypeCluster  = "cluster"
+	tokenTypeNode     = "node"
+	tokenTypeOperator = "operator"
+)
+
+// Auth failure reasons used as the reason label of auth failure metrics.
+const (
+	// authFailureMissing means no token header was sent.
+	authFailureMissing = "missing"
+
+	// authFailureMalformed means the token has an invalid length.
+	authFailureMalformed = "malformed"
+
+	// authFailureInvalid means the token did not match any credential.
+	authFailureInvalid = "invalid"
+)
+
+// unknownCluster is the cluster label for failures that cannot be attributed
+// to an existing cluster.
+const unknownCluster = "unknown"
+
+// AuthFailureAlerter publishes an event when failed token validations for a
+// token type and cluster reach a threshold within a fixed window.
+//
+// At most one event is published per token type, cluster, and window, so a
+// sustained attack produces one alert per window rather than one per request.
+type AuthFailureAlerter struct {
+	threshold int
+	window    time.Duration
+	notifier  events.Notifier
+	now       func() time.Time
+
+	mu      sync.Mutex
+	windows map[string]*failureWindow
+}
+
+// failureWindow counts failures for one token type and cluster.
+type failureWindow struct {
+	start    time.Time
+	failures int
+}
+
+// NewAuthFailureAlerter creates an alerter that notifies once failures reach
+// threshold within window.
+//
+// Parameters:
+//   - threshold: Failures within a window that trigger an alert
+//   - window: Length of the counting window
+//   - notifier: Destination for alert events
+//
+// Returns:
+//   - Configured AuthFailureAlerter
+func NewAuthFailureAlerter(threshold int, window time.Duration, notifier events.Notifier) *AuthFailureAlerter {
+	return &AuthFailureAlerter{
+		threshold: threshold,
+		window:    window,
+		notifier:  notifier,
+		now:       time.Now,
+		windows:   make(map[string]*failureWindow),
+	}
+}
+
+// Record counts a failure and publishes an alert when the threshold is reached.
+//
+// clusterID must already be bucketed (an existing cluster or "unknown") so the
+// number of tracked windows stays bounded by the number of clusters.
+//
+// Parameters:
+//   - tokenType: Token type that failed ("cluster", "node" or "operator")
+//   - tenantID: Tenant of the cluster, empty if unknown
+//   - clusterID: Cluster the failure is attributed to
+func (a *AuthFailureAlerter) Record(tokenType, tenantID, clusterID string) {
+	now := a.now()
+	key := tokenType + "/" + clusterID
+
+	a.mu.Lock()
+	w, ok := a.windows[key]
+	if !ok || now.Sub(w.start) >= a.window {
+		w = &failureWindow{start: now}
+		a.windows[key] = w
+	}
+	w.failures++
+	fire := w.failures == a.threshold
+	a.mu.Unlock()
+
+	if !fire {
+		return
+	}
+
+	if clusterID == unknownCluster {
+		clusterID = ""
+	}
+	a.notifier.Notify(events.Event{
+		Type:      events.TypeAuthFailuresExceeded,
+		TenantID:  tenantID,
+		ClusterID: clusterID,
+		Time:      now,
+		Data: map[string]any{
+			"token_type": tokenType,
+			"failures":   a.threshold,
+			"window":     a.window.String(),
+		},
+	})
+}
+
+// tokenHeader returns the header carrying a token type.
+func tokenHeader(tokenType string) string {
+	switch tokenType {
+	case tokenTypeCluster:
+		return HeaderClusterToken
+	case tokenTypeOperator:
+		return HeaderOperatorToken
+	}
+	return HeaderNodeToken
+}
+
+// rejectAuth records a failed token validation in metrics, the failure
+// alerter, and the lockout, then sends the generic authentication error response.
+func (config *AuthConfig) rejectAuth(c *gin.Context, tokenType, reason string) {
+	tenantID, clusterID := config.claimedCluster(c)
+	config.recordFailure(c, tokenType, reason, c.GetHeader(tokenHeader(tokenType)), tenantID, clusterID)
+	respondAuthError(c)
+}
+
+// recordFailure records a failed token validation in metrics, the failure
+// alerter, and the lockout. clusterID must already be bucketed.
+func (config *AuthConfig) recordFailure(c *gin.Context, tokenType, reason, providedToken, tenantID, clusterID string) {
+	metrics.AuthFailures.WithLabelValues(tokenType, clusterID, reason).Inc()
+	if config.FailureAlerter != nil {
+		config.FailureAlerter.Record(tokenType, tenantID, clusterID)
+	}
+	if config.Lockout != nil {
+		config.Lockout.recordFailure(c, tokenType, providedToken)
+	}
+}
+
+// claimedCluster attributes a failed request to the cluster named in its
+// path or, for routes without one such as /config/bundle, in the
+// X-NebulaGC-Cluster-ID header the SDK sends.
+//
+// Only clusters that exist are returned; requests naming no cluster, or one
+// that does not exist, are bucketed as "unknown".
+func (config *AuthConfig) claimedCluster(c *gin.Context) (tenantID, clusterID string) {
+	clusterID = c.Param("cluster_id")
+	if clusterID == "" {
+		clusterID = c.GetHeader(HeaderClusterID)
+	}
+	return config.existingCluster(clusterID)
+}
+
+// existingCluster returns the tenant of clusterID, or "unknown" as the
+// cluster if clusterID is empty or names no existing cluster.
+func (config *AuthConfig) existingCluster(clusterID string) (tenantID, bucketed string) {
+	if clusterID == "" || config.DB == nil {
+		return "", unknownCluster
+	}
+
+	err := config.DB.QueryRow(`SELECT tenant_id FROM clusters WHERE id = ?`, clusterID).Scan(&tenantID)
+	if err != nil {
+		return "", unknownCluster
+	}
+	return tenantID, clusterID
+}
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"nebulagc.io/server/internal/events"
+	"nebulagc.io/server/internal/metrics"
+	"nebulagc.io/server/internal/testutil"
+)
+
+// recordingNotifier collects published events.
+type recordingNotifier struct {
+	events []events.Event
+}
+
+func (n *recordingNotifier) Notify(event events.Event) {
+	n.events = append(n.events, event)
+}
+
+// authFailureCounts gathers nebulagc_auth_failures_total keyed by
+// "token_type/cluster_id/reason".
+func authFailureCounts(t *testing.T) map[string]float64 {
+	t.Helper()
+
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	counts := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != "nebulagc_auth_failures_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			key := labels["token_type"] + "/" + labels["cluster_id"] + "/" + labels["reason"]
+			counts[key] = metric.GetCounter().GetValue()
+		}
+	}
+	return counts
+}
+
+func TestAuthMiddleware_CountsFailures(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	metrics.Registry = prometheus.NewRegistry()
+	metrics.AuthFailures.Reset()
+	metrics.Registry.MustRegister(metrics.AuthFailures)
+
+	db := testutil.OpenDB(t)
+	tenantID := testutil.Tenant(t, db, "Test Tenant")
+	clusterID, _ := testutil.Cluster(t, db, tenantID, "Test Cluster")
+
+	config := &AuthConfig{DB: db, Secret: testutil.TestHMACSecret}
+	router := gin.New()
+	router.GET("/clusters/:cluster_id", RequireClusterToken(config), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/node", RequireNodeToken(config), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(path, header, value string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if value != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected 401 for %s, got %d", path, w.Code)
+		}
+	}
+
+	wrongToken := "wrong-token-with-enough-characters-to-pass-length-check-123"
+	request("/clusters/"+clusterID, HeaderClusterToken, "")
+	request("/clusters/"+clusterID, HeaderClusterToken, "short")
+	request("/clusters/"+clusterID, HeaderClusterToken, wrongToken)
+	request("/clusters/"+clusterID, HeaderClusterToken, wrongToken)
+	request("/clusters/no-such-cluster", HeaderClusterToken, wrongToken)
+	request("/node", HeaderNodeToken, wrongToken)
+
+	want := map[string]float64{
+		"cluster/" + clusterID + "/missing":   1,
+		"cluster/" + clusterID + "/malformed": 1,
+		"cluster/" + clusterID + "/invalid":   2,
+		"cluster/unknown/invalid":             1,
+		"node/unknown/invalid":                1,
+	}
+	got := authFailureCounts(t)
+	if len(got) != len(want) {
+		t.Errorf("Expected %d label sets, got %d: %v", len(want), len(got), got)
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("Expected %s = %v, got %v", key, value, got[key])
+		}
+	}
+}
+
+func TestAuthFailureAlerter(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	notifier := &recordingNotifier{}
+	alerter := NewAuthFailureAlerter(3, time.Minute, notifier)
+	alerter.now = func() time.Time { return now }
+
+	// Below the threshold, and other keys do not count toward it
+	alerter.Record(tokenTypeCluster, "tenant-1", "cluster-1")
+	alerter.Record(tokenTypeCluster, "tenant-1", "cluster-1")
+	alerter.Record(tokenTypeNode, "", unknownCluster)
+	if len(notifier.events) != 0 {
+		t.Fatalf("Expected no alert below threshold, got %d", len(notifier.events))
+	}
+
+	// Reaching the threshold alerts once per window
+	alerter.Record(tokenTypeCluster, "tenant-1", "cluster-1")
+	alerter.Record(tokenTypeCluster, "tenant-1", "cluster-1")
+	if len(notifier.events) != 1 {
+		t.Fatalf("Expected one alert, got %d", len(notifier.events))
+	}
+	event := notifier.events[0]
+	if event.Type != events.TypeAuthFailuresExceeded || event.TenantID != "tenant-1" || event.ClusterID != "cluster-1" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+	if event.Data["token_type"] != tokenTypeCluster || event.Data["failures"] != 3 || event.Data["window"] != "1m0s" {
+		t.Errorf("Unexpected event data: %v", event.Data)
+	}
+
+	// A new window starts counting from zero
+	now = now.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		alerter.Record(tokenTypeNode, "", unknownCluster)
+	}
+	if len(notifier.events) != 2 {
+		t.Fatalf("Expected a second alert in the new window, got %d", len(notifier.events))
+	}
+	if notifier.events[1].ClusterID != "" {
+		t.Errorf("Expected unattributed alert to have no cluster, got %q", notifier.events[1].ClusterID)
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestAuthGuard applies the lockout and failure accounting of the auth
+// middleware to credentials validated outside of it, such as the per-entry
+// tokens of the batch version endpoint, which arrive in the request body.
+//
+// Without it, such an endpoint would let a source try many tokens per
+// request without ever being locked out.
+type RequestAuthGuard struct {
+	config *AuthConfig
+	c      *gin.Context
+}
+
+// Guard returns a RequestAuthGuard for the request in c.
+//
+// Parameters:
+//   - c: Gin context of the request whose source failures are attributed to
+//
+// Returns:
+//   - RequestAuthGuard bound to the request
+func (config *AuthConfig) Guard(c *gin.Context) *RequestAuthGuard {
+	return &RequestAuthGuard{config: config, c: c}
+}
+
+// LockedOut reports whether the request's source is locked out for
+// providedToken and how long until it may retry.
+//
+// Parameters:
+//   - providedToken: Token about to be validated
+//
+// Returns:
+//   - Time until the lockout ends
+//   - true if the token must not be validated
+func (g *RequestAuthGuard) LockedOut(providedToken string) (time.Duration, bool) {
+	if g.config.Lockout == nil {
+		return 0, false
+	}
+	return g.config.Lockout.lockedOut(g.c, providedToken)
+}
+
+// RecordFailure counts a failed validation the same way as the auth
+// middleware: in metrics, the failure alerter, and the lockout.
+//
+// Parameters:
+//   - tokenType: "node" or "cluster"
+//   - reason: "missing", "malformed" or "invalid"
+//   - providedToken: Token that failed
+//   - clusterID: Cluster the caller claimed (bucketed as "unknown" if it does not exist)
+func (g *RequestAuthGuard) RecordFailure(tokenType, reason, providedToken, clusterID string) {
+	tenantID, clusterID := g.config.existingCluster(clusterID)
+	g.config.recordFailure(g.c, tokenType, reason, providedToken, tenantID, clusterID)
+}
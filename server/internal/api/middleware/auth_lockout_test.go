@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"nebulagc.io/server/internal/testutil"
+)
+
+// lockoutRouter serves /test behind node token auth with the given lockout.
+func lockoutRouter(t *testing.T, lockout *AuthLockout) (router *gin.Engine, validToken string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.OpenDB(t)
+	tenantID := testutil.Tenant(t, db, "Test Tenant")
+	clusterID, _ := testutil.Cluster(t, db, tenantID, "Test Cluster")
+	_, validToken = testutil.Node(t, db, tenantID, clusterID, "node-1", false)
+
+	router = gin.New()
+	router.Use(RequireNodeToken(&AuthConfig{DB: db, Secret: testutil.TestHMACSecret, Lockout: lockout}))
+	router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router, validToken
+}
+
+// lockoutRequest sends a node token from a source IP.
+func lockoutRequest(router *gin.Engine, sourceIP, nodeToken string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.RemoteAddr = sourceIP + ":40000"
+	req.Header.Set(HeaderNodeToken, nodeToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthLockout_LocksOutAndCoolsDown(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	lockout := NewAuthLockout(3, time.Minute, 10*time.Minute, LockoutScopeIP)
+	lockout.now = func() time.Time { return now }
+	router, validToken := lockoutRouter(t, lockout)
+
+	wrongToken := "wrong-token-with-enough-characters-to-pass-length-check-123"
+	for i := 0; i < 3; i++ {
+		if w := lockoutRequest(router, "203.0.113.7", wrongToken); w.Code != http.StatusUnauthorized {
+			t.Fatalf("Failure %d: expected 401, got %d", i+1, w.Code)
+		}
+	}
+
+	// Locked out: even a valid token from the same IP is rejected
+	w := lockoutRequest(router, "203.0.113.7", validToken)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 while locked out, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "600" {
+		t.Errorf("Expected Retry-After 600, got %q", got)
+	}
+
+	// Other sources are unaffected
+	if w := lockoutRequest(router, "198.51.100.1", validToken); w.Code != http.StatusOK {
+		t.Errorf("Expected other IP to authenticate, got %d", w.Code)
+	}
+
+	active := lockout.Active()
+	if len(active) != 1 || active[0].SourceIP != "203.0.113.7" || active[0].Failures != 3 ||
+		active[0].TokenType != tokenTypeNode || !active[0].LockedUntil.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("Unexpected active lockouts: %+v", active)
+	}
+
+	// After the cooldown the source may authenticate again
+	now = now.Add(10 * time.Minute)
+	if w := lockoutRequest(router, "203.0.113.7", validToken); w.Code != http.StatusOK {
+		t.Errorf("Expected valid token after cooldown, got %d", w.Code)
+	}
+	if active := lockout.Active(); len(active) != 0 {
+		t.Errorf("Expected no active lockouts after cooldown, got %+v", active)
+	}
+}
+
+func TestAuthLockout_FailuresOutsideWindowDoNotLockOut(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	lockout := NewAuthLockout(3, time.Minute, 10*time.Minute, LockoutScopeIP)
+	lockout.now = func() time.Time { return now }
+	router, _ := lockoutRouter(t, lockout)
+
+	wrongToken := "wrong-token-with-enough-characters-to-pass-length-check-123"
+	for i := 0; i < 4; i++ {
+		if w := lockoutRequest(router, "203.0.113.7", wrongToken); w.Code != http.StatusUnauthorized {
+			t.Fatalf("Failure %d: expected 401, got %d", i+1, w.Code)
+		}
+		now = now.Add(40 * time.Second)
+	}
+}
+
+func TestAuthLockout_IPTokenScopeSparesSharedNAT(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	lockout := NewAuthLockout(3, time.Minute, 10*time.Minute, LockoutScopeIPToken)
+	lockout.now = func() time.Time { return now }
+	router, validToken := lockoutRouter(t, lockout)
+
+	wrongToken := "wrong-token-with-enough-characters-to-pass-length-check-123"
+	for i := 0; i < 3; i++ {
+		lockoutRequest(router, "203.0.113.7", wrongToken)
+	}
+
+	// The failing token prefix is locked out from the shared IP...
+	if w := lockoutRequest(router, "203.0.113.7", wrongToken); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 for the failing token, got %d", w.Code)
+	}
+
+	// ...but a different node behind the same NAT keeps working
+	if w := lockoutRequest(router, "203.0.113.7", validToken); w.Code != http.StatusOK {
+		t.Errorf("Expected other token from the shared IP to authenticate, got %d", w.Code)
+	}
+
+	active := lockout.Active()
+	if len(active) != 1 || active[0].TokenFingerprint == "" {
+		t.Fatalf("Expected one fingerprinted lockout, got %+v", active)
+	}
+	if active[0].TokenFingerprint == wrongToken[:lockoutTokenPrefixLen] {
+		t.Error("Expected the fingerprint not to reveal the token prefix")
+	}
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"nebulagc.io/pkg/token"
+
+	"nebulagc.io/server/internal/testutil"
+)
+
+func TestRequireClusterToken_PreviousTokenOverlap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.OpenDB(t)
+	tenantID := testutil.Tenant(t, db, "Test Tenant")
+	clusterID, oldToken := testutil.Cluster(t, db, tenantID, "Test Cluster")
+
+	// Rotate: the old hash becomes the previous token for an hour
+	newToken, _ := token.Generate()
+	_, err := db.Exec(`
+		UPDATE clusters
+		SET previous_cluster_token_hash = cluster_token_hash,
+		    previous_cluster_token_expires_at = ?,
+		    cluster_token_hash = ?
+		WHERE id = ?
+	`, time.Now().Add(time.Hour).Unix(), token.Hash(newToken, testutil.TestHMACSecret), clusterID)
+	if err != nil {
+		t.Fatalf("Failed to rotate token: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(RequireClusterToken(&AuthConfig{DB: db, Secret: testutil.TestHMACSecret}))
+	router.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("cluster_id"))
+	})
+
+	request := func(clusterToken string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set(HeaderClusterToken, clusterToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for name, clusterToken := range map[string]string{"new": newToken, "previous": oldToken} {
+		w := request(clusterToken)
+		if w.Code != http.StatusOK || w.Body.String() != clusterID {
+			t.Errorf("Expected %s token to authenticate for %s, got %d %s", name, clusterID, w.Code, w.Body.String())
+		}
+	}
+
+	// Close the overlap window
+	if _, err := db.Exec(`UPDATE clusters SET previous_cluster_token_expires_at = ? WHERE id = ?`,
+		time.Now().Add(-time.Second).Unix(), clusterID); err != nil {
+		t.Fatalf("Failed to expire previous token: %v", err)
+	}
+
+	if w := request(oldToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected previous token to be rejected after the overlap, got %d", w.Code)
+	}
+	if w := request(newToken); w.Code != http.StatusOK {
+		t.Errorf("Expected new token to authenticate, got %d", w.Code)
+	}
+}
+
+func TestRequireNodeToken_SoftDeletedNode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.OpenDB(t)
+	tenantID := testutil.Tenant(t, db, "Test Tenant")
+	clusterID, _ := testutil.Cluster(t, db, tenantID, "Test Cluster")
+	nodeID, nodeToken := testutil.Node(t, db, tenantID, clusterID, "node-1", false)
+
+	router := gin.New()
+	router.Use(RequireNodeToken(&AuthConfig{DB: db, Secret: testutil.TestHMACSecret}))
+	router.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("node_id"))
+	})
+
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set(HeaderNodeToken, nodeToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := request(); w.Code != http.StatusOK || w.Body.String() != nodeID {
+		t.Fatalf("Expected live node to authenticate, got %d %s", w.Code, w.Body.String())
+	}
+
+	if _, err := db.Exec(`UPDATE nodes SET deleted_at = ? WHERE id = ?`, time.Now().Unix(), nodeID); err != nil {
+		t.Fatalf("Failed to soft-delete node: %v", err)
+	}
+
+	if w := request(); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected soft-deleted node to be rejected, got %d", w.Code)
+	}
+}
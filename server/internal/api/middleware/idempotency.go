@@ -0,0 +1,277 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"nebulagc.io/pkg/bundle"
+)
+
+const (
+	// HeaderIdempotencyKey is the header carrying a request's idempotency key.
+	HeaderIdempotencyKey = "Idempotency-Key"
+
+	// HeaderIdempotentReplayed marks a response replayed for a repeated key.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// DefaultIdempotencyTTL is how long the response to a keyed request is kept.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// maxIdempotencyKeyLen is the longest accepted idempotency key.
+const maxIdempotencyKeyLen = 255
+
+// maxIdempotentBodySize is the largest request body buffered to fingerprint a
+// keyed request: one byte over the largest config bundle, so the bundle
+// handler still sees an oversized upload and rejects it with its own error.
+const maxIdempotentBodySize = bundle.MaxBundleSize + 1
+
+// IdempotencyCache remembers the responses to POST requests sent with an
+// Idempotency-Key header, so a client retrying a request whose response it
+// never received gets the original response instead of, for example, a second
+// node.
+//
+// Keys are scoped to the authenticated caller and bound to the request they
+// were first used with. Responses are kept in memory by the instance that
+// processed the request; a retry that lands on a new master after a failover
+// is processed again.
+type IdempotencyCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]*idempotencyEntry // caller + key -> response
+	lastSweep time.Time
+}
+
+// idempotencyEntry is the response to one keyed request.
+type idempotencyEntry struct {
+	fingerprint string
+	done        chan struct{} // closed once the first request completes
+	status      int
+	contentType string
+	body        []byte
+	expiresAt   time.Time
+}
+
+// NewIdempotencyCache creates an idempotency cache.
+//
+// Parameters:
+//   - ttl: How long a response is replayed for its key (0 = DefaultIdempotencyTTL)
+//
+// Returns:
+//   - Configured IdempotencyCache
+func NewIdempotencyCache(ttl time.Duration) *IdempotencyCache {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+
+	return &IdempotencyCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]*idempotencyEntry),
+	}
+}
+
+// Idempotency creates middleware that deduplicates keyed POST requests.
+//
+// It must run after authentication. The first request with a key is processed
+// and its response is kept unless it is a 5xx error, which the client may
+// retry. Later requests with the same key from the same caller get that
+// response replayed with the Idempotent-Replayed header, waiting for the first
+// one if it is still being processed. Requests without a key pass through.
+//
+// The body of a keyed request is buffered to fingerprint it, so the middleware
+// must also run after any authorization check of the route.
+//
+// Errors:
+//   - 400 invalid_idempotency_key: The key is longer than 255 characters
+//   - 413 payload_too_large: The body of a keyed request is larger than the
+//     largest config bundle (plus one byte)
+//   - 422 idempotency_key_reused: The key was used for a different request
+//
+// Parameters:
+//   - cache: Response cache shared by the routes (nil = no deduplication)
+//
+// Returns:
+//   - Gin middleware handler function
+func Idempotency(cache *IdempotencyCache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderIdempotencyKey)
+		caller := idempotencyCaller(c)
+		if cache == nil || key == "" || caller == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLen {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_idempotency_key",
+				"message": "Idempotency key must be at most 255 characters",
+			})
+			c.Abort()
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodySize))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+					"error":   "payload_too_large",
+					"message": "Payload exceeds size limit",
+				})
+				c.Abort()
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":   "invalid_request",
+					"message": "Failed to read request body",
+				})
+				c.Abort()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		sum := sha256.Sum256(append([]byte(c.Request.URL.RequestURI()+"\n"), body...))
+
+		cache.serve(c, caller+"/"+key, hex.EncodeToString(sum[:]))
+	}
+}
+
+// idempotencyCaller returns who a request is authenticated as, or "" if the
+// route has no authentication.
+func idempotencyCaller(c *gin.Context) string {
+	if nodeID := c.GetString("node_id"); nodeID != "" {
+		return "node/" + nodeID
+	}
+	if clusterID := c.GetString("cluster_id"); clusterID != "" {
+		return "cluster/" + clusterID
+	}
+	if c.GetBool("is_operator") {
+		return "operator"
+	}
+	return ""
+}
+
+// serve processes a keyed request, or replays the response to its first use.
+func (ic *IdempotencyCache) serve(c *gin.Context, key, fingerprint string) {
+	for {
+		now := ic.now()
+
+		ic.mu.Lock()
+		ic.sweep(now)
+		entry, ok := ic.entries[key]
+		if ok && !now.Before(entry.expiresAt) {
+			delete(ic.entries, key)
+			ok = false
+		}
+		if !ok {
+			entry = &idempotencyEntry{
+				fingerprint: fingerprint,
+				done:        make(chan struct{}),
+				expiresAt:   now.Add(ic.ttl),
+			}
+			ic.entries[key] = entry
+			ic.mu.Unlock()
+
+			ic.process(c, key, entry)
+			return
+		}
+		ic.mu.Unlock()
+
+		if entry.fingerprint != fingerprint {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":   "idempotency_key_reused",
+				"message": "Idempotency key was already used for a different request",
+			})
+			c.Abort()
+			return
+		}
+
+		select {
+		case <-entry.done:
+		case <-c.Request.Context().Done():
+			c.Abort()
+			return
+		}
+
+		// A first attempt that failed with a 5xx error is forgotten; process
+		// this one in its place
+		if entry.status == 0 {
+			continue
+		}
+
+		c.Header(HeaderIdempotentReplayed, "true")
+		c.Data(entry.status, entry.contentType, entry.body)
+		c.Abort()
+		return
+	}
+}
+
+// process runs the handlers for the first request with a key and keeps the
+// response unless it is a server error.
+func (ic *IdempotencyCache) process(c *gin.Context, key string, entry *idempotencyEntry) {
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+
+	completed := false
+	defer func() {
+		ic.mu.Lock()
+		if completed && recorder.Status() < http.StatusInternalServerError {
+			entry.expiresAt = ic.now().Add(ic.ttl)
+			entry.status = recorder.Status()
+			entry.contentType = recorder.Header().Get("Content-Type")
+			entry.body = recorder.body.Bytes()
+		} else {
+			delete(ic.entries, key)
+		}
+		ic.mu.Unlock()
+		close(entry.done)
+	}()
+
+	c.Next()
+	completed = true
+}
+
+// sweep forgets expired responses, at most once per TTL. Must hold ic.mu.
+func (ic *IdempotencyCache) sweep(now time.Time) {
+	if now.Sub(ic.lastSweep) < ic.ttl {
+		return
+	}
+	ic.lastSweep = now
+
+	for key, entry := range ic.entries {
+		if entry.status != 0 && !now.Before(entry.expiresAt) {
+			delete(ic.entries, key)
+		}
+	}
+}
+
+// responseRecorder copies the response body while writing it to the client.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write writes data to the client and the copy.
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+// WriteString writes s to the client and the copy.
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
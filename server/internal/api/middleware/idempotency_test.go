@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idempotencyRouter serves POST /test behind the idempotency middleware. The
+// caller is taken from the X-Node header in place of node token auth, and the
+// handler answers with the number of times it ran or with the status in the
+// X-Status header.
+func idempotencyRouter(cache *IdempotencyCache, calls *int, mu *sync.Mutex) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("node_id", c.GetHeader("X-Node"))
+	})
+	router.Use(Idempotency(cache))
+	router.POST("/test", func(c *gin.Context) {
+		mu.Lock()
+		*calls++
+		n := *calls
+		mu.Unlock()
+		if status := c.GetHeader("X-Status"); status != "" {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"call": n})
+	})
+	return router
+}
+
+// idempotencyRequest sends a keyed POST as a node.
+func idempotencyRequest(router *gin.Engine, node, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+	req.Header.Set("X-Node", node)
+	if key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysResponse(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	router := idempotencyRouter(NewIdempotencyCache(time.Hour), &calls, &mu)
+
+	first := idempotencyRequest(router, "node-1", "key-1", `{"name":"a"}`)
+	second := idempotencyRequest(router, "node-1", "key-1", `{"name":"a"}`)
+	if calls != 1 {
+		t.Fatalf("Expected the handler to run once, ran %d times", calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("Replay = %d %q, want %d %q", second.Code, second.Body.String(), first.Code, first.Body.String())
+	}
+	if second.Header().Get(HeaderIdempotentReplayed) != "true" || first.Header().Get(HeaderIdempotentReplayed) != "" {
+		t.Errorf("Expected only the replay to carry %s", HeaderIdempotentReplayed)
+	}
+	if got := second.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/json") {
+		t.Errorf("Replay Content-Type = %q, want application/json", got)
+	}
+
+	// Keys are scoped to the caller, and requests without a key always run
+	idempotencyRequest(router, "node-2", "key-1", `{"name":"a"}`)
+	idempotencyRequest(router, "node-1", "", `{"name":"a"}`)
+	idempotencyRequest(router, "node-1", "", `{"name":"a"}`)
+	if calls != 4 {
+		t.Errorf("Expected 4 handler runs, got %d", calls)
+	}
+}
+
+func TestIdempotency_RejectsReusedKey(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	router := idempotencyRouter(NewIdempotencyCache(time.Hour), &calls, &mu)
+
+	idempotencyRequest(router, "node-1", "key-1", `{"name":"a"}`)
+	if w := idempotencyRequest(router, "node-1", "key-1", `{"name":"b"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Reused key for another body = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	if w := idempotencyRequest(router, "node-1", strings.Repeat("k", 256), ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Overlong key = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if calls != 1 {
+		t.Errorf("Expected the handler to run once, ran %d times", calls)
+	}
+}
+
+func TestIdempotency_RejectsOversizedBody(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	router := idempotencyRouter(NewIdempotencyCache(time.Hour), &calls, &mu)
+
+	body := strings.Repeat("x", maxIdempotentBodySize+1)
+	if w := idempotencyRequest(router, "node-1", "key-1", body); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Oversized keyed request = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+	if calls != 0 {
+		t.Errorf("Expected the handler not to run, ran %d times", calls)
+	}
+
+	// The limit itself is accepted and left to the handler
+	if w := idempotencyRequest(router, "node-1", "key-2", body[1:]); w.Code != http.StatusCreated {
+		t.Errorf("Keyed request at the limit = %d, want %d", w.Code, http.StatusCreated)
+	}
+}
+
+func TestIdempotency_ServerErrorsAndExpiry(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	now := time.Unix(1700000000, 0)
+	cache := NewIdempotencyCache(time.Hour)
+	cache.now = func() time.Time { return now }
+	router := idempotencyRouter(cache, &calls, &mu)
+
+	// A 5xx response is not kept, so the retry runs the handler again
+	req := httptest.NewRequest(http.MethodPost, "/test", nil)
+	req.Header.Set("X-Node", "node-1")
+	req.Header.Set(HeaderIdempotencyKey, "key-1")
+	req.Header.Set("X-Status", "500")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	idempotencyRequest(router, "node-1", "key-1", "")
+	idempotencyRequest(router, "node-1", "key-1", "")
+	if calls != 2 {
+		t.Fatalf("Expected 2 handler runs after a server error, got %d", calls)
+	}
+
+	// Once the TTL has passed the key is forgotten
+	now = now.Add(time.Hour)
+	if w := idempotencyRequest(router, "node-1", "key-1", ""); w.Header().Get(HeaderIdempotentReplayed) != "" {
+		t.Error("Expected an expired key to be processed again")
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 handler runs after expiry, got %d", calls)
+	}
+}
+
+func TestIdempotency_WaitsForInFlightRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var calls int
+	var mu sync.Mutex
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("node_id", "node-1") })
+	router.Use(Idempotency(NewIdempotencyCache(time.Hour)))
+	router.POST("/test", func(c *gin.Context) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		close(started)
+		<-release
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	send := func(i int) {
+		defer wg.Done()
+		codes[i] = idempotencyRequest(router, "node-1", "key-1", "").Code
+	}
+	wg.Add(2)
+	go send(0)
+	<-started
+	go send(1)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Expected the handler to run once, ran %d times", calls)
+	}
+	if codes[0] != http.StatusCreated || codes[1] != http.StatusCreated {
+		t.Errorf("Expected both requests to get 201, got %s", fmt.Sprint(codes))
+	}
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"nebulagc.io/pkg/token"
+
+	"nebulagc.io/server/internal/testutil"
+)
+
+// signingRouter serves POST /test behind node token auth for a cluster that
+// requires signed requests, echoing the request body.
+func signingRouter(t *testing.T, verifier *RequestVerifier) (router *gin.Engine, nodeToken string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.OpenDB(t)
+	tenantID := testutil.Tenant(t, db, "Test Tenant")
+	clusterID, _ := testutil.Cluster(t, db, tenantID, "Test Cluster")
+	_, nodeToken = testutil.Node(t, db, tenantID, clusterID, "node-1", false)
+	if _, err := db.Exec(`UPDATE clusters SET require_signed_requests = 1 WHERE id = ?`, clusterID); err != nil {
+		t.Fatalf("Failed to require signed requests: %v", err)
+	}
+
+	router = gin.New()
+	router.Use(RequireNodeToken(&AuthConfig{DB: db, Secret: testutil.TestHMACSecret, Signatures: verifier}))
+	router.POST("/test", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+	return router, nodeToken
+}
+
+// signedRequest builds a POST /test request signed at the given time.
+func signedRequest(nodeToken, nonce string, body []byte, signedAt time.Time) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/test", bytes.NewReader(body))
+	req.Header.Set(HeaderNodeToken, nodeToken)
+	req.Header.Set(HeaderRequestTimestamp, strconv.FormatInt(signedAt.Unix(), 10))
+	req.Header.Set(HeaderRequestNonce, nonce)
+	req.Header.Set(HeaderRequestSignature,
+		token.SignRequest(nodeToken, http.MethodPost, "/test", body, signedAt.Unix(), nonce))
+	return req
+}
+
+func serve(router *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequireNodeToken_SignedRequest(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verifier := NewRequestVerifier(time.Minute)
+	verifier.now = func() time.Time { return now }
+	router, nodeToken := signingRouter(t, verifier)
+
+	body := []byte(`{"routes":["10.0.0.0/24"]}`)
+	w := serve(router, signedRequest(nodeToken, "nonce-1", body, now))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected signed request to succeed, got %d", w.Code)
+	}
+	if w.Body.String() != string(body) {
+		t.Errorf("Expected handler to receive the body, got %q", w.Body.String())
+	}
+
+	// Unsigned requests are rejected once the cluster requires signing
+	req := httptest.NewRequest(http.MethodPost, "/test", bytes.NewReader(body))
+	req.Header.Set(HeaderNodeToken, nodeToken)
+	if w := serve(router, req); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected unsigned request to be rejected, got %d", w.Code)
+	}
+
+	// A tampered body breaks the signature
+	req = signedRequest(nodeToken, "nonce-2", body, now)
+	req.Body = io.NopCloser(bytes.NewReader([]byte(`{"routes":["0.0.0.0/0"]}`)))
+	if w := serve(router, req); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected tampered request to be rejected, got %d", w.Code)
+	}
+}
+
+func TestRequireNodeToken_SignedRequestReplay(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verifier := NewRequestVerifier(time.Minute)
+	verifier.now = func() time.Time { return now }
+	router, nodeToken := signingRouter(t, verifier)
+
+	body := []byte(`{}`)
+	if w := serve(router, signedRequest(nodeToken, "nonce-1", body, now)); w.Code != http.StatusOK {
+		t.Fatalf("Expected first request to succeed, got %d", w.Code)
+	}
+
+	// The same request again is a replay, even within the skew window
+	now = now.Add(30 * time.Second)
+	if w := serve(router, signedRequest(nodeToken, "nonce-1", body, now.Add(-30*time.Second))); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected replayed request to be rejected, got %d", w.Code)
+	}
+
+	// Once the timestamp is outside the window, the skew check rejects it
+	now = now.Add(2 * time.Minute)
+	if w := serve(router, signedRequest(nodeToken, "nonce-1", body, now.Add(-150*time.Second))); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected stale replay to be rejected, got %d", w.Code)
+	}
+
+	// A fresh nonce is accepted
+	if w := serve(router, signedRequest(nodeToken, "nonce-2", body, now)); w.Code != http.StatusOK {
+		t.Errorf("Expected request with new nonce to succeed, got %d", w.Code)
+	}
+}
+
+func TestRequireNodeToken_SignedRequestClockSkew(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verifier := NewRequestVerifier(time.Minute)
+	verifier.now = func() time.Time { return now }
+	router, nodeToken := signingRouter(t, verifier)
+
+	tests := []struct {
+		name     string
+		signedAt time.Time
+		want     int
+	}{
+		{"client clock slightly behind", now.Add(-time.Minute), http.StatusOK},
+		{"client clock slightly ahead", now.Add(time.Minute), http.StatusOK},
+		{"client clock too far behind", now.Add(-time.Minute - time.Second), http.StatusUnauthorized},
+		{"client clock too far ahead", now.Add(time.Minute + time.Second), http.StatusUnauthorized},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nonce := "nonce-" + strconv.Itoa(i)
+			if w := serve(router, signedRequest(nodeToken, nonce, []byte(`{}`), tt.signedAt)); w.Code != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestRequireNodeToken_SigningNotRequired(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.OpenDB(t)
+	tenantID := testutil.Tenant(t, db, "Test Tenant")
+	clusterID, _ := testutil.Cluster(t, db, tenantID, "Test Cluster")
+	_, nodeToken := testutil.Node(t, db, tenantID, clusterID, "node-1", false)
+
+	router := gin.New()
+	router.Use(RequireNodeToken(&AuthConfig{DB: db, Secret: testutil.TestHMACSecret}))
+	router.POST("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/test", nil)
+	req.Header.Set(HeaderNodeToken, nodeToken)
+	if w := serve(router, req); w.Code != http.StatusOK {
+		t.Errorf("Expected unsigned request to succeed when signing is not required, got %d", w.Code)
+	}
+}
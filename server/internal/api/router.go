@@ -2,12 +2,14 @@ package api
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 	"nebulagc.io/server/internal/api/handlers"
 	"nebulagc.io/server/internal/api/middleware"
+	"nebulagc.io/server/internal/events"
 	"nebulagc.io/server/internal/ha"
 	"nebulagc.io/server/internal/metrics"
 	"nebulagc.io/server/internal/service"
@@ -27,15 +29,74 @@ type RouterConfig struct {
 	// InstanceID is this control plane instance's UUID.
 	InstanceID string
 
+	// InstanceName is this instance's human-friendly name (may be empty).
+	InstanceName string
+
 	// AllowOrigins is the list of allowed CORS origins.
 	// Use []string{"*"} to allow all origins (not recommended for production).
 	AllowOrigins []string
 
+	// TrustedProxies lists the proxy IPs or CIDRs whose X-Forwarded-For and
+	// X-Real-IP headers name the client address for rate limits, auth lockouts
+	// and logs (nil = none; the connection's remote address is used).
+	TrustedProxies []string
+
 	// DisableWriteGuard disables the replica write guard (for single-instance deployments).
 	DisableWriteGuard bool
 
 	// HAManager provides master detection for write-guard and health endpoints.
 	HAManager *ha.Manager
+
+	// ClusterTokenOverlap is how long a rotated-out cluster token keeps validating
+	// (0 = revoked immediately).
+	ClusterTokenOverlap time.Duration
+
+	// Events receives control plane events such as cluster token rotation (nil = none).
+	Events events.Notifier
+
+	// AuthFailureAlertThreshold is the number of failed token validations per token
+	// type and cluster within AuthFailureAlertWindow that publishes an
+	// auth.failures_exceeded event to Events (0 = no alerts).
+	AuthFailureAlertThreshold int
+
+	// AuthFailureAlertWindow is the counting window for AuthFailureAlertThreshold.
+	AuthFailureAlertWindow time.Duration
+
+	// AuthLockoutThreshold is the number of failed token validations from one
+	// source within AuthLockoutWindow after which the source is rejected with
+	// 429 for AuthLockoutCooldown (0 = no lockout).
+	AuthLockoutThreshold int
+
+	// AuthLockoutWindow is the counting window for AuthLockoutThreshold.
+	AuthLockoutWindow time.Duration
+
+	// AuthLockoutCooldown is how long a locked out source is rejected.
+	AuthLockoutCooldown time.Duration
+
+	// AuthLockoutScope groups failures by source IP ("ip") or by source IP and
+	// token prefix ("ip_token", for nodes sharing a NAT address).
+	AuthLockoutScope string
+
+	// RequestSignatureMaxSkew is how far a signed request's timestamp may be from
+	// this instance's clock, for clusters that require signed node requests
+	// (0 = 5 minutes).
+	RequestSignatureMaxSkew time.Duration
+
+	// ReplicationPositionFile is where the external replication tool records the
+	// last applied position, reported by the consistency endpoint ("" = not reported).
+	ReplicationPositionFile string
+
+	// BundleStore holds config bundle data (nil = the database).
+	BundleStore service.BundleStore
+
+	// BundleService serves the bundle endpoints (nil = a new one on
+	// BundleStore). Share it with the bundle pruner and canary reconciler so
+	// their changes to a cluster are serialized with uploads.
+	BundleService *service.BundleService
+
+	// OperatorToken authenticates the control plane operator for endpoints
+	// spanning all tenants, such as tenant provisioning ("" = disabled).
+	OperatorToken string
 }
 
 // SetupRouter creates and configures the Gin HTTP router with all routes and middleware.
@@ -45,7 +106,15 @@ type RouterConfig struct {
 // - Health check endpoints (no auth required)
 // - Node management endpoints (node token auth)
 // - Config distribution endpoints (node token auth)
+// - Batched config version endpoint (per-cluster credentials in body)
 // - Topology management endpoints (cluster token auth)
+// - Cluster stats endpoints (cluster token auth)
+// - Tenant stats endpoints (admin node token auth)
+// - Tenant cluster management endpoints (operator token auth)
+// - Replica consistency endpoint (admin node token auth)
+// - Tenant provisioning endpoints (operator token auth)
+// - Auth lockout listing endpoint (operator token auth)
+// - Master step-down endpoint (operator token auth)
 // - Route management endpoints (node token auth)
 // - Token rotation endpoints (various auth)
 //
@@ -58,6 +127,13 @@ func SetupRouter(config *RouterConfig) *gin.Engine {
 	// Create router
 	router := gin.New()
 
+	// Only trust client address headers from configured proxies, so callers
+	// cannot spoof their address to dodge rate limits and lockouts
+	if err := router.SetTrustedProxies(config.TrustedProxies); err != nil {
+		config.Logger.Error("Invalid trusted proxies, trusting none", zap.Error(err))
+		_ = router.SetTrustedProxies(nil)
+	}
+
 	// Recovery middleware (recover from panics)
 	router.Use(gin.Recovery())
 
@@ -82,24 +158,77 @@ func SetupRouter(config *RouterConfig) *gin.Engine {
 
 	// Authentication config for middleware
 	authConfig := &middleware.AuthConfig{
-		DB:     config.DB,
-		Secret: config.HMACSecret,
+		DB:            config.DB,
+		Secret:        config.HMACSecret,
+		Signatures:    middleware.NewRequestVerifier(config.RequestSignatureMaxSkew),
+		OperatorToken: config.OperatorToken,
+	}
+	if config.AuthFailureAlertThreshold > 0 && config.Events != nil {
+		authConfig.FailureAlerter = middleware.NewAuthFailureAlerter(
+			config.AuthFailureAlertThreshold, config.AuthFailureAlertWindow, config.Events)
+	}
+	var lockouts handlers.LockoutLister
+	if config.AuthLockoutThreshold > 0 {
+		authConfig.Lockout = middleware.NewAuthLockout(
+			config.AuthLockoutThreshold, config.AuthLockoutWindow, config.AuthLockoutCooldown, config.AuthLockoutScope)
+		lockouts = authConfig.Lockout
 	}
 
 	// Services
 	nodeService := service.NewNodeService(config.DB, config.Logger, config.HMACSecret)
 	nodeHandler := handlers.NewNodeHandler(nodeService)
 
-	bundleService := service.NewBundleService(config.DB, config.Logger)
+	bundleService := config.BundleService
+	if bundleService == nil {
+		bundleStore := config.BundleStore
+		if bundleStore == nil {
+			bundleStore = service.NewDBBundleStore(config.DB)
+		}
+		bundleService = service.NewBundleServiceWithStore(config.DB, bundleStore, config.Logger)
+	}
 	bundleHandler := handlers.NewBundleHandler(bundleService)
 
 	topologyService := service.NewTopologyService(config.DB, config.Logger, config.HMACSecret)
+	topologyService.ConfigureTokenRotation(config.ClusterTokenOverlap, config.Events)
 	topologyHandler := handlers.NewTopologyHandler(topologyService)
 
+	versionService := service.NewVersionService(config.DB, config.Logger, config.HMACSecret)
+	versionHandler := handlers.NewVersionHandler(versionService, func(c *gin.Context) service.AuthGuard {
+		return authConfig.Guard(c)
+	})
+
+	clusterService := service.NewClusterService(config.DB, config.Logger, config.HMACSecret)
+	clusterHandler := handlers.NewClusterHandler(clusterService)
+
+	tenantService := service.NewTenantService(config.DB, config.Logger)
+	tenantHandler := handlers.NewTenantHandler(tenantService)
+
+	consistencyService := service.NewConsistencyService(config.DB, config.Logger)
+	consistencyHandler := handlers.NewConsistencyHandler(
+		consistencyService,
+		config.InstanceID,
+		selectMasterChecker(config),
+		config.ReplicationPositionFile,
+	)
+
+	lockoutHandler := handlers.NewLockoutHandler(lockouts, config.InstanceID)
+
+	// Keyed POST requests are processed once; retries get the first response.
+	// Attached per route after the admin check, so only callers allowed to
+	// make a request get their body buffered
+	idempotency := middleware.Idempotency(middleware.NewIdempotencyCache(middleware.DefaultIdempotencyTTL))
+
+	var stepper handlers.MasterStepper
+	if config.HAManager != nil {
+		stepper = config.HAManager
+	}
+	haHandler := handlers.NewHAHandler(stepper, config.InstanceID)
+
 	// Health check handler
 	healthHandler := handlers.NewHealthHandler(
 		config.DB,
 		config.InstanceID,
+		config.InstanceName,
 		selectMasterChecker(config),
 	)
 
@@ -126,21 +255,46 @@ func SetupRouter(config *RouterConfig) *gin.Engine {
 	nodes.Use(middleware.RateLimitByNode(50.0, 100)) // 50 req/s per node
 	{
 		// POST /api/v1/nodes - Create new node (requires admin node)
-		nodes.POST("", middleware.RequireAdminNode(), nodeHandler.CreateNode)
+		nodes.POST("", middleware.RequireAdminNode(), idempotency, nodeHandler.CreateNode)
+
+		// POST /api/v1/nodes/batch - Create several nodes in one transaction (requires admin node)
+		nodes.POST("/batch", middleware.RequireAdminNode(), idempotency, nodeHandler.CreateNodes)
 
 		// GET /api/v1/nodes - List nodes in cluster (requires admin node)
 		nodes.GET("", middleware.RequireAdminNode(), nodeHandler.ListNodes)
 
+		// GET /api/v1/nodes/:id - Get a single node (requires admin node)
+		nodes.GET("/:id", middleware.RequireAdminNode(), nodeHandler.GetNode)
+
 		// PATCH /api/v1/nodes/:id/mtu - Update MTU (requires admin node)
 		nodes.PATCH("/:id/mtu", middleware.RequireAdminNode(), nodeHandler.UpdateMTU)
 
+		// PUT /api/v1/nodes/:id/relays - Set preferred relays (requires admin node)
+		nodes.PUT("/:id/relays", middleware.RequireAdminNode(), nodeHandler.SetPreferredRelays)
+
+		// GET /api/v1/nodes/:id/effective-config - Resolved config of a node (requires admin node)
+		nodes.GET("/:id/effective-config", middleware.RequireAdminNode(), topologyHandler.GetEffectiveConfig)
+
+		// GET /api/v1/nodes/me - Identify the calling node
+		nodes.GET("/me", nodeHandler.WhoAmI)
+
+		// POST /api/v1/nodes/heartbeat - Report the calling node's config version and health
+		nodes.POST("/heartbeat", idempotency, nodeHandler.Heartbeat)
+
 		// POST /api/v1/nodes/:id/token - Rotate node token (requires admin node)
-		nodes.POST("/:id/token", middleware.RequireAdminNode(), nodeHandler.RotateNodeToken)
+		nodes.POST("/:id/token", middleware.RequireAdminNode(), idempotency, nodeHandler.RotateNodeToken)
+
+		// POST /api/v1/nodes/:id/restore - Restore a deleted node (requires admin node)
+		nodes.POST("/:id/restore", middleware.RequireAdminNode(), idempotency, nodeHandler.RestoreNode)
 
 		// DELETE /api/v1/nodes/:id - Delete node (requires admin node)
 		nodes.DELETE("/:id", middleware.RequireAdminNode(), nodeHandler.DeleteNode)
 	}
 
+	// POST /api/v1/config/versions - Check config versions of many clusters
+	// (credentials are carried per cluster in the request body; failures count toward the auth lockout)
+	v1.POST("/config/versions", middleware.RateLimitByIP(10.0, 20), versionHandler.GetVersions)
+
 	// Config distribution endpoints (requires node token authentication)
 	config_endpoints := v1.Group("/config")
 	config_endpoints.Use(middleware.RequireNodeToken(authConfig))
@@ -152,8 +306,29 @@ func SetupRouter(config *RouterConfig) *gin.Engine {
 		// GET /api/v1/config/bundle - Download config bundle
 		config_endpoints.GET("/bundle", bundleHandler.DownloadBundle)
 
+		// HEAD /api/v1/config/bundle - Check current config version without a body
+		config_endpoints.HEAD("/bundle", bundleHandler.HeadBundle)
+
 		// POST /api/v1/config/bundle - Upload config bundle (requires admin node)
-		config_endpoints.POST("/bundle", middleware.RequireAdminNode(), bundleHandler.UploadBundle)
+		config_endpoints.POST("/bundle", middleware.RequireAdminNode(), idempotency, bundleHandler.UploadBundle)
+
+		// GET /api/v1/config/canary - Get active canary rollout (requires admin node)
+		config_endpoints.GET("/canary", middleware.RequireAdminNode(), bundleHandler.GetCanary)
+
+		// PUT /api/v1/config/canary - Roll out latest bundle to a subset of nodes (requires admin node)
+		config_endpoints.PUT("/canary", middleware.RequireAdminNode(), bundleHandler.SetCanary)
+
+		// POST /api/v1/config/canary/promote - Promote canary cluster-wide (requires admin node)
+		config_endpoints.POST("/canary/promote", middleware.RequireAdminNode(), idempotency, bundleHandler.PromoteCanary)
+
+		// GET /api/v1/config/validate - Validate generated config for the cluster (requires admin node)
+		config_endpoints.GET("/validate", middleware.RequireAdminNode(), topologyHandler.ValidateConfig)
+
+		// GET /api/v1/config/connectivity - Node reachability matrix for a flow (requires admin node)
+		config_endpoints.GET("/connectivity", middleware.RequireAdminNode(), topologyHandler.GetConnectivity)
+
+		// POST /api/v1/config/connectivity/what-if - Simulate topology changes without persisting them (requires admin node)
+		config_endpoints.POST("/connectivity/what-if", middleware.RequireAdminNode(), idempotency, topologyHandler.WhatIf)
 	}
 
 	// Topology management endpoints (requires cluster token authentication)
@@ -165,16 +340,152 @@ func SetupRouter(config *RouterConfig) *gin.Engine {
 		topology.GET("", topologyHandler.GetTopology)
 
 		// POST /api/v1/topology/lighthouse - Assign lighthouse
-		topology.POST("/lighthouse", topologyHandler.AssignLighthouse)
+		topology.POST("/lighthouse", idempotency, topologyHandler.AssignLighthouse)
 
 		// DELETE /api/v1/topology/lighthouse/:node_id - Unassign lighthouse
 		topology.DELETE("/lighthouse/:node_id", topologyHandler.UnassignLighthouse)
 
 		// POST /api/v1/topology/relay - Assign relay
-		topology.POST("/relay", topologyHandler.AssignRelay)
+		topology.POST("/relay", idempotency, topologyHandler.AssignRelay)
 
 		// DELETE /api/v1/topology/relay/:node_id - Unassign relay
 		topology.DELETE("/relay/:node_id", topologyHandler.UnassignRelay)
+
+		// PUT /api/v1/topology/relay/max-hops - Set relay hop limit
+		topology.PUT("/relay/max-hops", topologyHandler.SetMaxRelayHops)
+
+		// GET /api/v1/topology/dns - Get lighthouse DNS settings
+		topology.GET("/dns", topologyHandler.GetLighthouseDNS)
+
+		// PUT /api/v1/topology/dns - Update lighthouse DNS settings
+		topology.PUT("/dns", topologyHandler.SetLighthouseDNS)
+
+		// PUT /api/v1/topology/subnet - Set cluster overlay subnet
+		topology.PUT("/subnet", topologyHandler.SetNebulaSubnet)
+
+		// GET /api/v1/topology/static-hosts - Get static host map
+		topology.GET("/static-hosts", topologyHandler.GetStaticHostMap)
+
+		// PUT /api/v1/topology/static-hosts - Replace static host map
+		topology.PUT("/static-hosts", topologyHandler.SetStaticHostMap)
+
+		// PUT /api/v1/topology/preferred-ranges - Set Nebula preferred ranges
+		topology.PUT("/preferred-ranges", topologyHandler.SetPreferredRanges)
+
+		// PUT /api/v1/topology/cipher - Change cluster cipher (requires force)
+		topology.PUT("/cipher", topologyHandler.SetCipher)
+
+		// PUT /api/v1/topology/handshake - Set handshake tuning
+		topology.PUT("/handshake", topologyHandler.SetHandshakeConfig)
+
+		// PUT /api/v1/topology/request-signing - Require signed node requests
+		topology.PUT("/request-signing", topologyHandler.SetRequestSigning)
+
+		// PUT /api/v1/topology/route-policy - Set rules for node routes
+		topology.PUT("/route-policy", topologyHandler.SetRoutePolicy)
+
+		// GET /api/v1/topology/snapshot - Export topology snapshot
+		topology.GET("/snapshot", topologyHandler.ExportSnapshot)
+
+		// PUT /api/v1/topology/snapshot - Import topology snapshot
+		topology.PUT("/snapshot", topologyHandler.ImportSnapshot)
+	}
+
+	// Cluster endpoints (requires cluster token authentication)
+	clusters := v1.Group("/clusters")
+	clusters.Use(middleware.RequireClusterToken(authConfig))
+	clusters.Use(middleware.RateLimitByCluster(20.0, 40)) // 20 req/s per cluster
+	{
+		// GET /api/v1/clusters/:cluster_id/stats - Get cluster summary counts
+		clusters.GET("/:cluster_id/stats", clusterHandler.GetStats)
+
+		// GET /api/v1/clusters/:cluster_id/history - Get the config change timeline
+		clusters.GET("/:cluster_id/history", clusterHandler.GetHistory)
+
+		// GET /api/v1/clusters/:cluster_id/topology.dot - Get the topology as a Graphviz DOT graph
+		clusters.GET("/:cluster_id/topology.dot", topologyHandler.GetTopologyDOT)
+
+		// GET /api/v1/clusters/:cluster_id/bundles/archive - Stream every stored bundle version as a tar
+		clusters.GET("/:cluster_id/bundles/archive", bundleHandler.GetArchive)
+
+		// PUT /api/v1/clusters/:cluster_id/bundles/retention - Set how many bundle versions are kept
+		clusters.PUT("/:cluster_id/bundles/retention", bundleHandler.SetRetention)
+	}
+
+	// Cluster config endpoints addressed by tenant (requires cluster token authentication)
+	clusterConfig := v1.Group("/tenants/:tenant_id/clusters/:cluster_id/config")
+	clusterConfig.Use(middleware.RequireClusterToken(authConfig))
+	clusterConfig.Use(middleware.RateLimitByCluster(20.0, 40)) // 20 req/s per cluster
+	{
+		// GET /api/v1/tenants/:tenant_id/clusters/:cluster_id/config/diff - Compare the files of two bundle versions
+		clusterConfig.GET("/diff", bundleHandler.DiffBundle)
+	}
+
+	// Tenant endpoints (requires admin node token authentication)
+	tenants := v1.Group("/tenants")
+	tenants.Use(middleware.RequireNodeToken(authConfig))
+	tenants.Use(middleware.RateLimitByNode(10.0, 20)) // 10 req/s per node
+	{
+		// GET /api/v1/tenants/:tenant_id/stats - Get tenant usage totals (requires admin node)
+		tenants.GET("/:tenant_id/stats", middleware.RequireAdminNode(), tenantHandler.GetStats)
+
+	}
+
+	// Tenant cluster management endpoints (requires operator token authentication)
+	tenantClusters := v1.Group("/tenants")
+	tenantClusters.Use(middleware.RateLimitByIP(10.0, 20)) // 10 req/s per IP
+	tenantClusters.Use(middleware.RequireOperatorToken(authConfig))
+	{
+		// GET /api/v1/tenants/:tenant_id/clusters - List the tenant's clusters
+		tenantClusters.GET("/:tenant_id/clusters", clusterHandler.ListClusters)
+
+		// POST /api/v1/tenants/:tenant_id/clusters - Create cluster
+		tenantClusters.POST("/:tenant_id/clusters", idempotency, clusterHandler.CreateCluster)
+
+		// GET /api/v1/tenants/:tenant_id/clusters/:cluster_id - Get cluster
+		tenantClusters.GET("/:tenant_id/clusters/:cluster_id", clusterHandler.GetCluster)
+
+		// DELETE /api/v1/tenants/:tenant_id/clusters/:cluster_id - Delete cluster with its nodes and bundles
+		tenantClusters.DELETE("/:tenant_id/clusters/:cluster_id", clusterHandler.DeleteCluster)
+	}
+
+	// Admin endpoints (requires admin node token authentication)
+	admin := v1.Group("/admin")
+	admin.Use(middleware.RequireNodeToken(authConfig))
+	admin.Use(middleware.RateLimitByNode(10.0, 20)) // 10 req/s per node
+	{
+		// GET /api/v1/admin/consistency - Report data versions and hashes for replica comparison (requires admin node)
+		admin.GET("/consistency", middleware.RequireAdminNode(), consistencyHandler.GetConsistency)
+	}
+
+	// Operator endpoints spanning all tenants (requires operator token authentication)
+	operator := v1.Group("/admin")
+	operator.Use(middleware.RateLimitByIP(10.0, 20)) // 10 req/s per IP
+	operator.Use(middleware.RequireOperatorToken(authConfig))
+	{
+		// GET /api/v1/admin/tenants - List tenants
+		operator.GET("/tenants", tenantHandler.ListTenants)
+
+		// POST /api/v1/admin/tenants - Provision tenant
+		operator.POST("/tenants", idempotency, tenantHandler.CreateTenant)
+
+		// GET /api/v1/admin/tenants/:tenant_id - Get tenant
+		operator.GET("/tenants/:tenant_id", tenantHandler.GetTenant)
+
+		// DELETE /api/v1/admin/tenants/:tenant_id - Delete tenant with its clusters
+		operator.DELETE("/tenants/:tenant_id", tenantHandler.DeleteTenant)
+
+		// GET /api/v1/admin/lockouts - List sources locked out after repeated auth failures
+		operator.GET("/lockouts", lockoutHandler.GetLockouts)
+	}
+
+	// HA endpoints (requires operator token authentication)
+	haGroup := v1.Group("/ha")
+	haGroup.Use(middleware.RateLimitByIP(10.0, 20)) // 10 req/s per IP
+	haGroup.Use(middleware.RequireOperatorToken(authConfig))
+	{
+		// POST /api/v1/ha/step-down - Hand the master role to another replica
+		haGroup.POST("/step-down", haHandler.StepDown)
 	}
 
 	// Route management endpoints (requires node token authentication)
@@ -198,6 +509,7 @@ func SetupRouter(config *RouterConfig) *gin.Engine {
 		// POST /api/v1/tokens/cluster/rotate - Rotate cluster token (requires cluster token)
 		tokens.POST("/cluster/rotate",
 			middleware.RequireClusterToken(authConfig),
+			idempotency,
 			topologyHandler.RotateClusterToken)
 	}
 
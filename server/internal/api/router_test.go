@@ -0,0 +1,444 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+
+	"nebulagc.io/server/internal/api/middleware"
+	"nebulagc.io/server/internal/metrics"
+	"nebulagc.io/server/internal/testutil"
+)
+
+const testOperatorToken = "operator-token-at-least-32-bytes-long"
+
+func TestTenantProvisioningRequiresOperator(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.OpenDB(t)
+	tenantA := testutil.Tenant(t, db, "Tenant A")
+	clusterA, _ := testutil.Cluster(t, db, tenantA, "Cluster A")
+	_, adminToken := testutil.AdminNode(t, db, tenantA, clusterA, "admin-a")
+	tenantB := testutil.Tenant(t, db, "Tenant B")
+	testutil.Cluster(t, db, tenantB, "Cluster B")
+
+	newRouter := func(operatorToken string) *gin.Engine {
+		return SetupRouter(&RouterConfig{
+			DB:                db,
+			Logger:            zap.NewNop(),
+			HMACSecret:        testutil.TestHMACSecret,
+			InstanceID:        "00000000-0000-4000-8000-000000000001",
+			DisableWriteGuard: true,
+			OperatorToken:     operatorToken,
+		})
+	}
+	request := func(router *gin.Engine, method, path, header, value string) int {
+		body := ""
+		if method == http.MethodPost {
+			body = `{"name":"Tenant C"}`
+		}
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	router := newRouter(testOperatorToken)
+	endpoints := []struct{ method, path string }{
+		{http.MethodGet, "/api/v1/admin/tenants"},
+		{http.MethodPost, "/api/v1/admin/tenants"},
+		{http.MethodGet, "/api/v1/admin/tenants/" + tenantB},
+		{http.MethodDelete, "/api/v1/admin/tenants/" + tenantB},
+	}
+
+	// Tenant A's admin node cannot see, create or delete tenants
+	for _, e := range endpoints {
+		if code := request(router, e.method, e.path, middleware.HeaderNodeToken, adminToken); code != http.StatusUnauthorized {
+			t.Errorf("%s %s with tenant A's admin token = %d, want %d", e.method, e.path, code, http.StatusUnauthorized)
+		}
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM tenants WHERE id = ?`, tenantB).Scan(&count); err != nil || count != 1 {
+		t.Fatalf("Expected tenant B to survive, count = %d, err = %v", count, err)
+	}
+
+	// A wrong operator token is rejected as well
+	if code := request(router, http.MethodGet, endpoints[2].path, middleware.HeaderOperatorToken, "wrong-operator-token-of-some-length"); code != http.StatusUnauthorized {
+		t.Errorf("GET tenant with a wrong operator token = %d, want %d", code, http.StatusUnauthorized)
+	}
+
+	// The operator can read tenant B
+	if code := request(router, http.MethodGet, endpoints[2].path, middleware.HeaderOperatorToken, testOperatorToken); code != http.StatusOK {
+		t.Errorf("GET tenant with the operator token = %d, want %d", code, http.StatusOK)
+	}
+
+	// Without a configured operator token the endpoints are disabled
+	disabled := newRouter("")
+	if code := request(disabled, http.MethodGet, endpoints[0].path, middleware.HeaderOperatorToken, testOperatorToken); code != http.StatusForbidden {
+		t.Errorf("GET tenants with operator endpoints disabled = %d, want %d", code, http.StatusForbidden)
+	}
+}
+
+func TestClusterManagementRequiresOperator(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.OpenDB(t)
+	tenant := testutil.Tenant(t, db, "Tenant")
+	clusterA, _ := testutil.Cluster(t, db, tenant, "Cluster A")
+	_, adminToken := testutil.AdminNode(t, db, tenant, clusterA, "admin-a")
+	clusterB, _ := testutil.Cluster(t, db, tenant, "Cluster B")
+
+	router := SetupRouter(&RouterConfig{
+		DB:                db,
+		Logger:            zap.NewNop(),
+		HMACSecret:        testutil.TestHMACSecret,
+		InstanceID:        "00000000-0000-4000-8000-000000000001",
+		DisableWriteGuard: true,
+		OperatorToken:     testOperatorToken,
+	})
+	request := func(method, path, header, value string) int {
+		body := ""
+		if method == http.MethodPost {
+			body = `{"name":"Cluster C"}`
+		}
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(header, value)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	base := "/api/v1/tenants/" + tenant + "/clusters"
+	endpoints := []struct{ method, path string }{
+		{http.MethodGet, base},
+		{http.MethodPost, base},
+		{http.MethodGet, base + "/" + clusterB},
+		{http.MethodDelete, base + "/" + clusterB},
+	}
+
+	// Cluster A's admin node cannot list, create, read or delete the tenant's clusters
+	for _, e := range endpoints {
+		if code := request(e.method, e.path, middleware.HeaderNodeToken, adminToken); code != http.StatusUnauthorized {
+			t.Errorf("%s %s with cluster A's admin token = %d, want %d", e.method, e.path, code, http.StatusUnauthorized)
+		}
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM clusters WHERE tenant_id = ?`, tenant).Scan(&count); err != nil || count != 2 {
+		t.Fatalf("Expected the tenant's clusters to be untouched, count = %d, err = %v", count, err)
+	}
+
+	// The operator can manage them
+	if code := request(http.MethodGet, endpoints[2].path, middleware.HeaderOperatorToken, testOperatorToken); code != http.StatusOK {
+		t.Errorf("GET cluster with the operator token = %d, want %d", code, http.StatusOK)
+	}
+	if code := request(http.MethodDelete, endpoints[3].path, middleware.HeaderOperatorToken, testOperatorToken); code != http.StatusNoContent {
+		t.Errorf("DELETE cluster with the operator token = %d, want %d", code, http.StatusNoContent)
+	}
+}
+
+func TestAuthLockoutSourceIgnoresUntrustedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.OpenDB(t)
+	tenant := testutil.Tenant(t, db, "Tenant")
+	cluster, _ := testutil.Cluster(t, db, tenant, "Cluster")
+	_, adminToken := testutil.AdminNode(t, db, tenant, cluster, "admin")
+
+	newRouter := func(trustedProxies []string) *gin.Engine {
+		return SetupRouter(&RouterConfig{
+			DB:                   db,
+			Logger:               zap.NewNop(),
+			HMACSecret:           testutil.TestHMACSecret,
+			InstanceID:           "00000000-0000-4000-8000-000000000001",
+			DisableWriteGuard:    true,
+			OperatorToken:        testOperatorToken,
+			TrustedProxies:       trustedProxies,
+			AuthLockoutThreshold: 2,
+			AuthLockoutWindow:    time.Minute,
+			AuthLockoutCooldown:  time.Hour,
+			AuthLockoutScope:     middleware.LockoutScopeIP,
+		})
+	}
+	request := func(router *gin.Engine, remoteAddr, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/nodes", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(middleware.HeaderNodeToken, "invalid-node-token-of-some-length")
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Without trusted proxies the forged header is ignored and the caller itself is locked out
+	router := newRouter(nil)
+	for i := 0; i < 2; i++ {
+		request(router, "203.0.113.1:40000", "198.51.100.7")
+	}
+	if code := request(router, "203.0.113.1:40001", "198.51.100.8"); code != http.StatusTooManyRequests {
+		t.Errorf("Request from the failing address = %d, want %d", code, http.StatusTooManyRequests)
+	}
+	if code := request(router, "198.51.100.7:40000", ""); code != http.StatusUnauthorized {
+		t.Errorf("Request from the forged address = %d, want %d", code, http.StatusUnauthorized)
+	}
+
+	// A trusted proxy reports the client address
+	router = newRouter([]string{"203.0.113.0/24"})
+	for i := 0; i < 2; i++ {
+		request(router, "203.0.113.1:40000", "198.51.100.7")
+	}
+	if code := request(router, "203.0.113.2:40000", "198.51.100.7"); code != http.StatusTooManyRequests {
+		t.Errorf("Request for the failing client via another proxy = %d, want %d", code, http.StatusTooManyRequests)
+	}
+	if code := request(router, "203.0.113.1:40001", "198.51.100.8"); code != http.StatusUnauthorized {
+		t.Errorf("Request for another client via the same proxy = %d, want %d", code, http.StatusUnauthorized)
+	}
+
+	// Lockouts span tenants, so listing them takes the operator token
+	list := func(header, value string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/lockouts", nil)
+		req.Header.Set(header, value)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := list(middleware.HeaderNodeToken, adminToken); code != http.StatusUnauthorized {
+		t.Errorf("GET lockouts with an admin node token = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := list(middleware.HeaderOperatorToken, testOperatorToken); code != http.StatusOK {
+		t.Errorf("GET lockouts with the operator token = %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestVersionBatchCountsTowardAuthLockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.OpenDB(t)
+	tenant := testutil.Tenant(t, db, "Tenant")
+	cluster, _ := testutil.Cluster(t, db, tenant, "Cluster")
+	_, nodeToken := testutil.Node(t, db, tenant, cluster, "node", false)
+
+	router := SetupRouter(&RouterConfig{
+		DB:                   db,
+		Logger:               zap.NewNop(),
+		HMACSecret:           testutil.TestHMACSecret,
+		InstanceID:           "00000000-0000-4000-8000-000000000001",
+		DisableWriteGuard:    true,
+		AuthLockoutThreshold: 5,
+		AuthLockoutWindow:    time.Minute,
+		AuthLockoutCooldown:  time.Hour,
+		AuthLockoutScope:     middleware.LockoutScopeIP,
+	})
+
+	// One batch guessing many tokens locks its source out
+	var entries []string
+	for i := 0; i < 10; i++ {
+		entries = append(entries, fmt.Sprintf(`{"tenant_id":%q,"cluster_id":%q,"node_token":"guessed-node-token-number-%02d-of-some-length"}`,
+			tenant, cluster, i))
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/config/versions",
+		strings.NewReader(`{"clusters":[`+strings.Join(entries, ",")+`]}`))
+	req.RemoteAddr = "203.0.113.1:40000"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("Batch of bad tokens = %d (Retry-After %q), want %d with Retry-After",
+			w.Code, w.Header().Get("Retry-After"), http.StatusTooManyRequests)
+	}
+
+	// The lockout covers the rest of the API, even with a valid token
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/config/version", nil)
+	req.RemoteAddr = "203.0.113.1:40001"
+	req.Header.Set(middleware.HeaderNodeToken, nodeToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Request from the locked out source = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestAuthFailureAttributedToClaimedCluster(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.OpenDB(t)
+	tenant := testutil.Tenant(t, db, "Tenant")
+	cluster, _ := testutil.Cluster(t, db, tenant, "Cluster")
+
+	router := SetupRouter(&RouterConfig{
+		DB:                db,
+		Logger:            zap.NewNop(),
+		HMACSecret:        testutil.TestHMACSecret,
+		InstanceID:        "00000000-0000-4000-8000-000000000001",
+		DisableWriteGuard: true,
+	})
+	request := func(clusterID string) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/config/bundle", nil)
+		req.Header.Set(middleware.HeaderNodeToken, "invalid-node-token-of-some-length-0123456789")
+		req.Header.Set(middleware.HeaderClusterID, clusterID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("GET bundle with a bad token = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	}
+
+	claimed := metrics.AuthFailures.WithLabelValues("node", cluster, "invalid")
+	unknown := metrics.AuthFailures.WithLabelValues("node", "unknown", "invalid")
+	claimedBefore, unknownBefore := promtest.ToFloat64(claimed), promtest.ToFloat64(unknown)
+
+	// The claimed cluster labels the failure; a cluster that does not exist does not
+	request(cluster)
+	request("no-such-cluster")
+	if got := promtest.ToFloat64(claimed) - claimedBefore; got != 1 {
+		t.Errorf("Failures attributed to the claimed cluster = %v, want 1", got)
+	}
+	if got := promtest.ToFloat64(unknown) - unknownBefore; got != 1 {
+		t.Errorf("Failures attributed to unknown = %v, want 1", got)
+	}
+}
+
+func TestStepDownRequiresOperator(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.OpenDB(t)
+	tenant := testutil.Tenant(t, db, "Tenant")
+	cluster, _ := testutil.Cluster(t, db, tenant, "Cluster")
+	_, adminToken := testutil.AdminNode(t, db, tenant, cluster, "admin")
+
+	router := SetupRouter(&RouterConfig{
+		DB:                db,
+		Logger:            zap.NewNop(),
+		HMACSecret:        testutil.TestHMACSecret,
+		InstanceID:        "00000000-0000-4000-8000-000000000001",
+		DisableWriteGuard: true,
+		OperatorToken:     testOperatorToken,
+	})
+	stepDown := func(header, value string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/ha/step-down", nil)
+		req.Header.Set(header, value)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := stepDown(middleware.HeaderNodeToken, adminToken); code != http.StatusUnauthorized {
+		t.Errorf("Step-down with an admin node token = %d, want %d", code, http.StatusUnauthorized)
+	}
+
+	// The operator gets through to the handler, which reports HA as disabled
+	if code := stepDown(middleware.HeaderOperatorToken, testOperatorToken); code != http.StatusConflict {
+		t.Errorf("Step-down with the operator token = %d, want %d", code, http.StatusConflict)
+	}
+}
+
+func TestCreateNodeWithIdempotencyKeyRunsOnce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.OpenDB(t)
+	tenant := testutil.Tenant(t, db, "Tenant")
+	cluster, _ := testutil.Cluster(t, db, tenant, "Cluster")
+	_, adminToken := testutil.AdminNode(t, db, tenant, cluster, "admin")
+
+	router := SetupRouter(&RouterConfig{
+		DB:                db,
+		Logger:            zap.NewNop(),
+		HMACSecret:        testutil.TestHMACSecret,
+		InstanceID:        "00000000-0000-4000-8000-000000000001",
+		DisableWriteGuard: true,
+	})
+	create := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/nodes", strings.NewReader(`{"name":"node-1"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(middleware.HeaderNodeToken, adminToken)
+		req.Header.Set(middleware.HeaderIdempotencyKey, "create-node-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := create()
+	retry := create()
+	if first.Code != http.StatusCreated {
+		t.Fatalf("First create = %d, want %d: %s", first.Code, http.StatusCreated, first.Body.String())
+	}
+	if retry.Code != first.Code || retry.Body.String() != first.Body.String() {
+		t.Errorf("Retry = %d %s, want the first response", retry.Code, retry.Body.String())
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM nodes WHERE name = 'node-1'`).Scan(&count); err != nil || count != 1 {
+		t.Errorf("Expected one node-1, count = %d, err = %v", count, err)
+	}
+}
+
+func TestClusterTokenRotationRequiresCurrentToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.OpenDB(t)
+	tenant := testutil.Tenant(t, db, "Tenant")
+	_, oldToken := testutil.Cluster(t, db, tenant, "Cluster")
+
+	router := SetupRouter(&RouterConfig{
+		DB:                  db,
+		Logger:              zap.NewNop(),
+		HMACSecret:          testutil.TestHMACSecret,
+		InstanceID:          "00000000-0000-4000-8000-000000000001",
+		DisableWriteGuard:   true,
+		ClusterTokenOverlap: time.Hour,
+	})
+	rotate := func(clusterToken, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/tokens/cluster/rotate"+query, nil)
+		req.Header.Set(middleware.HeaderClusterToken, clusterToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := rotate(oldToken, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Rotation with the current token = %d, want %d", w.Code, http.StatusOK)
+	}
+	newToken := rotatedToken(t, w.Body.String())
+
+	// The rotated-out token still authenticates but cannot rotate again
+	if w := rotate(oldToken, ""); w.Code != http.StatusForbidden {
+		t.Errorf("Rotation with the previous token = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	// Revoking the previous token on rotation shuts it out immediately
+	if w := rotate(newToken, "?revoke_previous=true"); w.Code != http.StatusOK {
+		t.Fatalf("Rotation revoking the previous token = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := rotate(newToken, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Rotation with a revoked token = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+// rotatedToken extracts the "token" field of a token rotation response.
+func rotatedToken(t *testing.T, body string) string {
+	t.Helper()
+
+	var resp struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(body), &resp); err != nil || resp.Data.Token == "" {
+		t.Fatalf("Failed to parse rotation response %q: %v", body, err)
+	}
+	return resp.Data.Token
+}
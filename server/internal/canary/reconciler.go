@@ -0,0 +1,199 @@
+package canary
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+	"nebulagc.io/server/internal/metrics"
+	"nebulagc.io/server/internal/service"
+)
+
+// Reconciler rolls back canary rollouts whose nodes fail to report healthy.
+//
+// Canary nodes are compared against their health when the canary was
+// defined: nodes that were already unhealthy are not held against the
+// canary. A healthy node fails the canary if it reports an error for the
+// canary version, or if, once the health window has passed, its latest
+// report is missing, stale, not "ok" or for an older version. Canaries are
+// evaluated until they are promoted or rolled back.
+//
+// Only the master evaluates canaries, since rollback writes to the database.
+type Reconciler struct {
+	config   *Config
+	bundles  *service.BundleService
+	isMaster func() (bool, string, error)
+	logger   *zap.Logger
+	now      func() time.Time
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewReconciler creates a new canary reconciler.
+//
+// Parameters:
+//   - config: Reconciler configuration
+//   - bundles: Bundle service owning canary state
+//   - isMaster: Reports whether this instance is the master
+//   - logger: Zap logger
+//
+// Returns:
+//   - Configured Reconciler
+func NewReconciler(config *Config, bundles *service.BundleService, isMaster func() (bool, string, error), logger *zap.Logger) *Reconciler {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Reconciler{
+		config:   config,
+		bundles:  bundles,
+		isMaster: isMaster,
+		logger:   logger,
+		now:      time.Now,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start starts the background reconcile loop.
+//
+// Returns:
+//   - Error if the configuration is invalid
+func (r *Reconciler) Start() error {
+	if !r.config.Enabled {
+		r.logger.Info("canary auto-rollback disabled")
+		return nil
+	}
+	if r.config.CheckInterval <= 0 || r.config.HealthWindow <= 0 || r.config.MaxHeartbeatAge <= 0 {
+		return fmt.Errorf("canary check interval, health window and max heartbeat age must be positive")
+	}
+
+	r.logger.Info("starting canary reconciler",
+		zap.Duration("check_interval", r.config.CheckInterval),
+		zap.Duration("health_window", r.config.HealthWindow),
+		zap.Duration("max_heartbeat_age", r.config.MaxHeartbeatAge),
+		zap.Bool("rollback_on_error", r.config.RollbackOnError))
+
+	r.wg.Add(1)
+	go r.watchLoop()
+
+	return nil
+}
+
+// Stop stops the reconcile loop and waits for it to exit.
+//
+// Returns:
+//   - Error if shutdown fails
+func (r *Reconciler) Stop() error {
+	r.cancel()
+	r.wg.Wait()
+	return nil
+}
+
+// watchLoop is the background goroutine that evaluates canaries.
+func (r *Reconciler) watchLoop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+			r.reconcile()
+		}
+	}
+}
+
+// reconcile evaluates every active canary and rolls back failing ones.
+func (r *Reconciler) reconcile() {
+	master, _, err := r.isMaster()
+	if err != nil {
+		r.logger.Warn("failed to determine master for canary reconcile", zap.Error(err))
+		return
+	}
+	if !master {
+		return
+	}
+
+	canaries, err := r.bundles.ListActiveCanaries()
+	if err != nil {
+		r.logger.Error("failed to list canaries", zap.Error(err))
+		return
+	}
+
+	now := r.now()
+	for _, canary := range canaries {
+		nodes, err := r.bundles.CanaryNodeHealth(canary.ClusterID)
+		if err != nil {
+			r.logger.Error("failed to load canary node health",
+				zap.String("cluster_id", canary.ClusterID),
+				zap.Error(err))
+			continue
+		}
+
+		reason, failed := r.evaluate(canary, nodes, now)
+		if !failed {
+			continue
+		}
+
+		if err := r.bundles.RollbackCanary(canary.ClusterID, canary.Version, reason); err != nil {
+			r.logger.Error("failed to roll back canary",
+				zap.String("cluster_id", canary.ClusterID),
+				zap.Int64("version", canary.Version),
+				zap.Error(err))
+			continue
+		}
+
+		metrics.CanaryRollbacks.WithLabelValues(canary.ClusterID).Inc()
+		r.logger.Error("ALERT: canary rollout failed and was rolled back",
+			zap.String("cluster_id", canary.ClusterID),
+			zap.Int64("version", canary.Version),
+			zap.Int64("stable_version", canary.StableVersion),
+			zap.String("reason", reason))
+	}
+}
+
+// evaluate reports whether a canary has failed, and why.
+func (r *Reconciler) evaluate(canary models.CanaryRollout, nodes []models.CanaryNodeHealth, now time.Time) (string, bool) {
+	windowPassed := now.Sub(canary.CreatedAt) >= r.config.HealthWindow
+
+	for _, node := range nodes {
+		if !node.HealthyBefore {
+			continue
+		}
+
+		reported := node.LastHeartbeatAt != nil && !node.LastHeartbeatAt.Before(canary.CreatedAt)
+
+		if r.config.RollbackOnError && reported &&
+			node.Status == models.NodeHealthError && node.ReportedVersion == canary.Version {
+			return fmt.Sprintf("node %s reported an error on version %d: %s",
+				node.NodeID, canary.Version, node.Message), true
+		}
+
+		if !windowPassed {
+			continue
+		}
+
+		switch {
+		case !reported:
+			return fmt.Sprintf("node %s did not report within %s of the rollout",
+				node.NodeID, r.config.HealthWindow), true
+		case now.Sub(*node.LastHeartbeatAt) > r.config.MaxHeartbeatAge:
+			return fmt.Sprintf("node %s has not reported for %s",
+				node.NodeID, now.Sub(*node.LastHeartbeatAt).Truncate(time.Second)), true
+		case node.ReportedVersion < canary.Version:
+			return fmt.Sprintf("node %s is still running version %d, not %d",
+				node.NodeID, node.ReportedVersion, canary.Version), true
+		case node.Status != models.NodeHealthOK:
+			return fmt.Sprintf("node %s reported %s on version %d: %s",
+				node.NodeID, node.Status, node.ReportedVersion, node.Message), true
+		}
+	}
+
+	return "", false
+}
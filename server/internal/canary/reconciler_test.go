@@ -0,0 +1,111 @@
+package canary
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+)
+
+func TestEvaluate(t *testing.T) {
+	createdAt := time.Unix(1700000000, 0).UTC()
+	before := createdAt.Add(-time.Minute)
+	after := createdAt.Add(time.Minute)
+	recent := createdAt.Add(5 * time.Minute)
+
+	canary := models.CanaryRollout{ClusterID: "cluster1", Version: 5, StableVersion: 4, CreatedAt: createdAt}
+
+	tests := []struct {
+		name       string
+		node       models.CanaryNodeHealth
+		elapsed    time.Duration
+		wantFailed bool
+		wantReason string
+	}{
+		{
+			name:    "healthy on canary version",
+			node:    models.CanaryNodeHealth{NodeID: "n1", HealthyBefore: true, LastHeartbeatAt: &after, ReportedVersion: 5, Status: models.NodeHealthOK},
+			elapsed: 2 * time.Minute,
+		},
+		{
+			name:       "error on canary version fails immediately",
+			node:       models.CanaryNodeHealth{NodeID: "n1", HealthyBefore: true, LastHeartbeatAt: &after, ReportedVersion: 5, Status: models.NodeHealthError, Message: "bad cert"},
+			elapsed:    2 * time.Minute,
+			wantFailed: true,
+			wantReason: "bad cert",
+		},
+		{
+			name:    "error on stable version waits for window",
+			node:    models.CanaryNodeHealth{NodeID: "n1", HealthyBefore: true, LastHeartbeatAt: &after, ReportedVersion: 4, Status: models.NodeHealthError},
+			elapsed: 2 * time.Minute,
+		},
+		{
+			name:    "silent node within window",
+			node:    models.CanaryNodeHealth{NodeID: "n1", HealthyBefore: true, LastHeartbeatAt: &before, ReportedVersion: 4, Status: models.NodeHealthOK},
+			elapsed: 4 * time.Minute,
+		},
+		{
+			name:       "silent node after window",
+			node:       models.CanaryNodeHealth{NodeID: "n1", HealthyBefore: true, LastHeartbeatAt: &before, ReportedVersion: 4, Status: models.NodeHealthOK},
+			elapsed:    6 * time.Minute,
+			wantFailed: true,
+			wantReason: "did not report",
+		},
+		{
+			name:       "stale heartbeat after window",
+			node:       models.CanaryNodeHealth{NodeID: "n1", HealthyBefore: true, LastHeartbeatAt: &after, ReportedVersion: 5, Status: models.NodeHealthOK},
+			elapsed:    10 * time.Minute,
+			wantFailed: true,
+			wantReason: "has not reported",
+		},
+		{
+			name:       "still on stable version after window",
+			node:       models.CanaryNodeHealth{NodeID: "n1", HealthyBefore: true, LastHeartbeatAt: &recent, ReportedVersion: 4, Status: models.NodeHealthOK},
+			elapsed:    6 * time.Minute,
+			wantFailed: true,
+			wantReason: "still running version 4",
+		},
+		{
+			name:    "unhealthy before rollout is ignored",
+			node:    models.CanaryNodeHealth{NodeID: "n1", HealthyBefore: false, ReportedVersion: 5, Status: models.NodeHealthError},
+			elapsed: 10 * time.Minute,
+		},
+	}
+
+	r := NewReconciler(DefaultConfig(), nil, nil, zap.NewNop())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, failed := r.evaluate(canary, []models.CanaryNodeHealth{tt.node}, createdAt.Add(tt.elapsed))
+			if failed != tt.wantFailed {
+				t.Fatalf("Expected failed=%v, got %v (reason %q)", tt.wantFailed, failed, reason)
+			}
+			if !strings.Contains(reason, tt.wantReason) {
+				t.Errorf("Expected reason containing %q, got %q", tt.wantReason, reason)
+			}
+		})
+	}
+}
+
+func TestEvaluateRollbackOnErrorDisabled(t *testing.T) {
+	createdAt := time.Unix(1700000000, 0).UTC()
+	after := createdAt.Add(time.Minute)
+
+	config := DefaultConfig()
+	config.RollbackOnError = false
+	r := NewReconciler(config, nil, nil, zap.NewNop())
+
+	canary := models.CanaryRollout{ClusterID: "cluster1", Version: 5, CreatedAt: createdAt}
+	nodes := []models.CanaryNodeHealth{
+		{NodeID: "n1", HealthyBefore: true, LastHeartbeatAt: &after, ReportedVersion: 5, Status: models.NodeHealthError},
+	}
+
+	if _, failed := r.evaluate(canary, nodes, after); failed {
+		t.Error("Expected no rollback within the window when RollbackOnError is disabled")
+	}
+	if _, failed := r.evaluate(canary, nodes, createdAt.Add(5*time.Minute)); !failed {
+		t.Error("Expected rollback once the window passes with the node in error")
+	}
+}
@@ -0,0 +1,45 @@
+// Package canary provides automatic rollback of canary config rollouts.
+//
+// The reconciler watches the health that canary nodes report after a canary
+// is defined and reverts the canary when they fail to report healthy, so a
+// bad bundle never reaches the rest of the cluster.
+package canary
+
+import "time"
+
+// Config holds configuration for the canary reconciler.
+type Config struct {
+	// CheckInterval is how often active canaries are evaluated.
+	// Default: 15 seconds
+	CheckInterval time.Duration
+
+	// HealthWindow is how long canary nodes have, after the canary is
+	// defined, to report healthy on the canary version.
+	// Default: 5 minutes
+	HealthWindow time.Duration
+
+	// MaxHeartbeatAge is how old a node's last report may be before the node
+	// counts as silent. Only applied once HealthWindow has passed.
+	// Default: 2 minutes
+	MaxHeartbeatAge time.Duration
+
+	// RollbackOnError rolls back as soon as a canary node reports an error
+	// for the canary version, without waiting for HealthWindow.
+	// Default: true
+	RollbackOnError bool
+
+	// Enabled determines if automatic rollback is enabled.
+	// Default: true
+	Enabled bool
+}
+
+// DefaultConfig returns a Config with default values.
+func DefaultConfig() *Config {
+	return &Config{
+		CheckInterval:   15 * time.Second,
+		HealthWindow:    5 * time.Minute,
+		MaxHeartbeatAge: 2 * time.Minute,
+		RollbackOnError: true,
+		Enabled:         true,
+	}
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Protocols a connectivity flow may use.
+const (
+	ProtoTCP  = "tcp"
+	ProtoUDP  = "udp"
+	ProtoICMP = "icmp"
+)
+
+// ErrInvalidFlow indicates a connectivity flow has an unknown protocol or an
+// out-of-range port.
+var ErrInvalidFlow = errors.New("flow protocol must be tcp, udp or icmp and port must be between 0 and 65535")
+
+// Flow is the kind of traffic a connectivity check evaluates.
+type Flow struct {
+	// Proto is the protocol: tcp, udp or icmp.
+	Proto string `json:"proto"`
+
+	// Port is the destination port (ignored for icmp).
+	Port int `json:"port"`
+}
+
+// Validate checks that the flow has a known protocol and a valid port.
+func (f Flow) Validate() error {
+	if f.Proto != ProtoTCP && f.Proto != ProtoUDP && f.Proto != ProtoICMP {
+		return ErrInvalidFlow
+	}
+	if f.Port < 0 || f.Port > 65535 {
+		return ErrInvalidFlow
+	}
+	return nil
+}
+
+// String returns the flow as "proto/port", or just "icmp".
+func (f Flow) String() string {
+	if f.Proto == ProtoICMP {
+		return f.Proto
+	}
+	return fmt.Sprintf("%s/%d", f.Proto, f.Port)
+}
+
+// Connectivity evaluates which nodes can reach which for a flow.
+//
+// A node reaches a peer when both can discover peers (they are lighthouses or
+// query at least one) and the flow passes the sender's outbound rules and the
+// receiver's inbound rules. Firewalls are deny-by-default: a flow no rule
+// matches is dropped. Only rules for "any" host are evaluated, since overlay
+// IPs and certificate groups are not known to the control plane; rules naming
+// a specific host never match.
+//
+// Parameters:
+//   - configs: Generated config of every node, indexed like the result
+//   - flow: Traffic to evaluate
+//
+// Returns:
+//   - Matrix where [i][j] reports whether node i reaches node j
+//     (the diagonal is always false)
+func Connectivity(configs []*NebulaConfig, flow Flow) [][]bool {
+	matrix := make([][]bool, len(configs))
+	for i, from := range configs {
+		matrix[i] = make([]bool, len(configs))
+		if !canDiscover(from) || !allows(from.Firewall.Outbound, flow) {
+			continue
+		}
+		for j, to := range configs {
+			if i != j && canDiscover(to) && allows(to.Firewall.Inbound, flow) {
+				matrix[i][j] = true
+			}
+		}
+	}
+	return matrix
+}
+
+// RelayedConnectivity evaluates which nodes can still reach which through a
+// relay when a direct connection between them is impossible, for example when
+// both are behind NATs that defeat hole punching.
+//
+// Node i reaches node j through a relay when i reaches j per Connectivity, i
+// uses relays, and one of the relays j lists is a node acting as a relay other
+// than i. Relays forward encrypted traffic, so their firewall is not consulted.
+//
+// Parameters:
+//   - configs: Generated config of every node, indexed like the result
+//   - addrs: Address each node is referenced by in the configs' relay lists
+//   - flow: Traffic to evaluate
+//
+// Returns:
+//   - Matrix where [i][j] reports whether node i reaches node j through a
+//     relay (the diagonal is always false)
+func RelayedConnectivity(configs []*NebulaConfig, addrs []string, flow Flow) [][]bool {
+	index := make(map[string]int, len(addrs))
+	for i, addr := range addrs {
+		index[addr] = i
+	}
+
+	matrix := Connectivity(configs, flow)
+	for i, from := range configs {
+		for j, to := range configs {
+			if !matrix[i][j] {
+				continue
+			}
+			matrix[i][j] = false
+			if !from.Relay.UseRelays {
+				continue
+			}
+			for _, relay := range to.Relay.Relays {
+				if k, ok := index[relay]; ok && k != i && configs[k].Relay.AmRelay {
+					matrix[i][j] = true
+					break
+				}
+			}
+		}
+	}
+	return matrix
+}
+
+// canDiscover reports whether a node can learn peer addresses. Regular nodes
+// need a lighthouse to query; lighthouses learn from the nodes querying them.
+func canDiscover(cfg *NebulaConfig) bool {
+	return cfg.Lighthouse.AmLighthouse || len(cfg.Lighthouse.Hosts) > 0
+}
+
+// allows reports whether any rule matches the flow.
+func allows(rules []FirewallRule, flow Flow) bool {
+	for _, rule := range rules {
+		if rule.Host != "any" {
+			continue
+		}
+		if rule.Proto != "any" && rule.Proto != flow.Proto {
+			continue
+		}
+		if flow.Proto == ProtoICMP || portMatches(rule.Port, flow.Port) {
+			return true
+		}
+	}
+	return false
+}
+
+// portMatches reports whether a rule's port ("any", a number, or a "low-high"
+// range) covers port.
+func portMatches(rulePort interface{}, port int) bool {
+	switch p := rulePort.(type) {
+	case int:
+		return p == port
+	case float64:
+		return int(p) == port
+	case string:
+		if p == "any" {
+			return true
+		}
+		low, high, isRange := strings.Cut(p, "-")
+		if !isRange {
+			high = low
+		}
+		lo, err1 := strconv.Atoi(low)
+		hi, err2 := strconv.Atoi(high)
+		return err1 == nil && err2 == nil && lo <= port && port <= hi
+	default:
+		return false
+	}
+}
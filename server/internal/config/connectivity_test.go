@@ -0,0 +1,130 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+// firewalled returns a config for a regular node querying one lighthouse.
+func firewalled(inbound ...FirewallRule) *NebulaConfig {
+	return &NebulaConfig{
+		Lighthouse: LighthouseConfig{Hosts: []string{"10.42.0.1"}},
+		Firewall: FirewallConfig{
+			Outbound: []FirewallRule{{Port: "any", Proto: "any", Host: "any"}},
+			Inbound:  inbound,
+		},
+	}
+}
+
+func TestConnectivityDenyByDefault(t *testing.T) {
+	web := firewalled(FirewallRule{Port: 443, Proto: "tcp", Host: "any"})
+	ssh := firewalled(FirewallRule{Port: "22-23", Proto: "tcp", Host: "any"})
+	closed := firewalled()
+	restricted := firewalled(FirewallRule{Port: "any", Proto: "any", Host: "10.42.0.0/16"})
+	configs := []*NebulaConfig{web, ssh, closed, restricted}
+
+	tests := []struct {
+		flow Flow
+		want [][]bool
+	}{
+		{
+			flow: Flow{Proto: ProtoTCP, Port: 443},
+			want: [][]bool{
+				{false, false, false, false},
+				{true, false, false, false},
+				{true, false, false, false},
+				{true, false, false, false},
+			},
+		},
+		{
+			flow: Flow{Proto: ProtoTCP, Port: 22},
+			want: [][]bool{
+				{false, true, false, false},
+				{false, false, false, false},
+				{false, true, false, false},
+				{false, true, false, false},
+			},
+		},
+		{
+			flow: Flow{Proto: ProtoUDP, Port: 443},
+			want: [][]bool{
+				{false, false, false, false},
+				{false, false, false, false},
+				{false, false, false, false},
+				{false, false, false, false},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flow.String(), func(t *testing.T) {
+			if got := Connectivity(configs, tt.flow); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Connectivity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectivityRequiresDiscovery(t *testing.T) {
+	open := FirewallRule{Port: "any", Proto: "any", Host: "any"}
+	lighthouse := firewalled(open)
+	lighthouse.Lighthouse = LighthouseConfig{AmLighthouse: true}
+	lost := firewalled(open)
+	lost.Lighthouse.Hosts = nil
+	configs := []*NebulaConfig{lighthouse, firewalled(open), lost}
+
+	want := [][]bool{
+		{false, true, false},
+		{true, false, false},
+		{false, false, false},
+	}
+	if got := Connectivity(configs, Flow{Proto: ProtoICMP}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Connectivity() = %v, want %v", got, want)
+	}
+}
+
+func TestRelayedConnectivity(t *testing.T) {
+	open := FirewallRule{Port: "any", Proto: "any", Host: "any"}
+	relay := firewalled(open)
+	relay.Relay = RelayConfig{AmRelay: true}
+	a := firewalled(open)
+	a.Relay = RelayConfig{Relays: []string{"relay"}, UseRelays: true}
+	b := firewalled(open)
+	b.Relay = RelayConfig{Relays: []string{"relay"}, UseRelays: true}
+	noRelays := firewalled(open)
+	configs := []*NebulaConfig{relay, a, b, noRelays}
+	addrs := []string{"relay", "a", "b", "no-relays"}
+
+	want := [][]bool{
+		{false, false, false, false},
+		{false, false, true, false},
+		{false, true, false, false},
+		{false, false, false, false},
+	}
+	if got := RelayedConnectivity(configs, addrs, Flow{Proto: ProtoICMP}); !reflect.DeepEqual(got, want) {
+		t.Errorf("RelayedConnectivity() = %v, want %v", got, want)
+	}
+
+	// A relay that no longer acts as one forwards nothing
+	relay.Relay.AmRelay = false
+	for i, row := range RelayedConnectivity(configs, addrs, Flow{Proto: ProtoICMP}) {
+		for j, reachable := range row {
+			if reachable {
+				t.Errorf("RelayedConnectivity()[%d][%d] = true without a relay", i, j)
+			}
+		}
+	}
+}
+
+func TestFlowValidate(t *testing.T) {
+	for _, flow := range []Flow{{Proto: ProtoTCP, Port: 443}, {Proto: ProtoICMP}} {
+		if err := flow.Validate(); err != nil {
+			t.Errorf("Validate(%v) = %v, want nil", flow, err)
+		}
+	}
+	for _, flow := range []Flow{{Proto: "any"}, {Proto: ProtoUDP, Port: 70000}, {Proto: ProtoTCP, Port: -1}} {
+		if err := flow.Validate(); err == nil {
+			t.Errorf("Validate(%v) = nil, want error", flow)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// defaultLighthousePort is used when neither the node nor the cluster sets one.
+const defaultLighthousePort = 4242
+
+// defaultMTU is used when the node has no MTU configured.
+const defaultMTU = 1300
+
+var (
+	// ErrMissingInput indicates Generate was called without input.
+	ErrMissingInput = errors.New("config input is required")
+
+	// ErrInvalidRelayHops indicates the cluster relay hop limit is out of range.
+	ErrInvalidRelayHops = fmt.Errorf("max relay hops must be between 0 and %d", MaxRelayHopsLimit)
+
+	// ErrInvalidCipher indicates the cluster cipher is not supported by Nebula.
+	ErrInvalidCipher = fmt.Errorf("cipher must be %q or %q", CipherAES, CipherChachaPoly)
+
+	// ErrDNSPortConflict indicates the lighthouse DNS port equals the Nebula UDP port.
+	ErrDNSPortConflict = errors.New("lighthouse DNS port conflicts with the Nebula listen port")
+)
+
+// Generate creates a Nebula configuration for a single node.
+//
+// Lighthouses are emitted into the static host map and lighthouse hosts list,
+// relays into the relay block (subject to the cluster's MaxRelayHops), and
+// routes advertised by other nodes into tun.unsafe_routes. Peers without an
+// overlay IP are skipped since Nebula cannot address them. Lighthouse nodes
+// also receive the DNS block when the cluster enables lighthouse DNS.
+//
+// Parameters:
+//   - in: Cluster, node, and peer information
+//
+// Returns:
+//   - Nebula configuration struct
+//   - Error if the input is invalid
+func Generate(in *Input) (*NebulaConfig, error) {
+	if in == nil {
+		return nil, ErrMissingInput
+	}
+	if in.Cluster.MaxRelayHops < 0 || in.Cluster.MaxRelayHops > MaxRelayHopsLimit {
+		return nil, ErrInvalidRelayHops
+	}
+	if in.Cluster.Cipher != "" && !ValidCipher(in.Cluster.Cipher) {
+		return nil, ErrInvalidCipher
+	}
+
+	configDir := in.ConfigDir
+	if configDir == "" {
+		configDir = DefaultConfigDir
+	}
+
+	mtu := in.Node.MTU
+	if mtu == 0 {
+		mtu = defaultMTU
+	}
+
+	dns, err := buildDNSConfig(in)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &NebulaConfig{
+		PKI: PKIConfig{
+			CA:   filepath.Join(configDir, "ca.crt"),
+			Cert: filepath.Join(configDir, "host.crt"),
+			Key:  filepath.Join(configDir, "host.key"),
+			CRL:  filepath.Join(configDir, "crl.pem"),
+		},
+		StaticHostMap: buildStaticHostMap(in),
+		Lighthouse: LighthouseConfig{
+			AmLighthouse: in.Node.IsLighthouse,
+			ServeDNS:     dns != nil,
+			DNS:          dns,
+			Interval:     60,
+			Hosts:        buildLighthouseHosts(in),
+		},
+		Listen: ListenConfig{
+			Host: "0.0.0.0",
+			Port: listenPort(in),
+		},
+		Punchy: PunchyConfig{
+			Punch:   true,
+			Respond: true,
+		},
+		PreferredRanges: in.Cluster.PreferredRanges,
+		Cipher:          in.Cluster.Cipher,
+		Handshakes:      buildHandshakesConfig(in),
+		Relay:           buildRelayConfig(in),
+		Tun: TunConfig{
+			Disabled:     false,
+			Dev:          "nebula1",
+			MTU:          mtu,
+			UnsafeRoutes: buildUnsafeRoutes(in),
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		Firewall: FirewallConfig{
+			Outbound: []FirewallRule{
+				{Port: "any", Proto: "any", Host: "any"},
+			},
+			Inbound: []FirewallRule{
+				{Port: "any", Proto: "icmp", Host: "any"},
+			},
+		},
+	}
+
+	return cfg, nil
+}
+
+// buildStaticHostMap maps each lighthouse's overlay IP to its public endpoint
+// and adds the cluster's static entries for fixed-endpoint peers. The node's
+// own overlay IP is omitted from the static entries.
+func buildStaticHostMap(in *Input) map[string][]string {
+	hostMap := make(map[string][]string)
+	for _, lh := range in.Lighthouses {
+		if lh.NebulaIP == "" || lh.PublicIP == "" {
+			continue
+		}
+		port := lh.Port
+		if port == 0 {
+			port = clusterLighthousePort(in)
+		}
+		endpoint := net.JoinHostPort(lh.PublicIP, strconv.Itoa(port))
+		hostMap[lh.NebulaIP] = append(hostMap[lh.NebulaIP], endpoint)
+	}
+
+	nebulaIPs := make([]string, 0, len(in.Cluster.StaticHostMap))
+	for nebulaIP := range in.Cluster.StaticHostMap {
+		nebulaIPs = append(nebulaIPs, nebulaIP)
+	}
+	sort.Strings(nebulaIPs)
+
+	for _, nebulaIP := range nebulaIPs {
+		if nebulaIP == in.Node.NebulaIP {
+			continue
+		}
+		for _, endpoint := range in.Cluster.StaticHostMap[nebulaIP] {
+			if !containsString(hostMap[nebulaIP], endpoint) {
+				hostMap[nebulaIP] = append(hostMap[nebulaIP], endpoint)
+			}
+		}
+	}
+	return hostMap
+}
+
+// containsString reports whether list contains value.
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// buildLighthouseHosts returns the overlay IPs of lighthouses to query.
+// Lighthouses themselves must not list other lighthouses.
+func buildLighthouseHosts(in *Input) []string {
+	hosts := []string{}
+	if in.Node.IsLighthouse {
+		return hosts
+	}
+	for _, lh := range in.Lighthouses {
+		if lh.NebulaIP == "" || lh.NodeID == in.Node.ID {
+			continue
+		}
+		hosts = append(hosts, lh.NebulaIP)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// ValidCipher reports whether name is a cipher Nebula supports.
+func ValidCipher(name string) bool {
+	return name == CipherAES || name == CipherChachaPoly
+}
+
+// buildHandshakesConfig returns the handshakes block, or nil when the cluster
+// leaves every handshake setting at the Nebula default.
+func buildHandshakesConfig(in *Input) *HandshakesConfig {
+	h := in.Cluster.Handshake
+	if h == (Handshake{}) {
+		return nil
+	}
+	return &HandshakesConfig{
+		TryInterval:   h.TryInterval,
+		Retries:       h.Retries,
+		TriggerBuffer: h.TriggerBuffer,
+	}
+}
+
+// buildDNSConfig returns the DNS block for lighthouse nodes, or nil when the
+// node is not a lighthouse or the cluster has lighthouse DNS disabled.
+// The DNS port must differ from the node's Nebula listen port.
+func buildDNSConfig(in *Input) (*DNSConfig, error) {
+	if !in.Node.IsLighthouse || !in.Cluster.DNS.Enabled {
+		return nil, nil
+	}
+
+	dns := &DNSConfig{Host: in.Cluster.DNS.Host, Port: in.Cluster.DNS.Port}
+	if dns.Host == "" {
+		dns.Host = DefaultDNSHost
+	}
+	if dns.Port == 0 {
+		dns.Port = DefaultDNSPort
+	}
+	if dns.Port == listenPort(in) {
+		return nil, ErrDNSPortConflict
+	}
+	return dns, nil
+}
+
+// buildRelayConfig produces the relay block while respecting MaxRelayHops.
+//
+// With a hop limit of 0 relaying is disabled. Relay nodes only use other
+// relays when the limit allows more than one hop, which prevents a relay
+// from forwarding traffic through another relay in single-hop clusters.
+//
+// If the node has preferred relays, only those are emitted, in preference
+// order. Preferences that no longer point at a relay are ignored, and if none
+// remain the node falls back to every relay in the cluster.
+func buildRelayConfig(in *Input) RelayConfig {
+	hops := in.Cluster.MaxRelayHops
+	if hops == 0 {
+		return RelayConfig{}
+	}
+
+	relay := RelayConfig{AmRelay: in.Node.IsRelay}
+	if in.Node.IsRelay && hops < 2 {
+		return relay
+	}
+
+	available := make(map[string]string, len(in.Relays))
+	for _, r := range in.Relays {
+		if r.NebulaIP == "" || r.NodeID == in.Node.ID {
+			continue
+		}
+		available[r.NodeID] = r.NebulaIP
+	}
+
+	for _, relayID := range in.Node.PreferredRelays {
+		if ip, ok := available[relayID]; ok {
+			relay.Relays = append(relay.Relays, ip)
+		}
+	}
+
+	if len(relay.Relays) == 0 {
+		for _, ip := range available {
+			relay.Relays = append(relay.Relays, ip)
+		}
+		sort.Strings(relay.Relays)
+	}
+	relay.UseRelays = len(relay.Relays) > 0
+
+	return relay
+}
+
+// buildUnsafeRoutes routes networks advertised by other nodes via their overlay IP.
+func buildUnsafeRoutes(in *Input) []UnsafeRoute {
+	var routes []UnsafeRoute
+	for _, peer := range in.RouteAdvertisers {
+		if peer.NebulaIP == "" || peer.NodeID == in.Node.ID {
+			continue
+		}
+		for _, route := range peer.Routes {
+			routes = append(routes, UnsafeRoute{Route: route, Via: peer.NebulaIP})
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Route != routes[j].Route {
+			return routes[i].Route < routes[j].Route
+		}
+		return routes[i].Via < routes[j].Via
+	})
+	return routes
+}
+
+// listenPort returns the UDP port the node listens on.
+// Lighthouses use a fixed port; other nodes let Nebula pick one.
+func listenPort(in *Input) int {
+	if !in.Node.IsLighthouse {
+		return 0
+	}
+	if in.Node.LighthousePort != 0 {
+		return in.Node.LighthousePort
+	}
+	return clusterLighthousePort(in)
+}
+
+// clusterLighthousePort returns the cluster default lighthouse port.
+func clusterLighthousePort(in *Input) int {
+	if in.Cluster.LighthousePort != 0 {
+		return in.Cluster.LighthousePort
+	}
+	return defaultLighthousePort
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// update rewrites golden files instead of comparing against them.
+// Run with: go test ./internal/config -update
+var update = flag.Bool("update", false, "update golden files")
+
+// testInput returns a small cluster with two lighthouses, two relays,
+// and one route advertiser, generated for the given node.
+func testInput(node Node, maxRelayHops int) *Input {
+	return &Input{
+		Cluster: Cluster{
+			ID:             "cluster-1",
+			LighthousePort: 4242,
+			MaxRelayHops:   maxRelayHops,
+		},
+		Node: node,
+		Lighthouses: []Peer{
+			{NodeID: "lh-1", Name: "lighthouse-1", NebulaIP: "10.42.0.1", PublicIP: "203.0.113.1", Port: 4242},
+			{NodeID: "lh-2", Name: "lighthouse-2", NebulaIP: "10.42.0.2", PublicIP: "203.0.113.2"},
+		},
+		Relays: []Peer{
+			{NodeID: "relay-1", Name: "relay-1", NebulaIP: "10.42.0.10"},
+			{NodeID: "relay-2", Name: "relay-2", NebulaIP: "10.42.0.11"},
+		},
+		RouteAdvertisers: []Peer{
+			{NodeID: "router-1", Name: "router-1", NebulaIP: "10.42.0.20", Routes: []string{"192.168.10.0/24", "172.16.0.0/16"}},
+		},
+	}
+}
+
+// withDNS enables lighthouse DNS on the input's cluster.
+func withDNS(in *Input, port int) *Input {
+	in.Cluster.DNS = DNS{Enabled: true, Host: "10.42.0.1", Port: port}
+	return in
+}
+
+// withStaticHosts adds static host map entries to the input's cluster,
+// including one that duplicates a lighthouse endpoint.
+func withStaticHosts(in *Input) *Input {
+	in.Cluster.StaticHostMap = map[string][]string{
+		"10.42.0.50": {"198.51.100.50:4242", "198.51.100.51:4242"},
+		"10.42.0.1":  {"203.0.113.1:4242", "198.51.100.1:4242"},
+	}
+	return in
+}
+
+// withPreferredRanges sets preferred ranges on the input's cluster.
+func withPreferredRanges(in *Input) *Input {
+	in.Cluster.PreferredRanges = []string{"192.168.0.0/16", "10.10.0.0/24"}
+	return in
+}
+
+// withCipher sets the cipher and handshake tuning on the input's cluster.
+func withCipher(in *Input) *Input {
+	in.Cluster.Cipher = CipherChachaPoly
+	in.Cluster.Handshake = Handshake{TryInterval: "200ms", Retries: 30, TriggerBuffer: 128}
+	return in
+}
+
+func TestGenerateGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		input *Input
+	}{
+		{
+			name:  "regular_node",
+			input: testInput(Node{ID: "node-1", Name: "node-1", NebulaIP: "10.42.0.100", MTU: 1300}, 1),
+		},
+		{
+			name:  "lighthouse_node",
+			input: testInput(Node{ID: "lh-1", Name: "lighthouse-1", NebulaIP: "10.42.0.1", IsLighthouse: true, LighthousePort: 4242}, 1),
+		},
+		{
+			name:  "relay_node_single_hop",
+			input: testInput(Node{ID: "relay-1", Name: "relay-1", NebulaIP: "10.42.0.10", IsRelay: true}, 1),
+		},
+		{
+			name:  "relay_node_multi_hop",
+			input: testInput(Node{ID: "relay-1", Name: "relay-1", NebulaIP: "10.42.0.10", IsRelay: true}, 2),
+		},
+		{
+			name:  "preferred_relays",
+			input: testInput(Node{ID: "node-1", Name: "node-1", NebulaIP: "10.42.0.100", PreferredRelays: []string{"relay-2", "missing-relay"}}, 1),
+		},
+		{
+			name:  "relays_disabled",
+			input: testInput(Node{ID: "node-1", Name: "node-1", NebulaIP: "10.42.0.100"}, 0),
+		},
+		{
+			name:  "lighthouse_dns",
+			input: withDNS(testInput(Node{ID: "lh-1", Name: "lighthouse-1", NebulaIP: "10.42.0.1", IsLighthouse: true}, 1), 5353),
+		},
+		{
+			name:  "static_host_map",
+			input: withStaticHosts(testInput(Node{ID: "node-1", Name: "node-1", NebulaIP: "10.42.0.100"}, 1)),
+		},
+		{
+			name:  "static_host_map_self",
+			input: withStaticHosts(testInput(Node{ID: "db-1", Name: "db-1", NebulaIP: "10.42.0.50"}, 1)),
+		},
+		{
+			name:  "preferred_ranges",
+			input: withPreferredRanges(testInput(Node{ID: "node-1", Name: "node-1", NebulaIP: "10.42.0.100"}, 1)),
+		},
+		{
+			name:  "cipher_handshake",
+			input: withCipher(testInput(Node{ID: "node-1", Name: "node-1", NebulaIP: "10.42.0.100"}, 1)),
+		},
+		{
+			name:  "regular_node_dns_enabled",
+			input: withDNS(testInput(Node{ID: "node-1", Name: "node-1", NebulaIP: "10.42.0.100"}, 1), 5353),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Generate(tt.input)
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+
+			got, err := yaml.Marshal(cfg)
+			if err != nil {
+				t.Fatalf("Failed to marshal config: %v", err)
+			}
+
+			golden := filepath.Join("testdata", tt.name+".yml")
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatalf("Failed to update golden file: %v", err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("Failed to read golden file: %v", err)
+			}
+
+			if string(got) != string(want) {
+				t.Errorf("Generated config does not match %s\n--- got ---\n%s\n--- want ---\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+func TestGenerateInvalidRelayHops(t *testing.T) {
+	for _, hops := range []int{-1, MaxRelayHopsLimit + 1} {
+		_, err := Generate(testInput(Node{ID: "node-1"}, hops))
+		if !errors.Is(err, ErrInvalidRelayHops) {
+			t.Errorf("Expected ErrInvalidRelayHops for %d hops, got %v", hops, err)
+		}
+	}
+}
+
+func TestGenerateDNSPortConflict(t *testing.T) {
+	in := withDNS(testInput(Node{ID: "lh-1", NebulaIP: "10.42.0.1", IsLighthouse: true}, 1), 4242)
+	if _, err := Generate(in); !errors.Is(err, ErrDNSPortConflict) {
+		t.Errorf("Expected ErrDNSPortConflict, got %v", err)
+	}
+}
+
+func TestGenerateInvalidCipher(t *testing.T) {
+	in := testInput(Node{ID: "node-1"}, 1)
+	in.Cluster.Cipher = "des"
+	if _, err := Generate(in); !errors.Is(err, ErrInvalidCipher) {
+		t.Errorf("Expected ErrInvalidCipher, got %v", err)
+	}
+}
+
+func TestGenerateMissingInput(t *testing.T) {
+	if _, err := Generate(nil); !errors.Is(err, ErrMissingInput) {
+		t.Errorf("Expected ErrMissingInput, got %v", err)
+	}
+}
@@ -0,0 +1,254 @@
+// Package config generates Nebula configuration files for cluster nodes.
+//
+// The generator is a pure function of the cluster topology: it takes the
+// cluster settings, the node being configured, and its peers (lighthouses,
+// relays, route advertisers) and produces a NebulaConfig that can be
+// marshalled to config.yml. It performs no database access. Lighthouses run
+// by the control plane are configured through it as well.
+package config
+
+// DefaultConfigDir is the directory where nodes keep their Nebula files.
+const DefaultConfigDir = "/etc/nebula"
+
+// DefaultMaxRelayHops is the default relay hop limit for a cluster.
+// A value of 1 allows nodes to reach peers through a single relay.
+const DefaultMaxRelayHops = 1
+
+// MaxRelayHopsLimit is the highest relay hop limit a cluster may configure.
+const MaxRelayHopsLimit = 4
+
+// Supported Nebula ciphers. Every node in a cluster must use the same one.
+const (
+	CipherAES        = "aes"
+	CipherChachaPoly = "chachapoly"
+)
+
+// DefaultDNSHost is the default listen address for lighthouse DNS.
+const DefaultDNSHost = "0.0.0.0"
+
+// DefaultDNSPort is the default listen port for lighthouse DNS.
+const DefaultDNSPort = 53
+
+// Input holds everything needed to generate a config for a single node.
+type Input struct {
+	// Cluster is the cluster-wide configuration.
+	Cluster Cluster
+
+	// Node is the node the config is generated for.
+	Node Node
+
+	// Lighthouses is the list of lighthouse peers in the cluster.
+	Lighthouses []Peer
+
+	// Relays is the list of relay peers in the cluster.
+	Relays []Peer
+
+	// RouteAdvertisers is the list of peers advertising unsafe routes.
+	RouteAdvertisers []Peer
+
+	// ConfigDir is the directory holding PKI files on the node.
+	// Default: /etc/nebula
+	ConfigDir string
+}
+
+// Cluster holds cluster-wide settings used by the generator.
+type Cluster struct {
+	// ID is the cluster's UUID.
+	ID string
+
+	// LighthousePort is the default UDP port for lighthouses.
+	LighthousePort int
+
+	// MaxRelayHops limits how many relays traffic may traverse.
+	// 0 disables relaying entirely.
+	MaxRelayHops int
+
+	// DNS holds the lighthouse DNS settings.
+	DNS DNS
+
+	// StaticHostMap maps Nebula IPs of fixed-endpoint peers to their
+	// public "ip:port" endpoints. Emitted into every node's config.
+	StaticHostMap map[string][]string
+
+	// PreferredRanges lists local networks nodes try first when
+	// connecting to peers.
+	PreferredRanges []string
+
+	// Cipher is the Nebula cipher (empty = Nebula default).
+	Cipher string
+
+	// Handshake holds optional handshake tuning.
+	Handshake Handshake
+}
+
+// Handshake holds the cluster's Nebula handshake tuning.
+// Zero values leave the Nebula default in place.
+type Handshake struct {
+	// TryInterval is the delay between handshake attempts (e.g., "100ms").
+	TryInterval string
+
+	// Retries is the number of handshake attempts before giving up.
+	Retries int
+
+	// TriggerBuffer is the size of the pending handshake trigger queue.
+	TriggerBuffer int
+}
+
+// DNS holds the cluster's lighthouse DNS settings.
+type DNS struct {
+	// Enabled indicates lighthouses answer DNS queries for node names.
+	Enabled bool
+
+	// Host is the DNS listen address (empty = DefaultDNSHost).
+	Host string
+
+	// Port is the DNS listen port (0 = DefaultDNSPort).
+	Port int
+}
+
+// Node describes the node a config is generated for.
+type Node struct {
+	// ID is the node's UUID.
+	ID string
+
+	// Name is the node's name.
+	Name string
+
+	// NebulaIP is the node's overlay IP address.
+	NebulaIP string
+
+	// MTU is the TUN device MTU.
+	MTU int
+
+	// IsLighthouse indicates the node acts as a lighthouse.
+	IsLighthouse bool
+
+	// LighthousePort is the node's lighthouse UDP port (0 = cluster default).
+	LighthousePort int
+
+	// IsRelay indicates the node acts as a relay.
+	IsRelay bool
+
+	// PreferredRelays is the ordered list of relay node IDs the node should use.
+	// When empty, every relay in the cluster is used.
+	PreferredRelays []string
+}
+
+// Peer describes another node referenced from the generated config.
+type Peer struct {
+	// NodeID is the peer's UUID.
+	NodeID string
+
+	// Name is the peer's name.
+	Name string
+
+	// NebulaIP is the peer's overlay IP address.
+	NebulaIP string
+
+	// PublicIP is the peer's public IP address (lighthouses only).
+	PublicIP string
+
+	// Port is the peer's public UDP port (lighthouses only).
+	Port int
+
+	// Routes is the list of CIDRs the peer advertises.
+	Routes []string
+}
+
+// NebulaConfig represents the structure of a Nebula node configuration file.
+type NebulaConfig struct {
+	PKI             PKIConfig           `json:"pki" yaml:"pki"`
+	StaticHostMap   map[string][]string `json:"static_host_map" yaml:"static_host_map"`
+	Lighthouse      LighthouseConfig    `json:"lighthouse" yaml:"lighthouse"`
+	Listen          ListenConfig        `json:"listen" yaml:"listen"`
+	Punchy          PunchyConfig        `json:"punchy" yaml:"punchy"`
+	PreferredRanges []string            `json:"preferred_ranges,omitempty" yaml:"preferred_ranges,omitempty"`
+	Cipher          string              `json:"cipher,omitempty" yaml:"cipher,omitempty"`
+	Handshakes      *HandshakesConfig   `json:"handshakes,omitempty" yaml:"handshakes,omitempty"`
+	Relay           RelayConfig         `json:"relay" yaml:"relay"`
+	Tun             TunConfig           `json:"tun" yaml:"tun"`
+	Logging         LoggingConfig       `json:"logging" yaml:"logging"`
+	Firewall        FirewallConfig      `json:"firewall" yaml:"firewall"`
+}
+
+// PKIConfig holds PKI file paths.
+type PKIConfig struct {
+	CA   string `json:"ca" yaml:"ca"`
+	Cert string `json:"cert" yaml:"cert"`
+	Key  string `json:"key" yaml:"key"`
+	CRL  string `json:"crl,omitempty" yaml:"crl,omitempty"`
+}
+
+// LighthouseConfig holds lighthouse settings.
+type LighthouseConfig struct {
+	AmLighthouse bool       `json:"am_lighthouse" yaml:"am_lighthouse"`
+	ServeDNS     bool       `json:"serve_dns,omitempty" yaml:"serve_dns,omitempty"`
+	DNS          *DNSConfig `json:"dns,omitempty" yaml:"dns,omitempty"`
+	Interval     int        `json:"interval" yaml:"interval"`
+	Hosts        []string   `json:"hosts" yaml:"hosts"`
+}
+
+// DNSConfig holds the lighthouse DNS listener settings.
+type DNSConfig struct {
+	Host string `json:"host" yaml:"host"`
+	Port int    `json:"port" yaml:"port"`
+}
+
+// ListenConfig holds network listener settings.
+type ListenConfig struct {
+	Host string `json:"host" yaml:"host"`
+	Port int    `json:"port" yaml:"port"`
+}
+
+// PunchyConfig holds NAT traversal settings.
+type PunchyConfig struct {
+	Punch   bool `json:"punch" yaml:"punch"`
+	Respond bool `json:"respond" yaml:"respond"`
+}
+
+// HandshakesConfig holds handshake tuning settings.
+type HandshakesConfig struct {
+	TryInterval   string `json:"try_interval,omitempty" yaml:"try_interval,omitempty"`
+	Retries       int    `json:"retries,omitempty" yaml:"retries,omitempty"`
+	TriggerBuffer int    `json:"trigger_buffer,omitempty" yaml:"trigger_buffer,omitempty"`
+}
+
+// RelayConfig holds relay settings.
+type RelayConfig struct {
+	Relays    []string `json:"relays,omitempty" yaml:"relays,omitempty"`
+	AmRelay   bool     `json:"am_relay" yaml:"am_relay"`
+	UseRelays bool     `json:"use_relays" yaml:"use_relays"`
+}
+
+// TunConfig holds TUN device settings.
+type TunConfig struct {
+	Disabled     bool          `json:"disabled" yaml:"disabled"`
+	Dev          string        `json:"dev" yaml:"dev"`
+	MTU          int           `json:"mtu" yaml:"mtu"`
+	UnsafeRoutes []UnsafeRoute `json:"unsafe_routes,omitempty" yaml:"unsafe_routes,omitempty"`
+}
+
+// UnsafeRoute routes a non-overlay network through a Nebula peer.
+type UnsafeRoute struct {
+	Route string `json:"route" yaml:"route"`
+	Via   string `json:"via" yaml:"via"`
+}
+
+// LoggingConfig holds logging settings.
+type LoggingConfig struct {
+	Level  string `json:"level" yaml:"level"`
+	Format string `json:"format" yaml:"format"`
+}
+
+// FirewallConfig holds firewall rules.
+type FirewallConfig struct {
+	Outbound []FirewallRule `json:"outbound" yaml:"outbound"`
+	Inbound  []FirewallRule `json:"inbound" yaml:"inbound"`
+}
+
+// FirewallRule represents a single firewall rule.
+type FirewallRule struct {
+	Port  interface{} `json:"port" yaml:"port"`   // Can be "any" or number
+	Proto string      `json:"proto" yaml:"proto"` // "any", "tcp", "udp", "icmp"
+	Host  string      `json:"host" yaml:"host"`   // "any" or CIDR
+}
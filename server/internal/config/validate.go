@@ -0,0 +1,200 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"sort"
+)
+
+// Severity classifies a validation issue.
+type Severity string
+
+const (
+	// SeverityError marks an issue that produces a non-working Nebula config.
+	SeverityError Severity = "error"
+
+	// SeverityWarning marks an issue that may degrade connectivity.
+	SeverityWarning Severity = "warning"
+)
+
+// Issue codes reported by Validate.
+const (
+	IssueGenerateFailed      = "generate_failed"
+	IssueMissingCA           = "missing_ca"
+	IssueNoNodes             = "no_nodes"
+	IssueNoLighthouse        = "no_lighthouse"
+	IssueLighthouseNoAddress = "lighthouse_missing_public_ip"
+	IssueInvalidRoute        = "invalid_route"
+	IssueOverlappingRoutes   = "overlapping_routes"
+	IssueTooManyRelays       = "too_many_relays"
+)
+
+// Issue is a single finding in a validation report.
+type Issue struct {
+	// Severity is "error" or "warning".
+	Severity Severity `json:"severity"`
+
+	// Code is a stable machine-readable identifier.
+	Code string `json:"code"`
+
+	// Message is a human-readable description.
+	Message string `json:"message"`
+
+	// NodeIDs lists the nodes the issue relates to, if any.
+	NodeIDs []string `json:"node_ids,omitempty"`
+}
+
+// Report is the result of validating a cluster's topology.
+type Report struct {
+	// Valid is true when the report contains no errors.
+	Valid bool `json:"valid"`
+
+	// RepresentativeNodeID is the node the config generator was run for.
+	RepresentativeNodeID string `json:"representative_node_id,omitempty"`
+
+	// Errors is the list of issues that break generated configs.
+	Errors []Issue `json:"errors"`
+
+	// Warnings is the list of issues that may degrade connectivity.
+	Warnings []Issue `json:"warnings"`
+}
+
+// ValidationInput holds the cluster state checked by Validate.
+type ValidationInput struct {
+	// Inputs are generator inputs for representative nodes (typically one
+	// regular node and one lighthouse). Each is passed to Generate.
+	Inputs []*Input
+
+	// NodeCount is the number of nodes in the cluster.
+	NodeCount int
+
+	// HasCA indicates the cluster has a CA certificate.
+	HasCA bool
+
+	// ControlPlaneLighthouse indicates the control plane runs lighthouses
+	// for the cluster, so nodes do not need a lighthouse of their own.
+	ControlPlaneLighthouse bool
+
+	// Lighthouses is the list of lighthouse nodes in the cluster.
+	Lighthouses []Peer
+
+	// RoutesByNode maps node IDs to their advertised routes.
+	RoutesByNode map[string][]string
+}
+
+// Validate runs the config generator for each representative node and checks
+// the cluster topology for problems that would produce a broken or degraded
+// Nebula network.
+//
+// Parameters:
+//   - in: Cluster state to validate
+//
+// Returns:
+//   - Report listing errors and warnings
+func Validate(in *ValidationInput) *Report {
+	report := &Report{Errors: []Issue{}, Warnings: []Issue{}}
+
+	for _, genInput := range in.Inputs {
+		if _, err := Generate(genInput); err != nil {
+			report.Errors = append(report.Errors, Issue{
+				Severity: SeverityError,
+				Code:     IssueGenerateFailed,
+				Message:  fmt.Sprintf("config generation failed: %v", err),
+				NodeIDs:  []string{genInput.Node.ID},
+			})
+		}
+	}
+
+	if !in.HasCA {
+		report.Errors = append(report.Errors, Issue{
+			Severity: SeverityError,
+			Code:     IssueMissingCA,
+			Message:  "cluster has no CA certificate; nodes cannot obtain or verify host certificates",
+		})
+	}
+
+	if in.NodeCount == 0 {
+		report.Warnings = append(report.Warnings, Issue{
+			Severity: SeverityWarning,
+			Code:     IssueNoNodes,
+			Message:  "cluster has no nodes",
+		})
+	} else if len(in.Lighthouses) == 0 && !in.ControlPlaneLighthouse {
+		report.Errors = append(report.Errors, Issue{
+			Severity: SeverityError,
+			Code:     IssueNoLighthouse,
+			Message:  "cluster has no lighthouse; nodes behind NAT cannot discover each other",
+		})
+	}
+
+	for _, lh := range in.Lighthouses {
+		if lh.PublicIP == "" {
+			report.Errors = append(report.Errors, Issue{
+				Severity: SeverityError,
+				Code:     IssueLighthouseNoAddress,
+				Message:  fmt.Sprintf("lighthouse %s has no public IP", lh.Name),
+				NodeIDs:  []string{lh.NodeID},
+			})
+		}
+	}
+
+	report.Errors = append(report.Errors, checkRoutes(in.RoutesByNode)...)
+
+	report.Valid = len(report.Errors) == 0
+	return report
+}
+
+// advertisedRoute is a parsed route and the node advertising it.
+type advertisedRoute struct {
+	nodeID  string
+	cidr    string
+	network *net.IPNet
+}
+
+// checkRoutes reports unparseable routes and routes advertised by different
+// nodes that overlap, since Nebula cannot pick a single gateway for them.
+func checkRoutes(routesByNode map[string][]string) []Issue {
+	var issues []Issue
+
+	nodeIDs := make([]string, 0, len(routesByNode))
+	for nodeID := range routesByNode {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	sort.Strings(nodeIDs)
+
+	var parsed []advertisedRoute
+	for _, nodeID := range nodeIDs {
+		for _, cidr := range routesByNode[nodeID] {
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				issues = append(issues, Issue{
+					Severity: SeverityError,
+					Code:     IssueInvalidRoute,
+					Message:  fmt.Sprintf("route %q is not a valid CIDR", cidr),
+					NodeIDs:  []string{nodeID},
+				})
+				continue
+			}
+			parsed = append(parsed, advertisedRoute{nodeID: nodeID, cidr: cidr, network: network})
+		}
+	}
+
+	for i := 0; i < len(parsed); i++ {
+		for j := i + 1; j < len(parsed); j++ {
+			a, b := parsed[i], parsed[j]
+			if a.nodeID == b.nodeID {
+				continue
+			}
+			if a.network.Contains(b.network.IP) || b.network.Contains(a.network.IP) {
+				issues = append(issues, Issue{
+					Severity: SeverityError,
+					Code:     IssueOverlappingRoutes,
+					Message:  fmt.Sprintf("route %s overlaps route %s", a.cidr, b.cidr),
+					NodeIDs:  []string{a.nodeID, b.nodeID},
+				})
+			}
+		}
+	}
+
+	return issues
+}
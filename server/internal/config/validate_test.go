@@ -0,0 +1,93 @@
+package config
+
+import (
+	"testing"
+)
+
+// issueCodes returns the codes of the given issues.
+func issueCodes(issues []Issue) map[string]bool {
+	codes := make(map[string]bool, len(issues))
+	for _, issue := range issues {
+		codes[issue.Code] = true
+	}
+	return codes
+}
+
+func TestValidateHealthyCluster(t *testing.T) {
+	in := testInput(Node{ID: "node-1", NebulaIP: "10.42.0.100"}, 1)
+	report := Validate(&ValidationInput{
+		Inputs:      []*Input{in},
+		NodeCount:   5,
+		HasCA:       true,
+		Lighthouses: in.Lighthouses,
+		RoutesByNode: map[string][]string{
+			"router-1": {"192.168.10.0/24"},
+			"router-2": {"192.168.20.0/24"},
+		},
+	})
+
+	if !report.Valid {
+		t.Errorf("Expected valid report, got errors: %+v", report.Errors)
+	}
+}
+
+func TestValidateReportsProblems(t *testing.T) {
+	in := testInput(Node{ID: "node-1", NebulaIP: "10.42.0.100"}, 1)
+	in.Cluster.Cipher = "des"
+
+	report := Validate(&ValidationInput{
+		Inputs:    []*Input{in},
+		NodeCount: 3,
+		HasCA:     false,
+		RoutesByNode: map[string][]string{
+			"router-1": {"192.168.0.0/16", "bogus"},
+			"router-2": {"192.168.20.0/24"},
+		},
+	})
+
+	if report.Valid {
+		t.Fatal("Expected invalid report")
+	}
+
+	codes := issueCodes(report.Errors)
+	for _, code := range []string{IssueGenerateFailed, IssueMissingCA, IssueNoLighthouse, IssueInvalidRoute, IssueOverlappingRoutes} {
+		if !codes[code] {
+			t.Errorf("Expected %s error, got %+v", code, report.Errors)
+		}
+	}
+}
+
+func TestValidateControlPlaneLighthouse(t *testing.T) {
+	report := Validate(&ValidationInput{
+		NodeCount:              2,
+		HasCA:                  true,
+		ControlPlaneLighthouse: true,
+	})
+
+	if !report.Valid {
+		t.Errorf("Expected control plane lighthouse to satisfy lighthouse check, got %+v", report.Errors)
+	}
+}
+
+func TestValidateLighthouseWithoutPublicIP(t *testing.T) {
+	report := Validate(&ValidationInput{
+		NodeCount:   2,
+		HasCA:       true,
+		Lighthouses: []Peer{{NodeID: "lh-1", Name: "lighthouse-1"}},
+	})
+
+	if !issueCodes(report.Errors)[IssueLighthouseNoAddress] {
+		t.Errorf("Expected %s error, got %+v", IssueLighthouseNoAddress, report.Errors)
+	}
+}
+
+func TestValidateEmptyCluster(t *testing.T) {
+	report := Validate(&ValidationInput{HasCA: true})
+
+	if !report.Valid {
+		t.Errorf("Expected empty cluster to be valid, got %+v", report.Errors)
+	}
+	if !issueCodes(report.Warnings)[IssueNoNodes] {
+		t.Errorf("Expected %s warning, got %+v", IssueNoNodes, report.Warnings)
+	}
+}
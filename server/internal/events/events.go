@@ -0,0 +1,46 @@
+// Package events notifies external tooling of control plane changes.
+//
+// Services publish an Event through a Notifier when something happens that
+// tooling outside the control plane must act on, such as a cluster token
+// rotation that has to be distributed to nodes before the old token expires.
+package events
+
+import "time"
+
+// Event types.
+const (
+	// TypeClusterTokenRotated is published when a cluster token is rotated.
+	// Data carries "token_fingerprint" (a non-secret fingerprint of the new
+	// token, see token.Fingerprint) and "previous_token_expires_at" (when the
+	// old token stops validating).
+	TypeClusterTokenRotated = "cluster_token.rotated"
+
+	// TypeAuthFailuresExceeded is published when failed token validations reach
+	// the alert threshold within the alert window. Data carries "token_type",
+	// "failures", and "window" (a duration string). ClusterID is empty when the
+	// failures could not be attributed to a cluster.
+	TypeAuthFailuresExceeded = "auth.failures_exceeded"
+)
+
+// Event describes a change in the control plane.
+type Event struct {
+	// Type identifies the kind of event (one of the Type constants).
+	Type string `json:"type"`
+
+	// TenantID is the tenant the event belongs to.
+	TenantID string `json:"tenant_id"`
+
+	// ClusterID is the cluster the event belongs to.
+	ClusterID string `json:"cluster_id"`
+
+	// Time is when the event happened.
+	Time time.Time `json:"time"`
+
+	// Data holds event-specific fields.
+	Data map[string]any `json:"data,omitempty"`
+}
+
+// Notifier delivers events. Notify must not block the caller on delivery.
+type Notifier interface {
+	Notify(event Event)
+}
@@ -0,0 +1,93 @@
+package events
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// HeaderSignature carries the hex HMAC-SHA256 of the request body, keyed
+// with the webhook secret, so receivers can verify the sender.
+const HeaderSignature = "X-NebulaGC-Signature"
+
+// webhookTimeout bounds a single webhook delivery.
+const webhookTimeout = 10 * time.Second
+
+// WebhookNotifier POSTs each event as JSON to a URL. Delivery happens in the
+// background and failures are logged, never returned to the publisher.
+type WebhookNotifier struct {
+	url    string
+	secret string
+	client *http.Client
+	logger *zap.Logger
+}
+
+// NewWebhookNotifier creates a notifier that posts events to url.
+//
+// Events may carry secrets (a rotated cluster token), so url should use
+// HTTPS.
+//
+// Parameters:
+//   - url: Endpoint that receives events
+//   - secret: Key for the X-NebulaGC-Signature header (empty disables signing)
+//   - logger: Zap logger
+//
+// Returns:
+//   - Configured WebhookNotifier
+func NewWebhookNotifier(url, secret string, logger *zap.Logger) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: webhookTimeout},
+		logger: logger,
+	}
+}
+
+// Notify delivers event in the background.
+func (n *WebhookNotifier) Notify(event Event) {
+	go func() {
+		if err := n.send(event); err != nil {
+			n.logger.Warn("Failed to deliver event webhook",
+				zap.String("type", event.Type),
+				zap.String("cluster_id", event.ClusterID),
+				zap.Error(err))
+		}
+	}()
+}
+
+// send posts event and checks the response status.
+func (n *WebhookNotifier) send(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.secret != "" {
+		mac := hmac.New(sha256.New, []byte(n.secret))
+		mac.Write(body)
+		req.Header.Set(HeaderSignature, hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
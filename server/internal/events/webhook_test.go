@@ -0,0 +1,76 @@
+package events
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(server.URL, "webhook-secret", zap.NewNop())
+	notifier.Notify(Event{
+		Type:      TypeClusterTokenRotated,
+		TenantID:  "tenant-1",
+		ClusterID: "cluster-1",
+		Time:      time.Unix(1700000000, 0).UTC(),
+		Data:      map[string]any{"token": "new-token"},
+	})
+
+	var req *http.Request
+	select {
+	case req = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Webhook was not called")
+	}
+	body := <-bodies
+
+	if req.Method != http.MethodPost {
+		t.Errorf("Expected POST, got %s", req.Method)
+	}
+
+	mac := hmac.New(sha256.New, []byte("webhook-secret"))
+	mac.Write(body)
+	if got := req.Header.Get(HeaderSignature); got != hex.EncodeToString(mac.Sum(nil)) {
+		t.Errorf("Signature header = %q, does not match body", got)
+	}
+
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		t.Fatalf("Failed to decode event: %v", err)
+	}
+	if event.Type != TypeClusterTokenRotated || event.ClusterID != "cluster-1" || event.Data["token"] != "new-token" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+}
+
+func TestWebhookNotifier_SendReportsStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderSignature) != "" {
+			t.Error("Expected no signature without a secret")
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier(server.URL, "", zap.NewNop()).send(Event{Type: TypeClusterTokenRotated})
+	if err == nil {
+		t.Error("Expected error for non-2xx response")
+	}
+}
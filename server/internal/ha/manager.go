@@ -11,12 +11,13 @@ import (
 
 // ReplicaRegistry defines the minimal operations needed by the HA manager.
 type ReplicaRegistry interface {
-	Register(instanceID, address string, mode Mode) error
+	Register(instanceID, name string, addresses []string, mode Mode, metadata map[string]string) error
 	ValidateSingleMaster() error
 	SendHeartbeat(instanceID string) error
 	PruneStale(threshold time.Duration, multiplier int) (int, error)
 	GetMaster(threshold time.Duration, currentInstanceID string) (*MasterInfo, error)
 	ListReplicas(threshold time.Duration, currentInstanceID string) ([]*ReplicaInfo, error)
+	StepDown(instanceID string) error
 	Unregister(instanceID string) error
 }
 
@@ -89,7 +90,7 @@ func (m *Manager) Start() error {
 	}
 
 	// Register this instance
-	if err := m.service.Register(m.config.InstanceID, m.config.Address, m.config.Mode); err != nil {
+	if err := m.service.Register(m.config.InstanceID, m.config.Name, m.config.PublicAddresses(), m.config.Mode, m.config.Metadata); err != nil {
 		return fmt.Errorf("failed to register replica: %w", err)
 	}
 
@@ -101,8 +102,10 @@ func (m *Manager) Start() error {
 
 	m.logger.Info("HA manager started",
 		zap.String("instance_id", m.config.InstanceID),
-		zap.String("address", m.config.Address),
+		zap.String("name", m.config.Name),
+		zap.Strings("addresses", m.config.PublicAddresses()),
 		zap.String("mode", string(m.config.Mode)),
+		zap.Any("metadata", m.config.Metadata),
 		zap.Duration("heartbeat_interval", m.config.HeartbeatInterval),
 		zap.Bool("pruning_enabled", m.config.EnablePruning),
 	)
@@ -185,6 +188,62 @@ func (m *Manager) ListReplicas() ([]*ReplicaInfo, error) {
 	return m.service.ListReplicas(m.config.HeartbeatThreshold, m.config.InstanceID)
 }
 
+// StepDown hands the master role over to the next-oldest healthy replica
+// without stopping this instance, e.g. ahead of maintenance.
+//
+// This instance is moved to the back of the master election order, so it
+// keeps serving reads and forwards writes to the new master, and will only
+// become master again once every replica registered before the step-down
+// has gone stale.
+//
+// Returns:
+//   - *MasterInfo: The new master
+//   - error: ErrNotMaster if this instance is not the master, ErrNoStandby if
+//     no other healthy replica could take over, or any other error
+func (m *Manager) StepDown() (*MasterInfo, error) {
+	master, err := m.GetMaster()
+	if err != nil {
+		return nil, err
+	}
+	if !master.IsSelf {
+		return nil, ErrNotMaster
+	}
+
+	replicas, err := m.ListReplicas()
+	if err != nil {
+		return nil, err
+	}
+	// Only replicas with a heartbeat within the threshold are listed
+	hasStandby := false
+	for _, r := range replicas {
+		if r.InstanceID != m.config.InstanceID {
+			hasStandby = true
+			break
+		}
+	}
+	if !hasStandby {
+		return nil, ErrNoStandby
+	}
+
+	if err := m.service.StepDown(m.config.InstanceID); err != nil {
+		return nil, fmt.Errorf("failed to step down: %w", err)
+	}
+	if err := m.service.ValidateSingleMaster(); err != nil {
+		return nil, fmt.Errorf("master validation failed: %w", err)
+	}
+
+	newMaster, err := m.GetMaster()
+	if err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("stepped down as master",
+		zap.String("instance_id", m.config.InstanceID),
+		zap.String("new_master", newMaster.InstanceID),
+	)
+	return newMaster, nil
+}
+
 // heartbeatLoop runs the periodic heartbeat sender.
 //
 // This goroutine sends a heartbeat at the configured interval until
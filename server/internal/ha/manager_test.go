@@ -18,10 +18,13 @@ type mockRegistry struct {
 	validateCalls   int
 	heartbeatCalls  int
 	pruneCalls      int
+	stepDownCalls   int
 
 	registerArgs struct {
-		id, addr string
-		mode     Mode
+		id    string
+		name  string
+		addrs []string
+		mode  Mode
 	}
 	masterInfo *MasterInfo
 	list       []*ReplicaInfo
@@ -32,15 +35,16 @@ type mockRegistry struct {
 	pruneErr     error
 }
 
-func (m *mockRegistry) Register(instanceID, address string, mode Mode) error {
+func (m *mockRegistry) Register(instanceID, name string, addresses []string, mode Mode, metadata map[string]string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.registerCalls++
 	m.registerArgs = struct {
-		id   string
-		addr string
-		mode Mode
-	}{instanceID, address, mode}
+		id    string
+		name  string
+		addrs []string
+		mode  Mode
+	}{instanceID, name, addresses, mode}
 	return m.registerErr
 }
 
@@ -80,6 +84,13 @@ func (m *mockRegistry) ListReplicas(time.Duration, string) ([]*ReplicaInfo, erro
 	return m.list, nil
 }
 
+func (m *mockRegistry) StepDown(string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stepDownCalls++
+	return nil
+}
+
 func (m *mockRegistry) Unregister(string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -97,7 +108,9 @@ func TestManagerStartValidatesMaster(t *testing.T) {
 	reg := &mockRegistry{}
 	cfg := &Config{
 		InstanceID:         "self",
+		Name:               "cp-test",
 		Address:            "https://self.example.com",
+		Addresses:          []string{"https://self.example.com", "https://self.internal"},
 		Mode:               ModeMaster,
 		HeartbeatInterval:  5 * time.Millisecond,
 		HeartbeatThreshold: 10 * time.Millisecond,
@@ -120,6 +133,9 @@ func TestManagerStartValidatesMaster(t *testing.T) {
 	if reg.registerCalls == 0 || reg.unregisterCalls == 0 {
 		t.Fatalf("expected register and unregister calls, got register=%d unregister=%d", reg.registerCalls, reg.unregisterCalls)
 	}
+	if reg.registerArgs.name != "cp-test" || len(reg.registerArgs.addrs) != 2 {
+		t.Fatalf("unexpected register args: %+v", reg.registerArgs)
+	}
 	if reg.validateCalls == 0 {
 		t.Fatal("expected master validation to be called")
 	}
@@ -178,3 +194,32 @@ func TestManagerStartValidationError(t *testing.T) {
 		t.Fatal("expected start to fail when validation fails")
 	}
 }
+
+func TestManagerStepDown(t *testing.T) {
+	cfg := &Config{InstanceID: "self", HeartbeatThreshold: time.Minute}
+
+	// Only the master itself is healthy
+	reg := &mockRegistry{list: []*ReplicaInfo{
+		{InstanceID: "self", IsMaster: true},
+	}}
+	if _, err := newTestHAManager(cfg, reg).StepDown(); !errors.Is(err, ErrNoStandby) {
+		t.Fatalf("expected ErrNoStandby, got %v", err)
+	}
+
+	// A replica cannot step down
+	reg = &mockRegistry{masterInfo: &MasterInfo{InstanceID: "other"}}
+	if _, err := newTestHAManager(cfg, reg).StepDown(); !errors.Is(err, ErrNotMaster) {
+		t.Fatalf("expected ErrNotMaster, got %v", err)
+	}
+
+	reg = &mockRegistry{list: []*ReplicaInfo{
+		{InstanceID: "self", IsMaster: true},
+		{InstanceID: "other"},
+	}}
+	if _, err := newTestHAManager(cfg, reg).StepDown(); err != nil {
+		t.Fatalf("step down failed: %v", err)
+	}
+	if reg.stepDownCalls != 1 || reg.validateCalls != 1 {
+		t.Fatalf("expected one step-down and master validation, got %d and %d", reg.stepDownCalls, reg.validateCalls)
+	}
+}
@@ -4,7 +4,32 @@
 // and automatic failover for N-way control plane replication.
 package ha
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrDuplicateInstance is returned by registration when another live instance
+// already holds the same instance ID under a different public address, which
+// would otherwise corrupt the registry and master election.
+var ErrDuplicateInstance = errors.New("instance ID is already in use by another live instance")
+
+// ErrNotMaster is returned by a step-down requested on an instance that is
+// not the current master.
+var ErrNotMaster = errors.New("this instance is not the master")
+
+// ErrNoStandby is returned by a step-down when no other healthy replica could
+// take over as master.
+var ErrNoStandby = errors.New("no other healthy replica to take over as master")
+
+// Well-known replica metadata keys.
+const (
+	// MetadataVersion is the replica's software version.
+	MetadataVersion = "version"
+
+	// MetadataRegion is the region or data center the replica runs in.
+	MetadataRegion = "region"
+)
 
 const (
 	// DefaultHeartbeatInterval is how often replicas send heartbeats.
@@ -30,12 +55,25 @@ type Config struct {
 	// InstanceID is this control plane instance's UUID.
 	InstanceID string
 
+	// Name is a human-friendly name for this instance (e.g., "cp-eu-west-1").
+	Name string
+
 	// Address is the public address of this instance (e.g., "https://cp1.example.com:8080").
 	Address string
 
+	// Addresses lists every public URL this instance is reachable at, for
+	// deployments where internal and external clients use different names
+	// (split-horizon DNS). The first entry should equal Address. When empty,
+	// Address is the only URL.
+	Addresses []string
+
 	// Mode indicates whether this instance is running as master or replica.
 	Mode Mode
 
+	// Metadata describes this instance in replica listings, keyed by
+	// MetadataVersion, MetadataRegion, etc. (optional).
+	Metadata map[string]string
+
 	// HeartbeatInterval is how often to send heartbeats.
 	HeartbeatInterval time.Duration
 
@@ -63,14 +101,28 @@ func DefaultConfig(instanceID, address string, mode Mode) *Config {
 	}
 }
 
+// PublicAddresses returns every public URL of this instance, primary first.
+func (c *Config) PublicAddresses() []string {
+	if len(c.Addresses) > 0 {
+		return c.Addresses
+	}
+	return []string{c.Address}
+}
+
 // ReplicaInfo holds information about a control plane replica.
 type ReplicaInfo struct {
 	// InstanceID is the replica's UUID.
 	InstanceID string
 
-	// Address is the replica's public address.
+	// Name is the replica's human-friendly name (empty if unnamed).
+	Name string
+
+	// Address is the replica's primary public address.
 	Address string
 
+	// Addresses lists every public URL of the replica, primary first.
+	Addresses []string
+
 	// Role is the replica's configured role (master or replica).
 	Role Mode
 
@@ -80,6 +132,13 @@ type ReplicaInfo struct {
 	// LastHeartbeat is when the replica last sent a heartbeat.
 	LastHeartbeat time.Time
 
+	// HeartbeatAge is how long ago LastHeartbeat was when the list was built.
+	HeartbeatAge time.Duration
+
+	// Metadata is what the replica reported about itself when it registered
+	// (nil if nothing).
+	Metadata map[string]string
+
 	// CreatedAt is when the replica was first registered.
 	CreatedAt time.Time
 }
@@ -89,9 +148,15 @@ type MasterInfo struct {
 	// InstanceID is the master's UUID.
 	InstanceID string
 
-	// Address is the master's public address.
+	// Name is the master's human-friendly name (empty if unnamed).
+	Name string
+
+	// Address is the master's primary public address.
 	Address string
 
+	// Addresses lists every public URL of the master, primary first.
+	Addresses []string
+
 	// IsSelf indicates if this instance is the master.
 	IsSelf bool
 }
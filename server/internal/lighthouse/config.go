@@ -6,121 +6,62 @@ import (
 	"path/filepath"
 
 	"gopkg.in/yaml.v3"
-)
-
-// NebulaConfig represents the structure of a Nebula configuration file.
-type NebulaConfig struct {
-	PKI        PKIConfig        `yaml:"pki"`
-	Lighthouse LighthouseConfig `yaml:"lighthouse"`
-	Listen     ListenConfig     `yaml:"listen"`
-	Punchy     PunchyConfig     `yaml:"punchy"`
-	Tun        TunConfig        `yaml:"tun"`
-	Logging    LoggingConfig    `yaml:"logging"`
-	Firewall   FirewallConfig   `yaml:"firewall"`
-}
-
-// PKIConfig holds PKI file paths.
-type PKIConfig struct {
-	CA   string `yaml:"ca"`
-	Cert string `yaml:"cert"`
-	Key  string `yaml:"key"`
-	CRL  string `yaml:"crl,omitempty"`
-}
-
-// LighthouseConfig holds lighthouse settings.
-type LighthouseConfig struct {
-	AmLighthouse bool `yaml:"am_lighthouse"`
-	ServeDNS     bool `yaml:"serve_dns"`
-	Interval     int  `yaml:"interval"`
-}
-
-// ListenConfig holds network listener settings.
-type ListenConfig struct {
-	Host string `yaml:"host"`
-	Port int    `yaml:"port"`
-}
-
-// PunchyConfig holds NAT traversal settings.
-type PunchyConfig struct {
-	Punch   bool `yaml:"punch"`
-	Respond bool `yaml:"respond"`
-}
-
-// TunConfig holds TUN device settings.
-type TunConfig struct {
-	Disabled bool   `yaml:"disabled"`
-	Dev      string `yaml:"dev"`
-	MTU      int    `yaml:"mtu"`
-}
-
-// LoggingConfig holds logging settings.
-type LoggingConfig struct {
-	Level  string `yaml:"level"`
-	Format string `yaml:"format"`
-}
 
-// FirewallConfig holds firewall rules.
-type FirewallConfig struct {
-	Outbound []FirewallRule `yaml:"outbound"`
-	Inbound  []FirewallRule `yaml:"inbound"`
-}
+	"nebulagc.io/server/internal/config"
+)
 
-// FirewallRule represents a single firewall rule.
-type FirewallRule struct {
-	Port  interface{} `yaml:"port"`  // Can be "any" or number
-	Proto string      `yaml:"proto"` // "any", "tcp", "udp", "icmp"
-	Host  string      `yaml:"host"`  // "any" or CIDR
-}
+// lighthouseMTU is the TUN MTU of control plane lighthouses.
+const lighthouseMTU = 1300
 
 // GenerateConfig creates a Nebula configuration for a lighthouse.
 //
+// The config comes from the same generator as node configs, so cluster-wide
+// settings such as the relay hop limit, handshake tuning and preferred ranges
+// apply to control plane lighthouses too. Each cluster gets its own TUN
+// device, since one instance runs a lighthouse per cluster.
+//
+// The DNS block is emitted when the cluster enables lighthouse DNS. A DNS port
+// equal to the Nebula UDP port is rejected when the setting is saved, so it is
+// skipped here rather than producing a config Nebula would fail to start with.
+//
 // Parameters:
 //   - clusterConfig: Cluster configuration data
 //   - basePath: Base directory for config files
 //
 // Returns:
 //   - Nebula configuration struct
-func GenerateConfig(clusterConfig *ClusterConfig, basePath string) *NebulaConfig {
-	clusterDir := filepath.Join(basePath, clusterConfig.ClusterID)
+//   - Error if the cluster settings are invalid
+func GenerateConfig(clusterConfig *ClusterConfig, basePath string) (*config.NebulaConfig, error) {
+	dns := config.DNS{
+		Enabled: clusterConfig.DNSEnabled && clusterConfig.DNSPort != clusterConfig.LighthousePort,
+		Host:    clusterConfig.DNSHost,
+		Port:    clusterConfig.DNSPort,
+	}
 
-	return &NebulaConfig{
-		PKI: PKIConfig{
-			CA:   filepath.Join(clusterDir, "ca.crt"),
-			Cert: filepath.Join(clusterDir, "host.crt"),
-			Key:  filepath.Join(clusterDir, "host.key"),
-			CRL:  filepath.Join(clusterDir, "crl.pem"),
-		},
-		Lighthouse: LighthouseConfig{
-			AmLighthouse: true,
-			ServeDNS:     false,
-			Interval:     60,
-		},
-		Listen: ListenConfig{
-			Host: "0.0.0.0",
-			Port: clusterConfig.LighthousePort,
+	nebulaConfig, err := config.Generate(&config.Input{
+		Cluster: config.Cluster{
+			ID:              clusterConfig.ClusterID,
+			LighthousePort:  clusterConfig.LighthousePort,
+			MaxRelayHops:    clusterConfig.MaxRelayHops,
+			DNS:             dns,
+			StaticHostMap:   clusterConfig.StaticHostMap,
+			PreferredRanges: clusterConfig.PreferredRanges,
+			Cipher:          clusterConfig.Cipher,
+			Handshake:       clusterConfig.Handshake,
 		},
-		Punchy: PunchyConfig{
-			Punch:   true,
-			Respond: true,
-		},
-		Tun: TunConfig{
-			Disabled: false,
-			Dev:      fmt.Sprintf("nebula-%s", clusterConfig.ClusterID[:8]),
-			MTU:      1300,
-		},
-		Logging: LoggingConfig{
-			Level:  "info",
-			Format: "text",
-		},
-		Firewall: FirewallConfig{
-			Outbound: []FirewallRule{
-				{Port: "any", Proto: "any", Host: "any"},
-			},
-			Inbound: []FirewallRule{
-				{Port: "any", Proto: "icmp", Host: "any"},
-			},
+		Node: config.Node{
+			Name:         clusterConfig.ClusterName,
+			MTU:          lighthouseMTU,
+			IsLighthouse: true,
 		},
+		ConfigDir: filepath.Join(basePath, clusterConfig.ClusterID),
+	})
+	if err != nil {
+		return nil, err
 	}
+
+	nebulaConfig.Tun.Dev = fmt.Sprintf("nebula-%s", clusterConfig.ClusterID[:8])
+	return nebulaConfig, nil
 }
 
 // WriteConfigFiles writes all config and PKI files for a cluster.
@@ -163,7 +104,10 @@ func WriteConfigFiles(clusterConfig *ClusterConfig, basePath string) (string, er
 	}
 
 	// Generate and write Nebula config
-	nebulaConfig := GenerateConfig(clusterConfig, basePath)
+	nebulaConfig, err := GenerateConfig(clusterConfig, basePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate config: %w", err)
+	}
 	configData, err := yaml.Marshal(nebulaConfig)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal config: %w", err)
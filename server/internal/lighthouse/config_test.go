@@ -6,8 +6,20 @@ import (
 	"testing"
 
 	"gopkg.in/yaml.v3"
+
+	"nebulagc.io/server/internal/config"
 )
 
+// generate runs GenerateConfig and fails the test on error.
+func generate(t *testing.T, clusterConfig *ClusterConfig, basePath string) *config.NebulaConfig {
+	t.Helper()
+	cfg, err := GenerateConfig(clusterConfig, basePath)
+	if err != nil {
+		t.Fatalf("GenerateConfig failed: %v", err)
+	}
+	return cfg
+}
+
 func TestGenerateConfig(t *testing.T) {
 	clusterConfig := &ClusterConfig{
 		ClusterID:      "test-cluster-123",
@@ -17,25 +29,68 @@ func TestGenerateConfig(t *testing.T) {
 	}
 
 	basePath := "/var/lib/nebulagc/lighthouse"
-	config := GenerateConfig(clusterConfig, basePath)
+	cfg := generate(t, clusterConfig, basePath)
 
 	// Verify basic structure
-	if config.Lighthouse.AmLighthouse != true {
+	if cfg.Lighthouse.AmLighthouse != true {
 		t.Error("Expected am_lighthouse to be true")
 	}
 
-	if config.Listen.Port != 4242 {
-		t.Errorf("Expected port 4242, got %d", config.Listen.Port)
+	if cfg.Listen.Port != 4242 {
+		t.Errorf("Expected port 4242, got %d", cfg.Listen.Port)
+	}
+
+	if cfg.Tun.MTU != 1300 {
+		t.Errorf("Expected MTU 1300, got %d", cfg.Tun.MTU)
+	}
+
+	if cfg.Cipher != "" {
+		t.Errorf("Expected no cipher override, got %s", cfg.Cipher)
 	}
 
-	if config.Tun.MTU != 1300 {
-		t.Errorf("Expected MTU 1300, got %d", config.Tun.MTU)
+	clusterConfig.Cipher = "chachapoly"
+	if got := generate(t, clusterConfig, basePath).Cipher; got != "chachapoly" {
+		t.Errorf("Expected cipher chachapoly, got %s", got)
 	}
 
 	// Verify PKI paths
 	expectedCAPath := filepath.Join(basePath, clusterConfig.ClusterID, "ca.crt")
-	if config.PKI.CA != expectedCAPath {
-		t.Errorf("Expected CA path %s, got %s", expectedCAPath, config.PKI.CA)
+	if cfg.PKI.CA != expectedCAPath {
+		t.Errorf("Expected CA path %s, got %s", expectedCAPath, cfg.PKI.CA)
+	}
+}
+
+func TestGenerateConfigClusterSettings(t *testing.T) {
+	clusterConfig := &ClusterConfig{
+		ClusterID:       "test-cluster-123",
+		LighthousePort:  4242,
+		MaxRelayHops:    2,
+		PreferredRanges: []string{"192.168.0.0/16"},
+		StaticHostMap:   map[string][]string{"10.42.0.5": {"203.0.113.5:4242"}},
+		Handshake:       config.Handshake{TryInterval: "200ms", Retries: 20},
+	}
+
+	cfg := generate(t, clusterConfig, "/var/lib/nebulagc/lighthouse")
+	if cfg.Tun.Dev != "nebula-test-clu" {
+		t.Errorf("Expected per-cluster TUN device nebula-test-clu, got %s", cfg.Tun.Dev)
+	}
+	if cfg.Relay.AmRelay || cfg.Relay.UseRelays {
+		t.Errorf("Expected the lighthouse to neither be nor use a relay, got %+v", cfg.Relay)
+	}
+	if len(cfg.PreferredRanges) != 1 || cfg.PreferredRanges[0] != "192.168.0.0/16" {
+		t.Errorf("Expected preferred ranges to be kept, got %v", cfg.PreferredRanges)
+	}
+	if got := cfg.StaticHostMap["10.42.0.5"]; len(got) != 1 || got[0] != "203.0.113.5:4242" {
+		t.Errorf("Expected static host map entry, got %v", got)
+	}
+	if cfg.Handshakes == nil || cfg.Handshakes.TryInterval != "200ms" || cfg.Handshakes.Retries != 20 {
+		t.Errorf("Expected handshake tuning, got %+v", cfg.Handshakes)
+	}
+
+	// Invalid cluster settings are reported instead of written
+	clusterConfig.MaxRelayHops = config.MaxRelayHopsLimit + 1
+	if _, err := GenerateConfig(clusterConfig, "/var/lib/nebulagc/lighthouse"); err == nil {
+		t.Error("Expected an error for an out-of-range relay hop limit")
 	}
 }
 
@@ -48,10 +103,10 @@ func TestGenerateConfigYAMLOutput(t *testing.T) {
 	}
 
 	basePath := "/var/lib/nebulagc/lighthouse"
-	config := GenerateConfig(clusterConfig, basePath)
+	cfg := generate(t, clusterConfig, basePath)
 
 	// Marshal to YAML to verify it's valid
-	data, err := yaml.Marshal(config)
+	data, err := yaml.Marshal(cfg)
 	if err != nil {
 		t.Fatalf("Failed to marshal config to YAML: %v", err)
 	}
@@ -61,7 +116,7 @@ func TestGenerateConfigYAMLOutput(t *testing.T) {
 	}
 
 	// Verify we can unmarshal it back
-	var parsed NebulaConfig
+	var parsed config.NebulaConfig
 	if err := yaml.Unmarshal(data, &parsed); err != nil {
 		t.Fatalf("Failed to unmarshal YAML: %v", err)
 	}
@@ -71,6 +126,39 @@ func TestGenerateConfigYAMLOutput(t *testing.T) {
 	}
 }
 
+func TestGenerateConfigDNS(t *testing.T) {
+	clusterConfig := &ClusterConfig{
+		ClusterID:      "test-cluster-123",
+		LighthousePort: 4242,
+		DNSEnabled:     true,
+		DNSHost:        "0.0.0.0",
+		DNSPort:        5353,
+	}
+
+	cfg := generate(t, clusterConfig, "/var/lib/nebulagc/lighthouse")
+	if !cfg.Lighthouse.ServeDNS {
+		t.Error("Expected serve_dns to be true")
+	}
+	if cfg.Lighthouse.DNS == nil || cfg.Lighthouse.DNS.Port != 5353 || cfg.Lighthouse.DNS.Host != "0.0.0.0" {
+		t.Errorf("Unexpected DNS block: %+v", cfg.Lighthouse.DNS)
+	}
+
+	// A colliding port must never reach the config file
+	clusterConfig.DNSPort = 4242
+	cfg = generate(t, clusterConfig, "/var/lib/nebulagc/lighthouse")
+	if cfg.Lighthouse.ServeDNS || cfg.Lighthouse.DNS != nil {
+		t.Error("Expected DNS to be skipped when the port collides with Nebula")
+	}
+
+	// Disabled DNS produces no block
+	clusterConfig.DNSEnabled = false
+	clusterConfig.DNSPort = 5353
+	cfg = generate(t, clusterConfig, "/var/lib/nebulagc/lighthouse")
+	if cfg.Lighthouse.ServeDNS || cfg.Lighthouse.DNS != nil {
+		t.Error("Expected no DNS block when DNS is disabled")
+	}
+}
+
 func TestWriteConfigFiles(t *testing.T) {
 	// Create temporary directory
 	tmpDir, err := os.MkdirTemp("", "lighthouse-test-*")
@@ -127,7 +215,7 @@ func TestWriteConfigFiles(t *testing.T) {
 		t.Fatalf("Failed to read config.yml: %v", err)
 	}
 
-	var parsedConfig NebulaConfig
+	var parsedConfig config.NebulaConfig
 	if err := yaml.Unmarshal(configData, &parsedConfig); err != nil {
 		t.Fatalf("Config.yml contains invalid YAML: %v", err)
 	}
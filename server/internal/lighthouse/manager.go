@@ -3,26 +3,34 @@ package lighthouse
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"sync"
 	"syscall"
 	"time"
 
 	"go.uber.org/zap"
+	"nebulagc.io/pkg/process"
 )
 
+// defaultStopTimeout is how long a lighthouse gets to exit after SIGTERM
+// before it is killed.
+const defaultStopTimeout = 5 * time.Second
+
 // Manager manages Nebula lighthouse processes.
 type Manager struct {
 	config    *Config
 	db        *sql.DB
 	logger    *zap.Logger
 	processes map[string]*ProcessInfo // clusterID -> ProcessInfo
+	runner    process.Runner
 	mu        sync.RWMutex
 	ctx       context.Context
 	cancel    context.CancelFunc
 	wg        sync.WaitGroup
+
+	stopTimeout time.Duration
 }
 
 // NewManager creates a new lighthouse manager.
@@ -37,13 +45,20 @@ type Manager struct {
 func NewManager(config *Config, db *sql.DB, logger *zap.Logger) *Manager {
 	ctx, cancel := context.WithCancel(context.Background())
 
+	runner := config.Runner
+	if runner == nil {
+		runner = process.ExecRunner{}
+	}
+
 	return &Manager{
-		config:    config,
-		db:        db,
-		logger:    logger,
-		processes: make(map[string]*ProcessInfo),
-		ctx:       ctx,
-		cancel:    cancel,
+		config:      config,
+		db:          db,
+		logger:      logger,
+		processes:   make(map[string]*ProcessInfo),
+		runner:      runner,
+		ctx:         ctx,
+		cancel:      cancel,
+		stopTimeout: defaultStopTimeout,
 	}
 }
 
@@ -177,6 +192,23 @@ func (m *Manager) updateLighthouse(clusterID string) error {
 		return fmt.Errorf("failed to load cluster config: %w", err)
 	}
 
+	if err := m.runLighthouse(clusterConfig); err != nil {
+		return err
+	}
+
+	// Update cluster_state
+	if err := m.updateClusterState(clusterID, clusterConfig.ConfigVersion); err != nil {
+		return fmt.Errorf("failed to update cluster state: %w", err)
+	}
+
+	return nil
+}
+
+// runLighthouse writes the config files for a cluster and (re)starts its
+// lighthouse process on them.
+func (m *Manager) runLighthouse(clusterConfig *ClusterConfig) error {
+	clusterID := clusterConfig.ClusterID
+
 	// Write config files
 	configPath, err := WriteConfigFiles(clusterConfig, m.config.BasePath)
 	if err != nil {
@@ -198,21 +230,20 @@ func (m *Manager) updateLighthouse(clusterID string) error {
 		return fmt.Errorf("failed to start process: %w", err)
 	}
 
-	// Update cluster_state
-	if err := m.updateClusterState(clusterID, clusterConfig.ConfigVersion); err != nil {
-		return fmt.Errorf("failed to update cluster state: %w", err)
-	}
-
 	return nil
 }
 
 // loadClusterConfig loads cluster configuration from the database.
 func (m *Manager) loadClusterConfig(clusterID string) (*ClusterConfig, error) {
 	var config ClusterConfig
-	var lighthousePort sql.NullInt64
+	var lighthousePort, retries, triggerBuffer sql.NullInt64
+	var staticJSON, rangesJSON, tryInterval sql.NullString
 
 	err := m.db.QueryRow(`
-		SELECT id, name, config_version, ca_cert, crl, lighthouse_cert, lighthouse_key, lighthouse_port
+		SELECT id, name, config_version, ca_cert, crl, lighthouse_cert, lighthouse_key, lighthouse_port,
+		       lighthouse_dns_enabled, lighthouse_dns_host, lighthouse_dns_port, cipher,
+		       max_relay_hops, static_host_map, preferred_ranges,
+		       handshake_try_interval, handshake_retries, handshake_trigger_buffer
 		FROM clusters
 		WHERE id = ?
 	`, clusterID).Scan(
@@ -224,11 +255,35 @@ func (m *Manager) loadClusterConfig(clusterID string) (*ClusterConfig, error) {
 		&config.HostCert,
 		&config.HostKey,
 		&lighthousePort,
+		&config.DNSEnabled,
+		&config.DNSHost,
+		&config.DNSPort,
+		&config.Cipher,
+		&config.MaxRelayHops,
+		&staticJSON,
+		&rangesJSON,
+		&tryInterval,
+		&retries,
+		&triggerBuffer,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query cluster: %w", err)
 	}
 
+	config.Handshake.TryInterval = tryInterval.String
+	config.Handshake.Retries = int(retries.Int64)
+	config.Handshake.TriggerBuffer = int(triggerBuffer.Int64)
+	if staticJSON.Valid && staticJSON.String != "" {
+		if err := json.Unmarshal([]byte(staticJSON.String), &config.StaticHostMap); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal static host map: %w", err)
+		}
+	}
+	if rangesJSON.Valid && rangesJSON.String != "" {
+		if err := json.Unmarshal([]byte(rangesJSON.String), &config.PreferredRanges); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal preferred ranges: %w", err)
+		}
+	}
+
 	if lighthousePort.Valid {
 		config.LighthousePort = int(lighthousePort.Int64)
 	} else {
@@ -255,26 +310,29 @@ func (m *Manager) loadClusterConfig(clusterID string) (*ClusterConfig, error) {
 
 // startProcess starts a Nebula process for a cluster.
 func (m *Manager) startProcess(clusterID, configPath string, version int64) error {
-	cmd := exec.Command(m.config.NebulaBinary, "-config", configPath)
-	cmd.Stdout = nil // TODO: Pipe to logger
-	cmd.Stderr = nil // TODO: Pipe to logger
-
-	if err := cmd.Start(); err != nil {
+	// Lighthouses outlive the watcher and are stopped explicitly by Stop
+	handle, err := m.runner.Start(context.Background(), process.Command{
+		Path: m.config.NebulaBinary,
+		Args: []string{"-config", configPath},
+		// TODO: Pipe Stdout and Stderr to logger
+	})
+	if err != nil {
 		return fmt.Errorf("failed to start nebula: %w", err)
 	}
 
 	m.mu.Lock()
 	m.processes[clusterID] = &ProcessInfo{
 		ClusterID:     clusterID,
-		PID:           cmd.Process.Pid,
+		PID:           handle.PID(),
 		ConfigVersion: version,
 		StartedAt:     time.Now(),
+		handle:        handle,
 	}
 	m.mu.Unlock()
 
 	m.logger.Info("started lighthouse process",
 		zap.String("cluster_id", clusterID),
-		zap.Int("pid", cmd.Process.Pid),
+		zap.Int("pid", handle.PID()),
 		zap.Int64("version", version))
 
 	return nil
@@ -282,28 +340,17 @@ func (m *Manager) startProcess(clusterID, configPath string, version int64) erro
 
 // stopProcessLocked stops a running process (caller must hold lock).
 func (m *Manager) stopProcessLocked(clusterID string, info *ProcessInfo) error {
-	process, err := os.FindProcess(info.PID)
-	if err != nil {
-		return fmt.Errorf("failed to find process: %w", err)
-	}
-
 	// Send SIGTERM
-	if err := process.Signal(syscall.SIGTERM); err != nil {
+	if err := info.handle.Signal(syscall.SIGTERM); err != nil {
 		return fmt.Errorf("failed to send SIGTERM: %w", err)
 	}
 
-	// Wait up to 5 seconds for graceful shutdown
-	done := make(chan error, 1)
-	go func() {
-		_, err := process.Wait()
-		done <- err
-	}()
-
+	// Wait up to stopTimeout for graceful shutdown
 	select {
-	case <-time.After(5 * time.Second):
+	case <-time.After(m.stopTimeout):
 		// Force kill
-		process.Kill()
-	case <-done:
+		info.handle.Kill()
+	case <-info.handle.Done():
 	}
 
 	delete(m.processes, clusterID)
@@ -321,21 +368,13 @@ func (m *Manager) checkProcesses() {
 	defer m.mu.RUnlock()
 
 	for clusterID, info := range m.processes {
-		process, err := os.FindProcess(info.PID)
-		if err != nil {
-			m.logger.Warn("process not found, needs restart",
-				zap.String("cluster_id", clusterID),
-				zap.Int("pid", info.PID))
-			// Trigger update on next check
-			continue
-		}
-
-		// Check if process is still alive by sending signal 0
-		if err := process.Signal(syscall.Signal(0)); err != nil {
+		select {
+		case <-info.handle.Done():
 			m.logger.Warn("process crashed, will restart on next check",
 				zap.String("cluster_id", clusterID),
 				zap.Int("pid", info.PID),
-				zap.Error(err))
+				zap.Error(info.handle.Wait()))
+		default:
 		}
 	}
 }
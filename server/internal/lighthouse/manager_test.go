@@ -0,0 +1,152 @@
+package lighthouse
+
+import (
+	"errors"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"nebulagc.io/pkg/process"
+)
+
+// newTestManager creates a manager that writes configs to a temporary
+// directory and starts lighthouses on a fake runner.
+func newTestManager(t *testing.T) (*Manager, *process.FakeRunner, *observer.ObservedLogs) {
+	t.Helper()
+
+	runner := process.NewFakeRunner()
+	config := DefaultConfig("instance-1")
+	config.BasePath = t.TempDir()
+	config.Runner = runner
+
+	core, logs := observer.New(zap.InfoLevel)
+	return NewManager(config, nil, zap.New(core)), runner, logs
+}
+
+// testClusterConfig returns a lighthouse config for cluster-1 at version.
+func testClusterConfig(version int64) *ClusterConfig {
+	return &ClusterConfig{
+		ClusterID:      "cluster-1",
+		ClusterName:    "Test Cluster",
+		LighthousePort: 4242,
+		ConfigVersion:  version,
+	}
+}
+
+func TestManagerRunLighthouse(t *testing.T) {
+	m, runner, _ := newTestManager(t)
+
+	if err := m.runLighthouse(testClusterConfig(1)); err != nil {
+		t.Fatalf("runLighthouse failed: %v", err)
+	}
+
+	started := runner.Started()
+	if len(started) != 1 {
+		t.Fatalf("Expected 1 process start, got %d", len(started))
+	}
+	cmd := started[0].Command
+	wantConfig := filepath.Join(m.config.BasePath, "cluster-1", "config.yml")
+	if cmd.Path != "/usr/local/bin/nebula" || len(cmd.Args) != 2 || cmd.Args[0] != "-config" || cmd.Args[1] != wantConfig {
+		t.Errorf("Started %s %v, want /usr/local/bin/nebula [-config %s]", cmd.Path, cmd.Args, wantConfig)
+	}
+
+	info := m.processes["cluster-1"]
+	if info == nil {
+		t.Fatal("Expected process to be tracked")
+	}
+	if info.PID != started[0].PID() || info.ConfigVersion != 1 {
+		t.Errorf("Tracked PID %d version %d, want PID %d version 1", info.PID, info.ConfigVersion, started[0].PID())
+	}
+}
+
+func TestManagerRunLighthouseRestartsOnNewVersion(t *testing.T) {
+	m, runner, _ := newTestManager(t)
+
+	if err := m.runLighthouse(testClusterConfig(1)); err != nil {
+		t.Fatalf("runLighthouse failed: %v", err)
+	}
+	if err := m.runLighthouse(testClusterConfig(2)); err != nil {
+		t.Fatalf("runLighthouse failed: %v", err)
+	}
+
+	started := runner.Started()
+	if len(started) != 2 {
+		t.Fatalf("Expected 2 process starts, got %d", len(started))
+	}
+
+	// The old version is stopped gracefully before the new one starts
+	old := started[0]
+	if signals := old.Signals(); len(signals) != 1 || signals[0] != syscall.SIGTERM {
+		t.Errorf("Old process signals = %v, want [SIGTERM]", signals)
+	}
+	if !old.Exited() || old.Killed() {
+		t.Error("Old process should have exited without being killed")
+	}
+
+	info := m.processes["cluster-1"]
+	if info.PID != started[1].PID() || info.ConfigVersion != 2 {
+		t.Errorf("Tracked PID %d version %d, want PID %d version 2", info.PID, info.ConfigVersion, started[1].PID())
+	}
+}
+
+func TestManagerStopKillsHungProcess(t *testing.T) {
+	m, runner, _ := newTestManager(t)
+	m.stopTimeout = 10 * time.Millisecond
+
+	if err := m.runLighthouse(testClusterConfig(1)); err != nil {
+		t.Fatalf("runLighthouse failed: %v", err)
+	}
+	p := runner.Started()[0]
+	p.IgnoreSignals()
+
+	if err := m.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	if !p.Killed() {
+		t.Error("Process ignoring SIGTERM should have been killed")
+	}
+	if len(m.processes) != 0 {
+		t.Errorf("Expected no tracked processes after Stop, got %d", len(m.processes))
+	}
+}
+
+func TestManagerCheckProcessesDetectsCrash(t *testing.T) {
+	m, runner, logs := newTestManager(t)
+
+	if err := m.runLighthouse(testClusterConfig(1)); err != nil {
+		t.Fatalf("runLighthouse failed: %v", err)
+	}
+
+	// A running process is not reported
+	m.checkProcesses()
+	if n := logs.FilterMessage("process crashed, will restart on next check").Len(); n != 0 {
+		t.Fatalf("Expected no crash reports, got %d", n)
+	}
+
+	runner.Started()[0].Exit(errors.New("exit status 1"))
+	m.checkProcesses()
+
+	crashes := logs.FilterMessage("process crashed, will restart on next check").All()
+	if len(crashes) != 1 {
+		t.Fatalf("Expected 1 crash report, got %d", len(crashes))
+	}
+	if fields := crashes[0].ContextMap(); fields["cluster_id"] != "cluster-1" || fields["error"] != "exit status 1" {
+		t.Errorf("Crash report fields = %v", fields)
+	}
+}
+
+func TestManagerRunLighthouseStartFailure(t *testing.T) {
+	m, runner, _ := newTestManager(t)
+	runner.FailStarts(errors.New("exec: \"nebula\": executable file not found in $PATH"))
+
+	if err := m.runLighthouse(testClusterConfig(1)); err == nil {
+		t.Fatal("Expected runLighthouse to fail when nebula cannot start")
+	}
+	if len(m.processes) != 0 {
+		t.Errorf("Expected no tracked processes, got %d", len(m.processes))
+	}
+}
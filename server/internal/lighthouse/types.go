@@ -4,7 +4,12 @@
 // and automatic restarts for Nebula lighthouse instances running on control plane servers.
 package lighthouse
 
-import "time"
+import (
+	"time"
+
+	"nebulagc.io/pkg/process"
+	"nebulagc.io/server/internal/config"
+)
 
 // Config holds configuration for the lighthouse manager.
 type Config struct {
@@ -26,6 +31,10 @@ type Config struct {
 	// Enabled determines if lighthouse management is enabled.
 	// Default: true
 	Enabled bool
+
+	// Runner starts lighthouse processes.
+	// Default: nil (os/exec)
+	Runner process.Runner
 }
 
 // DefaultConfig returns a Config with default values.
@@ -62,6 +71,31 @@ type ClusterConfig struct {
 	// LighthousePort is the UDP port for Nebula.
 	LighthousePort int
 
+	// DNSEnabled indicates the lighthouse serves DNS for node names.
+	DNSEnabled bool
+
+	// DNSHost is the DNS listen address.
+	DNSHost string
+
+	// DNSPort is the DNS listen port.
+	DNSPort int
+
+	// Cipher is the cluster's Nebula cipher; lighthouses must match the nodes.
+	Cipher string
+
+	// MaxRelayHops limits how many relays traffic may traverse (0 = no relaying).
+	MaxRelayHops int
+
+	// StaticHostMap maps Nebula IPs of fixed-endpoint peers to their public
+	// "ip:port" endpoints.
+	StaticHostMap map[string][]string
+
+	// PreferredRanges lists local networks tried first when connecting to peers.
+	PreferredRanges []string
+
+	// Handshake holds the cluster's handshake tuning.
+	Handshake config.Handshake
+
 	// ConfigVersion is the current config version from the database.
 	ConfigVersion int64
 
@@ -91,4 +125,7 @@ type ProcessInfo struct {
 
 	// StartedAt is when the process was started.
 	StartedAt time.Time
+
+	// handle controls the running process.
+	handle process.Handle
 }
@@ -41,4 +41,8 @@ const (
 
 	// FieldOperation identifies the specific operation being performed.
 	FieldOperation = "operation"
+
+	// FieldAudit marks log entries that record security- or availability-relevant
+	// configuration changes, so they can be filtered into an audit trail.
+	FieldAudit = "audit"
 )
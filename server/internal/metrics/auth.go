@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// AuthFailures counts failed token validations by token type, cluster, and reason.
+	//
+	// The cluster label is only set to a cluster ID that exists; anything else
+	// is bucketed as "unknown" so attackers cannot inflate label cardinality.
+	// Tokens and node IDs are never used as labels.
+	AuthFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nebulagc_auth_failures_total",
+			Help: "Total number of failed token validations",
+		},
+		[]string{"token_type", "cluster_id", "reason"},
+	)
+
+	// AuthLockouts counts sources locked out after repeated failed token validations.
+	AuthLockouts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nebulagc_auth_lockouts_total",
+			Help: "Total number of auth lockouts started",
+		},
+		[]string{"token_type"},
+	)
+)
+
+// registerAuthMetrics registers all authentication metrics.
+func registerAuthMetrics() error {
+	metrics := []prometheus.Collector{
+		AuthFailures,
+		AuthLockouts,
+	}
+
+	for _, metric := range metrics {
+		if err := Registry.Register(metric); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
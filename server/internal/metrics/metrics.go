@@ -39,6 +39,11 @@ func Init() error {
 		return err
 	}
 
+	// Register authentication metrics
+	if err := registerAuthMetrics(); err != nil {
+		return err
+	}
+
 	// Register database metrics
 	if err := registerDatabaseMetrics(); err != nil {
 		return err
@@ -72,6 +77,8 @@ func registerBusinessMetrics() error {
 		NodeCount,
 		ClusterCount,
 		BundleOperations,
+		TopologyCacheRequests,
+		CanaryRollbacks,
 	}
 
 	for _, metric := range metrics {
@@ -110,4 +117,22 @@ var (
 		},
 		[]string{"cluster_id", "operation", "status"},
 	)
+
+	// TopologyCacheRequests tracks topology cache lookups by result ("hit" or "miss").
+	TopologyCacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nebulagc_topology_cache_requests_total",
+			Help: "Total number of topology cache lookups",
+		},
+		[]string{"result"},
+	)
+
+	// CanaryRollbacks tracks canary rollouts rolled back automatically.
+	CanaryRollbacks = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nebulagc_canary_rollbacks_total",
+			Help: "Total number of canary rollouts rolled back automatically",
+		},
+		[]string{"cluster_id"},
+	)
 )
@@ -0,0 +1,124 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"nebulagc.io/server/internal/service"
+)
+
+// Pruner prunes config bundles of clusters that set a bundle retention.
+//
+// After pruning, bundle data no longer referenced by any version is
+// collected from the bundle store.
+//
+// Only the master prunes, since pruning writes to the database.
+type Pruner struct {
+	config   *Config
+	bundles  *service.BundleService
+	isMaster func() (bool, string, error)
+	logger   *zap.Logger
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewPruner creates a new bundle pruner.
+//
+// Parameters:
+//   - config: Pruner configuration
+//   - bundles: Bundle service owning the bundles
+//   - isMaster: Reports whether this instance is the master
+//   - logger: Zap logger
+//
+// Returns:
+//   - Configured Pruner
+func NewPruner(config *Config, bundles *service.BundleService, isMaster func() (bool, string, error), logger *zap.Logger) *Pruner {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Pruner{
+		config:   config,
+		bundles:  bundles,
+		isMaster: isMaster,
+		logger:   logger,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start starts the background prune loop.
+//
+// Returns:
+//   - Error if the configuration is invalid
+func (p *Pruner) Start() error {
+	if !p.config.Enabled {
+		p.logger.Info("scheduled bundle pruning disabled")
+		return nil
+	}
+	if p.config.CheckInterval <= 0 {
+		return fmt.Errorf("bundle prune interval must be positive")
+	}
+
+	p.logger.Info("starting bundle pruner",
+		zap.Duration("check_interval", p.config.CheckInterval))
+
+	p.wg.Add(1)
+	go p.pruneLoop()
+
+	return nil
+}
+
+// Stop stops the prune loop and waits for it to exit.
+//
+// Returns:
+//   - Error if shutdown fails
+func (p *Pruner) Stop() error {
+	p.cancel()
+	p.wg.Wait()
+	return nil
+}
+
+// pruneLoop is the background goroutine that prunes bundles.
+func (p *Pruner) pruneLoop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.prune()
+		}
+	}
+}
+
+// prune prunes every cluster to its retention and collects unreferenced data.
+func (p *Pruner) prune() {
+	master, _, err := p.isMaster()
+	if err != nil {
+		p.logger.Warn("failed to determine master for bundle prune", zap.Error(err))
+		return
+	}
+	if !master {
+		return
+	}
+
+	pruned, err := p.bundles.PruneByRetention(p.ctx)
+	if err != nil {
+		p.logger.Error("failed to prune config bundles", zap.Error(err))
+		return
+	}
+	if pruned == 0 {
+		return
+	}
+
+	if _, err := p.bundles.CollectGarbage(p.ctx); err != nil {
+		p.logger.Error("failed to collect bundle garbage", zap.Error(err))
+	}
+}
@@ -0,0 +1,86 @@
+package retention
+
+import (
+	"database/sql"
+	"testing"
+
+	"go.uber.org/zap"
+	"nebulagc.io/server/internal/service"
+	"nebulagc.io/server/internal/testutil"
+)
+
+// seedBundles creates a cluster with bundle versions 1-5 and the given
+// bundle retention.
+func seedBundles(t *testing.T, db *sql.DB, keep int) string {
+	t.Helper()
+
+	tenantID, clusterID, _ := testutil.Seed(t, db, 0)
+	for version := 1; version <= 5; version++ {
+		if _, err := db.Exec(`
+			INSERT INTO config_bundles (tenant_id, cluster_id, version, data, size)
+			VALUES (?, ?, ?, X'1f8b', 2)
+		`, tenantID, clusterID, version); err != nil {
+			t.Fatalf("Failed to insert bundle: %v", err)
+		}
+	}
+	if _, err := db.Exec(`
+		UPDATE clusters SET config_version = 5, bundle_retention = ? WHERE id = ?
+	`, keep, clusterID); err != nil {
+		t.Fatalf("Failed to update cluster: %v", err)
+	}
+
+	return clusterID
+}
+
+func countBundles(t *testing.T, db *sql.DB, clusterID string) int {
+	t.Helper()
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM config_bundles WHERE cluster_id = ?`, clusterID).Scan(&count); err != nil {
+		t.Fatalf("Failed to count bundles: %v", err)
+	}
+	return count
+}
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		name   string
+		master bool
+		keep   int
+		want   int
+	}{
+		{name: "master prunes to retention", master: true, keep: 2, want: 2},
+		{name: "replica leaves bundles", master: false, keep: 2, want: 5},
+		{name: "no retention keeps all", master: true, keep: 0, want: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testutil.OpenDB(t)
+			clusterID := seedBundles(t, db, tt.keep)
+
+			isMaster := func() (bool, string, error) { return tt.master, "", nil }
+			p := NewPruner(DefaultConfig(), service.NewBundleService(db, zap.NewNop()), isMaster, zap.NewNop())
+			p.prune()
+
+			if got := countBundles(t, db, clusterID); got != tt.want {
+				t.Errorf("Expected %d bundles, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStartRejectsInvalidInterval(t *testing.T) {
+	config := DefaultConfig()
+	config.CheckInterval = 0
+
+	p := NewPruner(config, nil, nil, zap.NewNop())
+	if err := p.Start(); err == nil {
+		t.Error("Expected error for zero check interval")
+	}
+
+	config.Enabled = false
+	if err := p.Start(); err != nil {
+		t.Errorf("Expected disabled pruner to start, got %v", err)
+	}
+}
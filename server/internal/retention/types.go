@@ -0,0 +1,27 @@
+// Package retention provides scheduled pruning of old config bundles.
+//
+// Clusters opt in by setting a bundle retention; the pruner periodically
+// deletes their bundle versions beyond it, so long-lived clusters do not
+// accumulate every bundle ever uploaded.
+package retention
+
+import "time"
+
+// Config holds configuration for the bundle pruner.
+type Config struct {
+	// CheckInterval is how often clusters are pruned to their retention.
+	// Default: 1 hour
+	CheckInterval time.Duration
+
+	// Enabled determines if scheduled pruning is enabled.
+	// Default: true
+	Enabled bool
+}
+
+// DefaultConfig returns a Config with default values.
+func DefaultConfig() *Config {
+	return &Config{
+		CheckInterval: time.Hour,
+		Enabled:       true,
+	}
+}
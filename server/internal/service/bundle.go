@@ -1,21 +1,37 @@
 package service
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
+	"nebulagc.io/models"
 	"nebulagc.io/pkg/bundle"
 )
 
 // BundleService provides operations for managing config bundles.
 type BundleService struct {
 	db     *sql.DB
+	store  BundleStore
 	logger *zap.Logger
+
+	// uploadLocks serializes uploads per cluster
+	uploadLocks clusterLocks
+
+	// now returns the current time for scheduled activation checks
+	now func() time.Time
 }
 
-// NewBundleService creates a new bundle service.
+// NewBundleService creates a new bundle service that keeps bundle data in
+// the database.
 //
 // Parameters:
 //   - db: Database connection
@@ -24,96 +40,328 @@ type BundleService struct {
 // Returns:
 //   - Configured BundleService
 func NewBundleService(db *sql.DB, logger *zap.Logger) *BundleService {
+	return NewBundleServiceWithStore(db, NewDBBundleStore(db), logger)
+}
+
+// NewBundleServiceWithStore creates a new bundle service that keeps bundle
+// metadata in the database and bundle data in store.
+//
+// Parameters:
+//   - db: Database connection
+//   - store: Bundle data store
+//   - logger: Zap logger for structured logging
+//
+// Returns:
+//   - Configured BundleService
+func NewBundleServiceWithStore(db *sql.DB, store BundleStore, logger *zap.Logger) *BundleService {
 	return &BundleService{
 		db:     db,
+		store:  store,
 		logger: logger,
+		now:    time.Now,
 	}
 }
 
-// Upload validates and stores a new config bundle for a cluster.
+// Upload validates and stores a new config bundle for a cluster, active immediately.
+//
+// Parameters:
+//   - clusterID: The cluster ID
+//   - data: The bundle data (tar.gz)
+//
+// Returns:
+//   - *models.BundleUploadResult: The new version number and the bundle's sizes
+//   - error: Any error that occurred
+func (s *BundleService) Upload(clusterID string, data []byte) (*models.BundleUploadResult, error) {
+	return s.UploadScheduled(clusterID, data, time.Time{})
+}
+
+// UploadOptions holds optional settings for a bundle upload.
+type UploadOptions struct {
+	// EffectiveAt is the activation time; zero or a past time activates immediately
+	EffectiveAt time.Time
+
+	// CreatedBy is the ID of the uploading node ("" if not uploaded by a node)
+	CreatedBy string
+
+	// Description is an optional note stored with the bundle
+	Description string
+
+	// Signature is an optional detached Ed25519 signature of the bundle,
+	// served with downloads for daemons to verify
+	Signature []byte
+
+	// CanaryNodeIDs releases the bundle only to these nodes as a canary
+	// rollout (see SetCanary); empty releases it to every node
+	CanaryNodeIDs []string
+}
+
+// UploadScheduled validates and stores a new config bundle for a cluster that
+// becomes active at effectiveAt.
+//
+// Parameters:
+//   - clusterID: The cluster ID
+//   - data: The bundle data (tar.gz)
+//   - effectiveAt: Activation time; zero or a past time activates immediately
+//
+// Returns:
+//   - *models.BundleUploadResult: The new version number and the bundle's sizes
+//   - error: Any error that occurred
+func (s *BundleService) UploadScheduled(clusterID string, data []byte, effectiveAt time.Time) (*models.BundleUploadResult, error) {
+	return s.UploadWithOptions(clusterID, data, UploadOptions{EffectiveAt: effectiveAt})
+}
+
+// UploadWithOptions validates and stores a new config bundle for a cluster
+// along with its metadata.
 //
 // This function:
+// 0. Checks the gzip magic bytes, failing fast with ErrInvalidBundleFormat
 // 1. Validates the bundle using bundle.Validate()
 // 2. Increments the cluster's config_version
-// 3. Stores the bundle in config_bundles table
+// 3. Stores the bundle's size and checksum in config_bundles table, and its
+// data in the bundle store
+//
+// Concurrent uploads to the same cluster are serialized and receive
+// sequential versions. A residual version collision (e.g., between two
+// control plane instances) returns ErrUploadConflict, which is safe to retry.
+//
+// A bundle scheduled in the future reserves its version now, but nodes keep
+// receiving the previous version until EffectiveAt (see effectiveVersion).
+// Activation is evaluated on every read, so no background job is involved;
+// nodes pick up the new version on their first poll after the cutover.
+//
+// A bundle uploaded with CanaryNodeIDs is stored together with its canary
+// rollout, so no other node can receive it before the canary is promoted.
 //
 // Parameters:
 //   - clusterID: The cluster ID
 //   - data: The bundle data (tar.gz)
+//   - opts: Activation time, uploader, description and signature
+//
+// The signature is stored as given; the control plane does not hold the
+// signing key, so authenticity is verified by daemons.
 //
 // Returns:
-//   - int64: The new version number
-//   - error: Any error that occurred
-func (s *BundleService) Upload(clusterID string, data []byte) (int64, error) {
+//   - *models.BundleUploadResult: The new version number, with the compressed
+//     and uncompressed sizes and file count found while validating the bundle
+//   - error: ErrInvalidRequest if the description is too long, the signature
+//     is not an Ed25519 signature, a canary node is not part of the cluster or
+//     a canary bundle is scheduled, or any other error
+func (s *BundleService) UploadWithOptions(clusterID string, data []byte, opts UploadOptions) (*models.BundleUploadResult, error) {
+	if len(opts.Description) > models.MaxBundleDescriptionLength {
+		return nil, fmt.Errorf("%w: description exceeds %d characters",
+			models.ErrInvalidRequest, models.MaxBundleDescriptionLength)
+	}
+	if opts.Signature != nil && len(opts.Signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("%w: signature must be %d bytes",
+			models.ErrInvalidRequest, ed25519.SignatureSize)
+	}
+	if len(opts.CanaryNodeIDs) > 0 && opts.EffectiveAt.After(s.now()) {
+		return nil, fmt.Errorf("%w: a canary bundle cannot be scheduled", models.ErrInvalidRequest)
+	}
+
+	// Reject the common "wrong file" mistake before attempting extraction
+	if len(data) <= bundle.MaxBundleSize && !hasGzipMagic(data) {
+		return nil, models.ErrInvalidBundleFormat
+	}
+
 	// Validate bundle
 	result := bundle.Validate(data)
 	if !result.Valid {
-		return 0, result.Error
+		return nil, result.Error
 	}
 
 	s.logger.Info("bundle validation passed",
 		zap.String("cluster_id", clusterID),
+		zap.Int("compressed_size", len(data)),
 		zap.Int64("size", result.Size),
 		zap.Int("files", len(result.Files)),
 	)
 
+	// Serialize uploads to the same cluster within this instance
+	unlock := s.uploadLocks.lock(clusterID)
+	defer unlock()
+
 	// Start transaction for atomic version increment and bundle storage
 	tx, err := s.db.Begin()
 	if err != nil {
-		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Get current version and increment
-	var currentVersion int64
-	err = tx.QueryRow(`SELECT config_version FROM clusters WHERE id = ?`, clusterID).Scan(&currentVersion)
+	// Read and increment the version in a single statement. Writing first
+	// takes SQLite's write lock, so a concurrent upload from another instance
+	// waits and then gets the next sequential version instead of reusing ours.
+	newVersion, tenantID, err := bumpConfigVersion(context.Background(), tx, clusterID, configChange{
+		changeType: models.ConfigChangeBundleUploaded,
+		actor:      opts.CreatedBy,
+		detail:     opts.Description,
+	})
+	if errors.Is(err, models.ErrClusterNotFound) {
+		return nil, err
+	}
 	if err != nil {
-		return 0, fmt.Errorf("failed to get current version: %w", err)
+		return nil, uploadError("failed to update cluster version", err)
 	}
 
-	newVersion := currentVersion + 1
-
-	// Update cluster version
-	_, err = tx.Exec(`UPDATE clusters SET config_version = ? WHERE id = ?`, newVersion, clusterID)
+	// Insert bundle; only future activation times are stored
+	now := s.now()
+	var activation sql.NullInt64
+	if opts.EffectiveAt.After(now) {
+		activation = sql.NullInt64{Int64: opts.EffectiveAt.Unix(), Valid: true}
+	}
+	checksum := sha256.Sum256(data)
+	_, err = tx.Exec(`
+		INSERT INTO config_bundles
+			(tenant_id, cluster_id, version, data, size, checksum, created_by, description, created_at, effective_at, signature)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, tenantID, clusterID, newVersion, []byte{}, len(data), hex.EncodeToString(checksum[:]),
+		nullString(opts.CreatedBy), nullString(opts.Description), now, activation, opts.Signature)
 	if err != nil {
-		return 0, fmt.Errorf("failed to update cluster version: %w", err)
+		return nil, uploadError("failed to insert bundle", err)
 	}
 
-	// Insert bundle
-	now := time.Now()
-	_, err = tx.Exec(`
-		INSERT INTO config_bundles (cluster_id, version, data, created_at)
-		VALUES (?, ?, ?, ?)
-	`, clusterID, newVersion, data, now)
+	// Store the data while the version is still reserved by the transaction;
+	// stores kept in the database write within it
+	if txStore, ok := s.store.(bundleTxStore); ok {
+		err = txStore.putTx(context.Background(), tx, clusterID, newVersion, data)
+	} else {
+		err = s.store.Put(context.Background(), clusterID, newVersion, data)
+	}
 	if err != nil {
-		return 0, fmt.Errorf("failed to insert bundle: %w", err)
+		return nil, uploadError("failed to store bundle data", err)
+	}
+
+	if len(opts.CanaryNodeIDs) > 0 {
+		if err := s.defineCanary(tx, clusterID, newVersion, opts.CanaryNodeIDs); err != nil {
+			return nil, err
+		}
 	}
 
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
-		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, uploadError("failed to commit transaction", err)
 	}
 
-	s.logger.Info("config bundle uploaded",
+	fields := []zap.Field{
 		zap.String("cluster_id", clusterID),
 		zap.Int64("version", newVersion),
 		zap.Int("size_bytes", len(data)),
-	)
+	}
+	if activation.Valid {
+		fields = append(fields, zap.Time("effective_at", time.Unix(activation.Int64, 0).UTC()))
+	}
+	if len(opts.CanaryNodeIDs) > 0 {
+		fields = append(fields, zap.Int("canary_nodes", len(opts.CanaryNodeIDs)))
+	}
+	s.logger.Info("config bundle uploaded", fields...)
+
+	return &models.BundleUploadResult{
+		Version:          newVersion,
+		CompressedSize:   int64(len(data)),
+		UncompressedSize: result.Size,
+		FileCount:        len(result.Files),
+		Checksum:         hex.EncodeToString(checksum[:]),
+	}, nil
+}
+
+// uploadError wraps a database error from Upload, mapping version collisions
+// and lock contention to the retryable ErrUploadConflict.
+func uploadError(msg string, err error) error {
+	text := err.Error()
+	if strings.Contains(text, "UNIQUE constraint failed") ||
+		strings.Contains(text, "database is locked") ||
+		strings.Contains(text, "SQLITE_BUSY") {
+		return fmt.Errorf("%w: %s: %v", models.ErrUploadConflict, msg, err)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// clusterLocks is a set of per-cluster mutexes.
+type clusterLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// lock acquires the mutex for clusterID and returns its unlock function.
+func (l *clusterLocks) lock(clusterID string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*sync.Mutex)
+	}
+	m, ok := l.locks[clusterID]
+	if !ok {
+		m = &sync.Mutex{}
+		l.locks[clusterID] = m
+	}
+	l.mu.Unlock()
 
-	return newVersion, nil
+	m.Lock()
+	return m.Unlock
 }
 
-// GetCurrentVersion returns the current config version for a cluster.
+// effectiveVersion returns the config version distributed to a node at now.
+//
+// A bundle scheduled for a future activation has already taken its version,
+// so the cluster is held at the version before the earliest pending bundle.
+// Later changes, including other uploads and topology updates, stay held
+// back with it and are released together once it activates.
+//
+// A canary holds nodes outside its node set at its stable version; after a
+// rollback its node set is empty, so every node is held there. An empty
+// nodeID (e.g., a caller authenticated with the cluster token) is never part
+// of a canary and resolves to the stable version.
+//
+// Returns sql.ErrNoRows if the cluster does not exist.
+func effectiveVersion(db *sql.DB, clusterID, nodeID string, now time.Time) (int64, error) {
+	var version int64
+	var scheduled, canary sql.NullInt64
+	err := db.QueryRow(`
+		SELECT c.config_version,
+			(SELECT MIN(b.version) FROM config_bundles b
+			 WHERE b.cluster_id = c.id AND b.effective_at > ?),
+			(SELECT r.stable_version FROM canary_rollouts r
+			 WHERE r.cluster_id = c.id
+			 AND NOT EXISTS (SELECT 1 FROM canary_nodes n WHERE n.cluster_id = r.cluster_id AND n.node_id = ?))
+		FROM clusters c
+		WHERE c.id = ?
+	`, now.Unix(), nodeID, clusterID).Scan(&version, &scheduled, &canary)
+	if err != nil {
+		return 0, err
+	}
+
+	if scheduled.Valid && scheduled.Int64-1 < version {
+		version = scheduled.Int64 - 1
+	}
+	if canary.Valid && canary.Int64 < version {
+		version = canary.Int64
+	}
+	return version, nil
+}
+
+// nullString converts an empty string to SQL NULL.
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// hasGzipMagic reports whether data starts with the gzip header (0x1f 0x8b).
+func hasGzipMagic(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+// GetCurrentVersion returns the current config version for a node of a cluster.
+//
+// Versions held back by a scheduled bundle are not reported until it activates,
+// and a canary version is only reported to its canary nodes.
 //
 // Parameters:
 //   - clusterID: The cluster ID
+//   - nodeID: The requesting node's ID ("" if not authenticated as a node)
 //
 // Returns:
 //   - int64: The current version number
 //   - error: Any error that occurred
-func (s *BundleService) GetCurrentVersion(clusterID string) (int64, error) {
-	var version int64
-	err := s.db.QueryRow(`SELECT config_version FROM clusters WHERE id = ?`, clusterID).Scan(&version)
+func (s *BundleService) GetCurrentVersion(clusterID, nodeID string) (int64, error) {
+	version, err := effectiveVersion(s.db, clusterID, nodeID, s.now())
 	if err == sql.ErrNoRows {
 		return 0, fmt.Errorf("cluster not found: %s", clusterID)
 	} else if err != nil {
@@ -123,81 +371,166 @@ func (s *BundleService) GetCurrentVersion(clusterID string) (int64, error) {
 	return version, nil
 }
 
+// IsRolledBack reports whether the cluster's canary was rolled back, so every
+// node is held at its stable version even if it already runs a later one.
+// Daemons only install a version lower than their own when this is set.
+//
+// Parameters:
+//   - clusterID: The cluster ID
+//
+// Returns:
+//   - bool: True if the cluster's canary was rolled back
+//   - error: Any error that occurred
+func (s *BundleService) IsRolledBack(clusterID string) (bool, error) {
+	var rolledBack bool
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) > 0 FROM canary_rollouts
+		WHERE cluster_id = ? AND rolled_back_at IS NOT NULL
+	`, clusterID).Scan(&rolledBack)
+	if err != nil {
+		return false, fmt.Errorf("failed to query canary rollback: %w", err)
+	}
+	return rolledBack, nil
+}
+
 // Download retrieves a config bundle by version.
 //
-// If version is 0, returns the latest bundle.
+// If version is 0, returns the latest bundle available to the node. Bundles
+// scheduled for a future activation, and any version after them, are not
+// served until the activation time; canary versions are only served to
+// canary nodes.
 //
 // Parameters:
 //   - clusterID: The cluster ID
+//   - nodeID: The requesting node's ID ("" if not authenticated as a node)
 //   - version: The version to retrieve (0 for latest)
 //
 // Returns:
 //   - []byte: The bundle data
 //   - int64: The bundle version
 //   - error: Any error that occurred
-func (s *BundleService) Download(clusterID string, version int64) ([]byte, int64, error) {
-	var data []byte
-	var actualVersion int64
-
-	var query string
-	var args []interface{}
-
-	if version == 0 {
-		// Get latest version
-		query = `
-			SELECT version, data
-			FROM config_bundles
-			WHERE cluster_id = ?
-			ORDER BY version DESC
-			LIMIT 1
-		`
-		args = []interface{}{clusterID}
-	} else {
-		// Get specific version
-		query = `
-			SELECT version, data
-			FROM config_bundles
-			WHERE cluster_id = ? AND version = ?
-		`
-		args = []interface{}{clusterID, version}
+func (s *BundleService) Download(clusterID, nodeID string, version int64) ([]byte, int64, error) {
+	meta, err := s.resolveDownload(clusterID, nodeID, version)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	err := s.db.QueryRow(query, args...).Scan(&actualVersion, &data)
-	if err == sql.ErrNoRows {
-		if version == 0 {
-			return nil, 0, fmt.Errorf("no bundles found for cluster: %s", clusterID)
-		}
-		return nil, 0, fmt.Errorf("bundle version %d not found for cluster: %s", version, clusterID)
-	} else if err != nil {
-		return nil, 0, fmt.Errorf("failed to download bundle: %w", err)
+	data, err := s.store.Get(context.Background(), clusterID, meta.Version)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to download bundle: failed to load bundle data: %w", err)
 	}
 
 	s.logger.Debug("config bundle downloaded",
 		zap.String("cluster_id", clusterID),
-		zap.Int64("version", actualVersion),
+		zap.Int64("version", meta.Version),
 		zap.Int("size_bytes", len(data)),
 	)
 
-	return data, actualVersion, nil
+	return data, meta.Version, nil
+}
+
+// GetMetadata returns a bundle's metadata without its data.
+//
+// Unlike Download, any stored version is returned, including versions held
+// back by a scheduled activation or a canary.
+//
+// Parameters:
+//   - clusterID: The cluster ID
+//   - version: The bundle version
+//
+// Returns:
+//   - The bundle with Data unset
+//   - ErrNotFound if the bundle does not exist, or error if the query fails
+func (s *BundleService) GetMetadata(clusterID string, version int64) (*models.ConfigBundle, error) {
+	meta, err := s.queryBundle(false, `b.cluster_id = ? AND b.version = ?`, clusterID, version)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bundle metadata: %w", err)
+	}
+
+	return meta, nil
+}
+
+// queryBundle loads the first bundle matching where (a condition on
+// config_bundles aliased as b, optionally followed by ORDER BY/LIMIT).
+// Data is only loaded, from the bundle store, when withData is set.
+func (s *BundleService) queryBundle(withData bool, where string, args ...interface{}) (*models.ConfigBundle, error) {
+	var stored models.ConfigBundle
+	var checksum, createdBy, description sql.NullString
+	err := s.db.QueryRow(`
+		SELECT b.version, b.tenant_id, b.cluster_id, b.size, b.checksum,
+			b.created_by, b.description, b.created_at, b.signature
+		FROM config_bundles b
+		WHERE `+where, args...).Scan(
+		&stored.Version, &stored.TenantID, &stored.ClusterID, &stored.Size, &checksum,
+		&createdBy, &description, &stored.CreatedAt, &stored.Signature,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if withData {
+		stored.Data, err = s.store.Get(context.Background(), stored.ClusterID, stored.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load bundle data: %w", err)
+		}
+	}
+
+	stored.Checksum = checksum.String
+	stored.Description = description.String
+	if createdBy.Valid {
+		stored.CreatedBy = &createdBy.String
+	}
+
+	return &stored, nil
 }
 
 // CheckVersion checks if a client's version is current.
 //
-// Returns true if the client has the latest version, false otherwise.
+// Returns true if the client has the version targeted at its node, false otherwise.
 //
 // Parameters:
 //   - clusterID: The cluster ID
+//   - nodeID: The requesting node's ID ("" if not authenticated as a node)
 //   - clientVersion: The client's current version
 //
 // Returns:
 //   - bool: true if client is up-to-date
 //   - int64: The current version number
 //   - error: Any error that occurred
-func (s *BundleService) CheckVersion(clusterID string, clientVersion int64) (bool, int64, error) {
-	currentVersion, err := s.GetCurrentVersion(clusterID)
+func (s *BundleService) CheckVersion(clusterID, nodeID string, clientVersion int64) (bool, int64, error) {
+	currentVersion, err := s.GetCurrentVersion(clusterID, nodeID)
 	if err != nil {
 		return false, 0, err
 	}
 
 	return clientVersion == currentVersion, currentVersion, nil
 }
+
+// CollectGarbage reclaims bundle data no longer referenced by any bundle
+// version. Only stores that share data between versions (the
+// content-addressed store) hold such data; for others it does nothing.
+//
+// Parameters:
+//   - ctx: Request context for cancellation
+//
+// Returns:
+//   - int: Number of unreferenced blobs removed
+//   - error: Any error that occurred
+func (s *BundleService) CollectGarbage(ctx context.Context) (int, error) {
+	gc, ok := s.store.(garbageCollector)
+	if !ok {
+		return 0, nil
+	}
+
+	removed, err := gc.CollectGarbage(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if removed > 0 {
+		s.logger.Info("unreferenced bundle blobs removed", zap.Int("blobs", removed))
+	}
+	return removed, nil
+}
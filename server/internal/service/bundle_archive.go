@@ -0,0 +1,132 @@
+package service
+
+import (
+	"archive/tar"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+)
+
+// BundleArchiveEntryName returns the name a bundle version is stored under
+// inside a bundle archive (e.g. "42.tar.gz").
+func BundleArchiveEntryName(version int64) string {
+	return fmt.Sprintf("%d.tar.gz", version)
+}
+
+// WriteArchive streams every stored bundle version of a cluster to w as a tar
+// archive, one entry per version in ascending order, named by
+// BundleArchiveEntryName.
+//
+// Versions are listed first and their data is then loaded one at a time, so
+// only a single bundle is held in memory and no read transaction stays open
+// while a slow client drains the archive. Passing afterVersion > 0 skips
+// versions up to and including it, which lets a client resume an interrupted
+// download from the last entry it received completely.
+//
+// Parameters:
+//   - ctx: Context for cancellation (e.g., the client disconnecting)
+//   - clusterID: The cluster ID
+//   - afterVersion: Only archive versions greater than this (0 = all)
+//   - w: Destination for the tar stream
+//
+// Returns:
+//   - int: Number of versions written
+//   - error: ErrNotFound if the cluster does not exist, or any query/write error
+func (s *BundleService) WriteArchive(ctx context.Context, clusterID string, afterVersion int64, w io.Writer) (int, error) {
+	var exists int
+	err := s.db.QueryRowContext(ctx, `SELECT 1 FROM clusters WHERE id = ?`, clusterID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return 0, models.ErrNotFound
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to check cluster: %w", err)
+	}
+
+	versions, err := s.archiveVersions(ctx, clusterID, afterVersion)
+	if err != nil {
+		return 0, err
+	}
+
+	tw := tar.NewWriter(w)
+	written := 0
+	for _, version := range versions {
+		var createdAt time.Time
+		err := s.db.QueryRowContext(ctx, `
+			SELECT created_at FROM config_bundles
+			WHERE cluster_id = ? AND version = ?
+		`, clusterID, version).Scan(&createdAt)
+		if err == sql.ErrNoRows {
+			// Pruned since the versions were listed
+			continue
+		} else if err != nil {
+			return written, fmt.Errorf("failed to load bundle version %d: %w", version, err)
+		}
+		data, err := s.store.Get(ctx, clusterID, version)
+		if err != nil {
+			return written, fmt.Errorf("failed to load bundle version %d: %w", version, err)
+		}
+
+		hdr := &tar.Header{
+			Name:    BundleArchiveEntryName(version),
+			Mode:    0600,
+			Size:    int64(len(data)),
+			ModTime: createdAt,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return written, fmt.Errorf("failed to write archive entry: %w", err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return written, fmt.Errorf("failed to write archive entry: %w", err)
+		}
+		if err := tw.Flush(); err != nil {
+			return written, fmt.Errorf("failed to write archive entry: %w", err)
+		}
+		if f, ok := w.(interface{ Flush() }); ok {
+			f.Flush()
+		}
+		written++
+	}
+
+	if err := tw.Close(); err != nil {
+		return written, fmt.Errorf("failed to finish archive: %w", err)
+	}
+
+	s.logger.Info("bundle archive streamed",
+		zap.String("cluster_id", clusterID),
+		zap.Int64("after_version", afterVersion),
+		zap.Int("versions", written),
+	)
+
+	return written, nil
+}
+
+// archiveVersions lists the stored bundle versions of a cluster above afterVersion.
+func (s *BundleService) archiveVersions(ctx context.Context, clusterID string, afterVersion int64) ([]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT version FROM config_bundles
+		WHERE cluster_id = ? AND version > ?
+		ORDER BY version ASC
+	`, clusterID, afterVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bundle versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []int64
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to scan bundle version: %w", err)
+		}
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list bundle versions: %w", err)
+	}
+
+	return versions, nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+)
+
+// readArchive returns the entries of a tar archive keyed by name, in order.
+func readArchive(t *testing.T, data []byte) ([]string, map[string][]byte) {
+	t.Helper()
+
+	var names []string
+	contents := make(map[string][]byte)
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read archive: %v", err)
+		}
+		body, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("Failed to read archive entry: %v", err)
+		}
+		names = append(names, hdr.Name)
+		contents[hdr.Name] = body
+	}
+
+	return names, contents
+}
+
+func TestBundleService_WriteArchive(t *testing.T) {
+	db := setupBundleTestDB(t)
+	defer db.Close()
+
+	service := NewBundleService(db, zap.NewNop())
+	bundleData := createTestBundle()
+	for i := 0; i < 3; i++ {
+		if _, err := service.Upload("cluster1", bundleData); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	n, err := service.WriteArchive(context.Background(), "cluster1", 0, &buf)
+	if err != nil {
+		t.Fatalf("WriteArchive failed: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Expected 3 versions written, got %d", n)
+	}
+
+	names, contents := readArchive(t, buf.Bytes())
+	want := []string{"2.tar.gz", "3.tar.gz", "4.tar.gz"}
+	if len(names) != len(want) {
+		t.Fatalf("Expected entries %v, got %v", want, names)
+	}
+	for i, name := range want {
+		if names[i] != name {
+			t.Errorf("Entry %d = %s, want %s", i, names[i], name)
+		}
+		if !bytes.Equal(contents[name], bundleData) {
+			t.Errorf("Entry %s does not match the uploaded bundle", name)
+		}
+	}
+
+	// Resuming after version 3 only sends what is left
+	buf.Reset()
+	if _, err := service.WriteArchive(context.Background(), "cluster1", 3, &buf); err != nil {
+		t.Fatalf("WriteArchive failed: %v", err)
+	}
+	if names, _ := readArchive(t, buf.Bytes()); len(names) != 1 || names[0] != "4.tar.gz" {
+		t.Errorf("Expected only 4.tar.gz after version 3, got %v", names)
+	}
+}
+
+func TestBundleService_WriteArchiveUnknownCluster(t *testing.T) {
+	db := setupBundleTestDB(t)
+	defer db.Close()
+
+	service := NewBundleService(db, zap.NewNop())
+	_, err := service.WriteArchive(context.Background(), "missing", 0, io.Discard)
+	if !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"io"
+	"sort"
+
+	"nebulagc.io/models"
+)
+
+// bundleFile is the size and content hash of a file in a bundle.
+type bundleFile struct {
+	size int64
+	hash [sha256.Size]byte
+}
+
+// Diff reports which files were added, removed or changed between two bundle
+// versions of a cluster. Files are compared by the SHA-256 of their content;
+// only names, change types and sizes are reported.
+//
+// Like GetMetadata, any stored version can be compared, including versions
+// held back by a scheduled activation or a canary.
+//
+// Parameters:
+//   - clusterID: The cluster ID
+//   - fromVersion: The version compared against
+//   - toVersion: The version whose changes are reported
+//
+// Returns:
+//   - *models.BundleDiff: Changed files sorted by name
+//   - error: ErrInvalidRequest if a version is not positive, ErrBundleNotFound
+//     if either version is not stored, or any other error
+func (s *BundleService) Diff(clusterID string, fromVersion, toVersion int64) (*models.BundleDiff, error) {
+	if fromVersion < 1 || toVersion < 1 {
+		return nil, fmt.Errorf("%w: versions must be positive", models.ErrInvalidRequest)
+	}
+
+	from, err := s.bundleFiles(clusterID, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.bundleFiles(clusterID, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &models.BundleDiff{
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+		Files:       []models.BundleFileChange{},
+	}
+	for name, old := range from {
+		file, ok := to[name]
+		switch {
+		case !ok:
+			diff.Files = append(diff.Files, models.BundleFileChange{
+				Name: name, Change: models.BundleFileRemoved, FromSize: old.size,
+			})
+		case file.hash != old.hash:
+			diff.Files = append(diff.Files, models.BundleFileChange{
+				Name: name, Change: models.BundleFileChanged, FromSize: old.size, ToSize: file.size,
+			})
+		}
+	}
+	for name, file := range to {
+		if _, ok := from[name]; !ok {
+			diff.Files = append(diff.Files, models.BundleFileChange{
+				Name: name, Change: models.BundleFileAdded, ToSize: file.size,
+			})
+		}
+	}
+	sort.Slice(diff.Files, func(i, j int) bool {
+		return diff.Files[i].Name < diff.Files[j].Name
+	})
+
+	return diff, nil
+}
+
+// bundleFiles loads a bundle version and returns its files keyed by name.
+func (s *BundleService) bundleFiles(clusterID string, version int64) (map[string]bundleFile, error) {
+	stored, err := s.queryBundle(true, `b.cluster_id = ? AND b.version = ?`, clusterID, version)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: version %d", models.ErrBundleNotFound, version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bundle version %d: %w", version, err)
+	}
+
+	files, err := hashBundleFiles(stored.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle version %d: %w", version, err)
+	}
+	return files, nil
+}
+
+// hashBundleFiles un-tars a bundle and hashes each regular file's content.
+// Directories are skipped.
+func hashBundleFiles(data []byte) (map[string]bundleFile, error) {
+	gzReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gzReader.Close()
+
+	files := make(map[string]bundleFile)
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeDir {
+			continue
+		}
+
+		h := sha256.New()
+		size, err := io.Copy(h, tarReader)
+		if err != nil {
+			return nil, err
+		}
+		var file bundleFile
+		file.size = size
+		copy(file.hash[:], h.Sum(nil))
+		files[header.Name] = file
+	}
+
+	return files, nil
+}
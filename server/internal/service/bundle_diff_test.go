@@ -0,0 +1,117 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+	"nebulagc.io/pkg/bundle"
+)
+
+func TestBundleService_Diff(t *testing.T) {
+	db := setupBundleTestDB(t)
+	defer db.Close()
+
+	service := NewBundleService(db, zap.NewNop())
+
+	base := map[string]string{
+		bundle.RequiredFileConfig:   "pki:\n  ca: ca.crt\n  cert: host.crt\n  key: host.key\n",
+		bundle.RequiredFileCACert:   "ca cert",
+		bundle.RequiredFileCRL:      "crl",
+		bundle.RequiredFileHostCert: "host cert",
+		bundle.RequiredFileHostKey:  "key",
+	}
+	next := make(map[string]string)
+	for name, content := range base {
+		next[name] = content
+	}
+	next[bundle.RequiredFileConfig] = base[bundle.RequiredFileConfig] + "listen:\n  port: 4242\n"
+	next["firewall.yml"] = "outbound: []\n"
+
+	baseSize := int64(len(base[bundle.RequiredFileConfig]))
+	nextSize := int64(len(next[bundle.RequiredFileConfig]))
+	firewallSize := int64(len(next["firewall.yml"]))
+
+	from, err := uploadVersion(service.Upload("cluster1", createTestBundleFromFiles(base)))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	to, err := uploadVersion(service.Upload("cluster1", createTestBundleFromFiles(next)))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		from int64
+		to   int64
+		want []models.BundleFileChange
+	}{
+		{
+			name: "forward",
+			from: from,
+			to:   to,
+			want: []models.BundleFileChange{
+				{Name: "config.yml", Change: models.BundleFileChanged, FromSize: baseSize, ToSize: nextSize},
+				{Name: "firewall.yml", Change: models.BundleFileAdded, ToSize: firewallSize},
+			},
+		},
+		{
+			name: "backward",
+			from: to,
+			to:   from,
+			want: []models.BundleFileChange{
+				{Name: "config.yml", Change: models.BundleFileChanged, FromSize: nextSize, ToSize: baseSize},
+				{Name: "firewall.yml", Change: models.BundleFileRemoved, FromSize: firewallSize},
+			},
+		},
+		{
+			name: "same version",
+			from: to,
+			to:   to,
+			want: []models.BundleFileChange{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := service.Diff("cluster1", tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("Diff failed: %v", err)
+			}
+			if diff.FromVersion != tt.from || diff.ToVersion != tt.to {
+				t.Errorf("Expected versions %d..%d, got %d..%d", tt.from, tt.to, diff.FromVersion, diff.ToVersion)
+			}
+			if len(diff.Files) != len(tt.want) {
+				t.Fatalf("Expected %d changed files, got %+v", len(tt.want), diff.Files)
+			}
+			for i, want := range tt.want {
+				if diff.Files[i] != want {
+					t.Errorf("File %d = %+v, want %+v", i, diff.Files[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestBundleService_DiffErrors(t *testing.T) {
+	db := setupBundleTestDB(t)
+	defer db.Close()
+
+	service := NewBundleService(db, zap.NewNop())
+	version, err := uploadVersion(service.Upload("cluster1", createTestBundle()))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	if _, err := service.Diff("cluster1", 0, version); !errors.Is(err, models.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest, got %v", err)
+	}
+	if _, err := service.Diff("cluster1", version, version+1); !errors.Is(err, models.ErrBundleNotFound) {
+		t.Errorf("Expected ErrBundleNotFound for missing version, got %v", err)
+	}
+	if _, err := service.Diff("missing", version, version); !errors.Is(err, models.ErrBundleNotFound) {
+		t.Errorf("Expected ErrBundleNotFound for other cluster, got %v", err)
+	}
+}
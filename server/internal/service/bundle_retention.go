@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+	"nebulagc.io/server/internal/logging"
+)
+
+// SetRetention sets how many bundle versions the scheduled prune keeps for a
+// cluster.
+//
+// Parameters:
+//   - clusterID: The cluster ID
+//   - keep: Number of latest versions to keep (0 disables automatic pruning)
+//
+// Returns:
+//   - ErrInvalidRequest if keep is negative, ErrClusterNotFound if the
+//     cluster does not exist, or error if the update fails
+func (s *BundleService) SetRetention(clusterID string, keep int) error {
+	if keep < 0 {
+		return fmt.Errorf("%w: retention must not be negative", models.ErrInvalidRequest)
+	}
+
+	result, err := s.db.Exec(`UPDATE clusters SET bundle_retention = ? WHERE id = ?`, keep, clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to set bundle retention: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.ErrClusterNotFound
+	}
+
+	s.logger.Info("Set bundle retention",
+		zap.Bool(logging.FieldAudit, true),
+		zap.String(logging.FieldOperation, "set_bundle_retention"),
+		zap.String(logging.FieldClusterID, clusterID),
+		zap.Int("keep", keep))
+
+	return nil
+}
+
+// PruneOldVersions deletes all but the latest keep bundle versions of a
+// cluster.
+//
+// Bundles still in use are never deleted, even when older than the latest
+// keep: those served for the version a control plane instance is running
+// (cluster_state), the version distributed to nodes outside a scheduled
+// activation or canary, and the canary and its stable version. Topology
+// changes bump the same config version without adding a bundle, so each of
+// these versions is resolved to the bundle a download serves for it, the
+// newest one at or below it. Bundle rows are deleted in one
+// transaction; their data is then removed from the bundle store, which for
+// the content-addressed store leaves blobs to CollectGarbage.
+//
+// Parameters:
+//   - clusterID: The cluster ID
+//   - keep: Number of latest versions to keep (at least 1)
+//
+// Returns:
+//   - int: Number of bundle versions pruned
+//   - error: ErrInvalidRequest if keep is below 1, ErrClusterNotFound if the
+//     cluster does not exist, or any other error
+func (s *BundleService) PruneOldVersions(clusterID string, keep int) (int, error) {
+	if keep < 1 {
+		return 0, fmt.Errorf("%w: must keep at least one version", models.ErrInvalidRequest)
+	}
+
+	// Uploads through this service wait, so the latest versions cannot shift;
+	// the server shares one BundleService between the API and the pruner
+	unlock := s.uploadLocks.lock(clusterID)
+	defer unlock()
+
+	current, err := effectiveVersion(s.db, clusterID, "", s.now())
+	if err == sql.ErrNoRows {
+		return 0, models.ErrClusterNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get version: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT b.version FROM config_bundles b
+		WHERE b.cluster_id = ?
+		AND b.version < (
+			SELECT MIN(k.version) FROM (
+				SELECT version FROM config_bundles
+				WHERE cluster_id = ?
+				ORDER BY version DESC LIMIT ?
+			) k)
+		AND NOT EXISTS (
+			SELECT 1 FROM (
+				SELECT ? AS version
+				UNION SELECT cs.running_config_version FROM cluster_state cs WHERE cs.cluster_id = ?
+				UNION SELECT r.version FROM canary_rollouts r WHERE r.cluster_id = ?
+				UNION SELECT r.stable_version FROM canary_rollouts r WHERE r.cluster_id = ?
+			) p
+			WHERE b.version = (
+				SELECT MAX(s.version) FROM config_bundles s
+				WHERE s.cluster_id = b.cluster_id AND s.version <= p.version))
+		ORDER BY b.version
+	`, clusterID, clusterID, keep, current, clusterID, clusterID, clusterID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list prunable bundles: %w", err)
+	}
+	var versions []int64
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan bundle version: %w", err)
+		}
+		versions = append(versions, version)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to list prunable bundles: %w", err)
+	}
+
+	for _, version := range versions {
+		if _, err := tx.Exec(`
+			DELETE FROM config_bundles WHERE cluster_id = ? AND version = ?
+		`, clusterID, version); err != nil {
+			return 0, fmt.Errorf("failed to delete bundle version %d: %w", version, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, version := range versions {
+		if err := s.store.Delete(context.Background(), clusterID, version); err != nil {
+			s.logger.Warn("failed to delete pruned bundle data",
+				zap.String("cluster_id", clusterID),
+				zap.Int64("version", version),
+				zap.Error(err))
+		}
+	}
+
+	if len(versions) > 0 {
+		s.logger.Info("old config bundles pruned",
+			zap.String("cluster_id", clusterID),
+			zap.Int("keep", keep),
+			zap.Int("pruned", len(versions)))
+	}
+
+	return len(versions), nil
+}
+
+// PruneByRetention prunes every cluster that sets a bundle retention down to
+// its retention (see PruneOldVersions). A cluster that fails to prune is
+// logged and skipped.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//
+// Returns:
+//   - int: Number of bundle versions pruned across all clusters
+//   - error: Error if the clusters could not be listed
+func (s *BundleService) PruneByRetention(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, bundle_retention FROM clusters
+		WHERE bundle_retention > 0
+		ORDER BY id
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list clusters with bundle retention: %w", err)
+	}
+	type retention struct {
+		clusterID string
+		keep      int
+	}
+	var clusters []retention
+	for rows.Next() {
+		var r retention
+		if err := rows.Scan(&r.clusterID, &r.keep); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan bundle retention: %w", err)
+		}
+		clusters = append(clusters, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to list clusters with bundle retention: %w", err)
+	}
+
+	total := 0
+	for _, r := range clusters {
+		if ctx.Err() != nil {
+			return total, ctx.Err()
+		}
+		pruned, err := s.PruneOldVersions(r.clusterID, r.keep)
+		if err != nil {
+			s.logger.Error("failed to prune config bundles",
+				zap.String("cluster_id", r.clusterID),
+				zap.Error(err))
+			continue
+		}
+		total += pruned
+	}
+
+	return total, nil
+}
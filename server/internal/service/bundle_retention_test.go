@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+)
+
+// bundleVersions returns the stored bundle versions of a cluster in order.
+func bundleVersions(t *testing.T, db *sql.DB, clusterID string) []int64 {
+	t.Helper()
+
+	rows, err := db.Query(`SELECT version FROM config_bundles WHERE cluster_id = ? ORDER BY version`, clusterID)
+	if err != nil {
+		t.Fatalf("Failed to query bundles: %v", err)
+	}
+	defer rows.Close()
+
+	var versions []int64
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			t.Fatalf("Failed to scan version: %v", err)
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+func TestBundleService_PruneOldVersions(t *testing.T) {
+	db := setupBundleTestDB(t)
+	defer db.Close()
+
+	store, err := NewFSBundleStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFSBundleStore failed: %v", err)
+	}
+	service := NewBundleServiceWithStore(db, store, zap.NewNop())
+	bundleData := createTestBundle()
+	for i := 0; i < 5; i++ {
+		if _, err := service.Upload("cluster1", bundleData); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+
+	// A control plane instance still running version 3 keeps it
+	if _, err := db.Exec(`
+		INSERT INTO cluster_state (cluster_id, instance_id, running_config_version)
+		VALUES ('cluster1', 'instance1', 3)
+	`); err != nil {
+		t.Fatalf("Failed to insert cluster state: %v", err)
+	}
+
+	pruned, err := service.PruneOldVersions("cluster1", 2)
+	if err != nil {
+		t.Fatalf("PruneOldVersions failed: %v", err)
+	}
+	if pruned != 2 {
+		t.Errorf("Expected 2 versions pruned, got %d", pruned)
+	}
+	if got, want := bundleVersions(t, db, "cluster1"), []int64{3, 5, 6}; !slices.Equal(got, want) {
+		t.Errorf("Expected versions %v, got %v", want, got)
+	}
+
+	// Pruned data is removed from the store
+	if _, err := store.Get(context.Background(), "cluster1", 2); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Expected pruned data to be deleted, got %v", err)
+	}
+
+	// Pruning again has nothing left to do
+	pruned, err = service.PruneOldVersions("cluster1", 2)
+	if err != nil {
+		t.Fatalf("PruneOldVersions failed: %v", err)
+	}
+	if pruned != 0 {
+		t.Errorf("Expected nothing pruned, got %d", pruned)
+	}
+}
+
+func TestBundleService_PruneOldVersionsKeepsServedVersion(t *testing.T) {
+	db := setupBundleTestDB(t)
+	defer db.Close()
+
+	service := NewBundleService(db, zap.NewNop())
+	start := time.Unix(1700000000, 0)
+	service.now = func() time.Time { return start }
+	bundleData := createTestBundle()
+
+	for i := 0; i < 3; i++ {
+		if _, err := service.Upload("cluster1", bundleData); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+	if _, err := service.UploadScheduled("cluster1", bundleData, start.Add(time.Hour)); err != nil {
+		t.Fatalf("UploadScheduled failed: %v", err)
+	}
+
+	// Version 4 is still served until the scheduled version 5 activates
+	if _, err := service.PruneOldVersions("cluster1", 1); err != nil {
+		t.Fatalf("PruneOldVersions failed: %v", err)
+	}
+	if got, want := bundleVersions(t, db, "cluster1"), []int64{4, 5}; !slices.Equal(got, want) {
+		t.Errorf("Expected versions %v, got %v", want, got)
+	}
+}
+
+func TestBundleService_PruneOldVersionsKeepsBundleBelowTopologyBump(t *testing.T) {
+	db := setupBundleTestDB(t)
+	defer db.Close()
+
+	service := NewBundleService(db, zap.NewNop())
+	start := time.Unix(1700000000, 0)
+	service.now = func() time.Time { return start }
+	bundleData := createTestBundle()
+
+	if _, err := service.Upload("cluster1", bundleData); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	// A topology change bumps the config version without adding a bundle
+	if _, err := db.Exec(`UPDATE clusters SET config_version = config_version + 1 WHERE id = 'cluster1'`); err != nil {
+		t.Fatalf("Failed to bump config version: %v", err)
+	}
+	if _, err := service.UploadScheduled("cluster1", bundleData, start.Add(time.Hour)); err != nil {
+		t.Fatalf("UploadScheduled failed: %v", err)
+	}
+
+	// Version 3 has no bundle, so nodes are still served version 2
+	if _, err := service.PruneOldVersions("cluster1", 1); err != nil {
+		t.Fatalf("PruneOldVersions failed: %v", err)
+	}
+	if got, want := bundleVersions(t, db, "cluster1"), []int64{2, 4}; !slices.Equal(got, want) {
+		t.Errorf("Expected versions %v, got %v", want, got)
+	}
+	if _, version, err := service.Download("cluster1", "", 0); err != nil {
+		t.Errorf("Download failed after prune: %v", err)
+	} else if version != 2 {
+		t.Errorf("Expected version 2 to be served, got %d", version)
+	}
+}
+
+func TestBundleService_PruneOldVersionsErrors(t *testing.T) {
+	db := setupBundleTestDB(t)
+	defer db.Close()
+
+	service := NewBundleService(db, zap.NewNop())
+
+	if _, err := service.PruneOldVersions("cluster1", 0); !errors.Is(err, models.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for keep 0, got %v", err)
+	}
+	if _, err := service.PruneOldVersions("missing", 1); !errors.Is(err, models.ErrClusterNotFound) {
+		t.Errorf("Expected ErrClusterNotFound, got %v", err)
+	}
+	if err := service.SetRetention("cluster1", -1); !errors.Is(err, models.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for negative retention, got %v", err)
+	}
+	if err := service.SetRetention("missing", 3); !errors.Is(err, models.ErrClusterNotFound) {
+		t.Errorf("Expected ErrClusterNotFound, got %v", err)
+	}
+}
+
+func TestBundleService_PruneByRetention(t *testing.T) {
+	db := setupBundleTestDB(t)
+	defer db.Close()
+
+	service := NewBundleService(db, zap.NewNop())
+	bundleData := createTestBundle()
+	for i := 0; i < 4; i++ {
+		if _, err := service.Upload("cluster1", bundleData); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+
+	// No retention set: nothing is pruned
+	pruned, err := service.PruneByRetention(context.Background())
+	if err != nil {
+		t.Fatalf("PruneByRetention failed: %v", err)
+	}
+	if pruned != 0 {
+		t.Errorf("Expected nothing pruned without retention, got %d", pruned)
+	}
+
+	if err := service.SetRetention("cluster1", 3); err != nil {
+		t.Fatalf("SetRetention failed: %v", err)
+	}
+	pruned, err = service.PruneByRetention(context.Background())
+	if err != nil {
+		t.Fatalf("PruneByRetention failed: %v", err)
+	}
+	if pruned != 1 {
+		t.Errorf("Expected 1 version pruned, got %d", pruned)
+	}
+	if got, want := bundleVersions(t, db, "cluster1"), []int64{3, 4, 5}; !slices.Equal(got, want) {
+		t.Errorf("Expected versions %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,625 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+)
+
+// Bundle store kinds accepted by OpenBundleStore.
+const (
+	// BundleStoreDB keeps bundle data in the config_bundles table.
+	BundleStoreDB = "db"
+
+	// BundleStoreFS keeps bundle data as files in a directory.
+	BundleStoreFS = "fs"
+
+	// BundleStoreCAS keeps bundle data content-addressed, sharing one blob
+	// between identical bundles, in the database or a directory.
+	BundleStoreCAS = "cas"
+)
+
+// BundleStore holds the data of config bundles. Bundle metadata (version,
+// size, checksum, activation) always lives in config_bundles; the store only
+// holds the archive bytes, keyed by cluster and version.
+//
+// Stores other than the database (a directory today, object storage in the
+// future) let large deployments keep bundle bytes out of SQLite. Every control
+// plane instance must see the same store, so a filesystem store has to be on
+// storage shared by all instances.
+type BundleStore interface {
+	// Put stores the data of a bundle version, replacing any existing data.
+	Put(ctx context.Context, clusterID string, version int64, data []byte) error
+
+	// Get returns the data of a bundle version, or ErrNotFound if the store
+	// does not hold it.
+	Get(ctx context.Context, clusterID string, version int64) ([]byte, error)
+
+	// Delete removes the data of a bundle version. Deleting data the store
+	// does not hold is not an error.
+	Delete(ctx context.Context, clusterID string, version int64) error
+}
+
+// bundleTxStore is implemented by stores that keep state in the database.
+// Uploads store the data through putTx, inside the transaction that records
+// the bundle, instead of calling Put.
+type bundleTxStore interface {
+	putTx(ctx context.Context, tx *sql.Tx, clusterID string, version int64, data []byte) error
+}
+
+// garbageCollector is implemented by stores that share data between bundle
+// versions and only reclaim it once no version references it.
+type garbageCollector interface {
+	CollectGarbage(ctx context.Context) (int, error)
+}
+
+// OpenBundleStore returns the bundle store of the given kind.
+//
+// Parameters:
+//   - kind: BundleStoreDB, BundleStoreFS or BundleStoreCAS
+//   - db: Database connection (used by the database and content-addressed stores)
+//   - dir: Directory of the filesystem store, or of the content-addressed
+//     store's blobs ("" keeps them in the database); created if missing
+//
+// Returns:
+//   - BundleStore: The store
+//   - error: Error if the kind is unknown or the directory cannot be created
+func OpenBundleStore(kind string, db *sql.DB, dir string) (BundleStore, error) {
+	switch kind {
+	case BundleStoreDB, "":
+		return NewDBBundleStore(db), nil
+	case BundleStoreFS:
+		return NewFSBundleStore(dir)
+	case BundleStoreCAS:
+		return NewContentBundleStore(db, dir)
+	default:
+		return nil, fmt.Errorf("unknown bundle store %q: must be %q, %q or %q", kind, BundleStoreDB, BundleStoreFS, BundleStoreCAS)
+	}
+}
+
+// sqlExecer is satisfied by *sql.DB and *sql.Tx.
+type sqlExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// DBBundleStore keeps bundle data in the data column of config_bundles.
+//
+// Uploads write the column in the transaction that records the bundle's
+// metadata; Put requires the bundle row to exist.
+type DBBundleStore struct {
+	db *sql.DB
+}
+
+// NewDBBundleStore creates a bundle store backed by the database.
+func NewDBBundleStore(db *sql.DB) *DBBundleStore {
+	return &DBBundleStore{db: db}
+}
+
+// Put writes data into an existing bundle row.
+func (s *DBBundleStore) Put(ctx context.Context, clusterID string, version int64, data []byte) error {
+	return s.put(ctx, s.db, clusterID, version, data)
+}
+
+func (s *DBBundleStore) putTx(ctx context.Context, tx *sql.Tx, clusterID string, version int64, data []byte) error {
+	return s.put(ctx, tx, clusterID, version, data)
+}
+
+func (s *DBBundleStore) put(ctx context.Context, exec sqlExecer, clusterID string, version int64, data []byte) error {
+	result, err := exec.ExecContext(ctx, `
+		UPDATE config_bundles SET data = ?
+		WHERE cluster_id = ? AND version = ?
+	`, data, clusterID, version)
+	if err != nil {
+		return fmt.Errorf("failed to store bundle data: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// Get reads the data column of a bundle row. A row whose data has been moved
+// to another store holds no data and is reported as ErrNotFound.
+func (s *DBBundleStore) Get(ctx context.Context, clusterID string, version int64) ([]byte, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT data FROM config_bundles
+		WHERE cluster_id = ? AND version = ?
+	`, clusterID, version).Scan(&data)
+	if err == sql.ErrNoRows || (err == nil && len(data) == 0) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bundle data: %w", err)
+	}
+	return data, nil
+}
+
+// Delete clears the data column of a bundle row, keeping its metadata.
+func (s *DBBundleStore) Delete(ctx context.Context, clusterID string, version int64) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE config_bundles SET data = X''
+		WHERE cluster_id = ? AND version = ?
+	`, clusterID, version)
+	if err != nil {
+		return fmt.Errorf("failed to clear bundle data: %w", err)
+	}
+	return nil
+}
+
+// FSBundleStore keeps bundle data as files named
+// <dir>/<cluster_id>/<version>.tar.gz.
+type FSBundleStore struct {
+	dir string
+}
+
+// NewFSBundleStore creates a bundle store in dir, creating it if missing.
+//
+// Parameters:
+//   - dir: Directory holding the bundle files
+//
+// Returns:
+//   - *FSBundleStore: The store
+//   - error: Error if dir is empty or cannot be created
+func NewFSBundleStore(dir string) (*FSBundleStore, error) {
+	if dir == "" {
+		return nil, errors.New("bundle directory is required for the filesystem bundle store")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create bundle directory: %w", err)
+	}
+	return &FSBundleStore{dir: dir}, nil
+}
+
+// path returns the file holding a bundle version.
+func (s *FSBundleStore) path(clusterID string, version int64) (string, error) {
+	// Cluster IDs are UUIDs; refuse anything that could escape the directory
+	if clusterID == "" || clusterID == "." || clusterID == ".." || strings.ContainsAny(clusterID, `/\`) {
+		return "", fmt.Errorf("invalid cluster ID %q", clusterID)
+	}
+	return filepath.Join(s.dir, clusterID, BundleArchiveEntryName(version)), nil
+}
+
+// Put writes the bundle file atomically, so readers never see partial data.
+func (s *FSBundleStore) Put(ctx context.Context, clusterID string, version int64, data []byte) error {
+	path, err := s.path(clusterID, version)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic writes data to path through a temporary file in the same
+// directory, creating the directory if needed.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create bundle directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create bundle file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write bundle file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write bundle file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write bundle file: %w", err)
+	}
+	return nil
+}
+
+// Get reads the bundle file.
+func (s *FSBundleStore) Get(ctx context.Context, clusterID string, version int64) ([]byte, error) {
+	path, err := s.path(clusterID, version)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle file: %w", err)
+	}
+	return data, nil
+}
+
+// Delete removes the bundle file.
+func (s *FSBundleStore) Delete(ctx context.Context, clusterID string, version int64) error {
+	path, err := s.path(clusterID, version)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete bundle file: %w", err)
+	}
+	return nil
+}
+
+// ContentBundleStore keeps bundle data content-addressed: each distinct
+// bundle is stored once as a blob keyed by its SHA-256, and every bundle
+// version with that content references the blob. Identical bundles uploaded
+// to several clusters, or re-uploaded as new versions, cost one blob.
+//
+// References live in bundle_blob_refs and go away with their bundle version,
+// including through cascading deletes; database triggers keep each blob's
+// ref_count current. Blobs whose count drops to zero are reclaimed by
+// CollectGarbage.
+type ContentBundleStore struct {
+	db  *sql.DB
+	dir string // "" keeps blob data in bundle_blobs
+}
+
+// NewContentBundleStore creates a content-addressed bundle store.
+//
+// Parameters:
+//   - db: Database connection holding blob metadata and references
+//   - dir: Directory for blob files ("" keeps blob data in the database);
+//     created if missing
+//
+// Returns:
+//   - *ContentBundleStore: The store
+//   - error: Error if the directory cannot be created
+func NewContentBundleStore(db *sql.DB, dir string) (*ContentBundleStore, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create bundle directory: %w", err)
+		}
+	}
+	return &ContentBundleStore{db: db, dir: dir}, nil
+}
+
+// blobPath returns the file holding a blob, fanned out by checksum prefix.
+func (s *ContentBundleStore) blobPath(checksum string) string {
+	return filepath.Join(s.dir, "blobs", checksum[:2], checksum)
+}
+
+// Put stores data for an existing bundle version.
+func (s *ContentBundleStore) Put(ctx context.Context, clusterID string, version int64, data []byte) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.putTx(ctx, tx, clusterID, version, data); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit bundle blob: %w", err)
+	}
+	return nil
+}
+
+func (s *ContentBundleStore) putTx(ctx context.Context, tx *sql.Tx, clusterID string, version int64, data []byte) error {
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	blobData := data
+	if s.dir != "" {
+		blobData = []byte{}
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO bundle_blobs (checksum, data, size) VALUES (?, ?, ?)
+		ON CONFLICT (checksum) DO NOTHING
+	`, checksum, blobData, len(data))
+	if err != nil {
+		return fmt.Errorf("failed to store bundle blob: %w", err)
+	}
+
+	// Only new content is written. The insert above took the write lock, so
+	// garbage collection cannot remove the file before the blob is referenced
+	if s.dir != "" {
+		path := s.blobPath(checksum)
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			if err := writeFileAtomic(path, data); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return fmt.Errorf("failed to check bundle blob: %w", err)
+		}
+	}
+
+	// Replace any earlier reference so a version is only counted once
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM bundle_blob_refs WHERE cluster_id = ? AND version = ?
+	`, clusterID, version)
+	if err != nil {
+		return fmt.Errorf("failed to replace bundle blob reference: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO bundle_blob_refs (tenant_id, cluster_id, version, checksum)
+		SELECT tenant_id, cluster_id, version, ? FROM config_bundles
+		WHERE cluster_id = ? AND version = ?
+	`, checksum, clusterID, version)
+	if err != nil {
+		return fmt.Errorf("failed to reference bundle blob: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// Get returns the blob referenced by a bundle version.
+func (s *ContentBundleStore) Get(ctx context.Context, clusterID string, version int64) ([]byte, error) {
+	var checksum string
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT b.checksum, b.data
+		FROM bundle_blob_refs r
+		JOIN bundle_blobs b ON b.checksum = r.checksum
+		WHERE r.cluster_id = ? AND r.version = ?
+	`, clusterID, version).Scan(&checksum, &data)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bundle blob: %w", err)
+	}
+
+	if s.dir != "" {
+		data, err = os.ReadFile(s.blobPath(checksum))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle blob %s: %w", checksum, err)
+		}
+	}
+	return data, nil
+}
+
+// Delete drops a bundle version's reference. The blob itself stays until
+// CollectGarbage finds it unreferenced.
+func (s *ContentBundleStore) Delete(ctx context.Context, clusterID string, version int64) error {
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM bundle_blob_refs WHERE cluster_id = ? AND version = ?
+	`, clusterID, version)
+	if err != nil {
+		return fmt.Errorf("failed to drop bundle blob reference: %w", err)
+	}
+	return nil
+}
+
+// CollectGarbage removes every blob no bundle version references. Blob files
+// are only deleted once the blob rows are, so a failed collection never loses
+// the file of a blob that is still stored.
+//
+// Parameters:
+//   - ctx: Request context for cancellation
+//
+// Returns:
+//   - int: Number of blobs removed
+//   - error: Error if the blobs could not be removed, or if some of their
+//     files could not be deleted afterwards
+func (s *ContentBundleStore) CollectGarbage(ctx context.Context) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Drop references left behind by bundle versions deleted without
+	// cascading (foreign keys are off on the connection)
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM bundle_blob_refs
+		WHERE NOT EXISTS (
+			SELECT 1 FROM config_bundles b
+			WHERE b.tenant_id = bundle_blob_refs.tenant_id
+			  AND b.cluster_id = bundle_blob_refs.cluster_id
+			  AND b.version = bundle_blob_refs.version
+		)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to drop stale blob references: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		DELETE FROM bundle_blobs WHERE ref_count <= 0
+		RETURNING checksum
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete unreferenced blobs: %w", err)
+	}
+	var checksums []string
+	for rows.Next() {
+		var checksum string
+		if err := rows.Scan(&checksum); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan blob: %w", err)
+		}
+		checksums = append(checksums, checksum)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to delete unreferenced blobs: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit blob deletion: %w", err)
+	}
+
+	if s.dir != "" && len(checksums) > 0 {
+		if err := s.removeBlobFiles(ctx, checksums); err != nil {
+			return len(checksums), err
+		}
+	}
+	return len(checksums), nil
+}
+
+// removeBlobFiles deletes the files of collected blobs. It holds the write
+// lock while doing so: an upload that stored one of the blobs again in the
+// meantime keeps its file, and a later one waits and writes the file anew.
+func (s *ContentBundleStore) removeBlobFiles(ctx context.Context, checksums []string) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `BEGIN IMMEDIATE`); err != nil {
+		return fmt.Errorf("failed to lock bundle blobs: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `ROLLBACK`)
+
+	for _, checksum := range checksums {
+		var stored bool
+		err := conn.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM bundle_blobs WHERE checksum = ?)
+		`, checksum).Scan(&stored)
+		if err != nil {
+			return fmt.Errorf("failed to check bundle blob: %w", err)
+		}
+		if stored {
+			continue
+		}
+		if err := os.Remove(s.blobPath(checksum)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete blob file: %w", err)
+		}
+	}
+	return nil
+}
+
+// BundleMigrationResult summarizes a MigrateBundles run.
+type BundleMigrationResult struct {
+	// Moved is the number of bundles copied to the destination store.
+	Moved int
+
+	// Skipped is the number of bundles the source store did not hold,
+	// usually because an earlier run already moved them.
+	Skipped int
+
+	// Collected is the number of blobs reclaimed from a content-addressed
+	// source store once its bundles were moved.
+	Collected int
+}
+
+// MigrateBundles moves the data of every stored bundle from one store to
+// another, for example from the database to a filesystem store.
+//
+// Each bundle is copied, read back from the destination and checked against
+// its recorded checksum before it is removed from the source, so an
+// interrupted migration can simply be run again. Bundles stored before
+// checksums were recorded get their checksum filled in. Run it while no
+// control plane instance is serving, then restart the instances with the
+// destination store configured.
+//
+// Parameters:
+//   - ctx: Cancels the migration between bundles
+//   - db: Database connection holding the bundle metadata
+//   - from: The store currently holding the data
+//   - to: The store to move the data to
+//   - logger: Zap logger for progress
+//
+// Returns:
+//   - BundleMigrationResult: How many bundles were moved and skipped
+//   - error: Error if a bundle could not be moved; earlier bundles stay moved
+func MigrateBundles(ctx context.Context, db *sql.DB, from, to BundleStore, logger *zap.Logger) (BundleMigrationResult, error) {
+	var result BundleMigrationResult
+
+	type bundleRef struct {
+		clusterID string
+		version   int64
+		checksum  sql.NullString
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT cluster_id, version, checksum FROM config_bundles
+		ORDER BY cluster_id, version
+	`)
+	if err != nil {
+		return result, fmt.Errorf("failed to list bundles: %w", err)
+	}
+	var refs []bundleRef
+	for rows.Next() {
+		var ref bundleRef
+		if err := rows.Scan(&ref.clusterID, &ref.version, &ref.checksum); err != nil {
+			rows.Close()
+			return result, fmt.Errorf("failed to scan bundle: %w", err)
+		}
+		refs = append(refs, ref)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("failed to list bundles: %w", err)
+	}
+
+	for _, ref := range refs {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		data, err := from.Get(ctx, ref.clusterID, ref.version)
+		if errors.Is(err, models.ErrNotFound) {
+			result.Skipped++
+			continue
+		}
+		if err != nil {
+			return result, fmt.Errorf("bundle %s/%d: %w", ref.clusterID, ref.version, err)
+		}
+
+		sum := sha256.Sum256(data)
+		checksum := hex.EncodeToString(sum[:])
+		if ref.checksum.Valid && ref.checksum.String != checksum {
+			return result, fmt.Errorf("bundle %s/%d: data does not match its recorded checksum", ref.clusterID, ref.version)
+		}
+
+		if !ref.checksum.Valid {
+			_, err := db.ExecContext(ctx, `
+				UPDATE config_bundles SET checksum = ?
+				WHERE cluster_id = ? AND version = ?
+			`, checksum, ref.clusterID, ref.version)
+			if err != nil {
+				return result, fmt.Errorf("bundle %s/%d: failed to record checksum: %w", ref.clusterID, ref.version, err)
+			}
+		}
+
+		if err := to.Put(ctx, ref.clusterID, ref.version, data); err != nil {
+			return result, fmt.Errorf("bundle %s/%d: %w", ref.clusterID, ref.version, err)
+		}
+		copied, err := to.Get(ctx, ref.clusterID, ref.version)
+		if err != nil {
+			return result, fmt.Errorf("bundle %s/%d: failed to read back: %w", ref.clusterID, ref.version, err)
+		}
+		if sum := sha256.Sum256(copied); hex.EncodeToString(sum[:]) != checksum {
+			return result, fmt.Errorf("bundle %s/%d: copy does not match the original", ref.clusterID, ref.version)
+		}
+
+		if err := from.Delete(ctx, ref.clusterID, ref.version); err != nil {
+			return result, fmt.Errorf("bundle %s/%d: %w", ref.clusterID, ref.version, err)
+		}
+
+		result.Moved++
+		logger.Debug("bundle migrated",
+			zap.String("cluster_id", ref.clusterID),
+			zap.Int64("version", ref.version),
+			zap.Int("size_bytes", len(data)),
+		)
+	}
+
+	if gc, ok := from.(garbageCollector); ok {
+		if result.Collected, err = gc.CollectGarbage(ctx); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,447 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+	"nebulagc.io/server/internal/testutil"
+)
+
+func TestFSBundleStore(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "bundles")
+	store, err := NewFSBundleStore(dir)
+	if err != nil {
+		t.Fatalf("NewFSBundleStore failed: %v", err)
+	}
+
+	if _, err := store.Get(ctx, "cluster1", 2); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing bundle, got %v", err)
+	}
+
+	if err := store.Put(ctx, "cluster1", 2, []byte("first")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.Put(ctx, "cluster1", 2, []byte("second")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	data, err := store.Get(ctx, "cluster1", 2)
+	if err != nil || string(data) != "second" {
+		t.Errorf("Get = %q, %v; want %q", data, err, "second")
+	}
+
+	// Only the bundle file is left behind, with no temporary files
+	entries, _ := os.ReadDir(filepath.Join(dir, "cluster1"))
+	if len(entries) != 1 || entries[0].Name() != "2.tar.gz" {
+		t.Errorf("Expected only 2.tar.gz in the cluster directory, got %v", entries)
+	}
+
+	if err := store.Delete(ctx, "cluster1", 2); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Delete(ctx, "cluster1", 2); err != nil {
+		t.Errorf("Deleting a missing bundle should succeed, got %v", err)
+	}
+	if _, err := store.Get(ctx, "cluster1", 2); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after Delete, got %v", err)
+	}
+
+	if err := store.Put(ctx, "../escape", 1, []byte("x")); err == nil {
+		t.Error("Expected a cluster ID with a path separator to be rejected")
+	}
+}
+
+func TestOpenBundleStore(t *testing.T) {
+	db := setupBundleTestDB(t)
+	defer db.Close()
+
+	if store, err := OpenBundleStore(BundleStoreDB, db, ""); err != nil {
+		t.Errorf("OpenBundleStore(db) error = %v", err)
+	} else if _, ok := store.(*DBBundleStore); !ok {
+		t.Errorf("OpenBundleStore(db) = %T, want *DBBundleStore", store)
+	}
+	if _, err := OpenBundleStore(BundleStoreFS, db, ""); err == nil {
+		t.Error("Expected the fs store to require a directory")
+	}
+	if _, err := OpenBundleStore("s3", db, ""); err == nil {
+		t.Error("Expected an unknown store to be rejected")
+	}
+}
+
+func TestBundleService_FSStore(t *testing.T) {
+	db := setupBundleTestDB(t)
+	defer db.Close()
+
+	store, err := NewFSBundleStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFSBundleStore failed: %v", err)
+	}
+	service := NewBundleServiceWithStore(db, store, zap.NewNop())
+
+	bundleData := createTestBundle()
+	version, err := uploadVersion(service.Upload("cluster1", bundleData))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	// Only metadata is kept in the database
+	var rowSize, size int
+	if err := db.QueryRow(`SELECT length(data), size FROM config_bundles WHERE version = ?`, version).Scan(&rowSize, &size); err != nil {
+		t.Fatalf("Failed to query bundle row: %v", err)
+	}
+	if rowSize != 0 || size != len(bundleData) {
+		t.Errorf("Row data length %d size %d, want 0 and %d", rowSize, size, len(bundleData))
+	}
+
+	stored, err := store.Get(context.Background(), "cluster1", version)
+	if err != nil || !bytes.Equal(stored, bundleData) {
+		t.Fatalf("Bundle data not in the store: %v", err)
+	}
+
+	data, got, err := service.Download("cluster1", "", 0)
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	if got != version || !bytes.Equal(data, bundleData) {
+		t.Errorf("Download returned version %d and %d bytes, want version %d and the uploaded bundle", got, len(data), version)
+	}
+
+	var buf bytes.Buffer
+	if n, err := service.WriteArchive(context.Background(), "cluster1", 0, &buf); err != nil || n != 1 {
+		t.Errorf("WriteArchive = %d, %v; want 1 version", n, err)
+	}
+}
+
+func TestMigrateBundles(t *testing.T) {
+	ctx := context.Background()
+	db := setupBundleTestDB(t)
+	defer db.Close()
+
+	service := NewBundleService(db, zap.NewNop())
+	bundleData := createTestBundle()
+	for i := 0; i < 2; i++ {
+		if _, err := service.Upload("cluster1", bundleData); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+	// A bundle stored before checksums were recorded
+	if _, err := db.Exec(`UPDATE config_bundles SET checksum = NULL WHERE version = 2`); err != nil {
+		t.Fatalf("Failed to clear checksum: %v", err)
+	}
+
+	dbStore := NewDBBundleStore(db)
+	fsStore, err := NewFSBundleStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFSBundleStore failed: %v", err)
+	}
+
+	result, err := MigrateBundles(ctx, db, dbStore, fsStore, zap.NewNop())
+	if err != nil {
+		t.Fatalf("MigrateBundles failed: %v", err)
+	}
+	if result.Moved != 2 || result.Skipped != 0 {
+		t.Errorf("Result = %+v, want 2 moved", result)
+	}
+
+	var inline, missingChecksums int
+	db.QueryRow(`SELECT COUNT(*) FROM config_bundles WHERE length(data) > 0`).Scan(&inline)
+	db.QueryRow(`SELECT COUNT(*) FROM config_bundles WHERE checksum IS NULL`).Scan(&missingChecksums)
+	if inline != 0 || missingChecksums != 0 {
+		t.Errorf("After migration: %d bundles still inline, %d without checksum", inline, missingChecksums)
+	}
+
+	// The data is served from the new store
+	migrated := NewBundleServiceWithStore(db, fsStore, zap.NewNop())
+	if data, _, err := migrated.Download("cluster1", "", 2); err != nil || !bytes.Equal(data, bundleData) {
+		t.Errorf("Download after migration failed: %v", err)
+	}
+
+	// Running again is a no-op
+	result, err = MigrateBundles(ctx, db, dbStore, fsStore, zap.NewNop())
+	if err != nil || result.Moved != 0 || result.Skipped != 2 {
+		t.Errorf("Second run = %+v, %v; want 2 skipped", result, err)
+	}
+
+	// And the data can be moved back into the database
+	result, err = MigrateBundles(ctx, db, fsStore, dbStore, zap.NewNop())
+	if err != nil || result.Moved != 2 {
+		t.Fatalf("Migrating back = %+v, %v; want 2 moved", result, err)
+	}
+	if data, _, err := service.Download("cluster1", "", 3); err != nil || !bytes.Equal(data, bundleData) {
+		t.Errorf("Download after migrating back failed: %v", err)
+	}
+}
+
+func TestMigrateBundlesChecksumMismatch(t *testing.T) {
+	db := setupBundleTestDB(t)
+	defer db.Close()
+
+	service := NewBundleService(db, zap.NewNop())
+	if _, err := service.Upload("cluster1", createTestBundle()); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE config_bundles SET data = X'00'`); err != nil {
+		t.Fatalf("Failed to corrupt bundle: %v", err)
+	}
+
+	fsStore, err := NewFSBundleStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFSBundleStore failed: %v", err)
+	}
+	result, err := MigrateBundles(context.Background(), db, NewDBBundleStore(db), fsStore, zap.NewNop())
+	if err == nil || result.Moved != 0 {
+		t.Errorf("MigrateBundles = %+v, %v; want a checksum error", result, err)
+	}
+	// The corrupt data is left where it was
+	var length int
+	db.QueryRow(`SELECT length(data) FROM config_bundles`).Scan(&length)
+	if length != 1 {
+		t.Errorf("Expected the source data to be kept, got length %d", length)
+	}
+}
+
+// countBlobs returns the number of blobs and their total reference count.
+func countBlobs(t *testing.T, db *sql.DB) (int, int) {
+	t.Helper()
+	var blobs, refs int
+	if err := db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(ref_count), 0) FROM bundle_blobs`).Scan(&blobs, &refs); err != nil {
+		t.Fatalf("Failed to count blobs: %v", err)
+	}
+	return blobs, refs
+}
+
+func TestContentBundleStore_Dedup(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		dir  bool
+	}{
+		{name: "database"},
+		{name: "directory", dir: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db := testutil.OpenDB(t)
+			tenantID, cluster1, _ := testutil.Seed(t, db, 0)
+			cluster2, _ := testutil.Cluster(t, db, tenantID, "second")
+
+			var dir string
+			if tc.dir {
+				dir = t.TempDir()
+			}
+			store, err := OpenBundleStore(BundleStoreCAS, db, dir)
+			if err != nil {
+				t.Fatalf("OpenBundleStore failed: %v", err)
+			}
+			service := NewBundleServiceWithStore(db, store, zap.NewNop())
+
+			// The same bundle in two versions of one cluster and in another cluster
+			shared := createTestBundle()
+			for _, clusterID := range []string{cluster1, cluster1, cluster2} {
+				if _, err := service.Upload(clusterID, shared); err != nil {
+					t.Fatalf("Upload failed: %v", err)
+				}
+			}
+			if blobs, refs := countBlobs(t, db); blobs != 1 || refs != 3 {
+				t.Errorf("Got %d blobs with %d references, want 1 blob with 3", blobs, refs)
+			}
+
+			other := createTestBundleWithConfig("# second\n")
+			version, err := uploadVersion(service.Upload(cluster2, other))
+			if err != nil {
+				t.Fatalf("Upload failed: %v", err)
+			}
+			if blobs, refs := countBlobs(t, db); blobs != 2 || refs != 4 {
+				t.Errorf("Got %d blobs with %d references, want 2 blobs with 4", blobs, refs)
+			}
+
+			// Neither the bundle rows nor, with a directory, the blob rows hold data
+			var inline int
+			db.QueryRow(`SELECT COUNT(*) FROM config_bundles WHERE length(data) > 0`).Scan(&inline)
+			if inline != 0 {
+				t.Errorf("Expected no inline bundle data, got %d rows", inline)
+			}
+			if tc.dir {
+				db.QueryRow(`SELECT COUNT(*) FROM bundle_blobs WHERE length(data) > 0`).Scan(&inline)
+				if inline != 0 {
+					t.Errorf("Expected blob data on disk only, got %d rows", inline)
+				}
+			}
+
+			if data, _, err := service.Download(cluster1, "", 2); err != nil || !bytes.Equal(data, shared) {
+				t.Errorf("Download of the shared bundle failed: %v", err)
+			}
+			if data, _, err := service.Download(cluster2, "", version); err != nil || !bytes.Equal(data, other) {
+				t.Errorf("Download of the distinct bundle failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestContentBundleStore_GarbageCollection(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.OpenDB(t)
+	tenantID, cluster1, _ := testutil.Seed(t, db, 0)
+	cluster2, _ := testutil.Cluster(t, db, tenantID, "second")
+
+	dir := t.TempDir()
+	store, err := NewContentBundleStore(db, dir)
+	if err != nil {
+		t.Fatalf("NewContentBundleStore failed: %v", err)
+	}
+	service := NewBundleServiceWithStore(db, store, zap.NewNop())
+
+	shared := createTestBundle()
+	old := createTestBundleWithConfig("# old\n")
+	oldVersion, err := uploadVersion(service.Upload(cluster1, old))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	sharedVersion, err := uploadVersion(service.Upload(cluster1, shared))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if _, err := service.Upload(cluster2, shared); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	sum := sha256.Sum256(old)
+	oldPath := store.blobPath(hex.EncodeToString(sum[:]))
+	if _, err := os.Stat(oldPath); err != nil {
+		t.Fatalf("Expected blob file for the old bundle: %v", err)
+	}
+
+	// Referenced blobs are kept
+	if removed, err := service.CollectGarbage(ctx); err != nil || removed != 0 {
+		t.Fatalf("CollectGarbage = %d, %v; want nothing removed", removed, err)
+	}
+
+	// Dropping the only version of a bundle makes its blob collectable
+	if err := store.Delete(ctx, cluster1, oldVersion); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	// A blob still referenced by another cluster survives deleting one version
+	if _, err := db.Exec(`DELETE FROM config_bundles WHERE cluster_id = ? AND version = ?`, cluster1, sharedVersion); err != nil {
+		t.Fatalf("Failed to delete bundle: %v", err)
+	}
+
+	removed, err := service.CollectGarbage(ctx)
+	if err != nil || removed != 1 {
+		t.Fatalf("CollectGarbage = %d, %v; want 1 blob removed", removed, err)
+	}
+	if _, err := os.Stat(oldPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the old blob file to be removed, got %v", err)
+	}
+	if blobs, refs := countBlobs(t, db); blobs != 1 || refs != 1 {
+		t.Errorf("Got %d blobs with %d references, want 1 blob with 1", blobs, refs)
+	}
+	if data, _, err := service.Download(cluster2, "", 0); err != nil || !bytes.Equal(data, shared) {
+		t.Errorf("Download of the shared bundle failed: %v", err)
+	}
+
+	// References of bundle rows deleted without cascading are dropped too
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get connection: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+		t.Fatalf("Failed to disable foreign keys: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, `DELETE FROM config_bundles WHERE cluster_id = ?`, cluster2); err != nil {
+		t.Fatalf("Failed to delete bundles: %v", err)
+	}
+	conn.Close()
+	if _, refs := countBlobs(t, db); refs != 1 {
+		t.Fatalf("Expected the reference to outlive its bundle, got %d references", refs)
+	}
+	if removed, err := service.CollectGarbage(ctx); err != nil || removed != 1 {
+		t.Errorf("CollectGarbage = %d, %v; want the orphaned blob removed", removed, err)
+	}
+	if blobs, _ := countBlobs(t, db); blobs != 0 {
+		t.Errorf("Expected no blobs left, got %d", blobs)
+	}
+}
+
+func TestContentBundleStore_GarbageCollectionKeepsReaddedBlob(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.OpenDB(t)
+	_, clusterID, _ := testutil.Seed(t, db, 0)
+
+	store, err := NewContentBundleStore(db, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewContentBundleStore failed: %v", err)
+	}
+	service := NewBundleServiceWithStore(db, store, zap.NewNop())
+
+	data := createTestBundle()
+	version, err := uploadVersion(service.Upload(clusterID, data))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	// Collect the blob's row, then store the same content again before the
+	// collection gets to its file, as a concurrent upload would
+	if err := store.Delete(ctx, clusterID, version); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := db.Exec(`DELETE FROM bundle_blobs WHERE checksum = ?`, checksum); err != nil {
+		t.Fatalf("Failed to delete blob: %v", err)
+	}
+	if err := store.Put(ctx, clusterID, version, data); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.removeBlobFiles(ctx, []string{checksum}); err != nil {
+		t.Fatalf("removeBlobFiles failed: %v", err)
+	}
+
+	if _, err := os.Stat(store.blobPath(checksum)); err != nil {
+		t.Fatalf("Expected the stored blob to keep its file: %v", err)
+	}
+	if got, err := store.Get(ctx, clusterID, version); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("Get after collection failed: %v", err)
+	}
+}
+
+func TestMigrateBundlesToContentStore(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.OpenDB(t)
+	_, clusterID, _ := testutil.Seed(t, db, 0)
+
+	service := NewBundleService(db, zap.NewNop())
+	bundleData := createTestBundle()
+	for i := 0; i < 2; i++ {
+		if _, err := service.Upload(clusterID, bundleData); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+
+	cas, err := NewContentBundleStore(db, "")
+	if err != nil {
+		t.Fatalf("NewContentBundleStore failed: %v", err)
+	}
+	dbStore := NewDBBundleStore(db)
+	if result, err := MigrateBundles(ctx, db, dbStore, cas, zap.NewNop()); err != nil || result.Moved != 2 {
+		t.Fatalf("MigrateBundles = %+v, %v; want 2 moved", result, err)
+	}
+	if blobs, refs := countBlobs(t, db); blobs != 1 || refs != 2 {
+		t.Errorf("Got %d blobs with %d references, want 1 blob with 2", blobs, refs)
+	}
+
+	// Moving back out leaves the blob unreferenced, and it is collected
+	result, err := MigrateBundles(ctx, db, cas, dbStore, zap.NewNop())
+	if err != nil || result.Moved != 2 || result.Collected != 1 {
+		t.Fatalf("MigrateBundles = %+v, %v; want 2 moved and 1 collected", result, err)
+	}
+	if data, _, err := service.Download(clusterID, "", 0); err != nil || !bytes.Equal(data, bundleData) {
+		t.Errorf("Download after migrating back failed: %v", err)
+	}
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+)
+
+// blobChunkSize is how much of a BLOB column is read per query when
+// streaming bundle data out of the database.
+const blobChunkSize = 256 * 1024
+
+// bundleOpener is implemented by stores that can stream bundle data instead
+// of loading a whole bundle into memory.
+type bundleOpener interface {
+	// Open returns a reader over the data of a bundle version and its size,
+	// or ErrNotFound if the store does not hold it.
+	Open(ctx context.Context, clusterID string, version int64) (io.ReadSeekCloser, int64, error)
+}
+
+// BundleStream is the data of a bundle version opened for reading. It must
+// be closed after use.
+type BundleStream struct {
+	io.ReadSeekCloser
+
+	// Size is the bundle's size in bytes
+	Size int64
+
+	// Checksum is the SHA-256 of the bundle (hex)
+	Checksum string
+
+	// Signature is the uploader's Ed25519 signature (nil if unsigned)
+	Signature []byte
+}
+
+// DownloadStream opens a config bundle for streaming instead of loading it
+// into memory. Versions are resolved exactly as in Download.
+//
+// Stores that support it (all built-in stores) are read incrementally;
+// database-backed data is read in blobChunkSize pieces, so memory use does
+// not grow with the bundle size or the number of concurrent downloads. The
+// stream is seekable, which lets callers serve byte ranges.
+//
+// Parameters:
+//   - clusterID: The cluster ID
+//   - nodeID: The requesting node's ID ("" if not authenticated as a node)
+//   - version: The version to retrieve (0 for latest)
+//
+// Returns:
+//   - *BundleStream: The bundle data, to be closed by the caller
+//   - int64: The bundle version
+//   - error: Any error that occurred
+func (s *BundleService) DownloadStream(clusterID, nodeID string, version int64) (*BundleStream, int64, error) {
+	meta, err := s.resolveDownload(clusterID, nodeID, version)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ctx := context.Background()
+	var stream io.ReadSeekCloser
+	var size int64
+	if opener, ok := s.store.(bundleOpener); ok {
+		stream, size, err = opener.Open(ctx, clusterID, meta.Version)
+	} else {
+		var data []byte
+		data, err = s.store.Get(ctx, clusterID, meta.Version)
+		stream, size = nopSeekCloser{bytes.NewReader(data)}, int64(len(data))
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load bundle data: %w", err)
+	}
+
+	// Bundles uploaded before checksums were recorded are hashed on the fly
+	checksum := meta.Checksum
+	if checksum == "" {
+		h := sha256.New()
+		if _, err := io.Copy(h, stream); err != nil {
+			stream.Close()
+			return nil, 0, fmt.Errorf("failed to hash bundle data: %w", err)
+		}
+		if _, err := stream.Seek(0, io.SeekStart); err != nil {
+			stream.Close()
+			return nil, 0, fmt.Errorf("failed to rewind bundle data: %w", err)
+		}
+		checksum = hex.EncodeToString(h.Sum(nil))
+	}
+
+	s.logger.Debug("config bundle download started",
+		zap.String("cluster_id", clusterID),
+		zap.Int64("version", meta.Version),
+		zap.Int64("size_bytes", size),
+	)
+
+	return &BundleStream{
+		ReadSeekCloser: stream,
+		Size:           size,
+		Checksum:       checksum,
+		Signature:      meta.Signature,
+	}, meta.Version, nil
+}
+
+// resolveDownload returns the metadata of the bundle a node downloads when
+// asking for version (0 for latest), applying scheduled activations and
+// canaries.
+func (s *BundleService) resolveDownload(clusterID, nodeID string, version int64) (*models.ConfigBundle, error) {
+	current, err := effectiveVersion(s.db, clusterID, nodeID, s.now())
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("cluster not found: %s", clusterID)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get version: %w", err)
+	}
+	if version > current {
+		return nil, fmt.Errorf("bundle version %d not found for cluster: %s", version, clusterID)
+	}
+
+	var where string
+	var args []interface{}
+
+	if version == 0 {
+		// Get latest version
+		where = `b.cluster_id = ? AND b.version <= ? ORDER BY b.version DESC LIMIT 1`
+		args = []interface{}{clusterID, current}
+	} else {
+		// Get specific version
+		where = `b.cluster_id = ? AND b.version = ?`
+		args = []interface{}{clusterID, version}
+	}
+
+	meta, err := s.queryBundle(false, where, args...)
+	if err == sql.ErrNoRows {
+		if version == 0 {
+			return nil, fmt.Errorf("no bundles found for cluster: %s", clusterID)
+		}
+		return nil, fmt.Errorf("bundle version %d not found for cluster: %s", version, clusterID)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to download bundle: %w", err)
+	}
+
+	return meta, nil
+}
+
+// nopSeekCloser adds a no-op Close to an in-memory reader.
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+// Close does nothing.
+func (nopSeekCloser) Close() error { return nil }
+
+// blobReader reads a BLOB column in chunks, one query per chunk, so a large
+// value is never held in memory at once. query selects
+// substr(<column>, ?, ?) for a single row; its first two arguments are the
+// 1-based offset and the chunk length, followed by args.
+type blobReader struct {
+	ctx    context.Context
+	db     *sql.DB
+	query  string
+	args   []interface{}
+	size   int64
+	offset int64
+	buf    []byte
+}
+
+// newBlobReader creates a reader over a BLOB of the given size.
+func newBlobReader(ctx context.Context, db *sql.DB, size int64, query string, args ...interface{}) *blobReader {
+	return &blobReader{ctx: ctx, db: db, query: query, args: args, size: size}
+}
+
+// Read implements io.Reader.
+func (r *blobReader) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		if r.offset >= r.size {
+			return 0, io.EOF
+		}
+		length := min(int64(blobChunkSize), r.size-r.offset)
+		args := append([]interface{}{r.offset + 1, length}, r.args...)
+		var chunk []byte
+		if err := r.db.QueryRowContext(r.ctx, r.query, args...).Scan(&chunk); err != nil {
+			return 0, fmt.Errorf("failed to read bundle data: %w", err)
+		}
+		if len(chunk) == 0 {
+			// The row changed underneath the reader
+			return 0, io.ErrUnexpectedEOF
+		}
+		r.buf = chunk
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.offset += int64(n)
+	return n, nil
+}
+
+// Seek implements io.Seeker.
+func (r *blobReader) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = r.offset + offset
+	case io.SeekEnd:
+		abs = r.size + offset
+	default:
+		return 0, errors.New("blobReader.Seek: invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("blobReader.Seek: negative position")
+	}
+	if abs != r.offset {
+		r.buf = nil
+		r.offset = abs
+	}
+	return abs, nil
+}
+
+// Close implements io.Closer.
+func (r *blobReader) Close() error {
+	return nil
+}
+
+// Open streams the data column of a bundle row.
+func (s *DBBundleStore) Open(ctx context.Context, clusterID string, version int64) (io.ReadSeekCloser, int64, error) {
+	var size int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT length(data) FROM config_bundles
+		WHERE cluster_id = ? AND version = ?
+	`, clusterID, version).Scan(&size)
+	if err == sql.ErrNoRows || (err == nil && size == 0) {
+		return nil, 0, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load bundle data: %w", err)
+	}
+
+	return newBlobReader(ctx, s.db, size, `
+		SELECT substr(data, ?, ?) FROM config_bundles
+		WHERE cluster_id = ? AND version = ?
+	`, clusterID, version), size, nil
+}
+
+// Open opens the bundle file.
+func (s *FSBundleStore) Open(ctx context.Context, clusterID string, version int64) (io.ReadSeekCloser, int64, error) {
+	path, err := s.path(clusterID, version)
+	if err != nil {
+		return nil, 0, err
+	}
+	return openFile(path)
+}
+
+// Open streams the blob referenced by a bundle version.
+func (s *ContentBundleStore) Open(ctx context.Context, clusterID string, version int64) (io.ReadSeekCloser, int64, error) {
+	var checksum string
+	var size int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT b.checksum, b.size
+		FROM bundle_blob_refs r
+		JOIN bundle_blobs b ON b.checksum = r.checksum
+		WHERE r.cluster_id = ? AND r.version = ?
+	`, clusterID, version).Scan(&checksum, &size)
+	if err == sql.ErrNoRows {
+		return nil, 0, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load bundle blob: %w", err)
+	}
+
+	if s.dir != "" {
+		return openFile(s.blobPath(checksum))
+	}
+	return newBlobReader(ctx, s.db, size, `
+		SELECT substr(data, ?, ?) FROM bundle_blobs WHERE checksum = ?
+	`, checksum), size, nil
+}
+
+// openFile opens a bundle file and returns it with its size.
+func openFile(path string) (io.ReadSeekCloser, int64, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open bundle file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, fmt.Errorf("failed to open bundle file: %w", err)
+	}
+	return f, info.Size(), nil
+}
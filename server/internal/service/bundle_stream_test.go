@@ -0,0 +1,131 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"go.uber.org/zap"
+	"nebulagc.io/server/internal/testutil"
+)
+
+func TestBundleService_DownloadStream(t *testing.T) {
+	// Random content spans several blobChunkSize reads even once compressed
+	random := make([]byte, 512*1024)
+	if _, err := rand.Read(random); err != nil {
+		t.Fatalf("Failed to generate data: %v", err)
+	}
+	bundleData := createTestBundleWithConfig("# " + hex.EncodeToString(random) + "\n")
+	if len(bundleData) <= 2*blobChunkSize {
+		t.Fatalf("Test bundle is %d bytes, want more than two chunks", len(bundleData))
+	}
+	sum := sha256.Sum256(bundleData)
+
+	for _, tc := range []struct {
+		name string
+		kind string
+		dir  bool
+	}{
+		{name: "database", kind: BundleStoreDB},
+		{name: "filesystem", kind: BundleStoreFS, dir: true},
+		{name: "content database", kind: BundleStoreCAS},
+		{name: "content directory", kind: BundleStoreCAS, dir: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db := testutil.OpenDB(t)
+			_, clusterID, _ := testutil.Seed(t, db, 0)
+
+			var dir string
+			if tc.dir {
+				dir = t.TempDir()
+			}
+			store, err := OpenBundleStore(tc.kind, db, dir)
+			if err != nil {
+				t.Fatalf("OpenBundleStore failed: %v", err)
+			}
+			service := NewBundleServiceWithStore(db, store, zap.NewNop())
+
+			if _, err := service.Upload(clusterID, createTestBundle()); err != nil {
+				t.Fatalf("Upload failed: %v", err)
+			}
+			latest, err := uploadVersion(service.Upload(clusterID, bundleData))
+			if err != nil {
+				t.Fatalf("Upload failed: %v", err)
+			}
+
+			stream, version, err := service.DownloadStream(clusterID, "", 0)
+			if err != nil {
+				t.Fatalf("DownloadStream failed: %v", err)
+			}
+			defer stream.Close()
+
+			if version != latest {
+				t.Errorf("Expected version %d, got %d", latest, version)
+			}
+			if stream.Size != int64(len(bundleData)) {
+				t.Errorf("Expected size %d, got %d", len(bundleData), stream.Size)
+			}
+			if stream.Checksum != hex.EncodeToString(sum[:]) {
+				t.Errorf("Checksum = %s, want %x", stream.Checksum, sum)
+			}
+
+			data, err := io.ReadAll(stream)
+			if err != nil {
+				t.Fatalf("Failed to read stream: %v", err)
+			}
+			if !bytes.Equal(data, bundleData) {
+				t.Fatal("Streamed data does not match the uploaded bundle")
+			}
+
+			// Seeking resumes mid-bundle, as a Range request does
+			offset := int64(blobChunkSize + 100)
+			if _, err := stream.Seek(offset, io.SeekStart); err != nil {
+				t.Fatalf("Seek failed: %v", err)
+			}
+			tail, err := io.ReadAll(stream)
+			if err != nil {
+				t.Fatalf("Failed to read after seek: %v", err)
+			}
+			if !bytes.Equal(tail, bundleData[offset:]) {
+				t.Error("Data after seek does not match the uploaded bundle")
+			}
+		})
+	}
+}
+
+func TestBundleService_DownloadStreamWithoutChecksum(t *testing.T) {
+	db := setupBundleTestDB(t)
+	defer db.Close()
+
+	service := NewBundleService(db, zap.NewNop())
+	bundleData := createTestBundle()
+	version, err := uploadVersion(service.Upload("cluster1", bundleData))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	// A bundle stored before checksums were recorded
+	if _, err := db.Exec(`UPDATE config_bundles SET checksum = NULL`); err != nil {
+		t.Fatalf("Failed to clear checksum: %v", err)
+	}
+
+	stream, _, err := service.DownloadStream("cluster1", "", version)
+	if err != nil {
+		t.Fatalf("DownloadStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	sum := sha256.Sum256(bundleData)
+	if stream.Checksum != hex.EncodeToString(sum[:]) {
+		t.Errorf("Checksum = %s, want %x", stream.Checksum, sum)
+	}
+	if data, err := io.ReadAll(stream); err != nil || !bytes.Equal(data, bundleData) {
+		t.Errorf("Streamed data does not match the uploaded bundle: %v", err)
+	}
+
+	if _, _, err := service.DownloadStream("cluster1", "", version+1); err == nil {
+		t.Error("Expected error for a version that does not exist")
+	}
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+)
+
+// canaryBaselineAge is how recent a node's last "ok" heartbeat must be for
+// the node to count as healthy when a canary is defined.
+const canaryBaselineAge = 5 * time.Minute
+
+// SetCanary makes a bundle version available only to the given nodes.
+//
+// Every other node of the cluster stays on the stable version, the version
+// before the canary, until the canary is promoted. The version must be the
+// latest uploaded bundle; nodes that poll between the upload and this call
+// may already have received it, so new bundles should be uploaded with
+// UploadOptions.CanaryNodeIDs instead. Defining a canary replaces any existing
+// canary for the cluster (including a rolled back one) but keeps its stable
+// version, so a replaced canary version is never released to other nodes.
+//
+// Each node's current health is recorded as its pre-rollout baseline.
+//
+// Parameters:
+//   - clusterID: The cluster ID
+//   - version: The bundle version to roll out
+//   - nodeIDs: The canary nodes, all belonging to the cluster
+//
+// Returns:
+//   - ErrInvalidRequest if the version is not the latest bundle or a node is
+//     not part of the cluster, or error if a query fails
+func (s *BundleService) SetCanary(clusterID string, version int64, nodeIDs []string) error {
+	if len(nodeIDs) == 0 {
+		return fmt.Errorf("%w: at least one canary node is required", models.ErrInvalidRequest)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var latest sql.NullInt64
+	err = tx.QueryRow(`SELECT MAX(version) FROM config_bundles WHERE cluster_id = ?`, clusterID).Scan(&latest)
+	if err != nil {
+		return fmt.Errorf("failed to query latest bundle: %w", err)
+	}
+	if !latest.Valid || latest.Int64 != version {
+		return fmt.Errorf("%w: version %d is not the latest bundle", models.ErrInvalidRequest, version)
+	}
+
+	if err := s.defineCanary(tx, clusterID, version, nodeIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.logger.Info("canary rollout defined",
+		zap.String("cluster_id", clusterID),
+		zap.Int64("version", version),
+		zap.Int("nodes", len(nodeIDs)),
+	)
+
+	return nil
+}
+
+// defineCanary stores the canary rollout of version to nodeIDs within tx,
+// recording each node's current health as its pre-rollout baseline.
+//
+// Returns:
+//   - ErrInvalidRequest if a node is not part of the cluster, or error if a
+//     query fails
+func (s *BundleService) defineCanary(tx *sql.Tx, clusterID string, version int64, nodeIDs []string) error {
+	// Replace any existing canary and its nodes, keeping its stable version
+	if _, err := tx.Exec(`DELETE FROM canary_nodes WHERE cluster_id = ?`, clusterID); err != nil {
+		return fmt.Errorf("failed to clear canary nodes: %w", err)
+	}
+	now := s.now()
+	_, err := tx.Exec(`
+		INSERT INTO canary_rollouts (cluster_id, version, stable_version, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(cluster_id) DO UPDATE SET
+			version = excluded.version,
+			created_at = excluded.created_at,
+			rolled_back_at = NULL,
+			rollback_reason = NULL
+	`, clusterID, version, version-1, now.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to store canary: %w", err)
+	}
+
+	baseline := now.Add(-canaryBaselineAge).Unix()
+	for _, nodeID := range nodeIDs {
+		var exists, healthy bool
+		err := tx.QueryRow(`
+			SELECT COUNT(*) > 0,
+				COALESCE(MAX(health_status = ? AND last_heartbeat_at >= ?), 0)
+			FROM nodes
+			WHERE id = ? AND cluster_id = ? AND deleted_at IS NULL
+		`, models.NodeHealthOK, baseline, nodeID, clusterID).Scan(&exists, &healthy)
+		if err != nil {
+			return fmt.Errorf("failed to query node: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("%w: node %s is not part of the cluster", models.ErrInvalidRequest, nodeID)
+		}
+
+		_, err = tx.Exec(`
+			INSERT OR IGNORE INTO canary_nodes (cluster_id, node_id, healthy_before) VALUES (?, ?, ?)
+		`, clusterID, nodeID, boolToInt(healthy))
+		if err != nil {
+			return fmt.Errorf("failed to store canary node: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetCanary returns the cluster's canary rollout, active or rolled back.
+//
+// Parameters:
+//   - clusterID: The cluster ID
+//
+// Returns:
+//   - The canary rollout
+//   - ErrNotFound if the cluster has no canary, or error if a query fails
+func (s *BundleService) GetCanary(clusterID string) (*models.CanaryRollout, error) {
+	canary := &models.CanaryRollout{ClusterID: clusterID, NodeIDs: []string{}}
+
+	var createdAt int64
+	var rolledBackAt sql.NullInt64
+	var reason sql.NullString
+	err := s.db.QueryRow(`
+		SELECT version, stable_version, created_at, rolled_back_at, rollback_reason
+		FROM canary_rollouts WHERE cluster_id = ?
+	`, clusterID).Scan(&canary.Version, &canary.StableVersion, &createdAt, &rolledBackAt, &reason)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query canary: %w", err)
+	}
+	canary.CreatedAt = time.Unix(createdAt, 0).UTC()
+	if rolledBackAt.Valid {
+		t := time.Unix(rolledBackAt.Int64, 0).UTC()
+		canary.RolledBackAt = &t
+		canary.RollbackReason = reason.String
+	}
+
+	rows, err := s.db.Query(`
+		SELECT node_id FROM canary_nodes WHERE cluster_id = ? ORDER BY node_id
+	`, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query canary nodes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var nodeID string
+		if err := rows.Scan(&nodeID); err != nil {
+			return nil, fmt.Errorf("failed to scan canary node: %w", err)
+		}
+		canary.NodeIDs = append(canary.NodeIDs, nodeID)
+	}
+
+	return canary, rows.Err()
+}
+
+// PromoteCanary ends the cluster's canary, making its version available to
+// every node.
+//
+// A rolled back canary cannot be promoted; define a new canary with a fixed
+// bundle instead.
+//
+// Parameters:
+//   - clusterID: The cluster ID
+//
+// Returns:
+//   - The promoted version
+//   - ErrNotFound if the cluster has no canary, ErrInvalidRequest if it was
+//     rolled back, or error if a query fails
+func (s *BundleService) PromoteCanary(clusterID string) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var version int64
+	var rolledBackAt sql.NullInt64
+	err = tx.QueryRow(`
+		DELETE FROM canary_rollouts WHERE cluster_id = ? RETURNING version, rolled_back_at
+	`, clusterID).Scan(&version, &rolledBackAt)
+	if err == sql.ErrNoRows {
+		return 0, models.ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete canary: %w", err)
+	}
+	if rolledBackAt.Valid {
+		return 0, fmt.Errorf("%w: canary version %d was rolled back and cannot be promoted",
+			models.ErrInvalidRequest, version)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM canary_nodes WHERE cluster_id = ?`, clusterID); err != nil {
+		return 0, fmt.Errorf("failed to delete canary nodes: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.logger.Info("canary rollout promoted",
+		zap.String("cluster_id", clusterID),
+		zap.Int64("version", version),
+	)
+
+	return version, nil
+}
+
+// ListActiveCanaries returns all canaries that have not been rolled back.
+// NodeIDs is not populated; use CanaryNodeHealth for per-node state.
+//
+// Returns:
+//   - Active canaries across all clusters
+//   - Error if the query fails
+func (s *BundleService) ListActiveCanaries() ([]models.CanaryRollout, error) {
+	rows, err := s.db.Query(`
+		SELECT cluster_id, version, stable_version, created_at
+		FROM canary_rollouts
+		WHERE rolled_back_at IS NULL
+		ORDER BY cluster_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query canaries: %w", err)
+	}
+	defer rows.Close()
+
+	var canaries []models.CanaryRollout
+	for rows.Next() {
+		var canary models.CanaryRollout
+		var createdAt int64
+		if err := rows.Scan(&canary.ClusterID, &canary.Version, &canary.StableVersion, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan canary: %w", err)
+		}
+		canary.CreatedAt = time.Unix(createdAt, 0).UTC()
+		canaries = append(canaries, canary)
+	}
+
+	return canaries, rows.Err()
+}
+
+// CanaryNodeHealth returns the last reported health of each canary node.
+//
+// Parameters:
+//   - clusterID: The cluster ID
+//
+// Returns:
+//   - Health of each canary node, ordered by node ID
+//   - Error if the query fails
+func (s *BundleService) CanaryNodeHealth(clusterID string) ([]models.CanaryNodeHealth, error) {
+	rows, err := s.db.Query(`
+		SELECT cn.node_id, cn.healthy_before, n.last_heartbeat_at, n.reported_version,
+			n.health_status, n.health_message
+		FROM canary_nodes cn
+		JOIN nodes n ON n.id = cn.node_id
+		WHERE cn.cluster_id = ? AND n.deleted_at IS NULL
+		ORDER BY cn.node_id
+	`, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query canary node health: %w", err)
+	}
+	defer rows.Close()
+
+	var health []models.CanaryNodeHealth
+	for rows.Next() {
+		var h models.CanaryNodeHealth
+		var heartbeat, version sql.NullInt64
+		var status, message sql.NullString
+		if err := rows.Scan(&h.NodeID, &h.HealthyBefore, &heartbeat, &version, &status, &message); err != nil {
+			return nil, fmt.Errorf("failed to scan canary node health: %w", err)
+		}
+		if heartbeat.Valid {
+			t := time.Unix(heartbeat.Int64, 0).UTC()
+			h.LastHeartbeatAt = &t
+		}
+		h.ReportedVersion = version.Int64
+		h.Status = status.String
+		h.Message = message.String
+		health = append(health, h)
+	}
+
+	return health, rows.Err()
+}
+
+// RollbackCanary reverts a canary: its nodes are removed, so every node is
+// served the stable version again, and the rollback is recorded with reason.
+//
+// The canary stays in place, holding the cluster at its stable version, until
+// an admin defines a new canary. The rollback only applies while the cluster's
+// canary is still at version, so a canary replaced concurrently is untouched.
+//
+// Parameters:
+//   - clusterID: The cluster ID
+//   - version: The canary version being rolled back
+//   - reason: Why the canary failed
+//
+// Returns:
+//   - ErrNotFound if no active canary at version exists, or error if a query fails
+func (s *BundleService) RollbackCanary(clusterID string, version int64, reason string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE canary_rollouts
+		SET rolled_back_at = ?, rollback_reason = ?
+		WHERE cluster_id = ? AND version = ? AND rolled_back_at IS NULL
+	`, s.now().Unix(), reason, clusterID, version)
+	if err != nil {
+		return fmt.Errorf("failed to roll back canary: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rollback result: %w", err)
+	}
+	if rows == 0 {
+		return models.ErrNotFound
+	}
+
+	if _, err := tx.Exec(`DELETE FROM canary_nodes WHERE cluster_id = ?`, clusterID); err != nil {
+		return fmt.Errorf("failed to delete canary nodes: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.logger.Warn("canary rollout rolled back",
+		zap.String("cluster_id", clusterID),
+		zap.Int64("version", version),
+		zap.String("reason", reason),
+	)
+
+	return nil
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+)
+
+func TestBundleService_CanaryRollout(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	service := NewBundleService(db, zap.NewNop())
+
+	stable, err := uploadVersion(service.Upload("cluster1", createTestBundle()))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	canary, err := uploadVersion(service.Upload("cluster1", createTestBundle()))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	if err := service.SetCanary("cluster1", canary, []string{"node1"}); err != nil {
+		t.Fatalf("SetCanary failed: %v", err)
+	}
+
+	assertTarget := func(nodeID string, want int64) {
+		t.Helper()
+
+		version, err := service.GetCurrentVersion("cluster1", nodeID)
+		if err != nil {
+			t.Fatalf("GetCurrentVersion failed: %v", err)
+		}
+		if version != want {
+			t.Errorf("Node %q: expected version %d, got %d", nodeID, want, version)
+		}
+
+		_, downloaded, err := service.Download("cluster1", nodeID, 0)
+		if err != nil {
+			t.Fatalf("Download failed: %v", err)
+		}
+		if downloaded != want {
+			t.Errorf("Node %q: expected bundle %d, got %d", nodeID, want, downloaded)
+		}
+	}
+
+	assertTarget("node1", canary)
+	assertTarget("node2", stable)
+	assertTarget("", stable) // cluster token callers are never canaries
+
+	// Non-canary nodes cannot fetch the canary version directly
+	if _, _, err := service.Download("cluster1", "node2", canary); err == nil {
+		t.Error("Expected canary version to be unavailable to non-canary node")
+	}
+
+	// The canary node is current; the stable node is too, at its own version
+	if current, _, _ := service.CheckVersion("cluster1", "node1", canary); !current {
+		t.Error("Expected canary node to be current at the canary version")
+	}
+	if current, _, _ := service.CheckVersion("cluster1", "node2", stable); !current {
+		t.Error("Expected non-canary node to be current at the stable version")
+	}
+
+	got, err := service.GetCanary("cluster1")
+	if err != nil {
+		t.Fatalf("GetCanary failed: %v", err)
+	}
+	if got.Version != canary || len(got.NodeIDs) != 1 || got.NodeIDs[0] != "node1" {
+		t.Errorf("Unexpected canary %+v", got)
+	}
+
+	promoted, err := service.PromoteCanary("cluster1")
+	if err != nil {
+		t.Fatalf("PromoteCanary failed: %v", err)
+	}
+	if promoted != canary {
+		t.Errorf("Expected promoted version %d, got %d", canary, promoted)
+	}
+
+	assertTarget("node2", canary)
+	assertTarget("", canary)
+
+	if _, err := service.GetCanary("cluster1"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after promotion, got %v", err)
+	}
+}
+
+func TestBundleService_UploadWithCanary(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	service := NewBundleService(db, zap.NewNop())
+
+	stable, err := uploadVersion(service.Upload("cluster1", createTestBundle()))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	// The bundle is held back from other nodes from the moment it is stored
+	canary, err := uploadVersion(service.UploadWithOptions("cluster1", createTestBundle(),
+		UploadOptions{CanaryNodeIDs: []string{"node1"}}))
+	if err != nil {
+		t.Fatalf("UploadWithOptions failed: %v", err)
+	}
+	for nodeID, want := range map[string]int64{"node1": canary, "node2": stable, "": stable} {
+		if version, _ := service.GetCurrentVersion("cluster1", nodeID); version != want {
+			t.Errorf("Node %q: expected version %d, got %d", nodeID, want, version)
+		}
+	}
+
+	// A rejected canary rejects the upload with it
+	_, err = service.UploadWithOptions("cluster1", createTestBundle(),
+		UploadOptions{CanaryNodeIDs: []string{"node1", "missing"}})
+	if !errors.Is(err, models.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for a node outside the cluster, got %v", err)
+	}
+	_, err = service.UploadWithOptions("cluster1", createTestBundle(),
+		UploadOptions{CanaryNodeIDs: []string{"node1"}, EffectiveAt: time.Now().Add(time.Hour)})
+	if !errors.Is(err, models.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for a scheduled canary, got %v", err)
+	}
+
+	got, err := service.GetCanary("cluster1")
+	if err != nil {
+		t.Fatalf("GetCanary failed: %v", err)
+	}
+	if got.Version != canary || got.StableVersion != stable {
+		t.Errorf("Expected canary %d over stable %d, got %+v", canary, stable, got)
+	}
+	if version, _ := service.GetCurrentVersion("cluster1", "node2"); version != stable {
+		t.Errorf("Expected failed uploads to leave node2 at %d, got %d", stable, version)
+	}
+}
+
+func TestBundleService_SetCanaryValidation(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	service := NewBundleService(db, zap.NewNop())
+
+	first, err := uploadVersion(service.Upload("cluster1", createTestBundle()))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	latest, err := uploadVersion(service.Upload("cluster1", createTestBundle()))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		version int64
+		nodeIDs []string
+	}{
+		{"not latest version", first, []string{"node1"}},
+		{"unknown version", latest + 1, []string{"node1"}},
+		{"no nodes", latest, nil},
+		{"node outside cluster", latest, []string{"node1", "missing"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.SetCanary("cluster1", tt.version, tt.nodeIDs)
+			if !errors.Is(err, models.ErrInvalidRequest) {
+				t.Errorf("Expected ErrInvalidRequest, got %v", err)
+			}
+		})
+	}
+
+	// A rejected canary leaves no partial rollout behind
+	if _, err := service.GetCanary("cluster1"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Expected no canary after failed definitions, got %v", err)
+	}
+	if _, err := service.PromoteCanary("cluster1"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound when promoting without a canary, got %v", err)
+	}
+}
+
+func TestBundleService_CanaryRollback(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	service := NewBundleService(db, zap.NewNop())
+	nodes := NewNodeService(db, zap.NewNop(), "secret-should-be-long-enough-123456")
+
+	// node1 is healthy before the rollout, node2 has never reported
+	err := nodes.RecordHeartbeat(context.Background(), "tenant1", "cluster1", "node1",
+		&models.NodeHeartbeatRequest{Version: 1, Status: models.NodeHealthOK})
+	if err != nil {
+		t.Fatalf("RecordHeartbeat failed: %v", err)
+	}
+
+	stable, err := uploadVersion(service.Upload("cluster1", createTestBundle()))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	first, err := uploadVersion(service.Upload("cluster1", createTestBundle()))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := service.SetCanary("cluster1", first, []string{"node1"}); err != nil {
+		t.Fatalf("SetCanary failed: %v", err)
+	}
+
+	// Replacing the canary keeps the original stable version
+	canary, err := uploadVersion(service.Upload("cluster1", createTestBundle()))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := service.SetCanary("cluster1", canary, []string{"node1", "node2"}); err != nil {
+		t.Fatalf("SetCanary failed: %v", err)
+	}
+	if version, _ := service.GetCurrentVersion("cluster1", "node3"); version != stable {
+		t.Errorf("Expected non-canary node at stable version %d, got %d", stable, version)
+	}
+
+	health, err := service.CanaryNodeHealth("cluster1")
+	if err != nil {
+		t.Fatalf("CanaryNodeHealth failed: %v", err)
+	}
+	if len(health) != 2 || !health[0].HealthyBefore || health[1].HealthyBefore {
+		t.Fatalf("Unexpected baseline %+v", health)
+	}
+
+	err = nodes.RecordHeartbeat(context.Background(), "tenant1", "cluster1", "node1",
+		&models.NodeHeartbeatRequest{Version: canary, Status: models.NodeHealthError, Message: "nebula failed to start"})
+	if err != nil {
+		t.Fatalf("RecordHeartbeat failed: %v", err)
+	}
+	health, err = service.CanaryNodeHealth("cluster1")
+	if err != nil {
+		t.Fatalf("CanaryNodeHealth failed: %v", err)
+	}
+	if health[0].ReportedVersion != canary || health[0].Status != models.NodeHealthError || health[0].LastHeartbeatAt == nil {
+		t.Errorf("Expected reported error at version %d, got %+v", canary, health[0])
+	}
+
+	// Rolling back a version that is no longer the canary is a no-op
+	if err := service.RollbackCanary("cluster1", first, "stale"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for stale rollback, got %v", err)
+	}
+
+	if rolledBack, err := service.IsRolledBack("cluster1"); err != nil || rolledBack {
+		t.Errorf("Expected active canary not to be rolled back, got %v (err %v)", rolledBack, err)
+	}
+
+	if err := service.RollbackCanary("cluster1", canary, "node1 failed"); err != nil {
+		t.Fatalf("RollbackCanary failed: %v", err)
+	}
+	if rolledBack, err := service.IsRolledBack("cluster1"); err != nil || !rolledBack {
+		t.Errorf("Expected canary to be rolled back, got %v (err %v)", rolledBack, err)
+	}
+
+	for _, nodeID := range []string{"node1", "node2", "node3"} {
+		if version, _ := service.GetCurrentVersion("cluster1", nodeID); version != stable {
+			t.Errorf("Node %q: expected stable version %d after rollback, got %d", nodeID, stable, version)
+		}
+	}
+
+	active, err := service.ListActiveCanaries()
+	if err != nil {
+		t.Fatalf("ListActiveCanaries failed: %v", err)
+	}
+	if len(active) != 0 {
+		t.Errorf("Expected no active canaries after rollback, got %+v", active)
+	}
+
+	got, err := service.GetCanary("cluster1")
+	if err != nil {
+		t.Fatalf("GetCanary failed: %v", err)
+	}
+	if got.RolledBackAt == nil || got.RollbackReason != "node1 failed" || len(got.NodeIDs) != 0 {
+		t.Errorf("Expected recorded rollback, got %+v", got)
+	}
+
+	if _, err := service.PromoteCanary("cluster1"); !errors.Is(err, models.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest promoting a rolled back canary, got %v", err)
+	}
+}
@@ -0,0 +1,449 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+	"nebulagc.io/pkg/token"
+	"nebulagc.io/server/internal/logging"
+)
+
+// clusterStatsTTL bounds how long cached stats are served for an unchanged
+// config version. Mutations bump the version and invalidate the entry
+// immediately; the TTL only limits memory held for idle clusters.
+const clusterStatsTTL = 10 * time.Second
+
+// clusterStatsEntry is cached stats for one cluster at one config version.
+type clusterStatsEntry struct {
+	version   int64
+	stats     models.ClusterStats
+	expiresAt time.Time
+}
+
+// clusterColumns are the clusters columns scanned by scanCluster, in order.
+const clusterColumns = `
+	id, tenant_id, name, provide_lighthouse, lighthouse_port, max_relay_hops,
+	lighthouse_dns_enabled, lighthouse_dns_host, lighthouse_dns_port, cipher,
+	handshake_try_interval, handshake_retries, handshake_trigger_buffer,
+	require_signed_requests, config_version, pki_ca_cert, pki_crl, created_at`
+
+// ClusterService handles cluster-level business logic.
+type ClusterService struct {
+	db     *sql.DB
+	logger *zap.Logger
+	secret string
+
+	statsMu    sync.Mutex
+	statsCache map[string]clusterStatsEntry
+}
+
+// NewClusterService creates a new cluster service.
+//
+// Parameters:
+//   - db: Database connection
+//   - logger: Zap logger for structured logging
+//   - secret: HMAC secret used to hash cluster tokens
+//
+// Returns:
+//   - Configured ClusterService
+func NewClusterService(db *sql.DB, logger *zap.Logger, secret string) *ClusterService {
+	return &ClusterService{
+		db:         db,
+		logger:     logger,
+		secret:     secret,
+		statsCache: make(map[string]clusterStatsEntry),
+	}
+}
+
+// CreateCluster creates a cluster in a tenant and generates its cluster token.
+//
+// The cluster starts at config version 0 with default settings. Only the
+// token's hash is stored; the token itself is returned once and cannot be
+// recovered.
+//
+// Parameters:
+//   - ctx: Request context
+//   - tenantID: Owning tenant
+//   - name: Cluster name (1-255 characters, unique within the tenant)
+//
+// Returns:
+//   - *models.Cluster: The created cluster
+//   - string: The cluster token
+//   - error: models.ErrInvalidRequest for an invalid name, models.ErrTenantNotFound,
+//     models.ErrDuplicateName if the tenant already has a cluster with this name,
+//     or error if token generation or the insert fails
+func (s *ClusterService) CreateCluster(ctx context.Context, tenantID, name string) (*models.Cluster, string, error) {
+	if name == "" || len(name) > 255 {
+		return nil, "", fmt.Errorf("%w: cluster name must be 1-255 characters", models.ErrInvalidRequest)
+	}
+
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM tenants WHERE id = ?)`, tenantID).Scan(&exists)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to query tenant: %w", err)
+	}
+	if !exists {
+		return nil, "", models.ErrTenantNotFound
+	}
+
+	clusterToken, err := token.Generate()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate cluster token: %w", err)
+	}
+
+	clusterID := uuid.New().String()
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO clusters (id, tenant_id, name, cluster_token_hash, config_version)
+		VALUES (?, ?, ?, ?, 0)
+	`, clusterID, tenantID, name, token.Hash(clusterToken, s.secret))
+	if err != nil {
+		if isUniqueConstraint(err) {
+			return nil, "", models.ErrDuplicateName
+		}
+		return nil, "", fmt.Errorf("failed to create cluster: %w", err)
+	}
+
+	cluster, err := s.GetCluster(ctx, tenantID, clusterID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.logger.Info("Created cluster",
+		zap.Bool(logging.FieldAudit, true),
+		zap.String(logging.FieldOperation, "create_cluster"),
+		zap.String(logging.FieldClusterID, clusterID),
+		zap.String("tenant_id", tenantID),
+		zap.String("name", name))
+
+	return cluster, clusterToken, nil
+}
+
+// ListClusters returns a page of a tenant's clusters, oldest first.
+//
+// Parameters:
+//   - ctx: Request context
+//   - tenantID: Owning tenant
+//   - page: Page number, starting at 1
+//   - pageSize: Clusters per page (default 50, max 500)
+//
+// Returns:
+//   - *models.ClusterListResponse with the page and the tenant's total cluster count
+//   - error if a query fails
+func (s *ClusterService) ListClusters(ctx context.Context, tenantID string, page, pageSize int) (*models.ClusterListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 50
+	}
+	if pageSize > 500 {
+		pageSize = 500
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM clusters WHERE tenant_id = ?
+	`, tenantID).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count clusters: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+clusterColumns+`
+		FROM clusters
+		WHERE tenant_id = ?
+		ORDER BY created_at ASC, name ASC
+		LIMIT ? OFFSET ?
+	`, tenantID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+	defer rows.Close()
+
+	clusters := []models.Cluster{}
+	for rows.Next() {
+		cluster, err := scanCluster(rows)
+		if err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, *cluster)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate clusters: %w", err)
+	}
+
+	return &models.ClusterListResponse{
+		Clusters: clusters,
+		Total:    total,
+		Page:     page,
+		PerPage:  pageSize,
+	}, nil
+}
+
+// GetCluster returns a single cluster of a tenant. Secrets (token hash, CA
+// key) are never loaded.
+//
+// Parameters:
+//   - ctx: Request context
+//   - tenantID: Owning tenant
+//   - clusterID: Cluster ID
+//
+// Returns:
+//   - *models.Cluster: The cluster
+//   - error: models.ErrClusterNotFound if the tenant has no such cluster
+func (s *ClusterService) GetCluster(ctx context.Context, tenantID, clusterID string) (*models.Cluster, error) {
+	cluster, err := scanCluster(s.db.QueryRowContext(ctx, `
+		SELECT `+clusterColumns+`
+		FROM clusters
+		WHERE id = ? AND tenant_id = ?
+	`, clusterID, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrClusterNotFound
+	}
+	return cluster, err
+}
+
+// DeleteCluster permanently deletes a cluster with its nodes, config bundles,
+// config history and canary state.
+//
+// Child rows are deleted explicitly in one transaction rather than relying
+// on foreign key cascades. Bundle data held by a filesystem bundle store is
+// not removed; content-addressed blobs are reclaimed by garbage collection.
+//
+// Parameters:
+//   - ctx: Request context
+//   - tenantID: Owning tenant
+//   - clusterID: Cluster ID
+//
+// Returns:
+//   - error: models.ErrClusterNotFound if the tenant has no such cluster, or
+//     error if a delete fails
+func (s *ClusterService) DeleteCluster(ctx context.Context, tenantID, clusterID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM clusters WHERE id = ? AND tenant_id = ?)
+	`, clusterID, tenantID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to query cluster: %w", err)
+	}
+	if !exists {
+		return models.ErrClusterNotFound
+	}
+
+	if err := deleteClusterRows(ctx, tx, clusterID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.statsMu.Lock()
+	delete(s.statsCache, clusterID)
+	s.statsMu.Unlock()
+
+	s.logger.Info("Deleted cluster",
+		zap.Bool(logging.FieldAudit, true),
+		zap.String(logging.FieldOperation, "delete_cluster"),
+		zap.String(logging.FieldClusterID, clusterID),
+		zap.String("tenant_id", tenantID))
+
+	return nil
+}
+
+// deleteClusterRows deletes a cluster and every row belonging to it. Children
+// are deleted first, so the deletes also succeed with foreign keys enforced.
+func deleteClusterRows(ctx context.Context, tx *sql.Tx, clusterID string) error {
+	for _, table := range []string{
+		"canary_nodes",
+		"canary_rollouts",
+		"bundle_blob_refs",
+		"config_bundles",
+		"config_changes",
+		"cluster_state",
+		"nodes",
+	} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE cluster_id = ?`, clusterID); err != nil {
+			return fmt.Errorf("failed to delete cluster %s: %w", table, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM clusters WHERE id = ?`, clusterID); err != nil {
+		return fmt.Errorf("failed to delete cluster: %w", err)
+	}
+	return nil
+}
+
+// scanCluster scans a row of clusterColumns.
+func scanCluster(row interface{ Scan(dest ...any) error }) (*models.Cluster, error) {
+	var c models.Cluster
+	var lighthousePort, retries, triggerBuffer sql.NullInt64
+	var tryInterval, caCert, crl sql.NullString
+	err := row.Scan(&c.ID, &c.TenantID, &c.Name, &c.ProvideLighthouse, &lighthousePort, &c.MaxRelayHops,
+		&c.LighthouseDNSEnabled, &c.LighthouseDNSHost, &c.LighthouseDNSPort, &c.Cipher,
+		&tryInterval, &retries, &triggerBuffer,
+		&c.RequireSignedRequests, &c.ConfigVersion, &caCert, &crl, &c.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan cluster: %w", err)
+	}
+
+	c.LighthousePort = int(lighthousePort.Int64)
+	c.PKICACert = caCert.String
+	c.PKICRL = crl.String
+	if tryInterval.Valid || retries.Valid || triggerBuffer.Valid {
+		c.Handshake = &models.HandshakeConfig{
+			TryInterval:   tryInterval.String,
+			Retries:       int(retries.Int64),
+			TriggerBuffer: int(triggerBuffer.Int64),
+		}
+	}
+	return &c, nil
+}
+
+// Stats returns node, role and route counts for a cluster along with its
+// current config version and last change time.
+//
+// Counts are computed with aggregate queries rather than loading nodes.
+// Results are cached briefly per config version: every mutation that affects
+// the counts bumps the version, so a cached entry is never stale.
+//
+// Parameters:
+//   - clusterID: Cluster ID
+//
+// Returns:
+//   - Cluster stats
+//   - ErrClusterNotFound if the cluster does not exist, or error if a query fails
+func (s *ClusterService) Stats(clusterID string) (*models.ClusterStats, error) {
+	var version int64
+	var updatedAt sql.NullInt64
+	err := s.db.QueryRow(`
+		SELECT config_version, config_updated_at FROM clusters WHERE id = ?
+	`, clusterID).Scan(&version, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrClusterNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cluster: %w", err)
+	}
+
+	if stats, ok := s.cachedStats(clusterID, version); ok {
+		return stats, nil
+	}
+
+	stats := models.ClusterStats{
+		ClusterID:     clusterID,
+		ConfigVersion: version,
+	}
+	if updatedAt.Valid {
+		changed := time.Unix(updatedAt.Int64, 0).UTC()
+		stats.LastChangeAt = &changed
+	}
+
+	// Routes are stored as a JSON array per node; malformed values count as none
+	err = s.db.QueryRow(`
+		SELECT
+			COUNT(*),
+			COALESCE(SUM(is_admin), 0),
+			COALESCE(SUM(is_lighthouse), 0),
+			COALESCE(SUM(is_relay), 0),
+			COALESCE(SUM(CASE WHEN json_valid(routes) THEN json_array_length(routes) ELSE 0 END), 0)
+		FROM nodes
+		WHERE cluster_id = ? AND deleted_at IS NULL
+	`, clusterID).Scan(
+		&stats.NodeCount,
+		&stats.AdminCount,
+		&stats.LighthouseCount,
+		&stats.RelayCount,
+		&stats.RouteCount,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate node stats: %w", err)
+	}
+
+	s.storeStats(clusterID, version, stats)
+
+	return &stats, nil
+}
+
+// History returns a cluster's config change timeline, newest first: one entry
+// per config version bump with what triggered it and which node made it.
+//
+// Parameters:
+//   - ctx: Request context
+//   - clusterID: Cluster ID
+//   - limit: Maximum number of changes (0 = DefaultConfigHistoryLimit, capped at MaxConfigHistoryLimit)
+//
+// Returns:
+//   - Config changes, newest first
+//   - ErrClusterNotFound if the cluster does not exist, or error if a query fails
+func (s *ClusterService) History(ctx context.Context, clusterID string, limit int) ([]models.ConfigChange, error) {
+	if limit <= 0 {
+		limit = DefaultConfigHistoryLimit
+	}
+	if limit > MaxConfigHistoryLimit {
+		limit = MaxConfigHistoryLimit
+	}
+
+	var exists int
+	err := s.db.QueryRowContext(ctx, `SELECT 1 FROM clusters WHERE id = ?`, clusterID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrClusterNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cluster: %w", err)
+	}
+
+	return listConfigChanges(ctx, s.db, clusterID, limit)
+}
+
+// cachedStats returns a copy of the cached stats if they were computed at version
+// and have not expired.
+func (s *ClusterService) cachedStats(clusterID string, version int64) (*models.ClusterStats, bool) {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+
+	entry, ok := s.statsCache[clusterID]
+	if !ok {
+		return nil, false
+	}
+	if entry.version != version || time.Now().After(entry.expiresAt) {
+		delete(s.statsCache, clusterID)
+		return nil, false
+	}
+
+	stats := entry.stats
+	return &stats, true
+}
+
+// storeStats caches stats for the cluster at version and drops expired entries.
+func (s *ClusterService) storeStats(clusterID string, version int64, stats models.ClusterStats) {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+
+	now := time.Now()
+	for id, entry := range s.statsCache {
+		if now.After(entry.expiresAt) {
+			delete(s.statsCache, id)
+		}
+	}
+
+	s.statsCache[clusterID] = clusterStatsEntry{
+		version:   version,
+		stats:     stats,
+		expiresAt: now.Add(clusterStatsTTL),
+	}
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+	"nebulagc.io/pkg/token"
+	"nebulagc.io/server/internal/testutil"
+)
+
+func TestClusterService_Stats(t *testing.T) {
+	db := testutil.OpenDB(t)
+	_, clusterID, nodeIDs := testutil.Seed(t, db, 3)
+
+	statements := []string{
+		`UPDATE nodes SET is_admin = 1, routes = '["10.1.0.0/24","10.2.0.0/24"]' WHERE id = ?`,
+		`UPDATE nodes SET is_lighthouse = 1, is_relay = 1, routes = '["10.3.0.0/24"]' WHERE id = ?`,
+		`UPDATE nodes SET routes = 'not-json' WHERE id = ?`,
+	}
+	for i, stmt := range statements {
+		if _, err := db.Exec(stmt, nodeIDs[i]); err != nil {
+			t.Fatalf("Failed to set up nodes: %v", err)
+		}
+	}
+
+	service := NewClusterService(db, zap.NewNop(), testutil.TestHMACSecret)
+
+	stats, err := service.Stats(clusterID)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+
+	want := models.ClusterStats{
+		ClusterID:       clusterID,
+		NodeCount:       3,
+		AdminCount:      1,
+		LighthouseCount: 1,
+		RelayCount:      1,
+		RouteCount:      3,
+		ConfigVersion:   1,
+	}
+	if *stats != want {
+		t.Errorf("Expected %+v, got %+v", want, *stats)
+	}
+
+	// Bumping the version invalidates the cached stats and records the change time
+	if _, err := db.Exec(`DELETE FROM nodes WHERE id = ?`, nodeIDs[2]); err != nil {
+		t.Fatalf("Failed to delete node: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE clusters SET config_version = config_version + 1 WHERE id = ?`, clusterID); err != nil {
+		t.Fatalf("Failed to bump version: %v", err)
+	}
+
+	stats, err = service.Stats(clusterID)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.NodeCount != 2 || stats.ConfigVersion != 2 {
+		t.Errorf("Expected 2 nodes at version 2, got %d nodes at version %d", stats.NodeCount, stats.ConfigVersion)
+	}
+	if stats.LastChangeAt == nil {
+		t.Error("Expected LastChangeAt to be set after a version bump")
+	}
+}
+
+func TestClusterService_StatsCachedByVersion(t *testing.T) {
+	db := testutil.OpenDB(t)
+	_, clusterID, nodeIDs := testutil.Seed(t, db, 3)
+
+	service := NewClusterService(db, zap.NewNop(), testutil.TestHMACSecret)
+
+	if _, err := service.Stats(clusterID); err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+
+	// A change without a version bump is not visible until the version moves
+	if _, err := db.Exec(`DELETE FROM nodes WHERE id = ?`, nodeIDs[2]); err != nil {
+		t.Fatalf("Failed to delete node: %v", err)
+	}
+
+	stats, err := service.Stats(clusterID)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.NodeCount != 3 {
+		t.Errorf("Expected cached node count 3, got %d", stats.NodeCount)
+	}
+}
+
+func TestClusterService_StatsNotFound(t *testing.T) {
+	db := testutil.OpenDB(t)
+
+	service := NewClusterService(db, zap.NewNop(), testutil.TestHMACSecret)
+
+	if _, err := service.Stats("missing"); !errors.Is(err, models.ErrClusterNotFound) {
+		t.Errorf("Expected ErrClusterNotFound, got %v", err)
+	}
+}
+
+func TestClusterService_History(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.OpenDB(t)
+	tenantID, clusterID, nodeIDs := testutil.Seed(t, db, 1)
+	admin := nodeIDs[0]
+
+	nodes := NewNodeService(db, zap.NewNop(), testutil.TestHMACSecret).WithActor(admin)
+	creds, err := nodes.CreateNode(ctx, tenantID, clusterID, "", &models.NodeCreateRequest{Name: "worker"})
+	if err != nil {
+		t.Fatalf("CreateNode failed: %v", err)
+	}
+	topology := NewTopologyService(db, zap.NewNop(), testutil.TestHMACSecret)
+	if err := topology.WithActor(creds.NodeID).UpdateRoutes(creds.NodeID, []string{"10.1.0.0/24"}); err != nil {
+		t.Fatalf("UpdateRoutes failed: %v", err)
+	}
+	if err := topology.WithActor(admin).SetMaxRelayHops(clusterID, 2); err != nil {
+		t.Fatalf("SetMaxRelayHops failed: %v", err)
+	}
+	bundles := NewBundleService(db, zap.NewNop())
+	if _, err := bundles.UploadWithOptions(clusterID, createTestBundle(), UploadOptions{CreatedBy: admin, Description: "initial"}); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := nodes.DeleteNode(ctx, tenantID, clusterID, creds.NodeID); err != nil {
+		t.Fatalf("DeleteNode failed: %v", err)
+	}
+
+	service := NewClusterService(db, zap.NewNop(), testutil.TestHMACSecret)
+	changes, err := service.History(ctx, clusterID, 0)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+
+	want := []models.ConfigChange{
+		{Version: 6, Type: models.ConfigChangeNodeDeleted, Actor: admin, Detail: creds.NodeID},
+		{Version: 5, Type: models.ConfigChangeBundleUploaded, Actor: admin, Detail: "initial"},
+		{Version: 4, Type: models.ConfigChangeClusterSettings, Actor: admin, Detail: "max_relay_hops"},
+		{Version: 3, Type: models.ConfigChangeRoutes, Actor: creds.NodeID, Detail: creds.NodeID},
+		{Version: 2, Type: models.ConfigChangeNodeCreated, Actor: admin, Detail: creds.NodeID},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Expected %d changes, got %+v", len(want), changes)
+	}
+	for i, change := range changes {
+		if change.CreatedAt.IsZero() {
+			t.Errorf("Change %d has no timestamp", change.Version)
+		}
+		change.CreatedAt = time.Time{}
+		if change != want[i] {
+			t.Errorf("Change %d = %+v, want %+v", i, change, want[i])
+		}
+	}
+
+	// Every version bump has an entry
+	stats, err := service.Stats(clusterID)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.ConfigVersion != changes[0].Version {
+		t.Errorf("Latest change is version %d, cluster is at %d", changes[0].Version, stats.ConfigVersion)
+	}
+
+	if changes, err := service.History(ctx, clusterID, 2); err != nil || len(changes) != 2 || changes[1].Version != 5 {
+		t.Errorf("History with limit 2 = %+v, %v; want versions 6 and 5", changes, err)
+	}
+	if _, err := service.History(ctx, "missing", 0); !errors.Is(err, models.ErrClusterNotFound) {
+		t.Errorf("Expected ErrClusterNotFound, got %v", err)
+	}
+}
+
+func TestClusterService_CreateGetList(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.OpenDB(t)
+	tenantID := testutil.Tenant(t, db, "Test Tenant")
+	otherTenantID := testutil.Tenant(t, db, "Other Tenant")
+
+	service := NewClusterService(db, zap.NewNop(), testutil.TestHMACSecret)
+
+	cluster, clusterToken, err := service.CreateCluster(ctx, tenantID, "prod")
+	if err != nil {
+		t.Fatalf("CreateCluster failed: %v", err)
+	}
+	if cluster.ID == "" || cluster.TenantID != tenantID || cluster.Name != "prod" || cluster.ConfigVersion != 0 {
+		t.Errorf("Unexpected cluster: %+v", cluster)
+	}
+	if cluster.CreatedAt.IsZero() {
+		t.Error("Expected CreatedAt to be set")
+	}
+
+	// Only the token's hash is stored
+	var tokenHash string
+	if err := db.QueryRow(`SELECT cluster_token_hash FROM clusters WHERE id = ?`, cluster.ID).Scan(&tokenHash); err != nil {
+		t.Fatalf("Failed to read token hash: %v", err)
+	}
+	if tokenHash != token.Hash(clusterToken, testutil.TestHMACSecret) {
+		t.Error("Expected the stored hash to validate the returned token")
+	}
+
+	if _, _, err := service.CreateCluster(ctx, tenantID, "prod"); !errors.Is(err, models.ErrDuplicateName) {
+		t.Errorf("Expected ErrDuplicateName, got %v", err)
+	}
+	if _, _, err := service.CreateCluster(ctx, otherTenantID, "prod"); err != nil {
+		t.Errorf("Expected names to be scoped per tenant, got %v", err)
+	}
+	if _, _, err := service.CreateCluster(ctx, tenantID, ""); !errors.Is(err, models.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for empty name, got %v", err)
+	}
+	if _, _, err := service.CreateCluster(ctx, "missing", "prod"); !errors.Is(err, models.ErrTenantNotFound) {
+		t.Errorf("Expected ErrTenantNotFound, got %v", err)
+	}
+
+	got, err := service.GetCluster(ctx, tenantID, cluster.ID)
+	if err != nil {
+		t.Fatalf("GetCluster failed: %v", err)
+	}
+	if got.Name != "prod" || got.Cipher != "aes" || got.MaxRelayHops != 1 {
+		t.Errorf("Unexpected cluster: %+v", got)
+	}
+	if _, err := service.GetCluster(ctx, otherTenantID, cluster.ID); !errors.Is(err, models.ErrClusterNotFound) {
+		t.Errorf("Expected ErrClusterNotFound from another tenant, got %v", err)
+	}
+
+	for _, name := range []string{"staging", "dev"} {
+		if _, _, err := service.CreateCluster(ctx, tenantID, name); err != nil {
+			t.Fatalf("CreateCluster(%s) failed: %v", name, err)
+		}
+	}
+
+	page1, err := service.ListClusters(ctx, tenantID, 1, 2)
+	if err != nil {
+		t.Fatalf("ListClusters failed: %v", err)
+	}
+	page2, err := service.ListClusters(ctx, tenantID, 2, 2)
+	if err != nil {
+		t.Fatalf("ListClusters failed: %v", err)
+	}
+	if page1.Total != 3 || len(page1.Clusters) != 2 || len(page2.Clusters) != 1 {
+		t.Fatalf("Expected 3 clusters over 2 pages, got %+v and %+v", page1, page2)
+	}
+	seen := map[string]bool{}
+	for _, c := range append(page1.Clusters, page2.Clusters...) {
+		if c.TenantID != tenantID {
+			t.Errorf("Listed cluster %s of tenant %s", c.Name, c.TenantID)
+		}
+		seen[c.Name] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("Expected each cluster once across pages, got %v", seen)
+	}
+}
+
+func TestClusterService_DeleteCascades(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.OpenDB(t)
+	tenantID, clusterID, _ := testutil.Seed(t, db, 2)
+	keepID, _ := testutil.Cluster(t, db, testutil.Tenant(t, db, "Other Tenant"), "Keep")
+
+	bundles := NewBundleService(db, zap.NewNop())
+	if _, err := bundles.Upload(clusterID, createTestBundle()); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	service := NewClusterService(db, zap.NewNop(), testutil.TestHMACSecret)
+
+	if err := service.DeleteCluster(ctx, "other-tenant", clusterID); !errors.Is(err, models.ErrClusterNotFound) {
+		t.Fatalf("Expected ErrClusterNotFound from another tenant, got %v", err)
+	}
+
+	if err := service.DeleteCluster(ctx, tenantID, clusterID); err != nil {
+		t.Fatalf("DeleteCluster failed: %v", err)
+	}
+
+	for _, table := range []string{"clusters", "nodes", "config_bundles", "config_changes"} {
+		column := "cluster_id"
+		if table == "clusters" {
+			column = "id"
+		}
+		var count int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE `+column+` = ?`, clusterID).Scan(&count); err != nil {
+			t.Fatalf("Failed to count %s: %v", table, err)
+		}
+		if count != 0 {
+			t.Errorf("Expected no %s rows left for the deleted cluster, got %d", table, count)
+		}
+	}
+
+	if _, err := service.Stats(keepID); err != nil {
+		t.Errorf("Expected other clusters to be untouched, got %v", err)
+	}
+	if err := service.DeleteCluster(ctx, tenantID, clusterID); !errors.Is(err, models.ErrClusterNotFound) {
+		t.Errorf("Expected ErrClusterNotFound deleting twice, got %v", err)
+	}
+}
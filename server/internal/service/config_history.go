@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"nebulagc.io/models"
+)
+
+// Config history page sizes.
+const (
+	// DefaultConfigHistoryLimit is how many changes History returns by default.
+	DefaultConfigHistoryLimit = 50
+
+	// MaxConfigHistoryLimit is the most changes History returns at once.
+	MaxConfigHistoryLimit = 500
+)
+
+// configChange describes why a cluster's config version is being bumped.
+type configChange struct {
+	changeType string // Reason for the bump: one of the models.ConfigChange* types (required)
+	actor      string // Node ID that made the change ("" if unknown)
+	detail     string // Subject of the change, e.g. the affected node ID
+}
+
+// bumpConfigVersion increments a cluster's config version and records the
+// change in config_changes, both within tx. Every config version bump goes
+// through here, so each version has exactly one history entry.
+//
+// Parameters:
+//   - ctx: Request context
+//   - tx: Transaction holding the change that caused the bump
+//   - clusterID: Cluster ID
+//   - change: What caused the bump and who made it
+//
+// Returns:
+//   - int64: The new config version
+//   - string: The cluster's tenant ID
+//   - error: ErrClusterNotFound if the cluster does not exist, or error if the
+//     change has no reason or a statement fails
+func bumpConfigVersion(ctx context.Context, tx *sql.Tx, clusterID string, change configChange) (int64, string, error) {
+	if change.changeType == "" {
+		return 0, "", errors.New("config version bump requires a change reason")
+	}
+
+	var version int64
+	var tenantID string
+	err := tx.QueryRowContext(ctx, `
+		UPDATE clusters SET config_version = config_version + 1
+		WHERE id = ?
+		RETURNING config_version, tenant_id
+	`, clusterID).Scan(&version, &tenantID)
+	if err == sql.ErrNoRows {
+		return 0, "", models.ErrClusterNotFound
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to bump config version: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO config_changes (tenant_id, cluster_id, version, change_type, actor, detail, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, CAST(strftime('%s', 'now') AS INTEGER))
+	`, tenantID, clusterID, version, change.changeType, nullString(change.actor), nullString(change.detail))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to record config change: %w", err)
+	}
+
+	return version, tenantID, nil
+}
+
+// listConfigChanges returns up to limit config changes of a cluster, newest
+// first.
+func listConfigChanges(ctx context.Context, db *sql.DB, clusterID string, limit int) ([]models.ConfigChange, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT version, change_type, actor, detail, created_at
+		FROM config_changes
+		WHERE cluster_id = ?
+		ORDER BY version DESC
+		LIMIT ?
+	`, clusterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query config history: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []models.ConfigChange{}
+	for rows.Next() {
+		var change models.ConfigChange
+		var actor, detail sql.NullString
+		var createdAt int64
+		if err := rows.Scan(&change.Version, &change.Type, &actor, &detail, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan config change: %w", err)
+		}
+		change.Actor = actor.String
+		change.Detail = detail.String
+		change.CreatedAt = time.Unix(createdAt, 0).UTC()
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query config history: %w", err)
+	}
+
+	return changes, nil
+}
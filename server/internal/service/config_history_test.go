@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+	"nebulagc.io/server/internal/testutil"
+)
+
+// latestConfigChange returns the cluster's config version and its most
+// recent history entry.
+func latestConfigChange(t *testing.T, db *sql.DB, clusterID string) (int64, models.ConfigChange) {
+	t.Helper()
+
+	var version int64
+	if err := db.QueryRow(`SELECT config_version FROM clusters WHERE id = ?`, clusterID).Scan(&version); err != nil {
+		t.Fatalf("Failed to query config version: %v", err)
+	}
+	changes, err := listConfigChanges(context.Background(), db, clusterID, 1)
+	if err != nil {
+		t.Fatalf("listConfigChanges failed: %v", err)
+	}
+	if len(changes) == 0 {
+		return version, models.ConfigChange{}
+	}
+	return version, changes[0]
+}
+
+func TestConfigChangeReasons(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.OpenDB(t)
+	tenantID, clusterID, nodeIDs := testutil.Seed(t, db, 2)
+	admin, relay := nodeIDs[0], nodeIDs[1]
+
+	nodes := NewNodeService(db, zap.NewNop(), testutil.TestHMACSecret).WithActor(admin)
+	topology := NewTopologyService(db, zap.NewNop(), testutil.TestHMACSecret).WithActor(admin)
+	bundles := NewBundleService(db, zap.NewNop())
+
+	var nodeID string
+	var snapshot *TopologyInfo
+	steps := []struct {
+		name       string
+		mutate     func() error
+		wantType   string
+		wantDetail func() string
+	}{
+		{
+			name: "CreateNode",
+			mutate: func() error {
+				creds, err := nodes.CreateNode(ctx, tenantID, clusterID, "", &models.NodeCreateRequest{Name: "worker"})
+				if err == nil {
+					nodeID = creds.NodeID
+				}
+				return err
+			},
+			wantType:   models.ConfigChangeNodeCreated,
+			wantDetail: func() string { return nodeID },
+		},
+		{
+			name: "UpdateMTU",
+			mutate: func() error {
+				_, err := nodes.UpdateMTU(ctx, tenantID, clusterID, nodeID, 1400)
+				return err
+			},
+			wantType:   models.ConfigChangeNodeMTU,
+			wantDetail: func() string { return nodeID },
+		},
+		{
+			name: "RotateNodeToken",
+			mutate: func() error {
+				_, err := nodes.RotateNodeToken(ctx, tenantID, clusterID, nodeID)
+				return err
+			},
+			wantType:   models.ConfigChangeNodeTokenRotated,
+			wantDetail: func() string { return nodeID },
+		},
+		{
+			name:       "UpdateRoutes",
+			mutate:     func() error { return topology.UpdateRoutes(nodeID, []string{"10.1.0.0/24"}) },
+			wantType:   models.ConfigChangeRoutes,
+			wantDetail: func() string { return nodeID },
+		},
+		{
+			name:       "SetLighthouse",
+			mutate:     func() error { return topology.SetLighthouse(clusterID, admin, "203.0.113.10", 4242) },
+			wantType:   models.ConfigChangeLighthouseSet,
+			wantDetail: func() string { return admin },
+		},
+		{
+			name:       "SetRelay",
+			mutate:     func() error { _, err := topology.SetRelay(clusterID, relay); return err },
+			wantType:   models.ConfigChangeRelaySet,
+			wantDetail: func() string { return relay },
+		},
+		{
+			name: "SetPreferredRelays",
+			mutate: func() error {
+				_, err := nodes.SetPreferredRelays(ctx, tenantID, clusterID, nodeID, []string{relay})
+				return err
+			},
+			wantType:   models.ConfigChangePreferredRelays,
+			wantDetail: func() string { return nodeID },
+		},
+		{
+			name:       "SetMaxRelayHops",
+			mutate:     func() error { return topology.SetMaxRelayHops(clusterID, 2) },
+			wantType:   models.ConfigChangeClusterSettings,
+			wantDetail: func() string { return "max_relay_hops" },
+		},
+		{
+			name: "SetLighthouseDNS",
+			mutate: func() error {
+				return topology.SetLighthouseDNS(clusterID, &models.LighthouseDNSConfig{Enabled: true, Port: 5353})
+			},
+			wantType:   models.ConfigChangeClusterSettings,
+			wantDetail: func() string { return "lighthouse_dns" },
+		},
+		{
+			name:       "SetNebulaSubnet",
+			mutate:     func() error { return topology.SetNebulaSubnet(clusterID, "10.42.0.0/16") },
+			wantType:   models.ConfigChangeClusterSettings,
+			wantDetail: func() string { return "nebula_subnet" },
+		},
+		{
+			name: "SetStaticHostMap",
+			mutate: func() error {
+				return topology.SetStaticHostMap(clusterID, map[string][]string{"10.42.0.1": {"203.0.113.10:4242"}})
+			},
+			wantType:   models.ConfigChangeClusterSettings,
+			wantDetail: func() string { return "static_host_map" },
+		},
+		{
+			name:       "SetPreferredRanges",
+			mutate:     func() error { return topology.SetPreferredRanges(clusterID, []string{"192.168.0.0/16"}) },
+			wantType:   models.ConfigChangeClusterSettings,
+			wantDetail: func() string { return "preferred_ranges" },
+		},
+		{
+			name:       "SetCipher",
+			mutate:     func() error { return topology.SetCipher(clusterID, "chachapoly", true) },
+			wantType:   models.ConfigChangeClusterSettings,
+			wantDetail: func() string { return "cipher" },
+		},
+		{
+			name: "SetHandshakeConfig",
+			mutate: func() error {
+				return topology.SetHandshakeConfig(clusterID, &models.HandshakeConfig{Retries: 5})
+			},
+			wantType:   models.ConfigChangeClusterSettings,
+			wantDetail: func() string { return "handshake" },
+		},
+		{
+			name: "ImportSnapshot",
+			mutate: func() error {
+				var err error
+				if snapshot, err = topology.ExportSnapshot(clusterID); err != nil {
+					return err
+				}
+				return topology.ImportSnapshot(clusterID, snapshot)
+			},
+			wantType:   models.ConfigChangeSnapshotImported,
+			wantDetail: func() string { return "" },
+		},
+		{
+			name:       "UnsetRelay",
+			mutate:     func() error { return topology.UnsetRelay(clusterID, relay) },
+			wantType:   models.ConfigChangeRelayUnset,
+			wantDetail: func() string { return relay },
+		},
+		{
+			name:       "UnsetLighthouse",
+			mutate:     func() error { return topology.UnsetLighthouse(clusterID, admin) },
+			wantType:   models.ConfigChangeLighthouseUnset,
+			wantDetail: func() string { return admin },
+		},
+		{
+			name: "Upload",
+			mutate: func() error {
+				_, err := bundles.UploadWithOptions(clusterID, createTestBundle(), UploadOptions{CreatedBy: admin, Description: "v2"})
+				return err
+			},
+			wantType:   models.ConfigChangeBundleUploaded,
+			wantDetail: func() string { return "v2" },
+		},
+		{
+			name:       "DeleteNode",
+			mutate:     func() error { return nodes.DeleteNode(ctx, tenantID, clusterID, nodeID) },
+			wantType:   models.ConfigChangeNodeDeleted,
+			wantDetail: func() string { return nodeID },
+		},
+	}
+
+	previous, _ := latestConfigChange(t, db, clusterID)
+	for _, step := range steps {
+		if err := step.mutate(); err != nil {
+			t.Fatalf("%s failed: %v", step.name, err)
+		}
+
+		version, change := latestConfigChange(t, db, clusterID)
+		if version != previous+1 {
+			t.Errorf("%s: config version %d, want %d", step.name, version, previous+1)
+		}
+		want := models.ConfigChange{
+			Version: version,
+			Type:    step.wantType,
+			Actor:   admin,
+			Detail:  step.wantDetail(),
+		}
+		change.CreatedAt = want.CreatedAt
+		if change != want {
+			t.Errorf("%s: recorded %+v, want %+v", step.name, change, want)
+		}
+		previous = version
+	}
+}
+
+func TestBumpConfigVersionRequiresReason(t *testing.T) {
+	db := testutil.OpenDB(t)
+	_, clusterID, _ := testutil.Seed(t, db, 0)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, _, err := bumpConfigVersion(context.Background(), tx, clusterID, configChange{}); err == nil {
+		t.Error("Expected a bump without a reason to be rejected")
+	}
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"nebulagc.io/models"
+	"nebulagc.io/server/internal/config"
+)
+
+// generatorCluster holds cluster settings loaded for the config generator,
+// plus cluster facts the generator itself does not need.
+type generatorCluster struct {
+	// Cluster is the generator's view of the cluster.
+	Cluster config.Cluster
+
+	// ProvideLighthouse indicates the control plane runs lighthouses.
+	ProvideLighthouse bool
+
+	// HasCA indicates the cluster has a CA certificate.
+	HasCA bool
+
+	// ConfigVersion is the cluster's current config version.
+	ConfigVersion int64
+}
+
+// ValidateConfig checks whether the cluster's current topology produces a
+// working Nebula configuration.
+//
+// The config generator is run for a representative regular node and, if the
+// cluster has one, a representative lighthouse, so generator-level problems
+// (invalid relay hops, cipher, DNS port conflicts) are reported alongside
+// topology problems (no lighthouse, overlapping routes, missing CA).
+//
+// Parameters:
+//   - clusterID: Cluster UUID
+//
+// Returns:
+//   - Validation report with errors and warnings
+//   - Error if cluster not found or query fails
+func (s *TopologyService) ValidateConfig(clusterID string) (*config.Report, error) {
+	gc, err := s.loadGeneratorCluster(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	topology, err := s.GetTopology(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := s.loadGeneratorNodes(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var lighthouses, relays, advertisers []config.Peer
+	for _, lh := range topology.Lighthouses {
+		lighthouses = append(lighthouses, config.Peer{NodeID: lh.NodeID, Name: lh.Name, PublicIP: lh.PublicIP, Port: lh.Port})
+	}
+	for _, r := range topology.Relays {
+		relays = append(relays, config.Peer{NodeID: r.NodeID, Name: r.Name})
+	}
+	for nodeID, routes := range topology.Routes {
+		advertisers = append(advertisers, config.Peer{NodeID: nodeID, Routes: routes})
+	}
+
+	// Pick the first regular node and the first lighthouse (nodes are sorted by name)
+	var inputs []*config.Input
+	var representative string
+	var haveRegular, haveLighthouse bool
+	for _, node := range nodes {
+		if (node.IsLighthouse && haveLighthouse) || (!node.IsLighthouse && haveRegular) {
+			continue
+		}
+		if node.IsLighthouse {
+			haveLighthouse = true
+		} else {
+			haveRegular = true
+			representative = node.ID
+		}
+		inputs = append(inputs, &config.Input{
+			Cluster:          gc.Cluster,
+			Node:             node,
+			Lighthouses:      lighthouses,
+			Relays:           relays,
+			RouteAdvertisers: advertisers,
+		})
+	}
+	if representative == "" && len(inputs) > 0 {
+		representative = inputs[0].Node.ID
+	}
+
+	report := config.Validate(&config.ValidationInput{
+		Inputs:                 inputs,
+		NodeCount:              len(nodes),
+		HasCA:                  gc.HasCA,
+		ControlPlaneLighthouse: gc.ProvideLighthouse,
+		Lighthouses:            lighthouses,
+		RoutesByNode:           topology.Routes,
+	})
+	report.RepresentativeNodeID = representative
+
+	return report, nil
+}
+
+// loadGeneratorCluster loads the cluster-wide settings used by the config generator.
+func (s *TopologyService) loadGeneratorCluster(clusterID string) (*generatorCluster, error) {
+	gc := &generatorCluster{Cluster: config.Cluster{ID: clusterID}}
+
+	var lighthousePort, retries, triggerBuffer sql.NullInt64
+	var caCert, staticJSON, rangesJSON, tryInterval sql.NullString
+	err := s.db.QueryRow(`
+		SELECT provide_lighthouse, lighthouse_port, pki_ca_cert, max_relay_hops,
+		       lighthouse_dns_enabled, lighthouse_dns_host, lighthouse_dns_port,
+		       static_host_map, preferred_ranges, cipher,
+		       handshake_try_interval, handshake_retries, handshake_trigger_buffer,
+		       config_version
+		FROM clusters
+		WHERE id = ?
+	`, clusterID).Scan(
+		&gc.ProvideLighthouse, &lighthousePort, &caCert, &gc.Cluster.MaxRelayHops,
+		&gc.Cluster.DNS.Enabled, &gc.Cluster.DNS.Host, &gc.Cluster.DNS.Port,
+		&staticJSON, &rangesJSON, &gc.Cluster.Cipher,
+		&tryInterval, &retries, &triggerBuffer,
+		&gc.ConfigVersion,
+	)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrClusterNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cluster: %w", err)
+	}
+
+	gc.HasCA = caCert.Valid && caCert.String != ""
+	gc.Cluster.LighthousePort = int(lighthousePort.Int64)
+	gc.Cluster.PreferredRanges = parseJSONList(rangesJSON)
+	gc.Cluster.Handshake = config.Handshake{
+		TryInterval:   tryInterval.String,
+		Retries:       int(retries.Int64),
+		TriggerBuffer: int(triggerBuffer.Int64),
+	}
+	if staticJSON.Valid && staticJSON.String != "" {
+		if err := json.Unmarshal([]byte(staticJSON.String), &gc.Cluster.StaticHostMap); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal static host map: %w", err)
+		}
+	}
+
+	return gc, nil
+}
+
+// loadGeneratorNodes loads the cluster's nodes, sorted by name, in generator form.
+func (s *TopologyService) loadGeneratorNodes(clusterID string) ([]config.Node, error) {
+	rows, err := s.db.Query(`
+		SELECT id, name, mtu, is_lighthouse, lighthouse_port, is_relay, preferred_relays
+		FROM nodes
+		WHERE cluster_id = ? AND deleted_at IS NULL
+		ORDER BY name
+	`, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nodes: %w", err)
+	}
+	defer rows.Close()
+
+	var nodes []config.Node
+	for rows.Next() {
+		var node config.Node
+		var lighthousePort sql.NullInt64
+		var preferredRelays sql.NullString
+		if err := rows.Scan(&node.ID, &node.Name, &node.MTU, &node.IsLighthouse, &lighthousePort, &node.IsRelay, &preferredRelays); err != nil {
+			return nil, fmt.Errorf("failed to scan node: %w", err)
+		}
+		node.LighthousePort = int(lighthousePort.Int64)
+		node.PreferredRelays = parseJSONList(preferredRelays)
+		nodes = append(nodes, node)
+	}
+
+	return nodes, rows.Err()
+}
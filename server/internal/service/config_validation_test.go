@@ -0,0 +1,76 @@
+package service
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+	"nebulagc.io/server/internal/config"
+)
+
+// hasIssue reports whether issues contains the given code.
+func hasIssue(issues []config.Issue, code string) bool {
+	for _, issue := range issues {
+		if issue.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTopologyService_ValidateConfig(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	logger := zap.NewNop()
+	service := NewTopologyService(db, logger, "secret")
+
+	// Fresh cluster: no CA, no lighthouse
+	report, err := service.ValidateConfig("cluster1")
+	if err != nil {
+		t.Fatalf("ValidateConfig failed: %v", err)
+	}
+	if report.Valid {
+		t.Error("Expected invalid report for cluster without CA or lighthouse")
+	}
+	if !hasIssue(report.Errors, config.IssueMissingCA) || !hasIssue(report.Errors, config.IssueNoLighthouse) {
+		t.Errorf("Expected missing_ca and no_lighthouse errors, got %+v", report.Errors)
+	}
+	if report.RepresentativeNodeID != "node1" {
+		t.Errorf("Expected representative node1, got %s", report.RepresentativeNodeID)
+	}
+
+	// Fix CA and lighthouse, then add overlapping routes
+	db.Exec(`UPDATE clusters SET pki_ca_cert = 'ca' WHERE id = 'cluster1'`)
+	if err := service.SetLighthouse("cluster1", "node3", "203.0.113.1", 4242); err != nil {
+		t.Fatalf("SetLighthouse failed: %v", err)
+	}
+	// Route updates reject overlaps unless the cluster allows them
+	if err := service.SetRoutePolicy("cluster1", &models.RoutePolicy{AllowOverlappingRoutes: true}); err != nil {
+		t.Fatalf("SetRoutePolicy failed: %v", err)
+	}
+	service.UpdateRoutes("node1", []string{"192.168.0.0/16"})
+	service.UpdateRoutes("node2", []string{"192.168.10.0/24"})
+
+	report, _ = service.ValidateConfig("cluster1")
+	if !hasIssue(report.Errors, config.IssueOverlappingRoutes) {
+		t.Errorf("Expected overlapping_routes error, got %+v", report.Errors)
+	}
+
+	service.UpdateRoutes("node2", []string{"10.10.0.0/24"})
+	report, _ = service.ValidateConfig("cluster1")
+	if !report.Valid {
+		t.Errorf("Expected valid report, got %+v", report.Errors)
+	}
+
+	// Generator errors surface for the lighthouse representative
+	db.Exec(`UPDATE clusters SET lighthouse_dns_enabled = 1, lighthouse_dns_port = 4242 WHERE id = 'cluster1'`)
+	report, _ = service.ValidateConfig("cluster1")
+	if !hasIssue(report.Errors, config.IssueGenerateFailed) {
+		t.Errorf("Expected generate_failed error, got %+v", report.Errors)
+	}
+
+	if _, err := service.ValidateConfig("missing"); err == nil {
+		t.Error("Expected error for missing cluster")
+	}
+}
@@ -0,0 +1,343 @@
+package service
+
+import (
+	"fmt"
+	"net"
+
+	"nebulagc.io/models"
+	"nebulagc.io/server/internal/config"
+)
+
+// ConnectivityNode identifies a row and column of a ConnectivityMatrix.
+type ConnectivityNode struct {
+	// NodeID is the node's UUID.
+	NodeID string `json:"node_id"`
+
+	// Name is the node's name.
+	Name string `json:"name"`
+}
+
+// ConnectivityMatrix reports which nodes of a cluster should be able to reach
+// which for one kind of traffic, according to their generated configs.
+type ConnectivityMatrix struct {
+	// Flow is the traffic evaluated.
+	Flow config.Flow `json:"flow"`
+
+	// Nodes lists the cluster's nodes, sorted by name.
+	Nodes []ConnectivityNode `json:"nodes"`
+
+	// Reachable[i][j] reports whether Nodes[i] can reach Nodes[j].
+	Reachable [][]bool `json:"reachable"`
+
+	// Relayed[i][j] reports whether Nodes[i] can still reach Nodes[j] through
+	// a relay when a direct connection between them is impossible.
+	Relayed [][]bool `json:"relayed"`
+
+	// Isolated lists the IDs of nodes that can neither reach nor be reached
+	// by any other node (empty for single-node clusters).
+	Isolated []string `json:"isolated"`
+
+	// RelayIsolated lists the IDs of nodes that can neither reach nor be
+	// reached by any other node through a relay, so they depend entirely on
+	// direct connections (empty for single-node clusters).
+	RelayIsolated []string `json:"relay_isolated"`
+}
+
+// ConnectivityPair is an ordered pair of nodes in a connectivity diff.
+type ConnectivityPair struct {
+	// From is the ID of the node initiating traffic.
+	From string `json:"from"`
+
+	// To is the ID of the node receiving traffic.
+	To string `json:"to"`
+
+	// Relayed is true when the pair is about reachability through a relay
+	// rather than direct reachability.
+	Relayed bool `json:"relayed"`
+}
+
+// TopologyWhatIf is the result of simulating topology changes.
+type TopologyWhatIf struct {
+	// Current is the connectivity of the cluster as stored.
+	Current *ConnectivityMatrix `json:"current"`
+
+	// Proposed is the connectivity after the proposed changes.
+	Proposed *ConnectivityMatrix `json:"proposed"`
+
+	// Lost lists the pairs reachable now but not after the changes.
+	Lost []ConnectivityPair `json:"lost"`
+
+	// Gained lists the pairs reachable after the changes but not now.
+	Gained []ConnectivityPair `json:"gained"`
+
+	// NewlyIsolated lists the nodes the changes isolate.
+	NewlyIsolated []string `json:"newly_isolated"`
+
+	// NewlyRelayIsolated lists the nodes the changes leave without any
+	// relayed path.
+	NewlyRelayIsolated []string `json:"newly_relay_isolated"`
+}
+
+// connectivityState is the stored state a connectivity matrix is computed
+// from. WhatIf applies proposed changes to a copy of it.
+type connectivityState struct {
+	cluster  generatorCluster
+	nodes    []config.Node
+	topology TopologyInfo
+}
+
+// ConnectivityMatrix generates every node's config against the cluster's
+// current topology and evaluates the flow between each pair of nodes, both
+// directly and through relays. See config.Connectivity and
+// config.RelayedConnectivity for the rules applied.
+//
+// Parameters:
+//   - clusterID: Cluster UUID
+//   - flow: Traffic to evaluate
+//
+// Returns:
+//   - Connectivity matrix with the nodes it is indexed by
+//   - Error if the flow is invalid, the cluster is not found, the generator
+//     rejects the cluster settings, or a query fails
+func (s *TopologyService) ConnectivityMatrix(clusterID string, flow config.Flow) (*ConnectivityMatrix, error) {
+	if err := flow.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidRequest, err)
+	}
+
+	state, err := s.loadConnectivityState(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	return state.matrix(flow)
+}
+
+// WhatIf simulates topology changes: it applies them in order to a copy of the
+// cluster's topology and reports the connectivity before and after, with the
+// pairs of nodes that lose or gain reachability. Nothing is persisted.
+//
+// Parameters:
+//   - clusterID: Cluster UUID
+//   - flow: Traffic to evaluate
+//   - changes: Proposed changes (see the models.TopologyChange* actions)
+//
+// Returns:
+//   - Connectivity before and after the changes, and their difference
+//   - Error if the flow or a change is invalid (ErrInvalidRequest), a change
+//     names a node outside the cluster (ErrNodeNotFound), the cluster is not
+//     found, or a query fails
+func (s *TopologyService) WhatIf(clusterID string, flow config.Flow, changes []models.TopologyChange) (*TopologyWhatIf, error) {
+	if err := flow.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidRequest, err)
+	}
+
+	current, err := s.loadConnectivityState(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	proposed := current.clone()
+	for i, change := range changes {
+		if err := proposed.apply(change); err != nil {
+			return nil, fmt.Errorf("change %d: %w", i, err)
+		}
+	}
+
+	result := &TopologyWhatIf{
+		Lost:               []ConnectivityPair{},
+		Gained:             []ConnectivityPair{},
+		NewlyIsolated:      []string{},
+		NewlyRelayIsolated: []string{},
+	}
+	if result.Current, err = current.matrix(flow); err != nil {
+		return nil, err
+	}
+	if result.Proposed, err = proposed.matrix(flow); err != nil {
+		return nil, fmt.Errorf("%w: proposed topology: %v", models.ErrInvalidRequest, err)
+	}
+
+	nodes := result.Current.Nodes
+	for i := range nodes {
+		for j := range nodes {
+			diffPair(result, nodes[i].NodeID, nodes[j].NodeID, false,
+				result.Current.Reachable[i][j], result.Proposed.Reachable[i][j])
+			diffPair(result, nodes[i].NodeID, nodes[j].NodeID, true,
+				result.Current.Relayed[i][j], result.Proposed.Relayed[i][j])
+		}
+	}
+	result.NewlyIsolated = newIDs(result.Current.Isolated, result.Proposed.Isolated)
+	result.NewlyRelayIsolated = newIDs(result.Current.RelayIsolated, result.Proposed.RelayIsolated)
+
+	return result, nil
+}
+
+// diffPair records a pair in Lost or Gained when its reachability changed.
+func diffPair(result *TopologyWhatIf, from, to string, relayed, before, after bool) {
+	pair := ConnectivityPair{From: from, To: to, Relayed: relayed}
+	switch {
+	case before && !after:
+		result.Lost = append(result.Lost, pair)
+	case !before && after:
+		result.Gained = append(result.Gained, pair)
+	}
+}
+
+// newIDs returns the IDs in after that are not in before, in order.
+func newIDs(before, after []string) []string {
+	seen := make(map[string]bool, len(before))
+	for _, id := range before {
+		seen[id] = true
+	}
+	added := []string{}
+	for _, id := range after {
+		if !seen[id] {
+			added = append(added, id)
+		}
+	}
+	return added
+}
+
+// loadConnectivityState loads the state a connectivity matrix is computed from.
+func (s *TopologyService) loadConnectivityState(clusterID string) (*connectivityState, error) {
+	gc, err := s.loadGeneratorCluster(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := s.loadGeneratorNodes(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	topology, err := s.GetTopology(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &connectivityState{cluster: *gc, nodes: nodes, topology: *topology}, nil
+}
+
+// clone returns a copy of the state that apply can modify without affecting
+// the original or the topology cache.
+func (st *connectivityState) clone() *connectivityState {
+	c := &connectivityState{cluster: st.cluster, topology: st.topology}
+	c.nodes = append([]config.Node(nil), st.nodes...)
+	c.topology.Lighthouses = append([]LighthouseInfo(nil), st.topology.Lighthouses...)
+	c.topology.Relays = append([]RelayInfo(nil), st.topology.Relays...)
+	return c
+}
+
+// apply makes a proposed change to the state.
+func (st *connectivityState) apply(change models.TopologyChange) error {
+	if change.Action == models.TopologyChangeSetMaxRelayHops {
+		if change.MaxRelayHops < 0 || change.MaxRelayHops > config.MaxRelayHopsLimit {
+			return fmt.Errorf("%w: %v", models.ErrInvalidRequest, config.ErrInvalidRelayHops)
+		}
+		st.cluster.Cluster.MaxRelayHops = change.MaxRelayHops
+		return nil
+	}
+
+	var node *config.Node
+	for i := range st.nodes {
+		if st.nodes[i].ID == change.NodeID {
+			node = &st.nodes[i]
+			break
+		}
+	}
+	if node == nil {
+		return models.ErrNodeNotFound
+	}
+
+	switch change.Action {
+	case models.TopologyChangeSetLighthouse:
+		if net.ParseIP(change.PublicIP) == nil {
+			return fmt.Errorf("%w: invalid IP address", models.ErrInvalidRequest)
+		}
+		node.IsLighthouse = true
+		node.LighthousePort = change.Port
+		st.removeLighthouse(node.ID)
+		st.topology.Lighthouses = append(st.topology.Lighthouses, LighthouseInfo{
+			NodeID: node.ID, Name: node.Name, PublicIP: change.PublicIP, Port: change.Port,
+		})
+	case models.TopologyChangeUnsetLighthouse:
+		node.IsLighthouse = false
+		node.LighthousePort = 0
+		st.removeLighthouse(node.ID)
+	case models.TopologyChangeSetRelay:
+		if !node.IsRelay {
+			st.topology.Relays = append(st.topology.Relays, RelayInfo{NodeID: node.ID, Name: node.Name})
+		}
+		node.IsRelay = true
+	case models.TopologyChangeUnsetRelay:
+		node.IsRelay = false
+		relays := st.topology.Relays[:0]
+		for _, r := range st.topology.Relays {
+			if r.NodeID != node.ID {
+				relays = append(relays, r)
+			}
+		}
+		st.topology.Relays = relays
+	default:
+		return fmt.Errorf("%w: unknown action %q", models.ErrInvalidRequest, change.Action)
+	}
+	return nil
+}
+
+// removeLighthouse drops a node from the state's lighthouse list.
+func (st *connectivityState) removeLighthouse(nodeID string) {
+	lighthouses := st.topology.Lighthouses[:0]
+	for _, lh := range st.topology.Lighthouses {
+		if lh.NodeID != nodeID {
+			lighthouses = append(lighthouses, lh)
+		}
+	}
+	st.topology.Lighthouses = lighthouses
+}
+
+// matrix generates every node's config and evaluates the flow between them.
+func (st *connectivityState) matrix(flow config.Flow) (*ConnectivityMatrix, error) {
+	matrix := &ConnectivityMatrix{
+		Flow:          flow,
+		Nodes:         make([]ConnectivityNode, len(st.nodes)),
+		Isolated:      []string{},
+		RelayIsolated: []string{},
+	}
+	configs := make([]*config.NebulaConfig, len(st.nodes))
+	addrs := make([]string, len(st.nodes))
+	for i, node := range st.nodes {
+		cfg, err := config.Generate(addressedInput(&st.cluster, node, &st.topology))
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate config for node %s: %w", node.ID, err)
+		}
+		matrix.Nodes[i] = ConnectivityNode{NodeID: node.ID, Name: node.Name}
+		configs[i] = cfg
+		addrs[i] = node.ID
+	}
+	matrix.Reachable = config.Connectivity(configs, flow)
+	matrix.Relayed = config.RelayedConnectivity(configs, addrs, flow)
+
+	if len(st.nodes) > 1 {
+		matrix.Isolated = isolatedNodes(st.nodes, matrix.Reachable)
+		matrix.RelayIsolated = isolatedNodes(st.nodes, matrix.Relayed)
+	}
+
+	return matrix, nil
+}
+
+// isolatedNodes returns the IDs of nodes with no reachable peer in either
+// direction.
+func isolatedNodes(nodes []config.Node, reachable [][]bool) []string {
+	isolated := []string{}
+	for i, node := range nodes {
+		connected := false
+		for j := range nodes {
+			if reachable[i][j] || reachable[j][i] {
+				connected = true
+				break
+			}
+		}
+		if !connected {
+			isolated = append(isolated, node.ID)
+		}
+	}
+	return isolated
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+	"nebulagc.io/server/internal/config"
+	"nebulagc.io/server/internal/testutil"
+)
+
+func TestTopologyService_ConnectivityMatrix(t *testing.T) {
+	db := testutil.OpenDB(t)
+	tenantID := testutil.Tenant(t, db, "tenant")
+	clusterID, _ := testutil.Cluster(t, db, tenantID, "cluster")
+	a, _ := testutil.Node(t, db, tenantID, clusterID, "a", false)
+	b, _ := testutil.Node(t, db, tenantID, clusterID, "b", false)
+	c, _ := testutil.Node(t, db, tenantID, clusterID, "c", false)
+
+	service := NewTopologyService(db, zap.NewNop(), testutil.TestHMACSecret)
+	icmp := config.Flow{Proto: config.ProtoICMP}
+
+	// Without a lighthouse no node can discover its peers
+	matrix, err := service.ConnectivityMatrix(clusterID, icmp)
+	if err != nil {
+		t.Fatalf("ConnectivityMatrix failed: %v", err)
+	}
+	if !reflect.DeepEqual(matrix.Isolated, []string{a, b, c}) {
+		t.Errorf("Expected every node isolated, got %v", matrix.Isolated)
+	}
+
+	// Generated configs allow inbound icmp from anywhere
+	if err := service.SetLighthouse(clusterID, a, "203.0.113.1", 4242); err != nil {
+		t.Fatalf("SetLighthouse failed: %v", err)
+	}
+	matrix, err = service.ConnectivityMatrix(clusterID, icmp)
+	if err != nil {
+		t.Fatalf("ConnectivityMatrix failed: %v", err)
+	}
+	want := [][]bool{
+		{false, true, true},
+		{true, false, true},
+		{true, true, false},
+	}
+	if !reflect.DeepEqual(matrix.Reachable, want) || len(matrix.Isolated) != 0 {
+		t.Errorf("Expected full icmp mesh, got %v (isolated %v)", matrix.Reachable, matrix.Isolated)
+	}
+	if matrix.Nodes[0].NodeID != a || matrix.Nodes[2].Name != "c" {
+		t.Errorf("Expected nodes sorted by name, got %+v", matrix.Nodes)
+	}
+
+	// ...and deny everything else inbound
+	matrix, err = service.ConnectivityMatrix(clusterID, config.Flow{Proto: config.ProtoTCP, Port: 22})
+	if err != nil {
+		t.Fatalf("ConnectivityMatrix failed: %v", err)
+	}
+	if len(matrix.Isolated) != 3 {
+		t.Errorf("Expected tcp/22 to be denied everywhere, got %v", matrix.Reachable)
+	}
+
+	if _, err := service.ConnectivityMatrix(clusterID, config.Flow{Proto: "gre"}); !errors.Is(err, models.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for unknown protocol, got %v", err)
+	}
+	if _, err := service.ConnectivityMatrix("missing", icmp); !errors.Is(err, models.ErrClusterNotFound) {
+		t.Errorf("Expected ErrClusterNotFound for missing cluster, got %v", err)
+	}
+}
+
+func TestTopologyService_WhatIf(t *testing.T) {
+	db := testutil.OpenDB(t)
+	tenantID := testutil.Tenant(t, db, "tenant")
+	clusterID, _ := testutil.Cluster(t, db, tenantID, "cluster")
+	a, _ := testutil.Node(t, db, tenantID, clusterID, "a", false)
+	b, _ := testutil.Node(t, db, tenantID, clusterID, "b", false)
+	lighthouse, _ := testutil.LighthouseNode(t, db, tenantID, clusterID, "lighthouse", "203.0.113.1", 4242)
+	relay, _ := testutil.RelayNode(t, db, tenantID, clusterID, "relay")
+
+	service := NewTopologyService(db, zap.NewNop(), testutil.TestHMACSecret)
+	icmp := config.Flow{Proto: config.ProtoICMP}
+
+	// Demoting the only relay leaves every node dependent on direct connections
+	whatIf, err := service.WhatIf(clusterID, icmp, []models.TopologyChange{
+		{Action: models.TopologyChangeUnsetRelay, NodeID: relay},
+	})
+	if err != nil {
+		t.Fatalf("WhatIf failed: %v", err)
+	}
+	if !reflect.DeepEqual(whatIf.Current.RelayIsolated, []string{relay}) {
+		t.Errorf("Expected only the relay to lack a relayed path now, got %v", whatIf.Current.RelayIsolated)
+	}
+	if !reflect.DeepEqual(whatIf.NewlyRelayIsolated, []string{a, b, lighthouse}) {
+		t.Errorf("Expected a, b and the lighthouse to lose every relayed path, got %v", whatIf.NewlyRelayIsolated)
+	}
+	lost := ConnectivityPair{From: a, To: b, Relayed: true}
+	found := false
+	for _, pair := range whatIf.Lost {
+		if pair == lost {
+			found = true
+		}
+		if !pair.Relayed {
+			t.Errorf("Expected direct reachability to be unaffected, lost %+v", pair)
+		}
+	}
+	if !found {
+		t.Errorf("Expected %+v in lost pairs, got %+v", lost, whatIf.Lost)
+	}
+	if len(whatIf.Gained) != 0 || len(whatIf.NewlyIsolated) != 0 {
+		t.Errorf("Expected no gains or direct isolation, got %+v / %v", whatIf.Gained, whatIf.NewlyIsolated)
+	}
+
+	// Demoting the only lighthouse isolates every node; promoting another
+	// lighthouse in the same proposal restores reachability
+	whatIf, err = service.WhatIf(clusterID, icmp, []models.TopologyChange{
+		{Action: models.TopologyChangeUnsetLighthouse, NodeID: lighthouse},
+	})
+	if err != nil {
+		t.Fatalf("WhatIf failed: %v", err)
+	}
+	if !reflect.DeepEqual(whatIf.NewlyIsolated, []string{a, b, lighthouse, relay}) {
+		t.Errorf("Expected every node isolated without a lighthouse, got %v", whatIf.NewlyIsolated)
+	}
+	whatIf, err = service.WhatIf(clusterID, icmp, []models.TopologyChange{
+		{Action: models.TopologyChangeUnsetLighthouse, NodeID: lighthouse},
+		{Action: models.TopologyChangeSetLighthouse, NodeID: a, PublicIP: "203.0.113.2"},
+	})
+	if err != nil {
+		t.Fatalf("WhatIf failed: %v", err)
+	}
+	if len(whatIf.NewlyIsolated) != 0 || len(whatIf.Proposed.Isolated) != 0 {
+		t.Errorf("Expected no isolation after replacing the lighthouse, got %v", whatIf.Proposed.Isolated)
+	}
+
+	// Nothing was persisted
+	topology, err := service.GetTopology(clusterID)
+	if err != nil {
+		t.Fatalf("GetTopology failed: %v", err)
+	}
+	if len(topology.Relays) != 1 || len(topology.Lighthouses) != 1 || topology.Lighthouses[0].NodeID != lighthouse {
+		t.Errorf("Expected topology unchanged, got %+v", topology)
+	}
+
+	invalid := [][]models.TopologyChange{
+		{{Action: "delete_everything", NodeID: a}},
+		{{Action: models.TopologyChangeSetLighthouse, NodeID: a, PublicIP: "not-an-ip"}},
+		{{Action: models.TopologyChangeSetMaxRelayHops, MaxRelayHops: config.MaxRelayHopsLimit + 1}},
+	}
+	for _, changes := range invalid {
+		if _, err := service.WhatIf(clusterID, icmp, changes); !errors.Is(err, models.ErrInvalidRequest) {
+			t.Errorf("Expected ErrInvalidRequest for %+v, got %v", changes, err)
+		}
+	}
+	_, err = service.WhatIf(clusterID, icmp, []models.TopologyChange{{Action: models.TopologyChangeUnsetRelay, NodeID: "missing"}})
+	if !errors.Is(err, models.ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound for unknown node, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"hash"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+)
+
+// consistencyVolatileColumns are node columns left out of content hashes:
+// nodes report health constantly, so including them would make in-sync
+// instances look divergent whenever a heartbeat is in flight.
+var consistencyVolatileColumns = map[string]bool{
+	"last_heartbeat_at": true,
+	"reported_version":  true,
+	"health_status":     true,
+	"health_message":    true,
+}
+
+// ConsistencyService summarizes an instance's data so replicas can be
+// compared with the master.
+type ConsistencyService struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+// NewConsistencyService creates a new consistency service.
+//
+// Parameters:
+//   - db: Database connection
+//   - logger: Zap logger for structured logging
+//
+// Returns:
+//   - Configured ConsistencyService
+func NewConsistencyService(db *sql.DB, logger *zap.Logger) *ConsistencyService {
+	return &ConsistencyService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Clusters returns the config version, latest bundle version and content
+// hash of every cluster in a tenant, as stored in this instance's database.
+//
+// The hash covers the cluster row, its nodes (except health reports), its
+// bundles (by checksum) and its canary rollout. Columns are hashed by name,
+// so an instance missing a migration also reports a different hash.
+//
+// Parameters:
+//   - tenantID: Tenant ID
+//
+// Returns:
+//   - One entry per cluster, ordered by cluster ID
+//   - Error if a query fails
+func (s *ConsistencyService) Clusters(tenantID string) ([]models.ClusterConsistency, error) {
+	rows, err := s.db.Query(`
+		SELECT c.id, c.config_version, COALESCE(MAX(b.version), 0)
+		FROM clusters c
+		LEFT JOIN config_bundles b ON b.cluster_id = c.id
+		WHERE c.tenant_id = ?
+		GROUP BY c.id
+		ORDER BY c.id
+	`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query clusters: %w", err)
+	}
+
+	clusters := []models.ClusterConsistency{}
+	for rows.Next() {
+		var cluster models.ClusterConsistency
+		if err := rows.Scan(&cluster.ClusterID, &cluster.ConfigVersion, &cluster.LatestBundleVersion); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan cluster: %w", err)
+		}
+		clusters = append(clusters, cluster)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query clusters: %w", err)
+	}
+
+	for i := range clusters {
+		contentHash, err := s.contentHash(clusters[i].ClusterID)
+		if err != nil {
+			return nil, err
+		}
+		clusters[i].ContentHash = contentHash
+	}
+
+	return clusters, nil
+}
+
+// contentHash hashes everything stored for a cluster that affects the
+// configs its nodes receive.
+func (s *ConsistencyService) contentHash(clusterID string) (string, error) {
+	h := sha256.New()
+
+	sections := []struct {
+		name  string
+		query string
+		skip  map[string]bool
+	}{
+		{"clusters", `SELECT * FROM clusters WHERE id = ?`, nil},
+		{"nodes", `SELECT * FROM nodes WHERE cluster_id = ? ORDER BY id`, consistencyVolatileColumns},
+		// Bundle data is represented by its checksum, and only read for bundles
+		// stored before checksums were recorded
+		{"config_bundles", `
+			SELECT version, size, checksum, effective_at, description,
+			       CASE WHEN checksum IS NULL THEN data END AS data
+			FROM config_bundles WHERE cluster_id = ? ORDER BY version`, nil},
+		{"canary_rollouts", `SELECT * FROM canary_rollouts WHERE cluster_id = ?`, nil},
+		{"canary_nodes", `SELECT * FROM canary_nodes WHERE cluster_id = ? ORDER BY node_id`, nil},
+	}
+
+	for _, section := range sections {
+		fmt.Fprintf(h, "[%s]\n", section.name)
+		if err := hashRows(h, s.db, section.query, clusterID, section.skip); err != nil {
+			return "", fmt.Errorf("failed to hash %s for cluster %s: %w", section.name, clusterID, err)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashRows writes every column of every row returned by query to h as
+// name=value pairs, skipping the named columns. BLOB values are written as
+// their SHA-256.
+func hashRows(h hash.Hash, db *sql.DB, query, arg string, skip map[string]bool) error {
+	rows, err := db.Query(query, arg)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	values := make([]any, len(columns))
+	ptrs := make([]any, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		for i, column := range columns {
+			if skip[column] {
+				continue
+			}
+			value := values[i]
+			if blob, ok := value.([]byte); ok {
+				sum := sha256.Sum256(blob)
+				value = hex.EncodeToString(sum[:])
+			}
+			fmt.Fprintf(h, "%s=%q\x00", column, fmt.Sprint(value))
+		}
+		h.Write([]byte{'\n'})
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"nebulagc.io/server/internal/testutil"
+)
+
+// snapshotDB copies db to a new database, as replication would.
+func snapshotDB(t *testing.T, db *sql.DB) *sql.DB {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "replica.db")
+	if _, err := db.Exec(`VACUUM INTO ?`, path); err != nil {
+		t.Fatalf("Failed to copy database: %v", err)
+	}
+	replica, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Failed to open copy: %v", err)
+	}
+	t.Cleanup(func() { replica.Close() })
+	return replica
+}
+
+func TestConsistencyService_Clusters(t *testing.T) {
+	db := testutil.OpenDB(t)
+	tenantID, clusterID, nodeIDs := testutil.Seed(t, db, 2)
+	otherCluster, _ := testutil.Cluster(t, db, tenantID, "Other Cluster")
+	testutil.Cluster(t, db, testutil.Tenant(t, db, "Other Tenant"), "Foreign Cluster")
+
+	version, err := uploadVersion(NewBundleService(db, zap.NewNop()).Upload(clusterID, createTestBundle()))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	master := NewConsistencyService(db, zap.NewNop())
+	replica := NewConsistencyService(snapshotDB(t, db), zap.NewNop())
+
+	report, err := master.Clusters(tenantID)
+	if err != nil {
+		t.Fatalf("Clusters failed: %v", err)
+	}
+	if len(report) != 2 {
+		t.Fatalf("Expected the tenant's 2 clusters, got %d", len(report))
+	}
+	for _, cluster := range report {
+		if cluster.ClusterID != clusterID && cluster.ClusterID != otherCluster {
+			t.Errorf("Unexpected cluster %s in report", cluster.ClusterID)
+		}
+		if cluster.ClusterID == clusterID && cluster.LatestBundleVersion != version {
+			t.Errorf("Expected latest bundle version %d, got %d", version, cluster.LatestBundleVersion)
+		}
+		if len(cluster.ContentHash) != 64 {
+			t.Errorf("Expected a SHA-256 content hash, got %q", cluster.ContentHash)
+		}
+	}
+
+	// An in-sync replica reports the same versions and hashes
+	replicaReport, err := replica.Clusters(tenantID)
+	if err != nil {
+		t.Fatalf("Clusters failed on replica: %v", err)
+	}
+	for i := range report {
+		if report[i] != replicaReport[i] {
+			t.Errorf("Expected in-sync replica to match master: %+v vs %+v", report[i], replicaReport[i])
+		}
+	}
+
+	// Heartbeats do not count as divergence
+	if _, err := db.Exec(`UPDATE nodes SET last_heartbeat_at = 1700000000, health_status = 'ok' WHERE id = ?`, nodeIDs[0]); err != nil {
+		t.Fatalf("Failed to record heartbeat: %v", err)
+	}
+	if after, _ := master.Clusters(tenantID); after[0] != report[0] || after[1] != report[1] {
+		t.Error("Expected a heartbeat to leave the content hash unchanged")
+	}
+
+	// A change the replica has not received shows up as a different hash
+	if _, err := db.Exec(`UPDATE nodes SET mtu = 1400 WHERE id = ?`, nodeIDs[1]); err != nil {
+		t.Fatalf("Failed to update node: %v", err)
+	}
+	after, err := master.Clusters(tenantID)
+	if err != nil {
+		t.Fatalf("Clusters failed: %v", err)
+	}
+	for i := range after {
+		changed := after[i].ContentHash != replicaReport[i].ContentHash
+		if changed != (after[i].ClusterID == clusterID) {
+			t.Errorf("Cluster %s hash changed = %v, want change only for the updated cluster", after[i].ClusterID, changed)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"nebulagc.io/models"
+	"nebulagc.io/server/internal/config"
+)
+
+// EffectiveConfig is a node's fully-resolved Nebula configuration as computed
+// by the server-side generator.
+//
+// The control plane does not track overlay IPs (they live in each node's
+// certificate, shipped in the config bundle), so peers in Config are referenced
+// by node ID wherever Nebula would use an overlay IP: lighthouse hosts, relays,
+// unsafe route gateways, and the lighthouse entries of the static host map.
+type EffectiveConfig struct {
+	// NodeID is the node the config was generated for.
+	NodeID string `json:"node_id"`
+
+	// Name is the node's name.
+	Name string `json:"name"`
+
+	// ClusterID is the node's cluster.
+	ClusterID string `json:"cluster_id"`
+
+	// ConfigVersion is the cluster config version the config reflects.
+	ConfigVersion int64 `json:"config_version"`
+
+	// Routes lists the networks the node itself advertises.
+	Routes []string `json:"routes"`
+
+	// Config is the generated Nebula configuration.
+	Config *config.NebulaConfig `json:"config"`
+}
+
+// EffectiveConfig runs the config generator for a single node against the
+// cluster's current topology and returns the result.
+//
+// Parameters:
+//   - clusterID: Cluster UUID
+//   - nodeID: Node UUID (must belong to the cluster)
+//
+// Returns:
+//   - The node's resolved configuration
+//   - Error if the cluster or node is not found, the generator rejects the
+//     cluster settings, or a query fails
+func (s *TopologyService) EffectiveConfig(clusterID, nodeID string) (*EffectiveConfig, error) {
+	gc, err := s.loadGeneratorCluster(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := s.loadGeneratorNodes(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var node *config.Node
+	for i := range nodes {
+		if nodes[i].ID == nodeID {
+			node = &nodes[i]
+			break
+		}
+	}
+	if node == nil {
+		return nil, models.ErrNodeNotFound
+	}
+
+	topology, err := s.GetTopology(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.Generate(addressedInput(gc, *node, topology))
+	if err != nil {
+		return nil, err
+	}
+
+	routes := topology.Routes[nodeID]
+	if routes == nil {
+		routes = []string{}
+	}
+
+	return &EffectiveConfig{
+		NodeID:        node.ID,
+		Name:          node.Name,
+		ClusterID:     clusterID,
+		ConfigVersion: gc.ConfigVersion,
+		Routes:        routes,
+		Config:        cfg,
+	}, nil
+}
+
+// addressedInput builds the generator input for node, addressing the node and
+// its peers by node ID in place of their overlay IP.
+func addressedInput(gc *generatorCluster, node config.Node, topology *TopologyInfo) *config.Input {
+	in := &config.Input{Cluster: gc.Cluster, Node: node}
+	in.Node.NebulaIP = node.ID
+	for _, lh := range topology.Lighthouses {
+		in.Lighthouses = append(in.Lighthouses, config.Peer{NodeID: lh.NodeID, Name: lh.Name, NebulaIP: lh.NodeID, PublicIP: lh.PublicIP, Port: lh.Port})
+	}
+	for _, r := range topology.Relays {
+		in.Relays = append(in.Relays, config.Peer{NodeID: r.NodeID, Name: r.Name, NebulaIP: r.NodeID})
+	}
+	for id, routes := range topology.Routes {
+		in.RouteAdvertisers = append(in.RouteAdvertisers, config.Peer{NodeID: id, NebulaIP: id, Routes: routes})
+	}
+	return in
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+	"nebulagc.io/server/internal/config"
+	"nebulagc.io/server/internal/testutil"
+)
+
+func TestTopologyService_EffectiveConfig(t *testing.T) {
+	db := testutil.OpenDB(t)
+	tenantID := testutil.Tenant(t, db, "tenant")
+	clusterID, _ := testutil.Cluster(t, db, tenantID, "cluster")
+	lighthouse, _ := testutil.LighthouseNode(t, db, tenantID, clusterID, "lighthouse", "203.0.113.1", 4242)
+	relay, _ := testutil.RelayNode(t, db, tenantID, clusterID, "relay")
+	worker, _ := testutil.Node(t, db, tenantID, clusterID, "worker", false)
+
+	service := NewTopologyService(db, zap.NewNop(), testutil.TestHMACSecret)
+	if err := service.UpdateRoutes(relay, []string{"10.1.0.0/24"}); err != nil {
+		t.Fatalf("UpdateRoutes failed: %v", err)
+	}
+
+	effective, err := service.EffectiveConfig(clusterID, worker)
+	if err != nil {
+		t.Fatalf("EffectiveConfig failed: %v", err)
+	}
+	if effective.Name != "worker" || effective.ConfigVersion == 0 || len(effective.Routes) != 0 {
+		t.Errorf("Unexpected node details: %+v", effective)
+	}
+
+	cfg := effective.Config
+	if !reflect.DeepEqual(cfg.Lighthouse.Hosts, []string{lighthouse}) {
+		t.Errorf("Expected lighthouse hosts [%s], got %v", lighthouse, cfg.Lighthouse.Hosts)
+	}
+	if !reflect.DeepEqual(cfg.StaticHostMap[lighthouse], []string{"203.0.113.1:4242"}) {
+		t.Errorf("Expected lighthouse endpoint in static host map, got %v", cfg.StaticHostMap)
+	}
+	if !cfg.Relay.UseRelays || !reflect.DeepEqual(cfg.Relay.Relays, []string{relay}) {
+		t.Errorf("Expected to use relay %s, got %+v", relay, cfg.Relay)
+	}
+	wantRoutes := []config.UnsafeRoute{{Route: "10.1.0.0/24", Via: relay}}
+	if !reflect.DeepEqual(cfg.Tun.UnsafeRoutes, wantRoutes) {
+		t.Errorf("Expected unsafe routes %v, got %v", wantRoutes, cfg.Tun.UnsafeRoutes)
+	}
+
+	// The advertising node reports its own routes but does not route through itself
+	effective, err = service.EffectiveConfig(clusterID, relay)
+	if err != nil {
+		t.Fatalf("EffectiveConfig failed: %v", err)
+	}
+	if !reflect.DeepEqual(effective.Routes, []string{"10.1.0.0/24"}) || len(effective.Config.Tun.UnsafeRoutes) != 0 {
+		t.Errorf("Unexpected relay routes: %v, unsafe routes %v", effective.Routes, effective.Config.Tun.UnsafeRoutes)
+	}
+	if !effective.Config.Relay.AmRelay || effective.Config.Relay.UseRelays {
+		t.Errorf("Expected relay to serve but not use relays, got %+v", effective.Config.Relay)
+	}
+
+	effective, err = service.EffectiveConfig(clusterID, lighthouse)
+	if err != nil {
+		t.Fatalf("EffectiveConfig failed: %v", err)
+	}
+	if !effective.Config.Lighthouse.AmLighthouse || len(effective.Config.Lighthouse.Hosts) != 0 || effective.Config.Listen.Port != 4242 {
+		t.Errorf("Unexpected lighthouse config: %+v, listen %+v", effective.Config.Lighthouse, effective.Config.Listen)
+	}
+
+	// Nodes of other clusters are not visible
+	otherCluster, _ := testutil.Cluster(t, db, tenantID, "other")
+	if _, err := service.EffectiveConfig(otherCluster, worker); !errors.Is(err, models.ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound for node of another cluster, got %v", err)
+	}
+	if _, err := service.EffectiveConfig("missing", worker); !errors.Is(err, models.ErrClusterNotFound) {
+		t.Errorf("Expected ErrClusterNotFound for missing cluster, got %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,6 +23,7 @@ type NodeService struct {
 	db     *sql.DB
 	logger *zap.Logger
 	secret string
+	actor  string // Node ID recorded as making config changes ("" = unknown)
 }
 
 // NewNodeService creates a new NodeService.
@@ -38,6 +40,20 @@ func NewNodeService(db *sql.DB, logger *zap.Logger, secret string) *NodeService
 	}
 }
 
+// WithActor returns a copy of the service that records actor (a node ID) as
+// the author of the config changes it makes.
+//
+// Parameters:
+//   - actor: Node ID making the changes
+//
+// Returns:
+//   - NodeService attributing changes to actor
+func (s *NodeService) WithActor(actor string) *NodeService {
+	scoped := *s
+	scoped.actor = actor
+	return &scoped
+}
+
 // CreateNode creates a new node within the provided tenant and cluster.
 //
 // Parameters:
@@ -62,6 +78,86 @@ func (s *NodeService) CreateNode(ctx context.Context, tenantID, clusterID, clust
 		return nil, err
 	}
 
+	creds, err := s.insertNode(ctx, s.db, tenantID, clusterID, clusterToken, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.bumpConfigVersion(ctx, tenantID, clusterID, models.ConfigChangeNodeCreated, creds.NodeID); err != nil {
+		return nil, err
+	}
+
+	return creds, nil
+}
+
+// CreateNodes creates several nodes within the provided tenant and cluster in
+// a single transaction, bumping the config version once. Either every node is
+// created or none is.
+//
+// Parameters:
+//   - ctx: Request context for cancellation
+//   - tenantID: Owning tenant ID
+//   - clusterID: Owning cluster ID
+//   - clusterToken: Raw cluster token (echoed back for convenience)
+//   - reqs: Node creation request payloads, at most models.MaxNodeBatchSize
+//
+// Returns:
+//   - Credentials of the new nodes, in the order of reqs
+//   - *models.BatchError wrapping the failure of the first rejected request
+//     (e.g. models.ErrDuplicateName), or another error if the batch is empty
+//     or too large, the cluster is not found, or database operations fail
+func (s *NodeService) CreateNodes(ctx context.Context, tenantID, clusterID, clusterToken string, reqs []*models.NodeCreateRequest) ([]*models.NodeCredentials, error) {
+	if len(reqs) == 0 || len(reqs) > models.MaxNodeBatchSize {
+		return nil, fmt.Errorf("%w: batch of %d nodes, must be 1-%d",
+			models.ErrInvalidRequest, len(reqs), models.MaxNodeBatchSize)
+	}
+	for i, req := range reqs {
+		if err := validateNodeName(req.Name); err != nil {
+			return nil, &models.BatchError{Index: i, Err: err}
+		}
+		if err := validateMTU(req.MTU); err != nil {
+			return nil, &models.BatchError{Index: i, Err: err}
+		}
+	}
+
+	if err := s.ensureClusterExists(ctx, tenantID, clusterID); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	creds := make([]*models.NodeCredentials, len(reqs))
+	for i, req := range reqs {
+		if creds[i], err = s.insertNode(ctx, tx, tenantID, clusterID, clusterToken, req); err != nil {
+			return nil, &models.BatchError{Index: i, Err: err}
+		}
+	}
+
+	_, clusterTenant, err := bumpConfigVersion(ctx, tx, clusterID, configChange{
+		changeType: models.ConfigChangeNodesCreated,
+		actor:      s.actor,
+		detail:     strconv.Itoa(len(reqs)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if clusterTenant != tenantID {
+		return nil, models.ErrClusterNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit node batch: %w", err)
+	}
+
+	return creds, nil
+}
+
+// insertNode inserts a validated node and returns its credentials.
+func (s *NodeService) insertNode(ctx context.Context, db sqlExecer, tenantID, clusterID, clusterToken string, req *models.NodeCreateRequest) (*models.NodeCredentials, error) {
 	nodeID := uuid.New().String()
 	nodeToken, err := token.Generate()
 	if err != nil {
@@ -80,7 +176,7 @@ func (s *NodeService) CreateNode(ctx context.Context, tenantID, clusterID, clust
 		) VALUES (?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err = s.db.ExecContext(ctx, insertQuery,
+	_, err = db.ExecContext(ctx, insertQuery,
 		nodeID, tenantID, clusterID, req.Name, boolToInt(req.IsAdmin), tokenHash, mtu,
 	)
 	if err != nil {
@@ -90,10 +186,6 @@ func (s *NodeService) CreateNode(ctx context.Context, tenantID, clusterID, clust
 		return nil, fmt.Errorf("failed to insert node: %w", err)
 	}
 
-	if err := s.bumpConfigVersion(ctx, tenantID, clusterID); err != nil {
-		return nil, err
-	}
-
 	return &models.NodeCredentials{
 		NodeID:       nodeID,
 		NodeToken:    nodeToken,
@@ -108,9 +200,10 @@ func (s *NodeService) CreateNode(ctx context.Context, tenantID, clusterID, clust
 //   - ctx: Request context
 //   - tenantID: Tenant scope
 //   - clusterID: Cluster scope
+//   - filter: Restricts the nodes listed (empty lists every node)
 //   - page: Page number (1-based)
 //   - pageSize: Items per page (clamped to 1..500)
-func (s *NodeService) ListNodes(ctx context.Context, tenantID, clusterID string, page, pageSize int) (*models.NodeListResponse, error) {
+func (s *NodeService) ListNodes(ctx context.Context, tenantID, clusterID string, filter models.NodeListFilter, page, pageSize int) (*models.NodeListResponse, error) {
 	if err := s.ensureClusterExists(ctx, tenantID, clusterID); err != nil {
 		return nil, err
 	}
@@ -127,26 +220,27 @@ func (s *NodeService) ListNodes(ctx context.Context, tenantID, clusterID string,
 
 	offset := (page - 1) * pageSize
 
+	where, args := nodeListWhere(tenantID, clusterID, filter)
+
 	countQuery := `
 		SELECT COUNT(*)
 		FROM nodes
-		WHERE tenant_id = ? AND cluster_id = ?
-	`
+		WHERE ` + where
 
 	var total int
-	if err := s.db.QueryRowContext(ctx, countQuery, tenantID, clusterID).Scan(&total); err != nil {
+	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
 		return nil, fmt.Errorf("failed to count nodes: %w", err)
 	}
 
 	listQuery := `
-		SELECT id, name, is_admin, mtu, is_lighthouse, is_relay, routes, created_at
+		SELECT id, name, is_admin, mtu, is_lighthouse, is_relay, routes, preferred_relays, created_at, updated_at
 		FROM nodes
-		WHERE tenant_id = ? AND cluster_id = ?
+		WHERE ` + where + `
 		ORDER BY created_at ASC
 		LIMIT ? OFFSET ?
 	`
 
-	rows, err := s.db.QueryContext(ctx, listQuery, tenantID, clusterID, pageSize, offset)
+	rows, err := s.db.QueryContext(ctx, listQuery, append(args, pageSize, offset)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list nodes: %w", err)
 	}
@@ -155,20 +249,15 @@ func (s *NodeService) ListNodes(ctx context.Context, tenantID, clusterID string,
 	var nodes []models.NodeSummary
 	for rows.Next() {
 		var n models.NodeSummary
-		var routes sql.NullString
-		if err := rows.Scan(&n.NodeID, &n.Name, &n.IsAdmin, &n.MTU, &n.IsLighthouse, &n.IsRelay, &routes, &n.CreatedAt); err != nil {
+		var routes, preferredRelays sql.NullString
+		var updatedAt sql.NullInt64
+		if err := rows.Scan(&n.NodeID, &n.Name, &n.IsAdmin, &n.MTU, &n.IsLighthouse, &n.IsRelay, &routes, &preferredRelays, &n.CreatedAt, &updatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan node: %w", err)
 		}
 
-		if routes.Valid && strings.TrimSpace(routes.String) != "" {
-			var parsed []string
-			if err := json.Unmarshal([]byte(routes.String), &parsed); err == nil {
-				n.Routes = parsed
-			}
-		}
-
-		// UpdatedAt not stored; mirror created_at for now
-		n.UpdatedAt = n.CreatedAt
+		n.Routes = parseJSONList(routes)
+		n.PreferredRelays = parseJSONList(preferredRelays)
+		n.UpdatedAt = nodeUpdatedAt(n.CreatedAt, updatedAt)
 		nodes = append(nodes, n)
 	}
 
@@ -185,6 +274,21 @@ func (s *NodeService) ListNodes(ctx context.Context, tenantID, clusterID string,
 	}, nil
 }
 
+// GetNode returns a single node of the given tenant and cluster.
+//
+// Parameters:
+//   - ctx: Request context
+//   - tenantID: Tenant scope
+//   - clusterID: Cluster scope
+//   - nodeID: Target node ID
+//
+// Returns:
+//   - *models.NodeSummary with the node's roles and routes
+//   - error: models.ErrNodeNotFound if no such node exists in the cluster
+func (s *NodeService) GetNode(ctx context.Context, tenantID, clusterID, nodeID string) (*models.NodeSummary, error) {
+	return s.getNodeSummary(ctx, tenantID, clusterID, nodeID)
+}
+
 // UpdateMTU updates the MTU for a specific node (admin only).
 //
 // Parameters:
@@ -200,9 +304,9 @@ func (s *NodeService) UpdateMTU(ctx context.Context, tenantID, clusterID, nodeID
 
 	result, err := s.db.ExecContext(ctx, `
 		UPDATE nodes
-		SET mtu = ?
-		WHERE id = ? AND tenant_id = ? AND cluster_id = ?
-	`, mtu, nodeID, tenantID, clusterID)
+		SET mtu = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ? AND cluster_id = ? AND deleted_at IS NULL
+	`, mtu, time.Now().Unix(), nodeID, tenantID, clusterID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update MTU: %w", err)
 	}
@@ -215,7 +319,7 @@ func (s *NodeService) UpdateMTU(ctx context.Context, tenantID, clusterID, nodeID
 		return nil, models.ErrNodeNotFound
 	}
 
-	if err := s.bumpConfigVersion(ctx, tenantID, clusterID); err != nil {
+	if err := s.bumpConfigVersion(ctx, tenantID, clusterID, models.ConfigChangeNodeMTU, nodeID); err != nil {
 		return nil, err
 	}
 
@@ -239,9 +343,9 @@ func (s *NodeService) RotateNodeToken(ctx context.Context, tenantID, clusterID,
 
 	result, err := s.db.ExecContext(ctx, `
 		UPDATE nodes
-		SET token_hash = ?
-		WHERE id = ? AND tenant_id = ? AND cluster_id = ?
-	`, hash, nodeID, tenantID, clusterID)
+		SET token_hash = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ? AND cluster_id = ? AND deleted_at IS NULL
+	`, hash, time.Now().Unix(), nodeID, tenantID, clusterID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to rotate token: %w", err)
 	}
@@ -254,7 +358,7 @@ func (s *NodeService) RotateNodeToken(ctx context.Context, tenantID, clusterID,
 		return nil, models.ErrNodeNotFound
 	}
 
-	if err := s.bumpConfigVersion(ctx, tenantID, clusterID); err != nil {
+	if err := s.bumpConfigVersion(ctx, tenantID, clusterID, models.ConfigChangeNodeTokenRotated, nodeID); err != nil {
 		return nil, err
 	}
 
@@ -266,7 +370,154 @@ func (s *NodeService) RotateNodeToken(ctx context.Context, tenantID, clusterID,
 	}, nil
 }
 
-// DeleteNode removes a node (admin only).
+// SetPreferredRelays sets the ordered list of relays a node should use (admin only).
+//
+// Each relay must be a relay node in the same cluster and cannot be the node
+// itself. Duplicate IDs are collapsed. An empty list clears the preference so
+// the node uses every relay in the cluster.
+//
+// Parameters:
+//   - ctx: Request context
+//   - tenantID: Tenant scope
+//   - clusterID: Cluster scope
+//   - nodeID: Target node ID
+//   - relayNodeIDs: Ordered relay node IDs
+func (s *NodeService) SetPreferredRelays(ctx context.Context, tenantID, clusterID, nodeID string, relayNodeIDs []string) (*models.NodeSummary, error) {
+	seen := make(map[string]bool, len(relayNodeIDs))
+	relays := make([]string, 0, len(relayNodeIDs))
+	for _, relayID := range relayNodeIDs {
+		if relayID == "" || relayID == nodeID {
+			return nil, fmt.Errorf("%w: invalid relay node ID %q", models.ErrInvalidRequest, relayID)
+		}
+		if seen[relayID] {
+			continue
+		}
+		seen[relayID] = true
+
+		var isRelay int
+		err := s.db.QueryRowContext(ctx, `
+			SELECT is_relay FROM nodes
+			WHERE id = ? AND tenant_id = ? AND cluster_id = ? AND deleted_at IS NULL
+		`, relayID, tenantID, clusterID).Scan(&isRelay)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s", models.ErrNotRelay, relayID)
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to check relay: %w", err)
+		}
+		if isRelay == 0 {
+			return nil, fmt.Errorf("%w: %s", models.ErrNotRelay, relayID)
+		}
+
+		relays = append(relays, relayID)
+	}
+
+	var relaysJSON sql.NullString
+	if len(relays) > 0 {
+		data, err := json.Marshal(relays)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal preferred relays: %w", err)
+		}
+		relaysJSON = sql.NullString{String: string(data), Valid: true}
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE nodes
+		SET preferred_relays = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ? AND cluster_id = ? AND deleted_at IS NULL
+	`, relaysJSON, time.Now().Unix(), nodeID, tenantID, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update preferred relays: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check preferred relays update result: %w", err)
+	}
+	if rows == 0 {
+		return nil, models.ErrNodeNotFound
+	}
+
+	if err := s.bumpConfigVersion(ctx, tenantID, clusterID, models.ConfigChangePreferredRelays, nodeID); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Set preferred relays",
+		zap.String("node_id", nodeID),
+		zap.Strings("relay_node_ids", relays))
+
+	return s.getNodeSummary(ctx, tenantID, clusterID, nodeID)
+}
+
+// RecordHeartbeat stores a node's self-reported config version and health.
+//
+// Heartbeats do not change the cluster's config, so the config version is
+// not bumped. The canary reconciler uses them to decide whether a rollout
+// is healthy.
+//
+// Parameters:
+//   - ctx: Request context
+//   - tenantID: Tenant scope
+//   - clusterID: Cluster scope
+//   - nodeID: Reporting node ID
+//   - req: Reported version and health
+func (s *NodeService) RecordHeartbeat(ctx context.Context, tenantID, clusterID, nodeID string, req *models.NodeHeartbeatRequest) error {
+	if req.Status != models.NodeHealthOK && req.Status != models.NodeHealthError {
+		return fmt.Errorf("%w: status must be %q or %q", models.ErrInvalidRequest, models.NodeHealthOK, models.NodeHealthError)
+	}
+
+	message := sql.NullString{String: req.Message, Valid: req.Status == models.NodeHealthError && req.Message != ""}
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE nodes
+		SET last_heartbeat_at = ?, reported_version = ?, health_status = ?, health_message = ?
+		WHERE id = ? AND tenant_id = ? AND cluster_id = ? AND deleted_at IS NULL
+	`, time.Now().Unix(), req.Version, req.Status, message, nodeID, tenantID, clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to record heartbeat: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check heartbeat result: %w", err)
+	}
+	if rows == 0 {
+		return models.ErrNodeNotFound
+	}
+
+	return nil
+}
+
+// WhoAmI returns the identity of the authenticated node.
+//
+// Parameters:
+//   - ctx: Request context
+//   - tenantID: Tenant scope
+//   - clusterID: Cluster scope
+//   - nodeID: Calling node ID
+//
+// Returns:
+//   - *models.NodeIdentity: The node's identity and roles
+//   - error: models.ErrNodeNotFound if the node no longer exists
+func (s *NodeService) WhoAmI(ctx context.Context, tenantID, clusterID, nodeID string) (*models.NodeIdentity, error) {
+	summary, err := s.getNodeSummary(ctx, tenantID, clusterID, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.NodeIdentity{
+		TenantID:     tenantID,
+		ClusterID:    clusterID,
+		NodeID:       summary.NodeID,
+		Name:         summary.Name,
+		IsAdmin:      summary.IsAdmin,
+		IsLighthouse: summary.IsLighthouse,
+		IsRelay:      summary.IsRelay,
+	}, nil
+}
+
+// DeleteNode soft-deletes a node (admin only). The node disappears from
+// listings, the cluster topology and token authentication, but its row is
+// kept until PurgeDeleted removes it, so RestoreNode can bring it back. Its
+// name stays reserved in the meantime.
 //
 // Parameters:
 //   - ctx: Request context
@@ -274,10 +525,12 @@ func (s *NodeService) RotateNodeToken(ctx context.Context, tenantID, clusterID,
 //   - clusterID: Cluster scope
 //   - nodeID: Target node ID
 func (s *NodeService) DeleteNode(ctx context.Context, tenantID, clusterID, nodeID string) error {
+	now := time.Now().Unix()
 	result, err := s.db.ExecContext(ctx, `
-		DELETE FROM nodes
-		WHERE id = ? AND tenant_id = ? AND cluster_id = ?
-	`, nodeID, tenantID, clusterID)
+		UPDATE nodes
+		SET deleted_at = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ? AND cluster_id = ? AND deleted_at IS NULL
+	`, now, now, nodeID, tenantID, clusterID)
 	if err != nil {
 		return fmt.Errorf("failed to delete node: %w", err)
 	}
@@ -290,7 +543,117 @@ func (s *NodeService) DeleteNode(ctx context.Context, tenantID, clusterID, nodeI
 		return models.ErrNodeNotFound
 	}
 
-	return s.bumpConfigVersion(ctx, tenantID, clusterID)
+	return s.bumpConfigVersion(ctx, tenantID, clusterID, models.ConfigChangeNodeDeleted, nodeID)
+}
+
+// RestoreNode undoes DeleteNode for a node that has not been purged yet. The
+// node keeps its token, roles and routes.
+//
+// Other nodes may have advertised overlapping routes, or the cluster's route
+// policy may have changed, while the node was deleted. The restore is refused
+// if its routes no longer fit the policy; they must be withdrawn from the
+// conflicting node first.
+//
+// Parameters:
+//   - ctx: Request context
+//   - tenantID: Tenant scope
+//   - clusterID: Cluster scope
+//   - nodeID: Target node ID
+//
+// Returns:
+//   - *models.NodeSummary of the restored node
+//   - error: models.ErrNodeNotFound if no deleted node with this ID exists in the cluster;
+//     models.ErrRouteOverlap or models.ErrIPv6NotAllowed (wrapped with the offending
+//     route) if the node's routes violate the cluster's route policy
+func (s *NodeService) RestoreNode(ctx context.Context, tenantID, clusterID, nodeID string) (*models.NodeSummary, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Restore first so the transaction holds the write lock during the route check
+	result, err := tx.ExecContext(ctx, `
+		UPDATE nodes
+		SET deleted_at = NULL, updated_at = ?
+		WHERE id = ? AND tenant_id = ? AND cluster_id = ? AND deleted_at IS NOT NULL
+	`, time.Now().Unix(), nodeID, tenantID, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore node: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check restore result: %w", err)
+	}
+	if rows == 0 {
+		return nil, models.ErrNodeNotFound
+	}
+
+	var policy models.RoutePolicy
+	if err := tx.QueryRowContext(ctx, `
+		SELECT ipv4_only, allow_overlapping_routes FROM clusters WHERE id = ?
+	`, clusterID).Scan(&policy.IPv4Only, &policy.AllowOverlappingRoutes); err != nil {
+		return nil, fmt.Errorf("failed to get route policy: %w", err)
+	}
+	if err := checkRoutePolicy(tx, s.logger, clusterID, nodeID, policy); err != nil {
+		s.logger.Warn("Node restore rejected by route policy",
+			zap.String("node_id", nodeID),
+			zap.String("cluster_id", clusterID),
+			zap.Error(err))
+		return nil, err
+	}
+
+	_, clusterTenant, err := bumpConfigVersion(ctx, tx, clusterID, configChange{
+		changeType: models.ConfigChangeNodeRestored,
+		actor:      s.actor,
+		detail:     nodeID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if clusterTenant != tenantID {
+		return nil, models.ErrClusterNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit restore: %w", err)
+	}
+
+	return s.getNodeSummary(ctx, tenantID, clusterID, nodeID)
+}
+
+// PurgeDeleted permanently removes nodes soft-deleted more than olderThan
+// ago, across all clusters. Purged nodes can no longer be restored and their
+// names become available again.
+//
+// Parameters:
+//   - olderThan: Minimum time since deletion
+//
+// Returns:
+//   - Number of nodes purged
+//   - error if the delete fails
+func (s *NodeService) PurgeDeleted(olderThan time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-olderThan).Unix()
+	result, err := s.db.Exec(`
+		DELETE FROM nodes
+		WHERE deleted_at IS NOT NULL AND deleted_at <= ?
+	`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted nodes: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check purge result: %w", err)
+	}
+
+	if purged > 0 {
+		s.logger.Info("Purged deleted nodes",
+			zap.Int64("count", purged),
+			zap.Duration("older_than", olderThan))
+	}
+	return purged, nil
 }
 
 func (s *NodeService) ensureClusterExists(ctx context.Context, tenantID, clusterID string) error {
@@ -306,35 +669,44 @@ func (s *NodeService) ensureClusterExists(ctx context.Context, tenantID, cluster
 	return nil
 }
 
-func (s *NodeService) bumpConfigVersion(ctx context.Context, tenantID, clusterID string) error {
-	result, err := s.db.ExecContext(ctx, `
-		UPDATE clusters
-		SET config_version = config_version + 1
-		WHERE id = ? AND tenant_id = ?
-	`, clusterID, tenantID)
+// bumpConfigVersion bumps the cluster's config version, recording the change
+// in the config history.
+func (s *NodeService) bumpConfigVersion(ctx context.Context, tenantID, clusterID, changeType, nodeID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to bump config version: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	rows, err := result.RowsAffected()
+	defer tx.Rollback()
+
+	_, clusterTenant, err := bumpConfigVersion(ctx, tx, clusterID, configChange{
+		changeType: changeType,
+		actor:      s.actor,
+		detail:     nodeID,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to check version bump: %w", err)
+		return err
 	}
-	if rows == 0 {
+	if clusterTenant != tenantID {
 		return models.ErrClusterNotFound
 	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit version bump: %w", err)
+	}
 	return nil
 }
 
 func (s *NodeService) getNodeSummary(ctx context.Context, tenantID, clusterID, nodeID string) (*models.NodeSummary, error) {
 	query := `
-		SELECT id, name, is_admin, mtu, is_lighthouse, is_relay, routes, created_at
+		SELECT id, name, is_admin, mtu, is_lighthouse, is_relay, routes, preferred_relays, created_at, updated_at
 		FROM nodes
-		WHERE id = ? AND tenant_id = ? AND cluster_id = ?
+		WHERE id = ? AND tenant_id = ? AND cluster_id = ? AND deleted_at IS NULL
 		LIMIT 1
 	`
 
 	var summary models.NodeSummary
-	var routes sql.NullString
+	var routes, preferredRelays sql.NullString
+	var updatedAt sql.NullInt64
 	if err := s.db.QueryRowContext(ctx, query, nodeID, tenantID, clusterID).Scan(
 		&summary.NodeID,
 		&summary.Name,
@@ -343,7 +715,9 @@ func (s *NodeService) getNodeSummary(ctx context.Context, tenantID, clusterID, n
 		&summary.IsLighthouse,
 		&summary.IsRelay,
 		&routes,
+		&preferredRelays,
 		&summary.CreatedAt,
+		&updatedAt,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, models.ErrNodeNotFound
@@ -351,17 +725,66 @@ func (s *NodeService) getNodeSummary(ctx context.Context, tenantID, clusterID, n
 		return nil, fmt.Errorf("failed to load node summary: %w", err)
 	}
 
-	if routes.Valid && strings.TrimSpace(routes.String) != "" {
-		var parsed []string
-		if err := json.Unmarshal([]byte(routes.String), &parsed); err == nil {
-			summary.Routes = parsed
-		}
-	}
+	summary.Routes = parseJSONList(routes)
+	summary.PreferredRelays = parseJSONList(preferredRelays)
+	summary.UpdatedAt = nodeUpdatedAt(summary.CreatedAt, updatedAt)
 
-	summary.UpdatedAt = summary.CreatedAt
 	return &summary, nil
 }
 
+// nodeUpdatedAt returns when a node was last modified: its updated_at column,
+// or its creation time if it was never modified.
+func nodeUpdatedAt(createdAt time.Time, updatedAt sql.NullInt64) time.Time {
+	if !updatedAt.Valid {
+		return createdAt
+	}
+	return time.Unix(updatedAt.Int64, 0).UTC()
+}
+
+// parseJSONList decodes a nullable JSON string array column.
+// Malformed or empty values yield nil.
+func parseJSONList(value sql.NullString) []string {
+	if !value.Valid || strings.TrimSpace(value.String) == "" {
+		return nil
+	}
+	var parsed []string
+	if err := json.Unmarshal([]byte(value.String), &parsed); err != nil {
+		return nil
+	}
+	return parsed
+}
+
+// nodeListWhere builds the WHERE clause and its arguments selecting the nodes
+// of a cluster that match filter. Only placeholders are added to the clause.
+func nodeListWhere(tenantID, clusterID string, filter models.NodeListFilter) (string, []any) {
+	where := "tenant_id = ? AND cluster_id = ? AND deleted_at IS NULL"
+	args := []any{tenantID, clusterID}
+
+	if filter.NameContains != "" {
+		where += ` AND name LIKE ? ESCAPE '\'`
+		args = append(args, "%"+escapeLike(filter.NameContains)+"%")
+	}
+	if filter.IsAdmin != nil {
+		where += " AND is_admin = ?"
+		args = append(args, boolToInt(*filter.IsAdmin))
+	}
+	if filter.IsLighthouse != nil {
+		where += " AND is_lighthouse = ?"
+		args = append(args, boolToInt(*filter.IsLighthouse))
+	}
+	if filter.IsRelay != nil {
+		where += " AND is_relay = ?"
+		args = append(args, boolToInt(*filter.IsRelay))
+	}
+
+	return where, args
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 func validateMTU(mtu int) error {
 	if mtu == 0 {
 		return nil
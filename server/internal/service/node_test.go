@@ -1,11 +1,17 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"errors"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
 	_ "modernc.org/sqlite"
 	"nebulagc.io/models"
@@ -23,7 +29,9 @@ func newNodeTestDB(t *testing.T) *sql.DB {
 CREATE TABLE clusters (
     id TEXT PRIMARY KEY,
     tenant_id TEXT NOT NULL,
-    config_version INTEGER NOT NULL DEFAULT 1
+    config_version INTEGER NOT NULL DEFAULT 1,
+    ipv4_only INTEGER NOT NULL DEFAULT 0,
+    allow_overlapping_routes INTEGER NOT NULL DEFAULT 0
 );
 CREATE TABLE nodes (
     id TEXT PRIMARY KEY,
@@ -40,9 +48,22 @@ CREATE TABLE nodes (
     lighthouse_port INTEGER,
     is_relay INTEGER NOT NULL DEFAULT 0,
     lighthouse_relay_updated_at DATETIME,
+    preferred_relays TEXT,
     created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at INTEGER,
+    deleted_at INTEGER,
     UNIQUE(tenant_id, cluster_id, name)
 );
+CREATE TABLE config_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id TEXT NOT NULL,
+    cluster_id TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    change_type TEXT NOT NULL,
+    actor TEXT,
+    detail TEXT,
+    created_at INTEGER NOT NULL
+);
 `
 	if _, err := db.Exec(schema); err != nil {
 		t.Fatalf("create schema: %v", err)
@@ -85,7 +106,7 @@ func TestCreateNodeAndList(t *testing.T) {
 		t.Fatalf("expected cluster token echoed, got %q", creds.ClusterToken)
 	}
 
-	resp, err := svc.ListNodes(context.Background(), tenantID, clusterID, 1, 10)
+	resp, err := svc.ListNodes(context.Background(), tenantID, clusterID, models.NodeListFilter{}, 1, 10)
 	if err != nil {
 		t.Fatalf("ListNodes failed: %v", err)
 	}
@@ -97,6 +118,75 @@ func TestCreateNodeAndList(t *testing.T) {
 	}
 }
 
+func TestListNodesFilter(t *testing.T) {
+	svc, db := newNodeService(t)
+	defer db.Close()
+	tenantID := "tenant-filter"
+	clusterID := "cluster-filter"
+	seedCluster(t, db, tenantID, clusterID)
+	ctx := context.Background()
+
+	for _, req := range []*models.NodeCreateRequest{
+		{Name: "edge-1"}, {Name: "Edge-2", IsAdmin: true}, {Name: "core_1"}, {Name: "core%"},
+	} {
+		if _, err := svc.CreateNode(ctx, tenantID, clusterID, "", req); err != nil {
+			t.Fatalf("CreateNode failed: %v", err)
+		}
+	}
+	if _, err := db.Exec(`UPDATE nodes SET is_relay = 1 WHERE name IN ('edge-1', 'core_1')`); err != nil {
+		t.Fatalf("mark relays: %v", err)
+	}
+
+	yes, no := true, false
+	tests := []struct {
+		name   string
+		filter models.NodeListFilter
+		want   []string
+	}{
+		{"empty", models.NodeListFilter{}, []string{"Edge-2", "core%", "core_1", "edge-1"}},
+		{"name case-insensitive", models.NodeListFilter{NameContains: "edge"}, []string{"Edge-2", "edge-1"}},
+		{"underscore literal", models.NodeListFilter{NameContains: "e_"}, []string{"core_1"}},
+		{"percent literal", models.NodeListFilter{NameContains: "%"}, []string{"core%"}},
+		{"admin", models.NodeListFilter{IsAdmin: &yes}, []string{"Edge-2"}},
+		{"relay", models.NodeListFilter{IsRelay: &yes}, []string{"core_1", "edge-1"}},
+		{"combined", models.NodeListFilter{NameContains: "edge", IsRelay: &no}, []string{"Edge-2"}},
+		{"no lighthouses", models.NodeListFilter{IsLighthouse: &yes}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := svc.ListNodes(ctx, tenantID, clusterID, tt.filter, 1, 10)
+			if err != nil {
+				t.Fatalf("ListNodes failed: %v", err)
+			}
+			var names []string
+			for _, n := range resp.Nodes {
+				names = append(names, n.Name)
+			}
+			sort.Strings(names)
+			if strings.Join(names, ",") != strings.Join(tt.want, ",") || resp.Total != len(tt.want) {
+				t.Errorf("ListNodes() = %v (total %d), want %v", names, resp.Total, tt.want)
+			}
+		})
+	}
+
+	// Pagination applies to the filtered list
+	filter := models.NodeListFilter{NameContains: "edge"}
+	seen := map[string]bool{}
+	for page := 1; page <= 2; page++ {
+		resp, err := svc.ListNodes(ctx, tenantID, clusterID, filter, page, 1)
+		if err != nil {
+			t.Fatalf("ListNodes failed: %v", err)
+		}
+		if resp.Total != 2 || len(resp.Nodes) != 1 {
+			t.Fatalf("expected one of two filtered nodes on page %d, got total=%d %+v", page, resp.Total, resp.Nodes)
+		}
+		seen[resp.Nodes[0].Name] = true
+	}
+	if !seen["edge-1"] || !seen["Edge-2"] {
+		t.Errorf("expected both filtered nodes across pages, got %v", seen)
+	}
+}
+
 func TestUpdateMTUAndRotateToken(t *testing.T) {
 	svc, db := newNodeService(t)
 	defer db.Close()
@@ -127,6 +217,84 @@ func TestUpdateMTUAndRotateToken(t *testing.T) {
 	}
 }
 
+func TestUpdatedAtTracksModification(t *testing.T) {
+	svc, db := newNodeService(t)
+	defer db.Close()
+	tenantID := "tenant-updated"
+	clusterID := "cluster-updated"
+	seedCluster(t, db, tenantID, clusterID)
+	ctx := context.Background()
+
+	creds, err := svc.CreateNode(ctx, tenantID, clusterID, "", &models.NodeCreateRequest{Name: "node-u"})
+	if err != nil {
+		t.Fatalf("CreateNode failed: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE nodes SET created_at = '2020-01-01 00:00:00' WHERE id = ?`, creds.NodeID); err != nil {
+		t.Fatalf("backdate node: %v", err)
+	}
+
+	resp, err := svc.ListNodes(ctx, tenantID, clusterID, models.NodeListFilter{}, 1, 10)
+	if err != nil {
+		t.Fatalf("ListNodes failed: %v", err)
+	}
+	if !resp.Nodes[0].UpdatedAt.Equal(resp.Nodes[0].CreatedAt) {
+		t.Fatalf("expected an unmodified node's UpdatedAt to equal CreatedAt, got %v / %v", resp.Nodes[0].UpdatedAt, resp.Nodes[0].CreatedAt)
+	}
+
+	before := time.Now().Truncate(time.Second)
+	summary, err := svc.UpdateMTU(ctx, tenantID, clusterID, creds.NodeID, 1400)
+	if err != nil {
+		t.Fatalf("UpdateMTU failed: %v", err)
+	}
+	if summary.UpdatedAt.Before(before) || !summary.UpdatedAt.After(summary.CreatedAt) {
+		t.Fatalf("expected UpdatedAt to move to the MTU change, got %v (created %v)", summary.UpdatedAt, summary.CreatedAt)
+	}
+
+	resp, err = svc.ListNodes(ctx, tenantID, clusterID, models.NodeListFilter{}, 1, 10)
+	if err != nil {
+		t.Fatalf("ListNodes failed: %v", err)
+	}
+	if !resp.Nodes[0].UpdatedAt.Equal(summary.UpdatedAt) {
+		t.Fatalf("expected ListNodes to report UpdatedAt %v, got %v", summary.UpdatedAt, resp.Nodes[0].UpdatedAt)
+	}
+}
+
+func TestGetNode(t *testing.T) {
+	svc, db := newNodeService(t)
+	defer db.Close()
+	tenantID := "tenant-get"
+	clusterID := "cluster-get"
+	seedCluster(t, db, tenantID, clusterID)
+	seedCluster(t, db, "other-tenant", "other-cluster")
+	ctx := context.Background()
+
+	creds, err := svc.CreateNode(ctx, tenantID, clusterID, "", &models.NodeCreateRequest{Name: "node-g", MTU: 1400})
+	if err != nil {
+		t.Fatalf("CreateNode failed: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE nodes SET is_lighthouse = 1, is_relay = 1, routes = '["10.1.0.0/16"]' WHERE id = ?`, creds.NodeID); err != nil {
+		t.Fatalf("set roles: %v", err)
+	}
+
+	node, err := svc.GetNode(ctx, tenantID, clusterID, creds.NodeID)
+	if err != nil {
+		t.Fatalf("GetNode failed: %v", err)
+	}
+	if node.NodeID != creds.NodeID || node.Name != "node-g" || node.MTU != 1400 {
+		t.Errorf("unexpected node: %+v", node)
+	}
+	if !node.IsLighthouse || !node.IsRelay || len(node.Routes) != 1 || node.Routes[0] != "10.1.0.0/16" {
+		t.Errorf("expected roles and routes, got %+v", node)
+	}
+
+	if _, err := svc.GetNode(ctx, tenantID, clusterID, "missing"); err != models.ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound for unknown node, got %v", err)
+	}
+	if _, err := svc.GetNode(ctx, "other-tenant", "other-cluster", creds.NodeID); err != models.ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound outside the node's cluster, got %v", err)
+	}
+}
+
 func TestDeleteNodeAndConfigBump(t *testing.T) {
 	svc, db := newNodeService(t)
 	defer db.Close()
@@ -153,6 +321,193 @@ func TestDeleteNodeAndConfigBump(t *testing.T) {
 	}
 }
 
+func TestSoftDeleteRestoreAndPurge(t *testing.T) {
+	svc, db := newNodeService(t)
+	defer db.Close()
+	ctx := context.Background()
+	tenantID := "tenant-soft"
+	clusterID := "cluster-soft"
+	seedCluster(t, db, tenantID, clusterID)
+
+	creds, err := svc.CreateNode(ctx, tenantID, clusterID, "", &models.NodeCreateRequest{Name: "node-s", MTU: 1400})
+	if err != nil {
+		t.Fatalf("CreateNode failed: %v", err)
+	}
+
+	if _, err := svc.RestoreNode(ctx, tenantID, clusterID, creds.NodeID); err != models.ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound restoring a live node, got %v", err)
+	}
+
+	if err := svc.DeleteNode(ctx, tenantID, clusterID, creds.NodeID); err != nil {
+		t.Fatalf("DeleteNode failed: %v", err)
+	}
+	if err := svc.DeleteNode(ctx, tenantID, clusterID, creds.NodeID); err != models.ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound deleting twice, got %v", err)
+	}
+	if _, err := svc.GetNode(ctx, tenantID, clusterID, creds.NodeID); err != models.ErrNodeNotFound {
+		t.Errorf("expected deleted node to be hidden from GetNode, got %v", err)
+	}
+	nodes, err := svc.ListNodes(ctx, tenantID, clusterID, models.NodeListFilter{}, 1, 10)
+	if err != nil {
+		t.Fatalf("ListNodes failed: %v", err)
+	}
+	if len(nodes.Nodes) != 0 {
+		t.Errorf("expected deleted node to be hidden from ListNodes, got %d nodes", len(nodes.Nodes))
+	}
+
+	restored, err := svc.RestoreNode(ctx, tenantID, clusterID, creds.NodeID)
+	if err != nil {
+		t.Fatalf("RestoreNode failed: %v", err)
+	}
+	if restored.NodeID != creds.NodeID || restored.Name != "node-s" || restored.MTU != 1400 {
+		t.Errorf("unexpected restored node: %+v", restored)
+	}
+	if _, err := svc.GetNode(ctx, tenantID, clusterID, creds.NodeID); err != nil {
+		t.Errorf("expected restored node to be visible, got %v", err)
+	}
+
+	var version int
+	if err := db.QueryRow(`SELECT config_version FROM clusters WHERE id = ?`, clusterID).Scan(&version); err != nil {
+		t.Fatalf("check config_version: %v", err)
+	}
+	if version != 4 { // initial 1 + create + delete + restore
+		t.Fatalf("expected config_version 4, got %d", version)
+	}
+
+	// Recently deleted nodes survive a purge with a long retention
+	if err := svc.DeleteNode(ctx, tenantID, clusterID, creds.NodeID); err != nil {
+		t.Fatalf("DeleteNode failed: %v", err)
+	}
+	if purged, err := svc.PurgeDeleted(time.Hour); err != nil || purged != 0 {
+		t.Fatalf("PurgeDeleted(1h) = %d, %v; want 0", purged, err)
+	}
+	if _, err := svc.CreateNode(ctx, tenantID, clusterID, "", &models.NodeCreateRequest{Name: "node-s"}); err != models.ErrDuplicateName {
+		t.Errorf("expected deleted node to reserve its name, got %v", err)
+	}
+
+	if purged, err := svc.PurgeDeleted(0); err != nil || purged != 1 {
+		t.Fatalf("PurgeDeleted(0) = %d, %v; want 1", purged, err)
+	}
+	if _, err := svc.RestoreNode(ctx, tenantID, clusterID, creds.NodeID); err != models.ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound restoring a purged node, got %v", err)
+	}
+	if _, err := svc.CreateNode(ctx, tenantID, clusterID, "", &models.NodeCreateRequest{Name: "node-s"}); err != nil {
+		t.Errorf("expected name to be reusable after purge, got %v", err)
+	}
+}
+
+
+func TestRestoreNodeChecksRoutePolicy(t *testing.T) {
+	svc, db := newNodeService(t)
+	defer db.Close()
+	ctx := context.Background()
+	tenantID := "tenant-routes"
+	clusterID := "cluster-routes"
+	seedCluster(t, db, tenantID, clusterID)
+
+	create := func(name, routes string) string {
+		t.Helper()
+		creds, err := svc.CreateNode(ctx, tenantID, clusterID, "", &models.NodeCreateRequest{Name: name, MTU: 1400})
+		if err != nil {
+			t.Fatalf("CreateNode failed: %v", err)
+		}
+		if _, err := db.Exec(`UPDATE nodes SET routes = ? WHERE id = ?`, routes, creds.NodeID); err != nil {
+			t.Fatalf("set routes: %v", err)
+		}
+		return creds.NodeID
+	}
+
+	// While the node is deleted, another node takes an overlapping route
+	deleted := create("node-a", `["10.0.0.0/16"]`)
+	if err := svc.DeleteNode(ctx, tenantID, clusterID, deleted); err != nil {
+		t.Fatalf("DeleteNode failed: %v", err)
+	}
+	other := create("node-b", `["10.0.1.0/24"]`)
+
+	_, err := svc.RestoreNode(ctx, tenantID, clusterID, deleted)
+	if !errors.Is(err, models.ErrRouteOverlap) || !strings.Contains(err.Error(), "advertised by node "+other) {
+		t.Fatalf("Expected ErrRouteOverlap naming %s, got %v", other, err)
+	}
+	if _, err := svc.GetNode(ctx, tenantID, clusterID, deleted); err != models.ErrNodeNotFound {
+		t.Errorf("Expected a refused restore to leave the node deleted, got %v", err)
+	}
+
+	// Allowed overlaps do not block the restore
+	if _, err := db.Exec(`UPDATE clusters SET allow_overlapping_routes = 1 WHERE id = ?`, clusterID); err != nil {
+		t.Fatalf("allow overlaps: %v", err)
+	}
+	if _, err := svc.RestoreNode(ctx, tenantID, clusterID, deleted); err != nil {
+		t.Errorf("RestoreNode failed with overlaps allowed: %v", err)
+	}
+}
+func TestCreateNodesBatch(t *testing.T) {
+	svc, db := newNodeService(t)
+	defer db.Close()
+	tenantID := "tenant-batch"
+	clusterID := "cluster-batch"
+	seedCluster(t, db, tenantID, clusterID)
+	ctx := context.Background()
+
+	reqs := []*models.NodeCreateRequest{{Name: "edge-1"}, {Name: "edge-2", MTU: 1400}, {Name: "edge-3", IsAdmin: true}}
+	creds, err := svc.CreateNodes(ctx, tenantID, clusterID, "cluster-token", reqs)
+	if err != nil {
+		t.Fatalf("CreateNodes failed: %v", err)
+	}
+	if len(creds) != len(reqs) {
+		t.Fatalf("expected %d credentials, got %d", len(reqs), len(creds))
+	}
+	for i, c := range creds {
+		var name string
+		if err := db.QueryRow(`SELECT name FROM nodes WHERE id = ?`, c.NodeID).Scan(&name); err != nil {
+			t.Fatalf("load node %d: %v", i, err)
+		}
+		if name != reqs[i].Name || c.NodeToken == "" || c.ClusterToken != "cluster-token" {
+			t.Errorf("credentials %d out of order or incomplete: name=%q %+v", i, name, c)
+		}
+	}
+
+	var version int
+	if err := db.QueryRow(`SELECT config_version FROM clusters WHERE id = ?`, clusterID).Scan(&version); err != nil {
+		t.Fatalf("check config_version: %v", err)
+	}
+	if version != 2 { // initial 1 + one bump for the whole batch
+		t.Fatalf("expected config_version 2, got %d", version)
+	}
+
+	// A duplicate name rolls back the whole batch and names the failing request
+	_, err = svc.CreateNodes(ctx, tenantID, clusterID, "", []*models.NodeCreateRequest{{Name: "edge-4"}, {Name: "edge-1"}})
+	var batchErr *models.BatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 || !errors.Is(err, models.ErrDuplicateName) {
+		t.Fatalf("expected BatchError at index 1 wrapping ErrDuplicateName, got %v", err)
+	}
+	_, err = svc.CreateNodes(ctx, tenantID, clusterID, "", []*models.NodeCreateRequest{{Name: "edge-5"}, {Name: "edge-5"}})
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 {
+		t.Fatalf("expected BatchError at index 1 for a name repeated in the batch, got %v", err)
+	}
+	_, err = svc.CreateNodes(ctx, tenantID, clusterID, "", []*models.NodeCreateRequest{{Name: "edge-6"}, {Name: "edge-7", MTU: 100}})
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 || !errors.Is(err, models.ErrInvalidMTU) {
+		t.Fatalf("expected BatchError at index 1 wrapping ErrInvalidMTU, got %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM nodes WHERE cluster_id = ?`, clusterID).Scan(&count); err != nil {
+		t.Fatalf("count nodes: %v", err)
+	}
+	if count != len(reqs) {
+		t.Fatalf("expected failed batches to create no nodes, got %d nodes", count)
+	}
+	if err := db.QueryRow(`SELECT config_version FROM clusters WHERE id = ?`, clusterID).Scan(&version); err != nil {
+		t.Fatalf("check config_version: %v", err)
+	}
+	if version != 2 {
+		t.Fatalf("expected failed batches to leave config_version 2, got %d", version)
+	}
+
+	if _, err := svc.CreateNodes(ctx, tenantID, clusterID, "", nil); !errors.Is(err, models.ErrInvalidRequest) {
+		t.Fatalf("expected ErrInvalidRequest for an empty batch, got %v", err)
+	}
+}
+
 func TestValidationErrors(t *testing.T) {
 	svc, db := newNodeService(t)
 	defer db.Close()
@@ -173,3 +528,106 @@ func TestValidationErrors(t *testing.T) {
 		t.Fatalf("expected ErrInvalidMTU, got %v", err)
 	}
 }
+
+func TestSetPreferredRelays(t *testing.T) {
+	svc, db := newNodeService(t)
+	defer db.Close()
+	tenantID := "tenant-5"
+	clusterID := "cluster-5"
+	seedCluster(t, db, tenantID, clusterID)
+
+	ctx := context.Background()
+	node, err := svc.CreateNode(ctx, tenantID, clusterID, "", &models.NodeCreateRequest{Name: "node-e"})
+	if err != nil {
+		t.Fatalf("CreateNode failed: %v", err)
+	}
+	relay, err := svc.CreateNode(ctx, tenantID, clusterID, "", &models.NodeCreateRequest{Name: "relay-e"})
+	if err != nil {
+		t.Fatalf("CreateNode failed: %v", err)
+	}
+	other, err := svc.CreateNode(ctx, tenantID, clusterID, "", &models.NodeCreateRequest{Name: "other-e"})
+	if err != nil {
+		t.Fatalf("CreateNode failed: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE nodes SET is_relay = 1 WHERE id = ?`, relay.NodeID); err != nil {
+		t.Fatalf("mark relay: %v", err)
+	}
+
+	// Non-relay targets are rejected
+	if _, err := svc.SetPreferredRelays(ctx, tenantID, clusterID, node.NodeID, []string{other.NodeID}); !errors.Is(err, models.ErrNotRelay) {
+		t.Fatalf("expected ErrNotRelay, got %v", err)
+	}
+
+	summary, err := svc.SetPreferredRelays(ctx, tenantID, clusterID, node.NodeID, []string{relay.NodeID, relay.NodeID})
+	if err != nil {
+		t.Fatalf("SetPreferredRelays failed: %v", err)
+	}
+	if len(summary.PreferredRelays) != 1 || summary.PreferredRelays[0] != relay.NodeID {
+		t.Fatalf("expected preferred relays [%s], got %v", relay.NodeID, summary.PreferredRelays)
+	}
+
+	// Empty list clears the preference
+	summary, err = svc.SetPreferredRelays(ctx, tenantID, clusterID, node.NodeID, nil)
+	if err != nil {
+		t.Fatalf("SetPreferredRelays clear failed: %v", err)
+	}
+	if len(summary.PreferredRelays) != 0 {
+		t.Fatalf("expected preferred relays cleared, got %v", summary.PreferredRelays)
+	}
+}
+
+func TestWhoAmI(t *testing.T) {
+	svc, db := newNodeService(t)
+	defer db.Close()
+	tenantID := "tenant-6"
+	clusterID := "cluster-6"
+	seedCluster(t, db, tenantID, clusterID)
+
+	ctx := context.Background()
+	node, err := svc.CreateNode(ctx, tenantID, clusterID, "", &models.NodeCreateRequest{Name: "node-f", IsAdmin: true})
+	if err != nil {
+		t.Fatalf("CreateNode failed: %v", err)
+	}
+
+	identity, err := svc.WhoAmI(ctx, tenantID, clusterID, node.NodeID)
+	if err != nil {
+		t.Fatalf("WhoAmI failed: %v", err)
+	}
+	if identity.NodeID != node.NodeID || identity.Name != "node-f" || !identity.IsAdmin {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+	if identity.TenantID != tenantID || identity.ClusterID != clusterID {
+		t.Fatalf("expected identity scoped to %s/%s, got %s/%s", tenantID, clusterID, identity.TenantID, identity.ClusterID)
+	}
+
+	// A node from another cluster is not found
+	if _, err := svc.WhoAmI(ctx, tenantID, "other-cluster", node.NodeID); !errors.Is(err, models.ErrNodeNotFound) {
+		t.Fatalf("expected ErrNodeNotFound, got %v", err)
+	}
+}
+
+func TestNodeCredentialsRedactedInLogs(t *testing.T) {
+	svc, db := newNodeService(t)
+	defer db.Close()
+	tenantID := "tenant-7"
+	clusterID := "cluster-7"
+	seedCluster(t, db, tenantID, clusterID)
+
+	creds, err := svc.CreateNode(context.Background(), tenantID, clusterID, "cluster-token-that-is-long-enough-0123456789", &models.NodeCreateRequest{Name: "node-g"})
+	if err != nil {
+		t.Fatalf("CreateNode failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	logger := zap.New(zapcore.NewCore(encoder, zapcore.AddSync(&buf), zap.InfoLevel))
+	logger.Info("node created", zap.Any("credentials", creds), zap.Any("credentials_value", *creds))
+
+	out := buf.String()
+	if strings.Contains(out, creds.NodeToken) || strings.Contains(out, creds.ClusterToken) {
+		t.Fatalf("logged credentials leaked a token: %s", out)
+	}
+	if !strings.Contains(out, creds.NodeID) || !strings.Contains(out, "***") {
+		t.Fatalf("expected node ID and redacted tokens in log, got %s", out)
+	}
+}
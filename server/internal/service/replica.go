@@ -7,10 +7,12 @@ package service
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"go.uber.org/zap"
+	"nebulagc.io/pkg/clock"
 	"nebulagc.io/server/internal/ha"
 )
 
@@ -18,6 +20,7 @@ import (
 type ReplicaService struct {
 	db     *sql.DB
 	logger *zap.Logger
+	clock  clock.Clock // Time source for heartbeats and staleness checks
 }
 
 // NewReplicaService creates a new replica service.
@@ -32,66 +35,104 @@ func NewReplicaService(db *sql.DB, logger *zap.Logger) *ReplicaService {
 	return &ReplicaService{
 		db:     db,
 		logger: logger,
+		clock:  clock.Real{},
 	}
 }
 
 // Register registers this control plane instance in the replicas table.
 //
-// If the instance already exists (restart scenario), updates the URL and heartbeat.
-// If not exists, inserts a new replica record.
+// If the instance already exists (restart scenario), updates the URLs and heartbeat.
+// If not exists, inserts a new replica record. An existing record that still has
+// a recent heartbeat but a primary address not among addresses belongs to another
+// live instance started with the same ID; registration is refused with
+// ha.ErrDuplicateInstance rather than taking over its identity.
 //
 // Parameters:
 //   - instanceID: This instance's UUID
-//   - address: This instance's public address
+//   - name: This instance's human-friendly name (may be empty)
+//   - addresses: This instance's public addresses, primary first
 //   - mode: The runtime mode (master or replica)
+//   - metadata: Descriptive metadata such as version and region (may be nil);
+//     replaces what was stored before
 //
 // Returns:
 //   - error: Any error that occurred during registration
-func (s *ReplicaService) Register(instanceID, address string, mode ha.Mode) error {
+func (s *ReplicaService) Register(instanceID, name string, addresses []string, mode ha.Mode, metadata map[string]string) error {
 	if !ha.ValidateMode(mode) {
 		return fmt.Errorf("invalid mode %q: must be master or replica", mode)
 	}
+	if len(addresses) == 0 || addresses[0] == "" {
+		return fmt.Errorf("at least one public address is required")
+	}
+
+	address := addresses[0]
+	urlsJSON, err := json.Marshal(addresses)
+	if err != nil {
+		return fmt.Errorf("failed to encode replica addresses: %w", err)
+	}
+
+	var metadataJSON sql.NullString
+	if len(metadata) > 0 {
+		encoded, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("failed to encode replica metadata: %w", err)
+		}
+		metadataJSON = sql.NullString{String: string(encoded), Valid: true}
+	}
 
 	// Check if replica already exists
-	var existingID string
-	checkQuery := `SELECT id FROM replicas WHERE id = ?`
-	err := s.db.QueryRow(checkQuery, instanceID).Scan(&existingID)
+	var existingAddress string
+	var lastSeen sql.NullTime
+	checkQuery := `SELECT address, last_seen_at FROM replicas WHERE id = ?`
+	err = s.db.QueryRow(checkQuery, instanceID).Scan(&existingAddress, &lastSeen)
 
-	now := time.Now()
+	now := s.clock.Now()
 
 	if err == sql.ErrNoRows {
 		// New replica - insert
 		insertQuery := `
-			INSERT INTO replicas (id, address, role, last_seen_at, created_at)
-			VALUES (?, ?, ?, ?, ?)
+			INSERT INTO replicas (id, name, address, urls, role, metadata, last_seen_at, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 		`
-		_, err = s.db.Exec(insertQuery, instanceID, address, string(mode), now, now)
+		_, err = s.db.Exec(insertQuery, instanceID, name, address, string(urlsJSON), string(mode), metadataJSON, now, now)
 		if err != nil {
 			return fmt.Errorf("failed to register replica: %w", err)
 		}
 
 		s.logger.Info("registered new replica",
 			zap.String("instance_id", instanceID),
-			zap.String("address", address),
+			zap.String("name", name),
+			zap.Strings("addresses", addresses),
 			zap.String("role", string(mode)),
 		)
 	} else if err != nil {
 		return fmt.Errorf("failed to check replica existence: %w", err)
+	} else if lastSeen.Valid && now.Sub(lastSeen.Time) < ha.DefaultHeartbeatThreshold && !containsAddress(addresses, existingAddress) {
+		// Another instance with this ID is still heartbeating from elsewhere
+		s.logger.Error("DUPLICATE INSTANCE ID: another live instance is registered with this ID; refusing to start",
+			zap.String("instance_id", instanceID),
+			zap.String("existing_address", existingAddress),
+			zap.Time("existing_last_seen", lastSeen.Time),
+			zap.Strings("addresses", addresses),
+		)
+		return fmt.Errorf("%w: %s is registered at %s (last heartbeat %s ago); give each instance its own NEBULAGC_INSTANCE_ID",
+			ha.ErrDuplicateInstance, instanceID, existingAddress, now.Sub(lastSeen.Time).Round(time.Second))
 	} else {
 		// Existing replica - update (restart scenario)
 		updateQuery := `
 			UPDATE replicas
-			SET address = ?, role = ?, last_seen_at = ?
+			SET name = ?, address = ?, urls = ?, role = ?, metadata = ?, last_seen_at = ?
 			WHERE id = ?
 		`
-		_, err = s.db.Exec(updateQuery, address, string(mode), now, instanceID)
+		_, err = s.db.Exec(updateQuery, name, address, string(urlsJSON), string(mode), metadataJSON, now, instanceID)
 		if err != nil {
 			return fmt.Errorf("failed to update replica: %w", err)
 		}
 
 		s.logger.Info("updated existing replica",
 			zap.String("instance_id", instanceID),
-			zap.String("address", address),
+			zap.String("name", name),
+			zap.Strings("addresses", addresses),
 			zap.String("role", string(mode)),
 		)
 	}
@@ -116,7 +157,7 @@ func (s *ReplicaService) SendHeartbeat(instanceID string) error {
 		WHERE id = ?
 	`
 
-	result, err := s.db.Exec(query, time.Now(), instanceID)
+	result, err := s.db.Exec(query, s.clock.Now(), instanceID)
 	if err != nil {
 		return fmt.Errorf("failed to send heartbeat: %w", err)
 	}
@@ -147,19 +188,20 @@ func (s *ReplicaService) SendHeartbeat(instanceID string) error {
 //   - *ha.MasterInfo: Information about the current master
 //   - error: Any error that occurred during master determination
 func (s *ReplicaService) GetMaster(threshold time.Duration, currentInstanceID string) (*ha.MasterInfo, error) {
-	cutoff := time.Now().Add(-threshold)
+	cutoff := s.clock.Now().Add(-threshold)
 
 	query := `
-		SELECT id, address, role
+		SELECT id, name, address, urls, role
 		FROM replicas
 		WHERE last_seen_at > ?
 		ORDER BY created_at ASC
 		LIMIT 1
 	`
 
-	var masterID, masterAddress string
+	var masterID, masterName, masterAddress string
+	var masterURLs sql.NullString
 	var masterRole string
-	err := s.db.QueryRow(query, cutoff).Scan(&masterID, &masterAddress, &masterRole)
+	err := s.db.QueryRow(query, cutoff).Scan(&masterID, &masterName, &masterAddress, &masterURLs, &masterRole)
 
 	if err == sql.ErrNoRows {
 		// No healthy replicas found - this shouldn't happen but we'll
@@ -185,12 +227,15 @@ func (s *ReplicaService) GetMaster(threshold time.Duration, currentInstanceID st
 
 	return &ha.MasterInfo{
 		InstanceID: masterID,
+		Name:       masterName,
 		Address:    masterAddress,
+		Addresses:  s.decodeAddresses(masterID, masterAddress, masterURLs),
 		IsSelf:     masterID == currentInstanceID,
 	}, nil
 }
 
-// ListReplicas returns all replicas with recent heartbeats.
+// ListReplicas returns all replicas with recent heartbeats, each with its
+// heartbeat age as of the time of the query.
 //
 // Parameters:
 //   - threshold: How long before a replica is considered stale
@@ -200,10 +245,11 @@ func (s *ReplicaService) GetMaster(threshold time.Duration, currentInstanceID st
 //   - []*ha.ReplicaInfo: List of healthy replicas
 //   - error: Any error that occurred during query
 func (s *ReplicaService) ListReplicas(threshold time.Duration, currentInstanceID string) ([]*ha.ReplicaInfo, error) {
-	cutoff := time.Now().Add(-threshold)
+	now := s.clock.Now()
+	cutoff := now.Add(-threshold)
 
 	query := `
-		SELECT id, address, role, last_seen_at, created_at
+		SELECT id, name, address, urls, role, metadata, last_seen_at, created_at
 		FROM replicas
 		WHERE last_seen_at > ?
 		ORDER BY created_at ASC
@@ -220,11 +266,15 @@ func (s *ReplicaService) ListReplicas(threshold time.Duration, currentInstanceID
 
 	for rows.Next() {
 		var r ha.ReplicaInfo
+		var urls, metadata sql.NullString
 		var role string
-		err := rows.Scan(&r.InstanceID, &r.Address, &role, &r.LastHeartbeat, &r.CreatedAt)
+		err := rows.Scan(&r.InstanceID, &r.Name, &r.Address, &urls, &role, &metadata, &r.LastHeartbeat, &r.CreatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan replica: %w", err)
 		}
+		r.Addresses = s.decodeAddresses(r.InstanceID, r.Address, urls)
+		r.Metadata = s.decodeMetadata(r.InstanceID, metadata)
+		r.HeartbeatAge = now.Sub(r.LastHeartbeat)
 
 		if ha.ValidateMode(ha.Mode(role)) {
 			r.Role = ha.Mode(role)
@@ -246,6 +296,47 @@ func (s *ReplicaService) ListReplicas(threshold time.Duration, currentInstanceID
 	return replicas, nil
 }
 
+// containsAddress reports whether address is one of addresses.
+func containsAddress(addresses []string, address string) bool {
+	for _, a := range addresses {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeAddresses returns the stored URL list for a replica, falling back to
+// the primary address for rows registered before urls was recorded.
+func (s *ReplicaService) decodeAddresses(instanceID, address string, urls sql.NullString) []string {
+	if urls.Valid && urls.String != "" {
+		var addresses []string
+		if err := json.Unmarshal([]byte(urls.String), &addresses); err == nil && len(addresses) > 0 {
+			return addresses
+		}
+		s.logger.Warn("replica has malformed urls, using primary address",
+			zap.String("instance_id", instanceID),
+		)
+	}
+	return []string{address}
+}
+
+// decodeMetadata returns the stored metadata of a replica, or nil if it has
+// none or it cannot be decoded.
+func (s *ReplicaService) decodeMetadata(instanceID string, metadata sql.NullString) map[string]string {
+	if !metadata.Valid || metadata.String == "" {
+		return nil
+	}
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(metadata.String), &decoded); err != nil {
+		s.logger.Warn("replica has malformed metadata, ignoring it",
+			zap.String("instance_id", instanceID),
+		)
+		return nil
+	}
+	return decoded
+}
+
 // PruneStale removes replicas with very old heartbeats.
 //
 // This prevents the replicas table from growing indefinitely with dead instances.
@@ -260,7 +351,7 @@ func (s *ReplicaService) ListReplicas(threshold time.Duration, currentInstanceID
 //   - int: Number of replicas pruned
 //   - error: Any error that occurred during pruning
 func (s *ReplicaService) PruneStale(threshold time.Duration, multiplier int) (int, error) {
-	cutoff := time.Now().Add(-threshold * time.Duration(multiplier))
+	cutoff := s.clock.Now().Add(-threshold * time.Duration(multiplier))
 
 	query := `
 		DELETE FROM replicas
@@ -287,6 +378,37 @@ func (s *ReplicaService) PruneStale(threshold time.Duration, multiplier int) (in
 	return int(rows), nil
 }
 
+// StepDown moves an instance to the back of the master election order by
+// resetting its created_at to now, so GetMaster picks the next-oldest healthy
+// replica. The configured role is left alone, so ValidateSingleMaster is
+// unaffected.
+//
+// Parameters:
+//   - instanceID: The UUID of the instance stepping down
+//
+// Returns:
+//   - error: Any error that occurred, including if the instance is not registered
+func (s *ReplicaService) StepDown(instanceID string) error {
+	query := `UPDATE replicas SET created_at = ? WHERE id = ?`
+
+	result, err := s.db.Exec(query, s.clock.Now(), instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to step down: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check step-down result: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("replica not found for step-down: %s", instanceID)
+	}
+
+	s.logger.Info("replica stepped down", zap.String("instance_id", instanceID))
+	return nil
+}
+
 // Unregister removes this instance from the replicas table.
 //
 // This should be called during graceful shutdown to immediately remove
@@ -2,12 +2,17 @@ package service
 
 import (
 	"database/sql"
+	"errors"
+	"reflect"
 	"testing"
 	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest/observer"
 	_ "modernc.org/sqlite"
+
+	"nebulagc.io/pkg/clock"
+	"nebulagc.io/server/internal/ha"
 )
 
 // createTestDB builds an in-memory SQLite database with the replicas schema.
@@ -22,8 +27,11 @@ func createTestDB(t *testing.T) *sql.DB {
 	schema := `
 CREATE TABLE replicas (
     id TEXT PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
     address TEXT NOT NULL UNIQUE,
+    urls TEXT,
     role TEXT NOT NULL CHECK(role IN ('master','replica')),
+    metadata TEXT,
     created_at DATETIME NOT NULL,
     last_seen_at DATETIME
 );
@@ -45,8 +53,10 @@ func TestRegisterAndHeartbeat(t *testing.T) {
 	defer db.Close()
 
 	svc := NewReplicaService(db, newTestLogger())
+	fake := clock.NewFake(time.Now())
+	svc.clock = fake
 
-	if err := svc.Register("id-1", "https://one.example.com", "master"); err != nil {
+	if err := svc.Register("id-1", "", []string{"https://one.example.com"}, "master", nil); err != nil {
 		t.Fatalf("register failed: %v", err)
 	}
 
@@ -59,8 +69,9 @@ func TestRegisterAndHeartbeat(t *testing.T) {
 		t.Fatalf("unexpected row: address=%s role=%s", address, role)
 	}
 
-	// Update existing replica
-	if err := svc.Register("id-1", "https://new.example.com", "replica"); err != nil {
+	// Update existing replica after the previous process stopped heartbeating
+	fake.Advance(time.Hour)
+	if err := svc.Register("id-1", "", []string{"https://new.example.com"}, "replica", nil); err != nil {
 		t.Fatalf("register update failed: %v", err)
 	}
 
@@ -82,6 +93,163 @@ func TestRegisterAndHeartbeat(t *testing.T) {
 	}
 }
 
+func TestRegisterRejectsLiveDuplicate(t *testing.T) {
+	db := createTestDB(t)
+	defer db.Close()
+
+	svc := NewReplicaService(db, newTestLogger())
+	fake := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	svc.clock = fake
+
+	if err := svc.Register("id-1", "", []string{"https://one.example.com"}, "master", nil); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	// A second instance started with the same ID elsewhere must be refused
+	err := svc.Register("id-1", "", []string{"https://two.example.com"}, "replica", nil)
+	if !errors.Is(err, ha.ErrDuplicateInstance) {
+		t.Fatalf("expected ErrDuplicateInstance, got %v", err)
+	}
+
+	var address, role string
+	if err := db.QueryRow(`SELECT address, role FROM replicas WHERE id = ?`, "id-1").Scan(&address, &role); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if address != "https://one.example.com" || role != "master" {
+		t.Fatalf("live record was overwritten: address=%s role=%s", address, role)
+	}
+
+	// Restarting the same instance (same address) is still allowed
+	if err := svc.Register("id-1", "", []string{"https://alt.example.com", "https://one.example.com"}, "master", nil); err != nil {
+		t.Fatalf("restart with a known address failed: %v", err)
+	}
+
+	// Once the old instance has stopped heartbeating, its ID can move
+	fake.Advance(ha.DefaultHeartbeatThreshold - time.Second)
+	if err := svc.Register("id-1", "", []string{"https://two.example.com"}, "replica", nil); !errors.Is(err, ha.ErrDuplicateInstance) {
+		t.Fatalf("expected ErrDuplicateInstance just inside the threshold, got %v", err)
+	}
+	fake.Advance(time.Second)
+	if err := svc.Register("id-1", "", []string{"https://two.example.com"}, "replica", nil); err != nil {
+		t.Fatalf("register after the old instance went stale failed: %v", err)
+	}
+}
+
+func TestHeartbeatStaleness(t *testing.T) {
+	db := createTestDB(t)
+	defer db.Close()
+
+	svc := NewReplicaService(db, newTestLogger())
+	fake := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	svc.clock = fake
+	threshold := 30 * time.Second
+
+	if err := svc.Register("id-1", "", []string{"https://one.example.com"}, "master", nil); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	fake.Advance(10 * time.Second)
+	if err := svc.Register("id-2", "", []string{"https://two.example.com"}, "replica", nil); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	// id-1 goes quiet while id-2 keeps heartbeating
+	fake.Advance(25 * time.Second)
+	if err := svc.SendHeartbeat("id-2"); err != nil {
+		t.Fatalf("heartbeat failed: %v", err)
+	}
+
+	fake.Advance(5 * time.Second)
+
+	list, err := svc.ListReplicas(threshold, "id-2")
+	if err != nil {
+		t.Fatalf("ListReplicas failed: %v", err)
+	}
+	if len(list) != 1 || list[0].InstanceID != "id-2" || !list[0].IsMaster {
+		t.Fatalf("expected only id-2 as healthy master, got %+v", list)
+	}
+	if list[0].HeartbeatAge != 5*time.Second {
+		t.Fatalf("expected id-2 with a 5s heartbeat age, got %s", list[0].HeartbeatAge)
+	}
+
+	master, err := svc.GetMaster(threshold, "id-2")
+	if err != nil {
+		t.Fatalf("GetMaster failed: %v", err)
+	}
+	if master.InstanceID != "id-2" || !master.IsSelf {
+		t.Fatalf("expected id-2 to take over as master, got %+v", master)
+	}
+
+	// Pruning removes id-1 once it is older than threshold * multiplier
+	fake.Advance(30 * time.Second)
+	pruned, err := svc.PruneStale(threshold, 2)
+	if err != nil {
+		t.Fatalf("PruneStale failed: %v", err)
+	}
+	if pruned != 1 {
+		t.Fatalf("expected 1 pruned replica, got %d", pruned)
+	}
+}
+
+func TestRegisterMultipleAddresses(t *testing.T) {
+	db := createTestDB(t)
+	defer db.Close()
+
+	svc := NewReplicaService(db, newTestLogger())
+
+	if err := svc.Register("id-1", "", nil, "master", nil); err == nil {
+		t.Fatal("expected error when no address is given")
+	}
+
+	addresses := []string{"https://cp1.example.com", "https://cp1.internal:8080"}
+	if err := svc.Register("id-1", "cp-eu-west-1", addresses, "master", nil); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	var address string
+	if err := db.QueryRow(`SELECT address FROM replicas WHERE id = ?`, "id-1").Scan(&address); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if address != addresses[0] {
+		t.Fatalf("expected primary address %s, got %s", addresses[0], address)
+	}
+
+	// Rows written before urls existed fall back to the primary address.
+	if _, err := db.Exec(
+		`INSERT INTO replicas (id, address, role, created_at, last_seen_at) VALUES (?, ?, ?, ?, ?)`,
+		"id-2", "https://cp2.example.com", "replica", time.Now(), time.Now(),
+	); err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+
+	master, err := svc.GetMaster(time.Minute, "id-2")
+	if err != nil {
+		t.Fatalf("GetMaster failed: %v", err)
+	}
+	if len(master.Addresses) != 2 || master.Addresses[1] != "https://cp1.internal:8080" {
+		t.Fatalf("unexpected master addresses: %v", master.Addresses)
+	}
+	if master.Name != "cp-eu-west-1" {
+		t.Fatalf("expected master name cp-eu-west-1, got %q", master.Name)
+	}
+
+	list, err := svc.ListReplicas(time.Minute, "id-2")
+	if err != nil {
+		t.Fatalf("ListReplicas failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 replicas, got %d", len(list))
+	}
+	if len(list[0].Addresses) != 2 {
+		t.Fatalf("expected both addresses for id-1, got %v", list[0].Addresses)
+	}
+	if list[0].Name != "cp-eu-west-1" || list[1].Name != "" {
+		t.Fatalf("unexpected replica names: %q, %q", list[0].Name, list[1].Name)
+	}
+	if len(list[1].Addresses) != 1 || list[1].Addresses[0] != "https://cp2.example.com" {
+		t.Fatalf("expected fallback to primary address for id-2, got %v", list[1].Addresses)
+	}
+}
+
 func TestMasterSelectionAndListing(t *testing.T) {
 	db := createTestDB(t)
 	defer db.Close()
@@ -178,3 +346,95 @@ func TestPruneAndValidateMasters(t *testing.T) {
 		t.Fatal("expected stale replicas to be pruned")
 	}
 }
+
+func TestStepDown(t *testing.T) {
+	db := createTestDB(t)
+	defer db.Close()
+
+	svc := NewReplicaService(db, newTestLogger())
+	fake := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	svc.clock = fake
+	threshold := 30 * time.Second
+
+	if err := svc.Register("id-1", "", []string{"https://one.example.com"}, "master", nil); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	fake.Advance(time.Second)
+	if err := svc.Register("id-2", "", []string{"https://two.example.com"}, "replica", nil); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	fake.Advance(time.Second)
+	if err := svc.Register("id-3", "", []string{"https://three.example.com"}, "replica", nil); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	fake.Advance(time.Second)
+	if err := svc.StepDown("id-1"); err != nil {
+		t.Fatalf("StepDown failed: %v", err)
+	}
+
+	// The next-oldest replica takes over and the old master goes last
+	master, err := svc.GetMaster(threshold, "id-1")
+	if err != nil {
+		t.Fatalf("GetMaster failed: %v", err)
+	}
+	if master.InstanceID != "id-2" || master.IsSelf {
+		t.Fatalf("expected id-2 to take over as master, got %+v", master)
+	}
+	list, err := svc.ListReplicas(threshold, "id-1")
+	if err != nil {
+		t.Fatalf("ListReplicas failed: %v", err)
+	}
+	if len(list) != 3 || list[2].InstanceID != "id-1" {
+		t.Fatalf("expected id-1 last in the election order, got %+v", list)
+	}
+
+	if err := svc.ValidateSingleMaster(); err != nil {
+		t.Fatalf("ValidateSingleMaster failed after step-down: %v", err)
+	}
+
+	if err := svc.StepDown("missing"); err == nil {
+		t.Fatal("expected an error stepping down an unregistered instance")
+	}
+}
+
+func TestRegisterMetadata(t *testing.T) {
+	db := createTestDB(t)
+	defer db.Close()
+
+	svc := NewReplicaService(db, newTestLogger())
+	metadata := map[string]string{ha.MetadataVersion: "0.1.0", ha.MetadataRegion: "eu-west-1"}
+
+	if err := svc.Register("id-1", "", []string{"https://one.example.com"}, "master", metadata); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	if err := svc.Register("id-2", "", []string{"https://two.example.com"}, "replica", nil); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	list, err := svc.ListReplicas(time.Minute, "id-1")
+	if err != nil {
+		t.Fatalf("ListReplicas failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 replicas, got %d", len(list))
+	}
+	if !reflect.DeepEqual(list[0].Metadata, metadata) {
+		t.Fatalf("expected metadata %v, got %v", metadata, list[0].Metadata)
+	}
+	if list[1].Metadata != nil {
+		t.Fatalf("expected no metadata for id-2, got %v", list[1].Metadata)
+	}
+
+	// A restart replaces the metadata, e.g. after an upgrade
+	if err := svc.Register("id-1", "", []string{"https://one.example.com"}, "master", map[string]string{ha.MetadataVersion: "0.2.0"}); err != nil {
+		t.Fatalf("re-register failed: %v", err)
+	}
+	list, err = svc.ListReplicas(time.Minute, "id-1")
+	if err != nil {
+		t.Fatalf("ListReplicas failed: %v", err)
+	}
+	if len(list[0].Metadata) != 1 || list[0].Metadata[ha.MetadataVersion] != "0.2.0" {
+		t.Fatalf("expected only the upgraded version, got %v", list[0].Metadata)
+	}
+}
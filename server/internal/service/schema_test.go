@@ -0,0 +1,65 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+
+	"go.uber.org/zap"
+	"nebulagc.io/server/internal/testutil"
+)
+
+// TestBundleService_CanonicalSchema runs the bundle service against the
+// migrated schema, so test-only schemas cannot drift from production.
+func TestBundleService_CanonicalSchema(t *testing.T) {
+	db := testutil.OpenDB(t)
+	tenantID, clusterID, nodeIDs := testutil.Seed(t, db, 2)
+
+	service := NewBundleService(db, zap.NewNop())
+	bundleData := createTestBundle()
+
+	version, err := uploadVersion(service.UploadWithOptions(clusterID, bundleData, UploadOptions{CreatedBy: nodeIDs[0], Description: "initial"}))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	data, downloaded, err := service.Download(clusterID, nodeIDs[0], 0)
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	if downloaded != version || !bytes.Equal(data, bundleData) {
+		t.Errorf("Expected bundle %d, got %d", version, downloaded)
+	}
+
+	meta, err := service.GetMetadata(clusterID, version)
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+	if meta.TenantID != tenantID || meta.Size != int64(len(bundleData)) || meta.CreatedAt.IsZero() {
+		t.Errorf("Unexpected metadata %+v", meta)
+	}
+
+	canary, err := uploadVersion(service.Upload(clusterID, bundleData))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := service.SetCanary(clusterID, canary, nodeIDs[:1]); err != nil {
+		t.Fatalf("SetCanary failed: %v", err)
+	}
+	if current, _ := service.GetCurrentVersion(clusterID, nodeIDs[1]); current != version {
+		t.Errorf("Expected non-canary node at %d, got %d", version, current)
+	}
+	if _, err := service.CanaryNodeHealth(clusterID); err != nil {
+		t.Fatalf("CanaryNodeHealth failed: %v", err)
+	}
+	if err := service.RollbackCanary(clusterID, canary, "test"); err != nil {
+		t.Fatalf("RollbackCanary failed: %v", err)
+	}
+
+	stats, err := NewTenantService(db, zap.NewNop()).Stats(tenantID)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.BundleCount != 2 {
+		t.Errorf("Expected 2 bundles, got %d", stats.BundleCount)
+	}
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+	"nebulagc.io/server/internal/logging"
+)
+
+// TenantService handles tenant-level business logic.
+type TenantService struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+// NewTenantService creates a new tenant service.
+//
+// Parameters:
+//   - db: Database connection
+//   - logger: Zap logger for structured logging
+//
+// Returns:
+//   - Configured TenantService
+func NewTenantService(db *sql.DB, logger *zap.Logger) *TenantService {
+	return &TenantService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateTenant creates a tenant.
+//
+// Parameters:
+//   - ctx: Request context
+//   - name: Tenant name (1-255 characters, unique across all tenants)
+//
+// Returns:
+//   - *models.Tenant: The created tenant
+//   - error: models.ErrInvalidRequest for an invalid name, models.ErrDuplicateName
+//     if a tenant with this name exists, or error if the insert fails
+func (s *TenantService) CreateTenant(ctx context.Context, name string) (*models.Tenant, error) {
+	if name == "" || len(name) > 255 {
+		return nil, fmt.Errorf("%w: tenant name must be 1-255 characters", models.ErrInvalidRequest)
+	}
+
+	tenantID := uuid.New().String()
+	_, err := s.db.ExecContext(ctx, `INSERT INTO tenants (id, name) VALUES (?, ?)`, tenantID, name)
+	if err != nil {
+		if isUniqueConstraint(err) {
+			return nil, models.ErrDuplicateName
+		}
+		return nil, fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	tenant, err := s.GetTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Created tenant",
+		zap.Bool(logging.FieldAudit, true),
+		zap.String(logging.FieldOperation, "create_tenant"),
+		zap.String("tenant_id", tenantID),
+		zap.String("name", name))
+
+	return tenant, nil
+}
+
+// GetTenant returns a single tenant.
+//
+// Parameters:
+//   - ctx: Request context
+//   - tenantID: Tenant ID
+//
+// Returns:
+//   - *models.Tenant: The tenant
+//   - error: models.ErrTenantNotFound if the tenant does not exist
+func (s *TenantService) GetTenant(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	var tenant models.Tenant
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, name, created_at FROM tenants WHERE id = ?
+	`, tenantID).Scan(&tenant.ID, &tenant.Name, &tenant.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tenant: %w", err)
+	}
+	return &tenant, nil
+}
+
+// ListTenants returns a page of tenants, ordered by name.
+//
+// Parameters:
+//   - ctx: Request context
+//   - page: Page number, starting at 1
+//   - pageSize: Tenants per page (default 50, max 500)
+//
+// Returns:
+//   - *models.TenantListResponse with the page and the total tenant count
+//   - error if a query fails
+func (s *TenantService) ListTenants(ctx context.Context, page, pageSize int) (*models.TenantListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 50
+	}
+	if pageSize > 500 {
+		pageSize = 500
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM tenants`).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count tenants: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, created_at
+		FROM tenants
+		ORDER BY name ASC
+		LIMIT ? OFFSET ?
+	`, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	defer rows.Close()
+
+	tenants := []models.Tenant{}
+	for rows.Next() {
+		var tenant models.Tenant
+		if err := rows.Scan(&tenant.ID, &tenant.Name, &tenant.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tenant: %w", err)
+		}
+		tenants = append(tenants, tenant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate tenants: %w", err)
+	}
+
+	return &models.TenantListResponse{
+		Tenants: tenants,
+		Total:   total,
+		Page:    page,
+		PerPage: pageSize,
+	}, nil
+}
+
+// DeleteTenant permanently deletes a tenant with all of its clusters and
+// everything belonging to them (nodes, config bundles, history), in one
+// transaction.
+//
+// Parameters:
+//   - ctx: Request context
+//   - tenantID: Tenant ID
+//
+// Returns:
+//   - error: models.ErrTenantNotFound if the tenant does not exist, or error
+//     if a delete fails
+func (s *TenantService) DeleteTenant(ctx context.Context, tenantID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM tenants WHERE id = ?)`, tenantID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to query tenant: %w", err)
+	}
+	if !exists {
+		return models.ErrTenantNotFound
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM clusters WHERE tenant_id = ?`, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to list tenant clusters: %w", err)
+	}
+	var clusterIDs []string
+	for rows.Next() {
+		var clusterID string
+		if err := rows.Scan(&clusterID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan cluster: %w", err)
+		}
+		clusterIDs = append(clusterIDs, clusterID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate clusters: %w", err)
+	}
+
+	for _, clusterID := range clusterIDs {
+		if err := deleteClusterRows(ctx, tx, clusterID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM tenants WHERE id = ?`, tenantID); err != nil {
+		return fmt.Errorf("failed to delete tenant: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.logger.Info("Deleted tenant",
+		zap.Bool(logging.FieldAudit, true),
+		zap.String(logging.FieldOperation, "delete_tenant"),
+		zap.String("tenant_id", tenantID),
+		zap.Int("cluster_count", len(clusterIDs)))
+
+	return nil
+}
+
+// Stats returns cluster, node and bundle storage totals for a tenant.
+//
+// Bundle storage counts every stored bundle version, not just the latest,
+// since old versions are retained and make up most of the database growth.
+//
+// Parameters:
+//   - tenantID: Tenant ID
+//
+// Returns:
+//   - Tenant stats
+//   - ErrTenantNotFound if the tenant does not exist, or error if a query fails
+func (s *TenantService) Stats(tenantID string) (*models.TenantStats, error) {
+	var exists bool
+	err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM tenants WHERE id = ?)`, tenantID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tenant: %w", err)
+	}
+	if !exists {
+		return nil, models.ErrTenantNotFound
+	}
+
+	stats := &models.TenantStats{TenantID: tenantID}
+
+	err = s.db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM clusters WHERE tenant_id = ?),
+			(SELECT COUNT(*) FROM nodes WHERE tenant_id = ? AND deleted_at IS NULL)
+	`, tenantID, tenantID).Scan(&stats.ClusterCount, &stats.NodeCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count clusters and nodes: %w", err)
+	}
+
+	err = s.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(b.size), 0)
+		FROM config_bundles b
+		WHERE b.tenant_id = ?
+	`, tenantID).Scan(&stats.BundleCount, &stats.BundleStorageBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate bundle storage: %w", err)
+	}
+
+	return stats, nil
+}
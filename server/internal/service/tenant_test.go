@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+	"nebulagc.io/server/internal/testutil"
+)
+
+func TestTenantService_Stats(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	statements := []string{
+		`INSERT INTO clusters (id, tenant_id, name, config_version, cluster_token_hash, created_at)
+		 VALUES ('cluster2', 'tenant1', 'Other Cluster', 1, 'hash', 1000000000)`,
+		`INSERT INTO tenants (id, name, created_at) VALUES ('tenant2', 'Other Tenant', 1000000000)`,
+		`INSERT INTO clusters (id, tenant_id, name, config_version, cluster_token_hash, created_at)
+		 VALUES ('cluster3', 'tenant2', 'Foreign Cluster', 1, 'hash', 1000000000)`,
+		`INSERT INTO nodes (id, tenant_id, cluster_id, name, token_hash, created_at)
+		 VALUES ('node4', 'tenant1', 'cluster2', 'node-4', 'hash4', 1000000000)`,
+		`INSERT INTO config_bundles (tenant_id, cluster_id, version, data, size, created_at) VALUES ('tenant1', 'cluster1', 1, zeroblob(100), 100, 1000000000)`,
+		`INSERT INTO config_bundles (tenant_id, cluster_id, version, data, size, created_at) VALUES ('tenant1', 'cluster1', 2, zeroblob(150), 150, 1000000000)`,
+		`INSERT INTO config_bundles (tenant_id, cluster_id, version, data, size, created_at) VALUES ('tenant1', 'cluster2', 1, zeroblob(50), 50, 1000000000)`,
+		`INSERT INTO config_bundles (tenant_id, cluster_id, version, data, size, created_at) VALUES ('tenant2', 'cluster3', 1, zeroblob(999), 999, 1000000000)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set up test data: %v", err)
+		}
+	}
+
+	service := NewTenantService(db, zap.NewNop())
+
+	stats, err := service.Stats("tenant1")
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+
+	want := models.TenantStats{
+		TenantID:           "tenant1",
+		ClusterCount:       2,
+		NodeCount:          4,
+		BundleCount:        3,
+		BundleStorageBytes: 300,
+	}
+	if *stats != want {
+		t.Errorf("Expected %+v, got %+v", want, *stats)
+	}
+
+	// A tenant with no clusters reports zero totals
+	if _, err := db.Exec(`INSERT INTO tenants (id, name, created_at) VALUES ('tenant3', 'Empty Tenant', 1000000000)`); err != nil {
+		t.Fatalf("Failed to insert tenant: %v", err)
+	}
+	stats, err = service.Stats("tenant3")
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if *stats != (models.TenantStats{TenantID: "tenant3"}) {
+		t.Errorf("Expected empty stats, got %+v", *stats)
+	}
+
+	if _, err := service.Stats("missing"); !errors.Is(err, models.ErrTenantNotFound) {
+		t.Errorf("Expected ErrTenantNotFound, got %v", err)
+	}
+}
+
+func TestTenantService_CreateGetList(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.OpenDB(t)
+
+	service := NewTenantService(db, zap.NewNop())
+
+	tenant, err := service.CreateTenant(ctx, "Acme")
+	if err != nil {
+		t.Fatalf("CreateTenant failed: %v", err)
+	}
+	if tenant.ID == "" || tenant.Name != "Acme" || tenant.CreatedAt.IsZero() {
+		t.Errorf("Unexpected tenant: %+v", tenant)
+	}
+
+	if _, err := service.CreateTenant(ctx, "Acme"); !errors.Is(err, models.ErrDuplicateName) {
+		t.Errorf("Expected ErrDuplicateName, got %v", err)
+	}
+	if _, err := service.CreateTenant(ctx, ""); !errors.Is(err, models.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for empty name, got %v", err)
+	}
+
+	got, err := service.GetTenant(ctx, tenant.ID)
+	if err != nil {
+		t.Fatalf("GetTenant failed: %v", err)
+	}
+	if got.Name != "Acme" {
+		t.Errorf("Expected Acme, got %+v", got)
+	}
+	if _, err := service.GetTenant(ctx, "missing"); !errors.Is(err, models.ErrTenantNotFound) {
+		t.Errorf("Expected ErrTenantNotFound, got %v", err)
+	}
+
+	for i := 1; i <= 4; i++ {
+		if _, err := service.CreateTenant(ctx, fmt.Sprintf("Tenant %d", i)); err != nil {
+			t.Fatalf("CreateTenant failed: %v", err)
+		}
+	}
+
+	var names []string
+	for page := 1; page <= 3; page++ {
+		resp, err := service.ListTenants(ctx, page, 2)
+		if err != nil {
+			t.Fatalf("ListTenants failed: %v", err)
+		}
+		if resp.Total != 5 {
+			t.Errorf("Expected total 5, got %d", resp.Total)
+		}
+		for _, tenant := range resp.Tenants {
+			names = append(names, tenant.Name)
+		}
+	}
+	want := []string{"Acme", "Tenant 1", "Tenant 2", "Tenant 3", "Tenant 4"}
+	if fmt.Sprint(names) != fmt.Sprint(want) {
+		t.Errorf("Expected %v across pages, got %v", want, names)
+	}
+}
+
+func TestTenantService_DeleteCascades(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.OpenDB(t)
+	tenantID, clusterID, _ := testutil.Seed(t, db, 2)
+	secondClusterID, _ := testutil.Cluster(t, db, tenantID, "Second Cluster")
+	keepTenantID := testutil.Tenant(t, db, "Keep Tenant")
+	keepClusterID, _ := testutil.Cluster(t, db, keepTenantID, "Keep Cluster")
+	testutil.Node(t, db, keepTenantID, keepClusterID, "keep-node", false)
+
+	if _, err := NewBundleService(db, zap.NewNop()).Upload(clusterID, createTestBundle()); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	service := NewTenantService(db, zap.NewNop())
+	if err := service.DeleteTenant(ctx, tenantID); err != nil {
+		t.Fatalf("DeleteTenant failed: %v", err)
+	}
+
+	count := func(query string, args ...any) int {
+		t.Helper()
+		var n int
+		if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+			t.Fatalf("Count failed: %v", err)
+		}
+		return n
+	}
+	if n := count(`SELECT COUNT(*) FROM clusters WHERE id IN (?, ?)`, clusterID, secondClusterID); n != 0 {
+		t.Errorf("Expected the tenant's clusters to be deleted, %d left", n)
+	}
+	if n := count(`SELECT COUNT(*) FROM nodes WHERE tenant_id = ?`, tenantID); n != 0 {
+		t.Errorf("Expected the tenant's nodes to be deleted, %d left", n)
+	}
+	if n := count(`SELECT COUNT(*) FROM config_bundles WHERE tenant_id = ?`, tenantID); n != 0 {
+		t.Errorf("Expected the tenant's bundles to be deleted, %d left", n)
+	}
+	if n := count(`SELECT COUNT(*) FROM nodes WHERE tenant_id = ?`, keepTenantID); n != 1 {
+		t.Errorf("Expected other tenants to be untouched, got %d nodes", n)
+	}
+
+	if _, err := service.GetTenant(ctx, tenantID); !errors.Is(err, models.ErrTenantNotFound) {
+		t.Errorf("Expected ErrTenantNotFound after delete, got %v", err)
+	}
+	if err := service.DeleteTenant(ctx, tenantID); !errors.Is(err, models.ErrTenantNotFound) {
+		t.Errorf("Expected ErrTenantNotFound deleting twice, got %v", err)
+	}
+
+	// The name is free again
+	if _, err := service.CreateTenant(ctx, "Test Tenant"); err != nil {
+		t.Errorf("Expected the deleted tenant's name to be reusable, got %v", err)
+	}
+}
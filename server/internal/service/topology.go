@@ -1,22 +1,45 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 	"nebulagc.io/models"
+	"nebulagc.io/pkg/clock"
 	"nebulagc.io/pkg/token"
+	"nebulagc.io/server/internal/config"
+	"nebulagc.io/server/internal/events"
+	"nebulagc.io/server/internal/logging"
 )
 
+// relayWarnFraction is the share of cluster nodes acting as relays above
+// which SetRelay warns about an oversized relay mesh.
+const relayWarnFraction = 0.5
+
+// DefaultClusterTokenOverlap is how long a rotated-out cluster token keeps
+// validating, giving operators time to roll the new token out to nodes.
+const DefaultClusterTokenOverlap = 24 * time.Hour
+
 // TopologyService handles topology management including routes, lighthouses, and relays.
 type TopologyService struct {
 	db     *sql.DB
 	logger *zap.Logger
-	secret string // HMAC secret for token rotation
+	secret string         // HMAC secret for token rotation
+	cache  *topologyCache // GetTopology results keyed by config version
+
+	tokenOverlap time.Duration   // How long the previous cluster token validates after rotation
+	events       events.Notifier // Receives token rotation events (nil = none)
+	clock        clock.Clock     // Time source for timestamps and overlap expiry
+	actor        string          // Node ID recorded as making config changes ("" = unknown)
 }
 
 // NewTopologyService creates a new topology service.
@@ -33,36 +56,84 @@ func NewTopologyService(db *sql.DB, logger *zap.Logger, secret string) *Topology
 		db:     db,
 		logger: logger,
 		secret: secret,
+		cache:  newTopologyCache(defaultTopologyCacheSize),
+
+		tokenOverlap: DefaultClusterTokenOverlap,
+		clock:        clock.Real{},
 	}
 }
 
+// ConfigureTokenRotation sets how cluster token rotation behaves.
+//
+// Parameters:
+//   - overlap: How long the previous token keeps validating (0 = revoke immediately)
+//   - notifier: Receives a cluster_token.rotated event per rotation (nil = none)
+func (s *TopologyService) ConfigureTokenRotation(overlap time.Duration, notifier events.Notifier) {
+	s.tokenOverlap = overlap
+	s.events = notifier
+}
+
+// WithActor returns a copy of the service that records actor (a node ID) as
+// the author of the config changes it makes.
+//
+// Parameters:
+//   - actor: Node ID making the changes
+//
+// Returns:
+//   - TopologyService attributing changes to actor
+func (s *TopologyService) WithActor(actor string) *TopologyService {
+	scoped := *s
+	scoped.actor = actor
+	return &scoped
+}
+
+// change describes a config change made through this service.
+func (s *TopologyService) change(changeType, detail string) configChange {
+	return configChange{changeType: changeType, actor: s.actor, detail: detail}
+}
+
 // UpdateRoutes updates the advertised routes for a node.
 //
-// Routes are validated as CIDR notation. An empty array clears all routes.
-// Updates bump the cluster config version.
+// Routes are validated as CIDR notation, classified as IPv4 or IPv6, and
+// stored normalized (host bits cleared, canonical address form). IPv6 routes
+// are rejected in IPv4-only clusters, and routes overlapping another node's
+// routes are rejected unless the cluster allows overlapping routes. An empty
+// array clears all routes. Updates bump the cluster config version.
 //
 // Parameters:
 //   - nodeID: Node UUID
-//   - routes: Array of CIDR strings (e.g., ["10.0.1.0/24"])
+//   - routes: Array of CIDR strings (e.g., ["10.0.1.0/24", "fd00::/64"])
 //
 // Returns:
-//   - Error if validation fails or update fails
+//   - Error if validation fails or update fails; models.ErrIPv6NotAllowed
+//     (wrapped with the offending route) if the cluster is IPv4-only;
+//     models.ErrRouteOverlap (wrapped with the conflicting node and CIDR) if a
+//     route overlaps another node's route
 func (s *TopologyService) UpdateRoutes(nodeID string, routes []string) error {
-	// Validate all routes
+	// Validate and normalize all routes
+	prefixes := make([]netip.Prefix, 0, len(routes))
+	normalized := make([]string, 0, len(routes))
+	var ipv6Routes []string
 	for _, route := range routes {
-		if err := validateCIDR(route); err != nil {
+		prefix, err := normalizeRoute(route)
+		if err != nil {
 			s.logger.Warn("Invalid CIDR in route update",
 				zap.String("node_id", nodeID),
 				zap.String("route", route),
 				zap.Error(err))
 			return fmt.Errorf("%w: %s", models.ErrInvalidCIDR, route)
 		}
+		if !prefix.Addr().Is4() {
+			ipv6Routes = append(ipv6Routes, route)
+		}
+		prefixes = append(prefixes, prefix)
+		normalized = append(normalized, prefix.String())
 	}
 
 	// Marshal routes to JSON
 	var routesJSON string
-	if len(routes) > 0 {
-		data, err := json.Marshal(routes)
+	if len(normalized) > 0 {
+		data, err := json.Marshal(normalized)
 		if err != nil {
 			return fmt.Errorf("failed to marshal routes: %w", err)
 		}
@@ -76,22 +147,15 @@ func (s *TopologyService) UpdateRoutes(nodeID string, routes []string) error {
 	}
 	defer tx.Rollback()
 
-	// Get cluster ID for version bump
-	var clusterID string
-	err = tx.QueryRow(`SELECT cluster_id FROM nodes WHERE id = ?`, nodeID).Scan(&clusterID)
-	if err == sql.ErrNoRows {
-		return models.ErrNodeNotFound
-	} else if err != nil {
-		return fmt.Errorf("failed to get cluster ID: %w", err)
-	}
-
-	// Update routes
-	now := time.Now().Unix()
+	// Write the routes before checking them, so the transaction holds the
+	// database write lock while it reads the other nodes' routes; concurrent
+	// updates are serialized instead of both passing the overlap check
+	now := s.clock.Now().Unix()
 	result, err := tx.Exec(`
 		UPDATE nodes
-		SET routes = ?, routes_updated_at = ?
-		WHERE id = ?
-	`, routesJSON, now, nodeID)
+		SET routes = ?, routes_updated_at = ?, updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`, routesJSON, now, now, nodeID)
 	if err != nil {
 		return fmt.Errorf("failed to update routes: %w", err)
 	}
@@ -101,14 +165,44 @@ func (s *TopologyService) UpdateRoutes(nodeID string, routes []string) error {
 		return models.ErrNodeNotFound
 	}
 
-	// Bump cluster config version
-	_, err = tx.Exec(`
-		UPDATE clusters
-		SET config_version = config_version + 1
-		WHERE id = ?
-	`, clusterID)
+	// Get cluster ID for version bump and the cluster's route policy
+	var clusterID string
+	var policy models.RoutePolicy
+	err = tx.QueryRow(`
+		SELECT n.cluster_id, c.ipv4_only, c.allow_overlapping_routes
+		FROM nodes n
+		JOIN clusters c ON c.id = n.cluster_id
+		WHERE n.id = ?
+	`, nodeID).Scan(&clusterID, &policy.IPv4Only, &policy.AllowOverlappingRoutes)
 	if err != nil {
-		return fmt.Errorf("failed to bump config version: %w", err)
+		return fmt.Errorf("failed to get cluster ID: %w", err)
+	}
+
+	if policy.IPv4Only && len(ipv6Routes) > 0 {
+		s.logger.Warn("IPv6 route rejected in IPv4-only cluster",
+			zap.String("node_id", nodeID),
+			zap.String("cluster_id", clusterID),
+			zap.Strings("routes", ipv6Routes))
+		return fmt.Errorf("%w: %s", models.ErrIPv6NotAllowed, ipv6Routes[0])
+	}
+
+	if !policy.AllowOverlappingRoutes && len(prefixes) > 0 {
+		clusterRoutes, err := loadClusterRoutes(tx, s.logger, clusterID)
+		if err != nil {
+			return err
+		}
+		if err := checkRouteOverlap(nodeID, prefixes, clusterRoutes); err != nil {
+			s.logger.Warn("Overlapping route rejected",
+				zap.String("node_id", nodeID),
+				zap.String("cluster_id", clusterID),
+				zap.Error(err))
+			return err
+		}
+	}
+
+	// Bump cluster config version
+	if _, _, err := bumpConfigVersion(context.Background(), tx, clusterID, s.change(models.ConfigChangeRoutes, nodeID)); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -117,7 +211,8 @@ func (s *TopologyService) UpdateRoutes(nodeID string, routes []string) error {
 
 	s.logger.Info("Updated node routes",
 		zap.String("node_id", nodeID),
-		zap.Int("route_count", len(routes)))
+		zap.Int("route_count", len(normalized)),
+		zap.Int("ipv6_route_count", len(ipv6Routes)))
 
 	return nil
 }
@@ -132,7 +227,7 @@ func (s *TopologyService) UpdateRoutes(nodeID string, routes []string) error {
 //   - Error if node not found
 func (s *TopologyService) GetNodeRoutes(nodeID string) ([]string, error) {
 	var routesJSON sql.NullString
-	err := s.db.QueryRow(`SELECT routes FROM nodes WHERE id = ?`, nodeID).Scan(&routesJSON)
+	err := s.db.QueryRow(`SELECT routes FROM nodes WHERE id = ? AND deleted_at IS NULL`, nodeID).Scan(&routesJSON)
 	if err == sql.ErrNoRows {
 		return nil, models.ErrNodeNotFound
 	} else if err != nil {
@@ -160,10 +255,21 @@ func (s *TopologyService) GetNodeRoutes(nodeID string) ([]string, error) {
 //   - Map of node ID to routes array
 //   - Error if query fails
 func (s *TopologyService) GetClusterRoutes(clusterID string) (map[string][]string, error) {
-	rows, err := s.db.Query(`
+	return loadClusterRoutes(s.db, s.logger, clusterID)
+}
+
+// rowsQuerier is satisfied by both *sql.DB and *sql.Tx.
+type rowsQuerier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// loadClusterRoutes returns the routes of a cluster's active nodes, keyed by
+// node ID. Nodes whose routes cannot be decoded are logged and skipped.
+func loadClusterRoutes(q rowsQuerier, logger *zap.Logger, clusterID string) (map[string][]string, error) {
+	rows, err := q.Query(`
 		SELECT id, routes
 		FROM nodes
-		WHERE cluster_id = ? AND routes IS NOT NULL AND routes != ''
+		WHERE cluster_id = ? AND deleted_at IS NULL AND routes IS NOT NULL AND routes != ''
 	`, clusterID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query routes: %w", err)
@@ -180,7 +286,7 @@ func (s *TopologyService) GetClusterRoutes(clusterID string) (map[string][]strin
 
 		var routes []string
 		if err := json.Unmarshal([]byte(routesJSON), &routes); err != nil {
-			s.logger.Warn("Failed to unmarshal routes for node",
+			logger.Warn("Failed to unmarshal routes for node",
 				zap.String("node_id", nodeID),
 				zap.Error(err))
 			continue
@@ -194,6 +300,9 @@ func (s *TopologyService) GetClusterRoutes(clusterID string) (map[string][]strin
 
 // SetLighthouse assigns lighthouse status to a node.
 //
+// The port must not collide with the cluster's lighthouse DNS port when
+// lighthouse DNS is enabled.
+//
 // Parameters:
 //   - clusterID: Cluster UUID
 //   - nodeID: Node UUID
@@ -201,7 +310,8 @@ func (s *TopologyService) GetClusterRoutes(clusterID string) (map[string][]strin
 //   - port: UDP port (0 = use cluster default)
 //
 // Returns:
-//   - Error if node not found or update fails
+//   - Error if the IP or port is invalid, the port collides with the DNS
+//     port, node not found, or update fails
 func (s *TopologyService) SetLighthouse(clusterID, nodeID, publicIP string, port int) error {
 	// Validate public IP
 	if net.ParseIP(publicIP) == nil {
@@ -215,16 +325,21 @@ func (s *TopologyService) SetLighthouse(clusterID, nodeID, publicIP string, port
 	}
 	defer tx.Rollback()
 
+	if err := checkLighthousePort(tx, clusterID, port); err != nil {
+		return err
+	}
+
 	// Update node
-	now := time.Now().Unix()
+	now := s.clock.Now().Unix()
 	result, err := tx.Exec(`
 		UPDATE nodes
 		SET is_lighthouse = 1,
 		    lighthouse_public_ip = ?,
 		    lighthouse_port = ?,
-		    lighthouse_relay_updated_at = ?
-		WHERE id = ? AND cluster_id = ?
-	`, publicIP, port, now, nodeID, clusterID)
+		    lighthouse_relay_updated_at = ?,
+		    updated_at = ?
+		WHERE id = ? AND cluster_id = ? AND deleted_at IS NULL
+	`, publicIP, port, now, now, nodeID, clusterID)
 	if err != nil {
 		return fmt.Errorf("failed to set lighthouse: %w", err)
 	}
@@ -235,13 +350,8 @@ func (s *TopologyService) SetLighthouse(clusterID, nodeID, publicIP string, port
 	}
 
 	// Bump cluster config version
-	_, err = tx.Exec(`
-		UPDATE clusters
-		SET config_version = config_version + 1
-		WHERE id = ?
-	`, clusterID)
-	if err != nil {
-		return fmt.Errorf("failed to bump config version: %w", err)
+	if _, _, err := bumpConfigVersion(context.Background(), tx, clusterID, s.change(models.ConfigChangeLighthouseSet, nodeID)); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -273,15 +383,16 @@ func (s *TopologyService) UnsetLighthouse(clusterID, nodeID string) error {
 	defer tx.Rollback()
 
 	// Update node
-	now := time.Now().Unix()
+	now := s.clock.Now().Unix()
 	result, err := tx.Exec(`
 		UPDATE nodes
 		SET is_lighthouse = 0,
 		    lighthouse_public_ip = NULL,
 		    lighthouse_port = NULL,
-		    lighthouse_relay_updated_at = ?
-		WHERE id = ? AND cluster_id = ?
-	`, now, nodeID, clusterID)
+		    lighthouse_relay_updated_at = ?,
+		    updated_at = ?
+		WHERE id = ? AND cluster_id = ? AND deleted_at IS NULL
+	`, now, now, nodeID, clusterID)
 	if err != nil {
 		return fmt.Errorf("failed to unset lighthouse: %w", err)
 	}
@@ -292,13 +403,8 @@ func (s *TopologyService) UnsetLighthouse(clusterID, nodeID string) error {
 	}
 
 	// Bump cluster config version
-	_, err = tx.Exec(`
-		UPDATE clusters
-		SET config_version = config_version + 1
-		WHERE id = ?
-	`, clusterID)
-	if err != nil {
-		return fmt.Errorf("failed to bump config version: %w", err)
+	if _, _, err := bumpConfigVersion(context.Background(), tx, clusterID, s.change(models.ConfigChangeLighthouseUnset, nodeID)); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -312,65 +418,176 @@ func (s *TopologyService) UnsetLighthouse(clusterID, nodeID string) error {
 
 // SetRelay assigns relay status to a node.
 //
+// Rejects assignments that would make every node in a multi-node cluster a
+// relay, and warns when relays exceed half of the cluster.
+//
 // Parameters:
 //   - clusterID: Cluster UUID
 //   - nodeID: Node UUID
 //
 // Returns:
-//   - Error if node not found or update fails
-func (s *TopologyService) SetRelay(clusterID, nodeID string) error {
+//   - Warnings about the resulting relay mesh (empty if none)
+//   - Error if node not found, the relay mesh would be saturated, or update fails
+func (s *TopologyService) SetRelay(clusterID, nodeID string) ([]config.Issue, error) {
 	// Start transaction
 	tx, err := s.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to start transaction: %w", err)
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	// Check current relay state of the node
+	var isRelay int
+	err = tx.QueryRow(`SELECT is_relay FROM nodes WHERE id = ? AND cluster_id = ? AND deleted_at IS NULL`, nodeID, clusterID).Scan(&isRelay)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrNodeNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+
+	// Validate relay mesh size
+	var nodeCount, relayCount int
+	err = tx.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(is_relay), 0)
+		FROM nodes
+		WHERE cluster_id = ? AND deleted_at IS NULL
+	`, clusterID).Scan(&nodeCount, &relayCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count relays: %w", err)
+	}
+
+	if isRelay == 0 {
+		relayCount++
+	}
+	if nodeCount > 1 && relayCount >= nodeCount {
+		s.logger.Warn("Rejected relay assignment: every node would be a relay",
+			zap.String("cluster_id", clusterID),
+			zap.String("node_id", nodeID),
+			zap.Int("node_count", nodeCount))
+		return nil, models.ErrRelayMeshSaturated
+	}
+	warnings := []config.Issue{}
+	if float64(relayCount)/float64(nodeCount) > relayWarnFraction {
+		s.logger.Warn("Relay count exceeds recommended fraction of cluster",
+			zap.String("cluster_id", clusterID),
+			zap.Int("relay_count", relayCount),
+			zap.Int("node_count", nodeCount),
+			zap.Float64("max_fraction", relayWarnFraction))
+		warnings = append(warnings, config.Issue{
+			Severity: config.SeverityWarning,
+			Code:     config.IssueTooManyRelays,
+			Message: fmt.Sprintf("%d of %d nodes are relays, more than the recommended %.0f%% of the cluster",
+				relayCount, nodeCount, relayWarnFraction*100),
+		})
+	}
+
 	// Update node
-	now := time.Now().Unix()
+	now := s.clock.Now().Unix()
 	result, err := tx.Exec(`
 		UPDATE nodes
 		SET is_relay = 1,
-		    lighthouse_relay_updated_at = ?
-		WHERE id = ? AND cluster_id = ?
-	`, now, nodeID, clusterID)
+		    lighthouse_relay_updated_at = ?,
+		    updated_at = ?
+		WHERE id = ? AND cluster_id = ? AND deleted_at IS NULL
+	`, now, now, nodeID, clusterID)
 	if err != nil {
-		return fmt.Errorf("failed to set relay: %w", err)
+		return nil, fmt.Errorf("failed to set relay: %w", err)
 	}
 
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return models.ErrNodeNotFound
+		return nil, models.ErrNodeNotFound
 	}
 
 	// Bump cluster config version
-	_, err = tx.Exec(`
-		UPDATE clusters
-		SET config_version = config_version + 1
-		WHERE id = ?
-	`, clusterID)
-	if err != nil {
-		return fmt.Errorf("failed to bump config version: %w", err)
+	if _, _, err := bumpConfigVersion(context.Background(), tx, clusterID, s.change(models.ConfigChangeRelaySet, nodeID)); err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	s.logger.Info("Set relay status", zap.String("node_id", nodeID))
 
+	return warnings, nil
+}
+
+// SetMaxRelayHops sets the relay hop limit for a cluster.
+//
+// The config generator disables relaying when the limit is 0 and only lets
+// relay nodes use other relays when the limit is above 1.
+//
+// Parameters:
+//   - clusterID: Cluster UUID
+//   - hops: Maximum relay hops (0-4)
+//
+// Returns:
+//   - Error if the limit is out of range, cluster not found, or update fails
+func (s *TopologyService) SetMaxRelayHops(clusterID string, hops int) error {
+	if hops < 0 || hops > config.MaxRelayHopsLimit {
+		return fmt.Errorf("%w: max_relay_hops must be between 0 and %d", models.ErrInvalidRequest, config.MaxRelayHopsLimit)
+	}
+
+	err := s.updateClusterSettings(clusterID, "max_relay_hops", `
+		UPDATE clusters SET max_relay_hops = ? WHERE id = ?
+	`, hops, clusterID)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("Set max relay hops",
+		zap.String("cluster_id", clusterID),
+		zap.Int("max_relay_hops", hops))
+
 	return nil
 }
 
-// UnsetRelay removes relay status from a node.
+// GetLighthouseDNS returns the lighthouse DNS settings for a cluster.
 //
 // Parameters:
 //   - clusterID: Cluster UUID
-//   - nodeID: Node UUID
 //
 // Returns:
-//   - Error if node not found or update fails
-func (s *TopologyService) UnsetRelay(clusterID, nodeID string) error {
+//   - Lighthouse DNS settings
+//   - Error if cluster not found or query fails
+func (s *TopologyService) GetLighthouseDNS(clusterID string) (*models.LighthouseDNSConfig, error) {
+	dns, _, err := lighthouseDNSSettings(s.db, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	return dns, nil
+}
+
+// SetLighthouseDNS updates the lighthouse DNS settings for a cluster.
+//
+// Empty host and zero port fall back to 0.0.0.0 and 53. When DNS is enabled,
+// the port must differ from the cluster's Nebula UDP port and from the port of
+// every lighthouse node in the cluster.
+//
+// Parameters:
+//   - clusterID: Cluster UUID
+//   - dns: Lighthouse DNS settings
+//
+// Returns:
+//   - Error if settings are invalid, the port collides, cluster not found, or update fails
+func (s *TopologyService) SetLighthouseDNS(clusterID string, dns *models.LighthouseDNSConfig) error {
+	host := dns.Host
+	if host == "" {
+		host = config.DefaultDNSHost
+	}
+	port := dns.Port
+	if port == 0 {
+		port = config.DefaultDNSPort
+	}
+
+	if net.ParseIP(host) == nil {
+		return fmt.Errorf("%w: invalid DNS host address", models.ErrInvalidRequest)
+	}
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("%w: DNS port must be between 1 and 65535", models.ErrInvalidRequest)
+	}
+
 	// Start transaction
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -378,138 +595,874 @@ func (s *TopologyService) UnsetRelay(clusterID, nodeID string) error {
 	}
 	defer tx.Rollback()
 
-	// Update node
-	now := time.Now().Unix()
-	result, err := tx.Exec(`
-		UPDATE nodes
-		SET is_relay = 0,
-		    lighthouse_relay_updated_at = ?
-		WHERE id = ? AND cluster_id = ?
-	`, now, nodeID, clusterID)
+	_, clusterPort, err := lighthouseDNSSettings(tx, clusterID)
 	if err != nil {
-		return fmt.Errorf("failed to unset relay: %w", err)
+		return err
 	}
 
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		return models.ErrNodeNotFound
+	if dns.Enabled {
+		if port == clusterPort {
+			return models.ErrDNSPortConflict
+		}
+
+		var conflicts int
+		err = tx.QueryRow(`
+			SELECT COUNT(*) FROM nodes
+			WHERE cluster_id = ? AND deleted_at IS NULL AND is_lighthouse = 1 AND lighthouse_port = ?
+		`, clusterID, port).Scan(&conflicts)
+		if err != nil {
+			return fmt.Errorf("failed to check lighthouse ports: %w", err)
+		}
+		if conflicts > 0 {
+			return models.ErrDNSPortConflict
+		}
 	}
 
-	// Bump cluster config version
 	_, err = tx.Exec(`
 		UPDATE clusters
-		SET config_version = config_version + 1
+		SET lighthouse_dns_enabled = ?,
+		    lighthouse_dns_host = ?,
+		    lighthouse_dns_port = ?
 		WHERE id = ?
-	`, clusterID)
+	`, dns.Enabled, host, port, clusterID)
 	if err != nil {
-		return fmt.Errorf("failed to bump config version: %w", err)
+		return fmt.Errorf("failed to set lighthouse DNS: %w", err)
+	}
+
+	if _, _, err := bumpConfigVersion(context.Background(), tx, clusterID, s.change(models.ConfigChangeClusterSettings, "lighthouse_dns")); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	s.logger.Info("Unset relay status", zap.String("node_id", nodeID))
+	s.logger.Info("Set lighthouse DNS",
+		zap.String("cluster_id", clusterID),
+		zap.Bool("enabled", dns.Enabled),
+		zap.String("host", host),
+		zap.Int("port", port))
 
 	return nil
 }
 
-// TopologyInfo holds information about cluster topology.
-type TopologyInfo struct {
-	// Lighthouses is the list of lighthouse nodes.
-	Lighthouses []LighthouseInfo `json:"lighthouses"`
+// SetNebulaSubnet sets the overlay network CIDR for a cluster.
+//
+// Static host map entries must fall inside this subnet, so the change is
+// rejected if any existing entry would end up outside it.
+//
+// Parameters:
+//   - clusterID: Cluster UUID
+//   - subnet: Overlay network CIDR (e.g., 10.42.0.0/16)
+//
+// Returns:
+//   - Error if the CIDR is invalid, excludes static hosts, cluster not found, or update fails
+func (s *TopologyService) SetNebulaSubnet(clusterID, subnet string) error {
+	_, network, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return fmt.Errorf("%w: invalid subnet %q", models.ErrInvalidRequest, subnet)
+	}
 
-	// Relays is the list of relay nodes.
-	Relays []RelayInfo `json:"relays"`
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	// Routes is a map of node ID to advertised routes.
-	Routes map[string][]string `json:"routes"`
-}
+	_, hosts, err := staticHostSettings(tx, clusterID)
+	if err != nil {
+		return err
+	}
+	for nebulaIP := range hosts {
+		if !network.Contains(net.ParseIP(nebulaIP)) {
+			return fmt.Errorf("%w: static host %s is outside subnet %s", models.ErrInvalidRequest, nebulaIP, network)
+		}
+	}
 
-// LighthouseInfo holds information about a lighthouse node.
-type LighthouseInfo struct {
-	// NodeID is the node's UUID.
-	NodeID string `json:"node_id"`
+	_, err = tx.Exec(`
+		UPDATE clusters
+		SET nebula_subnet = ?
+		WHERE id = ?
+	`, network.String(), clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to set subnet: %w", err)
+	}
 
-	// Name is the node's name.
-	Name string `json:"name"`
+	if _, _, err := bumpConfigVersion(context.Background(), tx, clusterID, s.change(models.ConfigChangeClusterSettings, "nebula_subnet")); err != nil {
+		return err
+	}
 
-	// PublicIP is the lighthouse's public IP address.
-	PublicIP string `json:"public_ip"`
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 
-	// Port is the UDP port.
-	Port int `json:"port"`
-}
+	s.logger.Info("Set cluster subnet",
+		zap.String("cluster_id", clusterID),
+		zap.String("subnet", network.String()))
 
-// RelayInfo holds information about a relay node.
-type RelayInfo struct {
-	// NodeID is the node's UUID.
-	NodeID string `json:"node_id"`
+	return nil
+}
 
-	// Name is the node's name.
-	Name string `json:"name"`
+// GetStaticHostMap returns the static host map for a cluster.
+//
+// Parameters:
+//   - clusterID: Cluster UUID
+//
+// Returns:
+//   - Map of Nebula IP to public endpoints
+//   - Error if cluster not found or query fails
+func (s *TopologyService) GetStaticHostMap(clusterID string) (map[string][]string, error) {
+	_, hosts, err := staticHostSettings(s.db, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	return hosts, nil
 }
 
-// GetTopology returns the complete topology for a cluster.
+// SetStaticHostMap replaces the static host map for a cluster.
+//
+// Every Nebula IP must belong to the cluster subnet, and every endpoint must
+// be an "ip:port" pair. Endpoints are normalised and de-duplicated. An empty
+// map clears the static host map.
 //
 // Parameters:
 //   - clusterID: Cluster UUID
+//   - hosts: Map of Nebula IP to public endpoints
 //
 // Returns:
-//   - TopologyInfo with lighthouses, relays, and routes
-//   - Error if query fails
-func (s *TopologyService) GetTopology(clusterID string) (*TopologyInfo, error) {
-	topology := &TopologyInfo{
-		Lighthouses: []LighthouseInfo{},
-		Relays:      []RelayInfo{},
-		Routes:      make(map[string][]string),
+//   - Error if an entry is invalid, cluster not found, or update fails
+func (s *TopologyService) SetStaticHostMap(clusterID string, hosts map[string][]string) error {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	// Query all nodes
-	rows, err := s.db.Query(`
-		SELECT id, name, is_lighthouse, lighthouse_public_ip, lighthouse_port,
-		       is_relay, routes
-		FROM nodes
-		WHERE cluster_id = ?
-	`, clusterID)
+	subnet, _, err := staticHostSettings(tx, clusterID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query nodes: %w", err)
+		return err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var nodeID, name string
-		var isLighthouse, isRelay int
-		var publicIP sql.NullString
-		var port sql.NullInt64
-		var routesJSON sql.NullString
+	if len(hosts) > 0 && subnet == nil {
+		return fmt.Errorf("%w: cluster subnet is not configured", models.ErrInvalidRequest)
+	}
 
-		if err := rows.Scan(&nodeID, &name, &isLighthouse, &publicIP, &port, &isRelay, &routesJSON); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+	normalized := make(map[string][]string, len(hosts))
+	for nebulaIP, endpoints := range hosts {
+		ip := net.ParseIP(nebulaIP)
+		if ip == nil {
+			return fmt.Errorf("%w: invalid Nebula IP %q", models.ErrInvalidRequest, nebulaIP)
+		}
+		if !subnet.Contains(ip) {
+			return fmt.Errorf("%w: Nebula IP %s is outside subnet %s", models.ErrInvalidRequest, nebulaIP, subnet)
+		}
+		if len(endpoints) == 0 {
+			return fmt.Errorf("%w: no endpoints for %s", models.ErrInvalidRequest, nebulaIP)
 		}
 
-		// Add lighthouse
-		if isLighthouse == 1 {
-			topology.Lighthouses = append(topology.Lighthouses, LighthouseInfo{
-				NodeID:   nodeID,
-				Name:     name,
-				PublicIP: publicIP.String,
-				Port:     int(port.Int64),
-			})
+		seen := make(map[string]bool, len(endpoints))
+		var list []string
+		for _, endpoint := range endpoints {
+			normalizedEndpoint, err := normalizeEndpoint(endpoint)
+			if err != nil {
+				return err
+			}
+			if seen[normalizedEndpoint] {
+				continue
+			}
+			seen[normalizedEndpoint] = true
+			list = append(list, normalizedEndpoint)
 		}
+		normalized[ip.String()] = list
+	}
 
-		// Add relay
-		if isRelay == 1 {
-			topology.Relays = append(topology.Relays, RelayInfo{
-				NodeID: nodeID,
-				Name:   name,
-			})
+	var stored interface{}
+	if len(normalized) > 0 {
+		data, err := json.Marshal(normalized)
+		if err != nil {
+			return fmt.Errorf("failed to marshal static host map: %w", err)
 		}
+		stored = string(data)
+	}
 
-		// Add routes
-		if routesJSON.Valid && routesJSON.String != "" {
-			var routes []string
-			if err := json.Unmarshal([]byte(routesJSON.String), &routes); err != nil {
+	_, err = tx.Exec(`
+		UPDATE clusters
+		SET static_host_map = ?
+		WHERE id = ?
+	`, stored, clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to set static host map: %w", err)
+	}
+
+	if _, _, err := bumpConfigVersion(context.Background(), tx, clusterID, s.change(models.ConfigChangeClusterSettings, "static_host_map")); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.logger.Info("Set static host map",
+		zap.String("cluster_id", clusterID),
+		zap.Int("hosts", len(normalized)))
+
+	return nil
+}
+
+// SetPreferredRanges replaces the cluster's Nebula preferred_ranges.
+//
+// Each entry must be a network CIDR with no host bits set (e.g.,
+// 192.168.0.0/16, not 192.168.1.5/16). Entries are normalised and
+// de-duplicated, keeping their order. An empty list clears the setting.
+//
+// Parameters:
+//   - clusterID: Cluster UUID
+//   - ranges: Network CIDRs nodes should try first
+//
+// Returns:
+//   - Error if a CIDR is invalid, cluster not found, or update fails
+func (s *TopologyService) SetPreferredRanges(clusterID string, ranges []string) error {
+	seen := make(map[string]bool, len(ranges))
+	var normalized []string
+	for _, cidr := range ranges {
+		ip, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("%w: invalid CIDR %q", models.ErrInvalidRequest, cidr)
+		}
+		if !ip.Equal(network.IP) {
+			return fmt.Errorf("%w: %q is not a network address (did you mean %s?)", models.ErrInvalidRequest, cidr, network)
+		}
+		if seen[network.String()] {
+			continue
+		}
+		seen[network.String()] = true
+		normalized = append(normalized, network.String())
+	}
+
+	var stored interface{}
+	if len(normalized) > 0 {
+		data, err := json.Marshal(normalized)
+		if err != nil {
+			return fmt.Errorf("failed to marshal preferred ranges: %w", err)
+		}
+		stored = string(data)
+	}
+
+	err := s.updateClusterSettings(clusterID, "preferred_ranges", `
+		UPDATE clusters SET preferred_ranges = ? WHERE id = ?
+	`, stored, clusterID)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("Set preferred ranges",
+		zap.String("cluster_id", clusterID),
+		zap.Strings("preferred_ranges", normalized))
+
+	return nil
+}
+
+// SetCipher changes the Nebula cipher for a cluster.
+//
+// Nodes using different ciphers cannot establish tunnels, so a cluster is
+// partially partitioned until every node has picked up the new config. The
+// change is therefore refused unless force is set, and is logged as an audit
+// entry at warn level. Setting the current cipher again is a no-op.
+//
+// Parameters:
+//   - clusterID: Cluster UUID
+//   - cipher: New cipher ("aes" or "chachapoly")
+//   - force: Confirms the disruptive change
+//
+// Returns:
+//   - Error if the cipher is unknown, not confirmed, cluster not found, or update fails
+func (s *TopologyService) SetCipher(clusterID, cipher string, force bool) error {
+	if !config.ValidCipher(cipher) {
+		return fmt.Errorf("%w: unknown cipher %q (allowed: %s, %s)", models.ErrInvalidRequest, cipher, config.CipherAES, config.CipherChachaPoly)
+	}
+
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current string
+	err = tx.QueryRow(`SELECT cipher FROM clusters WHERE id = ?`, clusterID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return models.ErrClusterNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to query cipher: %w", err)
+	}
+
+	if current == cipher {
+		return nil
+	}
+	if !force {
+		return fmt.Errorf("%w: changing cipher from %s to %s breaks tunnels until all nodes update", models.ErrConfirmationRequired, current, cipher)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE clusters
+		SET cipher = ?
+		WHERE id = ?
+	`, cipher, clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to set cipher: %w", err)
+	}
+
+	if _, _, err := bumpConfigVersion(context.Background(), tx, clusterID, s.change(models.ConfigChangeClusterSettings, "cipher")); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.logger.Warn("Cluster cipher changed; tunnels between nodes on different ciphers will fail until all nodes update",
+		zap.Bool(logging.FieldAudit, true),
+		zap.String(logging.FieldOperation, "set_cipher"),
+		zap.String(logging.FieldClusterID, clusterID),
+		zap.String("old_cipher", current),
+		zap.String("new_cipher", cipher))
+
+	return nil
+}
+
+// SetRequestSigning turns the signed-request requirement for a cluster's
+// node requests on or off.
+//
+// This is not part of the Nebula config, so the config version is not bumped.
+// The change is logged as an audit entry.
+//
+// Parameters:
+//   - clusterID: Cluster UUID
+//   - required: Whether node requests must be signed
+//
+// Returns:
+//   - Error if cluster not found or update fails
+func (s *TopologyService) SetRequestSigning(clusterID string, required bool) error {
+	result, err := s.db.Exec(`
+		UPDATE clusters
+		SET require_signed_requests = ?
+		WHERE id = ?
+	`, required, clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to set request signing: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return models.ErrClusterNotFound
+	}
+
+	s.logger.Info("Set request signing requirement",
+		zap.Bool(logging.FieldAudit, true),
+		zap.String(logging.FieldOperation, "set_request_signing"),
+		zap.String(logging.FieldClusterID, clusterID),
+		zap.Bool("required", required))
+
+	return nil
+}
+
+// SetRoutePolicy replaces the rules node routes must follow in a cluster.
+//
+// The policy must already hold for the routes nodes advertise: making a
+// cluster IPv4-only fails if a node advertises an IPv6 route, and
+// disallowing overlapping routes fails if two nodes' routes overlap. The
+// offending routes must be withdrawn first. The policy only gates route
+// updates and is not part of the Nebula config, so the config version is not
+// bumped. The change is logged as an audit entry.
+//
+// Parameters:
+//   - clusterID: Cluster UUID
+//   - policy: Route policy
+//
+// Returns:
+//   - Error if cluster not found or update fails; models.ErrIPv6NotAllowed
+//     or models.ErrRouteOverlap (wrapped with the offending route) if an
+//     existing route violates the policy
+func (s *TopologyService) SetRoutePolicy(clusterID string, policy *models.RoutePolicy) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Update first so no route update can slip in between the check and the commit
+	result, err := tx.Exec(`
+		UPDATE clusters
+		SET ipv4_only = ?, allow_overlapping_routes = ?
+		WHERE id = ?
+	`, policy.IPv4Only, policy.AllowOverlappingRoutes, clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to set route policy: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return models.ErrClusterNotFound
+	}
+
+	if err := checkRoutePolicy(tx, s.logger, clusterID, "", *policy); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit route policy: %w", err)
+	}
+
+	s.logger.Info("Set route policy",
+		zap.Bool(logging.FieldAudit, true),
+		zap.String(logging.FieldOperation, "set_route_policy"),
+		zap.String(logging.FieldClusterID, clusterID),
+		zap.Bool("ipv4_only", policy.IPv4Only),
+		zap.Bool("allow_overlapping_routes", policy.AllowOverlappingRoutes))
+
+	return nil
+}
+
+// SetHandshakeConfig replaces the Nebula handshake tuning for a cluster.
+//
+// Zero values reset a setting to the Nebula default.
+//
+// Parameters:
+//   - clusterID: Cluster UUID
+//   - hs: Handshake tuning
+//
+// Returns:
+//   - Error if a value is out of range, cluster not found, or update fails
+func (s *TopologyService) SetHandshakeConfig(clusterID string, hs *models.HandshakeConfig) error {
+	if err := validateHandshakeConfig(hs); err != nil {
+		return err
+	}
+
+	err := s.updateClusterSettings(clusterID, "handshake", `
+		UPDATE clusters
+		SET handshake_try_interval = ?,
+		    handshake_retries = ?,
+		    handshake_trigger_buffer = ?
+		WHERE id = ?
+	`, nullIfEmpty(hs.TryInterval), nullIfZero(hs.Retries), nullIfZero(hs.TriggerBuffer), clusterID)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("Set handshake config",
+		zap.String("cluster_id", clusterID),
+		zap.String("try_interval", hs.TryInterval),
+		zap.Int("retries", hs.Retries),
+		zap.Int("trigger_buffer", hs.TriggerBuffer))
+
+	return nil
+}
+
+// updateClusterSettings runs an UPDATE of cluster-wide settings and bumps the
+// config version in the same transaction, recording setting in the config
+// history.
+func (s *TopologyService) updateClusterSettings(clusterID, setting, query string, args ...interface{}) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to set %s: %w", setting, err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return models.ErrClusterNotFound
+	}
+
+	if _, _, err := bumpConfigVersion(context.Background(), tx, clusterID, s.change(models.ConfigChangeClusterSettings, setting)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// validateHandshakeConfig checks handshake tuning against sane bounds.
+func validateHandshakeConfig(hs *models.HandshakeConfig) error {
+	if hs.TryInterval != "" {
+		d, err := time.ParseDuration(hs.TryInterval)
+		if err != nil || d < 10*time.Millisecond || d > 5*time.Second {
+			return fmt.Errorf("%w: try_interval must be a duration between 10ms and 5s", models.ErrInvalidRequest)
+		}
+	}
+	if hs.Retries < 0 || hs.Retries > 100 {
+		return fmt.Errorf("%w: retries must be between 0 (default) and 100", models.ErrInvalidRequest)
+	}
+	if hs.TriggerBuffer < 0 || hs.TriggerBuffer > 4096 {
+		return fmt.Errorf("%w: trigger_buffer must be between 0 (default) and 4096", models.ErrInvalidRequest)
+	}
+	return nil
+}
+
+// nullIfEmpty converts an empty string to SQL NULL.
+func nullIfEmpty(v string) interface{} {
+	if v == "" {
+		return nil
+	}
+	return v
+}
+
+// nullIfZero converts a zero integer to SQL NULL.
+func nullIfZero(v int) interface{} {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
+
+// staticHostSettings loads a cluster's subnet (nil if unset) and static host map.
+func staticHostSettings(q queryRower, clusterID string) (*net.IPNet, map[string][]string, error) {
+	var subnetStr, hostsJSON sql.NullString
+	err := q.QueryRow(`
+		SELECT nebula_subnet, static_host_map FROM clusters WHERE id = ?
+	`, clusterID).Scan(&subnetStr, &hostsJSON)
+	if err == sql.ErrNoRows {
+		return nil, nil, models.ErrClusterNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query static host map: %w", err)
+	}
+
+	var subnet *net.IPNet
+	if subnetStr.Valid && subnetStr.String != "" {
+		if _, subnet, err = net.ParseCIDR(subnetStr.String); err != nil {
+			return nil, nil, fmt.Errorf("invalid stored subnet %q: %w", subnetStr.String, err)
+		}
+	}
+
+	hosts := make(map[string][]string)
+	if hostsJSON.Valid && hostsJSON.String != "" {
+		if err := json.Unmarshal([]byte(hostsJSON.String), &hosts); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal static host map: %w", err)
+		}
+	}
+	return subnet, hosts, nil
+}
+
+// normalizeEndpoint validates an "ip:port" endpoint and returns its canonical form.
+func normalizeEndpoint(endpoint string) (string, error) {
+	host, portStr, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid endpoint %q, expected ip:port", models.ErrInvalidRequest, endpoint)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "", fmt.Errorf("%w: invalid endpoint IP in %q", models.ErrInvalidRequest, endpoint)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return "", fmt.Errorf("%w: invalid endpoint port in %q", models.ErrInvalidRequest, endpoint)
+	}
+	return net.JoinHostPort(ip.String(), strconv.Itoa(port)), nil
+}
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx.
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// lighthouseDNSSettings loads a cluster's DNS settings and its Nebula UDP port.
+func lighthouseDNSSettings(q queryRower, clusterID string) (*models.LighthouseDNSConfig, int, error) {
+	var dns models.LighthouseDNSConfig
+	var lighthousePort sql.NullInt64
+	err := q.QueryRow(`
+		SELECT lighthouse_dns_enabled, lighthouse_dns_host, lighthouse_dns_port, lighthouse_port
+		FROM clusters
+		WHERE id = ?
+	`, clusterID).Scan(&dns.Enabled, &dns.Host, &dns.Port, &lighthousePort)
+	if err == sql.ErrNoRows {
+		return nil, 0, models.ErrClusterNotFound
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query lighthouse DNS settings: %w", err)
+	}
+
+	port := 4242
+	if lighthousePort.Valid && lighthousePort.Int64 != 0 {
+		port = int(lighthousePort.Int64)
+	}
+	return &dns, port, nil
+}
+
+// checkLighthousePort validates a lighthouse's UDP port (0 = use cluster
+// default) and rejects a port that would collide with the cluster's DNS
+// listener when lighthouse DNS is enabled.
+func checkLighthousePort(q queryRower, clusterID string, port int) error {
+	if port < 0 || port > 65535 {
+		return fmt.Errorf("%w: lighthouse port must be between 1 and 65535, or 0 for the cluster default", models.ErrInvalidRequest)
+	}
+
+	dns, clusterPort, err := lighthouseDNSSettings(q, clusterID)
+	if err != nil {
+		return err
+	}
+	if port == 0 {
+		port = clusterPort
+	}
+	if dns.Enabled && dns.Port == port {
+		return models.ErrDNSPortConflict
+	}
+	return nil
+}
+
+// UnsetRelay removes relay status from a node.
+//
+// Parameters:
+//   - clusterID: Cluster UUID
+//   - nodeID: Node UUID
+//
+// Returns:
+//   - Error if node not found or update fails
+func (s *TopologyService) UnsetRelay(clusterID, nodeID string) error {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Update node
+	now := s.clock.Now().Unix()
+	result, err := tx.Exec(`
+		UPDATE nodes
+		SET is_relay = 0,
+		    lighthouse_relay_updated_at = ?,
+		    updated_at = ?
+		WHERE id = ? AND cluster_id = ? AND deleted_at IS NULL
+	`, now, now, nodeID, clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to unset relay: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return models.ErrNodeNotFound
+	}
+
+	// Bump cluster config version
+	if _, _, err := bumpConfigVersion(context.Background(), tx, clusterID, s.change(models.ConfigChangeRelayUnset, nodeID)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.logger.Info("Unset relay status", zap.String("node_id", nodeID))
+
+	return nil
+}
+
+// TopologyInfo holds information about cluster topology.
+type TopologyInfo struct {
+	// Lighthouses is the list of lighthouse nodes.
+	Lighthouses []LighthouseInfo `json:"lighthouses"`
+
+	// Relays is the list of relay nodes.
+	Relays []RelayInfo `json:"relays"`
+
+	// Routes is a map of node ID to advertised routes.
+	Routes map[string][]string `json:"routes"`
+
+	// RoutesByName is a map of node name to advertised routes.
+	// Only populated by ExportSnapshot, since node IDs differ across clusters.
+	RoutesByName map[string][]string `json:"routes_by_name,omitempty"`
+
+	// RelayStats summarises relay usage in the cluster.
+	RelayStats RelayStats `json:"relay_stats"`
+
+	// StaticHostMap maps Nebula IPs to fixed public endpoints ("ip:port").
+	// These entries are emitted into every node's static_host_map.
+	StaticHostMap map[string][]string `json:"static_host_map"`
+
+	// PreferredRanges lists networks nodes try first when connecting to peers.
+	PreferredRanges []string `json:"preferred_ranges"`
+
+	// Cipher is the Nebula cipher used by the cluster.
+	Cipher string `json:"cipher"`
+
+	// Handshake holds handshake tuning (nil = Nebula defaults).
+	Handshake *models.HandshakeConfig `json:"handshake,omitempty"`
+}
+
+// RelayStats holds relay statistics for a cluster.
+type RelayStats struct {
+	// RelayCount is the number of relay nodes.
+	RelayCount int `json:"relay_count"`
+
+	// NodeCount is the total number of nodes.
+	NodeCount int `json:"node_count"`
+
+	// RelayFraction is the share of nodes acting as relays (0-1).
+	RelayFraction float64 `json:"relay_fraction"`
+
+	// MaxRelayHops is the cluster's relay hop limit.
+	MaxRelayHops int `json:"max_relay_hops"`
+}
+
+// LighthouseInfo holds information about a lighthouse node.
+type LighthouseInfo struct {
+	// NodeID is the node's UUID.
+	NodeID string `json:"node_id"`
+
+	// Name is the node's name.
+	Name string `json:"name"`
+
+	// PublicIP is the lighthouse's public IP address.
+	PublicIP string `json:"public_ip"`
+
+	// Port is the UDP port.
+	Port int `json:"port"`
+}
+
+// RelayInfo holds information about a relay node.
+type RelayInfo struct {
+	// NodeID is the node's UUID.
+	NodeID string `json:"node_id"`
+
+	// Name is the node's name.
+	Name string `json:"name"`
+}
+
+// GetTopology returns the complete topology for a cluster.
+//
+// Results are cached per cluster and served while the cluster's config version
+// is unchanged. The version is read before the topology is loaded, so a cached
+// entry is never older than the version it is keyed by.
+//
+// Parameters:
+//   - clusterID: Cluster UUID
+//
+// Returns:
+//   - TopologyInfo with lighthouses, relays, routes, relay statistics, and
+//     cluster-wide settings (static hosts, preferred ranges, cipher, handshake)
+//   - Error if query fails
+func (s *TopologyService) GetTopology(clusterID string) (*TopologyInfo, error) {
+	var version int64
+	err := s.db.QueryRow(`SELECT config_version FROM clusters WHERE id = ?`, clusterID).Scan(&version)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to query config version: %w", err)
+	}
+	cacheable := err == nil
+
+	if cacheable {
+		if cached, ok := s.cache.get(clusterID, version); ok {
+			return cached, nil
+		}
+	}
+
+	topology, err := s.loadTopology(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable {
+		s.cache.put(clusterID, version, topology)
+	}
+	return topology, nil
+}
+
+// loadTopology reads a cluster's topology from the database.
+func (s *TopologyService) loadTopology(clusterID string) (*TopologyInfo, error) {
+	topology := &TopologyInfo{
+		Lighthouses:     []LighthouseInfo{},
+		Relays:          []RelayInfo{},
+		Routes:          make(map[string][]string),
+		RelayStats:      RelayStats{MaxRelayHops: config.DefaultMaxRelayHops},
+		StaticHostMap:   make(map[string][]string),
+		PreferredRanges: []string{},
+	}
+
+	// Query cluster-wide settings
+	var staticJSON, rangesJSON, tryInterval sql.NullString
+	var retries, triggerBuffer sql.NullInt64
+	err := s.db.QueryRow(`
+		SELECT max_relay_hops, static_host_map, preferred_ranges, cipher,
+		       handshake_try_interval, handshake_retries, handshake_trigger_buffer
+		FROM clusters WHERE id = ?
+	`, clusterID).Scan(&topology.RelayStats.MaxRelayHops, &staticJSON, &rangesJSON, &topology.Cipher,
+		&tryInterval, &retries, &triggerBuffer)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to query cluster: %w", err)
+	}
+	if tryInterval.Valid || retries.Valid || triggerBuffer.Valid {
+		topology.Handshake = &models.HandshakeConfig{
+			TryInterval:   tryInterval.String,
+			Retries:       int(retries.Int64),
+			TriggerBuffer: int(triggerBuffer.Int64),
+		}
+	}
+	if ranges := parseJSONList(rangesJSON); ranges != nil {
+		topology.PreferredRanges = ranges
+	}
+	if staticJSON.Valid && staticJSON.String != "" {
+		if err := json.Unmarshal([]byte(staticJSON.String), &topology.StaticHostMap); err != nil {
+			s.logger.Warn("Failed to unmarshal static host map",
+				zap.String("cluster_id", clusterID),
+				zap.Error(err))
+		}
+	}
+
+	// Query all nodes
+	rows, err := s.db.Query(`
+		SELECT id, name, is_lighthouse, lighthouse_public_ip, lighthouse_port,
+		       is_relay, routes
+		FROM nodes
+		WHERE cluster_id = ? AND deleted_at IS NULL
+	`, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nodes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		topology.RelayStats.NodeCount++
+
+		var nodeID, name string
+		var isLighthouse, isRelay int
+		var publicIP sql.NullString
+		var port sql.NullInt64
+		var routesJSON sql.NullString
+
+		if err := rows.Scan(&nodeID, &name, &isLighthouse, &publicIP, &port, &isRelay, &routesJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		// Add lighthouse
+		if isLighthouse == 1 {
+			topology.Lighthouses = append(topology.Lighthouses, LighthouseInfo{
+				NodeID:   nodeID,
+				Name:     name,
+				PublicIP: publicIP.String,
+				Port:     int(port.Int64),
+			})
+		}
+
+		// Add relay
+		if isRelay == 1 {
+			topology.Relays = append(topology.Relays, RelayInfo{
+				NodeID: nodeID,
+				Name:   name,
+			})
+		}
+
+		// Add routes
+		if routesJSON.Valid && routesJSON.String != "" {
+			var routes []string
+			if err := json.Unmarshal([]byte(routesJSON.String), &routes); err != nil {
 				s.logger.Warn("Failed to unmarshal routes",
 					zap.String("node_id", nodeID),
 					zap.Error(err))
@@ -519,49 +1472,434 @@ func (s *TopologyService) GetTopology(clusterID string) (*TopologyInfo, error) {
 		}
 	}
 
+	topology.RelayStats.RelayCount = len(topology.Relays)
+	if topology.RelayStats.NodeCount > 0 {
+		topology.RelayStats.RelayFraction = float64(topology.RelayStats.RelayCount) / float64(topology.RelayStats.NodeCount)
+	}
+
+	return topology, nil
+}
+
+// ExportSnapshot returns a portable snapshot of the cluster topology.
+//
+// The snapshot is the same as GetTopology but additionally keys routes by
+// node name, so it can be imported into a different cluster where node IDs
+// are not the same.
+//
+// Parameters:
+//   - clusterID: Cluster UUID
+//
+// Returns:
+//   - TopologyInfo with lighthouses, relays, routes, and routes by name
+//   - Error if query fails
+func (s *TopologyService) ExportSnapshot(clusterID string) (*TopologyInfo, error) {
+	topology, err := s.GetTopology(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := s.nodeNamesByID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	topology.RoutesByName = make(map[string][]string, len(topology.Routes))
+	for nodeID, routes := range topology.Routes {
+		if name, ok := names[nodeID]; ok {
+			topology.RoutesByName[name] = routes
+		}
+	}
+
 	return topology, nil
 }
 
+// ImportSnapshot applies a topology snapshot to a cluster.
+//
+// Lighthouses, relays, and routes are matched to nodes by name. The snapshot
+// replaces the cluster's existing topology: nodes not mentioned in the
+// snapshot lose their lighthouse/relay status and routes. All changes are
+// applied in a single transaction with one config version bump. The static
+// host map is tied to the source cluster's subnet and is not imported.
+//
+// If any node named in the snapshot does not exist in the target cluster,
+// nothing is applied and ErrSnapshotNodesMissing is returned listing the
+// missing names. Routes are normalized and must satisfy the target cluster's
+// route policy, as with UpdateRoutes; lighthouse ports are checked as with
+// SetLighthouse, and the relay mesh as with SetRelay.
+//
+// Parameters:
+//   - clusterID: Target cluster UUID
+//   - t: Snapshot to apply (typically produced by ExportSnapshot)
+//
+// Returns:
+//   - Error if validation fails, nodes are missing, or update fails
+func (s *TopologyService) ImportSnapshot(clusterID string, t *TopologyInfo) error {
+	if t == nil {
+		return fmt.Errorf("%w: snapshot is required", models.ErrInvalidRequest)
+	}
+
+	// Validate before touching the database
+	for _, lh := range t.Lighthouses {
+		if net.ParseIP(lh.PublicIP) == nil {
+			return fmt.Errorf("%w: invalid IP address for %s", models.ErrInvalidRequest, lh.Name)
+		}
+	}
+	routesByName := make(map[string][]string, len(t.RoutesByName))
+	for name, routes := range t.RoutesByName {
+		normalized := make([]string, 0, len(routes))
+		for _, route := range routes {
+			prefix, err := normalizeRoute(route)
+			if err != nil {
+				return fmt.Errorf("%w: %s (node %s)", models.ErrInvalidCIDR, route, name)
+			}
+			normalized = append(normalized, prefix.String())
+		}
+		routesByName[name] = normalized
+	}
+
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Resolve node names in the target cluster
+	rows, err := tx.Query(`SELECT id, name FROM nodes WHERE cluster_id = ? AND deleted_at IS NULL`, clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to query nodes: %w", err)
+	}
+	ids := make(map[string]string)
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		ids[name] = id
+	}
+	rows.Close()
+
+	missing := make(map[string]struct{})
+	for _, lh := range t.Lighthouses {
+		if _, ok := ids[lh.Name]; !ok {
+			missing[lh.Name] = struct{}{}
+		}
+	}
+	for _, relay := range t.Relays {
+		if _, ok := ids[relay.Name]; !ok {
+			missing[relay.Name] = struct{}{}
+		}
+	}
+	for name := range t.RoutesByName {
+		if _, ok := ids[name]; !ok {
+			missing[name] = struct{}{}
+		}
+	}
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		s.logger.Warn("Topology snapshot references unknown nodes",
+			zap.String("cluster_id", clusterID),
+			zap.Strings("missing", names))
+		return fmt.Errorf("%w: %s", models.ErrSnapshotNodesMissing, strings.Join(names, ", "))
+	}
+
+	// Reset existing topology
+	now := s.clock.Now().Unix()
+	_, err = tx.Exec(`
+		UPDATE nodes
+		SET is_lighthouse = 0,
+		    lighthouse_public_ip = NULL,
+		    lighthouse_port = NULL,
+		    is_relay = 0,
+		    lighthouse_relay_updated_at = ?,
+		    routes = NULL,
+		    routes_updated_at = ?,
+		    updated_at = ?
+		WHERE cluster_id = ? AND deleted_at IS NULL
+	`, now, now, now, clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to reset topology: %w", err)
+	}
+
+	// Apply lighthouses
+	for _, lh := range t.Lighthouses {
+		if err := checkLighthousePort(tx, clusterID, lh.Port); err != nil {
+			return fmt.Errorf("lighthouse %s: %w", lh.Name, err)
+		}
+		_, err = tx.Exec(`
+			UPDATE nodes
+			SET is_lighthouse = 1,
+			    lighthouse_public_ip = ?,
+			    lighthouse_port = ?
+			WHERE id = ?
+		`, lh.PublicIP, lh.Port, ids[lh.Name])
+		if err != nil {
+			return fmt.Errorf("failed to set lighthouse: %w", err)
+		}
+	}
+
+	// Apply relays
+	for _, relay := range t.Relays {
+		_, err = tx.Exec(`UPDATE nodes SET is_relay = 1 WHERE id = ?`, ids[relay.Name])
+		if err != nil {
+			return fmt.Errorf("failed to set relay: %w", err)
+		}
+	}
+
+	// Reject a snapshot that makes every node a relay, as SetRelay does
+	var nodeCount, relayCount int
+	err = tx.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(is_relay), 0)
+		FROM nodes
+		WHERE cluster_id = ? AND deleted_at IS NULL
+	`, clusterID).Scan(&nodeCount, &relayCount)
+	if err != nil {
+		return fmt.Errorf("failed to count relays: %w", err)
+	}
+	if nodeCount > 1 && relayCount >= nodeCount {
+		s.logger.Warn("Topology snapshot rejected: every node would be a relay",
+			zap.String("cluster_id", clusterID),
+			zap.Int("node_count", nodeCount))
+		return models.ErrRelayMeshSaturated
+	}
+
+	// Apply routes
+	for name, routes := range routesByName {
+		if len(routes) == 0 {
+			continue
+		}
+		data, err := json.Marshal(routes)
+		if err != nil {
+			return fmt.Errorf("failed to marshal routes: %w", err)
+		}
+		_, err = tx.Exec(`
+			UPDATE nodes
+			SET routes = ?, routes_updated_at = ?, updated_at = ?
+			WHERE id = ?
+		`, string(data), now, now, ids[name])
+		if err != nil {
+			return fmt.Errorf("failed to update routes: %w", err)
+		}
+	}
+
+	// Check the imported routes against the cluster's route policy after
+	// writing them, as UpdateRoutes does, so the check sees the final state
+	var policy models.RoutePolicy
+	if err := tx.QueryRow(`
+		SELECT ipv4_only, allow_overlapping_routes FROM clusters WHERE id = ?
+	`, clusterID).Scan(&policy.IPv4Only, &policy.AllowOverlappingRoutes); err != nil {
+		return fmt.Errorf("failed to get route policy: %w", err)
+	}
+	if err := checkRoutePolicy(tx, s.logger, clusterID, "", policy); err != nil {
+		s.logger.Warn("Topology snapshot rejected by route policy",
+			zap.String("cluster_id", clusterID),
+			zap.Error(err))
+		return err
+	}
+
+	// Bump cluster config version
+	if _, _, err := bumpConfigVersion(context.Background(), tx, clusterID, s.change(models.ConfigChangeSnapshotImported, "")); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.logger.Info("Imported topology snapshot",
+		zap.String("cluster_id", clusterID),
+		zap.Int("lighthouse_count", len(t.Lighthouses)),
+		zap.Int("relay_count", len(t.Relays)),
+		zap.Int("route_node_count", len(t.RoutesByName)))
+
+	return nil
+}
+
+// nodeNamesByID returns a map of node ID to node name for a cluster.
+func (s *TopologyService) nodeNamesByID(clusterID string) (map[string]string, error) {
+	rows, err := s.db.Query(`SELECT id, name FROM nodes WHERE cluster_id = ? AND deleted_at IS NULL`, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nodes: %w", err)
+	}
+	defer rows.Close()
+
+	names := make(map[string]string)
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		names[id] = name
+	}
+
+	return names, rows.Err()
+}
+
 // RotateClusterToken generates a new cluster token and updates the hash.
 //
+// The replaced token keeps validating until the overlap window configured
+// with ConfigureTokenRotation ends, so nodes still holding it are not cut
+// off while the new token is rolled out; revokePrevious ends it immediately,
+// e.g. when the old token leaked. A previous token from an earlier rotation
+// is dropped. A cluster_token.rotated event is published with the new token's
+// fingerprint; the token itself is never sent to the event webhook.
+//
 // Parameters:
 //   - clusterID: Cluster UUID
+//   - revokePrevious: Stop accepting the replaced token right away
 //
 // Returns:
 //   - New plaintext token (only time it's visible)
+//   - Time the previous token stops validating
 //   - Error if generation or update fails
-func (s *TopologyService) RotateClusterToken(clusterID string) (string, error) {
+func (s *TopologyService) RotateClusterToken(clusterID string, revokePrevious bool) (string, time.Time, error) {
 	// Generate new token
 	newToken, err := token.Generate()
 	if err != nil {
-		return "", fmt.Errorf("failed to generate token: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to generate token: %w", err)
 	}
 
 	// Hash token
 	hash := token.Hash(newToken, s.secret)
 
-	// Update database
+	now := s.clock.Now()
+	expiresAt := now.Add(s.tokenOverlap)
+	if revokePrevious {
+		expiresAt = now
+	}
+
+	// Update database, keeping the old hash for the overlap window
 	result, err := s.db.Exec(`
 		UPDATE clusters
-		SET cluster_token_hash = ?
+		SET previous_cluster_token_hash = cluster_token_hash,
+		    previous_cluster_token_expires_at = ?,
+		    cluster_token_hash = ?
 		WHERE id = ?
-	`, hash, clusterID)
+	`, expiresAt.Unix(), hash, clusterID)
 	if err != nil {
-		return "", fmt.Errorf("failed to update token: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to update token: %w", err)
 	}
 
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return "", models.ErrClusterNotFound
+		return "", time.Time{}, models.ErrClusterNotFound
+	}
+
+	s.logger.Info("Rotated cluster token",
+		zap.String("cluster_id", clusterID),
+		zap.String("token_fingerprint", token.Fingerprint(newToken)),
+		zap.Time("previous_token_expires_at", expiresAt))
+
+	if s.events != nil {
+		var tenantID string
+		if err := s.db.QueryRow(`SELECT tenant_id FROM clusters WHERE id = ?`, clusterID).Scan(&tenantID); err != nil {
+			s.logger.Warn("Failed to look up tenant for token rotation event",
+				zap.String("cluster_id", clusterID), zap.Error(err))
+		}
+		s.events.Notify(events.Event{
+			Type:      events.TypeClusterTokenRotated,
+			TenantID:  tenantID,
+			ClusterID: clusterID,
+			Time:      now,
+			Data: map[string]any{
+				"token_fingerprint":         token.Fingerprint(newToken),
+				"previous_token_expires_at": expiresAt,
+			},
+		})
 	}
 
-	s.logger.Info("Rotated cluster token", zap.String("cluster_id", clusterID))
+	return newToken, expiresAt, nil
+}
+
+// normalizeRoute parses a route in CIDR notation and returns it with host
+// bits cleared. The prefix's address family classifies the route: IPv4-mapped
+// IPv6 prefixes (::ffff:a.b.c.d/n) count as IPv6.
+func normalizeRoute(route string) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(route)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix.Masked(), nil
+}
 
-	return newToken, nil
+// checkRouteOverlap returns models.ErrRouteOverlap, wrapped with the first
+// conflict found, if any of a node's routes overlaps (contains, is contained
+// in, or equals) a route advertised by another node in the cluster. Nodes are
+// checked in ID order so the reported conflict is deterministic.
+func checkRouteOverlap(nodeID string, prefixes []netip.Prefix, clusterRoutes map[string][]string) error {
+	otherIDs := make([]string, 0, len(clusterRoutes))
+	for otherID := range clusterRoutes {
+		if otherID != nodeID {
+			otherIDs = append(otherIDs, otherID)
+		}
+	}
+	sort.Strings(otherIDs)
+
+	for _, prefix := range prefixes {
+		for _, otherID := range otherIDs {
+			for _, route := range clusterRoutes[otherID] {
+				other, err := normalizeRoute(route)
+				if err != nil {
+					continue
+				}
+				if prefix.Overlaps(other) {
+					return fmt.Errorf("%w: %s overlaps %s advertised by node %s",
+						models.ErrRouteOverlap, prefix, route, otherID)
+				}
+			}
+		}
+	}
+	return nil
 }
 
-// validateCIDR checks if a string is valid CIDR notation.
-func validateCIDR(cidr string) error {
-	_, _, err := net.ParseCIDR(cidr)
-	return err
+// checkRoutePolicy returns the first route advertised in a cluster that
+// violates policy: models.ErrIPv6NotAllowed for an IPv6 route in an IPv4-only
+// cluster, or models.ErrRouteOverlap for a route overlapping another node's
+// when overlaps are not allowed. If nodeID is set, only that node's routes
+// are checked. Nodes are checked in ID order so the reported violation is
+// deterministic.
+func checkRoutePolicy(q rowsQuerier, logger *zap.Logger, clusterID, nodeID string, policy models.RoutePolicy) error {
+	if !policy.IPv4Only && policy.AllowOverlappingRoutes {
+		return nil
+	}
+
+	routesByNode, err := loadClusterRoutes(q, logger, clusterID)
+	if err != nil {
+		return err
+	}
+
+	nodeIDs := []string{nodeID}
+	if nodeID == "" {
+		nodeIDs = make([]string, 0, len(routesByNode))
+		for id := range routesByNode {
+			nodeIDs = append(nodeIDs, id)
+		}
+		sort.Strings(nodeIDs)
+	}
+
+	for _, id := range nodeIDs {
+		prefixes := make([]netip.Prefix, 0, len(routesByNode[id]))
+		for _, route := range routesByNode[id] {
+			prefix, err := normalizeRoute(route)
+			if err != nil {
+				continue
+			}
+			if policy.IPv4Only && !prefix.Addr().Is4() {
+				return fmt.Errorf("%w: %s", models.ErrIPv6NotAllowed, route)
+			}
+			prefixes = append(prefixes, prefix)
+		}
+		if !policy.AllowOverlappingRoutes {
+			if err := checkRouteOverlap(id, prefixes, routesByNode); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+
+	"nebulagc.io/server/internal/metrics"
+)
+
+// defaultTopologyCacheSize is the number of clusters whose topology is cached.
+const defaultTopologyCacheSize = 256
+
+// topologyCacheEntry is a cached topology for one cluster at one config version.
+type topologyCacheEntry struct {
+	clusterID string
+	version   int64
+	topology  *TopologyInfo
+}
+
+// topologyCache is a bounded LRU of cluster topologies keyed by config version.
+//
+// Every mutation of a cluster's topology bumps its config version, so an entry
+// is only served while the cluster is still at the version it was built for.
+// A version mismatch drops the entry; no explicit invalidation is needed from
+// mutation paths, including those in other services (e.g., NodeService).
+type topologyCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List               // front = most recently used
+	entries  map[string]*list.Element // cluster ID -> element holding *topologyCacheEntry
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// newTopologyCache creates a topology cache holding at most capacity clusters.
+func newTopologyCache(capacity int) *topologyCache {
+	if capacity <= 0 {
+		capacity = defaultTopologyCacheSize
+	}
+	return &topologyCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// get returns a copy of the cached topology if it was built at version.
+func (c *topologyCache) get(clusterID string, version int64) (*TopologyInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[clusterID]
+	if ok {
+		entry := elem.Value.(*topologyCacheEntry)
+		if entry.version == version {
+			c.order.MoveToFront(elem)
+			c.hits.Add(1)
+			metrics.TopologyCacheRequests.WithLabelValues("hit").Inc()
+			return entry.topology.clone(), true
+		}
+		// Stale: the cluster has moved on to a newer version
+		c.order.Remove(elem)
+		delete(c.entries, clusterID)
+	}
+
+	c.misses.Add(1)
+	metrics.TopologyCacheRequests.WithLabelValues("miss").Inc()
+	return nil, false
+}
+
+// put stores a copy of topology for the cluster at version, evicting the
+// least recently used cluster when the cache is full. An entry for a newer
+// version is never replaced by an older one.
+func (c *topologyCache) put(clusterID string, version int64, topology *TopologyInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[clusterID]; ok {
+		entry := elem.Value.(*topologyCacheEntry)
+		if entry.version > version {
+			return
+		}
+		entry.version = version
+		entry.topology = topology.clone()
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[clusterID] = c.order.PushFront(&topologyCacheEntry{
+		clusterID: clusterID,
+		version:   version,
+		topology:  topology.clone(),
+	})
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*topologyCacheEntry).clusterID)
+	}
+}
+
+// len returns the number of cached clusters.
+func (c *topologyCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// clone returns a deep copy so callers can modify the result without
+// affecting the cached value.
+func (t *TopologyInfo) clone() *TopologyInfo {
+	out := *t
+	out.Lighthouses = make([]LighthouseInfo, len(t.Lighthouses))
+	copy(out.Lighthouses, t.Lighthouses)
+	out.Relays = make([]RelayInfo, len(t.Relays))
+	copy(out.Relays, t.Relays)
+	out.PreferredRanges = make([]string, len(t.PreferredRanges))
+	copy(out.PreferredRanges, t.PreferredRanges)
+	out.Routes = cloneStringSliceMap(t.Routes)
+	out.RoutesByName = cloneStringSliceMap(t.RoutesByName)
+	out.StaticHostMap = cloneStringSliceMap(t.StaticHostMap)
+	if t.Handshake != nil {
+		handshake := *t.Handshake
+		out.Handshake = &handshake
+	}
+	return &out
+}
+
+// cloneStringSliceMap deep-copies a map of string slices, preserving nil.
+func cloneStringSliceMap(m map[string][]string) map[string][]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string][]string, len(m))
+	for k, v := range m {
+		out[k] = append([]string(nil), v...)
+	}
+	return out
+}
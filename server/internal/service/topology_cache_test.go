@@ -0,0 +1,145 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestTopologyService_GetTopologyCache(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	logger := zap.NewNop()
+	service := NewTopologyService(db, logger, "secret")
+
+	first, err := service.GetTopology("cluster1")
+	if err != nil {
+		t.Fatalf("GetTopology failed: %v", err)
+	}
+	if _, err := service.GetTopology("cluster1"); err != nil {
+		t.Fatalf("GetTopology failed: %v", err)
+	}
+	if hits := service.cache.hits.Load(); hits != 1 {
+		t.Errorf("Expected 1 cache hit, got %d", hits)
+	}
+
+	// Callers may modify results without corrupting the cache
+	first.Relays = append(first.Relays, RelayInfo{NodeID: "bogus"})
+	first.Routes["bogus"] = []string{"10.0.0.0/8"}
+	cached, _ := service.GetTopology("cluster1")
+	if len(cached.Relays) != 0 || len(cached.Routes) != 0 {
+		t.Errorf("Cached topology was modified through a returned copy: %+v", cached)
+	}
+
+	// Any mutation bumps the version and invalidates the entry
+	if _, err := service.SetRelay("cluster1", "node1"); err != nil {
+		t.Fatalf("SetRelay failed: %v", err)
+	}
+	updated, _ := service.GetTopology("cluster1")
+	if len(updated.Relays) != 1 {
+		t.Errorf("Expected 1 relay after SetRelay, got %d", len(updated.Relays))
+	}
+
+	// Version bumps from outside TopologyService invalidate too
+	db.Exec(`UPDATE nodes SET is_relay = 0 WHERE id = 'node1'`)
+	db.Exec(`UPDATE clusters SET config_version = config_version + 1 WHERE id = 'cluster1'`)
+	updated, _ = service.GetTopology("cluster1")
+	if len(updated.Relays) != 0 {
+		t.Errorf("Expected 0 relays after external bump, got %d", len(updated.Relays))
+	}
+
+	// Unknown clusters are not cached
+	service.GetTopology("missing")
+	if service.cache.len() != 1 {
+		t.Errorf("Expected 1 cached cluster, got %d", service.cache.len())
+	}
+}
+
+func TestTopologyCache_LRUEviction(t *testing.T) {
+	cache := newTopologyCache(2)
+	topology := &TopologyInfo{Routes: map[string][]string{}}
+
+	cache.put("a", 1, topology)
+	cache.put("b", 1, topology)
+	cache.get("a", 1) // a is now most recently used
+	cache.put("c", 1, topology)
+
+	if cache.len() != 2 {
+		t.Fatalf("Expected 2 entries, got %d", cache.len())
+	}
+	if _, ok := cache.get("b", 1); ok {
+		t.Error("Expected least recently used entry b to be evicted")
+	}
+	if _, ok := cache.get("a", 1); !ok {
+		t.Error("Expected entry a to survive eviction")
+	}
+
+	// Stale versions are dropped and older versions never overwrite newer ones
+	cache.put("a", 3, topology)
+	cache.put("a", 2, topology)
+	if _, ok := cache.get("a", 3); !ok {
+		t.Error("Expected version 3 to remain cached")
+	}
+	if _, ok := cache.get("a", 4); ok {
+		t.Error("Expected miss for newer version")
+	}
+	if _, ok := cache.get("a", 3); ok {
+		t.Error("Expected stale entry to be dropped after version mismatch")
+	}
+}
+
+func TestTopologyService_GetTopologyConcurrent(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+	// In-memory SQLite databases are per connection
+	db.SetMaxOpenConns(1)
+
+	logger := zap.NewNop()
+	service := NewTopologyService(db, logger, "secret")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				topology, err := service.GetTopology("cluster1")
+				if err != nil {
+					t.Errorf("GetTopology failed: %v", err)
+					return
+				}
+				topology.Routes["scratch"] = nil // must not race with other readers
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 20; j++ {
+			route := fmt.Sprintf("10.%d.0.0/16", j)
+			if err := service.UpdateRoutes("node2", []string{route}); err != nil {
+				t.Errorf("UpdateRoutes failed: %v", err)
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	cached, err := service.GetTopology("cluster1")
+	if err != nil {
+		t.Fatalf("GetTopology failed: %v", err)
+	}
+	fresh, err := service.loadTopology("cluster1")
+	if err != nil {
+		t.Fatalf("loadTopology failed: %v", err)
+	}
+	if !reflect.DeepEqual(cached, fresh) {
+		t.Errorf("Cached topology diverged from database:\ncached: %+v\nfresh:  %+v", cached, fresh)
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TopologyDOT renders the cluster topology as a Graphviz DOT graph, suitable
+// for `dot -Tpng`. Every node of the cluster is drawn; lighthouses and relays
+// are highlighted, dashed edges point at the lighthouses each node queries,
+// dotted edges at the relays it may use, and advertised routes hang off the
+// node advertising them.
+//
+// Parameters:
+//   - clusterID: Cluster UUID
+//
+// Returns:
+//   - DOT source
+//   - Error if the cluster is not found or a query fails
+func (s *TopologyService) TopologyDOT(clusterID string) (string, error) {
+	// Fails with ErrClusterNotFound for unknown clusters, unlike GetTopology
+	if _, err := s.loadGeneratorCluster(clusterID); err != nil {
+		return "", err
+	}
+
+	nodes, err := s.loadGeneratorNodes(clusterID)
+	if err != nil {
+		return "", err
+	}
+
+	topology, err := s.GetTopology(clusterID)
+	if err != nil {
+		return "", err
+	}
+
+	names := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		names[node.ID] = node.Name
+	}
+
+	return renderTopologyDOT(clusterID, names, topology), nil
+}
+
+// renderTopologyDOT renders a topology as DOT. names maps the ID of every node
+// in the cluster to its name. Output is sorted so it is stable across calls.
+func renderTopologyDOT(clusterID string, names map[string]string, topology *TopologyInfo) string {
+	lighthouses := make(map[string]LighthouseInfo, len(topology.Lighthouses))
+	for _, lh := range topology.Lighthouses {
+		lighthouses[lh.NodeID] = lh
+	}
+	relays := make(map[string]bool, len(topology.Relays))
+	for _, r := range topology.Relays {
+		relays[r.NodeID] = true
+	}
+
+	// Nodes by name, then ID
+	ids := make([]string, 0, len(names))
+	for id := range names {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if names[ids[i]] != names[ids[j]] {
+			return names[ids[i]] < names[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	lighthouseIDs := filterIDs(ids, func(id string) bool { _, ok := lighthouses[id]; return ok })
+	relayIDs := filterIDs(ids, func(id string) bool { return relays[id] })
+	hops := topology.RelayStats.MaxRelayHops
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote("nebula-"+clusterID))
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=box];\n")
+
+	b.WriteString("\n\t// Nodes\n")
+	for _, id := range ids {
+		label := names[id]
+		attrs := ""
+		if lh, ok := lighthouses[id]; ok {
+			if lh.PublicIP != "" && lh.Port != 0 {
+				label += fmt.Sprintf("\n%s:%d", lh.PublicIP, lh.Port)
+			} else if lh.PublicIP != "" {
+				label += "\n" + lh.PublicIP
+			}
+			attrs = ", shape=doubleoctagon"
+			if relays[id] {
+				attrs += ", style=filled, fillcolor=lightgrey"
+			}
+		} else if relays[id] {
+			attrs = ", shape=hexagon"
+		}
+		fmt.Fprintf(&b, "\t%s [label=%s%s];\n", dotQuote(id), dotQuote(label), attrs)
+	}
+
+	b.WriteString("\n\t// Lighthouse links\n")
+	for _, id := range ids {
+		if _, ok := lighthouses[id]; ok {
+			continue
+		}
+		for _, lh := range lighthouseIDs {
+			fmt.Fprintf(&b, "\t%s -> %s [style=dashed, label=\"lighthouse\"];\n", dotQuote(id), dotQuote(lh))
+		}
+	}
+
+	// Mirrors the generator: no relaying at 0 hops, and relays only use
+	// other relays when more than one hop is allowed
+	b.WriteString("\n\t// Relay links\n")
+	if hops > 0 {
+		for _, id := range ids {
+			if relays[id] && hops < 2 {
+				continue
+			}
+			for _, relay := range relayIDs {
+				if relay != id {
+					fmt.Fprintf(&b, "\t%s -> %s [style=dotted, label=\"relay\"];\n", dotQuote(id), dotQuote(relay))
+				}
+			}
+		}
+	}
+
+	b.WriteString("\n\t// Routes\n")
+	for _, id := range ids {
+		routes := append([]string(nil), topology.Routes[id]...)
+		sort.Strings(routes)
+		for _, route := range routes {
+			routeID := dotQuote("route:" + route)
+			fmt.Fprintf(&b, "\t%s [label=%s, shape=note];\n", routeID, dotQuote(route))
+			fmt.Fprintf(&b, "\t%s -> %s [label=\"route\"];\n", dotQuote(id), routeID)
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// filterIDs returns the IDs for which keep returns true, in order.
+func filterIDs(ids []string, keep func(string) bool) []string {
+	var kept []string
+	for _, id := range ids {
+		if keep(id) {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
+
+// dotQuote returns s as a quoted DOT ID, escaping quotes and backslashes and
+// turning newlines into DOT line breaks.
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+	"nebulagc.io/server/internal/testutil"
+)
+
+// update rewrites golden files instead of comparing against them.
+// Run with: go test ./internal/service -run DOT -update
+var update = flag.Bool("update", false, "update golden files")
+
+func TestRenderTopologyDOT(t *testing.T) {
+	names := map[string]string{
+		"node-lh":     "lighthouse",
+		"node-relay":  "relay",
+		"node-router": "router",
+		"node-web":    `web "1"`,
+	}
+	topology := &TopologyInfo{
+		Lighthouses: []LighthouseInfo{{NodeID: "node-lh", Name: "lighthouse", PublicIP: "203.0.113.1", Port: 4242}},
+		Relays:      []RelayInfo{{NodeID: "node-relay", Name: "relay"}},
+		Routes: map[string][]string{
+			"node-router": {"192.168.20.0/24", "192.168.10.0/24"},
+		},
+		RelayStats: RelayStats{MaxRelayHops: 1},
+	}
+
+	got := renderTopologyDOT("cluster-1", names, topology)
+
+	golden := filepath.Join("testdata", "topology.dot")
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+
+	if got != string(want) {
+		t.Errorf("DOT output does not match %s\n--- got ---\n%s\n--- want ---\n%s", golden, got, want)
+	}
+}
+
+func TestTopologyService_TopologyDOT(t *testing.T) {
+	db := testutil.OpenDB(t)
+	tenantID := testutil.Tenant(t, db, "tenant")
+	clusterID, _ := testutil.Cluster(t, db, tenantID, "cluster")
+	lighthouse, _ := testutil.LighthouseNode(t, db, tenantID, clusterID, "lighthouse", "203.0.113.1", 4242)
+	worker, _ := testutil.Node(t, db, tenantID, clusterID, "worker", false)
+
+	service := NewTopologyService(db, zap.NewNop(), testutil.TestHMACSecret)
+	dot, err := service.TopologyDOT(clusterID)
+	if err != nil {
+		t.Fatalf("TopologyDOT failed: %v", err)
+	}
+	link := `"` + worker + `" -> "` + lighthouse + `" [style=dashed, label="lighthouse"];`
+	if !strings.Contains(dot, link) {
+		t.Errorf("Expected lighthouse link %s in:\n%s", link, dot)
+	}
+
+	if _, err := service.TopologyDOT("missing"); !errors.Is(err, models.ErrClusterNotFound) {
+		t.Errorf("Expected ErrClusterNotFound for missing cluster, got %v", err)
+	}
+}
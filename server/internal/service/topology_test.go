@@ -2,74 +2,36 @@ package service
 
 import (
 	"database/sql"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
-	_ "modernc.org/sqlite"
 	"go.uber.org/zap"
+	"nebulagc.io/models"
+	"nebulagc.io/pkg/clock"
+	"nebulagc.io/pkg/token"
+	"nebulagc.io/server/internal/config"
+	"nebulagc.io/server/internal/events"
+	"nebulagc.io/server/internal/testutil"
 )
 
-// setupTopologyTestDB creates an in-memory database for topology testing.
+// setupTopologyTestDB creates a migration-backed database with tenant1,
+// cluster1 at config version 1 and its regular nodes node1 to node3, named
+// node-1 to node-3. Tests refer to the fixed IDs, so the rows are inserted
+// directly instead of through the testutil fixtures, which generate IDs.
 func setupTopologyTestDB(t *testing.T) *sql.DB {
-	db, err := sql.Open("sqlite", ":memory:?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)")
-	if err != nil {
-		t.Fatalf("Failed to open database: %v", err)
-	}
-
-	// Create schema
-	schema := `
-	CREATE TABLE tenants (
-		id TEXT PRIMARY KEY,
-		name TEXT NOT NULL,
-		created_at INTEGER NOT NULL
-	);
-
-	CREATE TABLE clusters (
-		id TEXT PRIMARY KEY,
-		tenant_id TEXT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
-		name TEXT NOT NULL,
-		config_version INTEGER NOT NULL DEFAULT 1,
-		cluster_token_hash TEXT NOT NULL,
-		created_at INTEGER NOT NULL
-	);
-
-	CREATE TABLE nodes (
-		id TEXT PRIMARY KEY,
-		tenant_id TEXT NOT NULL,
-		cluster_id TEXT NOT NULL,
-		name TEXT NOT NULL,
-		is_admin INTEGER NOT NULL DEFAULT 0,
-		token_hash TEXT NOT NULL,
-		mtu INTEGER NOT NULL DEFAULT 1300,
-		routes TEXT,
-		routes_updated_at INTEGER,
-		is_lighthouse INTEGER NOT NULL DEFAULT 0,
-		lighthouse_public_ip TEXT,
-		lighthouse_port INTEGER,
-		is_relay INTEGER NOT NULL DEFAULT 0,
-		lighthouse_relay_updated_at INTEGER,
-		created_at INTEGER NOT NULL,
-		FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
-		FOREIGN KEY (cluster_id) REFERENCES clusters(id) ON DELETE CASCADE
-	);
-	`
-
-	if _, err := db.Exec(schema); err != nil {
-		t.Fatalf("Failed to create schema: %v", err)
-	}
-
-	// Insert test data
-	_, err = db.Exec(`
-		INSERT INTO tenants (id, name, created_at) VALUES ('tenant1', 'Test Tenant', 1000000000);
-		INSERT INTO clusters (id, tenant_id, name, config_version, cluster_token_hash, created_at)
-		VALUES ('cluster1', 'tenant1', 'Test Cluster', 1, 'hash', 1000000000);
-		INSERT INTO nodes (id, tenant_id, cluster_id, name, token_hash, created_at)
+	db := setupBundleTestDB(t)
+
+	_, err := db.Exec(`
+		INSERT INTO nodes (id, tenant_id, cluster_id, name, token_hash)
 		VALUES
-			('node1', 'tenant1', 'cluster1', 'node-1', 'hash1', 1000000000),
-			('node2', 'tenant1', 'cluster1', 'node-2', 'hash2', 1000000000),
-			('node3', 'tenant1', 'cluster1', 'node-3', 'hash3', 1000000000);
+			('node1', 'tenant1', 'cluster1', 'node-1', 'hash1'),
+			('node2', 'tenant1', 'cluster1', 'node-2', 'hash2'),
+			('node3', 'tenant1', 'cluster1', 'node-3', 'hash3');
 	`)
 	if err != nil {
-		t.Fatalf("Failed to insert test data: %v", err)
+		t.Fatalf("Failed to insert test nodes: %v", err)
 	}
 
 	return db
@@ -127,6 +89,227 @@ func TestTopologyService_UpdateRoutesInvalidCIDR(t *testing.T) {
 	}
 }
 
+func TestTopologyService_UpdateRoutesNormalizesMixedFamilies(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	service := NewTopologyService(db, zap.NewNop(), "secret")
+
+	routes := []string{"10.0.1.7/24", "FD00:0:0:1::5/64", "192.168.0.0/16", "::ffff:172.16.0.0/108"}
+	if err := service.UpdateRoutes("node1", routes); err != nil {
+		t.Fatalf("UpdateRoutes failed: %v", err)
+	}
+
+	stored, err := service.GetNodeRoutes("node1")
+	if err != nil {
+		t.Fatalf("GetNodeRoutes failed: %v", err)
+	}
+	want := []string{"10.0.1.0/24", "fd00:0:0:1::/64", "192.168.0.0/16", "::ffff:172.16.0.0/108"}
+	if strings.Join(stored, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected normalized routes %v, got %v", want, stored)
+	}
+}
+
+func TestTopologyService_UpdateRoutesIPv4Only(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	service := NewTopologyService(db, zap.NewNop(), "secret")
+
+	if err := service.UpdateRoutes("node1", []string{"10.0.1.0/24", "fd00::/64"}); err != nil {
+		t.Fatalf("UpdateRoutes failed: %v", err)
+	}
+
+	// Existing IPv6 routes block switching to IPv4-only
+	err := service.SetRoutePolicy("cluster1", &models.RoutePolicy{IPv4Only: true})
+	if !errors.Is(err, models.ErrIPv6NotAllowed) || !strings.Contains(err.Error(), "fd00::/64") {
+		t.Fatalf("Expected ErrIPv6NotAllowed naming fd00::/64, got %v", err)
+	}
+
+	if err := service.UpdateRoutes("node1", []string{"10.0.1.0/24"}); err != nil {
+		t.Fatalf("UpdateRoutes failed: %v", err)
+	}
+	if err := service.SetRoutePolicy("cluster1", &models.RoutePolicy{IPv4Only: true}); err != nil {
+		t.Fatalf("SetRoutePolicy failed: %v", err)
+	}
+
+	var version int64
+	if err := db.QueryRow(`SELECT config_version FROM clusters WHERE id = 'cluster1'`).Scan(&version); err != nil {
+		t.Fatalf("Failed to get config version: %v", err)
+	}
+
+	// A mixed list is rejected as a whole, naming the first IPv6 route
+	err = service.UpdateRoutes("node2", []string{"10.0.2.0/24", "2001:db8::/32", "fd00::/64"})
+	if !errors.Is(err, models.ErrIPv6NotAllowed) || !strings.Contains(err.Error(), "2001:db8::/32") {
+		t.Fatalf("Expected ErrIPv6NotAllowed naming 2001:db8::/32, got %v", err)
+	}
+	if stored, _ := service.GetNodeRoutes("node2"); len(stored) != 0 {
+		t.Errorf("Expected rejected update to store nothing, got %v", stored)
+	}
+
+	// IPv4-mapped IPv6 prefixes are IPv6 routes
+	if err := service.UpdateRoutes("node2", []string{"::ffff:10.0.0.0/104"}); !errors.Is(err, models.ErrIPv6NotAllowed) {
+		t.Errorf("Expected ErrIPv6NotAllowed for IPv4-mapped prefix, got %v", err)
+	}
+
+	var after int64
+	if err := db.QueryRow(`SELECT config_version FROM clusters WHERE id = 'cluster1'`).Scan(&after); err != nil {
+		t.Fatalf("Failed to get config version: %v", err)
+	}
+	if after != version {
+		t.Errorf("Expected rejected updates not to bump the config version, got %d -> %d", version, after)
+	}
+
+	if err := service.UpdateRoutes("node2", []string{"10.0.2.0/24"}); err != nil {
+		t.Errorf("Expected IPv4 routes to be accepted, got %v", err)
+	}
+
+	// Lifting the restriction allows IPv6 again
+	if err := service.SetRoutePolicy("cluster1", &models.RoutePolicy{}); err != nil {
+		t.Fatalf("SetRoutePolicy failed: %v", err)
+	}
+	if err := service.UpdateRoutes("node2", []string{"10.0.2.0/24", "2001:db8::/32"}); err != nil {
+		t.Errorf("Expected mixed routes to be accepted, got %v", err)
+	}
+
+	if err := service.SetRoutePolicy("no-such-cluster", &models.RoutePolicy{}); !errors.Is(err, models.ErrClusterNotFound) {
+		t.Errorf("Expected ErrClusterNotFound, got %v", err)
+	}
+}
+
+func TestTopologyService_UpdateRoutesOverlap(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		update   []string
+		overlap  string // expected conflicting route, empty if accepted
+	}{
+		{name: "subnet of existing supernet", existing: []string{"10.0.0.0/8"}, update: []string{"10.1.0.0/16"}, overlap: "10.0.0.0/8"},
+		{name: "supernet of existing subnet", existing: []string{"10.1.0.0/16"}, update: []string{"10.0.0.0/8"}, overlap: "10.1.0.0/16"},
+		{name: "identical route", existing: []string{"192.168.1.0/24"}, update: []string{"192.168.1.0/24"}, overlap: "192.168.1.0/24"},
+		{name: "host bits normalized before comparing", existing: []string{"10.1.0.0/16"}, update: []string{"10.1.2.3/24"}, overlap: "10.1.0.0/16"},
+		{name: "IPv6 subnet", existing: []string{"fd00::/48"}, update: []string{"fd00:0:0:1::/64"}, overlap: "fd00::/48"},
+		{name: "second route in list overlaps", existing: []string{"172.16.0.0/12"}, update: []string{"10.0.0.0/8", "172.20.0.0/16"}, overlap: "172.16.0.0/12"},
+		{name: "adjacent routes", existing: []string{"10.0.0.0/24"}, update: []string{"10.0.1.0/24"}},
+		{name: "different families", existing: []string{"10.0.0.0/8"}, update: []string{"fd00::/8"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTopologyTestDB(t)
+			defer db.Close()
+
+			service := NewTopologyService(db, zap.NewNop(), "secret")
+
+			if err := service.UpdateRoutes("node1", tt.existing); err != nil {
+				t.Fatalf("UpdateRoutes(node1) failed: %v", err)
+			}
+
+			err := service.UpdateRoutes("node2", tt.update)
+			if tt.overlap == "" {
+				if err != nil {
+					t.Fatalf("Expected routes to be accepted, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, models.ErrRouteOverlap) {
+				t.Fatalf("Expected ErrRouteOverlap, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.overlap+" advertised by node node1") {
+				t.Errorf("Expected error to name %s on node1, got %v", tt.overlap, err)
+			}
+			if stored, _ := service.GetNodeRoutes("node2"); len(stored) != 0 {
+				t.Errorf("Expected rejected update to store nothing, got %v", stored)
+			}
+		})
+	}
+}
+
+func TestTopologyService_UpdateRoutesOverlapOptOut(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	service := NewTopologyService(db, zap.NewNop(), "secret")
+
+	if err := service.UpdateRoutes("node1", []string{"10.0.0.0/8"}); err != nil {
+		t.Fatalf("UpdateRoutes failed: %v", err)
+	}
+
+	// A node may replace its own routes with overlapping ones
+	if err := service.UpdateRoutes("node1", []string{"10.0.0.0/16", "10.0.0.0/8"}); err != nil {
+		t.Fatalf("Expected a node's own routes not to conflict, got %v", err)
+	}
+
+	if err := service.UpdateRoutes("node2", []string{"10.1.0.0/16"}); !errors.Is(err, models.ErrRouteOverlap) {
+		t.Fatalf("Expected ErrRouteOverlap, got %v", err)
+	}
+
+	if err := service.SetRoutePolicy("cluster1", &models.RoutePolicy{AllowOverlappingRoutes: true}); err != nil {
+		t.Fatalf("SetRoutePolicy failed: %v", err)
+	}
+	if err := service.UpdateRoutes("node2", []string{"10.1.0.0/16"}); err != nil {
+		t.Fatalf("Expected overlap to be allowed after opting out, got %v", err)
+	}
+
+	// Existing overlaps block re-enabling the check
+	err := service.SetRoutePolicy("cluster1", &models.RoutePolicy{})
+	if !errors.Is(err, models.ErrRouteOverlap) || !strings.Contains(err.Error(), "advertised by node node2") {
+		t.Fatalf("Expected ErrRouteOverlap naming node2, got %v", err)
+	}
+	var allowed bool
+	if err := db.QueryRow(`SELECT allow_overlapping_routes FROM clusters WHERE id = 'cluster1'`).Scan(&allowed); err != nil {
+		t.Fatalf("Failed to get route policy: %v", err)
+	}
+	if !allowed {
+		t.Error("Expected a refused policy change to keep the old policy")
+	}
+
+	// Once the overlap is withdrawn the check can be re-enabled and rejects new overlaps
+	if err := service.UpdateRoutes("node2", []string{"192.168.0.0/24"}); err != nil {
+		t.Fatalf("UpdateRoutes failed: %v", err)
+	}
+	if err := service.SetRoutePolicy("cluster1", &models.RoutePolicy{}); err != nil {
+		t.Fatalf("SetRoutePolicy failed: %v", err)
+	}
+	if err := service.UpdateRoutes("node3", []string{"10.2.0.0/16"}); !errors.Is(err, models.ErrRouteOverlap) {
+		t.Errorf("Expected ErrRouteOverlap, got %v", err)
+	}
+}
+
+func TestTopologyService_UpdateRoutesConcurrentOverlap(t *testing.T) {
+	db := testutil.OpenDB(t)
+	tenantID := testutil.Tenant(t, db, "Test Tenant")
+	clusterID, _ := testutil.Cluster(t, db, tenantID, "Test Cluster")
+	node1, _ := testutil.Node(t, db, tenantID, clusterID, "node-1", false)
+	node2, _ := testutil.Node(t, db, tenantID, clusterID, "node-2", false)
+
+	service := NewTopologyService(db, zap.NewNop(), testutil.TestHMACSecret)
+
+	// Another writer has claimed the route but not committed yet
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`UPDATE nodes SET routes = '["10.0.0.0/24"]' WHERE id = ?`, node1); err != nil {
+		t.Fatalf("Failed to claim route: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- service.UpdateRoutes(node2, []string{"10.0.0.0/24"})
+	}()
+
+	// The update must wait for the claim and then see it
+	time.Sleep(100 * time.Millisecond)
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit claim: %v", err)
+	}
+	if err := <-done; !errors.Is(err, models.ErrRouteOverlap) {
+		t.Errorf("Expected ErrRouteOverlap for a route claimed concurrently, got %v", err)
+	}
+}
+
 func TestTopologyService_UpdateRoutesClearAll(t *testing.T) {
 	db := setupTopologyTestDB(t)
 	defer db.Close()
@@ -264,16 +447,19 @@ func TestTopologyService_SetRelay(t *testing.T) {
 
 	logger := zap.NewNop()
 	service := NewTopologyService(db, logger, "secret")
+	now := time.Unix(1700000000, 0)
+	service.clock = clock.NewFake(now)
 
 	// Set relay status
-	err := service.SetRelay("cluster1", "node1")
+	_, err := service.SetRelay("cluster1", "node1")
 	if err != nil {
 		t.Fatalf("SetRelay failed: %v", err)
 	}
 
-	// Verify relay status
+	// Verify relay status and modification time
 	var isRelay int
-	err = db.QueryRow(`SELECT is_relay FROM nodes WHERE id = 'node1'`).Scan(&isRelay)
+	var updatedAt int64
+	err = db.QueryRow(`SELECT is_relay, updated_at FROM nodes WHERE id = 'node1'`).Scan(&isRelay, &updatedAt)
 	if err != nil {
 		t.Fatalf("Failed to query relay status: %v", err)
 	}
@@ -281,6 +467,9 @@ func TestTopologyService_SetRelay(t *testing.T) {
 	if isRelay != 1 {
 		t.Error("Expected is_relay = 1")
 	}
+	if updatedAt != now.Unix() {
+		t.Errorf("Expected updated_at %d, got %d", now.Unix(), updatedAt)
+	}
 
 	// Verify config version was bumped
 	var version int64
@@ -368,7 +557,7 @@ func TestTopologyService_RotateClusterToken(t *testing.T) {
 	service := NewTopologyService(db, logger, "secret")
 
 	// Rotate token
-	newToken, err := service.RotateClusterToken("cluster1")
+	newToken, _, err := service.RotateClusterToken("cluster1", false)
 	if err != nil {
 		t.Fatalf("RotateClusterToken failed: %v", err)
 	}
@@ -389,6 +578,92 @@ func TestTopologyService_RotateClusterToken(t *testing.T) {
 	}
 }
 
+// recordingNotifier collects published events.
+type recordingNotifier struct {
+	events []events.Event
+}
+
+func (n *recordingNotifier) Notify(event events.Event) {
+	n.events = append(n.events, event)
+}
+
+func TestTopologyService_RotateClusterTokenOverlap(t *testing.T) {
+	db := testutil.OpenDB(t)
+	tenantID := testutil.Tenant(t, db, "Test Tenant")
+	clusterID, oldToken := testutil.Cluster(t, db, tenantID, "Test Cluster")
+
+	now := time.Unix(1700000000, 0)
+	notifier := &recordingNotifier{}
+	service := NewTopologyService(db, zap.NewNop(), testutil.TestHMACSecret)
+	service.ConfigureTokenRotation(time.Hour, notifier)
+	service.clock = clock.NewFake(now)
+
+	newToken, expiresAt, err := service.RotateClusterToken(clusterID, false)
+	if err != nil {
+		t.Fatalf("RotateClusterToken failed: %v", err)
+	}
+	if !expiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected previous token to expire at %v, got %v", now.Add(time.Hour), expiresAt)
+	}
+
+	var current, previous string
+	var previousExpiresAt int64
+	err = db.QueryRow(`
+		SELECT cluster_token_hash, previous_cluster_token_hash, previous_cluster_token_expires_at
+		FROM clusters WHERE id = ?
+	`, clusterID).Scan(&current, &previous, &previousExpiresAt)
+	if err != nil {
+		t.Fatalf("Failed to query token hashes: %v", err)
+	}
+	if !token.ValidateWithPrevious(oldToken, testutil.TestHMACSecret, current, previous) {
+		t.Error("Expected old token to validate as the previous token")
+	}
+	if !token.Validate(newToken, testutil.TestHMACSecret, current) {
+		t.Error("Expected new token to validate as the current token")
+	}
+	if previousExpiresAt != expiresAt.Unix() {
+		t.Errorf("Expected stored expiry %d, got %d", expiresAt.Unix(), previousExpiresAt)
+	}
+
+	if len(notifier.events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(notifier.events))
+	}
+	event := notifier.events[0]
+	if event.Type != events.TypeClusterTokenRotated || event.TenantID != tenantID || event.ClusterID != clusterID {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	if _, ok := event.Data["token"]; ok {
+		t.Error("Expected event not to carry the plaintext token")
+	}
+	if event.Data["token_fingerprint"] != token.Fingerprint(newToken) {
+		t.Error("Expected event to carry the new token's fingerprint")
+	}
+
+	// A second rotation retires the first token entirely
+	if _, _, err := service.RotateClusterToken(clusterID, false); err != nil {
+		t.Fatalf("RotateClusterToken failed: %v", err)
+	}
+	db.QueryRow(`SELECT cluster_token_hash, previous_cluster_token_hash FROM clusters WHERE id = ?`, clusterID).Scan(&current, &previous)
+	if token.ValidateWithPrevious(oldToken, testutil.TestHMACSecret, current, previous) {
+		t.Error("Expected the token from two rotations ago to be rejected")
+	}
+	if !token.ValidateWithPrevious(newToken, testutil.TestHMACSecret, current, previous) {
+		t.Error("Expected the token from the last rotation to still validate")
+	}
+
+	// Revoking the previous token ends the overlap window immediately
+	if _, expiresAt, err = service.RotateClusterToken(clusterID, true); err != nil {
+		t.Fatalf("RotateClusterToken failed: %v", err)
+	}
+	if !expiresAt.Equal(now) {
+		t.Errorf("Expected previous token to expire at %v, got %v", now, expiresAt)
+	}
+
+	if _, _, err := service.RotateClusterToken("missing", false); !errors.Is(err, models.ErrClusterNotFound) {
+		t.Errorf("Expected ErrClusterNotFound, got %v", err)
+	}
+}
+
 func TestTopologyService_MultipleLighthouses(t *testing.T) {
 	db := setupTopologyTestDB(t)
 	defer db.Close()
@@ -432,3 +707,541 @@ func TestTopologyService_MultipleRelays(t *testing.T) {
 		t.Errorf("Expected 2 relays, got %d", len(topology.Relays))
 	}
 }
+
+func TestTopologyService_SnapshotRoundTrip(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	logger := zap.NewNop()
+	service := NewTopologyService(db, logger, "secret")
+
+	// Second cluster with matching node names but different IDs
+	_, err := db.Exec(`
+		INSERT INTO clusters (id, tenant_id, name, config_version, cluster_token_hash, created_at)
+		VALUES ('cluster2', 'tenant1', 'Target Cluster', 1, 'hash', 1000000000);
+		INSERT INTO nodes (id, tenant_id, cluster_id, name, token_hash, created_at, is_relay)
+		VALUES
+			('c2-node1', 'tenant1', 'cluster2', 'node-1', 'hash', 1000000000, 0),
+			('c2-node2', 'tenant1', 'cluster2', 'node-2', 'hash', 1000000000, 0),
+			('c2-node3', 'tenant1', 'cluster2', 'node-3', 'hash', 1000000000, 1);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert target cluster: %v", err)
+	}
+
+	service.SetLighthouse("cluster1", "node1", "203.0.113.1", 4242)
+	service.SetRelay("cluster1", "node2")
+	service.UpdateRoutes("node2", []string{"10.0.1.0/24"})
+
+	snapshot, err := service.ExportSnapshot("cluster1")
+	if err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+
+	if len(snapshot.RoutesByName["node-2"]) != 1 {
+		t.Fatalf("Expected routes keyed by name, got %v", snapshot.RoutesByName)
+	}
+
+	if err := service.ImportSnapshot("cluster2", snapshot); err != nil {
+		t.Fatalf("ImportSnapshot failed: %v", err)
+	}
+
+	topology, err := service.GetTopology("cluster2")
+	if err != nil {
+		t.Fatalf("GetTopology failed: %v", err)
+	}
+
+	if len(topology.Lighthouses) != 1 || topology.Lighthouses[0].NodeID != "c2-node1" {
+		t.Errorf("Expected lighthouse c2-node1, got %+v", topology.Lighthouses)
+	}
+
+	// Pre-existing relay on node-3 is replaced by the snapshot
+	if len(topology.Relays) != 1 || topology.Relays[0].NodeID != "c2-node2" {
+		t.Errorf("Expected relay c2-node2, got %+v", topology.Relays)
+	}
+
+	if len(topology.Routes["c2-node2"]) != 1 {
+		t.Errorf("Expected routes on c2-node2, got %v", topology.Routes)
+	}
+
+	// Import bumps the version exactly once
+	var version int64
+	db.QueryRow(`SELECT config_version FROM clusters WHERE id = 'cluster2'`).Scan(&version)
+	if version != 2 {
+		t.Errorf("Expected config version 2, got %d", version)
+	}
+}
+
+func TestTopologyService_ImportSnapshotMissingNodes(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	logger := zap.NewNop()
+	service := NewTopologyService(db, logger, "secret")
+
+	snapshot := &TopologyInfo{
+		Lighthouses: []LighthouseInfo{{Name: "node-1", PublicIP: "203.0.113.1", Port: 4242}},
+		Relays:      []RelayInfo{{Name: "ghost-relay"}},
+		RoutesByName: map[string][]string{
+			"ghost-router": {"10.0.1.0/24"},
+		},
+	}
+
+	err := service.ImportSnapshot("cluster1", snapshot)
+	if !errors.Is(err, models.ErrSnapshotNodesMissing) {
+		t.Fatalf("Expected ErrSnapshotNodesMissing, got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "ghost-relay") || !strings.Contains(err.Error(), "ghost-router") {
+		t.Errorf("Expected missing node names in error, got %q", err.Error())
+	}
+
+	// Nothing should have been applied
+	topology, _ := service.GetTopology("cluster1")
+	if len(topology.Lighthouses) != 0 {
+		t.Errorf("Expected no lighthouses after failed import, got %d", len(topology.Lighthouses))
+	}
+
+	var version int64
+	db.QueryRow(`SELECT config_version FROM clusters WHERE id = 'cluster1'`).Scan(&version)
+	if version != 1 {
+		t.Errorf("Expected config version 1, got %d", version)
+	}
+}
+
+func TestTopologyService_ImportSnapshotRoutePolicy(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	service := NewTopologyService(db, zap.NewNop(), "secret")
+
+	// Overlapping routes are rejected
+	err := service.ImportSnapshot("cluster1", &TopologyInfo{
+		RoutesByName: map[string][]string{
+			"node-1": {"10.1.0.0/16"},
+			"node-2": {"10.1.2.0/24"},
+		},
+	})
+	if !errors.Is(err, models.ErrRouteOverlap) {
+		t.Fatalf("Expected ErrRouteOverlap, got %v", err)
+	}
+
+	// IPv6 routes are rejected in an IPv4-only cluster
+	if err := service.SetRoutePolicy("cluster1", &models.RoutePolicy{IPv4Only: true}); err != nil {
+		t.Fatalf("SetRoutePolicy failed: %v", err)
+	}
+	err = service.ImportSnapshot("cluster1", &TopologyInfo{
+		RoutesByName: map[string][]string{"node-1": {"fd00::/64"}},
+	})
+	if !errors.Is(err, models.ErrIPv6NotAllowed) {
+		t.Fatalf("Expected ErrIPv6NotAllowed, got %v", err)
+	}
+
+	routes, err := service.GetNodeRoutes("node1")
+	if err != nil {
+		t.Fatalf("GetNodeRoutes failed: %v", err)
+	}
+	if len(routes) != 0 {
+		t.Errorf("Expected no routes after rejected imports, got %v", routes)
+	}
+
+	// Accepted routes are stored normalized
+	err = service.ImportSnapshot("cluster1", &TopologyInfo{
+		RoutesByName: map[string][]string{"node-1": {"10.1.2.3/16"}},
+	})
+	if err != nil {
+		t.Fatalf("ImportSnapshot failed: %v", err)
+	}
+	routes, err = service.GetNodeRoutes("node1")
+	if err != nil {
+		t.Fatalf("GetNodeRoutes failed: %v", err)
+	}
+	if len(routes) != 1 || routes[0] != "10.1.0.0/16" {
+		t.Errorf("Expected normalized route 10.1.0.0/16, got %v", routes)
+	}
+}
+
+func TestTopologyService_ImportSnapshotLighthousePort(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	service := NewTopologyService(db, zap.NewNop(), "secret")
+
+	err := service.ImportSnapshot("cluster1", &TopologyInfo{
+		Lighthouses: []LighthouseInfo{{Name: "node-1", PublicIP: "203.0.113.1", Port: 70000}},
+	})
+	if !errors.Is(err, models.ErrInvalidRequest) {
+		t.Fatalf("Expected ErrInvalidRequest for out-of-range port, got %v", err)
+	}
+
+	if err := service.SetLighthouseDNS("cluster1", &models.LighthouseDNSConfig{Enabled: true, Port: 5353}); err != nil {
+		t.Fatalf("SetLighthouseDNS failed: %v", err)
+	}
+	err = service.ImportSnapshot("cluster1", &TopologyInfo{
+		Lighthouses: []LighthouseInfo{{Name: "node-1", PublicIP: "203.0.113.1", Port: 5353}},
+	})
+	if !errors.Is(err, models.ErrDNSPortConflict) {
+		t.Fatalf("Expected ErrDNSPortConflict, got %v", err)
+	}
+
+	topology, err := service.GetTopology("cluster1")
+	if err != nil {
+		t.Fatalf("GetTopology failed: %v", err)
+	}
+	if len(topology.Lighthouses) != 0 {
+		t.Errorf("Expected no lighthouses after rejected imports, got %+v", topology.Lighthouses)
+	}
+}
+
+func TestTopologyService_ImportSnapshotRejectsFullRelayMesh(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	service := NewTopologyService(db, zap.NewNop(), "secret")
+
+	snapshot := &TopologyInfo{}
+	for _, name := range []string{"node-1", "node-2", "node-3"} {
+		snapshot.Relays = append(snapshot.Relays, RelayInfo{Name: name})
+	}
+	if err := service.ImportSnapshot("cluster1", snapshot); !errors.Is(err, models.ErrRelayMeshSaturated) {
+		t.Fatalf("Expected ErrRelayMeshSaturated, got %v", err)
+	}
+
+	topology, err := service.GetTopology("cluster1")
+	if err != nil {
+		t.Fatalf("GetTopology failed: %v", err)
+	}
+	if len(topology.Relays) != 0 {
+		t.Errorf("Expected no relays after rejected import, got %+v", topology.Relays)
+	}
+}
+
+func TestTopologyService_SetRelayRejectsFullMesh(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	logger := zap.NewNop()
+	service := NewTopologyService(db, logger, "secret")
+
+	warnings, err := service.SetRelay("cluster1", "node1")
+	if err != nil {
+		t.Fatalf("SetRelay failed: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("Expected no warnings for 1 of 3 relays, got %v", warnings)
+	}
+
+	// Two of three nodes relaying is more than half the cluster
+	warnings, err = service.SetRelay("cluster1", "node2")
+	if err != nil {
+		t.Fatalf("SetRelay failed: %v", err)
+	}
+	if len(warnings) != 1 || warnings[0].Code != config.IssueTooManyRelays {
+		t.Errorf("Expected a too_many_relays warning, got %v", warnings)
+	}
+
+	// Re-setting an existing relay is not a new relay
+	if _, err := service.SetRelay("cluster1", "node2"); err != nil {
+		t.Fatalf("SetRelay on existing relay failed: %v", err)
+	}
+
+	// Third relay would make every node a relay
+	_, err = service.SetRelay("cluster1", "node3")
+	if err != models.ErrRelayMeshSaturated {
+		t.Errorf("Expected ErrRelayMeshSaturated, got %v", err)
+	}
+
+	topology, err := service.GetTopology("cluster1")
+	if err != nil {
+		t.Fatalf("GetTopology failed: %v", err)
+	}
+
+	stats := topology.RelayStats
+	if stats.RelayCount != 2 || stats.NodeCount != 3 {
+		t.Errorf("Expected 2 relays of 3 nodes, got %d of %d", stats.RelayCount, stats.NodeCount)
+	}
+	if stats.MaxRelayHops != 1 {
+		t.Errorf("Expected default max relay hops 1, got %d", stats.MaxRelayHops)
+	}
+}
+
+func TestTopologyService_SetMaxRelayHops(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	logger := zap.NewNop()
+	service := NewTopologyService(db, logger, "secret")
+
+	if err := service.SetMaxRelayHops("cluster1", 2); err != nil {
+		t.Fatalf("SetMaxRelayHops failed: %v", err)
+	}
+
+	topology, _ := service.GetTopology("cluster1")
+	if topology.RelayStats.MaxRelayHops != 2 {
+		t.Errorf("Expected max relay hops 2, got %d", topology.RelayStats.MaxRelayHops)
+	}
+
+	var version int64
+	db.QueryRow(`SELECT config_version FROM clusters WHERE id = 'cluster1'`).Scan(&version)
+	if version != 2 {
+		t.Errorf("Expected config version 2, got %d", version)
+	}
+
+	if err := service.SetMaxRelayHops("cluster1", 5); !errors.Is(err, models.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for out-of-range hops, got %v", err)
+	}
+
+	if err := service.SetMaxRelayHops("missing", 1); err != models.ErrClusterNotFound {
+		t.Errorf("Expected ErrClusterNotFound, got %v", err)
+	}
+}
+
+func TestTopologyService_LighthouseDNS(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	logger := zap.NewNop()
+	service := NewTopologyService(db, logger, "secret")
+
+	// Defaults apply when host and port are omitted
+	if err := service.SetLighthouseDNS("cluster1", &models.LighthouseDNSConfig{Enabled: true}); err != nil {
+		t.Fatalf("SetLighthouseDNS failed: %v", err)
+	}
+
+	dns, err := service.GetLighthouseDNS("cluster1")
+	if err != nil {
+		t.Fatalf("GetLighthouseDNS failed: %v", err)
+	}
+	if !dns.Enabled || dns.Host != "0.0.0.0" || dns.Port != 53 {
+		t.Errorf("Unexpected DNS settings: %+v", dns)
+	}
+
+	var version int64
+	db.QueryRow(`SELECT config_version FROM clusters WHERE id = 'cluster1'`).Scan(&version)
+	if version != 2 {
+		t.Errorf("Expected config version 2, got %d", version)
+	}
+
+	// Cluster Nebula port collision
+	err = service.SetLighthouseDNS("cluster1", &models.LighthouseDNSConfig{Enabled: true, Port: 4242})
+	if !errors.Is(err, models.ErrDNSPortConflict) {
+		t.Errorf("Expected ErrDNSPortConflict for cluster port, got %v", err)
+	}
+
+	// Lighthouse node port collision
+	if err := service.SetLighthouse("cluster1", "node1", "203.0.113.1", 5353); err != nil {
+		t.Fatalf("SetLighthouse failed: %v", err)
+	}
+	err = service.SetLighthouseDNS("cluster1", &models.LighthouseDNSConfig{Enabled: true, Port: 5353})
+	if !errors.Is(err, models.ErrDNSPortConflict) {
+		t.Errorf("Expected ErrDNSPortConflict for lighthouse port, got %v", err)
+	}
+
+	// Lighthouses cannot take the DNS port while DNS is enabled
+	err = service.SetLighthouse("cluster1", "node2", "203.0.113.2", 53)
+	if !errors.Is(err, models.ErrDNSPortConflict) {
+		t.Errorf("Expected ErrDNSPortConflict from SetLighthouse, got %v", err)
+	}
+
+	err = service.SetLighthouseDNS("cluster1", &models.LighthouseDNSConfig{Enabled: true, Host: "not-an-ip"})
+	if !errors.Is(err, models.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for invalid host, got %v", err)
+	}
+
+	if err := service.SetLighthouseDNS("missing", &models.LighthouseDNSConfig{}); err != models.ErrClusterNotFound {
+		t.Errorf("Expected ErrClusterNotFound, got %v", err)
+	}
+}
+
+func TestTopologyService_StaticHostMap(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	logger := zap.NewNop()
+	service := NewTopologyService(db, logger, "secret")
+
+	hosts := map[string][]string{
+		"10.42.0.50": {"198.51.100.50:4242", "198.51.100.50:4242", "[2001:db8::1]:4242"},
+	}
+
+	// Subnet must be configured first
+	if err := service.SetStaticHostMap("cluster1", hosts); !errors.Is(err, models.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest without subnet, got %v", err)
+	}
+
+	if err := service.SetNebulaSubnet("cluster1", "10.42.0.0/16"); err != nil {
+		t.Fatalf("SetNebulaSubnet failed: %v", err)
+	}
+
+	if err := service.SetStaticHostMap("cluster1", hosts); err != nil {
+		t.Fatalf("SetStaticHostMap failed: %v", err)
+	}
+
+	topology, err := service.GetTopology("cluster1")
+	if err != nil {
+		t.Fatalf("GetTopology failed: %v", err)
+	}
+	endpoints := topology.StaticHostMap["10.42.0.50"]
+	if len(endpoints) != 2 || endpoints[0] != "198.51.100.50:4242" || endpoints[1] != "[2001:db8::1]:4242" {
+		t.Errorf("Unexpected static host endpoints: %v", endpoints)
+	}
+
+	var version int64
+	db.QueryRow(`SELECT config_version FROM clusters WHERE id = 'cluster1'`).Scan(&version)
+	if version != 3 {
+		t.Errorf("Expected config version 3, got %d", version)
+	}
+
+	invalid := []map[string][]string{
+		{"10.99.0.1": {"198.51.100.1:4242"}},   // outside subnet
+		{"not-an-ip": {"198.51.100.1:4242"}},   // bad Nebula IP
+		{"10.42.0.51": {"198.51.100.1"}},       // missing port
+		{"10.42.0.51": {"example.com:4242"}},   // hostname instead of IP
+		{"10.42.0.51": {"198.51.100.1:70000"}}, // port out of range
+		{"10.42.0.51": {}},                     // no endpoints
+	}
+	for _, h := range invalid {
+		if err := service.SetStaticHostMap("cluster1", h); !errors.Is(err, models.ErrInvalidRequest) {
+			t.Errorf("Expected ErrInvalidRequest for %v, got %v", h, err)
+		}
+	}
+
+	// Shrinking the subnet must not orphan existing entries
+	if err := service.SetNebulaSubnet("cluster1", "10.43.0.0/16"); !errors.Is(err, models.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for subnet excluding static hosts, got %v", err)
+	}
+
+	// Empty map clears the entries
+	if err := service.SetStaticHostMap("cluster1", map[string][]string{}); err != nil {
+		t.Fatalf("SetStaticHostMap clear failed: %v", err)
+	}
+	cleared, _ := service.GetStaticHostMap("cluster1")
+	if len(cleared) != 0 {
+		t.Errorf("Expected empty static host map, got %v", cleared)
+	}
+
+	if _, err := service.GetStaticHostMap("missing"); err != models.ErrClusterNotFound {
+		t.Errorf("Expected ErrClusterNotFound, got %v", err)
+	}
+}
+
+func TestTopologyService_SetPreferredRanges(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	logger := zap.NewNop()
+	service := NewTopologyService(db, logger, "secret")
+
+	err := service.SetPreferredRanges("cluster1", []string{"192.168.0.0/16", "10.1.0.0/24", "192.168.0.0/16"})
+	if err != nil {
+		t.Fatalf("SetPreferredRanges failed: %v", err)
+	}
+
+	topology, _ := service.GetTopology("cluster1")
+	if strings.Join(topology.PreferredRanges, ",") != "192.168.0.0/16,10.1.0.0/24" {
+		t.Errorf("Unexpected preferred ranges: %v", topology.PreferredRanges)
+	}
+
+	var version int64
+	db.QueryRow(`SELECT config_version FROM clusters WHERE id = 'cluster1'`).Scan(&version)
+	if version != 2 {
+		t.Errorf("Expected config version 2, got %d", version)
+	}
+
+	for _, cidr := range []string{"192.168.1.5/16", "not-a-cidr", "10.0.0.0"} {
+		if err := service.SetPreferredRanges("cluster1", []string{cidr}); !errors.Is(err, models.ErrInvalidRequest) {
+			t.Errorf("Expected ErrInvalidRequest for %q, got %v", cidr, err)
+		}
+	}
+
+	if err := service.SetPreferredRanges("cluster1", nil); err != nil {
+		t.Fatalf("SetPreferredRanges clear failed: %v", err)
+	}
+	topology, _ = service.GetTopology("cluster1")
+	if len(topology.PreferredRanges) != 0 {
+		t.Errorf("Expected no preferred ranges, got %v", topology.PreferredRanges)
+	}
+
+	if err := service.SetPreferredRanges("missing", nil); err != models.ErrClusterNotFound {
+		t.Errorf("Expected ErrClusterNotFound, got %v", err)
+	}
+}
+
+func TestTopologyService_SetCipher(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	logger := zap.NewNop()
+	service := NewTopologyService(db, logger, "secret")
+
+	if err := service.SetCipher("cluster1", "des", true); !errors.Is(err, models.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for unknown cipher, got %v", err)
+	}
+
+	if err := service.SetCipher("cluster1", "chachapoly", false); !errors.Is(err, models.ErrConfirmationRequired) {
+		t.Errorf("Expected ErrConfirmationRequired without force, got %v", err)
+	}
+
+	if err := service.SetCipher("cluster1", "chachapoly", true); err != nil {
+		t.Fatalf("SetCipher failed: %v", err)
+	}
+
+	// Re-applying the current cipher is a no-op and needs no confirmation
+	if err := service.SetCipher("cluster1", "chachapoly", false); err != nil {
+		t.Errorf("Expected no-op for unchanged cipher, got %v", err)
+	}
+
+	topology, _ := service.GetTopology("cluster1")
+	if topology.Cipher != "chachapoly" {
+		t.Errorf("Expected cipher chachapoly, got %s", topology.Cipher)
+	}
+
+	var version int64
+	db.QueryRow(`SELECT config_version FROM clusters WHERE id = 'cluster1'`).Scan(&version)
+	if version != 2 {
+		t.Errorf("Expected config version 2, got %d", version)
+	}
+
+	if err := service.SetCipher("missing", "aes", true); err != models.ErrClusterNotFound {
+		t.Errorf("Expected ErrClusterNotFound, got %v", err)
+	}
+}
+
+func TestTopologyService_SetHandshakeConfig(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	logger := zap.NewNop()
+	service := NewTopologyService(db, logger, "secret")
+
+	hs := &models.HandshakeConfig{TryInterval: "200ms", Retries: 30}
+	if err := service.SetHandshakeConfig("cluster1", hs); err != nil {
+		t.Fatalf("SetHandshakeConfig failed: %v", err)
+	}
+
+	topology, _ := service.GetTopology("cluster1")
+	if topology.Handshake == nil || *topology.Handshake != *hs {
+		t.Errorf("Unexpected handshake config: %+v", topology.Handshake)
+	}
+
+	invalid := []*models.HandshakeConfig{
+		{TryInterval: "fast"},
+		{TryInterval: "1ms"},
+		{Retries: 101},
+		{TriggerBuffer: -1},
+	}
+	for _, h := range invalid {
+		if err := service.SetHandshakeConfig("cluster1", h); !errors.Is(err, models.ErrInvalidRequest) {
+			t.Errorf("Expected ErrInvalidRequest for %+v, got %v", h, err)
+		}
+	}
+
+	// Zero values reset to Nebula defaults
+	if err := service.SetHandshakeConfig("cluster1", &models.HandshakeConfig{}); err != nil {
+		t.Fatalf("SetHandshakeConfig reset failed: %v", err)
+	}
+	topology, _ = service.GetTopology("cluster1")
+	if topology.Handshake != nil {
+		t.Errorf("Expected nil handshake config after reset, got %+v", topology.Handshake)
+	}
+}
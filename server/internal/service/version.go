@@ -0,0 +1,219 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+	"nebulagc.io/pkg/token"
+)
+
+// versionErrorUnauthorized is reported for batch entries that fail
+// authentication or name a cluster that does not exist.
+const versionErrorUnauthorized = "unauthorized"
+
+// versionErrorSignedRequired is reported for batch entries of clusters that
+// require signed requests. Batch entries are not signed, so their nodes must
+// use GET /api/v1/config/version instead.
+const versionErrorSignedRequired = "signed_requests_required"
+
+// Token types and failure reasons passed to an AuthGuard; they match the
+// labels the auth middleware uses for its failure metrics.
+const (
+	authTokenNode    = "node"
+	authTokenCluster = "cluster"
+
+	authFailureMissing   = "missing"
+	authFailureMalformed = "malformed"
+	authFailureInvalid   = "invalid"
+)
+
+// AuthGuard applies the auth middleware's lockout and failure accounting to
+// tokens a service validates itself. A guard is bound to one request, and so
+// to the source the failures are attributed to.
+type AuthGuard interface {
+	// LockedOut reports whether the source is locked out for the token and
+	// how long until it may retry.
+	LockedOut(providedToken string) (time.Duration, bool)
+
+	// RecordFailure counts a failed validation of a token of tokenType,
+	// attributed to the cluster the caller claimed.
+	RecordFailure(tokenType, reason, providedToken, clusterID string)
+}
+
+// VersionService answers config version checks for many clusters at once.
+//
+// A daemon managing several clusters would otherwise poll the version
+// endpoint once per cluster per interval; a batch collapses those polls into
+// a single request.
+type VersionService struct {
+	db     *sql.DB
+	logger *zap.Logger
+	secret string
+}
+
+// NewVersionService creates a new version service.
+//
+// Parameters:
+//   - db: Database connection
+//   - logger: Zap logger for structured logging
+//   - secret: HMAC secret for token validation
+//
+// Returns:
+//   - Configured VersionService
+func NewVersionService(db *sql.DB, logger *zap.Logger, secret string) *VersionService {
+	return &VersionService{
+		db:     db,
+		logger: logger,
+		secret: secret,
+	}
+}
+
+// GetVersions returns the current config version of each requested cluster.
+//
+// Every entry is authenticated on its own, with a node token belonging to
+// the cluster or with the cluster token. Entries that fail authentication
+// get an "unauthorized" error in their result instead of failing the batch;
+// unknown clusters are reported the same way so existence is not disclosed.
+// Entries for clusters that require signed requests get a
+// "signed_requests_required" error, since batch entries carry no signature.
+// Results are returned in request order.
+//
+// Each failed entry is counted by guard like a failed request, so a batch
+// cannot be used to try many tokens per request. Once the source is locked
+// out the whole batch is rejected, including entries already answered.
+//
+// Parameters:
+//   - entries: Clusters to check, at most models.MaxVersionBatchSize
+//   - guard: Lockout and failure accounting for the request (nil = none)
+//
+// Returns:
+//   - One result per entry
+//   - ErrInvalidRequest if the batch is empty or too large,
+//     *models.AuthLockedOutError if the source is locked out, or error if a query fails
+func (s *VersionService) GetVersions(entries []models.VersionBatchEntry, guard AuthGuard) ([]models.ClusterVersion, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: at least one cluster is required", models.ErrInvalidRequest)
+	}
+	if len(entries) > models.MaxVersionBatchSize {
+		return nil, fmt.Errorf("%w: batch of %d clusters exceeds the limit of %d",
+			models.ErrInvalidRequest, len(entries), models.MaxVersionBatchSize)
+	}
+
+	results := make([]models.ClusterVersion, len(entries))
+	for i, entry := range entries {
+		results[i] = models.ClusterVersion{TenantID: entry.TenantID, ClusterID: entry.ClusterID}
+
+		tokenType, provided := authTokenNode, entry.NodeToken
+		if provided == "" && entry.ClusterToken != "" {
+			tokenType, provided = authTokenCluster, entry.ClusterToken
+		}
+
+		// Reject locked out sources before looking the token up
+		if guard != nil {
+			if retryAfter, locked := guard.LockedOut(provided); locked {
+				return nil, &models.AuthLockedOutError{RetryAfter: retryAfter}
+			}
+		}
+
+		credential, reason, err := s.authenticate(tokenType, provided)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			if guard != nil {
+				guard.RecordFailure(tokenType, reason, provided, entry.ClusterID)
+			}
+			results[i].Error = versionErrorUnauthorized
+			continue
+		}
+		if credential.tenantID != entry.TenantID || credential.clusterID != entry.ClusterID {
+			results[i].Error = versionErrorUnauthorized
+			continue
+		}
+		if credential.requireSigned {
+			results[i].Error = versionErrorSignedRequired
+			continue
+		}
+
+		// authenticate has matched the tenant, so only the cluster is looked up
+		results[i].Version, err = effectiveVersion(s.db, entry.ClusterID, credential.nodeID, time.Now())
+		if err == sql.ErrNoRows {
+			results[i].Error = versionErrorUnauthorized
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to query config version: %w", err)
+		}
+	}
+
+	return results, nil
+}
+
+// versionCredential is what a batch entry's token authenticated as.
+type versionCredential struct {
+	tenantID  string
+	clusterID string
+	nodeID    string // "" for cluster tokens
+
+	// requireSigned is set if the cluster only accepts signed requests
+	requireSigned bool
+}
+
+// authenticate validates a node or cluster token. On failure it returns the
+// reason ("missing", "malformed" or "invalid") instead of an error; errors
+// are reserved for failed queries. A cluster token replaced by rotation is
+// accepted until its overlap window ends.
+func (s *VersionService) authenticate(tokenType, provided string) (versionCredential, string, error) {
+	var credential versionCredential
+	if provided == "" {
+		return credential, authFailureMissing, nil
+	}
+	if err := token.ValidateLength(provided); err != nil {
+		return credential, authFailureMalformed, nil
+	}
+
+	var query string
+	var queryArgs []any
+	now := time.Now().Unix()
+	hash := token.Hash(provided, s.secret)
+	if tokenType == authTokenNode {
+		query = `SELECT n.id, n.tenant_id, n.cluster_id, c.require_signed_requests, n.token_hash, '', 0
+		         FROM nodes n
+		         JOIN clusters c ON c.id = n.cluster_id
+		         WHERE n.token_hash = ? AND n.deleted_at IS NULL
+		         LIMIT 1`
+		queryArgs = []any{hash}
+	} else {
+		query = `SELECT '', tenant_id, id, require_signed_requests, cluster_token_hash,
+		                COALESCE(previous_cluster_token_hash, ''), COALESCE(previous_cluster_token_expires_at, 0)
+		         FROM clusters
+		         WHERE cluster_token_hash = ?
+		            OR (previous_cluster_token_hash = ? AND previous_cluster_token_expires_at > ?)
+		         LIMIT 1`
+		queryArgs = []any{hash, hash, now}
+	}
+
+	var storedHash, previousHash string
+	var previousExpiresAt int64
+	err := s.db.QueryRow(query, queryArgs...).Scan(&credential.nodeID, &credential.tenantID, &credential.clusterID,
+		&credential.requireSigned, &storedHash, &previousHash, &previousExpiresAt)
+	if err == sql.ErrNoRows {
+		return versionCredential{}, authFailureInvalid, nil
+	}
+	if err != nil {
+		return versionCredential{}, "", fmt.Errorf("failed to look up token: %w", err)
+	}
+
+	// Validate token using constant-time comparison
+	if previousExpiresAt <= now {
+		previousHash = ""
+	}
+	if !token.ValidateWithPrevious(provided, s.secret, storedHash, previousHash) {
+		return versionCredential{}, authFailureInvalid, nil
+	}
+
+	return credential, "", nil
+}
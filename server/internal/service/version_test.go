@@ -0,0 +1,220 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"nebulagc.io/models"
+	"nebulagc.io/pkg/token"
+	"nebulagc.io/server/internal/testutil"
+)
+
+func TestVersionService_GetVersions(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	const secret = "secret"
+	nodeToken, _ := token.Generate()
+	clusterToken, _ := token.Generate()
+	otherToken, _ := token.Generate()
+
+	statements := []struct {
+		query string
+		arg   string
+	}{
+		{`UPDATE nodes SET token_hash = ? WHERE id = 'node1'`, nodeToken},
+		{`UPDATE clusters SET cluster_token_hash = ?, config_version = 7 WHERE id = 'cluster1'`, clusterToken},
+		{`INSERT INTO clusters (id, tenant_id, name, config_version, cluster_token_hash, created_at)
+		  VALUES ('cluster2', 'tenant1', 'Other Cluster', 3, ?, 1000000000)`, otherToken},
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt.query, token.Hash(stmt.arg, secret)); err != nil {
+			t.Fatalf("Failed to set up tokens: %v", err)
+		}
+	}
+
+	service := NewVersionService(db, zap.NewNop(), secret)
+
+	results, err := service.GetVersions([]models.VersionBatchEntry{
+		{TenantID: "tenant1", ClusterID: "cluster1", NodeToken: nodeToken},
+		{TenantID: "tenant1", ClusterID: "cluster2", ClusterToken: otherToken},
+		{TenantID: "tenant1", ClusterID: "cluster2", NodeToken: nodeToken},       // node belongs to cluster1
+		{TenantID: "tenant1", ClusterID: "cluster1", ClusterToken: "short"},      // malformed token
+		{TenantID: "tenant1", ClusterID: "cluster1"},                             // no credentials
+		{TenantID: "tenant2", ClusterID: "cluster1", ClusterToken: clusterToken}, // wrong tenant
+	}, nil)
+	if err != nil {
+		t.Fatalf("GetVersions failed: %v", err)
+	}
+
+	want := []models.ClusterVersion{
+		{TenantID: "tenant1", ClusterID: "cluster1", Version: 7},
+		{TenantID: "tenant1", ClusterID: "cluster2", Version: 3},
+		{TenantID: "tenant1", ClusterID: "cluster2", Error: "unauthorized"},
+		{TenantID: "tenant1", ClusterID: "cluster1", Error: "unauthorized"},
+		{TenantID: "tenant1", ClusterID: "cluster1", Error: "unauthorized"},
+		{TenantID: "tenant2", ClusterID: "cluster1", Error: "unauthorized"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("Expected %d results, got %d", len(want), len(results))
+	}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Errorf("Result %d: expected %+v, got %+v", i, want[i], results[i])
+		}
+	}
+}
+
+func TestVersionService_GetVersionsBatchSize(t *testing.T) {
+	db := setupTopologyTestDB(t)
+	defer db.Close()
+
+	service := NewVersionService(db, zap.NewNop(), "secret")
+
+	if _, err := service.GetVersions(nil, nil); !errors.Is(err, models.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for empty batch, got %v", err)
+	}
+
+	entries := make([]models.VersionBatchEntry, models.MaxVersionBatchSize+1)
+	if _, err := service.GetVersions(entries, nil); !errors.Is(err, models.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for oversized batch, got %v", err)
+	}
+
+	results, err := service.GetVersions(entries[:models.MaxVersionBatchSize], nil)
+	if err != nil {
+		t.Fatalf("GetVersions failed at the batch limit: %v", err)
+	}
+	if len(results) != models.MaxVersionBatchSize {
+		t.Errorf("Expected %d results, got %d", models.MaxVersionBatchSize, len(results))
+	}
+}
+
+func TestVersionService_PreviousClusterTokenOverlap(t *testing.T) {
+	db := testutil.OpenDB(t)
+	tenantID := testutil.Tenant(t, db, "Test Tenant")
+	clusterID, oldToken := testutil.Cluster(t, db, tenantID, "Test Cluster")
+
+	topology := NewTopologyService(db, zap.NewNop(), testutil.TestHMACSecret)
+	topology.ConfigureTokenRotation(time.Hour, nil)
+	newToken, _, err := topology.RotateClusterToken(clusterID, false)
+	if err != nil {
+		t.Fatalf("RotateClusterToken failed: %v", err)
+	}
+
+	service := NewVersionService(db, zap.NewNop(), testutil.TestHMACSecret)
+	check := func(clusterToken string) string {
+		t.Helper()
+		results, err := service.GetVersions([]models.VersionBatchEntry{
+			{TenantID: tenantID, ClusterID: clusterID, ClusterToken: clusterToken},
+		}, nil)
+		if err != nil {
+			t.Fatalf("GetVersions failed: %v", err)
+		}
+		return results[0].Error
+	}
+
+	if got := check(newToken); got != "" {
+		t.Errorf("Expected new token to authenticate, got %q", got)
+	}
+	if got := check(oldToken); got != "" {
+		t.Errorf("Expected old token to authenticate during the overlap, got %q", got)
+	}
+
+	// Close the overlap window
+	if _, err := db.Exec(`UPDATE clusters SET previous_cluster_token_expires_at = ? WHERE id = ?`,
+		time.Now().Add(-time.Second).Unix(), clusterID); err != nil {
+		t.Fatalf("Failed to expire previous token: %v", err)
+	}
+	if got := check(oldToken); got != "unauthorized" {
+		t.Errorf("Expected old token to be rejected after the overlap, got %q", got)
+	}
+	if got := check(newToken); got != "" {
+		t.Errorf("Expected new token to authenticate, got %q", got)
+	}
+}
+
+// fakeAuthGuard locks out after limit failures, like an ip-scoped lockout.
+type fakeAuthGuard struct {
+	limit    int
+	failures []string
+}
+
+func (g *fakeAuthGuard) LockedOut(string) (time.Duration, bool) {
+	return time.Minute, len(g.failures) >= g.limit
+}
+
+func (g *fakeAuthGuard) RecordFailure(tokenType, reason, _, clusterID string) {
+	g.failures = append(g.failures, tokenType+"/"+reason+"/"+clusterID)
+}
+
+func TestVersionService_GetVersionsRecordsFailures(t *testing.T) {
+	db := testutil.OpenDB(t)
+	tenantID := testutil.Tenant(t, db, "Test Tenant")
+	clusterID, clusterToken := testutil.Cluster(t, db, tenantID, "Test Cluster")
+	service := NewVersionService(db, zap.NewNop(), testutil.TestHMACSecret)
+
+	wrongToken, _ := token.Generate()
+	guard := &fakeAuthGuard{limit: 3}
+	results, err := service.GetVersions([]models.VersionBatchEntry{
+		{TenantID: tenantID, ClusterID: clusterID, ClusterToken: clusterToken},
+		{TenantID: tenantID, ClusterID: clusterID, NodeToken: wrongToken},
+		{TenantID: tenantID, ClusterID: clusterID, ClusterToken: "short"},
+		{TenantID: "other", ClusterID: clusterID, ClusterToken: clusterToken}, // valid token, wrong tenant
+	}, guard)
+	if err != nil {
+		t.Fatalf("GetVersions failed: %v", err)
+	}
+	if results[0].Error != "" || results[1].Error != "unauthorized" || results[2].Error != "unauthorized" {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+	want := []string{"node/invalid/" + clusterID, "cluster/malformed/" + clusterID}
+	if fmt.Sprint(guard.failures) != fmt.Sprint(want) {
+		t.Errorf("Recorded failures = %v, want %v", guard.failures, want)
+	}
+
+	// Each failed entry counts, and the batch is rejected once the source is locked out
+	entries := make([]models.VersionBatchEntry, 10)
+	for i := range entries {
+		entries[i] = models.VersionBatchEntry{TenantID: tenantID, ClusterID: clusterID, NodeToken: wrongToken}
+	}
+	guard = &fakeAuthGuard{limit: 3}
+	_, err = service.GetVersions(entries, guard)
+	var lockedOut *models.AuthLockedOutError
+	if !errors.As(err, &lockedOut) || lockedOut.RetryAfter != time.Minute {
+		t.Fatalf("Expected AuthLockedOutError, got %v", err)
+	}
+	if len(guard.failures) != 3 {
+		t.Errorf("Expected validation to stop at the lockout after 3 failures, got %d", len(guard.failures))
+	}
+}
+
+func TestVersionService_GetVersionsSignedRequestsRequired(t *testing.T) {
+	db := testutil.OpenDB(t)
+	tenantID := testutil.Tenant(t, db, "Test Tenant")
+	clusterID, clusterToken := testutil.Cluster(t, db, tenantID, "Test Cluster")
+	_, nodeToken := testutil.Node(t, db, tenantID, clusterID, "node", false)
+	if _, err := db.Exec(`UPDATE clusters SET require_signed_requests = 1 WHERE id = ?`, clusterID); err != nil {
+		t.Fatalf("Failed to require signed requests: %v", err)
+	}
+
+	service := NewVersionService(db, zap.NewNop(), testutil.TestHMACSecret)
+	guard := &fakeAuthGuard{limit: 10}
+	results, err := service.GetVersions([]models.VersionBatchEntry{
+		{TenantID: tenantID, ClusterID: clusterID, NodeToken: nodeToken},
+		{TenantID: tenantID, ClusterID: clusterID, ClusterToken: clusterToken},
+	}, guard)
+	if err != nil {
+		t.Fatalf("GetVersions failed: %v", err)
+	}
+	for i, result := range results {
+		if result.Error != "signed_requests_required" || result.Version != 0 {
+			t.Errorf("Result %d: expected signed_requests_required without a version, got %+v", i, result)
+		}
+	}
+	if len(guard.failures) != 0 {
+		t.Errorf("Expected valid tokens not to count as failures, got %v", guard.failures)
+	}
+}
@@ -0,0 +1,65 @@
+// Package testutil provides database fixtures for unit tests.
+//
+// Databases are built from the migrations in server/migrations, the schema
+// production runs against, so tests seeded through these helpers cannot
+// drift from it. The helpers mirror the e2e fixtures in tests/e2e/fixtures.
+package testutil
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// migrationsDir returns the path of server/migrations.
+func migrationsDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "migrations")
+}
+
+// OpenDB creates a database in a temporary directory and applies the Up
+// section of every migration in order. The database is closed when the test
+// finishes.
+//
+// Parameters:
+//   - t: The test
+//
+// Returns:
+//   - Database with the production schema and no data
+func OpenDB(t testing.TB) *sql.DB {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(migrationsDir(), "*.sql"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("failed to find migrations: %v", err)
+	}
+	sort.Strings(paths)
+
+	// Writers wait for each other as in production instead of failing with SQLITE_BUSY
+	dsn := filepath.Join(t.TempDir(), "nebula.db") + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for _, path := range paths {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read migration: %v", err)
+		}
+
+		up, _, _ := strings.Cut(string(contents), "-- +goose Down")
+		if _, err := db.Exec(up); err != nil {
+			t.Fatalf("failed to apply migration %s: %v", filepath.Base(path), err)
+		}
+	}
+
+	return db
+}
@@ -0,0 +1,126 @@
+package testutil
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"nebulagc.io/pkg/token"
+)
+
+// TestHMACSecret is an HMAC secret for hashing fixture tokens.
+const TestHMACSecret = "test-hmac-secret-32-bytes-long!!"
+
+// Tenant creates a tenant and returns its ID.
+func Tenant(t testing.TB, db *sql.DB, name string) string {
+	t.Helper()
+
+	tenantID := uuid.New().String()
+
+	_, err := db.Exec(`INSERT INTO tenants (id, name) VALUES (?, ?)`, tenantID, name)
+	if err != nil {
+		t.Fatalf("failed to create test tenant: %v", err)
+	}
+
+	return tenantID
+}
+
+// Cluster creates a cluster at config version 1 and returns its ID and token.
+func Cluster(t testing.TB, db *sql.DB, tenantID, name string) (string, string) {
+	t.Helper()
+
+	clusterID := uuid.New().String()
+	clusterToken, err := token.Generate()
+	if err != nil {
+		t.Fatalf("failed to generate cluster token: %v", err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO clusters (id, tenant_id, name, cluster_token_hash)
+		VALUES (?, ?, ?, ?)
+	`, clusterID, tenantID, name, token.Hash(clusterToken, TestHMACSecret))
+	if err != nil {
+		t.Fatalf("failed to create test cluster: %v", err)
+	}
+
+	return clusterID, clusterToken
+}
+
+// Node creates a node and returns its ID and token.
+func Node(t testing.TB, db *sql.DB, tenantID, clusterID, name string, isAdmin bool) (string, string) {
+	t.Helper()
+
+	nodeID := uuid.New().String()
+	nodeToken, err := token.Generate()
+	if err != nil {
+		t.Fatalf("failed to generate node token: %v", err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO nodes (id, tenant_id, cluster_id, name, is_admin, token_hash)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, nodeID, tenantID, clusterID, name, isAdmin, token.Hash(nodeToken, TestHMACSecret))
+	if err != nil {
+		t.Fatalf("failed to create test node: %v", err)
+	}
+
+	return nodeID, nodeToken
+}
+
+// AdminNode creates an admin node and returns its ID and token.
+func AdminNode(t testing.TB, db *sql.DB, tenantID, clusterID, name string) (string, string) {
+	t.Helper()
+	return Node(t, db, tenantID, clusterID, name, true)
+}
+
+// LighthouseNode creates a lighthouse node and returns its ID and token.
+func LighthouseNode(t testing.TB, db *sql.DB, tenantID, clusterID, name, publicIP string, port int) (string, string) {
+	t.Helper()
+
+	nodeID, nodeToken := Node(t, db, tenantID, clusterID, name, false)
+
+	_, err := db.Exec(`
+		UPDATE nodes SET is_lighthouse = 1, lighthouse_public_ip = ?, lighthouse_port = ?
+		WHERE id = ?
+	`, publicIP, port, nodeID)
+	if err != nil {
+		t.Fatalf("failed to update lighthouse status: %v", err)
+	}
+
+	return nodeID, nodeToken
+}
+
+// RelayNode creates a relay node and returns its ID and token.
+func RelayNode(t testing.TB, db *sql.DB, tenantID, clusterID, name string) (string, string) {
+	t.Helper()
+
+	nodeID, nodeToken := Node(t, db, tenantID, clusterID, name, false)
+
+	if _, err := db.Exec(`UPDATE nodes SET is_relay = 1 WHERE id = ?`, nodeID); err != nil {
+		t.Fatalf("failed to update relay status: %v", err)
+	}
+
+	return nodeID, nodeToken
+}
+
+// Seed creates a tenant with one cluster and the given number of regular
+// nodes, the common starting point for service tests.
+//
+// Returns:
+//   - Tenant ID
+//   - Cluster ID
+//   - Node IDs, named node-1 to node-n
+func Seed(t testing.TB, db *sql.DB, nodes int) (string, string, []string) {
+	t.Helper()
+
+	tenantID := Tenant(t, db, "Test Tenant")
+	clusterID, _ := Cluster(t, db, tenantID, "Test Cluster")
+
+	nodeIDs := make([]string, nodes)
+	for i := range nodeIDs {
+		nodeIDs[i], _ = Node(t, db, tenantID, clusterID, fmt.Sprintf("node-%d", i+1), false)
+	}
+
+	return tenantID, clusterID, nodeIDs
+}
@@ -0,0 +1,35 @@
+package testutil
+
+import (
+	"testing"
+
+	"nebulagc.io/pkg/token"
+)
+
+func TestFixtures(t *testing.T) {
+	db := OpenDB(t)
+
+	tenantID, clusterID, nodeIDs := Seed(t, db, 2)
+	adminID, adminToken := AdminNode(t, db, tenantID, clusterID, "admin")
+	LighthouseNode(t, db, tenantID, clusterID, "lighthouse", "203.0.113.10", 4242)
+	RelayNode(t, db, tenantID, clusterID, "relay")
+
+	var count, admins, lighthouses, relays int
+	err := db.QueryRow(`
+		SELECT COUNT(*), SUM(is_admin), SUM(is_lighthouse), SUM(is_relay) FROM nodes WHERE cluster_id = ?
+	`, clusterID).Scan(&count, &admins, &lighthouses, &relays)
+	if err != nil {
+		t.Fatalf("Failed to count nodes: %v", err)
+	}
+	if count != len(nodeIDs)+3 || admins != 1 || lighthouses != 1 || relays != 1 {
+		t.Errorf("Unexpected nodes: %d total, %d admin, %d lighthouse, %d relay", count, admins, lighthouses, relays)
+	}
+
+	var hash string
+	if err := db.QueryRow(`SELECT token_hash FROM nodes WHERE id = ?`, adminID).Scan(&hash); err != nil {
+		t.Fatalf("Failed to load admin node: %v", err)
+	}
+	if hash != token.Hash(adminToken, TestHMACSecret) {
+		t.Error("Expected node token hashed with TestHMACSecret")
+	}
+}
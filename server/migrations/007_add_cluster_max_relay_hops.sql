@@ -0,0 +1,8 @@
+-- +goose Up
+-- Add per-cluster relay hop limit.
+-- Bounds how many relays traffic may traverse; 0 disables relaying entirely.
+-- The config generator only lets relay nodes use other relays when the limit is above 1.
+ALTER TABLE clusters ADD COLUMN max_relay_hops INTEGER NOT NULL DEFAULT 1 CHECK(max_relay_hops >= 0 AND max_relay_hops <= 4); -- Maximum relay hops (0-4)
+
+-- +goose Down
+ALTER TABLE clusters DROP COLUMN max_relay_hops;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Add per-node preferred relay list.
+-- When set, the config generator emits only these relays for the node (in order)
+-- instead of every relay in the cluster.
+ALTER TABLE nodes ADD COLUMN preferred_relays TEXT; -- JSON array of relay node IDs (e.g., ["uuid-1","uuid-2"])
+
+-- +goose Down
+ALTER TABLE nodes DROP COLUMN preferred_relays;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Add per-cluster lighthouse DNS settings.
+-- When enabled, lighthouses answer DNS queries for node names on the given host/port.
+-- The DNS port must not collide with the Nebula UDP port used by lighthouses.
+ALTER TABLE clusters ADD COLUMN lighthouse_dns_enabled INTEGER NOT NULL DEFAULT 0 CHECK(lighthouse_dns_enabled IN (0,1)); -- Boolean: lighthouses serve DNS
+ALTER TABLE clusters ADD COLUMN lighthouse_dns_host TEXT NOT NULL DEFAULT '0.0.0.0'; -- DNS listen address
+ALTER TABLE clusters ADD COLUMN lighthouse_dns_port INTEGER NOT NULL DEFAULT 53 CHECK(lighthouse_dns_port >= 1 AND lighthouse_dns_port <= 65535); -- DNS listen port
+
+-- +goose Down
+ALTER TABLE clusters DROP COLUMN lighthouse_dns_port;
+ALTER TABLE clusters DROP COLUMN lighthouse_dns_host;
+ALTER TABLE clusters DROP COLUMN lighthouse_dns_enabled;
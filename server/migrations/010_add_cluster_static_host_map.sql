@@ -0,0 +1,10 @@
+-- +goose Up
+-- Add the cluster overlay subnet and a per-cluster static host map.
+-- The static host map pins fixed public endpoints for non-lighthouse peers and
+-- is emitted into every node's config alongside lighthouse entries.
+ALTER TABLE clusters ADD COLUMN nebula_subnet TEXT; -- Overlay network CIDR (e.g., 10.42.0.0/16)
+ALTER TABLE clusters ADD COLUMN static_host_map TEXT; -- JSON object: nebula IP -> ["ip:port", ...]
+
+-- +goose Down
+ALTER TABLE clusters DROP COLUMN static_host_map;
+ALTER TABLE clusters DROP COLUMN nebula_subnet;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Add per-cluster Nebula preferred_ranges.
+-- Nodes try local addresses in these networks first when connecting to peers.
+ALTER TABLE clusters ADD COLUMN preferred_ranges TEXT; -- JSON array of CIDRs (e.g., ["192.168.0.0/16"])
+
+-- +goose Down
+ALTER TABLE clusters DROP COLUMN preferred_ranges;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Add per-cluster Nebula cipher and handshake tuning.
+-- Changing the cipher breaks tunnels between nodes on different ciphers, so the
+-- API requires explicit confirmation before updating it.
+ALTER TABLE clusters ADD COLUMN cipher TEXT NOT NULL DEFAULT 'aes' CHECK(cipher IN ('aes','chachapoly')); -- Nebula cipher
+ALTER TABLE clusters ADD COLUMN handshake_try_interval TEXT; -- Duration between handshake attempts (NULL = Nebula default)
+ALTER TABLE clusters ADD COLUMN handshake_retries INTEGER; -- Handshake attempts before giving up (NULL = Nebula default)
+ALTER TABLE clusters ADD COLUMN handshake_trigger_buffer INTEGER; -- Pending handshake trigger queue size (NULL = Nebula default)
+
+-- +goose Down
+ALTER TABLE clusters DROP COLUMN handshake_trigger_buffer;
+ALTER TABLE clusters DROP COLUMN handshake_retries;
+ALTER TABLE clusters DROP COLUMN handshake_try_interval;
+ALTER TABLE clusters DROP COLUMN cipher;
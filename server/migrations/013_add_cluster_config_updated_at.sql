@@ -0,0 +1,18 @@
+-- +goose Up
+-- Track when a cluster's config version last changed.
+-- Every config mutation bumps config_version, so a trigger keeps the timestamp
+-- current without each mutation path having to set it.
+ALTER TABLE clusters ADD COLUMN config_updated_at INTEGER; -- Unix time of the last config version bump (NULL = never changed)
+
+-- +goose StatementBegin
+CREATE TRIGGER clusters_config_updated_at
+AFTER UPDATE OF config_version ON clusters
+FOR EACH ROW WHEN NEW.config_version != OLD.config_version
+BEGIN
+    UPDATE clusters SET config_updated_at = CAST(strftime('%s', 'now') AS INTEGER) WHERE id = NEW.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS clusters_config_updated_at;
+ALTER TABLE clusters DROP COLUMN config_updated_at;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Allow bundles to be scheduled for activation in a maintenance window.
+-- A scheduled bundle reserves its version at upload but is not distributed
+-- until effective_at; the activation is evaluated on read.
+ALTER TABLE config_bundles ADD COLUMN effective_at INTEGER; -- Unix time the bundle becomes active (NULL = immediately)
+
+-- Index for finding pending scheduled bundles
+CREATE INDEX idx_config_bundles_effective_at ON config_bundles(cluster_id, effective_at) WHERE effective_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_config_bundles_effective_at;
+ALTER TABLE config_bundles DROP COLUMN effective_at;
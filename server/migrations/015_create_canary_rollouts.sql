@@ -0,0 +1,22 @@
+-- +goose Up
+-- Create tables for canary rollouts.
+-- While a canary is active, only the listed nodes receive its bundle version;
+-- every other node stays on the version before it until the canary is promoted.
+CREATE TABLE canary_rollouts (
+    cluster_id TEXT PRIMARY KEY,             -- Foreign key to clusters.id (one canary per cluster)
+    version INTEGER NOT NULL,                -- Bundle version under canary
+    created_at INTEGER NOT NULL,             -- Unix time the canary was defined
+    FOREIGN KEY (cluster_id) REFERENCES clusters(id) ON DELETE CASCADE
+);
+
+CREATE TABLE canary_nodes (
+    cluster_id TEXT NOT NULL,                -- Foreign key to canary_rollouts.cluster_id
+    node_id TEXT NOT NULL,                   -- Foreign key to nodes.id
+    PRIMARY KEY (cluster_id, node_id),
+    FOREIGN KEY (cluster_id) REFERENCES canary_rollouts(cluster_id) ON DELETE CASCADE,
+    FOREIGN KEY (node_id) REFERENCES nodes(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS canary_nodes;
+DROP TABLE IF EXISTS canary_rollouts;
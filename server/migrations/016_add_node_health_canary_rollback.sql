@@ -0,0 +1,26 @@
+-- +goose Up
+-- Add node health reporting and automatic canary rollback.
+-- Daemons report their running config version and health; the canary
+-- reconciler compares canary node health before and after a rollout and
+-- reverts the canary when canary nodes fail to report healthy.
+ALTER TABLE nodes ADD COLUMN last_heartbeat_at INTEGER; -- Unix time of the last health report (NULL = never reported)
+ALTER TABLE nodes ADD COLUMN reported_version INTEGER; -- Config version the node reported running
+ALTER TABLE nodes ADD COLUMN health_status TEXT CHECK(health_status IN ('ok','error')); -- Last reported health
+ALTER TABLE nodes ADD COLUMN health_message TEXT; -- Error detail for health_status = 'error'
+
+ALTER TABLE canary_rollouts ADD COLUMN stable_version INTEGER NOT NULL DEFAULT 0; -- Version served to nodes outside the canary
+ALTER TABLE canary_rollouts ADD COLUMN rolled_back_at INTEGER; -- Unix time of automatic rollback (NULL = active)
+ALTER TABLE canary_rollouts ADD COLUMN rollback_reason TEXT; -- Why the canary was rolled back
+UPDATE canary_rollouts SET stable_version = version - 1;
+
+ALTER TABLE canary_nodes ADD COLUMN healthy_before INTEGER NOT NULL DEFAULT 0; -- Node was healthy when the canary was defined
+
+-- +goose Down
+ALTER TABLE canary_nodes DROP COLUMN healthy_before;
+ALTER TABLE canary_rollouts DROP COLUMN rollback_reason;
+ALTER TABLE canary_rollouts DROP COLUMN rolled_back_at;
+ALTER TABLE canary_rollouts DROP COLUMN stable_version;
+ALTER TABLE nodes DROP COLUMN health_message;
+ALTER TABLE nodes DROP COLUMN health_status;
+ALTER TABLE nodes DROP COLUMN reported_version;
+ALTER TABLE nodes DROP COLUMN last_heartbeat_at;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Record bundle metadata at upload so bundles can be listed and described
+-- without loading their data.
+ALTER TABLE config_bundles ADD COLUMN size INTEGER NOT NULL DEFAULT 0; -- Size of data in bytes
+ALTER TABLE config_bundles ADD COLUMN checksum TEXT; -- SHA-256 of data (hex); NULL for bundles uploaded before this migration
+ALTER TABLE config_bundles ADD COLUMN description TEXT; -- Optional uploader-supplied description
+UPDATE config_bundles SET size = length(data);
+
+-- +goose Down
+ALTER TABLE config_bundles DROP COLUMN description;
+ALTER TABLE config_bundles DROP COLUMN checksum;
+ALTER TABLE config_bundles DROP COLUMN size;
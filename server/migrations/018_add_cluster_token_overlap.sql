@@ -0,0 +1,14 @@
+-- +goose Up
+-- Keep the previous cluster token valid for a while after rotation.
+-- Rotating the cluster token moves the old hash here, and authentication
+-- accepts it until the expiry so operators can roll out the new token.
+ALTER TABLE clusters ADD COLUMN previous_cluster_token_hash TEXT; -- HMAC-SHA256 of the token replaced by the last rotation (NULL = none)
+ALTER TABLE clusters ADD COLUMN previous_cluster_token_expires_at INTEGER; -- Unix time the previous token stops validating
+
+-- Index for previous-token lookups during the overlap window
+CREATE INDEX idx_clusters_previous_token ON clusters(previous_cluster_token_hash);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_clusters_previous_token;
+ALTER TABLE clusters DROP COLUMN previous_cluster_token_expires_at;
+ALTER TABLE clusters DROP COLUMN previous_cluster_token_hash;
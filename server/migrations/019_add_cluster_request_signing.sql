@@ -0,0 +1,9 @@
+-- +goose Up
+-- Let high-security clusters require signed node requests.
+-- When set, node-token requests must carry an HMAC of the request keyed by
+-- the node token, with a fresh timestamp and nonce, so captured requests
+-- cannot be replayed.
+ALTER TABLE clusters ADD COLUMN require_signed_requests INTEGER NOT NULL DEFAULT 0; -- 1 = node requests must be signed
+
+-- +goose Down
+ALTER TABLE clusters DROP COLUMN require_signed_requests;
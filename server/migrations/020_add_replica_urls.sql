@@ -0,0 +1,9 @@
+-- +goose Up
+-- Record every public URL a replica is reachable at.
+-- Split-horizon deployments expose the same instance under different names
+-- to internal and external clients; address keeps the primary URL and urls
+-- lists all of them so clients can try each during failover.
+ALTER TABLE replicas ADD COLUMN urls TEXT; -- JSON array of public URLs, primary first (NULL = address only)
+
+-- +goose Down
+ALTER TABLE replicas DROP COLUMN urls;
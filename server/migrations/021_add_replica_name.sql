@@ -0,0 +1,8 @@
+-- +goose Up
+-- Give replicas a human-friendly name for HA dashboards.
+-- Instance IDs are UUIDs; the name (e.g. "cp-eu-west-1", defaulting to the
+-- host name) lets operators tell instances apart without a lookup table.
+ALTER TABLE replicas ADD COLUMN name TEXT NOT NULL DEFAULT ''; -- Human-friendly instance name ('' = unnamed)
+
+-- +goose Down
+ALTER TABLE replicas DROP COLUMN name;
@@ -0,0 +1,59 @@
+-- +goose Up
+-- Create content-addressed bundle storage.
+-- Identical bundles (across versions and clusters) share one blob keyed by its
+-- SHA-256. Each bundle version using a blob holds a reference, and triggers keep
+-- the blob's ref_count in step with its references, including references removed
+-- by cascading deletes, so unreferenced blobs can be garbage-collected.
+CREATE TABLE bundle_blobs (
+    checksum TEXT PRIMARY KEY,               -- SHA-256 of data (hex)
+    data BLOB NOT NULL,                      -- Tar.gz archive data (empty when stored on the filesystem)
+    size INTEGER NOT NULL,                   -- Size of data in bytes
+    ref_count INTEGER NOT NULL DEFAULT 0,    -- Number of bundle versions referencing this blob
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Index for garbage collection (partial index for performance)
+CREATE INDEX idx_bundle_blobs_unreferenced ON bundle_blobs(checksum) WHERE ref_count <= 0;
+
+CREATE TABLE bundle_blob_refs (
+    tenant_id TEXT NOT NULL,                 -- Tenant of the referencing bundle
+    cluster_id TEXT NOT NULL,                -- Cluster of the referencing bundle
+    version INTEGER NOT NULL,                -- Version of the referencing bundle
+    checksum TEXT NOT NULL,                  -- Foreign key to bundle_blobs.checksum
+    PRIMARY KEY (tenant_id, cluster_id, version),
+    FOREIGN KEY (tenant_id, cluster_id, version) REFERENCES config_bundles(tenant_id, cluster_id, version) ON DELETE CASCADE,
+    FOREIGN KEY (checksum) REFERENCES bundle_blobs(checksum)
+);
+
+-- Index for loading a bundle version's blob
+CREATE INDEX idx_bundle_blob_refs_version ON bundle_blob_refs(cluster_id, version);
+
+-- Index for reference lookups by blob
+CREATE INDEX idx_bundle_blob_refs_checksum ON bundle_blob_refs(checksum);
+
+-- +goose StatementBegin
+CREATE TRIGGER bundle_blob_refs_insert
+AFTER INSERT ON bundle_blob_refs
+FOR EACH ROW
+BEGIN
+    UPDATE bundle_blobs SET ref_count = ref_count + 1 WHERE checksum = NEW.checksum;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER bundle_blob_refs_delete
+AFTER DELETE ON bundle_blob_refs
+FOR EACH ROW
+BEGIN
+    UPDATE bundle_blobs SET ref_count = ref_count - 1 WHERE checksum = OLD.checksum;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS bundle_blob_refs_delete;
+DROP TRIGGER IF EXISTS bundle_blob_refs_insert;
+DROP INDEX IF EXISTS idx_bundle_blob_refs_checksum;
+DROP INDEX IF EXISTS idx_bundle_blob_refs_version;
+DROP TABLE IF EXISTS bundle_blob_refs;
+DROP INDEX IF EXISTS idx_bundle_blobs_unreferenced;
+DROP TABLE IF EXISTS bundle_blobs;
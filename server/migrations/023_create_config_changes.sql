@@ -0,0 +1,22 @@
+-- +goose Up
+-- Record a timeline of cluster config changes.
+-- Each config version bump writes one row in the same transaction, so every
+-- version of a cluster's config has an entry saying what changed and who did it.
+CREATE TABLE config_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id TEXT NOT NULL,                 -- Foreign key to tenants.id
+    cluster_id TEXT NOT NULL,                -- Foreign key to clusters.id
+    version INTEGER NOT NULL,                -- Config version the change produced
+    change_type TEXT NOT NULL,               -- What triggered the bump (e.g. node_created, bundle_uploaded)
+    actor TEXT,                              -- Node ID that made the change (NULL if unknown)
+    detail TEXT,                             -- Subject of the change, e.g. the affected node ID (NULL if none)
+    created_at INTEGER NOT NULL,             -- Unix time of the change
+    FOREIGN KEY (cluster_id) REFERENCES clusters(id) ON DELETE CASCADE
+);
+
+-- Index for listing a cluster's history newest first
+CREATE UNIQUE INDEX idx_config_changes_cluster_version ON config_changes(cluster_id, version);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_config_changes_cluster_version;
+DROP TABLE IF EXISTS config_changes;
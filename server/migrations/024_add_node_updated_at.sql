@@ -0,0 +1,8 @@
+-- +goose Up
+-- Track when a node was last modified.
+-- Node summaries used to report created_at as updated_at; mutating node and
+-- topology operations now set this column alongside their change.
+ALTER TABLE nodes ADD COLUMN updated_at INTEGER; -- Unix time of the last modification (NULL = unchanged since creation)
+
+-- +goose Down
+ALTER TABLE nodes DROP COLUMN updated_at;
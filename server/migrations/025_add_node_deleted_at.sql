@@ -0,0 +1,13 @@
+-- +goose Up
+-- Soft-delete nodes so accidental deletions can be undone.
+-- Deleting a node sets deleted_at; every read treats such nodes as gone until
+-- they are restored or purged. Names stay reserved until the row is purged.
+ALTER TABLE nodes ADD COLUMN deleted_at INTEGER; -- Unix time the node was deleted (NULL = live)
+
+-- Index for purging deleted nodes (partial index for performance)
+CREATE INDEX idx_nodes_deleted ON nodes(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_nodes_deleted;
+DELETE FROM nodes WHERE deleted_at IS NOT NULL;
+ALTER TABLE nodes DROP COLUMN deleted_at;
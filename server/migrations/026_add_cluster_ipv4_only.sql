@@ -0,0 +1,8 @@
+-- +goose Up
+-- Let clusters whose nodes cannot route IPv6 networks reject IPv6 routes.
+-- When set, route updates containing an IPv6 CIDR fail instead of being
+-- stored and later dropped from generated configs.
+ALTER TABLE clusters ADD COLUMN ipv4_only INTEGER NOT NULL DEFAULT 0; -- 1 = node routes must be IPv4
+
+-- +goose Down
+ALTER TABLE clusters DROP COLUMN ipv4_only;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Reject routes that overlap another node's routes unless a cluster opts out.
+-- Nebula cannot pick a single gateway for overlapping unsafe routes, so route
+-- updates are checked against the rest of the cluster by default.
+ALTER TABLE clusters ADD COLUMN allow_overlapping_routes INTEGER NOT NULL DEFAULT 0; -- 1 = skip the overlap check
+
+-- +goose Down
+ALTER TABLE clusters DROP COLUMN allow_overlapping_routes;
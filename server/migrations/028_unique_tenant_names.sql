@@ -0,0 +1,9 @@
+-- +goose Up
+-- Enforce unique tenant names now that tenants are provisioned through the API.
+-- Replaces the plain name index; rename duplicate tenants before upgrading.
+DROP INDEX IF EXISTS idx_tenants_name;
+CREATE UNIQUE INDEX idx_tenants_name ON tenants(name);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tenants_name;
+CREATE INDEX idx_tenants_name ON tenants(name);
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Optional automatic pruning of old config bundles.
+-- The server's scheduled prune keeps the latest bundle_retention versions of
+-- each cluster that sets it and deletes the rest.
+ALTER TABLE clusters ADD COLUMN bundle_retention INTEGER NOT NULL DEFAULT 0; -- Versions to keep (0 = keep all)
+
+-- +goose Down
+ALTER TABLE clusters DROP COLUMN bundle_retention;
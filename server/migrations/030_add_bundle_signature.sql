@@ -0,0 +1,8 @@
+-- +goose Up
+-- Optional detached Ed25519 signature of each config bundle, supplied by the
+-- uploading admin and served with downloads so daemons can verify bundles
+-- against the cluster's signing key.
+ALTER TABLE config_bundles ADD COLUMN signature BLOB; -- Ed25519 signature of the bundle data (NULL if unsigned)
+
+-- +goose Down
+ALTER TABLE config_bundles DROP COLUMN signature;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Descriptive metadata reported by each replica when it registers, such as
+-- its software version and region, to help debug HA clusters.
+ALTER TABLE replicas ADD COLUMN metadata TEXT; -- JSON object of string values (NULL = none)
+
+-- +goose Down
+ALTER TABLE replicas DROP COLUMN metadata;
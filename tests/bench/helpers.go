@@ -130,15 +130,20 @@ func runMigrations(db *sql.DB) error {
 		)`,
 		// Config bundles table
 		`CREATE TABLE IF NOT EXISTS config_bundles (
-			id TEXT PRIMARY KEY,
+			version INTEGER NOT NULL,
 			tenant_id TEXT NOT NULL,
 			cluster_id TEXT NOT NULL,
-			version INTEGER NOT NULL,
-			bundle_data BLOB NOT NULL,
+			data BLOB NOT NULL,
+			created_by TEXT,
 			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			effective_at INTEGER,
+			size INTEGER NOT NULL DEFAULT 0,
+			checksum TEXT,
+			description TEXT,
+			signature BLOB,
+			PRIMARY KEY (tenant_id, cluster_id, version),
 			FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
-			FOREIGN KEY (cluster_id) REFERENCES clusters(id) ON DELETE CASCADE,
-			UNIQUE(cluster_id, version)
+			FOREIGN KEY (cluster_id) REFERENCES clusters(id) ON DELETE CASCADE
 		)`,
 		// Replicas table
 		`CREATE TABLE IF NOT EXISTS replicas (
@@ -211,14 +216,13 @@ func SeedNodes(tb testing.TB, db *sql.DB, clusterID string, count int) []string
 }
 
 // SeedBundle creates a config bundle in the database.
-func SeedBundle(tb testing.TB, db *sql.DB, tenantID, clusterID string, version int, sizeBytes int) string {
+func SeedBundle(tb testing.TB, db *sql.DB, tenantID, clusterID string, version int, sizeBytes int) {
 	tb.Helper()
 
-	bundleID := fmt.Sprintf("bundle-%d", version)
 	bundleData := generateRandomData(sizeBytes)
 
-	_, err := db.Exec(`INSERT INTO config_bundles (id, tenant_id, cluster_id, version, bundle_data) VALUES (?, ?, ?, ?, ?)`,
-		bundleID, tenantID, clusterID, version, bundleData)
+	_, err := db.Exec(`INSERT INTO config_bundles (tenant_id, cluster_id, version, data, size) VALUES (?, ?, ?, ?, ?)`,
+		tenantID, clusterID, version, bundleData, len(bundleData))
 	if err != nil {
 		tb.Fatalf("Failed to seed bundle: %v", err)
 	}
@@ -229,8 +233,6 @@ func SeedBundle(tb testing.TB, db *sql.DB, tenantID, clusterID string, version i
 	if err != nil {
 		tb.Fatalf("Failed to update cluster state: %v", err)
 	}
-
-	return bundleID
 }
 
 // generateRandomData creates random bytes for bundle data.
@@ -159,11 +159,9 @@ func RelayNode(t *testing.T, db *sql.DB, tenantID, clusterID, name, hmacSecret s
 }
 
 // ConfigBundle inserts a config bundle into the database.
-func ConfigBundle(t *testing.T, db *sql.DB, clusterID string, version int64, data []byte) string {
+func ConfigBundle(t *testing.T, db *sql.DB, clusterID string, version int64, data []byte) {
 	t.Helper()
 
-	bundleID := uuid.New().String()
-
 	// Get tenant_id from cluster
 	var tenantID string
 	err := db.QueryRow("SELECT tenant_id FROM clusters WHERE id = ?", clusterID).Scan(&tenantID)
@@ -172,15 +170,13 @@ func ConfigBundle(t *testing.T, db *sql.DB, clusterID string, version int64, dat
 	}
 
 	_, err = db.Exec(`
-		INSERT INTO config_bundles (id, tenant_id, cluster_id, version, bundle_data, created_at)
+		INSERT INTO config_bundles (tenant_id, cluster_id, version, data, size, created_at)
 		VALUES (?, ?, ?, ?, ?, ?)
-	`, bundleID, tenantID, clusterID, version, data, time.Now())
+	`, tenantID, clusterID, version, data, len(data), time.Now())
 
 	if err != nil {
 		t.Fatalf("failed to create config bundle: %v", err)
 	}
-
-	return bundleID
 }
 
 // Replica creates a replica entry in the database.
@@ -145,13 +145,18 @@ func runMigrations(db *sql.DB) error {
 			name: "006_create_config_bundles",
 			sql: `
 				CREATE TABLE IF NOT EXISTS config_bundles (
-					id TEXT PRIMARY KEY,
+					version INTEGER NOT NULL,
 					tenant_id TEXT NOT NULL,
 					cluster_id TEXT NOT NULL,
-					version INTEGER NOT NULL,
-					bundle_data BLOB NOT NULL,
+					data BLOB NOT NULL,
+					created_by TEXT,
 					created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-					UNIQUE (tenant_id, cluster_id, version),
+					effective_at INTEGER,
+					size INTEGER NOT NULL DEFAULT 0,
+					checksum TEXT,
+					description TEXT,
+					signature BLOB,
+					PRIMARY KEY (tenant_id, cluster_id, version),
 					FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
 					FOREIGN KEY (cluster_id) REFERENCES clusters(id) ON DELETE CASCADE
 				);
@@ -147,7 +147,7 @@ func TestBundleAPIOperations(t *testing.T) {
 
 		// Verify bundle stored
 		var storedData []byte
-		err := db.DB.QueryRow("SELECT bundle_data FROM config_bundles WHERE cluster_id = ? AND version = ?", clusterID, 1).Scan(&storedData)
+		err := db.DB.QueryRow("SELECT data FROM config_bundles WHERE cluster_id = ? AND version = ?", clusterID, 1).Scan(&storedData)
 		require.NoError(t, err)
 		assert.Equal(t, bundleData, storedData)
 	})
@@ -159,7 +159,7 @@ func TestBundleAPIOperations(t *testing.T) {
 
 		// Query bundle
 		var retrieved []byte
-		err := db.DB.QueryRow("SELECT bundle_data FROM config_bundles WHERE cluster_id = ? AND version = ?", clusterID, 2).Scan(&retrieved)
+		err := db.DB.QueryRow("SELECT data FROM config_bundles WHERE cluster_id = ? AND version = ?", clusterID, 2).Scan(&retrieved)
 		require.NoError(t, err)
 
 		assert.Equal(t, len(bundleData), len(retrieved))